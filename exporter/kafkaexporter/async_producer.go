@@ -0,0 +1,117 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"context"
+	"sync"
+
+	"github.com/IBM/sarama"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+)
+
+// asyncProducer adapts a sarama.AsyncProducer to the sarama.SyncProducer used by the exporter. SendMessages
+// returns once the messages are queued, without waiting for the brokers, and the messages that fail to be
+// delivered are logged and counted in the background. They are not retried by the exporter, only by the
+// producer itself. SendMessage waits for the delivery of its message, so that the startup probe gets its offset.
+type asyncProducer struct {
+	producer  sarama.AsyncProducer
+	logger    *zap.Logger
+	statsTags []tag.Mutator
+	// drained is done once the Successes and Errors channels of producer are closed.
+	drained sync.WaitGroup
+}
+
+// asyncResult is the Metadata of the messages sent by SendMessage, to which their delivery is reported.
+type asyncResult chan *sarama.ProducerError
+
+func newAsyncProducer(producer sarama.AsyncProducer, logger *zap.Logger, id component.ID) *asyncProducer {
+	p := &asyncProducer{
+		producer:  producer,
+		logger:    logger,
+		statsTags: []tag.Mutator{tag.Upsert(tagInstanceName, id.String())},
+	}
+	p.drained.Add(2)
+	go p.drainSuccesses()
+	go p.drainErrors()
+	return p
+}
+
+func (p *asyncProducer) drainSuccesses() {
+	defer p.drained.Done()
+	for message := range p.producer.Successes() {
+		if result, ok := message.Metadata.(asyncResult); ok {
+			result <- nil
+		}
+	}
+}
+
+func (p *asyncProducer) drainErrors() {
+	defer p.drained.Done()
+	for err := range p.producer.Errors() {
+		if result, ok := err.Msg.Metadata.(asyncResult); ok {
+			result <- err
+			continue
+		}
+		_ = stats.RecordWithTags(context.Background(), p.statsTags, statAsyncProduceFailed.M(1))
+		p.logger.Error("Failed to deliver a Kafka message",
+			zap.String("topic", err.Msg.Topic),
+			zap.Error(err.Err))
+	}
+}
+
+func (p *asyncProducer) SendMessage(message *sarama.ProducerMessage) (int32, int64, error) {
+	result := make(asyncResult, 1)
+	message.Metadata = result
+	p.producer.Input() <- message
+	if err := <-result; err != nil {
+		return -1, -1, err.Err
+	}
+	return message.Partition, message.Offset, nil
+}
+
+func (p *asyncProducer) SendMessages(messages []*sarama.ProducerMessage) error {
+	for _, message := range messages {
+		p.producer.Input() <- message
+	}
+	return nil
+}
+
+// Close waits for the queued messages to be delivered or to fail before returning.
+func (p *asyncProducer) Close() error {
+	p.producer.AsyncClose()
+	p.drained.Wait()
+	return nil
+}
+
+func (p *asyncProducer) TxnStatus() sarama.ProducerTxnStatusFlag {
+	return p.producer.TxnStatus()
+}
+
+func (p *asyncProducer) IsTransactional() bool {
+	return p.producer.IsTransactional()
+}
+
+func (p *asyncProducer) BeginTxn() error {
+	return p.producer.BeginTxn()
+}
+
+func (p *asyncProducer) CommitTxn() error {
+	return p.producer.CommitTxn()
+}
+
+func (p *asyncProducer) AbortTxn() error {
+	return p.producer.AbortTxn()
+}
+
+func (p *asyncProducer) AddOffsetsToTxn(offsets map[string][]*sarama.PartitionOffsetMetadata, groupID string) error {
+	return p.producer.AddOffsetsToTxn(offsets, groupID)
+}
+
+func (p *asyncProducer) AddMessageToTxn(msg *sarama.ConsumerMessage, groupID string, metadata *string) error {
+	return p.producer.AddMessageToTxn(msg, groupID, metadata)
+}
@@ -0,0 +1,100 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func asyncProducerConfig() *sarama.Config {
+	c := sarama.NewConfig()
+	c.Producer.Return.Successes = true
+	c.Producer.Return.Errors = true
+	return c
+}
+
+func TestAsyncProducer_closeDrains(t *testing.T) {
+	mock := mocks.NewAsyncProducer(t, asyncProducerConfig())
+	var delivered atomic.Int64
+	for i := 0; i < 100; i++ {
+		mock.ExpectInputWithMessageCheckerFunctionAndSucceed(func(*sarama.ProducerMessage) error {
+			delivered.Add(1)
+			return nil
+		})
+	}
+	p := kafkaLogsProducer{
+		producer:  newAsyncProducer(mock, zap.NewNop(), component.NewID("kafka")),
+		marshaler: newPdataLogsMarshaler(&plog.ProtoMarshaler{}, defaultEncoding),
+		config:    &Config{Topic: "topic", Producer: Producer{MaxMessageBytes: 1000 * 1000, Async: true}},
+		logger:    zap.NewNop(),
+	}
+
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("record")
+	for i := 0; i < 100; i++ {
+		require.NoError(t, p.logsDataPusher(context.Background(), ld))
+	}
+	require.NoError(t, p.Close(context.Background()))
+	assert.Equal(t, int64(100), delivered.Load())
+}
+
+func TestAsyncProducer_errors(t *testing.T) {
+	views := exporterMetricViews()
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	zcore, logObserver := observer.New(zapcore.ErrorLevel)
+	mock := mocks.NewAsyncProducer(t, asyncProducerConfig())
+	mock.ExpectInputAndSucceed()
+	mock.ExpectInputAndFail(sarama.ErrOutOfBrokers)
+	mock.ExpectInputAndFail(sarama.ErrOutOfBrokers)
+	producer := newAsyncProducer(mock, zap.New(zcore), component.NewID("kafka"))
+
+	// The failures are not returned by SendMessages.
+	require.NoError(t, producer.SendMessages([]*sarama.ProducerMessage{
+		{Topic: "topic", Value: sarama.StringEncoder("a")},
+		{Topic: "topic", Value: sarama.StringEncoder("b")},
+	}))
+	// SendMessage waits for the delivery of its message.
+	_, _, err := producer.SendMessage(&sarama.ProducerMessage{Topic: "probe", Value: sarama.StringEncoder("c")})
+	assert.True(t, errors.Is(err, sarama.ErrOutOfBrokers))
+	require.NoError(t, producer.Close())
+
+	logs := logObserver.FilterMessage("Failed to deliver a Kafka message").All()
+	require.Len(t, logs, 1)
+	assert.Equal(t, "topic", logs[0].ContextMap()["topic"])
+	rows, err := view.RetrieveData(statAsyncProduceFailed.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestAsyncProducer_sendMessage(t *testing.T) {
+	mock := mocks.NewAsyncProducer(t, asyncProducerConfig())
+	mock.ExpectInputAndSucceed()
+	mock.ExpectInputAndSucceed()
+	producer := newAsyncProducer(mock, zap.NewNop(), component.NewID("kafka"))
+
+	_, offset, err := producer.SendMessage(&sarama.ProducerMessage{Topic: "topic", Value: sarama.StringEncoder("a")})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), offset)
+	_, offset, err = producer.SendMessage(&sarama.ProducerMessage{Topic: "topic", Value: sarama.StringEncoder("b")})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), offset)
+	require.NoError(t, producer.Close())
+}
@@ -0,0 +1,99 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"context"
+	"encoding/binary"
+	"unicode/utf8"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.uber.org/zap"
+)
+
+// truncatedValueMarker ends the header values truncated by MaxHeaderValueBytes.
+const truncatedValueMarker = "..."
+
+// newResourceHeaders returns the headers_from_attributes and baggage headers of the messages of a resource, with
+// their values truncated to max_header_value_bytes, or nil if none is configured. The baggage of ctx is added to
+// the headers of every resource.
+func newResourceHeaders(ctx context.Context, config *Config, logger *zap.Logger) func(pcommon.Resource) []sarama.RecordHeader {
+	if !config.Baggage.FromContext && config.Baggage.Attribute == "" && len(config.HeadersFromAttributes) == 0 {
+		return nil
+	}
+	return func(resource pcommon.Resource) []sarama.RecordHeader {
+		headers := truncateHeaderValues(attributeHeaders(config.HeadersFromAttributes, resource), config.MaxHeaderValueBytes)
+		return append(headers, truncateHeaderValues(baggageHeaders(ctx, config.Baggage, resource, logger), config.MaxHeaderValueBytes)...)
+	}
+}
+
+// resourceHeadersKey returns the key grouping the resources by their headers, so that the data of resources
+// with different headers never shares a message.
+func resourceHeadersKey(headers func(pcommon.Resource) []sarama.RecordHeader) func(pcommon.Resource) sarama.Encoder {
+	return func(resource pcommon.Resource) sarama.Encoder {
+		return headersKey(headers(resource))
+	}
+}
+
+// headersKey encodes headers as the length-prefixed sequence of their keys and values.
+type headersKey []sarama.RecordHeader
+
+func (h headersKey) Encode() ([]byte, error) {
+	var b []byte
+	for _, header := range h {
+		b = binary.AppendUvarint(b, uint64(len(header.Key)))
+		b = append(b, header.Key...)
+		b = binary.AppendUvarint(b, uint64(len(header.Value)))
+		b = append(b, header.Value...)
+	}
+	return b, nil
+}
+
+func (h headersKey) Length() int {
+	b, _ := h.Encode()
+	return len(b)
+}
+
+// attributeHeaders returns a header for every attribute of attributes the resource has, named after the
+// attribute and holding the string representation of its value.
+func attributeHeaders(attributes []string, resource pcommon.Resource) []sarama.RecordHeader {
+	var headers []sarama.RecordHeader
+	for _, attribute := range attributes {
+		value, ok := resource.Attributes().Get(attribute)
+		if !ok {
+			continue
+		}
+		headers = append(headers, sarama.RecordHeader{
+			Key:   []byte(attribute),
+			Value: []byte(value.AsString()),
+		})
+	}
+	return headers
+}
+
+// truncateHeaderValues truncates the values of headers longer than maxBytes to maxBytes, their end being
+// replaced by "...". The values are not cut within a UTF-8 character. A maxBytes of 0 disables the truncation.
+func truncateHeaderValues(headers []sarama.RecordHeader, maxBytes int) []sarama.RecordHeader {
+	if maxBytes <= 0 {
+		return headers
+	}
+	for i, header := range headers {
+		if len(header.Value) <= maxBytes {
+			continue
+		}
+		marker := truncatedValueMarker
+		if maxBytes <= len(marker) {
+			marker = ""
+		}
+		end := maxBytes - len(marker)
+		for end > 0 && !utf8.RuneStart(header.Value[end]) {
+			end--
+		}
+		value := make([]byte, 0, end+len(marker))
+		value = append(value, header.Value[:end]...)
+		headers[i].Value = append(value, marker...)
+	}
+	return headers
+}
@@ -0,0 +1,253 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+func TestAttributeHeaders(t *testing.T) {
+	attributes := []string{"service.name", "deployment.environment", "replicas"}
+	with := pcommon.NewResource()
+	with.Attributes().PutStr("service.name", "checkout")
+	with.Attributes().PutStr("deployment.environment", "prod")
+	with.Attributes().PutInt("replicas", 3)
+	without := pcommon.NewResource()
+	without.Attributes().PutStr("host.name", "node-1")
+
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("service.name"), Value: []byte("checkout")},
+		{Key: []byte("deployment.environment"), Value: []byte("prod")},
+		{Key: []byte("replicas"), Value: []byte("3")},
+	}, attributeHeaders(attributes, with))
+	assert.Empty(t, attributeHeaders(attributes, without))
+	assert.Empty(t, attributeHeaders(nil, with))
+}
+
+func TestNewResourceHeaders(t *testing.T) {
+	assert.Nil(t, newResourceHeaders(context.Background(), &Config{}, zap.NewNop()))
+
+	config := &Config{
+		HeadersFromAttributes: []string{"service.name"},
+		Baggage:               Baggage{FromContext: true, Attribute: "baggage"},
+		MaxHeaderValueBytes:   8,
+	}
+	headers := newResourceHeaders(contextWithBaggage(t, "tenant=acme"), config, zap.NewNop())
+	resource := pcommon.NewResource()
+	resource.Attributes().PutStr("service.name", "checkout-service")
+	resource.Attributes().PutStr("baggage", "order.id=42")
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("service.name"), Value: []byte("check...")},
+		{Key: []byte("tenant"), Value: []byte("acme")},
+		{Key: []byte("order.id"), Value: []byte("42")},
+	}, headers(resource))
+	assert.Equal(t, []sarama.RecordHeader{{Key: []byte("tenant"), Value: []byte("acme")}}, headers(pcommon.NewResource()))
+}
+
+func TestTruncateHeaderValues(t *testing.T) {
+	headers := func(values ...string) []sarama.RecordHeader {
+		var headers []sarama.RecordHeader
+		for _, value := range values {
+			headers = append(headers, sarama.RecordHeader{Key: []byte("key"), Value: []byte(value)})
+		}
+		return headers
+	}
+	tests := []struct {
+		name     string
+		maxBytes int
+		values   []string
+		expected []string
+	}{
+		{name: "disabled", maxBytes: 0, values: []string{"checkout-service"}, expected: []string{"checkout-service"}},
+		{name: "short", maxBytes: 8, values: []string{"checkout", "cart"}, expected: []string{"checkout", "cart"}},
+		{name: "long", maxBytes: 8, values: []string{"checkout-service", "cart"}, expected: []string{"check...", "cart"}},
+		{name: "utf8", maxBytes: 6, values: []string{"naïveté"}, expected: []string{"na..."}},
+		{name: "no room for marker", maxBytes: 3, values: []string{"checkout"}, expected: []string{"che"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, headers(tt.expected...), truncateHeaderValues(headers(tt.values...), tt.maxBytes))
+		})
+	}
+}
+
+func TestPushers_headers_from_attributes(t *testing.T) {
+	config := &Config{
+		HeadersFromAttributes: []string{"service.name", "deployment.environment"},
+		Producer:              Producer{protoVersion: 2, MaxMessageBytes: 1000 * 1000},
+	}
+	expected := map[string]string{"service.name": "checkout"}
+	checker := func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, expected, headersMap(msg.Headers))
+		return nil
+	}
+	producer := mocks.NewSyncProducer(t, sarama.NewConfig())
+	for i := 0; i < 3; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(checker)
+	}
+	t.Cleanup(func() {
+		require.NoError(t, producer.Close())
+	})
+
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "checkout")
+	rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	traces := kafkaTracesProducer{
+		producer:  producer,
+		marshaler: newPdataTracesMarshaler(&ptrace.ProtoMarshaler{}, defaultEncoding),
+		config:    config,
+		logger:    zap.NewNop(),
+	}
+	require.NoError(t, traces.tracesPusher(context.Background(), td))
+
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "checkout")
+	rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1)
+	metrics := kafkaMetricsProducer{
+		producer:  producer,
+		marshaler: newPdataMetricsMarshaler(&pmetric.ProtoMarshaler{}, defaultEncoding),
+		config:    config,
+		logger:    zap.NewNop(),
+	}
+	require.NoError(t, metrics.metricsDataPusher(context.Background(), md))
+
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "checkout")
+	rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("log")
+	logs := kafkaLogsProducer{
+		producer:  producer,
+		marshaler: newPdataLogsMarshaler(&plog.ProtoMarshaler{}, defaultEncoding),
+		config:    config,
+		logger:    zap.NewNop(),
+	}
+	require.NoError(t, logs.logsDataPusher(context.Background(), ld))
+}
+
+func TestPushers_headers_from_attributes_per_resource(t *testing.T) {
+	resources := map[string]string{"checkout": "prod", "cart": "staging"}
+	// The headers of the messages of every service, the baggage of checkout being truncated.
+	expected := map[string]map[string]string{
+		"checkout": {"service.name": "checkout", "deployment.environment": "prod", "owner": "team-ch..."},
+		"cart":     {"service.name": "cart", "deployment.environment": "staging", "owner": "team-cart"},
+	}
+	tests := []struct {
+		name   string
+		config *Config
+		// messages is the number of messages produced for every resource.
+		messages int
+	}{
+		{
+			name:     "shared message",
+			config:   &Config{},
+			messages: 1,
+		},
+		{
+			name:     "topic_from_attribute",
+			config:   &Config{TopicFromAttribute: "service.name"},
+			messages: 1,
+		},
+		{
+			name:     "partition_key",
+			config:   &Config{PartitionKey: "resource_attribute:service.name"},
+			messages: 1,
+		},
+		{
+			name:     "jaeger_proto",
+			config:   &Config{Encoding: "jaeger_proto"},
+			messages: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Topic = "spans"
+			config.HeadersFromAttributes = []string{"service.name", "deployment.environment"}
+			config.Baggage = Baggage{Attribute: "baggage"}
+			config.MaxHeaderValueBytes = 10
+			config.Producer = Producer{protoVersion: 2, MaxMessageBytes: 1000 * 1000}
+			marshaler := tracesMarshalers()[defaultEncoding]
+			if config.Encoding != "" {
+				marshaler = tracesMarshalers()[config.Encoding]
+			}
+
+			td := ptrace.NewTraces()
+			for service, environment := range resources {
+				rs := td.ResourceSpans().AppendEmpty()
+				rs.Resource().Attributes().PutStr("service.name", service)
+				rs.Resource().Attributes().PutStr("deployment.environment", environment)
+				rs.Resource().Attributes().PutStr("baggage", "owner=team-"+service)
+				spans := rs.ScopeSpans().AppendEmpty().Spans()
+				for i := 0; i < 2; i++ {
+					span := spans.AppendEmpty()
+					span.SetName(service)
+					span.SetTraceID([16]byte{byte(len(service)), byte(i + 1)})
+					span.SetSpanID([8]byte{byte(len(service)), byte(i + 1)})
+				}
+			}
+
+			produced := map[string]int{}
+			producer := mocks.NewSyncProducer(t, sarama.NewConfig())
+			for i := 0; i < tt.messages*len(resources); i++ {
+				producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+					headers := headersMap(msg.Headers)
+					assert.Equal(t, expected[headers["service.name"]], headers)
+					produced[headers["service.name"]]++
+					return nil
+				})
+			}
+			p := kafkaTracesProducer{
+				producer:  producer,
+				marshaler: marshaler,
+				config:    config,
+				logger:    zap.NewNop(),
+			}
+			require.NoError(t, p.tracesPusher(context.Background(), td))
+			require.NoError(t, p.Close(context.Background()))
+			assert.Equal(t, map[string]int{"checkout": tt.messages, "cart": tt.messages}, produced)
+		})
+	}
+}
+
+func TestTracesPusher_max_header_value_bytes(t *testing.T) {
+	config := &Config{
+		HeadersFromAttributes: []string{"service.name", "k8s.pod.name"},
+		MaxHeaderValueBytes:   16,
+		Producer:              Producer{protoVersion: 2, MaxMessageBytes: 1000 * 1000},
+	}
+	producer := mocks.NewSyncProducer(t, sarama.NewConfig())
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, map[string]string{"service.name": "checkout", "k8s.pod.name": "checkout-7d9f..."}, headersMap(msg.Headers))
+		return nil
+	})
+	traces := kafkaTracesProducer{
+		producer:  producer,
+		marshaler: newPdataTracesMarshaler(&ptrace.ProtoMarshaler{}, defaultEncoding),
+		config:    config,
+		logger:    zap.NewNop(),
+	}
+	t.Cleanup(func() {
+		require.NoError(t, traces.Close(context.Background()))
+	})
+
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "checkout")
+	rs.Resource().Attributes().PutStr("k8s.pod.name", "checkout-7d9f8b6c5-x2x4z")
+	rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	require.NoError(t, traces.tracesPusher(context.Background(), td))
+}
@@ -0,0 +1,78 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"sort"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// attributeRenamer renames the attributes of the data before it is marshaled.
+type attributeRenamer struct {
+	// from are the renamed keys, sorted so that the renames are applied in the same order every time.
+	from []string
+	to   map[string]string
+}
+
+// newAttributeRenamer returns nil if renames is empty.
+func newAttributeRenamer(renames map[string]string) *attributeRenamer {
+	if len(renames) == 0 {
+		return nil
+	}
+	r := &attributeRenamer{to: renames}
+	for from := range renames {
+		r.from = append(r.from, from)
+	}
+	sort.Strings(r.from)
+	return r
+}
+
+// traces returns a copy of td with its attributes renamed, td is left unchanged.
+func (r *attributeRenamer) traces(td ptrace.Traces) ptrace.Traces {
+	renamed := ptrace.NewTraces()
+	td.CopyTo(renamed)
+	rangeTracesAttributes(renamed, r.rename)
+	return renamed
+}
+
+// metrics returns a copy of md with its attributes renamed, md is left unchanged.
+func (r *attributeRenamer) metrics(md pmetric.Metrics) pmetric.Metrics {
+	renamed := pmetric.NewMetrics()
+	md.CopyTo(renamed)
+	rangeMetricsAttributes(renamed, r.rename)
+	return renamed
+}
+
+// logs returns a copy of ld with its attributes renamed, ld is left unchanged.
+func (r *attributeRenamer) logs(ld plog.Logs) plog.Logs {
+	renamed := plog.NewLogs()
+	ld.CopyTo(renamed)
+	rangeLogsAttributes(renamed, r.rename)
+	return renamed
+}
+
+// rename moves the values of the renamed keys of m to their new keys, replacing the values already there.
+// The renames are not chained: an attribute renamed to a key that is renamed too keeps its new key.
+func (r *attributeRenamer) rename(m pcommon.Map) {
+	var keys []string
+	var values []pcommon.Value
+	for _, from := range r.from {
+		v, ok := m.Get(from)
+		if !ok {
+			continue
+		}
+		value := pcommon.NewValueEmpty()
+		v.CopyTo(value)
+		keys = append(keys, r.to[from])
+		values = append(values, value)
+		m.Remove(from)
+	}
+	for i, key := range keys {
+		values[i].CopyTo(m.PutEmpty(key))
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+func TestAttributeRenamer_rename(t *testing.T) {
+	r := newAttributeRenamer(map[string]string{
+		"k8s.pod.name":       "pod",
+		"k8s.namespace.name": "namespace",
+		"namespace":          "legacy.namespace",
+	})
+	td := ptrace.NewTraces()
+	attrs := td.ResourceSpans().AppendEmpty().Resource().Attributes()
+	attrs.PutStr("k8s.pod.name", "api-0")
+	attrs.PutStr("pod", "replaced")
+	attrs.PutStr("k8s.namespace.name", "prod")
+	attrs.PutStr("namespace", "legacy")
+	attrs.PutInt("http.status_code", 200)
+
+	renamed := r.traces(td)
+	assert.Equal(t, map[string]any{
+		"pod":              "api-0",
+		"namespace":        "prod",
+		"legacy.namespace": "legacy",
+		"http.status_code": int64(200),
+	}, renamed.ResourceSpans().At(0).Resource().Attributes().AsRaw())
+	// The input is left unchanged.
+	assert.Equal(t, 5, attrs.Len())
+
+	assert.Nil(t, newAttributeRenamer(nil))
+}
+
+func TestAttributeRenames(t *testing.T) {
+	config := &Config{
+		Topic:            "topic",
+		Encoding:         "otlp_json",
+		AttributeRenames: map[string]string{"k8s.pod.name": "pod"},
+		Producer:         Producer{MaxMessageBytes: 1000 * 1000},
+	}
+	renamer := newAttributeRenamer(config.AttributeRenames)
+
+	producer := mocks.NewSyncProducer(t, nil)
+	var values [][]byte
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recordValue(&values))
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recordValue(&values))
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recordValue(&values))
+
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("k8s.pod.name", "api-0")
+	rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().Attributes().PutStr("k8s.pod.name", "api-0")
+	traces := kafkaTracesProducer{producer: producer, marshaler: tracesMarshalers()["otlp_json"], config: config, renamer: renamer, logger: zap.NewNop()}
+	require.NoError(t, traces.tracesPusher(context.Background(), td))
+
+	md := pmetric.NewMetrics()
+	md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty().Attributes().PutStr("k8s.pod.name", "api-0")
+	metrics := kafkaMetricsProducer{producer: producer, marshaler: metricsMarshalers()["otlp_json"], config: config, renamer: renamer, logger: zap.NewNop()}
+	require.NoError(t, metrics.metricsDataPusher(context.Background(), md))
+
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Attributes().PutStr("k8s.pod.name", "api-0")
+	logs := kafkaLogsProducer{producer: producer, marshaler: logsMarshalers()["otlp_json"], config: config, renamer: renamer, logger: zap.NewNop()}
+	require.NoError(t, logs.logsDataPusher(context.Background(), ld))
+
+	require.Len(t, values, 3)
+	for _, value := range values {
+		assert.Contains(t, string(value), `"key":"pod"`)
+		assert.NotContains(t, string(value), "k8s.pod.name")
+	}
+	// The data passed to the exporter is left unchanged.
+	_, ok := td.ResourceSpans().At(0).Resource().Attributes().Get("k8s.pod.name")
+	assert.True(t, ok)
+}
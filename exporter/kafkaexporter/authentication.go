@@ -7,11 +7,13 @@ import (
 	"crypto/sha256"
 	"crypto/sha512"
 	"fmt"
+	"time"
 
 	"github.com/IBM/sarama"
 	"go.opentelemetry.io/collector/config/configtls"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/awsmsk"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/kafkaauth"
 )
 
 // Authentication defines authentication.
@@ -34,12 +36,25 @@ type SASLConfig struct {
 	Username string `mapstructure:"username"`
 	// Password to be used on authentication
 	Password string `mapstructure:"password"`
-	// SASL Mechanism to be used, possible values are: (PLAIN, AWS_MSK_IAM, SCRAM-SHA-256 or SCRAM-SHA-512).
+	// SASL Mechanism to be used, possible values are: (PLAIN, AWS_MSK_IAM, AWS_MSK_IAM_OAUTHBEARER, OAUTHBEARER, SCRAM-SHA-256 or SCRAM-SHA-512).
 	Mechanism string `mapstructure:"mechanism"`
 	// SASL Protocol Version to be used, possible values are: (0, 1). Defaults to 0.
 	Version int `mapstructure:"version"`
 
-	AWSMSK AWSMSKConfig `mapstructure:"aws_msk"`
+	AWSMSK      AWSMSKConfig      `mapstructure:"aws_msk"`
+	OAuthBearer OAuthBearerConfig `mapstructure:"oauthbearer"`
+}
+
+// OAuthBearerConfig defines the token endpoint used by the OAUTHBEARER mechanism.
+// The tokens are obtained with the client credentials grant, the username and password
+// being the client ID and secret.
+type OAuthBearerConfig struct {
+	// TokenURL is the URL of the token endpoint of the authorization server
+	TokenURL string `mapstructure:"token_url"`
+	// Scopes are requested for the token
+	Scopes []string `mapstructure:"scopes"`
+	// TokenExpiryBuffer is how long before its expiry the token is refreshed (default 30s when 0)
+	TokenExpiryBuffer time.Duration `mapstructure:"token_expiry_buffer"`
 }
 
 // AWSMSKConfig defines the additional SASL authentication
@@ -49,6 +64,8 @@ type AWSMSKConfig struct {
 	Region string `mapstructure:"region"`
 	// BrokerAddr is the client is connecting to in order to perform the auth required
 	BrokerAddr string `mapstructure:"broker_addr"`
+	// AssumeRoleARN is the IAM role assumed with the AWS credentials, e.g. to reach a cluster of another account
+	AssumeRoleARN string `mapstructure:"assume_role_arn"`
 }
 
 // KerberosConfig defines kereros configuration.
@@ -60,6 +77,16 @@ type KerberosConfig struct {
 	Password    string `mapstructure:"password" json:"-"`
 	ConfigPath  string `mapstructure:"config_file"`
 	KeyTabPath  string `mapstructure:"keytab_file"`
+	// DisablePAFXFAST disables the FAST pre-authentication, for the KDCs that do not support it
+	DisablePAFXFAST bool `mapstructure:"disable_fast_negotiation"`
+}
+
+// Validate checks the SASL and Kerberos settings, it is shared by the Kafka exporter and receiver.
+func (config Authentication) Validate() error {
+	if err := validateSASLConfig(config.SASL); err != nil {
+		return err
+	}
+	return validateKerberosConfig(config.Kerberos)
 }
 
 // ConfigureAuthentication configures authentication in sarama.Config.
@@ -92,12 +119,15 @@ func configurePlaintext(config PlainTextConfig, saramaConfig *sarama.Config) {
 
 func configureSASL(config SASLConfig, saramaConfig *sarama.Config) error {
 
-	if config.Username == "" {
-		return fmt.Errorf("username have to be provided")
-	}
+	// AWS_MSK_IAM and AWS_MSK_IAM_OAUTHBEARER fall back to the default credential chain of the AWS SDK.
+	if config.Mechanism != awsmsk.Mechanism && config.Mechanism != awsmsk.OAuthBearerMechanism {
+		if config.Username == "" {
+			return fmt.Errorf("username have to be provided")
+		}
 
-	if config.Password == "" {
-		return fmt.Errorf("password have to be provided")
+		if config.Password == "" {
+			return fmt.Errorf("password have to be provided")
+		}
 	}
 
 	saramaConfig.Net.SASL.Enable = true
@@ -114,12 +144,29 @@ func configureSASL(config SASLConfig, saramaConfig *sarama.Config) error {
 	case "PLAIN":
 		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
 	case "AWS_MSK_IAM":
-		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
-			return awsmsk.NewIAMSASLClient(config.AWSMSK.BrokerAddr, config.AWSMSK.Region, saramaConfig.ClientID)
+		generator, err := awsmsk.NewIAMSASLClientGenerator(config.AWSMSK.BrokerAddr, config.AWSMSK.Region, saramaConfig.ClientID,
+			config.Username, config.Password, config.AWSMSK.AssumeRoleARN)
+		if err != nil {
+			return fmt.Errorf("failed to load the AWS credentials of AWS_MSK_IAM: %w", err)
 		}
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = generator
 		saramaConfig.Net.SASL.Mechanism = awsmsk.Mechanism
+	case awsmsk.OAuthBearerMechanism:
+		provider, err := awsmsk.NewTokenProvider(config.AWSMSK.Region, config.Username, config.Password, config.AWSMSK.AssumeRoleARN, saramaConfig.ClientID)
+		if err != nil {
+			return fmt.Errorf("failed to load the AWS credentials of AWS_MSK_IAM_OAUTHBEARER: %w", err)
+		}
+		saramaConfig.Net.SASL.TokenProvider = provider
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+	case kafkaauth.OAuthBearerMechanism:
+		if config.OAuthBearer.TokenURL == "" {
+			return fmt.Errorf("token_url have to be provided")
+		}
+		saramaConfig.Net.SASL.TokenProvider = kafkaauth.NewClientCredentialsTokenProvider(
+			config.OAuthBearer.TokenURL, config.Username, config.Password, config.OAuthBearer.Scopes, config.OAuthBearer.TokenExpiryBuffer)
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeOAuth
 	default:
-		return fmt.Errorf(`invalid SASL Mechanism %q: can be either "PLAIN", "AWS_MSK_IAM", "SCRAM-SHA-256" or "SCRAM-SHA-512"`, config.Mechanism)
+		return fmt.Errorf(`invalid SASL Mechanism %q: can be either "PLAIN", "AWS_MSK_IAM", "AWS_MSK_IAM_OAUTHBEARER", "OAUTHBEARER", "SCRAM-SHA-256" or "SCRAM-SHA-512"`, config.Mechanism)
 	}
 
 	switch config.Version {
@@ -158,4 +205,5 @@ func configureKerberos(config KerberosConfig, saramaConfig *sarama.Config) {
 	saramaConfig.Net.SASL.GSSAPI.Username = config.Username
 	saramaConfig.Net.SASL.GSSAPI.Realm = config.Realm
 	saramaConfig.Net.SASL.GSSAPI.ServiceName = config.ServiceName
+	saramaConfig.Net.SASL.GSSAPI.DisablePAFXFAST = config.DisablePAFXFAST
 }
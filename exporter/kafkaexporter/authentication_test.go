@@ -10,6 +10,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/config/configtls"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/awsmsk"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/kafkaauth"
 )
 
 func TestAuthentication(t *testing.T) {
@@ -63,6 +66,17 @@ func TestAuthentication(t *testing.T) {
 	saramaKerberosKeyTabCfg.Net.SASL.GSSAPI.KeyTabPath = "/path"
 	saramaKerberosKeyTabCfg.Net.SASL.GSSAPI.AuthType = sarama.KRB5_KEYTAB_AUTH
 
+	saramaKerberosFullCfg := &sarama.Config{}
+	saramaKerberosFullCfg.Net.SASL.Mechanism = sarama.SASLTypeGSSAPI
+	saramaKerberosFullCfg.Net.SASL.Enable = true
+	saramaKerberosFullCfg.Net.SASL.GSSAPI.ServiceName = "kafka"
+	saramaKerberosFullCfg.Net.SASL.GSSAPI.Realm = "EXAMPLE.COM"
+	saramaKerberosFullCfg.Net.SASL.GSSAPI.Username = "jdoe"
+	saramaKerberosFullCfg.Net.SASL.GSSAPI.Password = "pass"
+	saramaKerberosFullCfg.Net.SASL.GSSAPI.KerberosConfigPath = "/etc/krb5.conf"
+	saramaKerberosFullCfg.Net.SASL.GSSAPI.AuthType = sarama.KRB5_USER_AUTH
+	saramaKerberosFullCfg.Net.SASL.GSSAPI.DisablePAFXFAST = true
+
 	tests := []struct {
 		auth         Authentication
 		saramaConfig *sarama.Config
@@ -91,6 +105,17 @@ func TestAuthentication(t *testing.T) {
 			auth:         Authentication{Kerberos: &KerberosConfig{UseKeyTab: true, KeyTabPath: "/path"}},
 			saramaConfig: saramaKerberosKeyTabCfg,
 		},
+		{
+			auth: Authentication{Kerberos: &KerberosConfig{
+				ServiceName:     "kafka",
+				Realm:           "EXAMPLE.COM",
+				Username:        "jdoe",
+				Password:        "pass",
+				ConfigPath:      "/etc/krb5.conf",
+				DisablePAFXFAST: true,
+			}},
+			saramaConfig: saramaKerberosFullCfg,
+		},
 		{
 			auth:         Authentication{SASL: &SASLConfig{Username: "jdoe", Password: "pass", Mechanism: "SCRAM-SHA-256"}},
 			saramaConfig: saramaSASLSCRAM256Config,
@@ -143,3 +168,48 @@ func TestAuthentication(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigureAuthentication_oauthbearer(t *testing.T) {
+	config := &sarama.Config{}
+	err := ConfigureAuthentication(Authentication{SASL: &SASLConfig{
+		Username:    "client",
+		Password:    "secret",
+		Mechanism:   "OAUTHBEARER",
+		OAuthBearer: OAuthBearerConfig{TokenURL: "https://idp.example.com/token", Scopes: []string{"kafka"}},
+	}}, config)
+	require.NoError(t, err)
+	assert.True(t, config.Net.SASL.Enable)
+	assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypeOAuth), config.Net.SASL.Mechanism)
+	assert.IsType(t, &kafkaauth.ClientCredentialsTokenProvider{}, config.Net.SASL.TokenProvider)
+
+	err = ConfigureAuthentication(Authentication{SASL: &SASLConfig{Username: "client", Password: "secret", Mechanism: "OAUTHBEARER"}}, &sarama.Config{})
+	assert.EqualError(t, err, "token_url have to be provided")
+}
+
+func TestConfigureAuthentication_aws_msk_iam_default_credentials(t *testing.T) {
+	config := &sarama.Config{}
+	err := ConfigureAuthentication(Authentication{SASL: &SASLConfig{
+		Mechanism: "AWS_MSK_IAM",
+		AWSMSK:    AWSMSKConfig{Region: "us-east-1", BrokerAddr: "broker:9098"},
+	}}, config)
+	require.NoError(t, err)
+	assert.Equal(t, sarama.SASLMechanism(awsmsk.Mechanism), config.Net.SASL.Mechanism)
+	require.NotNil(t, config.Net.SASL.SCRAMClientGeneratorFunc)
+}
+
+func TestConfigureAuthentication_aws_msk_iam_oauthbearer(t *testing.T) {
+	config := &sarama.Config{}
+	err := ConfigureAuthentication(Authentication{SASL: &SASLConfig{
+		Username:  "testing",
+		Password:  "hunter2",
+		Mechanism: "AWS_MSK_IAM_OAUTHBEARER",
+		AWSMSK:    AWSMSKConfig{Region: "us-east-1"},
+	}}, config)
+	require.NoError(t, err)
+	assert.True(t, config.Net.SASL.Enable)
+	assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypeOAuth), config.Net.SASL.Mechanism)
+	require.IsType(t, &awsmsk.TokenProvider{}, config.Net.SASL.TokenProvider)
+	token, err := config.Net.SASL.TokenProvider.Token()
+	require.NoError(t, err)
+	assert.NotEmpty(t, token.Token)
+}
@@ -0,0 +1,202 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"bytes"
+	"compress/gzip"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	snappy "github.com/eapache/go-xerial-snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"go.uber.org/zap"
+)
+
+const compressionAuto = "auto"
+
+// autoCompressionProducer produces the first sampled messages without compression, then
+// benchmarks the codecs on the sampled payloads and replaces the producer with one using
+// the selected codec.
+type autoCompressionProducer struct {
+	// The embedded producer is guarded by mu.
+	sarama.SyncProducer
+	mu sync.RWMutex
+
+	newProducer func(codec sarama.CompressionCodec) (sarama.SyncProducer, error)
+	samples     int
+	logger      *zap.Logger
+
+	sampleMu sync.Mutex
+	payloads [][]byte
+	selected bool
+}
+
+func newAutoCompressionProducer(samples int, newProducer func(codec sarama.CompressionCodec) (sarama.SyncProducer, error), logger *zap.Logger) (*autoCompressionProducer, error) {
+	producer, err := newProducer(sarama.CompressionNone)
+	if err != nil {
+		return nil, err
+	}
+	return &autoCompressionProducer{
+		SyncProducer: producer,
+		newProducer:  newProducer,
+		samples:      samples,
+		logger:       logger,
+	}, nil
+}
+
+func (p *autoCompressionProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	p.sample([]*sarama.ProducerMessage{msg})
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.SyncProducer.SendMessage(msg)
+}
+
+func (p *autoCompressionProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	p.sample(msgs)
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.SyncProducer.SendMessages(msgs)
+}
+
+func (p *autoCompressionProducer) Close() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.SyncProducer.Close()
+}
+
+// sample collects the payloads of msgs until enough are sampled to select the codec.
+func (p *autoCompressionProducer) sample(msgs []*sarama.ProducerMessage) {
+	p.sampleMu.Lock()
+	if p.selected {
+		p.sampleMu.Unlock()
+		return
+	}
+	for _, msg := range msgs {
+		if len(p.payloads) == p.samples {
+			break
+		}
+		if msg.Value == nil {
+			continue
+		}
+		if payload, err := msg.Value.Encode(); err == nil {
+			p.payloads = append(p.payloads, payload)
+		}
+	}
+	if len(p.payloads) < p.samples {
+		p.sampleMu.Unlock()
+		return
+	}
+	p.selected = true
+	payloads := p.payloads
+	p.payloads = nil
+	p.sampleMu.Unlock()
+
+	codec := selectCompressionCodec(payloads)
+	p.logger.Info("Selected compression codec from sampled messages", zap.Stringer("codec", codec), zap.Int("samples", len(payloads)))
+	if codec == sarama.CompressionNone {
+		return
+	}
+	producer, err := p.newProducer(codec)
+	if err != nil {
+		p.logger.Warn("Failed to create producer with the selected compression codec, producing uncompressed messages", zap.Error(err))
+		return
+	}
+	p.mu.Lock()
+	previous := p.SyncProducer
+	p.SyncProducer = producer
+	p.mu.Unlock()
+	if err = previous.Close(); err != nil {
+		p.logger.Warn("Failed to close uncompressed producer", zap.Error(err))
+	}
+}
+
+// compressionCandidates are the codecs benchmarked by selectCompressionCodec.
+var compressionCandidates = []struct {
+	codec    sarama.CompressionCodec
+	compress func([]byte) ([]byte, error)
+}{
+	{codec: sarama.CompressionGZIP, compress: compressGZIP},
+	{codec: sarama.CompressionSnappy, compress: func(data []byte) ([]byte, error) { return snappy.Encode(data), nil }},
+	{codec: sarama.CompressionLZ4, compress: compressLZ4},
+	{codec: sarama.CompressionZSTD, compress: compressZSTD},
+}
+
+// maxCompressionSlowdown is how many times slower than the fastest codec a codec may be to be selected.
+const maxCompressionSlowdown = 4
+
+// selectCompressionCodec compresses the payloads as a single batch with every candidate codec,
+// and returns the codec with the smallest output among those at most maxCompressionSlowdown
+// times slower than the fastest one. CompressionNone is returned if no codec shrinks the payloads.
+func selectCompressionCodec(payloads [][]byte) sarama.CompressionCodec {
+	batch := bytes.Join(payloads, nil)
+
+	type result struct {
+		codec    sarama.CompressionCodec
+		size     int
+		duration time.Duration
+	}
+	results := make([]result, 0, len(compressionCandidates))
+	var fastest time.Duration
+	for _, candidate := range compressionCandidates {
+		start := time.Now()
+		compressed, err := candidate.compress(batch)
+		duration := time.Since(start)
+		if err != nil {
+			continue
+		}
+		if len(results) == 0 || duration < fastest {
+			fastest = duration
+		}
+		results = append(results, result{codec: candidate.codec, size: len(compressed), duration: duration})
+	}
+
+	selected := sarama.CompressionNone
+	smallest := len(batch)
+	for _, r := range results {
+		if r.duration > maxCompressionSlowdown*fastest {
+			continue
+		}
+		if r.size < smallest {
+			selected = r.codec
+			smallest = r.size
+		}
+	}
+	return selected
+}
+
+func compressGZIP(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func compressLZ4(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := lz4.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func compressZSTD(data []byte) ([]byte, error) {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer encoder.Close()
+	return encoder.EncodeAll(data, nil), nil
+}
@@ -0,0 +1,47 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSelectCompressionCodec(t *testing.T) {
+	compressible := []byte(strings.Repeat(`{"resource":{"attributes":[{"key":"service.name","value":"foo"}]}}`, 100))
+	assert.NotEqual(t, sarama.CompressionNone, selectCompressionCodec([][]byte{compressible, compressible}))
+
+	random := make([]byte, 1024)
+	_, err := rand.Read(random)
+	require.NoError(t, err)
+	assert.Equal(t, sarama.CompressionNone, selectCompressionCodec([][]byte{random}))
+}
+
+func TestAutoCompressionProducer(t *testing.T) {
+	var codecs []sarama.CompressionCodec
+	p, err := newAutoCompressionProducer(2, func(codec sarama.CompressionCodec) (sarama.SyncProducer, error) {
+		codecs = append(codecs, codec)
+		producer := mocks.NewSyncProducer(t, sarama.NewConfig())
+		producer.ExpectSendMessageAndSucceed()
+		return producer, nil
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	value := sarama.StringEncoder(strings.Repeat("compressible ", 100))
+	require.NoError(t, p.SendMessages([]*sarama.ProducerMessage{{Topic: "topic", Value: value}}))
+	assert.Equal(t, []sarama.CompressionCodec{sarama.CompressionNone}, codecs)
+
+	// The second message completes the samples, it is sent with the selected codec.
+	require.NoError(t, p.SendMessages([]*sarama.ProducerMessage{{Topic: "topic", Value: value}}))
+	require.Len(t, codecs, 2)
+	assert.NotEqual(t, sarama.CompressionNone, codecs[1])
+	require.NoError(t, p.Close())
+}
@@ -0,0 +1,807 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/avro"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const schemaRegistryTimeout = 10 * time.Second
+
+const (
+	subjectNameStrategyTopicName       = "TopicName"
+	subjectNameStrategyRecordName      = "RecordName"
+	subjectNameStrategyTopicRecordName = "TopicRecordName"
+)
+
+// avroKeyValueSchema defines the KeyValue record of the OTLP attributes, and the ArrayValue and KeyValueList
+// records of the values holding attributes. AnyValue is a union referencing them, as unions cannot be named.
+const avroKeyValueSchema = `{"type": "record", "name": "KeyValue", "namespace": "opentelemetry.proto.common.v1", "fields": [
+	{"name": "key", "type": "string"},
+	{"name": "value", "type": ["null", "string", "boolean", "long", "double", "bytes",
+		{"type": "record", "name": "ArrayValue", "fields": [
+			{"name": "values", "type": {"type": "array", "items": ["null", "string", "boolean", "long", "double", "bytes", "ArrayValue",
+				{"type": "record", "name": "KeyValueList", "fields": [
+					{"name": "values", "type": {"type": "array", "items": "KeyValue"}}
+				]}
+			]}}
+		]},
+		"KeyValueList"
+	]}
+]}`
+
+// avroResourceSchema defines the Resource record, and so the KeyValue record.
+const avroResourceSchema = `{"type": "record", "name": "Resource", "namespace": "opentelemetry.proto.resource.v1", "fields": [
+	{"name": "attributes", "type": {"type": "array", "items": ` + avroKeyValueSchema + `}},
+	{"name": "dropped_attributes_count", "type": "long"}
+]}`
+
+// avroScopeSchema defines the InstrumentationScope record, once the KeyValue record is defined.
+const avroScopeSchema = `{"type": "record", "name": "InstrumentationScope", "namespace": "opentelemetry.proto.common.v1", "fields": [
+	{"name": "name", "type": "string"},
+	{"name": "version", "type": "string"},
+	{"name": "attributes", "type": {"type": "array", "items": "KeyValue"}},
+	{"name": "dropped_attributes_count", "type": "long"}
+]}`
+
+// avroTracesSchema mirrors the TracesData message of OTLP, the ids being bytes, the timestamps longs, and the
+// enums holding the names of their OTLP values.
+const avroTracesSchema = `{"type": "record", "name": "TracesData", "namespace": "opentelemetry.proto.trace.v1", "fields": [
+	{"name": "resource_spans", "type": {"type": "array", "items": {"type": "record", "name": "ResourceSpans", "fields": [
+		{"name": "resource", "type": ` + avroResourceSchema + `},
+		{"name": "scope_spans", "type": {"type": "array", "items": {"type": "record", "name": "ScopeSpans", "fields": [
+			{"name": "scope", "type": ` + avroScopeSchema + `},
+			{"name": "spans", "type": {"type": "array", "items": {"type": "record", "name": "Span", "fields": [
+				{"name": "trace_id", "type": "bytes"},
+				{"name": "span_id", "type": "bytes"},
+				{"name": "trace_state", "type": "string"},
+				{"name": "parent_span_id", "type": "bytes"},
+				{"name": "name", "type": "string"},
+				{"name": "kind", "type": {"type": "enum", "name": "SpanKind", "symbols": [
+					"SPAN_KIND_UNSPECIFIED", "SPAN_KIND_INTERNAL", "SPAN_KIND_SERVER", "SPAN_KIND_CLIENT", "SPAN_KIND_PRODUCER", "SPAN_KIND_CONSUMER"
+				]}},
+				{"name": "start_time_unix_nano", "type": "long"},
+				{"name": "end_time_unix_nano", "type": "long"},
+				{"name": "attributes", "type": {"type": "array", "items": "opentelemetry.proto.common.v1.KeyValue"}},
+				{"name": "dropped_attributes_count", "type": "long"},
+				{"name": "events", "type": {"type": "array", "items": {"type": "record", "name": "Event", "fields": [
+					{"name": "time_unix_nano", "type": "long"},
+					{"name": "name", "type": "string"},
+					{"name": "attributes", "type": {"type": "array", "items": "opentelemetry.proto.common.v1.KeyValue"}},
+					{"name": "dropped_attributes_count", "type": "long"}
+				]}}},
+				{"name": "dropped_events_count", "type": "long"},
+				{"name": "links", "type": {"type": "array", "items": {"type": "record", "name": "Link", "fields": [
+					{"name": "trace_id", "type": "bytes"},
+					{"name": "span_id", "type": "bytes"},
+					{"name": "trace_state", "type": "string"},
+					{"name": "attributes", "type": {"type": "array", "items": "opentelemetry.proto.common.v1.KeyValue"}},
+					{"name": "dropped_attributes_count", "type": "long"}
+				]}}},
+				{"name": "dropped_links_count", "type": "long"},
+				{"name": "status", "type": {"type": "record", "name": "Status", "fields": [
+					{"name": "message", "type": "string"},
+					{"name": "code", "type": {"type": "enum", "name": "StatusCode", "symbols": ["STATUS_CODE_UNSET", "STATUS_CODE_OK", "STATUS_CODE_ERROR"]}}
+				]}}
+			]}}},
+			{"name": "schema_url", "type": "string"}
+		]}}},
+		{"name": "schema_url", "type": "string"}
+	]}}}
+]}`
+
+// avroMetricsSchema mirrors the MetricsData message of OTLP. The data of a metric is a union of its Gauge, Sum,
+// Histogram, ExponentialHistogram and Summary records, and the values of the number data points and exemplars
+// unions of double and long, like the oneofs of OTLP.
+const avroMetricsSchema = `{"type": "record", "name": "MetricsData", "namespace": "opentelemetry.proto.metrics.v1", "fields": [
+	{"name": "resource_metrics", "type": {"type": "array", "items": {"type": "record", "name": "ResourceMetrics", "fields": [
+		{"name": "resource", "type": ` + avroResourceSchema + `},
+		{"name": "scope_metrics", "type": {"type": "array", "items": {"type": "record", "name": "ScopeMetrics", "fields": [
+			{"name": "scope", "type": ` + avroScopeSchema + `},
+			{"name": "metrics", "type": {"type": "array", "items": {"type": "record", "name": "Metric", "fields": [
+				{"name": "name", "type": "string"},
+				{"name": "description", "type": "string"},
+				{"name": "unit", "type": "string"},
+				{"name": "data", "type": ["null",
+					{"type": "record", "name": "Gauge", "fields": [
+						{"name": "data_points", "type": {"type": "array", "items": {"type": "record", "name": "NumberDataPoint", "fields": [
+							{"name": "attributes", "type": {"type": "array", "items": "opentelemetry.proto.common.v1.KeyValue"}},
+							{"name": "start_time_unix_nano", "type": "long"},
+							{"name": "time_unix_nano", "type": "long"},
+							{"name": "value", "type": ["null", "double", "long"]},
+							{"name": "exemplars", "type": {"type": "array", "items": {"type": "record", "name": "Exemplar", "fields": [
+								{"name": "filtered_attributes", "type": {"type": "array", "items": "opentelemetry.proto.common.v1.KeyValue"}},
+								{"name": "time_unix_nano", "type": "long"},
+								{"name": "value", "type": ["null", "double", "long"]},
+								{"name": "span_id", "type": "bytes"},
+								{"name": "trace_id", "type": "bytes"}
+							]}}},
+							{"name": "flags", "type": "long"}
+						]}}}
+					]},
+					{"type": "record", "name": "Sum", "fields": [
+						{"name": "data_points", "type": {"type": "array", "items": "NumberDataPoint"}},
+						{"name": "aggregation_temporality", "type": {"type": "enum", "name": "AggregationTemporality", "symbols": [
+							"AGGREGATION_TEMPORALITY_UNSPECIFIED", "AGGREGATION_TEMPORALITY_DELTA", "AGGREGATION_TEMPORALITY_CUMULATIVE"
+						]}},
+						{"name": "is_monotonic", "type": "boolean"}
+					]},
+					{"type": "record", "name": "Histogram", "fields": [
+						{"name": "data_points", "type": {"type": "array", "items": {"type": "record", "name": "HistogramDataPoint", "fields": [
+							{"name": "attributes", "type": {"type": "array", "items": "opentelemetry.proto.common.v1.KeyValue"}},
+							{"name": "start_time_unix_nano", "type": "long"},
+							{"name": "time_unix_nano", "type": "long"},
+							{"name": "count", "type": "long"},
+							{"name": "sum", "type": ["null", "double"]},
+							{"name": "bucket_counts", "type": {"type": "array", "items": "long"}},
+							{"name": "explicit_bounds", "type": {"type": "array", "items": "double"}},
+							{"name": "exemplars", "type": {"type": "array", "items": "Exemplar"}},
+							{"name": "flags", "type": "long"},
+							{"name": "min", "type": ["null", "double"]},
+							{"name": "max", "type": ["null", "double"]}
+						]}}},
+						{"name": "aggregation_temporality", "type": "AggregationTemporality"}
+					]},
+					{"type": "record", "name": "ExponentialHistogram", "fields": [
+						{"name": "data_points", "type": {"type": "array", "items": {"type": "record", "name": "ExponentialHistogramDataPoint", "fields": [
+							{"name": "attributes", "type": {"type": "array", "items": "opentelemetry.proto.common.v1.KeyValue"}},
+							{"name": "start_time_unix_nano", "type": "long"},
+							{"name": "time_unix_nano", "type": "long"},
+							{"name": "count", "type": "long"},
+							{"name": "sum", "type": ["null", "double"]},
+							{"name": "scale", "type": "int"},
+							{"name": "zero_count", "type": "long"},
+							{"name": "positive", "type": {"type": "record", "name": "Buckets", "fields": [
+								{"name": "offset", "type": "int"},
+								{"name": "bucket_counts", "type": {"type": "array", "items": "long"}}
+							]}},
+							{"name": "negative", "type": "Buckets"},
+							{"name": "flags", "type": "long"},
+							{"name": "exemplars", "type": {"type": "array", "items": "Exemplar"}},
+							{"name": "min", "type": ["null", "double"]},
+							{"name": "max", "type": ["null", "double"]}
+						]}}},
+						{"name": "aggregation_temporality", "type": "AggregationTemporality"}
+					]},
+					{"type": "record", "name": "Summary", "fields": [
+						{"name": "data_points", "type": {"type": "array", "items": {"type": "record", "name": "SummaryDataPoint", "fields": [
+							{"name": "attributes", "type": {"type": "array", "items": "opentelemetry.proto.common.v1.KeyValue"}},
+							{"name": "start_time_unix_nano", "type": "long"},
+							{"name": "time_unix_nano", "type": "long"},
+							{"name": "count", "type": "long"},
+							{"name": "sum", "type": "double"},
+							{"name": "quantile_values", "type": {"type": "array", "items": {"type": "record", "name": "ValueAtQuantile", "fields": [
+								{"name": "quantile", "type": "double"},
+								{"name": "value", "type": "double"}
+							]}}},
+							{"name": "flags", "type": "long"}
+						]}}}
+					]}
+				]}
+			]}}},
+			{"name": "schema_url", "type": "string"}
+		]}}},
+		{"name": "schema_url", "type": "string"}
+	]}}}
+]}`
+
+// avroLogsSchema mirrors the LogsData message of OTLP. The body of a log record is the AnyValue union of the
+// attributes, null being an empty body.
+const avroLogsSchema = `{"type": "record", "name": "LogsData", "namespace": "opentelemetry.proto.logs.v1", "fields": [
+	{"name": "resource_logs", "type": {"type": "array", "items": {"type": "record", "name": "ResourceLogs", "fields": [
+		{"name": "resource", "type": ` + avroResourceSchema + `},
+		{"name": "scope_logs", "type": {"type": "array", "items": {"type": "record", "name": "ScopeLogs", "fields": [
+			{"name": "scope", "type": ` + avroScopeSchema + `},
+			{"name": "log_records", "type": {"type": "array", "items": {"type": "record", "name": "LogRecord", "fields": [
+				{"name": "time_unix_nano", "type": "long"},
+				{"name": "observed_time_unix_nano", "type": "long"},
+				{"name": "severity_number", "type": {"type": "enum", "name": "SeverityNumber", "symbols": [
+					"SEVERITY_NUMBER_UNSPECIFIED",
+					"SEVERITY_NUMBER_TRACE", "SEVERITY_NUMBER_TRACE2", "SEVERITY_NUMBER_TRACE3", "SEVERITY_NUMBER_TRACE4",
+					"SEVERITY_NUMBER_DEBUG", "SEVERITY_NUMBER_DEBUG2", "SEVERITY_NUMBER_DEBUG3", "SEVERITY_NUMBER_DEBUG4",
+					"SEVERITY_NUMBER_INFO", "SEVERITY_NUMBER_INFO2", "SEVERITY_NUMBER_INFO3", "SEVERITY_NUMBER_INFO4",
+					"SEVERITY_NUMBER_WARN", "SEVERITY_NUMBER_WARN2", "SEVERITY_NUMBER_WARN3", "SEVERITY_NUMBER_WARN4",
+					"SEVERITY_NUMBER_ERROR", "SEVERITY_NUMBER_ERROR2", "SEVERITY_NUMBER_ERROR3", "SEVERITY_NUMBER_ERROR4",
+					"SEVERITY_NUMBER_FATAL", "SEVERITY_NUMBER_FATAL2", "SEVERITY_NUMBER_FATAL3", "SEVERITY_NUMBER_FATAL4"
+				]}},
+				{"name": "severity_text", "type": "string"},
+				{"name": "body", "type": ["null", "string", "boolean", "long", "double", "bytes",
+					"opentelemetry.proto.common.v1.ArrayValue", "opentelemetry.proto.common.v1.KeyValueList"]},
+				{"name": "attributes", "type": {"type": "array", "items": "opentelemetry.proto.common.v1.KeyValue"}},
+				{"name": "dropped_attributes_count", "type": "long"},
+				{"name": "flags", "type": "long"},
+				{"name": "trace_id", "type": "bytes"},
+				{"name": "span_id", "type": "bytes"}
+			]}}},
+			{"name": "schema_url", "type": "string"}
+		]}}},
+		{"name": "schema_url", "type": "string"}
+	]}}}
+]}`
+
+// The union branches of AnyValue holding arrays and maps are named after their records.
+const (
+	avroArrayValueBranch   = "opentelemetry.proto.common.v1.ArrayValue"
+	avroKeyValueListBranch = "opentelemetry.proto.common.v1.KeyValueList"
+)
+
+// The union branches of the data of a metric are named after their records.
+const (
+	avroGaugeBranch                = "opentelemetry.proto.metrics.v1.Gauge"
+	avroSumBranch                  = "opentelemetry.proto.metrics.v1.Sum"
+	avroHistogramBranch            = "opentelemetry.proto.metrics.v1.Histogram"
+	avroExponentialHistogramBranch = "opentelemetry.proto.metrics.v1.ExponentialHistogram"
+	avroSummaryBranch              = "opentelemetry.proto.metrics.v1.Summary"
+)
+
+var (
+	avroSpanKinds     = []string{"SPAN_KIND_UNSPECIFIED", "SPAN_KIND_INTERNAL", "SPAN_KIND_SERVER", "SPAN_KIND_CLIENT", "SPAN_KIND_PRODUCER", "SPAN_KIND_CONSUMER"}
+	avroStatusCodes   = []string{"STATUS_CODE_UNSET", "STATUS_CODE_OK", "STATUS_CODE_ERROR"}
+	avroTemporalities = []string{"AGGREGATION_TEMPORALITY_UNSPECIFIED", "AGGREGATION_TEMPORALITY_DELTA", "AGGREGATION_TEMPORALITY_CUMULATIVE"}
+	avroSeverities    = []string{
+		"SEVERITY_NUMBER_UNSPECIFIED",
+		"SEVERITY_NUMBER_TRACE", "SEVERITY_NUMBER_TRACE2", "SEVERITY_NUMBER_TRACE3", "SEVERITY_NUMBER_TRACE4",
+		"SEVERITY_NUMBER_DEBUG", "SEVERITY_NUMBER_DEBUG2", "SEVERITY_NUMBER_DEBUG3", "SEVERITY_NUMBER_DEBUG4",
+		"SEVERITY_NUMBER_INFO", "SEVERITY_NUMBER_INFO2", "SEVERITY_NUMBER_INFO3", "SEVERITY_NUMBER_INFO4",
+		"SEVERITY_NUMBER_WARN", "SEVERITY_NUMBER_WARN2", "SEVERITY_NUMBER_WARN3", "SEVERITY_NUMBER_WARN4",
+		"SEVERITY_NUMBER_ERROR", "SEVERITY_NUMBER_ERROR2", "SEVERITY_NUMBER_ERROR3", "SEVERITY_NUMBER_ERROR4",
+		"SEVERITY_NUMBER_FATAL", "SEVERITY_NUMBER_FATAL2", "SEVERITY_NUMBER_FATAL3", "SEVERITY_NUMBER_FATAL4",
+	}
+)
+
+// avroEncoder encodes the values of a schema into message values: an object container file embedding the
+// schema, or the Confluent wire format prefixing the values with the ID of the schema when
+// schema_registry_url is set.
+type avroEncoder struct {
+	codec    *avro.Codec
+	registry *avro.SchemaRegistry
+}
+
+func newAvroEncoder(schema string, registry *avro.SchemaRegistry) avroEncoder {
+	return avroEncoder{codec: avro.MustNewCodec(schema), registry: registry}
+}
+
+func (e avroEncoder) encode(config *Config, value any) ([]byte, error) {
+	if config.SchemaRegistryURL == "" {
+		return e.codec.OCFFromNative([]any{value})
+	}
+	id, err := e.registry.ID(config.SchemaRegistryURL, e.subject(config), e.codec.Schema())
+	if err != nil {
+		return nil, err
+	}
+	payload, err := e.codec.BinaryFromNative(nil, value)
+	if err != nil {
+		return nil, err
+	}
+	return avro.WireFormat(id, payload), nil
+}
+
+// subject returns the subject the schema is registered under with subject_name_strategy.
+func (e avroEncoder) subject(config *Config) string {
+	switch config.SubjectNameStrategy {
+	case subjectNameStrategyRecordName:
+		return e.codec.Name()
+	case subjectNameStrategyTopicRecordName:
+		return config.Topic + "-" + e.codec.Name()
+	default:
+		return config.Topic + "-value"
+	}
+}
+
+// avroTracesMarshaler produces the traces as Avro TracesData records. It shares the keys and the splitting
+// of the otlp encodings, the traces being marshaled by avroTracesEncoder instead of a pdata marshaler.
+type avroTracesMarshaler struct {
+	encoder avroEncoder
+}
+
+func newAvroTracesMarshaler(registry *avro.SchemaRegistry) avroTracesMarshaler {
+	return avroTracesMarshaler{encoder: newAvroEncoder(avroTracesSchema, registry)}
+}
+
+func (a avroTracesMarshaler) Marshal(td ptrace.Traces, config *Config) ([]*sarama.ProducerMessage, error) {
+	return pdataTracesMarshaler{
+		marshaler: avroTracesEncoder{encoder: a.encoder, config: config},
+		encoding:  a.Encoding(),
+	}.Marshal(td, config)
+}
+
+func (a avroTracesMarshaler) Encoding() string {
+	return "avro_traces"
+}
+
+// avroTracesEncoder is the ptrace.Marshaler of the avro_traces encoding for config.
+type avroTracesEncoder struct {
+	encoder avroEncoder
+	config  *Config
+}
+
+func (e avroTracesEncoder) MarshalTraces(td ptrace.Traces) ([]byte, error) {
+	return e.encoder.encode(e.config, avroTracesData(td))
+}
+
+// avroMetricsMarshaler produces every resource of the metrics as its own Avro MetricsData record, split to fit
+// max_message_bytes. It shares the per_datapoint granularity, the keys and the splitting of the otlp encodings,
+// the metrics being marshaled by avroMetricsEncoder instead of a pdata marshaler.
+type avroMetricsMarshaler struct {
+	encoder avroEncoder
+}
+
+func newAvroMetricsMarshaler(registry *avro.SchemaRegistry) avroMetricsMarshaler {
+	return avroMetricsMarshaler{
+		encoder: newAvroEncoder(avroMetricsSchema, registry),
+	}
+}
+
+func (a avroMetricsMarshaler) Marshal(md pmetric.Metrics, config *Config) ([]*sarama.ProducerMessage, error) {
+	p := pdataMetricsMarshaler{
+		marshaler: avroMetricsEncoder{encoder: a.encoder, config: config},
+		encoding:  a.Encoding(),
+	}
+	md = explicitHistograms(md, config.ExponentialHistograms)
+	if config.MetricsGranularity == metricsGranularityPerDataPoint {
+		return p.marshalPerDataPoint(md, config)
+	}
+	key := resourcePartitionKey(config)
+	var messages []*sarama.ProducerMessage
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		single := pmetric.NewMetrics()
+		rm.CopyTo(single.ResourceMetrics().AppendEmpty())
+		var resourceKey sarama.Encoder
+		if key != nil {
+			resourceKey = key(rm.Resource())
+		}
+		resourceMessages, err := p.marshalWithKey(single, config, resourceKey)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, resourceMessages...)
+	}
+	return messages, nil
+}
+
+func (a avroMetricsMarshaler) Encoding() string {
+	return "avro_metrics"
+}
+
+// avroMetricsEncoder is the pmetric.Marshaler of the avro_metrics encoding for config.
+type avroMetricsEncoder struct {
+	encoder avroEncoder
+	config  *Config
+}
+
+func (e avroMetricsEncoder) MarshalMetrics(md pmetric.Metrics) ([]byte, error) {
+	return e.encoder.encode(e.config, avroMetricsData(md))
+}
+
+// avroLogsMarshaler produces the logs as Avro LogsData records. It shares the keys and the splitting of the otlp
+// encodings, the logs being marshaled by avroLogsEncoder instead of a pdata marshaler.
+type avroLogsMarshaler struct {
+	encoder avroEncoder
+}
+
+func newAvroLogsMarshaler(registry *avro.SchemaRegistry) avroLogsMarshaler {
+	return avroLogsMarshaler{encoder: newAvroEncoder(avroLogsSchema, registry)}
+}
+
+func (a avroLogsMarshaler) Marshal(ld plog.Logs, config *Config) ([]*sarama.ProducerMessage, error) {
+	return pdataLogsMarshaler{
+		marshaler: avroLogsEncoder{encoder: a.encoder, config: config},
+		encoding:  a.Encoding(),
+	}.Marshal(ld, config)
+}
+
+func (a avroLogsMarshaler) Encoding() string {
+	return "avro_logs"
+}
+
+// avroLogsEncoder is the plog.Marshaler of the avro_logs encoding for config.
+type avroLogsEncoder struct {
+	encoder avroEncoder
+	config  *Config
+}
+
+func (e avroLogsEncoder) MarshalLogs(ld plog.Logs) ([]byte, error) {
+	return e.encoder.encode(e.config, avroLogsData(ld))
+}
+
+func newSchemaRegistry() *avro.SchemaRegistry {
+	return avro.NewSchemaRegistry(&http.Client{Timeout: schemaRegistryTimeout})
+}
+
+func avroTracesData(td ptrace.Traces) map[string]any {
+	resourceSpans := make([]any, 0, td.ResourceSpans().Len())
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		scopeSpans := make([]any, 0, rs.ScopeSpans().Len())
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			spans := make([]any, 0, ss.Spans().Len())
+			for k := 0; k < ss.Spans().Len(); k++ {
+				spans = append(spans, avroSpan(ss.Spans().At(k)))
+			}
+			scopeSpans = append(scopeSpans, map[string]any{
+				"scope":      avroScope(ss.Scope()),
+				"spans":      spans,
+				"schema_url": ss.SchemaUrl(),
+			})
+		}
+		resourceSpans = append(resourceSpans, map[string]any{
+			"resource":    avroResource(rs.Resource()),
+			"scope_spans": scopeSpans,
+			"schema_url":  rs.SchemaUrl(),
+		})
+	}
+	return map[string]any{"resource_spans": resourceSpans}
+}
+
+func avroSpan(span ptrace.Span) map[string]any {
+	events := make([]any, 0, span.Events().Len())
+	for i := 0; i < span.Events().Len(); i++ {
+		event := span.Events().At(i)
+		events = append(events, map[string]any{
+			"time_unix_nano":           int64(event.Timestamp()),
+			"name":                     event.Name(),
+			"attributes":               avroAttributes(event.Attributes()),
+			"dropped_attributes_count": event.DroppedAttributesCount(),
+		})
+	}
+	links := make([]any, 0, span.Links().Len())
+	for i := 0; i < span.Links().Len(); i++ {
+		link := span.Links().At(i)
+		links = append(links, map[string]any{
+			"trace_id":                 avroTraceID(link.TraceID()),
+			"span_id":                  avroSpanID(link.SpanID()),
+			"trace_state":              link.TraceState().AsRaw(),
+			"attributes":               avroAttributes(link.Attributes()),
+			"dropped_attributes_count": link.DroppedAttributesCount(),
+		})
+	}
+	return map[string]any{
+		"trace_id":                 avroTraceID(span.TraceID()),
+		"span_id":                  avroSpanID(span.SpanID()),
+		"trace_state":              span.TraceState().AsRaw(),
+		"parent_span_id":           avroSpanID(span.ParentSpanID()),
+		"name":                     span.Name(),
+		"kind":                     avroSymbol(avroSpanKinds, int(span.Kind())),
+		"start_time_unix_nano":     int64(span.StartTimestamp()),
+		"end_time_unix_nano":       int64(span.EndTimestamp()),
+		"attributes":               avroAttributes(span.Attributes()),
+		"dropped_attributes_count": span.DroppedAttributesCount(),
+		"events":                   events,
+		"dropped_events_count":     span.DroppedEventsCount(),
+		"links":                    links,
+		"dropped_links_count":      span.DroppedLinksCount(),
+		"status": map[string]any{
+			"message": span.Status().Message(),
+			"code":    avroSymbol(avroStatusCodes, int(span.Status().Code())),
+		},
+	}
+}
+
+// avroSymbol returns the symbol of the OTLP enum value i, the unknown values being unspecified.
+func avroSymbol(symbols []string, i int) string {
+	if i < 0 || i >= len(symbols) {
+		return symbols[0]
+	}
+	return symbols[i]
+}
+
+// avroTraceID and avroSpanID return the bytes of the ids, which are empty for the empty ids like in OTLP.
+func avroTraceID(id pcommon.TraceID) []byte {
+	if id.IsEmpty() {
+		return []byte{}
+	}
+	return id[:]
+}
+
+func avroSpanID(id pcommon.SpanID) []byte {
+	if id.IsEmpty() {
+		return []byte{}
+	}
+	return id[:]
+}
+
+func avroResource(resource pcommon.Resource) map[string]any {
+	return map[string]any{
+		"attributes":               avroAttributes(resource.Attributes()),
+		"dropped_attributes_count": resource.DroppedAttributesCount(),
+	}
+}
+
+func avroScope(scope pcommon.InstrumentationScope) map[string]any {
+	return map[string]any{
+		"name":                     scope.Name(),
+		"version":                  scope.Version(),
+		"attributes":               avroAttributes(scope.Attributes()),
+		"dropped_attributes_count": scope.DroppedAttributesCount(),
+	}
+}
+
+func avroAttributes(attributes pcommon.Map) []any {
+	keyValues := make([]any, 0, attributes.Len())
+	attributes.Range(func(k string, v pcommon.Value) bool {
+		keyValues = append(keyValues, map[string]any{"key": k, "value": avroAnyValue(v)})
+		return true
+	})
+	return keyValues
+}
+
+// avroAnyValue returns the AnyValue union of v, holding the branch of its type.
+func avroAnyValue(v pcommon.Value) any {
+	switch v.Type() {
+	case pcommon.ValueTypeStr:
+		return map[string]any{"string": v.Str()}
+	case pcommon.ValueTypeBool:
+		return map[string]any{"boolean": v.Bool()}
+	case pcommon.ValueTypeInt:
+		return map[string]any{"long": v.Int()}
+	case pcommon.ValueTypeDouble:
+		return map[string]any{"double": v.Double()}
+	case pcommon.ValueTypeBytes:
+		return map[string]any{"bytes": v.Bytes().AsRaw()}
+	case pcommon.ValueTypeSlice:
+		values := make([]any, 0, v.Slice().Len())
+		for i := 0; i < v.Slice().Len(); i++ {
+			values = append(values, avroAnyValue(v.Slice().At(i)))
+		}
+		return map[string]any{avroArrayValueBranch: map[string]any{"values": values}}
+	case pcommon.ValueTypeMap:
+		return map[string]any{avroKeyValueListBranch: map[string]any{"values": avroAttributes(v.Map())}}
+	}
+	return nil
+}
+
+func avroMetricsData(md pmetric.Metrics) map[string]any {
+	resourceMetrics := make([]any, 0, md.ResourceMetrics().Len())
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		scopeMetrics := make([]any, 0, rm.ScopeMetrics().Len())
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			sm := rm.ScopeMetrics().At(j)
+			metrics := make([]any, 0, sm.Metrics().Len())
+			for k := 0; k < sm.Metrics().Len(); k++ {
+				metric := sm.Metrics().At(k)
+				metrics = append(metrics, map[string]any{
+					"name":        metric.Name(),
+					"description": metric.Description(),
+					"unit":        metric.Unit(),
+					"data":        avroMetricData(metric),
+				})
+			}
+			scopeMetrics = append(scopeMetrics, map[string]any{
+				"scope":      avroScope(sm.Scope()),
+				"metrics":    metrics,
+				"schema_url": sm.SchemaUrl(),
+			})
+		}
+		resourceMetrics = append(resourceMetrics, map[string]any{
+			"resource":      avroResource(rm.Resource()),
+			"scope_metrics": scopeMetrics,
+			"schema_url":    rm.SchemaUrl(),
+		})
+	}
+	return map[string]any{"resource_metrics": resourceMetrics}
+}
+
+// avroMetricData returns the data union of metric, holding the branch of its type.
+func avroMetricData(metric pmetric.Metric) any {
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		return map[string]any{avroGaugeBranch: map[string]any{
+			"data_points": avroNumberDataPoints(metric.Gauge().DataPoints()),
+		}}
+	case pmetric.MetricTypeSum:
+		return map[string]any{avroSumBranch: map[string]any{
+			"data_points":             avroNumberDataPoints(metric.Sum().DataPoints()),
+			"aggregation_temporality": avroSymbol(avroTemporalities, int(metric.Sum().AggregationTemporality())),
+			"is_monotonic":            metric.Sum().IsMonotonic(),
+		}}
+	case pmetric.MetricTypeHistogram:
+		return map[string]any{avroHistogramBranch: map[string]any{
+			"data_points":             avroHistogramDataPoints(metric.Histogram().DataPoints()),
+			"aggregation_temporality": avroSymbol(avroTemporalities, int(metric.Histogram().AggregationTemporality())),
+		}}
+	case pmetric.MetricTypeExponentialHistogram:
+		return map[string]any{avroExponentialHistogramBranch: map[string]any{
+			"data_points":             avroExponentialHistogramDataPoints(metric.ExponentialHistogram().DataPoints()),
+			"aggregation_temporality": avroSymbol(avroTemporalities, int(metric.ExponentialHistogram().AggregationTemporality())),
+		}}
+	case pmetric.MetricTypeSummary:
+		return map[string]any{avroSummaryBranch: map[string]any{
+			"data_points": avroSummaryDataPoints(metric.Summary().DataPoints()),
+		}}
+	}
+	return nil
+}
+
+func avroNumberDataPoints(dps pmetric.NumberDataPointSlice) []any {
+	points := make([]any, 0, dps.Len())
+	for i := 0; i < dps.Len(); i++ {
+		dp := dps.At(i)
+		var value any
+		switch dp.ValueType() {
+		case pmetric.NumberDataPointValueTypeDouble:
+			value = map[string]any{"double": dp.DoubleValue()}
+		case pmetric.NumberDataPointValueTypeInt:
+			value = map[string]any{"long": dp.IntValue()}
+		}
+		points = append(points, map[string]any{
+			"attributes":           avroAttributes(dp.Attributes()),
+			"start_time_unix_nano": int64(dp.StartTimestamp()),
+			"time_unix_nano":       int64(dp.Timestamp()),
+			"value":                value,
+			"exemplars":            avroExemplars(dp.Exemplars()),
+			"flags":                uint32(dp.Flags()),
+		})
+	}
+	return points
+}
+
+func avroHistogramDataPoints(dps pmetric.HistogramDataPointSlice) []any {
+	points := make([]any, 0, dps.Len())
+	for i := 0; i < dps.Len(); i++ {
+		dp := dps.At(i)
+		explicitBounds := make([]any, 0, dp.ExplicitBounds().Len())
+		for j := 0; j < dp.ExplicitBounds().Len(); j++ {
+			explicitBounds = append(explicitBounds, dp.ExplicitBounds().At(j))
+		}
+		points = append(points, map[string]any{
+			"attributes":           avroAttributes(dp.Attributes()),
+			"start_time_unix_nano": int64(dp.StartTimestamp()),
+			"time_unix_nano":       int64(dp.Timestamp()),
+			"count":                int64(dp.Count()),
+			"sum":                  avroOptionalDouble(dp.HasSum(), dp.Sum()),
+			"bucket_counts":        avroBucketCounts(dp.BucketCounts()),
+			"explicit_bounds":      explicitBounds,
+			"exemplars":            avroExemplars(dp.Exemplars()),
+			"flags":                uint32(dp.Flags()),
+			"min":                  avroOptionalDouble(dp.HasMin(), dp.Min()),
+			"max":                  avroOptionalDouble(dp.HasMax(), dp.Max()),
+		})
+	}
+	return points
+}
+
+func avroExponentialHistogramDataPoints(dps pmetric.ExponentialHistogramDataPointSlice) []any {
+	points := make([]any, 0, dps.Len())
+	for i := 0; i < dps.Len(); i++ {
+		dp := dps.At(i)
+		points = append(points, map[string]any{
+			"attributes":           avroAttributes(dp.Attributes()),
+			"start_time_unix_nano": int64(dp.StartTimestamp()),
+			"time_unix_nano":       int64(dp.Timestamp()),
+			"count":                int64(dp.Count()),
+			"sum":                  avroOptionalDouble(dp.HasSum(), dp.Sum()),
+			"scale":                dp.Scale(),
+			"zero_count":           int64(dp.ZeroCount()),
+			"positive":             avroBuckets(dp.Positive()),
+			"negative":             avroBuckets(dp.Negative()),
+			"flags":                uint32(dp.Flags()),
+			"exemplars":            avroExemplars(dp.Exemplars()),
+			"min":                  avroOptionalDouble(dp.HasMin(), dp.Min()),
+			"max":                  avroOptionalDouble(dp.HasMax(), dp.Max()),
+		})
+	}
+	return points
+}
+
+func avroSummaryDataPoints(dps pmetric.SummaryDataPointSlice) []any {
+	points := make([]any, 0, dps.Len())
+	for i := 0; i < dps.Len(); i++ {
+		dp := dps.At(i)
+		quantileValues := make([]any, 0, dp.QuantileValues().Len())
+		for j := 0; j < dp.QuantileValues().Len(); j++ {
+			quantile := dp.QuantileValues().At(j)
+			quantileValues = append(quantileValues, map[string]any{
+				"quantile": quantile.Quantile(),
+				"value":    quantile.Value(),
+			})
+		}
+		points = append(points, map[string]any{
+			"attributes":           avroAttributes(dp.Attributes()),
+			"start_time_unix_nano": int64(dp.StartTimestamp()),
+			"time_unix_nano":       int64(dp.Timestamp()),
+			"count":                int64(dp.Count()),
+			"sum":                  dp.Sum(),
+			"quantile_values":      quantileValues,
+			"flags":                uint32(dp.Flags()),
+		})
+	}
+	return points
+}
+
+func avroExemplars(exemplars pmetric.ExemplarSlice) []any {
+	values := make([]any, 0, exemplars.Len())
+	for i := 0; i < exemplars.Len(); i++ {
+		exemplar := exemplars.At(i)
+		var value any
+		switch exemplar.ValueType() {
+		case pmetric.ExemplarValueTypeDouble:
+			value = map[string]any{"double": exemplar.DoubleValue()}
+		case pmetric.ExemplarValueTypeInt:
+			value = map[string]any{"long": exemplar.IntValue()}
+		}
+		values = append(values, map[string]any{
+			"filtered_attributes": avroAttributes(exemplar.FilteredAttributes()),
+			"time_unix_nano":      int64(exemplar.Timestamp()),
+			"value":               value,
+			"span_id":             avroSpanID(exemplar.SpanID()),
+			"trace_id":            avroTraceID(exemplar.TraceID()),
+		})
+	}
+	return values
+}
+
+func avroBuckets(buckets pmetric.ExponentialHistogramDataPointBuckets) map[string]any {
+	return map[string]any{
+		"offset":        buckets.Offset(),
+		"bucket_counts": avroBucketCounts(buckets.BucketCounts()),
+	}
+}
+
+func avroBucketCounts(counts pcommon.UInt64Slice) []any {
+	values := make([]any, 0, counts.Len())
+	for i := 0; i < counts.Len(); i++ {
+		values = append(values, int64(counts.At(i)))
+	}
+	return values
+}
+
+// avroOptionalDouble returns the union of an optional double, null if it is not set.
+func avroOptionalDouble(ok bool, value float64) any {
+	if !ok {
+		return nil
+	}
+	return map[string]any{"double": value}
+}
+
+func avroLogsData(ld plog.Logs) map[string]any {
+	resourceLogs := make([]any, 0, ld.ResourceLogs().Len())
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		scopeLogs := make([]any, 0, rl.ScopeLogs().Len())
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			logRecords := make([]any, 0, sl.LogRecords().Len())
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				logRecords = append(logRecords, avroLogRecord(sl.LogRecords().At(k)))
+			}
+			scopeLogs = append(scopeLogs, map[string]any{
+				"scope":       avroScope(sl.Scope()),
+				"log_records": logRecords,
+				"schema_url":  sl.SchemaUrl(),
+			})
+		}
+		resourceLogs = append(resourceLogs, map[string]any{
+			"resource":   avroResource(rl.Resource()),
+			"scope_logs": scopeLogs,
+			"schema_url": rl.SchemaUrl(),
+		})
+	}
+	return map[string]any{"resource_logs": resourceLogs}
+}
+
+func avroLogRecord(lr plog.LogRecord) map[string]any {
+	return map[string]any{
+		"time_unix_nano":           int64(lr.Timestamp()),
+		"observed_time_unix_nano":  int64(lr.ObservedTimestamp()),
+		"severity_number":          avroSymbol(avroSeverities, int(lr.SeverityNumber())),
+		"severity_text":            lr.SeverityText(),
+		"body":                     avroAnyValue(lr.Body()),
+		"attributes":               avroAttributes(lr.Attributes()),
+		"dropped_attributes_count": lr.DroppedAttributesCount(),
+		"flags":                    int64(lr.Flags()),
+		"trace_id":                 avroTraceID(lr.TraceID()),
+		"span_id":                  avroSpanID(lr.SpanID()),
+	}
+}
@@ -0,0 +1,476 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/avro"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func testAvroTraces() ptrace.Traces {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.SetSchemaUrl("https://opentelemetry.io/schemas/1.6.1")
+	rs.Resource().Attributes().PutStr("service.name", "checkout")
+	rs.Resource().SetDroppedAttributesCount(1)
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("io.opentelemetry.contrib")
+	ss.Scope().SetVersion("1.0.0")
+	ss.Scope().Attributes().PutBool("scope.enabled", true)
+
+	span := ss.Spans().AppendEmpty()
+	span.SetTraceID([16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	span.SetSpanID([8]byte{1, 2, 3, 4, 5, 6, 7, 8})
+	span.SetParentSpanID([8]byte{8, 7, 6, 5, 4, 3, 2, 1})
+	span.TraceState().FromRaw("vendor=value")
+	span.SetName("GET /cart")
+	span.SetKind(ptrace.SpanKindServer)
+	span.SetStartTimestamp(1_000_000_000)
+	span.SetEndTimestamp(2_000_000_000)
+	span.Attributes().PutInt("http.status_code", 200)
+	span.Attributes().PutDouble("ratio", 0.5)
+	span.Attributes().PutEmptyBytes("raw").FromRaw([]byte{0, 1})
+	span.Attributes().PutEmpty("empty")
+	slice := span.Attributes().PutEmptySlice("tags")
+	slice.AppendEmpty().SetStr("a")
+	slice.AppendEmpty().SetEmptySlice().AppendEmpty().SetInt(1)
+	kvlist := span.Attributes().PutEmptyMap("nested")
+	kvlist.PutStr("key", "value")
+	kvlist.PutEmptyMap("deeper").PutBool("ok", true)
+	span.SetDroppedAttributesCount(2)
+	event := span.Events().AppendEmpty()
+	event.SetTimestamp(1_500_000_000)
+	event.SetName("exception")
+	event.Attributes().PutStr("exception.type", "timeout")
+	event.SetDroppedAttributesCount(3)
+	span.SetDroppedEventsCount(4)
+	link := span.Links().AppendEmpty()
+	link.SetTraceID([16]byte{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1})
+	link.SetSpanID([8]byte{1, 1, 1, 1, 1, 1, 1, 1})
+	link.TraceState().FromRaw("link=state")
+	link.Attributes().PutStr("link.kind", "follows_from")
+	link.SetDroppedAttributesCount(5)
+	span.SetDroppedLinksCount(6)
+	span.Status().SetCode(ptrace.StatusCodeError)
+	span.Status().SetMessage("deadline exceeded")
+
+	ss.Spans().AppendEmpty().SetName("root without ids")
+	return td
+}
+
+// decodedAvroTraces returns the traces of a decoded TracesData record.
+func decodedAvroTraces(t *testing.T, value any) ptrace.Traces {
+	td := ptrace.NewTraces()
+	require.NoError(t, tracesFromAvro(value, td))
+	return td
+}
+
+func TestAvroTracesMarshaler_embeddedSchema(t *testing.T) {
+	td := testAvroTraces()
+	m := tracesMarshalers()["avro_traces"]
+	messages, err := m.Marshal(td, &Config{Topic: "spans"})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "spans", messages[0].Topic)
+
+	value, err := messages[0].Value.Encode()
+	require.NoError(t, err)
+	codec, values, err := avro.NativeFromOCF(value)
+	require.NoError(t, err)
+	assert.Equal(t, avro.MustNewCodec(avroTracesSchema).Schema(), codec.Schema())
+	require.Len(t, values, 1)
+	assert.Equal(t, td, decodedAvroTraces(t, values[0]))
+}
+
+func TestAvroTracesMarshaler_schemaRegistry(t *testing.T) {
+	var subjects []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subjects = append(subjects, strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/subjects/"), "/versions"))
+		_, _ = w.Write([]byte(`{"id": 7}`))
+	}))
+	defer server.Close()
+
+	td := testAvroTraces()
+	m := newAvroTracesMarshaler(avro.NewSchemaRegistry(server.Client()))
+	config := &Config{Topic: "spans", SchemaRegistryURL: server.URL}
+	for i := 0; i < 2; i++ {
+		messages, err := m.Marshal(td, config)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+
+		value, err := messages[0].Value.Encode()
+		require.NoError(t, err)
+		require.Greater(t, len(value), 5)
+		assert.Equal(t, byte(0), value[0])
+		assert.Equal(t, uint32(7), binary.BigEndian.Uint32(value[1:5]))
+		decoded, rest, err := avro.MustNewCodec(avroTracesSchema).NativeFromBinary(value[5:])
+		require.NoError(t, err)
+		assert.Empty(t, rest)
+		assert.Equal(t, td, decodedAvroTraces(t, decoded))
+	}
+	assert.Equal(t, []string{"spans-value"}, subjects, "the schema ID should be cached")
+}
+
+func TestAvroTracesMarshaler_schemaRegistryError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	m := newAvroTracesMarshaler(avro.NewSchemaRegistry(server.Client()))
+	_, err := m.Marshal(testAvroTraces(), &Config{Topic: "spans", SchemaRegistryURL: server.URL})
+	assert.ErrorContains(t, err, "registering subject spans-value returned 500")
+}
+
+func TestAvroMarshalers_subjectNameStrategy(t *testing.T) {
+	var subjects []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subjects = append(subjects, strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/subjects/"), "/versions"))
+		_, _ = w.Write([]byte(`{"id": 3}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		strategy string
+		expected []string
+	}{
+		{
+			strategy: "",
+			expected: []string{"telemetry-value", "telemetry-value", "telemetry-value"},
+		},
+		{
+			strategy: subjectNameStrategyTopicName,
+			expected: []string{"telemetry-value", "telemetry-value", "telemetry-value"},
+		},
+		{
+			strategy: subjectNameStrategyRecordName,
+			expected: []string{"opentelemetry.proto.trace.v1.TracesData", "opentelemetry.proto.metrics.v1.MetricsData", "opentelemetry.proto.logs.v1.LogsData"},
+		},
+		{
+			strategy: subjectNameStrategyTopicRecordName,
+			expected: []string{"telemetry-opentelemetry.proto.trace.v1.TracesData", "telemetry-opentelemetry.proto.metrics.v1.MetricsData", "telemetry-opentelemetry.proto.logs.v1.LogsData"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			subjects = nil
+			registry := avro.NewSchemaRegistry(server.Client())
+			config := &Config{Topic: "telemetry", SchemaRegistryURL: server.URL, SubjectNameStrategy: tt.strategy, Producer: Producer{MaxMessageBytes: 1000 * 1000}}
+			_, err := newAvroTracesMarshaler(registry).Marshal(testAvroTraces(), config)
+			require.NoError(t, err)
+			_, err = newAvroMetricsMarshaler(registry).Marshal(testAvroMetrics(pmetric.MetricTypeGauge), config)
+			require.NoError(t, err)
+			_, err = newAvroLogsMarshaler(registry).Marshal(testAvroLogs(), config)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, subjects)
+		})
+	}
+}
+
+func TestAvroTracesMarshaler_maxMessageBytes(t *testing.T) {
+	td := ptrace.NewTraces()
+	spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	for i := 0; i < 200; i++ {
+		span := spans.AppendEmpty()
+		span.SetName(strings.Repeat("span", 10))
+		span.SetTraceID([16]byte{byte(i + 1)})
+	}
+	config := &Config{Topic: "spans", Producer: Producer{MaxMessageBytes: 8000}}
+	m := tracesMarshalers()["avro_traces"]
+	messages, err := m.Marshal(td, config)
+	require.NoError(t, err)
+	assert.Greater(t, len(messages), 1)
+
+	total := 0
+	for _, message := range messages {
+		assert.LessOrEqual(t, message.ByteSize(2), config.Producer.MaxMessageBytes)
+		value, err := message.Value.Encode()
+		require.NoError(t, err)
+		_, values, err := avro.NativeFromOCF(value)
+		require.NoError(t, err)
+		total += decodedAvroTraces(t, values[0]).SpanCount()
+	}
+	assert.Equal(t, td.SpanCount(), total)
+
+	spans.At(0).SetName(strings.Repeat("span", 2000))
+	_, err = m.Marshal(td, config)
+	assert.ErrorIs(t, err, errSingleKafkaProducerMessageSizeOverMaxMsgByte)
+
+	config.NoSplit = true
+	messages, err = m.Marshal(td, config)
+	require.NoError(t, err)
+	assert.Len(t, messages, 1)
+}
+
+func testAvroMetrics(metricType pmetric.MetricType) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "checkout")
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("io.opentelemetry.contrib")
+	metric := sm.Metrics().AppendEmpty()
+	metric.SetName("requests")
+	metric.SetDescription("The requests")
+	metric.SetUnit("1")
+	switch metricType {
+	case pmetric.MetricTypeGauge:
+		dps := metric.SetEmptyGauge().DataPoints()
+		dp := dps.AppendEmpty()
+		dp.SetDoubleValue(1.5)
+		dp.SetTimestamp(2_000_000_000)
+		exemplar := dp.Exemplars().AppendEmpty()
+		exemplar.SetIntValue(3)
+		exemplar.SetTimestamp(1_500_000_000)
+		exemplar.SetTraceID([16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+		exemplar.SetSpanID([8]byte{1, 2, 3, 4, 5, 6, 7, 8})
+		exemplar.FilteredAttributes().PutStr("user", "alice")
+		dps.AppendEmpty().Attributes().PutStr("empty", "value")
+	case pmetric.MetricTypeSum:
+		sum := metric.SetEmptySum()
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+		sum.SetIsMonotonic(true)
+		dp := sum.DataPoints().AppendEmpty()
+		dp.SetIntValue(42)
+		dp.SetStartTimestamp(1_000_000_000)
+		dp.SetTimestamp(2_000_000_000)
+		dp.SetFlags(pmetric.DefaultDataPointFlags.WithNoRecordedValue(true))
+		dp.Attributes().PutStr("method", "GET")
+	case pmetric.MetricTypeHistogram:
+		histogram := metric.SetEmptyHistogram()
+		histogram.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+		dp := histogram.DataPoints().AppendEmpty()
+		dp.SetCount(6)
+		dp.SetSum(21)
+		dp.SetMin(0.5)
+		dp.SetMax(9)
+		dp.BucketCounts().FromRaw([]uint64{1, 2, 3})
+		dp.ExplicitBounds().FromRaw([]float64{1, 5})
+		dp.Exemplars().AppendEmpty().SetDoubleValue(0.5)
+		histogram.DataPoints().AppendEmpty().SetCount(0)
+	case pmetric.MetricTypeExponentialHistogram:
+		histogram := metric.SetEmptyExponentialHistogram()
+		histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+		dp := histogram.DataPoints().AppendEmpty()
+		dp.SetCount(7)
+		dp.SetSum(12.5)
+		dp.SetScale(-2)
+		dp.SetZeroCount(1)
+		dp.Positive().SetOffset(-1)
+		dp.Positive().BucketCounts().FromRaw([]uint64{2, 3})
+		dp.Negative().SetOffset(4)
+		dp.Negative().BucketCounts().FromRaw([]uint64{1})
+		dp.SetMax(8)
+	case pmetric.MetricTypeSummary:
+		dp := metric.SetEmptySummary().DataPoints().AppendEmpty()
+		dp.SetCount(10)
+		dp.SetSum(100)
+		quantile := dp.QuantileValues().AppendEmpty()
+		quantile.SetQuantile(0.99)
+		quantile.SetValue(42)
+	}
+	return md
+}
+
+// decodedAvroMetrics returns the metrics of a decoded MetricsData record.
+func decodedAvroMetrics(t *testing.T, value any) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	require.NoError(t, metricsFromAvro(value, md))
+	return md
+}
+
+func TestAvroMetricsMarshaler_metricTypes(t *testing.T) {
+	m := metricsMarshalers()["avro_metrics"]
+	for _, metricType := range []pmetric.MetricType{
+		pmetric.MetricTypeGauge,
+		pmetric.MetricTypeSum,
+		pmetric.MetricTypeHistogram,
+		pmetric.MetricTypeExponentialHistogram,
+		pmetric.MetricTypeSummary,
+		pmetric.MetricTypeEmpty,
+	} {
+		t.Run(metricType.String(), func(t *testing.T) {
+			md := testAvroMetrics(metricType)
+			messages, err := m.Marshal(md, &Config{Topic: "metrics"})
+			require.NoError(t, err)
+			require.Len(t, messages, 1)
+
+			value, err := messages[0].Value.Encode()
+			require.NoError(t, err)
+			_, values, err := avro.NativeFromOCF(value)
+			require.NoError(t, err)
+			require.Len(t, values, 1)
+			assert.Equal(t, md, decodedAvroMetrics(t, values[0]))
+		})
+	}
+}
+
+func TestAvroMetricsMarshaler_messagePerResource(t *testing.T) {
+	md := pmetric.NewMetrics()
+	for _, metricType := range []pmetric.MetricType{pmetric.MetricTypeGauge, pmetric.MetricTypeSum} {
+		testAvroMetrics(metricType).ResourceMetrics().MoveAndAppendTo(md.ResourceMetrics())
+	}
+	md.ResourceMetrics().At(1).Resource().Attributes().PutStr("tenant", "acme")
+
+	config := &Config{Topic: "metrics", PartitionKey: "resource_attribute:tenant"}
+	messages, err := metricsMarshalers()["avro_metrics"].Marshal(md, config)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Nil(t, messages[0].Key)
+	assert.Equal(t, sarama.StringEncoder("acme"), messages[1].Key)
+	for i, message := range messages {
+		value, err := message.Value.Encode()
+		require.NoError(t, err)
+		_, values, err := avro.NativeFromOCF(value)
+		require.NoError(t, err)
+		expected := pmetric.NewMetrics()
+		md.ResourceMetrics().At(i).CopyTo(expected.ResourceMetrics().AppendEmpty())
+		assert.Equal(t, expected, decodedAvroMetrics(t, values[0]))
+	}
+}
+
+func TestAvroMetricsMarshaler_maxMessageBytes(t *testing.T) {
+	md := pmetric.NewMetrics()
+	dps := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints()
+	for i := 0; i < 500; i++ {
+		dp := dps.AppendEmpty()
+		dp.SetIntValue(int64(i))
+		dp.Attributes().PutStr("series", strings.Repeat("s", 20))
+	}
+	config := &Config{Topic: "metrics", Producer: Producer{MaxMessageBytes: 10000}}
+	messages, err := metricsMarshalers()["avro_metrics"].Marshal(md, config)
+	require.NoError(t, err)
+	assert.Greater(t, len(messages), 1)
+
+	total := 0
+	for _, message := range messages {
+		assert.LessOrEqual(t, message.ByteSize(2), config.Producer.MaxMessageBytes)
+		value, err := message.Value.Encode()
+		require.NoError(t, err)
+		_, values, err := avro.NativeFromOCF(value)
+		require.NoError(t, err)
+		total += decodedAvroMetrics(t, values[0]).DataPointCount()
+	}
+	assert.Equal(t, md.DataPointCount(), total)
+}
+
+func TestAvroMetricsMarshaler_perDataPoint(t *testing.T) {
+	md := testAvroMetrics(pmetric.MetricTypeGauge)
+	config := &Config{Topic: "metrics", MetricsGranularity: metricsGranularityPerDataPoint}
+	messages, err := metricsMarshalers()["avro_metrics"].Marshal(md, config)
+	require.NoError(t, err)
+	assert.Len(t, messages, md.DataPointCount())
+	for _, message := range messages {
+		assert.NotNil(t, message.Key)
+	}
+}
+
+func testAvroLogs() plog.Logs {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.SetSchemaUrl("https://opentelemetry.io/schemas/1.6.1")
+	rl.Resource().Attributes().PutStr("service.name", "checkout")
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.Scope().SetName("io.opentelemetry.contrib")
+
+	lr := sl.LogRecords().AppendEmpty()
+	lr.SetTimestamp(1_000_000_000)
+	lr.SetObservedTimestamp(1_500_000_000)
+	lr.SetSeverityNumber(plog.SeverityNumberWarn2)
+	lr.SetSeverityText("WARNING")
+	body := lr.Body().SetEmptyMap()
+	body.PutStr("message", "cart not found")
+	body.PutInt("status", 404)
+	request := body.PutEmptyMap("request")
+	request.PutStr("method", "GET")
+	request.PutEmptySlice("ids").AppendEmpty().SetEmptyBytes().FromRaw([]byte{0, 1})
+	lr.Attributes().PutEmptyMap("http").PutStr("route", "/cart")
+	lr.SetDroppedAttributesCount(1)
+	lr.SetFlags(plog.DefaultLogRecordFlags.WithIsSampled(true))
+	lr.SetTraceID([16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	lr.SetSpanID([8]byte{1, 2, 3, 4, 5, 6, 7, 8})
+
+	sl.LogRecords().AppendEmpty().SetSeverityNumber(plog.SeverityNumberFatal4)
+	return ld
+}
+
+// decodedAvroLogs returns the logs of a decoded LogsData record.
+func decodedAvroLogs(t *testing.T, value any) plog.Logs {
+	ld := plog.NewLogs()
+	require.NoError(t, logsFromAvro(value, ld))
+	return ld
+}
+
+func TestAvroLogsMarshaler_mapBody(t *testing.T) {
+	ld := testAvroLogs()
+	messages, err := logsMarshalers()["avro_logs"].Marshal(ld, &Config{Topic: "logs"})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+
+	value, err := messages[0].Value.Encode()
+	require.NoError(t, err)
+	codec, values, err := avro.NativeFromOCF(value)
+	require.NoError(t, err)
+	assert.Equal(t, avro.MustNewCodec(avroLogsSchema).Schema(), codec.Schema())
+	require.Len(t, values, 1)
+	assert.Equal(t, ld, decodedAvroLogs(t, values[0]))
+}
+
+func TestAvroLogsMarshaler_schemaRegistry(t *testing.T) {
+	var subjects []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subjects = append(subjects, strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/subjects/"), "/versions"))
+		_, _ = w.Write([]byte(`{"id": 9}`))
+	}))
+	defer server.Close()
+
+	ld := testAvroLogs()
+	m := newAvroLogsMarshaler(avro.NewSchemaRegistry(server.Client()))
+	messages, err := m.Marshal(ld, &Config{Topic: "logs", SchemaRegistryURL: server.URL})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+
+	value, err := messages[0].Value.Encode()
+	require.NoError(t, err)
+	require.Greater(t, len(value), 5)
+	assert.Equal(t, uint32(9), binary.BigEndian.Uint32(value[1:5]))
+	decoded, rest, err := avro.MustNewCodec(avroLogsSchema).NativeFromBinary(value[5:])
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+	assert.Equal(t, ld, decodedAvroLogs(t, decoded))
+	assert.Equal(t, []string{"logs-value"}, subjects)
+}
+
+func TestAvroLogsMarshaler_maxMessageBytes(t *testing.T) {
+	ld := plog.NewLogs()
+	records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for i := 0; i < 200; i++ {
+		records.AppendEmpty().Body().SetStr(strings.Repeat("log", 20))
+	}
+	config := &Config{Topic: "logs", Producer: Producer{MaxMessageBytes: 8000}}
+	messages, err := logsMarshalers()["avro_logs"].Marshal(ld, config)
+	require.NoError(t, err)
+	assert.Greater(t, len(messages), 1)
+
+	total := 0
+	for _, message := range messages {
+		assert.LessOrEqual(t, message.ByteSize(2), config.Producer.MaxMessageBytes)
+		value, err := message.Value.Encode()
+		require.NoError(t, err)
+		_, values, err := avro.NativeFromOCF(value)
+		require.NoError(t, err)
+		total += decodedAvroLogs(t, values[0]).LogRecordCount()
+	}
+	assert.Equal(t, ld.LogRecordCount(), total)
+}
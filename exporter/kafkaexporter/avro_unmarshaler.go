@@ -0,0 +1,513 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/avro"
+)
+
+var (
+	errNotAvro          = errors.New("avro: not an object container file nor in the Confluent wire format")
+	errNoSchemaRegistry = errors.New("avro: the message is in the Confluent wire format but no schema registry is set")
+)
+
+// avroOCFMagic starts the Avro object container files.
+var avroOCFMagic = []byte{'O', 'b', 'j', 1}
+
+// AvroUnmarshaler unmarshals the messages of the avro_traces, avro_metrics and avro_logs encodings, so that
+// the Kafka receiver can consume them: the object container files embedding their schema, and the values of
+// the Confluent wire format, whose schema is fetched by its ID from the schema registry. The fields missing
+// from the schema of a message are left empty.
+//
+// The schemas of the IDs are fetched once and kept for as long as the unmarshaler is used, so that the
+// messages of the known IDs are still unmarshaled while the schema registry is unavailable.
+type AvroUnmarshaler struct {
+	registryURL string
+	registry    *avro.SchemaRegistry
+
+	mu     sync.Mutex
+	codecs map[int]*avro.Codec
+}
+
+// NewAvroUnmarshaler returns an unmarshaler fetching the schemas from the Confluent compatible schema registry
+// at schemaRegistryURL. If it is empty, only the object container files are unmarshaled.
+func NewAvroUnmarshaler(schemaRegistryURL string) *AvroUnmarshaler {
+	return &AvroUnmarshaler{
+		registryURL: schemaRegistryURL,
+		registry:    newSchemaRegistry(),
+		codecs:      map[int]*avro.Codec{},
+	}
+}
+
+// UnmarshalTraces unmarshals the avro_traces message buf.
+func (u *AvroUnmarshaler) UnmarshalTraces(buf []byte) (ptrace.Traces, error) {
+	td := ptrace.NewTraces()
+	values, err := u.values(buf)
+	if err != nil {
+		return td, err
+	}
+	for _, value := range values {
+		if err = tracesFromAvro(value, td); err != nil {
+			return ptrace.NewTraces(), err
+		}
+	}
+	return td, nil
+}
+
+// UnmarshalMetrics unmarshals the avro_metrics message buf.
+func (u *AvroUnmarshaler) UnmarshalMetrics(buf []byte) (pmetric.Metrics, error) {
+	md := pmetric.NewMetrics()
+	values, err := u.values(buf)
+	if err != nil {
+		return md, err
+	}
+	for _, value := range values {
+		if err = metricsFromAvro(value, md); err != nil {
+			return pmetric.NewMetrics(), err
+		}
+	}
+	return md, nil
+}
+
+// UnmarshalLogs unmarshals the avro_logs message buf.
+func (u *AvroUnmarshaler) UnmarshalLogs(buf []byte) (plog.Logs, error) {
+	ld := plog.NewLogs()
+	values, err := u.values(buf)
+	if err != nil {
+		return ld, err
+	}
+	for _, value := range values {
+		if err = logsFromAvro(value, ld); err != nil {
+			return plog.NewLogs(), err
+		}
+	}
+	return ld, nil
+}
+
+// values returns the records of buf, an object container file or a value in the Confluent wire format.
+func (u *AvroUnmarshaler) values(buf []byte) ([]any, error) {
+	if bytes.HasPrefix(buf, avroOCFMagic) {
+		_, values, err := avro.NativeFromOCF(buf)
+		return values, err
+	}
+	id, payload, ok := avro.FromWireFormat(buf)
+	if !ok {
+		return nil, errNotAvro
+	}
+	codec, err := u.codec(id)
+	if err != nil {
+		return nil, err
+	}
+	value, rest, err := codec.NativeFromBinary(payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("avro: %d bytes after the record of schema %d", len(rest), id)
+	}
+	return []any{value}, nil
+}
+
+// codec returns the codec of the schema id, fetching the schema from the registry the first time. The lock
+// is not held while fetching, so that the known IDs are not blocked by the requests to the registry.
+func (u *AvroUnmarshaler) codec(id int) (*avro.Codec, error) {
+	u.mu.Lock()
+	codec, ok := u.codecs[id]
+	u.mu.Unlock()
+	if ok {
+		return codec, nil
+	}
+	if u.registryURL == "" {
+		return nil, errNoSchemaRegistry
+	}
+	schema, err := u.registry.Schema(u.registryURL, id)
+	if err != nil {
+		return nil, err
+	}
+	if codec, err = avro.NewCodec(schema); err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+	u.mu.Lock()
+	u.codecs[id] = codec
+	u.mu.Unlock()
+	return codec, nil
+}
+
+func tracesFromAvro(value any, td ptrace.Traces) error {
+	record, ok := value.(map[string]any)
+	if !ok || record["resource_spans"] == nil {
+		return fmt.Errorf("avro: the record is not a TracesData")
+	}
+	for _, rsValue := range avroArray(record["resource_spans"]) {
+		rsRecord := avroRecord(rsValue)
+		rs := td.ResourceSpans().AppendEmpty()
+		resourceFromAvro(rsRecord["resource"], rs.Resource())
+		rs.SetSchemaUrl(avroString(rsRecord["schema_url"]))
+		for _, ssValue := range avroArray(rsRecord["scope_spans"]) {
+			ssRecord := avroRecord(ssValue)
+			ss := rs.ScopeSpans().AppendEmpty()
+			scopeFromAvro(ssRecord["scope"], ss.Scope())
+			ss.SetSchemaUrl(avroString(ssRecord["schema_url"]))
+			for _, spanValue := range avroArray(ssRecord["spans"]) {
+				spanFromAvro(avroRecord(spanValue), ss.Spans().AppendEmpty())
+			}
+		}
+	}
+	return nil
+}
+
+func spanFromAvro(record map[string]any, span ptrace.Span) {
+	span.SetTraceID(traceIDFromAvro(record["trace_id"]))
+	span.SetSpanID(spanIDFromAvro(record["span_id"]))
+	span.TraceState().FromRaw(avroString(record["trace_state"]))
+	span.SetParentSpanID(spanIDFromAvro(record["parent_span_id"]))
+	span.SetName(avroString(record["name"]))
+	span.SetKind(ptrace.SpanKind(avroSymbolIndex(avroSpanKinds, record["kind"])))
+	span.SetStartTimestamp(pcommon.Timestamp(avroLong(record["start_time_unix_nano"])))
+	span.SetEndTimestamp(pcommon.Timestamp(avroLong(record["end_time_unix_nano"])))
+	attributesFromAvro(record["attributes"], span.Attributes())
+	span.SetDroppedAttributesCount(uint32(avroLong(record["dropped_attributes_count"])))
+	for _, eventValue := range avroArray(record["events"]) {
+		eventRecord := avroRecord(eventValue)
+		event := span.Events().AppendEmpty()
+		event.SetTimestamp(pcommon.Timestamp(avroLong(eventRecord["time_unix_nano"])))
+		event.SetName(avroString(eventRecord["name"]))
+		attributesFromAvro(eventRecord["attributes"], event.Attributes())
+		event.SetDroppedAttributesCount(uint32(avroLong(eventRecord["dropped_attributes_count"])))
+	}
+	span.SetDroppedEventsCount(uint32(avroLong(record["dropped_events_count"])))
+	for _, linkValue := range avroArray(record["links"]) {
+		linkRecord := avroRecord(linkValue)
+		link := span.Links().AppendEmpty()
+		link.SetTraceID(traceIDFromAvro(linkRecord["trace_id"]))
+		link.SetSpanID(spanIDFromAvro(linkRecord["span_id"]))
+		link.TraceState().FromRaw(avroString(linkRecord["trace_state"]))
+		attributesFromAvro(linkRecord["attributes"], link.Attributes())
+		link.SetDroppedAttributesCount(uint32(avroLong(linkRecord["dropped_attributes_count"])))
+	}
+	span.SetDroppedLinksCount(uint32(avroLong(record["dropped_links_count"])))
+	status := avroRecord(record["status"])
+	span.Status().SetMessage(avroString(status["message"]))
+	span.Status().SetCode(ptrace.StatusCode(avroSymbolIndex(avroStatusCodes, status["code"])))
+}
+
+func metricsFromAvro(value any, md pmetric.Metrics) error {
+	record, ok := value.(map[string]any)
+	if !ok || record["resource_metrics"] == nil {
+		return fmt.Errorf("avro: the record is not a MetricsData")
+	}
+	for _, rmValue := range avroArray(record["resource_metrics"]) {
+		rmRecord := avroRecord(rmValue)
+		rm := md.ResourceMetrics().AppendEmpty()
+		resourceFromAvro(rmRecord["resource"], rm.Resource())
+		rm.SetSchemaUrl(avroString(rmRecord["schema_url"]))
+		for _, smValue := range avroArray(rmRecord["scope_metrics"]) {
+			smRecord := avroRecord(smValue)
+			sm := rm.ScopeMetrics().AppendEmpty()
+			scopeFromAvro(smRecord["scope"], sm.Scope())
+			sm.SetSchemaUrl(avroString(smRecord["schema_url"]))
+			for _, metricValue := range avroArray(smRecord["metrics"]) {
+				metricFromAvro(avroRecord(metricValue), sm.Metrics().AppendEmpty())
+			}
+		}
+	}
+	return nil
+}
+
+// metricFromAvro sets metric from its record, the branch of the data union giving the type of the metric.
+func metricFromAvro(record map[string]any, metric pmetric.Metric) {
+	metric.SetName(avroString(record["name"]))
+	metric.SetDescription(avroString(record["description"]))
+	metric.SetUnit(avroString(record["unit"]))
+	branch, data := avroUnion(record["data"])
+	dataRecord := avroRecord(data)
+	switch branch {
+	case avroGaugeBranch:
+		numberDataPointsFromAvro(dataRecord["data_points"], metric.SetEmptyGauge().DataPoints())
+	case avroSumBranch:
+		sum := metric.SetEmptySum()
+		numberDataPointsFromAvro(dataRecord["data_points"], sum.DataPoints())
+		sum.SetAggregationTemporality(pmetric.AggregationTemporality(avroSymbolIndex(avroTemporalities, dataRecord["aggregation_temporality"])))
+		sum.SetIsMonotonic(avroBoolean(dataRecord["is_monotonic"]))
+	case avroHistogramBranch:
+		histogram := metric.SetEmptyHistogram()
+		histogram.SetAggregationTemporality(pmetric.AggregationTemporality(avroSymbolIndex(avroTemporalities, dataRecord["aggregation_temporality"])))
+		for _, dpValue := range avroArray(dataRecord["data_points"]) {
+			dpRecord := avroRecord(dpValue)
+			dp := histogram.DataPoints().AppendEmpty()
+			attributesFromAvro(dpRecord["attributes"], dp.Attributes())
+			dp.SetStartTimestamp(pcommon.Timestamp(avroLong(dpRecord["start_time_unix_nano"])))
+			dp.SetTimestamp(pcommon.Timestamp(avroLong(dpRecord["time_unix_nano"])))
+			dp.SetCount(uint64(avroLong(dpRecord["count"])))
+			optionalDoubleFromAvro(dpRecord["sum"], dp.SetSum)
+			dp.BucketCounts().FromRaw(bucketCountsFromAvro(dpRecord["bucket_counts"]))
+			for _, bound := range avroArray(dpRecord["explicit_bounds"]) {
+				dp.ExplicitBounds().Append(avroDouble(bound))
+			}
+			exemplarsFromAvro(dpRecord["exemplars"], dp.Exemplars())
+			dp.SetFlags(pmetric.DataPointFlags(avroLong(dpRecord["flags"])))
+			optionalDoubleFromAvro(dpRecord["min"], dp.SetMin)
+			optionalDoubleFromAvro(dpRecord["max"], dp.SetMax)
+		}
+	case avroExponentialHistogramBranch:
+		histogram := metric.SetEmptyExponentialHistogram()
+		histogram.SetAggregationTemporality(pmetric.AggregationTemporality(avroSymbolIndex(avroTemporalities, dataRecord["aggregation_temporality"])))
+		for _, dpValue := range avroArray(dataRecord["data_points"]) {
+			dpRecord := avroRecord(dpValue)
+			dp := histogram.DataPoints().AppendEmpty()
+			attributesFromAvro(dpRecord["attributes"], dp.Attributes())
+			dp.SetStartTimestamp(pcommon.Timestamp(avroLong(dpRecord["start_time_unix_nano"])))
+			dp.SetTimestamp(pcommon.Timestamp(avroLong(dpRecord["time_unix_nano"])))
+			dp.SetCount(uint64(avroLong(dpRecord["count"])))
+			optionalDoubleFromAvro(dpRecord["sum"], dp.SetSum)
+			dp.SetScale(int32(avroLong(dpRecord["scale"])))
+			dp.SetZeroCount(uint64(avroLong(dpRecord["zero_count"])))
+			bucketsFromAvro(dpRecord["positive"], dp.Positive())
+			bucketsFromAvro(dpRecord["negative"], dp.Negative())
+			dp.SetFlags(pmetric.DataPointFlags(avroLong(dpRecord["flags"])))
+			exemplarsFromAvro(dpRecord["exemplars"], dp.Exemplars())
+			optionalDoubleFromAvro(dpRecord["min"], dp.SetMin)
+			optionalDoubleFromAvro(dpRecord["max"], dp.SetMax)
+		}
+	case avroSummaryBranch:
+		summary := metric.SetEmptySummary()
+		for _, dpValue := range avroArray(dataRecord["data_points"]) {
+			dpRecord := avroRecord(dpValue)
+			dp := summary.DataPoints().AppendEmpty()
+			attributesFromAvro(dpRecord["attributes"], dp.Attributes())
+			dp.SetStartTimestamp(pcommon.Timestamp(avroLong(dpRecord["start_time_unix_nano"])))
+			dp.SetTimestamp(pcommon.Timestamp(avroLong(dpRecord["time_unix_nano"])))
+			dp.SetCount(uint64(avroLong(dpRecord["count"])))
+			dp.SetSum(avroDouble(dpRecord["sum"]))
+			for _, quantileValue := range avroArray(dpRecord["quantile_values"]) {
+				quantileRecord := avroRecord(quantileValue)
+				quantile := dp.QuantileValues().AppendEmpty()
+				quantile.SetQuantile(avroDouble(quantileRecord["quantile"]))
+				quantile.SetValue(avroDouble(quantileRecord["value"]))
+			}
+			dp.SetFlags(pmetric.DataPointFlags(avroLong(dpRecord["flags"])))
+		}
+	}
+}
+
+func numberDataPointsFromAvro(value any, dps pmetric.NumberDataPointSlice) {
+	for _, dpValue := range avroArray(value) {
+		dpRecord := avroRecord(dpValue)
+		dp := dps.AppendEmpty()
+		attributesFromAvro(dpRecord["attributes"], dp.Attributes())
+		dp.SetStartTimestamp(pcommon.Timestamp(avroLong(dpRecord["start_time_unix_nano"])))
+		dp.SetTimestamp(pcommon.Timestamp(avroLong(dpRecord["time_unix_nano"])))
+		numberFromAvro(dpRecord["value"], dp.SetDoubleValue, dp.SetIntValue)
+		exemplarsFromAvro(dpRecord["exemplars"], dp.Exemplars())
+		dp.SetFlags(pmetric.DataPointFlags(avroLong(dpRecord["flags"])))
+	}
+}
+
+func exemplarsFromAvro(value any, exemplars pmetric.ExemplarSlice) {
+	for _, exemplarValue := range avroArray(value) {
+		exemplarRecord := avroRecord(exemplarValue)
+		exemplar := exemplars.AppendEmpty()
+		attributesFromAvro(exemplarRecord["filtered_attributes"], exemplar.FilteredAttributes())
+		exemplar.SetTimestamp(pcommon.Timestamp(avroLong(exemplarRecord["time_unix_nano"])))
+		numberFromAvro(exemplarRecord["value"], exemplar.SetDoubleValue, exemplar.SetIntValue)
+		exemplar.SetSpanID(spanIDFromAvro(exemplarRecord["span_id"]))
+		exemplar.SetTraceID(traceIDFromAvro(exemplarRecord["trace_id"]))
+	}
+}
+
+// numberFromAvro sets the value of the double or long union value, and leaves it unset if it is null.
+func numberFromAvro(value any, setDouble func(float64), setInt func(int64)) {
+	switch branch, v := avroUnion(value); branch {
+	case "double":
+		setDouble(avroDouble(v))
+	case "long":
+		setInt(avroLong(v))
+	}
+}
+
+func optionalDoubleFromAvro(value any, set func(float64)) {
+	if branch, v := avroUnion(value); branch == "double" {
+		set(avroDouble(v))
+	}
+}
+
+func bucketsFromAvro(value any, buckets pmetric.ExponentialHistogramDataPointBuckets) {
+	record := avroRecord(value)
+	buckets.SetOffset(int32(avroLong(record["offset"])))
+	buckets.BucketCounts().FromRaw(bucketCountsFromAvro(record["bucket_counts"]))
+}
+
+func bucketCountsFromAvro(value any) []uint64 {
+	items := avroArray(value)
+	counts := make([]uint64, 0, len(items))
+	for _, item := range items {
+		counts = append(counts, uint64(avroLong(item)))
+	}
+	return counts
+}
+
+func logsFromAvro(value any, ld plog.Logs) error {
+	record, ok := value.(map[string]any)
+	if !ok || record["resource_logs"] == nil {
+		return fmt.Errorf("avro: the record is not a LogsData")
+	}
+	for _, rlValue := range avroArray(record["resource_logs"]) {
+		rlRecord := avroRecord(rlValue)
+		rl := ld.ResourceLogs().AppendEmpty()
+		resourceFromAvro(rlRecord["resource"], rl.Resource())
+		rl.SetSchemaUrl(avroString(rlRecord["schema_url"]))
+		for _, slValue := range avroArray(rlRecord["scope_logs"]) {
+			slRecord := avroRecord(slValue)
+			sl := rl.ScopeLogs().AppendEmpty()
+			scopeFromAvro(slRecord["scope"], sl.Scope())
+			sl.SetSchemaUrl(avroString(slRecord["schema_url"]))
+			for _, lrValue := range avroArray(slRecord["log_records"]) {
+				lrRecord := avroRecord(lrValue)
+				lr := sl.LogRecords().AppendEmpty()
+				lr.SetTimestamp(pcommon.Timestamp(avroLong(lrRecord["time_unix_nano"])))
+				lr.SetObservedTimestamp(pcommon.Timestamp(avroLong(lrRecord["observed_time_unix_nano"])))
+				lr.SetSeverityNumber(plog.SeverityNumber(avroSymbolIndex(avroSeverities, lrRecord["severity_number"])))
+				lr.SetSeverityText(avroString(lrRecord["severity_text"]))
+				anyValueFromAvro(lrRecord["body"], lr.Body())
+				attributesFromAvro(lrRecord["attributes"], lr.Attributes())
+				lr.SetDroppedAttributesCount(uint32(avroLong(lrRecord["dropped_attributes_count"])))
+				lr.SetFlags(plog.LogRecordFlags(avroLong(lrRecord["flags"])))
+				lr.SetTraceID(traceIDFromAvro(lrRecord["trace_id"]))
+				lr.SetSpanID(spanIDFromAvro(lrRecord["span_id"]))
+			}
+		}
+	}
+	return nil
+}
+
+func resourceFromAvro(value any, resource pcommon.Resource) {
+	record := avroRecord(value)
+	attributesFromAvro(record["attributes"], resource.Attributes())
+	resource.SetDroppedAttributesCount(uint32(avroLong(record["dropped_attributes_count"])))
+}
+
+func scopeFromAvro(value any, scope pcommon.InstrumentationScope) {
+	record := avroRecord(value)
+	scope.SetName(avroString(record["name"]))
+	scope.SetVersion(avroString(record["version"]))
+	attributesFromAvro(record["attributes"], scope.Attributes())
+	scope.SetDroppedAttributesCount(uint32(avroLong(record["dropped_attributes_count"])))
+}
+
+func attributesFromAvro(value any, attributes pcommon.Map) {
+	items := avroArray(value)
+	attributes.EnsureCapacity(len(items))
+	for _, item := range items {
+		keyValue := avroRecord(item)
+		anyValueFromAvro(keyValue["value"], attributes.PutEmpty(avroString(keyValue["key"])))
+	}
+}
+
+// anyValueFromAvro sets dest from the AnyValue union value, and leaves it empty if it is null.
+func anyValueFromAvro(value any, dest pcommon.Value) {
+	branch, v := avroUnion(value)
+	switch branch {
+	case "string":
+		dest.SetStr(avroString(v))
+	case "boolean":
+		dest.SetBool(avroBoolean(v))
+	case "long":
+		dest.SetInt(avroLong(v))
+	case "double":
+		dest.SetDouble(avroDouble(v))
+	case "bytes":
+		b, _ := v.([]byte)
+		dest.SetEmptyBytes().FromRaw(b)
+	case avroArrayValueBranch:
+		slice := dest.SetEmptySlice()
+		for _, item := range avroArray(avroRecord(v)["values"]) {
+			anyValueFromAvro(item, slice.AppendEmpty())
+		}
+	case avroKeyValueListBranch:
+		attributesFromAvro(avroRecord(v)["values"], dest.SetEmptyMap())
+	}
+}
+
+func traceIDFromAvro(value any) (id pcommon.TraceID) {
+	if b, ok := value.([]byte); ok && len(b) == len(id) {
+		copy(id[:], b)
+	}
+	return id
+}
+
+func spanIDFromAvro(value any) (id pcommon.SpanID) {
+	if b, ok := value.([]byte); ok && len(b) == len(id) {
+		copy(id[:], b)
+	}
+	return id
+}
+
+// avroSymbolIndex returns the OTLP enum value of the symbol value, the unknown symbols being unspecified.
+func avroSymbolIndex(symbols []string, value any) int {
+	symbol, _ := value.(string)
+	for i, candidate := range symbols {
+		if candidate == symbol {
+			return i
+		}
+	}
+	return 0
+}
+
+// avroUnion returns the branch name and the value of the union value, or "" if it is null.
+func avroUnion(value any) (string, any) {
+	for branch, v := range avroRecord(value) {
+		return branch, v
+	}
+	return "", nil
+}
+
+func avroRecord(value any) map[string]any {
+	record, _ := value.(map[string]any)
+	return record
+}
+
+func avroArray(value any) []any {
+	items, _ := value.([]any)
+	return items
+}
+
+func avroString(value any) string {
+	s, _ := value.(string)
+	return s
+}
+
+func avroBoolean(value any) bool {
+	b, _ := value.(bool)
+	return b
+}
+
+func avroLong(value any) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	}
+	return 0
+}
+
+func avroDouble(value any) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	}
+	return 0
+}
@@ -0,0 +1,118 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/avro"
+)
+
+// newTestSchemaRegistry returns a schema registry registering the schemas of every subject under the IDs 1, 2...,
+// and serving them until it is made unavailable.
+func newTestSchemaRegistry(t *testing.T) (*httptest.Server, *atomic.Bool) {
+	var unavailable atomic.Bool
+	var schemas []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unavailable.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method == http.MethodPost {
+			var body map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			schemas = append(schemas, body["schema"])
+			_ = json.NewEncoder(w).Encode(map[string]int{"id": len(schemas)})
+			return
+		}
+		for id, schema := range schemas {
+			if r.URL.Path == "/schemas/ids/"+strconv.Itoa(id+1) {
+				_ = json.NewEncoder(w).Encode(map[string]string{"schema": schema})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+	return server, &unavailable
+}
+
+func TestAvroUnmarshaler_objectContainerFile(t *testing.T) {
+	u := NewAvroUnmarshaler("")
+	config := &Config{Topic: "telemetry", Producer: Producer{MaxMessageBytes: 1000 * 1000}}
+
+	td := testAvroTraces()
+	messages, err := newAvroTracesMarshaler(newSchemaRegistry()).Marshal(td, config)
+	require.NoError(t, err)
+	value, err := messages[0].Value.Encode()
+	require.NoError(t, err)
+	unmarshaledTraces, err := u.UnmarshalTraces(value)
+	require.NoError(t, err)
+	assert.Equal(t, td, unmarshaledTraces)
+
+	md := testAvroMetrics(pmetric.MetricTypeHistogram)
+	messages, err = newAvroMetricsMarshaler(newSchemaRegistry()).Marshal(md, config)
+	require.NoError(t, err)
+	value, err = messages[0].Value.Encode()
+	require.NoError(t, err)
+	unmarshaledMetrics, err := u.UnmarshalMetrics(value)
+	require.NoError(t, err)
+	assert.Equal(t, md, unmarshaledMetrics)
+
+	ld := testAvroLogs()
+	messages, err = newAvroLogsMarshaler(newSchemaRegistry()).Marshal(ld, config)
+	require.NoError(t, err)
+	value, err = messages[0].Value.Encode()
+	require.NoError(t, err)
+	unmarshaledLogs, err := u.UnmarshalLogs(value)
+	require.NoError(t, err)
+	assert.Equal(t, ld, unmarshaledLogs)
+
+	// A message of another signal is not unmarshaled.
+	_, err = u.UnmarshalTraces(value)
+	assert.EqualError(t, err, "avro: the record is not a TracesData")
+}
+
+func TestAvroUnmarshaler_schemaRegistry(t *testing.T) {
+	server, unavailable := newTestSchemaRegistry(t)
+	config := &Config{Topic: "logs", SchemaRegistryURL: server.URL, Producer: Producer{MaxMessageBytes: 1000 * 1000}}
+	ld := testAvroLogs()
+	messages, err := newAvroLogsMarshaler(newSchemaRegistry()).Marshal(ld, config)
+	require.NoError(t, err)
+	value, err := messages[0].Value.Encode()
+	require.NoError(t, err)
+
+	u := NewAvroUnmarshaler(server.URL)
+	unmarshaled, err := u.UnmarshalLogs(value)
+	require.NoError(t, err)
+	assert.Equal(t, ld, unmarshaled)
+
+	// The known schemas are still unmarshaled while the registry is unavailable, the others fail.
+	unavailable.Store(true)
+	unmarshaled, err = u.UnmarshalLogs(value)
+	require.NoError(t, err)
+	assert.Equal(t, ld, unmarshaled)
+	_, err = u.UnmarshalLogs(avro.WireFormat(2, []byte{0}))
+	assert.ErrorContains(t, err, "fetching schema 2 returned 503 Service Unavailable")
+
+	_, err = NewAvroUnmarshaler("").UnmarshalLogs(value)
+	assert.ErrorIs(t, err, errNoSchemaRegistry)
+}
+
+func TestAvroUnmarshaler_errors(t *testing.T) {
+	u := NewAvroUnmarshaler("")
+	_, err := u.UnmarshalTraces([]byte(`{"resourceSpans": []}`))
+	assert.ErrorIs(t, err, errNotAvro)
+	_, err = u.UnmarshalTraces(append([]byte{'O', 'b', 'j', 1}, 0xff))
+	assert.Error(t, err)
+}
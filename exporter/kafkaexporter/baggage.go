@@ -0,0 +1,82 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"context"
+	"sort"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/otel/baggage"
+	"go.uber.org/zap"
+)
+
+// headersTruncatedHeader is set on the messages some headers of which were trimmed by MaxHeadersPerMessage.
+const headersTruncatedHeader = "headers-truncated"
+
+// baggageHeaders returns a header for every baggage entry of ctx and of the baggage attribute of
+// resource. The entries of ctx come first, and the first value of a key wins.
+func baggageHeaders(ctx context.Context, config Baggage, resource pcommon.Resource, logger *zap.Logger) []sarama.RecordHeader {
+	var headers []sarama.RecordHeader
+	seen := map[string]bool{}
+	add := func(b baggage.Baggage) {
+		members := b.Members()
+		sort.Slice(members, func(i, j int) bool { return members[i].Key() < members[j].Key() })
+		for _, member := range members {
+			if seen[member.Key()] {
+				continue
+			}
+			seen[member.Key()] = true
+			headers = append(headers, sarama.RecordHeader{
+				Key:   []byte(config.HeaderPrefix + member.Key()),
+				Value: []byte(member.Value()),
+			})
+		}
+	}
+
+	if config.FromContext {
+		add(baggage.FromContext(ctx))
+	}
+	if config.Attribute != "" {
+		if value, ok := resource.Attributes().Get(config.Attribute); ok {
+			b, err := baggage.Parse(value.AsString())
+			if err != nil {
+				logger.Debug("Ignoring invalid baggage attribute", zap.String("attribute", config.Attribute), zap.Error(err))
+			} else {
+				add(b)
+			}
+		}
+	}
+	return headers
+}
+
+// addHeaders appends headers to the headers of every message.
+func addHeaders(messages []*sarama.ProducerMessage, headers []sarama.RecordHeader) {
+	if len(headers) == 0 {
+		return
+	}
+	for _, message := range messages {
+		message.Headers = append(message.Headers, headers...)
+	}
+}
+
+// trimHeaders keeps the first maxHeaders-1 headers of the messages with more than maxHeaders headers,
+// and adds the headers-truncated header to them. The headers are added by order of priority: the
+// content-encoding, the span status, the headers_from_attributes, the baggage entries, then the coalesced-repeats
+// header. A maxHeaders of 0 disables the trimming.
+func trimHeaders(messages []*sarama.ProducerMessage, maxHeaders int) {
+	if maxHeaders <= 0 {
+		return
+	}
+	for _, message := range messages {
+		if len(message.Headers) <= maxHeaders {
+			continue
+		}
+		message.Headers = append(message.Headers[:maxHeaders-1:maxHeaders-1], sarama.RecordHeader{
+			Key:   []byte(headersTruncatedHeader),
+			Value: []byte("true"),
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/otel/baggage"
+	"go.uber.org/zap"
+)
+
+func contextWithBaggage(t *testing.T, value string) context.Context {
+	b, err := baggage.Parse(value)
+	require.NoError(t, err)
+	return baggage.ContextWithBaggage(context.Background(), b)
+}
+
+func headersMap(headers []sarama.RecordHeader) map[string]string {
+	m := map[string]string{}
+	for _, header := range headers {
+		m[string(header.Key)] = string(header.Value)
+	}
+	return m
+}
+
+func TestBaggageHeaders(t *testing.T) {
+	ctx := contextWithBaggage(t, "tenant=acme,region=eu")
+	resource := pcommon.NewResource()
+	resource.Attributes().PutStr("baggage", "tenant=other,order.id=42")
+	invalid := pcommon.NewResource()
+	invalid.Attributes().PutStr("baggage", "not baggage")
+
+	headers := baggageHeaders(ctx, Baggage{FromContext: true}, resource, zap.NewNop())
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("region"), Value: []byte("eu")},
+		{Key: []byte("tenant"), Value: []byte("acme")},
+	}, headers)
+
+	assert.Empty(t, baggageHeaders(ctx, Baggage{Attribute: "baggage"}, invalid, zap.NewNop()))
+	headers = baggageHeaders(ctx, Baggage{Attribute: "baggage", HeaderPrefix: "baggage."}, resource, zap.NewNop())
+	assert.Equal(t, map[string]string{"baggage.order.id": "42", "baggage.tenant": "other"}, headersMap(headers))
+
+	// The entries of the context come first.
+	headers = baggageHeaders(ctx, Baggage{FromContext: true, Attribute: "baggage"}, resource, zap.NewNop())
+	assert.Equal(t, map[string]string{"order.id": "42", "region": "eu", "tenant": "acme"}, headersMap(headers))
+
+	assert.Empty(t, baggageHeaders(ctx, Baggage{}, resource, zap.NewNop()))
+}
+
+func TestTracesPusher_baggage(t *testing.T) {
+	c := sarama.NewConfig()
+	producer := mocks.NewSyncProducer(t, c)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, map[string]string{"tenant": "acme", "order.id": "42"}, headersMap(msg.Headers))
+		return nil
+	})
+
+	p := kafkaTracesProducer{
+		producer:  producer,
+		marshaler: newPdataTracesMarshaler(&ptrace.ProtoMarshaler{}, defaultEncoding),
+		config: &Config{
+			Baggage:  Baggage{FromContext: true, Attribute: "baggage"},
+			Producer: Producer{protoVersion: 2, MaxMessageBytes: 1000 * 1000},
+		},
+		logger: zap.NewNop(),
+	}
+	t.Cleanup(func() {
+		require.NoError(t, p.Close(context.Background()))
+	})
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("baggage", "order.id=42")
+	rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	require.NoError(t, p.tracesPusher(contextWithBaggage(t, "tenant=acme"), td))
+}
+
+func TestTrimHeaders(t *testing.T) {
+	header := func(key string) sarama.RecordHeader {
+		return sarama.RecordHeader{Key: []byte(key), Value: []byte("v")}
+	}
+	messages := []*sarama.ProducerMessage{
+		{Headers: []sarama.RecordHeader{header("a"), header("b"), header("c"), header("d"), header("e")}},
+		{Headers: []sarama.RecordHeader{header("a"), header("b"), header("c")}},
+		{},
+	}
+	trimHeaders(messages, 3)
+
+	assert.Equal(t, []sarama.RecordHeader{header("a"), header("b"), {Key: []byte("headers-truncated"), Value: []byte("true")}}, messages[0].Headers)
+	assert.Equal(t, []sarama.RecordHeader{header("a"), header("b"), header("c")}, messages[1].Headers)
+	assert.Empty(t, messages[2].Headers)
+
+	trimHeaders(messages, 0)
+	assert.Len(t, messages[0].Headers, 3)
+}
+
+func TestTracesPusher_max_headers_per_message(t *testing.T) {
+	c := sarama.NewConfig()
+	producer := mocks.NewSyncProducer(t, c)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Len(t, msg.Headers, 3)
+		assert.Equal(t, map[string]string{
+			"content-encoding":  "x-snappy-framed",
+			"order.id":          "42",
+			"headers-truncated": "true",
+		}, headersMap(msg.Headers))
+		return nil
+	})
+
+	p := kafkaTracesProducer{
+		producer:  producer,
+		marshaler: newPdataTracesMarshaler(&ptrace.ProtoMarshaler{}, defaultEncoding),
+		config: &Config{
+			Baggage:              Baggage{FromContext: true},
+			MaxHeadersPerMessage: 3,
+			Producer:             Producer{protoVersion: 2, MaxMessageBytes: 1000 * 1000, PayloadCompression: payloadCompressionXerialSnappy},
+		},
+		logger: zap.NewNop(),
+	}
+	t.Cleanup(func() {
+		require.NoError(t, p.Close(context.Background()))
+	})
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	require.NoError(t, p.tracesPusher(contextWithBaggage(t, "tenant=acme,region=eu,order.id=42"), td))
+}
@@ -0,0 +1,187 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	// cloudEventsSpecVersion is the version of the CloudEvents specification of the events.
+	cloudEventsSpecVersion = "1.0"
+	// cloudEventsContentType is the content-type header of the messages in the structured mode of the Kafka
+	// protocol binding of CloudEvents.
+	cloudEventsContentType = "application/cloudevents+json"
+	contentTypeHeader      = "content-type"
+
+	cloudEventsTracesType  = "com.opentelemetry.traces"
+	cloudEventsMetricsType = "com.opentelemetry.metrics"
+	cloudEventsLogsType    = "com.opentelemetry.logs"
+)
+
+// cloudEvent is the JSON format of a CloudEvent, the data being the OTLP JSON payload.
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// newCloudEvent returns the CloudEvent of type holding the OTLP JSON payload, with a random UUID.
+func newCloudEvent(config *Config, eventType string, payload []byte) ([]byte, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	// Version 4 and variant bits of a random UUID.
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	source := config.CloudEventsSource
+	if source == "" {
+		source = defaultCloudEventsSource
+	}
+	return json.Marshal(cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16]),
+		Source:          source,
+		Type:            eventType,
+		DataContentType: "application/json",
+		Data:            payload,
+	})
+}
+
+var cloudEventsContentTypeHeader = sarama.RecordHeader{
+	Key:   []byte(contentTypeHeader),
+	Value: []byte(cloudEventsContentType),
+}
+
+// cloudEventsConfig returns a copy of config whose max_message_bytes leaves room for the content-type header,
+// which is added once the messages are split.
+func cloudEventsConfig(config *Config) *Config {
+	headerConfig := *config
+	message := sarama.ProducerMessage{Headers: []sarama.RecordHeader{cloudEventsContentTypeHeader}}
+	headerConfig.Producer.MaxMessageBytes -= message.ByteSize(config.Producer.protoVersion) - getBlankProducerMessageSize(config)
+	return &headerConfig
+}
+
+// addCloudEventsContentType sets the content-type header of the structured mode on messages.
+func addCloudEventsContentType(messages []*sarama.ProducerMessage) {
+	for _, message := range messages {
+		message.Headers = append(message.Headers, cloudEventsContentTypeHeader)
+	}
+}
+
+// cloudEventsTracesMarshaler produces the traces as CloudEvents holding their OTLP JSON. It shares the keys and
+// the splitting of the otlp encodings, the traces being marshaled by cloudEventsTracesEncoder.
+type cloudEventsTracesMarshaler struct{}
+
+func (c cloudEventsTracesMarshaler) Marshal(td ptrace.Traces, config *Config) ([]*sarama.ProducerMessage, error) {
+	messages, err := pdataTracesMarshaler{
+		marshaler: cloudEventsTracesEncoder{config: config},
+		encoding:  c.Encoding(),
+	}.Marshal(td, cloudEventsConfig(config))
+	if err != nil {
+		return nil, err
+	}
+	addCloudEventsContentType(messages)
+	return messages, nil
+}
+
+func (c cloudEventsTracesMarshaler) Encoding() string {
+	return "cloudEvents_traces"
+}
+
+// cloudEventsTracesEncoder is the ptrace.Marshaler of the cloudEvents_traces encoding for config.
+type cloudEventsTracesEncoder struct {
+	config *Config
+}
+
+func (e cloudEventsTracesEncoder) MarshalTraces(td ptrace.Traces) ([]byte, error) {
+	payload, err := (&ptrace.JSONMarshaler{}).MarshalTraces(td)
+	if err != nil {
+		return nil, err
+	}
+	return newCloudEvent(e.config, cloudEventsTracesType, payload)
+}
+
+// cloudEventsMetricsMarshaler is the cloudEventsTracesMarshaler of metrics. It keeps the state of the otlp
+// metrics encodings, such as the first timestamps of missing_start_timestamp, across the exports.
+type cloudEventsMetricsMarshaler struct {
+	pdata pdataMetricsMarshaler
+}
+
+func newCloudEventsMetricsMarshaler() cloudEventsMetricsMarshaler {
+	return cloudEventsMetricsMarshaler{
+		pdata: newPdataMetricsMarshaler(nil, "cloudEvents_metrics").(pdataMetricsMarshaler),
+	}
+}
+
+func (c cloudEventsMetricsMarshaler) Marshal(md pmetric.Metrics, config *Config) ([]*sarama.ProducerMessage, error) {
+	p := c.pdata
+	p.marshaler = cloudEventsMetricsEncoder{config: config}
+	messages, err := p.Marshal(md, cloudEventsConfig(config))
+	if err != nil {
+		return nil, err
+	}
+	addCloudEventsContentType(messages)
+	return messages, nil
+}
+
+func (c cloudEventsMetricsMarshaler) Encoding() string {
+	return "cloudEvents_metrics"
+}
+
+// cloudEventsMetricsEncoder is the pmetric.Marshaler of the cloudEvents_metrics encoding for config.
+type cloudEventsMetricsEncoder struct {
+	config *Config
+}
+
+func (e cloudEventsMetricsEncoder) MarshalMetrics(md pmetric.Metrics) ([]byte, error) {
+	payload, err := (&pmetric.JSONMarshaler{}).MarshalMetrics(md)
+	if err != nil {
+		return nil, err
+	}
+	return newCloudEvent(e.config, cloudEventsMetricsType, payload)
+}
+
+// cloudEventsLogsMarshaler is the cloudEventsTracesMarshaler of logs.
+type cloudEventsLogsMarshaler struct{}
+
+func (c cloudEventsLogsMarshaler) Marshal(ld plog.Logs, config *Config) ([]*sarama.ProducerMessage, error) {
+	messages, err := pdataLogsMarshaler{
+		marshaler: cloudEventsLogsEncoder{config: config},
+		encoding:  c.Encoding(),
+	}.Marshal(ld, cloudEventsConfig(config))
+	if err != nil {
+		return nil, err
+	}
+	addCloudEventsContentType(messages)
+	return messages, nil
+}
+
+func (c cloudEventsLogsMarshaler) Encoding() string {
+	return "cloudEvents_logs"
+}
+
+// cloudEventsLogsEncoder is the plog.Marshaler of the cloudEvents_logs encoding for config.
+type cloudEventsLogsEncoder struct {
+	config *Config
+}
+
+func (e cloudEventsLogsEncoder) MarshalLogs(ld plog.Logs) ([]byte, error) {
+	payload, err := (&plog.JSONMarshaler{}).MarshalLogs(ld)
+	if err != nil {
+		return nil, err
+	}
+	return newCloudEvent(e.config, cloudEventsLogsType, payload)
+}
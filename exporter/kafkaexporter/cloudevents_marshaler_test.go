@@ -0,0 +1,152 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"encoding/json"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
+)
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	// cloudEventsAttributeName is the naming convention of the context attributes of CloudEvents 1.0.
+	cloudEventsAttributeName = regexp.MustCompile(`^[a-z0-9]{1,20}$`)
+)
+
+// cloudEventFromMessage checks that the value of message is a structured CloudEvent of eventType valid against the
+// JSON event format of CloudEvents 1.0, independently of the marshaler's own types, and returns its data.
+func cloudEventFromMessage(t *testing.T, message *sarama.ProducerMessage, source string, eventType string) []byte {
+	assert.Contains(t, message.Headers, sarama.RecordHeader{Key: []byte("content-type"), Value: []byte("application/cloudevents+json")})
+	value, err := message.Value.Encode()
+	require.NoError(t, err)
+	var members map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(value, &members))
+	assert.ElementsMatch(t, []string{"specversion", "id", "source", "type", "datacontenttype", "data"}, mapKeys(members))
+
+	attributes := map[string]string{}
+	for name, raw := range members {
+		assert.Regexp(t, cloudEventsAttributeName, name)
+		if name == "data" {
+			continue
+		}
+		var attribute string
+		require.NoError(t, json.Unmarshal(raw, &attribute), "attribute %q must be a string", name)
+		attributes[name] = attribute
+	}
+	// The required attributes.
+	assert.Equal(t, "1.0", attributes["specversion"])
+	assert.Regexp(t, uuidPattern, attributes["id"])
+	assert.Equal(t, source, attributes["source"])
+	_, err = url.Parse(attributes["source"])
+	assert.NoError(t, err, "source must be a URI-reference")
+	assert.Equal(t, eventType, attributes["type"])
+	// The data of a JSON datacontenttype is held as JSON.
+	mediaType, _, err := mime.ParseMediaType(attributes["datacontenttype"])
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", mediaType)
+	assert.True(t, json.Valid(members["data"]))
+	return members["data"]
+}
+
+func mapKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestCloudEventsTracesMarshaler(t *testing.T) {
+	td := testdata.GenerateTracesTwoSpansSameResource()
+	messages, err := tracesMarshalers()["cloudEvents_traces"].Marshal(td, &Config{Topic: "spans"})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	data := cloudEventFromMessage(t, messages[0], "otelcol/kafkaexporter", "com.opentelemetry.traces")
+	decoded, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(data)
+	require.NoError(t, err)
+	assert.Equal(t, td, decoded)
+}
+
+func TestCloudEventsMetricsMarshaler(t *testing.T) {
+	md := testdata.GenerateMetricsTwoMetrics()
+	config := &Config{Topic: "metrics", CloudEventsSource: "/collectors/eu-west"}
+	messages, err := metricsMarshalers()["cloudEvents_metrics"].Marshal(md, config)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	data := cloudEventFromMessage(t, messages[0], "/collectors/eu-west", "com.opentelemetry.metrics")
+	decoded, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics(data)
+	require.NoError(t, err)
+	assert.Equal(t, md, decoded)
+}
+
+func TestCloudEventsMetricsMarshaler_missing_start_timestamp(t *testing.T) {
+	marshaler := newStartTimestampsMarshaler(metricsMarshalers()["cloudEvents_metrics"])
+	config := &Config{Topic: "metrics", MissingStartTimestamp: startTimestampFirstSeen}
+	startTimestamp := func(md pmetric.Metrics) pcommon.Timestamp {
+		messages, err := marshaler.Marshal(md, config)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		decoded, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics(cloudEventFromMessage(t, messages[0], "otelcol/kafkaexporter", "com.opentelemetry.metrics"))
+		require.NoError(t, err)
+		return decoded.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0).StartTimestamp()
+	}
+
+	// The first timestamps of the series are remembered across the exports, like with the otlp encodings.
+	assert.Equal(t, pcommon.Timestamp(100), startTimestamp(cumulativeSum(0, 100, "a")))
+	assert.Equal(t, pcommon.Timestamp(100), startTimestamp(cumulativeSum(0, 200, "a")))
+}
+
+func TestCloudEventsLogsMarshaler(t *testing.T) {
+	ld := testdata.GenerateLogsOneLogRecord()
+	messages, err := logsMarshalers()["cloudEvents_logs"].Marshal(ld, &Config{Topic: "logs"})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	data := cloudEventFromMessage(t, messages[0], "otelcol/kafkaexporter", "com.opentelemetry.logs")
+	decoded, err := (&plog.JSONUnmarshaler{}).UnmarshalLogs(data)
+	require.NoError(t, err)
+	assert.Equal(t, ld, decoded)
+}
+
+func TestCloudEventsLogsMarshaler_maxMessageBytes(t *testing.T) {
+	ld := plog.NewLogs()
+	records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for i := 0; i < 100; i++ {
+		records.AppendEmpty().Body().SetStr(strings.Repeat("log", 20))
+	}
+	config := &Config{Topic: "logs", Producer: Producer{MaxMessageBytes: 2000, protoVersion: 2}}
+	messages, err := logsMarshalers()["cloudEvents_logs"].Marshal(ld, config)
+	require.NoError(t, err)
+	assert.Greater(t, len(messages), 1)
+
+	ids := map[string]bool{}
+	total := 0
+	for _, message := range messages {
+		assert.LessOrEqual(t, message.ByteSize(2), config.Producer.MaxMessageBytes)
+		data := cloudEventFromMessage(t, message, "otelcol/kafkaexporter", "com.opentelemetry.logs")
+		decoded, err := (&plog.JSONUnmarshaler{}).UnmarshalLogs(data)
+		require.NoError(t, err)
+		total += decoded.LogRecordCount()
+		value, err := message.Value.Encode()
+		require.NoError(t, err)
+		var event cloudEvent
+		require.NoError(t, json.Unmarshal(value, &event))
+		ids[event.ID] = true
+	}
+	assert.Equal(t, ld.LogRecordCount(), total)
+	assert.Len(t, ids, len(messages), "every event has its own id")
+}
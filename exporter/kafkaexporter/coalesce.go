@@ -0,0 +1,162 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// coalescedHeader holds the number of repeated values dropped before the changes produced by a push.
+const coalescedHeader = "coalesced-repeats"
+
+// repeatCoalescer drops the data points of the cumulative sums whose value and start timestamp are those of
+// the last data point produced for their series. It remembers the last produced data point of every series,
+// until the series has no data point for expiry.
+type repeatCoalescer struct {
+	mu   sync.Mutex
+	last map[string]coalescedPoint
+	// maxInterval is how long a repeat is dropped after the last data point produced for its series.
+	maxInterval time.Duration
+	// expiry is how long a series without a data point is remembered.
+	expiry time.Duration
+	// maxSeries is the number of series remembered, the others are produced as they are.
+	maxSeries    int
+	lastEviction time.Time
+	now          func() time.Time
+}
+
+// coalescedPoint is the last produced data point of a series, and the number of repeats dropped since.
+type coalescedPoint struct {
+	start     pcommon.Timestamp
+	valueType pmetric.NumberDataPointValueType
+	double    float64
+	int       int64
+	dropped   int
+	// produced is when the data point was produced, and seen when the series last had a data point.
+	produced time.Time
+	seen     time.Time
+}
+
+// coalescerUpdate holds the series changed by a push, which are only remembered once it is produced, so that
+// the retries of a failed push are not dropped as repeats.
+type coalescerUpdate struct {
+	points map[string]coalescedPoint
+	// resumed is the number of repeats dropped before the changes of the push.
+	resumed int
+}
+
+// newRepeatCoalescer returns nil if coalesce_repeats is disabled.
+func newRepeatCoalescer(config *Config) *repeatCoalescer {
+	if !config.CoalesceRepeats {
+		return nil
+	}
+	return &repeatCoalescer{
+		last:        map[string]coalescedPoint{},
+		maxInterval: config.CoalesceRepeatsMaxInterval,
+		expiry:      config.CoalesceRepeatsExpiry,
+		maxSeries:   config.CoalesceRepeatsMaxSeries,
+		now:         time.Now,
+	}
+}
+
+// metrics returns md without the repeated data points of its cumulative sums, and without the metrics left
+// without data points, and the update to commit once it is produced. md is left unchanged.
+func (c *repeatCoalescer) metrics(md pmetric.Metrics) (pmetric.Metrics, *coalescerUpdate) {
+	update := &coalescerUpdate{points: map[string]coalescedPoint{}}
+	coalesced := pmetric.NewMetrics()
+	md.CopyTo(coalesced)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	coalesced.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+		rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+			sm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+				if m.Type() != pmetric.MetricTypeSum || m.Sum().AggregationTemporality() != pmetric.AggregationTemporalityCumulative {
+					return false
+				}
+				prefix := seriesPrefix(rm, sm, m)
+				m.Sum().DataPoints().RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+					return c.repeated(update, now, prefix, dp)
+				})
+				return m.Sum().DataPoints().Len() == 0
+			})
+			return sm.Metrics().Len() == 0
+		})
+		return rm.ScopeMetrics().Len() == 0
+	})
+	return coalesced, update
+}
+
+// repeated reports whether dp repeats the last data point of its series, produced less than maxInterval before
+// now, and records it in update. It is called with c.mu held.
+func (c *repeatCoalescer) repeated(update *coalescerUpdate, now time.Time, prefix string, dp pmetric.NumberDataPoint) bool {
+	var b strings.Builder
+	b.WriteString(prefix)
+	writeSortedAttributes(&b, dp.Attributes(), nil)
+	key := b.String()
+	last, ok := update.points[key]
+	if !ok {
+		last, ok = c.last[key]
+	}
+	point := coalescedPoint{start: dp.StartTimestamp(), valueType: dp.ValueType(), double: dp.DoubleValue(), int: dp.IntValue(), produced: now, seen: now}
+	if ok && last.start == point.start && last.valueType == point.valueType && last.double == point.double && last.int == point.int &&
+		(c.maxInterval <= 0 || now.Sub(last.produced) < c.maxInterval) {
+		last.dropped++
+		last.seen = now
+		update.points[key] = last
+		return true
+	}
+	if ok {
+		update.resumed += last.dropped
+	}
+	update.points[key] = point
+	return false
+}
+
+// commit remembers the series of update once its push is produced, up to maxSeries series.
+func (c *repeatCoalescer) commit(update *coalescerUpdate) {
+	if c == nil || update == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evict(c.now())
+	for key, point := range update.points {
+		if _, ok := c.last[key]; !ok && c.maxSeries > 0 && len(c.last) >= c.maxSeries {
+			continue
+		}
+		c.last[key] = point
+	}
+}
+
+// evict forgets the series without a data point for expiry, at most once per expiry so that the series are not
+// all walked for every push. It is called with c.mu held.
+func (c *repeatCoalescer) evict(now time.Time) {
+	if c.expiry <= 0 || now.Sub(c.lastEviction) < c.expiry {
+		return
+	}
+	c.lastEviction = now
+	for key, point := range c.last {
+		if now.Sub(point.seen) >= c.expiry {
+			delete(c.last, key)
+		}
+	}
+}
+
+// headers returns the coalesced-repeats header of the messages of the push of update, if it resumes series
+// after dropped repeats.
+func (update *coalescerUpdate) headers() []sarama.RecordHeader {
+	if update == nil || update.resumed == 0 {
+		return nil
+	}
+	return []sarama.RecordHeader{{Key: []byte(coalescedHeader), Value: []byte(strconv.Itoa(update.resumed))}}
+}
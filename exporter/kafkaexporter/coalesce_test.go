@@ -0,0 +1,188 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+)
+
+// coalesceMetrics returns a cumulative sum and a gauge with one data point holding value for every series.
+func coalesceMetrics(values map[string]int64) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	metrics := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	sum := metrics.AppendEmpty()
+	sum.SetName("requests")
+	sum.SetEmptySum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	gauge := metrics.AppendEmpty()
+	gauge.SetName("temperature")
+	gauge.SetEmptyGauge()
+	for _, series := range []string{"a", "b"} {
+		value, ok := values[series]
+		if !ok {
+			continue
+		}
+		dp := sum.Sum().DataPoints().AppendEmpty()
+		dp.SetStartTimestamp(1)
+		dp.SetIntValue(value)
+		dp.Attributes().PutStr("series", series)
+		gauge.Gauge().DataPoints().AppendEmpty().SetIntValue(value)
+	}
+	return md
+}
+
+func TestRepeatCoalescer(t *testing.T) {
+	c := newRepeatCoalescer(&Config{CoalesceRepeats: true})
+	push := func(values map[string]int64) (pmetric.Metrics, *coalescerUpdate) {
+		md, update := c.metrics(coalesceMetrics(values))
+		c.commit(update)
+		return md, update
+	}
+
+	md, update := push(map[string]int64{"a": 1, "b": 1})
+	assert.Equal(t, 2, md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().Len())
+	assert.Nil(t, update.headers())
+
+	// The unchanged repeats are dropped, the gauges are kept.
+	md, _ = push(map[string]int64{"a": 1, "b": 1})
+	metrics := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 1, metrics.Len())
+	assert.Equal(t, "temperature", metrics.At(0).Name())
+	md, _ = push(map[string]int64{"a": 1, "b": 1})
+	assert.Equal(t, 2, md.DataPointCount())
+
+	// The changes are produced, and stamped with the repeats dropped before them.
+	md, update = push(map[string]int64{"a": 2, "b": 1})
+	dps := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints()
+	require.Equal(t, 1, dps.Len())
+	series, _ := dps.At(0).Attributes().Get("series")
+	assert.Equal(t, "a", series.Str())
+	assert.Equal(t, int64(2), dps.At(0).IntValue())
+	assert.Equal(t, []sarama.RecordHeader{{Key: []byte(coalescedHeader), Value: []byte("2")}}, update.headers())
+
+	md, update = push(map[string]int64{"a": 3})
+	assert.Equal(t, 2, md.DataPointCount())
+	assert.Nil(t, update.headers())
+
+	// A reset with the same value is produced.
+	in := coalesceMetrics(map[string]int64{"a": 3})
+	in.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0).SetStartTimestamp(2)
+	md, _ = c.metrics(in)
+	assert.Equal(t, 2, md.DataPointCount())
+}
+
+func TestRepeatCoalescer_maxInterval(t *testing.T) {
+	c := newRepeatCoalescer(&Config{CoalesceRepeats: true, CoalesceRepeatsMaxInterval: time.Minute})
+	now := time.Unix(1000, 0)
+	c.now = func() time.Time { return now }
+	push := func() (pmetric.Metrics, *coalescerUpdate) {
+		md, update := c.metrics(coalesceMetrics(map[string]int64{"a": 1}))
+		c.commit(update)
+		return md, update
+	}
+
+	md, _ := push()
+	assert.Equal(t, 2, md.DataPointCount())
+	now = now.Add(30 * time.Second)
+	md, _ = push()
+	assert.Equal(t, 1, md.DataPointCount())
+	now = now.Add(20 * time.Second)
+	md, _ = push()
+	assert.Equal(t, 1, md.DataPointCount())
+
+	// The repeat is produced once the interval has elapsed since the last produced data point, with the repeats
+	// dropped before it.
+	now = now.Add(10 * time.Second)
+	md, update := push()
+	assert.Equal(t, 2, md.DataPointCount())
+	assert.Equal(t, []sarama.RecordHeader{{Key: []byte(coalescedHeader), Value: []byte("2")}}, update.headers())
+	now = now.Add(30 * time.Second)
+	md, _ = push()
+	assert.Equal(t, 1, md.DataPointCount())
+}
+
+func TestRepeatCoalescer_expiry(t *testing.T) {
+	c := newRepeatCoalescer(&Config{CoalesceRepeats: true, CoalesceRepeatsExpiry: time.Hour})
+	now := time.Unix(1000, 0)
+	c.now = func() time.Time { return now }
+	push := func(values map[string]int64) {
+		_, update := c.metrics(coalesceMetrics(values))
+		c.commit(update)
+	}
+
+	push(map[string]int64{"a": 1, "b": 1})
+	now = now.Add(30 * time.Minute)
+	push(map[string]int64{"a": 1})
+	assert.Len(t, c.last, 2)
+
+	// b has no data point for an hour, a is kept by its dropped repeat.
+	now = now.Add(45 * time.Minute)
+	push(map[string]int64{"a": 1})
+	assert.Len(t, c.last, 1)
+	md, _ := c.metrics(coalesceMetrics(map[string]int64{"a": 1, "b": 1}))
+	assert.Equal(t, 3, md.DataPointCount())
+}
+
+func TestRepeatCoalescer_maxSeries(t *testing.T) {
+	c := newRepeatCoalescer(&Config{CoalesceRepeats: true, CoalesceRepeatsMaxSeries: 1})
+	push := func(values map[string]int64) pmetric.Metrics {
+		md, update := c.metrics(coalesceMetrics(values))
+		c.commit(update)
+		return md
+	}
+
+	push(map[string]int64{"a": 1})
+	push(map[string]int64{"a": 1, "b": 1})
+	assert.Len(t, c.last, 1)
+
+	// The series over the limit are produced as they are.
+	md := push(map[string]int64{"a": 1, "b": 1})
+	dps := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints()
+	require.Equal(t, 1, dps.Len())
+	series, _ := dps.At(0).Attributes().Get("series")
+	assert.Equal(t, "b", series.Str())
+}
+
+func TestMetricsDataPusher_coalesceRepeats(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	p := kafkaMetricsProducer{
+		producer:  producer,
+		marshaler: newPdataMetricsMarshaler(&pmetric.ProtoMarshaler{}, defaultEncoding),
+		config:    &Config{Topic: "metrics", CoalesceRepeats: true, Producer: Producer{MaxMessageBytes: 1000 * 1000}},
+		coalescer: newRepeatCoalescer(&Config{CoalesceRepeats: true}),
+		logger:    zap.NewNop(),
+	}
+	t.Cleanup(func() {
+		require.NoError(t, p.Close(context.Background()))
+	})
+	md := pmetric.NewMetrics()
+	sum := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptySum()
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	sum.DataPoints().AppendEmpty().SetIntValue(1)
+
+	// A failed push is not remembered, so that its retry is produced.
+	producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+	assert.True(t, errors.Is(p.metricsDataPusher(context.Background(), md), sarama.ErrOutOfBrokers))
+	producer.ExpectSendMessageAndSucceed()
+	require.NoError(t, p.metricsDataPusher(context.Background(), md))
+
+	// The repeat is not produced.
+	require.NoError(t, p.metricsDataPusher(context.Background(), md))
+
+	sum.DataPoints().At(0).SetIntValue(2)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(message *sarama.ProducerMessage) error {
+		assert.Contains(t, message.Headers, sarama.RecordHeader{Key: []byte(coalescedHeader), Value: []byte("1")})
+		return nil
+	})
+	require.NoError(t, p.metricsDataPusher(context.Background(), md))
+}
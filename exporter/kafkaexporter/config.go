@@ -5,6 +5,8 @@ package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collect
 
 import (
 	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/IBM/sarama"
@@ -20,14 +22,174 @@ type Config struct {
 
 	// The list of kafka brokers (default localhost:9092)
 	Brokers []string `mapstructure:"brokers"`
+	// The minimum number of brokers that have to be reachable at start. Unreachable
+	// brokers are logged. Defaults to 0, which disables the check.
+	BrokerQuorum int `mapstructure:"broker_quorum"`
 	// Kafka protocol version
 	ProtocolVersion string `mapstructure:"protocol_version"`
-	// The name of the kafka topic to export to (default otlp_spans for traces, otlp_metrics for metrics)
+	// The name of the kafka topic to export to (default otlp_spans for traces, otlp_metrics for metrics, otlp_logs for logs)
 	Topic string `mapstructure:"topic"`
+	// TracesTopic, MetricsTopic and LogsTopic override Topic for the traces, metrics and logs exporters
+	TracesTopic  string `mapstructure:"traces_topic"`
+	MetricsTopic string `mapstructure:"metrics_topic"`
+	LogsTopic    string `mapstructure:"logs_topic"`
+	// TopicFromAttribute names the resource attribute whose value is the topic of the messages of a resource,
+	// with the characters Kafka does not accept replaced by '_'. The resources without it use the topic.
+	TopicFromAttribute string `mapstructure:"topic_from_attribute"`
+	// TopicTemplate is a Go text/template whose result is the topic of the messages of a resource, e.g.
+	// `traces-{{.Attributes.GetStr "deployment.environment"}}`, sanitized like TopicFromAttribute. The resources
+	// missing an attribute used by the template use the topic.
+	TopicTemplate string `mapstructure:"topic_template"`
+	// EmptyTopic controls what happens to the resources without a topic from TopicFromAttribute or
+	// TopicTemplate (default "default").
+	// The options are:
+	//   default -> the resources are produced to the topic
+	//   drop -> the resources are dropped
+	EmptyTopic string `mapstructure:"empty_topic"`
+	// ErrorSpansTopic is the topic the spans with an error status are also produced to, in addition to their
+	// topic. Only used by the traces exporter.
+	ErrorSpansTopic string `mapstructure:"error_spans_topic"`
 
 	// Encoding of messages (default "otlp_proto")
 	Encoding string `mapstructure:"encoding"`
 
+	// SchemaRegistryURL is the URL of the Confluent compatible schema registry of the Avro encodings. The schemas
+	// are registered under the subject of SubjectNameStrategy, and the messages prefixed with their ID. If empty,
+	// the messages are Avro object container files embedding their schema.
+	SchemaRegistryURL string `mapstructure:"schema_registry_url"`
+	// SubjectNameStrategy is how the subject of the schemas registered in SchemaRegistryURL is named, like the
+	// strategies of the Confluent serializers (default "TopicName").
+	// The options are:
+	//   TopicName -> "<topic>-value"
+	//   RecordName -> the full name of the record, e.g. "opentelemetry.proto.trace.v1.TracesData"
+	//   TopicRecordName -> "<topic>-<record full name>"
+	SubjectNameStrategy string `mapstructure:"subject_name_strategy"`
+
+	// CloudEventsSource is the source of the events of the cloudEvents encodings (default "otelcol/kafkaexporter").
+	CloudEventsSource string `mapstructure:"cloudevents_source"`
+
+	// MetricsGranularity controls how many metric data points are produced per message (default "per_request").
+	// The options are:
+	//   per_request -> one message per request, split only to fit max_message_bytes
+	//   per_datapoint -> one message per data point, keyed by the series of the data point
+	MetricsGranularity string `mapstructure:"metrics_granularity"`
+
+	// MissingStartTimestamp controls what happens to the cumulative data points without a start timestamp (default "keep").
+	// The options are:
+	//   keep -> the data points are produced as they are
+	//   first_seen -> the start timestamp is set to the timestamp of the first data point of the series
+	//   drop -> the data points are dropped
+	MissingStartTimestamp string `mapstructure:"missing_start_timestamp"`
+
+	// FirstSeenExpiry is how long the first timestamp of a series is kept by the first_seen policy of
+	// MissingStartTimestamp after the last data point of the series without a start timestamp (default 1h).
+	// 0 keeps them for as long as the exporter runs.
+	FirstSeenExpiry time.Duration `mapstructure:"first_seen_expiry"`
+
+	// ExponentialHistograms controls how the exponential histograms are produced (default "keep").
+	// The options are:
+	//   keep -> the exponential histograms are produced as they are
+	//   explicit -> the exponential histograms are converted to explicit bucket histograms
+	ExponentialHistograms string `mapstructure:"exponential_histograms"`
+
+	// LogsKey controls the key of the log messages (default "none").
+	// The options are:
+	//   none -> the messages are not keyed
+	//   host -> the log records are grouped by host, and the messages keyed by the host.name and host.id
+	//           resource attributes, so that all the log records of a host are produced to the same partition
+	LogsKey string `mapstructure:"logs_key"`
+
+	// PartitionTracesByID groups the spans of every request by trace ID, and produces every trace as its own
+	// messages keyed by the hex trace ID, so that all the spans of a trace are produced to the same partition.
+	// Only used by the traces exporter with the otlp_proto and otlp_json encodings.
+	PartitionTracesByID bool `mapstructure:"partition_traces_by_id"`
+
+	// PartitionKey controls the key of the messages of the otlp_proto and otlp_json encodings (default "none").
+	// The options are:
+	//   none                      -> the messages are not keyed
+	//   trace_id                  -> the spans are keyed like with PartitionTracesByID, metrics and logs are not keyed
+	//   resource_attribute:<name> -> the resources are grouped by the value of their <name> attribute, and the
+	//                                messages keyed by it
+	PartitionKey string `mapstructure:"partition_key"`
+
+	// KeyAttributeTrimming trims the values of high-cardinality attributes before they are
+	// used in message keys, to keep the distribution of the keys over the partitions even.
+	KeyAttributeTrimming []AttributeTrimming `mapstructure:"key_attribute_trimming"`
+
+	// OnUnsplittable controls what happens to the spans, data points and log records that
+	// exceed max_message_bytes on their own, and so cannot be split to fit in a message.
+	OnUnsplittable OnUnsplittable `mapstructure:"on_unsplittable"`
+
+	// NoSplit produces every request as one message whatever its size, for the brokers accepting large
+	// messages (default false). The messages exceeding max_message_bytes are logged instead of failing
+	// the request, and are accepted or rejected by the broker.
+	NoSplit bool `mapstructure:"no_split"`
+
+	// Baggage copies W3C baggage entries into the headers of the messages.
+	Baggage Baggage `mapstructure:"baggage"`
+
+	// AttributeRenames renames the attributes of the resources, scopes, spans, data points and log records before
+	// they are marshaled, e.g. k8s.pod.name to pod. The keys are the original names and the values the new ones.
+	AttributeRenames map[string]string `mapstructure:"attribute_renames"`
+
+	// DeterministicOrder sorts the attributes by key before they are marshaled with the otlp_json,
+	// otlp_json_envelope and jaeger_json encodings, so that the same data always gives the same bytes (default false).
+	DeterministicOrder bool `mapstructure:"deterministic_order"`
+
+	// SpanStatusHeaders sets the otel-status-code and otel-status-message headers of the per-span messages
+	// of the jaeger_proto and jaeger_json encodings to the status of their span (default false).
+	SpanStatusHeaders bool `mapstructure:"span_status_headers"`
+
+	// TraceContextHeaders sets the trace-id and span-id headers of the log messages of the otlp_proto, otlp_json
+	// and raw encodings to the trace context of their log records (default false).
+	TraceContextHeaders bool `mapstructure:"trace_context_headers"`
+
+	// SyslogSeverity sets the syslog-severity header of the log messages of the otlp_proto, otlp_json and
+	// raw encodings to the syslog severity of their most severe log record.
+	SyslogSeverity SyslogSeverity `mapstructure:"syslog_severity"`
+
+	// HeadersFromAttributes copies the named resource attributes into the headers of the messages of the resource,
+	// the header of an attribute holding the string representation of its value. Missing attributes are skipped.
+	HeadersFromAttributes []string `mapstructure:"headers_from_attributes"`
+
+	// SequenceHeader sets the sequence header of the messages to their sequence number in their partition,
+	// so that consumers can detect gaps (default false).
+	SequenceHeader bool `mapstructure:"sequence_header"`
+
+	// MaxHeadersPerMessage caps the number of headers of a message. The lowest-priority headers of the messages
+	// exceeding it are removed, and the headers-truncated header is set. Defaults to 0, which disables the cap.
+	MaxHeadersPerMessage int `mapstructure:"max_headers_per_message"`
+
+	// MaxHeaderValueBytes truncates the values of the headers_from_attributes and baggage headers longer than it,
+	// their end being replaced by "...". Defaults to 0, which disables the truncation.
+	MaxHeaderValueBytes int `mapstructure:"max_header_value_bytes"`
+
+	// MaxRecordAge drops the spans, data points and log records whose timestamp is older than
+	// now minus MaxRecordAge before they are produced. Defaults to 0, which disables the check.
+	MaxRecordAge time.Duration `mapstructure:"max_record_age"`
+
+	// CoalesceRepeats drops the data points of the cumulative sums whose value is the last value produced for
+	// their series. Only used by the metrics exporter.
+	CoalesceRepeats bool `mapstructure:"coalesce_repeats"`
+
+	// CoalesceRepeatsMaxInterval is how long the repeats of a series are dropped after its last produced data point,
+	// so that a repeat is still produced at least once per interval. 0 drops the repeats until the value changes.
+	CoalesceRepeatsMaxInterval time.Duration `mapstructure:"coalesce_repeats_max_interval"`
+
+	// CoalesceRepeatsExpiry is how long the last value of a series without a data point is kept by coalesce_repeats.
+	// 0 keeps them for the life of the exporter.
+	CoalesceRepeatsExpiry time.Duration `mapstructure:"coalesce_repeats_expiry"`
+
+	// CoalesceRepeatsMaxSeries is the number of series whose last value is kept by coalesce_repeats, the data points
+	// of the others are produced as they are. 0 keeps every series.
+	CoalesceRepeatsMaxSeries int `mapstructure:"coalesce_repeats_max_series"`
+
+	// StartupProbe produces a canary message when the exporter starts, to check that it can produce.
+	StartupProbe StartupProbe `mapstructure:"startup_probe"`
+
+	// Index produces a compact binary index of the messages of every export to a companion topic.
+	Index Index `mapstructure:"index"`
+
 	// Metadata is the namespace for metadata management properties used by the
 	// Client, and shared by the Producer/Consumer.
 	Metadata Metadata `mapstructure:"metadata"`
@@ -39,6 +201,143 @@ type Config struct {
 	Authentication Authentication `mapstructure:"auth"`
 }
 
+// AttributeTrimming defines how the value of an attribute is trimmed before it is used in a message key.
+type AttributeTrimming struct {
+	// Attribute is the key of the attribute to trim.
+	Attribute string `mapstructure:"attribute"`
+
+	// MaxLength truncates string values to the given number of characters, and bytes values keying messages on
+	// their own to the given number of bytes. 0 keeps the whole value.
+	MaxLength int `mapstructure:"max_length"`
+
+	// RoundTo rounds numeric values down to a multiple of the given value. 0 keeps the exact value.
+	RoundTo float64 `mapstructure:"round_to"`
+}
+
+// OnUnsplittable defines the policies applied to the items exceeding the maximum message size on their own.
+// The options are:
+//
+//	error -> the whole request fails ( default )
+//	drop -> the item is dropped
+//	truncate -> the item is shrunk to fit, or dropped if it cannot be
+//	deadletter -> the item is produced alone to DeadLetterTopic
+type OnUnsplittable struct {
+	// Traces is the policy applied to spans.
+	Traces string `mapstructure:"traces"`
+
+	// Metrics is the policy applied to data points.
+	Metrics string `mapstructure:"metrics"`
+
+	// Logs is the policy applied to log records.
+	Logs string `mapstructure:"logs"`
+
+	// DeadLetterTopic is the topic the items are produced to with the deadletter policy.
+	DeadLetterTopic string `mapstructure:"dead_letter_topic"`
+
+	// DeadLetterMaxMessageBytes is the maximum size of the messages produced to DeadLetterTopic,
+	// it has to be greater than the max_message_bytes of the producer.
+	DeadLetterMaxMessageBytes int `mapstructure:"dead_letter_max_message_bytes"`
+}
+
+// Baggage defines where the W3C baggage entries copied into the message headers come from.
+// Every entry becomes a header, named after its key.
+type Baggage struct {
+	// FromContext copies the baggage of the context of the request (default false).
+	FromContext bool `mapstructure:"from_context"`
+
+	// Attribute is a resource attribute holding a W3C baggage string, e.g. `key1=value1,key2=value2`.
+	// The entries of all the resources of a request are copied. Disabled if empty (default).
+	Attribute string `mapstructure:"attribute"`
+
+	// HeaderPrefix is prepended to the keys of the entries to build the header names (default none).
+	HeaderPrefix string `mapstructure:"header_prefix"`
+}
+
+// SyslogSeverity defines the syslog-severity header of the log messages, holding the numeric RFC 5424
+// severity (0 for emergency to 7 for debug) derived from the OTLP severity number.
+type SyslogSeverity struct {
+	// Enabled sets the header (default false).
+	Enabled bool `mapstructure:"enabled"`
+
+	// Mapping overrides the syslog severities of the OTLP severity ranges, keyed by trace, debug, info, warn,
+	// error or fatal. The defaults are 7 for trace and debug, 6 for info, 4 for warn, 3 for error and 2 for fatal.
+	Mapping map[string]int `mapstructure:"mapping"`
+}
+
+// signalTopic returns the topic of the messages of a signal: its own topic if set, else topic if set,
+// else the default topic of the signal.
+func signalTopic(topic, signal, defaultTopic string) string {
+	if signal != "" {
+		return signal
+	}
+	if topic != "" {
+		return topic
+	}
+	return defaultTopic
+}
+
+// configuredTopic is the topic a signal is produced to, and the setting it comes from.
+type configuredTopic struct {
+	setting string
+	topic   string
+}
+
+// signalTopics returns the topics of the traces, metrics and logs, as resolved by signalTopic.
+func (cfg *Config) signalTopics() []configuredTopic {
+	signals := []struct {
+		name, topic, defaultTopic string
+	}{
+		{name: "traces", topic: cfg.TracesTopic, defaultTopic: defaultTracesTopic},
+		{name: "metrics", topic: cfg.MetricsTopic, defaultTopic: defaultMetricsTopic},
+		{name: "logs", topic: cfg.LogsTopic, defaultTopic: defaultLogsTopic},
+	}
+	topics := make([]configuredTopic, len(signals))
+	for i, signal := range signals {
+		setting := signal.name + "_topic"
+		switch {
+		case signal.topic != "":
+		case cfg.Topic != "":
+			setting = "topic"
+		default:
+			setting = "the default " + signal.name + " topic"
+		}
+		topics[i] = configuredTopic{setting: setting, topic: signalTopic(cfg.Topic, signal.topic, signal.defaultTopic)}
+	}
+	return topics
+}
+
+// isSignalTopic returns whether the traces, metrics or logs are produced to topic.
+func (cfg *Config) isSignalTopic(topic string) bool {
+	for _, signal := range cfg.signalTopics() {
+		if signal.topic == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSignalTopics checks that the per-signal topics do not make two signals share a topic. The signals all
+// produced to topic share it on purpose.
+func (cfg *Config) validateSignalTopics() error {
+	topics := cfg.signalTopics()
+	for i, signal := range topics {
+		for _, other := range topics[i+1:] {
+			if signal.topic == other.topic && (signal.setting != "topic" || other.setting != "topic") {
+				return fmt.Errorf("%s is ambiguous with %s, as both produce to the same topic. configured value %v", signal.setting, other.setting, signal.topic)
+			}
+		}
+	}
+	return nil
+}
+
+// maxMessageBytes returns the maximum size of the messages produced to topic.
+func (cfg *Config) maxMessageBytes(topic string) int {
+	if cfg.OnUnsplittable.DeadLetterTopic != "" && topic == cfg.OnUnsplittable.DeadLetterTopic {
+		return cfg.OnUnsplittable.DeadLetterMaxMessageBytes
+	}
+	return cfg.Producer.MaxMessageBytes
+}
+
 // Metadata defines configuration for retrieving metadata from the broker.
 type Metadata struct {
 	// Whether to maintain a full set of metadata for all topics, or just
@@ -53,6 +352,85 @@ type Metadata struct {
 	Retry MetadataRetry `mapstructure:"retry"`
 }
 
+// RateLimit defines the maximum produce rate. A zero value disables the corresponding limit.
+type RateLimit struct {
+	// MessagesPerSecond is the maximum number of messages produced per second.
+	MessagesPerSecond float64 `mapstructure:"messages_per_second"`
+
+	// BytesPerSecond is the maximum number of message bytes produced per second.
+	BytesPerSecond int `mapstructure:"bytes_per_second"`
+}
+
+// HotPartition defines how the keyed messages of a partition receiving too large a share of the messages
+// are spilled to the next partition.
+type HotPartition struct {
+	// MaxShare is the share of the recent messages of a topic, between 0 and 1, above which the keyed messages
+	// of a partition are produced to the next partition. Defaults to 0, which disables the spillover.
+	MaxShare float64 `mapstructure:"max_share"`
+
+	// Window is the number of recent messages of a topic the shares are computed over (default 1000).
+	Window int `mapstructure:"window"`
+}
+
+// StartupProbe defines the canary message produced when the exporter starts.
+type StartupProbe struct {
+	// Topic the canary is produced to. Disabled if empty (default).
+	Topic string `mapstructure:"topic"`
+
+	// PayloadSize is the size of the value of the canary in bytes (default 64).
+	PayloadSize int `mapstructure:"payload_size"`
+
+	// Timeout bounds the time spent producing the canary, and consuming it back (default 10s).
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// ConsumeBack consumes the canary back once produced (default false).
+	ConsumeBack bool `mapstructure:"consume_back"`
+
+	// Strict fails the start of the exporter if the probe fails, it is only logged otherwise (default false).
+	Strict bool `mapstructure:"strict"`
+}
+
+// Index defines the index message produced after the messages of every export, listing their keys and the
+// offsets of their values within the payload of the export.
+type Index struct {
+	// Topic the index messages are produced to. Disabled if empty (default).
+	Topic string `mapstructure:"topic"`
+}
+
+// AutoCompression defines how the compression codec is selected from the produced messages.
+type AutoCompression struct {
+	// Samples is the number of messages, produced without compression, that are used to
+	// benchmark the codecs.
+	Samples int `mapstructure:"samples"`
+}
+
+// RequiredAcks is the sarama.RequiredAcks of the producer, which is configured by its name or its number.
+type RequiredAcks sarama.RequiredAcks
+
+// requiredAcksNames are the names of the RequiredAcks options.
+var requiredAcksNames = map[string]RequiredAcks{
+	"none":   RequiredAcks(sarama.NoResponse),
+	"leader": RequiredAcks(sarama.WaitForLocal),
+	"local":  RequiredAcks(sarama.WaitForLocal),
+	"all":    RequiredAcks(sarama.WaitForAll),
+}
+
+const requiredAcksError = "producer.required_acks should be one of 'none', 'leader', 'local', 'all', 0, 1 or -1. configured value %v"
+
+// UnmarshalText parses the name of the option, or its number.
+func (a *RequiredAcks) UnmarshalText(text []byte) error {
+	if acks, ok := requiredAcksNames[string(text)]; ok {
+		*a = acks
+		return nil
+	}
+	acks, err := strconv.ParseInt(string(text), 10, 16)
+	if err != nil {
+		return fmt.Errorf(requiredAcksError, string(text))
+	}
+	*a = RequiredAcks(acks)
+	return nil
+}
+
 // Producer defines configuration for producer
 type Producer struct {
 	// Maximum message bytes the producer will accept to produce.
@@ -61,21 +439,44 @@ type Producer struct {
 	// RequiredAcks Number of acknowledgements required to assume that a message has been sent.
 	// https://pkg.go.dev/github.com/IBM/sarama@v1.30.0#RequiredAcks
 	// The options are:
-	//   0 -> NoResponse.  doesn't send any response
-	//   1 -> WaitForLocal. waits for only the local commit to succeed before responding ( default )
-	//   -1 -> WaitForAll. waits for all in-sync replicas to commit before responding.
-	RequiredAcks sarama.RequiredAcks `mapstructure:"required_acks"`
+	//   none or 0 -> NoResponse.  doesn't send any response
+	//   leader, local or 1 -> WaitForLocal. waits for only the local commit to succeed before responding ( default )
+	//   all or -1 -> WaitForAll. waits for all in-sync replicas to commit before responding.
+	RequiredAcks RequiredAcks `mapstructure:"required_acks"`
 
 	// Compression Codec used to produce messages
 	// https://pkg.go.dev/github.com/IBM/sarama@v1.30.0#CompressionCodec
-	// The options are: 'none', 'gzip', 'snappy', 'lz4', and 'zstd'
+	// The options are: 'none', 'gzip', 'snappy', 'lz4', 'zstd' and 'auto'
 	Compression string `mapstructure:"compression"`
 
+	// AutoCompression configures how the codec is selected if Compression is 'auto'.
+	AutoCompression AutoCompression `mapstructure:"auto_compression"`
+
+	// PayloadCompression compresses the value of every message at the application layer, on top of Compression,
+	// and sets its content-encoding header. The options are: 'none' ( default ) and 'xerial_snappy', the snappy
+	// framing used by Hadoop, which does not understand the block snappy codec of Kafka.
+	PayloadCompression string `mapstructure:"payload_compression"`
+
 	// The maximum number of messages the producer will send in a single
 	// broker request. Defaults to 0 for unlimited. Similar to
 	// `queue.buffering.max.messages` in the JVM producer.
 	FlushMaxMessages int `mapstructure:"flush_max_messages"`
 
+	// RateLimit caps the rate at which messages are produced. Pushing blocks until the
+	// messages fit in the rate limit.
+	RateLimit RateLimit `mapstructure:"rate_limit"`
+
+	// ProduceDeadline bounds the time spent sending the messages of every push to the brokers, excluding the time
+	// spent marshaling them and waiting for the rate limit. Defaults to 0, which disables the deadline.
+	ProduceDeadline time.Duration `mapstructure:"produce_deadline"`
+
+	// HotPartition spreads the keyed messages of the partitions receiving too many messages to the next partition.
+	HotPartition HotPartition `mapstructure:"hot_partition"`
+
+	// Async produces the messages with a sarama.AsyncProducer: exporting returns once the messages are queued,
+	// and the messages that fail to be delivered are only logged and counted. Defaults to false.
+	Async bool `mapstructure:"async"`
+
 	// Kafka protocol version,
 	protoVersion int
 }
@@ -90,12 +491,164 @@ type MetadataRetry struct {
 	Backoff time.Duration `mapstructure:"backoff"`
 }
 
+const (
+	metricsGranularityPerRequest   = "per_request"
+	metricsGranularityPerDataPoint = "per_datapoint"
+)
+
+const (
+	logsKeyNone = "none"
+	logsKeyHost = "host"
+)
+
 var _ component.Config = (*Config)(nil)
 
 // Validate checks if the exporter configuration is valid
 func (cfg *Config) Validate() error {
-	if cfg.Producer.RequiredAcks < -1 || cfg.Producer.RequiredAcks > 1 {
-		return fmt.Errorf("producer.required_acks has to be between -1 and 1. configured value %v", cfg.Producer.RequiredAcks)
+	if _, err := saramaRequiredAcks(cfg.Producer.RequiredAcks); err != nil {
+		return err
+	}
+
+	if cfg.Topic != "" && cfg.TracesTopic != "" && cfg.MetricsTopic != "" && cfg.LogsTopic != "" {
+		return fmt.Errorf("topic is ambiguous with traces_topic, metrics_topic and logs_topic all set, as it is never used. configured value %v", cfg.Topic)
+	}
+	if err := cfg.validateSignalTopics(); err != nil {
+		return err
+	}
+
+	if cfg.BrokerQuorum < 0 || cfg.BrokerQuorum > len(cfg.Brokers) {
+		return fmt.Errorf("broker_quorum has to be between 0 and the number of brokers. configured value %v", cfg.BrokerQuorum)
+	}
+
+	if cfg.Producer.RateLimit.MessagesPerSecond < 0 || cfg.Producer.RateLimit.BytesPerSecond < 0 {
+		return fmt.Errorf("producer.rate_limit must not be negative. configured value %+v", cfg.Producer.RateLimit)
+	}
+
+	switch cfg.MetricsGranularity {
+	case "", metricsGranularityPerRequest, metricsGranularityPerDataPoint:
+	default:
+		return fmt.Errorf("metrics_granularity should be one of 'per_request' or 'per_datapoint'. configured value %v", cfg.MetricsGranularity)
+	}
+
+	switch cfg.MissingStartTimestamp {
+	case "", startTimestampKeep, startTimestampFirstSeen, startTimestampDrop:
+	default:
+		return fmt.Errorf("missing_start_timestamp should be one of 'keep', 'first_seen' or 'drop'. configured value %v", cfg.MissingStartTimestamp)
+	}
+	if cfg.FirstSeenExpiry < 0 {
+		return fmt.Errorf("first_seen_expiry must not be negative. configured value %v", cfg.FirstSeenExpiry)
+	}
+	if cfg.CoalesceRepeatsMaxInterval < 0 {
+		return fmt.Errorf("coalesce_repeats_max_interval must not be negative. configured value %v", cfg.CoalesceRepeatsMaxInterval)
+	}
+	if cfg.CoalesceRepeatsExpiry < 0 {
+		return fmt.Errorf("coalesce_repeats_expiry must not be negative. configured value %v", cfg.CoalesceRepeatsExpiry)
+	}
+	if cfg.CoalesceRepeatsMaxSeries < 0 {
+		return fmt.Errorf("coalesce_repeats_max_series must not be negative. configured value %v", cfg.CoalesceRepeatsMaxSeries)
+	}
+
+	switch cfg.ExponentialHistograms {
+	case "", exponentialHistogramsKeep, exponentialHistogramsExplicit:
+	default:
+		return fmt.Errorf("exponential_histograms should be one of 'keep' or 'explicit'. configured value %v", cfg.ExponentialHistograms)
+	}
+
+	switch cfg.LogsKey {
+	case "", logsKeyNone, logsKeyHost:
+	default:
+		return fmt.Errorf("logs_key should be one of 'none' or 'host'. configured value %v", cfg.LogsKey)
+	}
+
+	for severityRange, severity := range cfg.SyslogSeverity.Mapping {
+		if _, ok := defaultSyslogSeverities[severityRange]; !ok {
+			return fmt.Errorf("syslog_severity.mapping keys should be one of 'trace', 'debug', 'info', 'warn', 'error' or 'fatal'. configured value %v", severityRange)
+		}
+		if severity < 0 || severity > 7 {
+			return fmt.Errorf("syslog_severity.mapping has to be between 0 and 7. configured value %v: %v", severityRange, severity)
+		}
+	}
+
+	if cfg.PartitionKey != "" && cfg.PartitionKey != partitionKeyNone && cfg.PartitionKey != partitionKeyTraceID &&
+		partitionKeyAttribute(cfg.PartitionKey) == "" {
+		return fmt.Errorf("partition_key should be one of 'none', 'trace_id' or 'resource_attribute:<name>'. configured value %v", cfg.PartitionKey)
+	}
+
+	if cfg.MaxHeadersPerMessage < 0 {
+		return fmt.Errorf("max_headers_per_message must not be negative. configured value %v", cfg.MaxHeadersPerMessage)
+	}
+
+	if cfg.MaxHeaderValueBytes < 0 {
+		return fmt.Errorf("max_header_value_bytes must not be negative. configured value %v", cfg.MaxHeaderValueBytes)
+	}
+
+	for from, to := range cfg.AttributeRenames {
+		if from == "" || to == "" {
+			return fmt.Errorf("attribute_renames must not have empty names. configured value %v: %v", from, to)
+		}
+	}
+
+	if cfg.Producer.HotPartition.MaxShare < 0 || cfg.Producer.HotPartition.MaxShare > 1 {
+		return fmt.Errorf("producer.hot_partition.max_share has to be between 0 and 1. configured value %v", cfg.Producer.HotPartition.MaxShare)
+	}
+	if cfg.Producer.HotPartition.MaxShare > 0 && cfg.Producer.HotPartition.Window <= 0 {
+		return fmt.Errorf("producer.hot_partition.window has to be positive. configured value %v", cfg.Producer.HotPartition.Window)
+	}
+	if cfg.Producer.ProduceDeadline < 0 {
+		return fmt.Errorf("producer.produce_deadline must not be negative. configured value %v", cfg.Producer.ProduceDeadline)
+	}
+	if cfg.StartupProbe.Topic != "" {
+		if cfg.StartupProbe.PayloadSize <= 0 || cfg.StartupProbe.PayloadSize > cfg.Producer.MaxMessageBytes {
+			return fmt.Errorf("startup_probe.payload_size has to be between 1 and producer.max_message_bytes. configured value %v", cfg.StartupProbe.PayloadSize)
+		}
+		if cfg.StartupProbe.Timeout <= 0 {
+			return fmt.Errorf("startup_probe.timeout has to be positive. configured value %v", cfg.StartupProbe.Timeout)
+		}
+	}
+	if cfg.TopicTemplate != "" {
+		if cfg.TopicFromAttribute != "" {
+			return fmt.Errorf("topic_template and topic_from_attribute cannot both be set")
+		}
+		if _, err := compileTopicTemplate(cfg.TopicTemplate); err != nil {
+			return err
+		}
+	}
+	switch cfg.EmptyTopic {
+	case "", emptyTopicDefault, emptyTopicDrop:
+	default:
+		return fmt.Errorf("empty_topic should be one of 'default' or 'drop'. configured value %v", cfg.EmptyTopic)
+	}
+	if cfg.ErrorSpansTopic != "" && cfg.isSignalTopic(cfg.ErrorSpansTopic) {
+		return fmt.Errorf("error_spans_topic has to be different from the topics of the traces, metrics and logs. configured value %v", cfg.ErrorSpansTopic)
+	}
+	if cfg.Index.Topic != "" && cfg.isSignalTopic(cfg.Index.Topic) {
+		return fmt.Errorf("index.topic has to be different from the topics of the traces, metrics and logs. configured value %v", cfg.Index.Topic)
+	}
+	if cfg.SchemaRegistryURL != "" {
+		if u, err := url.Parse(cfg.SchemaRegistryURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("schema_registry_url has to be an http or https URL. configured value %v", cfg.SchemaRegistryURL)
+		}
+	}
+	switch cfg.SubjectNameStrategy {
+	case "", subjectNameStrategyTopicName, subjectNameStrategyRecordName, subjectNameStrategyTopicRecordName:
+	default:
+		return fmt.Errorf("subject_name_strategy should be one of 'TopicName', 'RecordName' or 'TopicRecordName'. configured value %v", cfg.SubjectNameStrategy)
+	}
+	if cfg.MaxRecordAge < 0 {
+		return fmt.Errorf("max_record_age must not be negative. configured value %v", cfg.MaxRecordAge)
+	}
+
+	for _, trimming := range cfg.KeyAttributeTrimming {
+		if trimming.Attribute == "" {
+			return fmt.Errorf("key_attribute_trimming.attribute is required")
+		}
+		if trimming.MaxLength < 0 || trimming.RoundTo < 0 {
+			return fmt.Errorf("key_attribute_trimming must not be negative. configured value %+v", trimming)
+		}
+	}
+
+	if err := validateOnUnsplittable(cfg); err != nil {
+		return err
 	}
 
 	_, err := saramaProducerCompressionCodec(cfg.Producer.Compression)
@@ -103,7 +656,44 @@ func (cfg *Config) Validate() error {
 		return err
 	}
 
-	return validateSASLConfig(cfg.Authentication.SASL)
+	if cfg.Producer.Compression == compressionAuto && cfg.Producer.AutoCompression.Samples <= 0 {
+		return fmt.Errorf("producer.auto_compression.samples has to be positive. configured value %v", cfg.Producer.AutoCompression.Samples)
+	}
+
+	switch cfg.Producer.PayloadCompression {
+	case "", payloadCompressionNone, payloadCompressionXerialSnappy:
+	default:
+		return fmt.Errorf("producer.payload_compression should be one of 'none' or 'xerial_snappy'. configured value %v", cfg.Producer.PayloadCompression)
+	}
+
+	return cfg.Authentication.Validate()
+}
+
+func validateOnUnsplittable(cfg *Config) error {
+	deadLetter := false
+	for _, p := range []struct{ signal, policy string }{
+		{signal: "traces", policy: cfg.OnUnsplittable.Traces},
+		{signal: "metrics", policy: cfg.OnUnsplittable.Metrics},
+		{signal: "logs", policy: cfg.OnUnsplittable.Logs},
+	} {
+		switch p.policy {
+		case "", unsplittableError, unsplittableDrop, unsplittableTruncate:
+		case unsplittableDeadLetter:
+			deadLetter = true
+		default:
+			return fmt.Errorf("on_unsplittable.%s should be one of 'error', 'drop', 'truncate' or 'deadletter'. configured value %v", p.signal, p.policy)
+		}
+	}
+	if !deadLetter {
+		return nil
+	}
+	if cfg.OnUnsplittable.DeadLetterTopic == "" {
+		return fmt.Errorf("on_unsplittable.dead_letter_topic is required by the deadletter policy")
+	}
+	if cfg.OnUnsplittable.DeadLetterMaxMessageBytes <= cfg.Producer.MaxMessageBytes {
+		return fmt.Errorf("on_unsplittable.dead_letter_max_message_bytes has to be greater than producer.max_message_bytes. configured value %v", cfg.OnUnsplittable.DeadLetterMaxMessageBytes)
+	}
+	return nil
 }
 
 func validateSASLConfig(c *SASLConfig) error {
@@ -111,19 +701,33 @@ func validateSASLConfig(c *SASLConfig) error {
 		return nil
 	}
 
-	if c.Username == "" {
-		return fmt.Errorf("auth.sasl.username is required")
-	}
+	// AWS_MSK_IAM and AWS_MSK_IAM_OAUTHBEARER fall back to the default credential chain of the AWS SDK.
+	if c.Mechanism != "AWS_MSK_IAM" && c.Mechanism != "AWS_MSK_IAM_OAUTHBEARER" {
+		if c.Username == "" {
+			return fmt.Errorf("auth.sasl.username is required")
+		}
 
-	if c.Password == "" {
-		return fmt.Errorf("auth.sasl.password is required")
+		if c.Password == "" {
+			return fmt.Errorf("auth.sasl.password is required")
+		}
 	}
 
 	switch c.Mechanism {
-	case "PLAIN", "AWS_MSK_IAM", "SCRAM-SHA-256", "SCRAM-SHA-512":
+	case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
 		// Do nothing, valid mechanism
+	case "AWS_MSK_IAM", "AWS_MSK_IAM_OAUTHBEARER":
+		if c.AWSMSK.Region == "" {
+			return fmt.Errorf("auth.sasl.aws_msk.region is required")
+		}
+	case "OAUTHBEARER":
+		if c.OAuthBearer.TokenURL == "" {
+			return fmt.Errorf("auth.sasl.oauthbearer.token_url is required")
+		}
+		if c.OAuthBearer.TokenExpiryBuffer < 0 {
+			return fmt.Errorf("auth.sasl.oauthbearer.token_expiry_buffer must not be negative. configured value %v", c.OAuthBearer.TokenExpiryBuffer)
+		}
 	default:
-		return fmt.Errorf("auth.sasl.mechanism should be one of 'PLAIN', 'AWS_MSK_IAM', 'SCRAM-SHA-256' or 'SCRAM-SHA-512'. configured value %v", c.Mechanism)
+		return fmt.Errorf("auth.sasl.mechanism should be one of 'PLAIN', 'AWS_MSK_IAM', 'AWS_MSK_IAM_OAUTHBEARER', 'OAUTHBEARER', 'SCRAM-SHA-256' or 'SCRAM-SHA-512'. configured value %v", c.Mechanism)
 	}
 
 	if c.Version < 0 || c.Version > 1 {
@@ -133,6 +737,36 @@ func validateSASLConfig(c *SASLConfig) error {
 	return nil
 }
 
+// validateKerberosConfig checks that exactly one of the password and the keytab file is set.
+func validateKerberosConfig(c *KerberosConfig) error {
+	if c == nil {
+		return nil
+	}
+	if c.Password != "" && c.KeyTabPath != "" {
+		return fmt.Errorf("auth.kerberos.password and auth.kerberos.keytab_file cannot both be set")
+	}
+	if !c.UseKeyTab {
+		if c.Password == "" {
+			return fmt.Errorf("auth.kerberos.password is required without auth.kerberos.use_keytab")
+		}
+		return nil
+	}
+	if c.KeyTabPath == "" {
+		return fmt.Errorf("auth.kerberos.keytab_file is required with auth.kerberos.use_keytab")
+	}
+	return nil
+}
+
+// saramaRequiredAcks returns the sarama.RequiredAcks of acks, or an error if it is not one of the options.
+func saramaRequiredAcks(acks RequiredAcks) (sarama.RequiredAcks, error) {
+	switch sarama.RequiredAcks(acks) {
+	case sarama.NoResponse, sarama.WaitForLocal, sarama.WaitForAll:
+		return sarama.RequiredAcks(acks), nil
+	default:
+		return sarama.WaitForLocal, fmt.Errorf(requiredAcksError, acks)
+	}
+}
+
 func saramaProducerCompressionCodec(compression string) (sarama.CompressionCodec, error) {
 	switch compression {
 	case "none":
@@ -145,7 +779,10 @@ func saramaProducerCompressionCodec(compression string) (sarama.CompressionCodec
 		return sarama.CompressionLZ4, nil
 	case "zstd":
 		return sarama.CompressionZSTD, nil
+	case compressionAuto:
+		// The messages are produced without compression until the codec is selected.
+		return sarama.CompressionNone, nil
 	default:
-		return sarama.CompressionNone, fmt.Errorf("producer.compression should be one of 'none', 'gzip', 'snappy', 'lz4', or 'zstd'. configured value %v", compression)
+		return sarama.CompressionNone, fmt.Errorf("producer.compression should be one of 'none', 'gzip', 'snappy', 'lz4', 'zstd', or 'auto'. configured value %v", compression)
 	}
 }
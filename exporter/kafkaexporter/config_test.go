@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/confmap"
 	"go.opentelemetry.io/collector/confmap/confmaptest"
 	"go.opentelemetry.io/collector/exporter/exporterhelper"
 
@@ -53,15 +54,35 @@ func TestLoadConfig(t *testing.T) {
 					NumConsumers: 2,
 					QueueSize:    10,
 				},
-				Topic:    "spans",
-				Encoding: "otlp_proto",
-				Brokers:  []string{"foo:123", "bar:456"},
+				Topic:                      "spans",
+				Encoding:                   "otlp_proto",
+				CloudEventsSource:          "otelcol/kafkaexporter",
+				MetricsGranularity:         "per_request",
+				EmptyTopic:                 "default",
+				SubjectNameStrategy:        "TopicName",
+				MissingStartTimestamp:      "keep",
+				FirstSeenExpiry:            time.Hour,
+				CoalesceRepeatsMaxInterval: 5 * time.Minute,
+				CoalesceRepeatsExpiry:      time.Hour,
+				ExponentialHistograms:      "keep",
+				LogsKey:                    "none",
+				PartitionKey:               "none",
+				OnUnsplittable: OnUnsplittable{
+					Traces:  "error",
+					Metrics: "error",
+					Logs:    "error",
+				},
+				Brokers: []string{"foo:123", "bar:456"},
 				Authentication: Authentication{
 					PlainText: &PlainTextConfig{
 						Username: "jdoe",
 						Password: "pass",
 					},
 				},
+				StartupProbe: StartupProbe{
+					PayloadSize: 64,
+					Timeout:     10 * time.Second,
+				},
 				Metadata: Metadata{
 					Full: false,
 					Retry: MetadataRetry{
@@ -71,8 +92,15 @@ func TestLoadConfig(t *testing.T) {
 				},
 				Producer: Producer{
 					MaxMessageBytes: 10000000,
-					RequiredAcks:    sarama.WaitForAll,
+					RequiredAcks:    RequiredAcks(sarama.WaitForAll),
 					Compression:     "none",
+					AutoCompression: AutoCompression{
+						Samples: 10,
+					},
+					PayloadCompression: "none",
+					HotPartition: HotPartition{
+						Window: 1000,
+					},
 				},
 			},
 		},
@@ -105,9 +133,25 @@ func TestLoadConfig(t *testing.T) {
 					NumConsumers: 2,
 					QueueSize:    10,
 				},
-				Topic:    "spans",
-				Encoding: "otlp_proto",
-				Brokers:  []string{"foo:123", "bar:456"},
+				Topic:                      "spans",
+				Encoding:                   "otlp_proto",
+				CloudEventsSource:          "otelcol/kafkaexporter",
+				MetricsGranularity:         "per_request",
+				EmptyTopic:                 "default",
+				SubjectNameStrategy:        "TopicName",
+				MissingStartTimestamp:      "keep",
+				FirstSeenExpiry:            time.Hour,
+				CoalesceRepeatsMaxInterval: 5 * time.Minute,
+				CoalesceRepeatsExpiry:      time.Hour,
+				ExponentialHistograms:      "keep",
+				LogsKey:                    "none",
+				PartitionKey:               "none",
+				OnUnsplittable: OnUnsplittable{
+					Traces:  "error",
+					Metrics: "error",
+					Logs:    "error",
+				},
+				Brokers: []string{"foo:123", "bar:456"},
 				Authentication: Authentication{
 					PlainText: &PlainTextConfig{
 						Username: "jdoe",
@@ -120,6 +164,10 @@ func TestLoadConfig(t *testing.T) {
 						Version:   0,
 					},
 				},
+				StartupProbe: StartupProbe{
+					PayloadSize: 64,
+					Timeout:     10 * time.Second,
+				},
 				Metadata: Metadata{
 					Full: false,
 					Retry: MetadataRetry{
@@ -129,8 +177,15 @@ func TestLoadConfig(t *testing.T) {
 				},
 				Producer: Producer{
 					MaxMessageBytes: 10000000,
-					RequiredAcks:    sarama.WaitForAll,
+					RequiredAcks:    RequiredAcks(sarama.WaitForAll),
 					Compression:     "none",
+					AutoCompression: AutoCompression{
+						Samples: 10,
+					},
+					PayloadCompression: "none",
+					HotPartition: HotPartition{
+						Window: 1000,
+					},
 				},
 			},
 		},
@@ -158,7 +213,436 @@ func TestValidate_err_compression(t *testing.T) {
 	}
 
 	err := config.Validate()
-	assert.EqualError(t, err, "producer.compression should be one of 'none', 'gzip', 'snappy', 'lz4', or 'zstd'. configured value idk")
+	assert.EqualError(t, err, "producer.compression should be one of 'none', 'gzip', 'snappy', 'lz4', 'zstd', or 'auto'. configured value idk")
+}
+
+func TestValidate_err_broker_quorum(t *testing.T) {
+	config := &Config{
+		Brokers:      []string{"foo:123"},
+		BrokerQuorum: 2,
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "broker_quorum has to be between 0 and the number of brokers. configured value 2")
+}
+
+func TestValidate_err_auto_compression_samples(t *testing.T) {
+	config := &Config{
+		Producer: Producer{
+			Compression: "auto",
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "producer.auto_compression.samples has to be positive. configured value 0")
+}
+
+func TestValidate_err_rate_limit(t *testing.T) {
+	config := &Config{
+		Producer: Producer{
+			Compression: "none",
+			RateLimit:   RateLimit{BytesPerSecond: -1},
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "producer.rate_limit must not be negative. configured value {MessagesPerSecond:0 BytesPerSecond:-1}")
+}
+
+func TestValidate_err_metrics_granularity(t *testing.T) {
+	config := &Config{
+		MetricsGranularity: "per_metric",
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "metrics_granularity should be one of 'per_request' or 'per_datapoint'. configured value per_metric")
+}
+
+func TestValidate_err_max_headers_per_message(t *testing.T) {
+	config := &Config{
+		MaxHeadersPerMessage: -1,
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "max_headers_per_message must not be negative. configured value -1")
+}
+
+func TestValidate_err_max_header_value_bytes(t *testing.T) {
+	config := &Config{
+		MaxHeaderValueBytes: -1,
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "max_header_value_bytes must not be negative. configured value -1")
+}
+
+func TestValidate_err_syslog_severity(t *testing.T) {
+	config := &Config{
+		SyslogSeverity: SyslogSeverity{Mapping: map[string]int{"notice": 5}},
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+	assert.EqualError(t, config.Validate(), "syslog_severity.mapping keys should be one of 'trace', 'debug', 'info', 'warn', 'error' or 'fatal'. configured value notice")
+
+	config.SyslogSeverity.Mapping = map[string]int{"warn": 8}
+	assert.EqualError(t, config.Validate(), "syslog_severity.mapping has to be between 0 and 7. configured value warn: 8")
+}
+
+func TestValidate_err_schema_registry_url(t *testing.T) {
+	config := &Config{
+		SchemaRegistryURL: "registry:8081",
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+	assert.EqualError(t, config.Validate(), "schema_registry_url has to be an http or https URL. configured value registry:8081")
+}
+
+func TestValidate_err_ambiguous_topic(t *testing.T) {
+	tests := []struct {
+		name         string
+		topic        string
+		tracesTopic  string
+		metricsTopic string
+		logsTopic    string
+		expected     string
+	}{
+		{name: "topic", topic: "telemetry"},
+		{name: "defaults"},
+		{name: "per-signal topics", tracesTopic: "spans", metricsTopic: "metrics", logsTopic: "logs"},
+		{name: "topic and one per-signal topic", topic: "telemetry", tracesTopic: "spans"},
+		{name: "topic and two per-signal topics", topic: "telemetry", tracesTopic: "spans", logsTopic: "logs"},
+		{
+			name: "topic and all per-signal topics", topic: "telemetry", tracesTopic: "spans", metricsTopic: "metrics", logsTopic: "logs",
+			expected: "topic is ambiguous with traces_topic, metrics_topic and logs_topic all set, as it is never used. configured value telemetry",
+		},
+		{
+			name: "per-signal topic equal to topic", topic: "telemetry", tracesTopic: "telemetry",
+			expected: "traces_topic is ambiguous with topic, as both produce to the same topic. configured value telemetry",
+		},
+		{
+			name: "per-signal topic equal to topic for a later signal", topic: "telemetry", logsTopic: "telemetry",
+			expected: "topic is ambiguous with logs_topic, as both produce to the same topic. configured value telemetry",
+		},
+		{
+			name: "two per-signal topics equal", metricsTopic: "telemetry", logsTopic: "telemetry",
+			expected: "metrics_topic is ambiguous with logs_topic, as both produce to the same topic. configured value telemetry",
+		},
+		{
+			name: "per-signal topic equal to a default topic", tracesTopic: "otlp_metrics",
+			expected: "traces_topic is ambiguous with the default metrics topic, as both produce to the same topic. configured value otlp_metrics",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Topic:        tt.topic,
+				TracesTopic:  tt.tracesTopic,
+				MetricsTopic: tt.metricsTopic,
+				LogsTopic:    tt.logsTopic,
+				Producer: Producer{
+					Compression: "none",
+				},
+			}
+			if tt.expected == "" {
+				assert.NoError(t, config.Validate())
+			} else {
+				assert.EqualError(t, config.Validate(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidate_err_companion_topics(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{name: "index topic", config: Config{Topic: "telemetry", Index: Index{Topic: "index"}}},
+		{
+			name:     "index topic equal to topic",
+			config:   Config{Topic: "spans", Index: Index{Topic: "spans"}},
+			expected: "index.topic has to be different from the topics of the traces, metrics and logs. configured value spans",
+		},
+		{
+			name:     "index topic equal to a per-signal topic",
+			config:   Config{Topic: "telemetry", MetricsTopic: "metrics", Index: Index{Topic: "metrics"}},
+			expected: "index.topic has to be different from the topics of the traces, metrics and logs. configured value metrics",
+		},
+		{
+			name:     "index topic equal to a default topic",
+			config:   Config{Index: Index{Topic: "otlp_logs"}},
+			expected: "index.topic has to be different from the topics of the traces, metrics and logs. configured value otlp_logs",
+		},
+		{name: "error spans topic", config: Config{TracesTopic: "spans", ErrorSpansTopic: "errors"}},
+		{
+			name:     "error spans topic equal to traces topic",
+			config:   Config{TracesTopic: "spans", ErrorSpansTopic: "spans"},
+			expected: "error_spans_topic has to be different from the topics of the traces, metrics and logs. configured value spans",
+		},
+		{
+			name:     "error spans topic equal to topic",
+			config:   Config{Topic: "telemetry", ErrorSpansTopic: "telemetry"},
+			expected: "error_spans_topic has to be different from the topics of the traces, metrics and logs. configured value telemetry",
+		},
+		{
+			name:     "error spans topic equal to the logs topic",
+			config:   Config{TracesTopic: "spans", LogsTopic: "logs", ErrorSpansTopic: "logs"},
+			expected: "error_spans_topic has to be different from the topics of the traces, metrics and logs. configured value logs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Producer.Compression = "none"
+			if tt.expected == "" {
+				assert.NoError(t, config.Validate())
+			} else {
+				assert.EqualError(t, config.Validate(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestSignalTopic(t *testing.T) {
+	assert.Equal(t, "otlp_spans", signalTopic("", "", defaultTracesTopic))
+	assert.Equal(t, "telemetry", signalTopic("telemetry", "", defaultTracesTopic))
+	assert.Equal(t, "spans", signalTopic("telemetry", "spans", defaultTracesTopic))
+	assert.Equal(t, "spans", signalTopic("", "spans", defaultTracesTopic))
+}
+
+func TestValidate_err_attribute_renames(t *testing.T) {
+	config := &Config{
+		AttributeRenames: map[string]string{"k8s.pod.name": ""},
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "attribute_renames must not have empty names. configured value k8s.pod.name: ")
+}
+
+func TestValidate_err_subject_name_strategy(t *testing.T) {
+	config := &Config{
+		SubjectNameStrategy: "topic_name",
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "subject_name_strategy should be one of 'TopicName', 'RecordName' or 'TopicRecordName'. configured value topic_name")
+}
+
+func TestValidate_err_empty_topic(t *testing.T) {
+	config := &Config{
+		EmptyTopic: "fallback",
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "empty_topic should be one of 'default' or 'drop'. configured value fallback")
+}
+
+func TestValidate_err_coalesce_repeats(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		err    string
+	}{
+		{
+			name:   "max_interval",
+			config: Config{CoalesceRepeatsMaxInterval: -time.Minute},
+			err:    "coalesce_repeats_max_interval must not be negative. configured value -1m0s",
+		},
+		{
+			name:   "expiry",
+			config: Config{CoalesceRepeatsExpiry: -time.Minute},
+			err:    "coalesce_repeats_expiry must not be negative. configured value -1m0s",
+		},
+		{
+			name:   "max_series",
+			config: Config{CoalesceRepeatsMaxSeries: -1},
+			err:    "coalesce_repeats_max_series must not be negative. configured value -1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.Producer = Producer{Compression: "none"}
+			assert.EqualError(t, test.config.Validate(), test.err)
+		})
+	}
+}
+
+func TestValidate_err_first_seen_expiry(t *testing.T) {
+	config := &Config{
+		FirstSeenExpiry: -time.Minute,
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "first_seen_expiry must not be negative. configured value -1m0s")
+}
+
+func TestValidate_err_max_record_age(t *testing.T) {
+	config := &Config{
+		MaxRecordAge: -time.Minute,
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "max_record_age must not be negative. configured value -1m0s")
+}
+
+func TestValidate_err_hot_partition(t *testing.T) {
+	config := &Config{
+		Producer: Producer{
+			Compression:  "none",
+			HotPartition: HotPartition{MaxShare: 1.5, Window: 1000},
+		},
+	}
+	assert.EqualError(t, config.Validate(), "producer.hot_partition.max_share has to be between 0 and 1. configured value 1.5")
+
+	config.Producer.HotPartition = HotPartition{MaxShare: 0.5}
+	assert.EqualError(t, config.Validate(), "producer.hot_partition.window has to be positive. configured value 0")
+}
+
+func TestValidate_err_startup_probe(t *testing.T) {
+	config := &Config{
+		StartupProbe: StartupProbe{Topic: "canary", PayloadSize: 2000, Timeout: time.Second},
+		Producer: Producer{
+			Compression:     "none",
+			MaxMessageBytes: 1000,
+		},
+	}
+	assert.EqualError(t, config.Validate(), "startup_probe.payload_size has to be between 1 and producer.max_message_bytes. configured value 2000")
+
+	config.StartupProbe.PayloadSize = 64
+	config.StartupProbe.Timeout = 0
+	assert.EqualError(t, config.Validate(), "startup_probe.timeout has to be positive. configured value 0s")
+}
+
+func TestValidate_err_produce_deadline(t *testing.T) {
+	config := &Config{
+		Producer: Producer{
+			Compression:     "none",
+			ProduceDeadline: -time.Second,
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "producer.produce_deadline must not be negative. configured value -1s")
+}
+
+func TestValidate_err_payload_compression(t *testing.T) {
+	config := &Config{
+		Producer: Producer{
+			Compression:        "none",
+			PayloadCompression: "gzip",
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "producer.payload_compression should be one of 'none' or 'xerial_snappy'. configured value gzip")
+}
+
+func TestValidate_err_missing_start_timestamp(t *testing.T) {
+	config := &Config{
+		MissingStartTimestamp: "now",
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "missing_start_timestamp should be one of 'keep', 'first_seen' or 'drop'. configured value now")
+}
+
+func TestValidate_err_exponential_histograms(t *testing.T) {
+	config := &Config{
+		ExponentialHistograms: "native",
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "exponential_histograms should be one of 'keep' or 'explicit'. configured value native")
+}
+
+func TestValidate_err_partition_key(t *testing.T) {
+	for _, partitionKey := range []string{"span_id", "resource_attribute:"} {
+		config := &Config{
+			PartitionKey: partitionKey,
+			Producer: Producer{
+				Compression: "none",
+			},
+		}
+		assert.EqualError(t, config.Validate(), "partition_key should be one of 'none', 'trace_id' or 'resource_attribute:<name>'. configured value "+partitionKey)
+	}
+}
+
+func TestValidate_err_logs_key(t *testing.T) {
+	config := &Config{
+		LogsKey: "service",
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+
+	err := config.Validate()
+	assert.EqualError(t, err, "logs_key should be one of 'none' or 'host'. configured value service")
+}
+
+func TestValidate_err_key_attribute_trimming(t *testing.T) {
+	config := &Config{
+		KeyAttributeTrimming: []AttributeTrimming{{MaxLength: 8}},
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+	assert.EqualError(t, config.Validate(), "key_attribute_trimming.attribute is required")
+
+	config.KeyAttributeTrimming = []AttributeTrimming{{Attribute: "url", MaxLength: -1}}
+	assert.EqualError(t, config.Validate(), "key_attribute_trimming must not be negative. configured value {Attribute:url MaxLength:-1 RoundTo:0}")
+}
+
+func TestValidate_err_on_unsplittable(t *testing.T) {
+	config := &Config{
+		OnUnsplittable: OnUnsplittable{Metrics: "split"},
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+	assert.EqualError(t, config.Validate(), "on_unsplittable.metrics should be one of 'error', 'drop', 'truncate' or 'deadletter'. configured value split")
+
+	config.OnUnsplittable = OnUnsplittable{Logs: "deadletter"}
+	assert.EqualError(t, config.Validate(), "on_unsplittable.dead_letter_topic is required by the deadletter policy")
+
+	config.OnUnsplittable = OnUnsplittable{Logs: "deadletter", DeadLetterTopic: "dlq"}
+	config.Producer.MaxMessageBytes = 1000
+	assert.EqualError(t, config.Validate(), "on_unsplittable.dead_letter_max_message_bytes has to be greater than producer.max_message_bytes. configured value 0")
 }
 
 func TestValidate_sasl_username(t *testing.T) {
@@ -212,7 +696,7 @@ func TestValidate_sasl_mechanism(t *testing.T) {
 	}
 
 	err := config.Validate()
-	assert.EqualError(t, err, "auth.sasl.mechanism should be one of 'PLAIN', 'AWS_MSK_IAM', 'SCRAM-SHA-256' or 'SCRAM-SHA-512'. configured value FAKE")
+	assert.EqualError(t, err, "auth.sasl.mechanism should be one of 'PLAIN', 'AWS_MSK_IAM', 'AWS_MSK_IAM_OAUTHBEARER', 'OAUTHBEARER', 'SCRAM-SHA-256' or 'SCRAM-SHA-512'. configured value FAKE")
 }
 
 func TestValidate_sasl_version(t *testing.T) {
@@ -234,6 +718,47 @@ func TestValidate_sasl_version(t *testing.T) {
 	assert.EqualError(t, err, "auth.sasl.version has to be either 0 or 1. configured value 42")
 }
 
+func TestValidate_sasl_oauthbearer(t *testing.T) {
+	config := &Config{
+		Producer: Producer{
+			Compression: "none",
+		},
+		Authentication: Authentication{
+			SASL: &SASLConfig{
+				Username:  "client",
+				Password:  "secret",
+				Mechanism: "OAUTHBEARER",
+			},
+		},
+	}
+	assert.EqualError(t, config.Validate(), "auth.sasl.oauthbearer.token_url is required")
+
+	config.Authentication.SASL.OAuthBearer.TokenURL = "https://idp.example.com/token"
+	assert.NoError(t, config.Validate())
+
+	config.Authentication.SASL.OAuthBearer.TokenExpiryBuffer = -time.Second
+	assert.EqualError(t, config.Validate(), "auth.sasl.oauthbearer.token_expiry_buffer must not be negative. configured value -1s")
+}
+
+func TestValidate_sasl_aws_msk_iam_default_credentials(t *testing.T) {
+	for _, mechanism := range []string{"AWS_MSK_IAM", "AWS_MSK_IAM_OAUTHBEARER"} {
+		config := &Config{
+			Producer: Producer{
+				Compression: "none",
+			},
+			Authentication: Authentication{
+				SASL: &SASLConfig{
+					Mechanism: mechanism,
+				},
+			},
+		}
+		assert.EqualError(t, config.Validate(), "auth.sasl.aws_msk.region is required")
+
+		config.Authentication.SASL.AWSMSK.Region = "us-east-1"
+		assert.NoError(t, config.Validate())
+	}
+}
+
 func Test_saramaProducerCompressionCodec(t *testing.T) {
 	tests := map[string]struct {
 		compression         string
@@ -265,10 +790,15 @@ func Test_saramaProducerCompressionCodec(t *testing.T) {
 			expectedCompression: sarama.CompressionZSTD,
 			expectedError:       nil,
 		},
+		"auto": {
+			compression:         "auto",
+			expectedCompression: sarama.CompressionNone,
+			expectedError:       nil,
+		},
 		"unknown": {
 			compression:         "unknown",
 			expectedCompression: sarama.CompressionNone,
-			expectedError:       fmt.Errorf("producer.compression should be one of 'none', 'gzip', 'snappy', 'lz4', or 'zstd'. configured value unknown"),
+			expectedError:       fmt.Errorf("producer.compression should be one of 'none', 'gzip', 'snappy', 'lz4', 'zstd', or 'auto'. configured value unknown"),
 		},
 	}
 
@@ -280,3 +810,97 @@ func Test_saramaProducerCompressionCodec(t *testing.T) {
 		})
 	}
 }
+
+func Test_saramaRequiredAcks(t *testing.T) {
+	for name, expected := range map[string]sarama.RequiredAcks{
+		"none":  sarama.NoResponse,
+		"local": sarama.WaitForLocal,
+		"all":   sarama.WaitForAll,
+	} {
+		t.Run(name, func(t *testing.T) {
+			acks, err := saramaRequiredAcks(requiredAcksNames[name])
+			require.NoError(t, err)
+			assert.Equal(t, expected, acks)
+		})
+	}
+
+	_, err := saramaRequiredAcks(RequiredAcks(3))
+	assert.EqualError(t, err, "producer.required_acks should be one of 'none', 'leader', 'local', 'all', 0, 1 or -1. configured value 3")
+}
+
+func TestValidate_kerberos_keytab(t *testing.T) {
+	config := &Config{
+		Producer: Producer{
+			Compression: "none",
+		},
+		Authentication: Authentication{
+			Kerberos: &KerberosConfig{
+				ServiceName: "kafka",
+				UseKeyTab:   true,
+			},
+		},
+	}
+	assert.EqualError(t, config.Validate(), "auth.kerberos.keytab_file is required with auth.kerberos.use_keytab")
+
+	// The keytab is only read when the exporter starts.
+	config.Authentication.Kerberos.KeyTabPath = filepath.Join(t.TempDir(), "kafka.keytab")
+	assert.NoError(t, config.Validate())
+
+	// The keytab is not used with a password.
+	config.Authentication.Kerberos = &KerberosConfig{ServiceName: "kafka", Password: "secret"}
+	assert.NoError(t, config.Validate())
+}
+
+func TestValidate_kerberos_credentials(t *testing.T) {
+	config := &Config{
+		Producer: Producer{
+			Compression: "none",
+		},
+		Authentication: Authentication{
+			Kerberos: &KerberosConfig{ServiceName: "kafka", Username: "jdoe"},
+		},
+	}
+	assert.EqualError(t, config.Validate(), "auth.kerberos.password is required without auth.kerberos.use_keytab")
+
+	config.Authentication.Kerberos.Password = "secret"
+	config.Authentication.Kerberos.KeyTabPath = "/etc/security/kafka.keytab"
+	assert.EqualError(t, config.Validate(), "auth.kerberos.password and auth.kerberos.keytab_file cannot both be set")
+	config.Authentication.Kerberos.UseKeyTab = true
+	assert.EqualError(t, config.Validate(), "auth.kerberos.password and auth.kerberos.keytab_file cannot both be set")
+}
+
+func TestRequiredAcks_unmarshal(t *testing.T) {
+	tests := []struct {
+		value    any
+		expected sarama.RequiredAcks
+		err      string
+	}{
+		{value: "none", expected: sarama.NoResponse},
+		{value: "leader", expected: sarama.WaitForLocal},
+		{value: "local", expected: sarama.WaitForLocal},
+		{value: "all", expected: sarama.WaitForAll},
+		{value: 0, expected: sarama.NoResponse},
+		{value: 1, expected: sarama.WaitForLocal},
+		{value: -1, expected: sarama.WaitForAll},
+		{value: "-1", expected: sarama.WaitForAll},
+		{value: "quorum", err: "producer.required_acks should be one of 'none', 'leader', 'local', 'all', 0, 1 or -1. configured value quorum"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.value), func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cm := confmap.NewFromStringMap(map[string]any{"producer": map[string]any{"required_acks": tt.value}})
+			err := component.UnmarshalConfig(cm, cfg)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, sarama.RequiredAcks(cfg.Producer.RequiredAcks))
+			assert.NoError(t, cfg.Validate())
+		})
+	}
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Producer.RequiredAcks = 2
+	assert.EqualError(t, cfg.Validate(), "producer.required_acks should be one of 'none', 'leader', 'local', 'all', 0, 1 or -1. configured value 2")
+}
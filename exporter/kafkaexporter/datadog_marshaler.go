@@ -0,0 +1,133 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	conventions "go.opentelemetry.io/collector/semconv/v1.6.1"
+)
+
+const (
+	datadogTypeGauge = "gauge"
+	datadogTypeCount = "count"
+)
+
+// datadogPayload is the body of the Datadog series API.
+type datadogPayload struct {
+	Series []datadogSeries `json:"series"`
+}
+
+// datadogSeries is a single data point of a metric in the Datadog series format.
+type datadogSeries struct {
+	Metric string `json:"metric"`
+	Type   string `json:"type"`
+	// Points are [timestamp in seconds, value] pairs.
+	Points   [][2]float64 `json:"points"`
+	Host     string       `json:"host,omitempty"`
+	Tags     []string     `json:"tags,omitempty"`
+	Interval int64        `json:"interval,omitempty"`
+}
+
+// datadogMetricsMarshaler produces every request as one message holding the Datadog series of its gauges and sums.
+// Delta monotonic sums are counts, the other sums are gauges. Histograms, exponential histograms and summaries
+// have no series equivalent and are dropped.
+type datadogMetricsMarshaler struct{}
+
+func (d datadogMetricsMarshaler) Marshal(md pmetric.Metrics, config *Config) ([]*sarama.ProducerMessage, error) {
+	payload := datadogPayload{Series: []datadogSeries{}}
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		host := ""
+		if v, ok := rm.Resource().Attributes().Get(conventions.AttributeHostName); ok {
+			host = v.AsString()
+		}
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			metrics := rm.ScopeMetrics().At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				metric := metrics.At(k)
+				var points pmetric.NumberDataPointSlice
+				metricType := datadogTypeGauge
+				switch metric.Type() {
+				case pmetric.MetricTypeGauge:
+					points = metric.Gauge().DataPoints()
+				case pmetric.MetricTypeSum:
+					points = metric.Sum().DataPoints()
+					if metric.Sum().IsMonotonic() && metric.Sum().AggregationTemporality() == pmetric.AggregationTemporalityDelta {
+						metricType = datadogTypeCount
+					}
+				default:
+					continue
+				}
+				for l := 0; l < points.Len(); l++ {
+					if series, ok := newDatadogSeries(metric.Name(), metricType, host, rm.Resource(), points.At(l)); ok {
+						payload.Series = append(payload.Series, series)
+					}
+				}
+			}
+		}
+	}
+	bts, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return []*sarama.ProducerMessage{{
+		Topic: config.Topic,
+		Value: sarama.ByteEncoder(bts),
+	}}, nil
+}
+
+func (d datadogMetricsMarshaler) Encoding() string {
+	return "datadog_json"
+}
+
+// newDatadogSeries returns false for the data points without a value, which cannot be rendered.
+func newDatadogSeries(name string, metricType string, host string, resource pcommon.Resource, dp pmetric.NumberDataPoint) (datadogSeries, bool) {
+	var value float64
+	switch dp.ValueType() {
+	case pmetric.NumberDataPointValueTypeInt:
+		value = float64(dp.IntValue())
+	case pmetric.NumberDataPointValueTypeDouble:
+		value = dp.DoubleValue()
+	default:
+		return datadogSeries{}, false
+	}
+	if dp.Flags().NoRecordedValue() || math.IsNaN(value) || math.IsInf(value, 0) {
+		return datadogSeries{}, false
+	}
+	series := datadogSeries{
+		Metric: name,
+		Type:   metricType,
+		Points: [][2]float64{{float64(dp.Timestamp().AsTime().Unix()), value}},
+		Host:   host,
+		Tags:   datadogTags(resource.Attributes(), dp.Attributes()),
+	}
+	if metricType == datadogTypeCount && dp.StartTimestamp() != 0 && dp.Timestamp() > dp.StartTimestamp() {
+		series.Interval = int64(dp.Timestamp().AsTime().Sub(dp.StartTimestamp().AsTime()).Seconds())
+	}
+	return series, true
+}
+
+// datadogTags renders the resource and data point attributes as sorted key:value tags. The data point
+// attributes win over the resource attributes of the same key.
+func datadogTags(resource pcommon.Map, attributes pcommon.Map) []string {
+	values := make(map[string]string, resource.Len()+attributes.Len())
+	for _, m := range []pcommon.Map{resource, attributes} {
+		m.Range(func(k string, v pcommon.Value) bool {
+			values[k] = v.AsString()
+			return true
+		})
+	}
+	tags := make([]string, 0, len(values))
+	for k, v := range values {
+		tags = append(tags, k+":"+v)
+	}
+	sort.Strings(tags)
+	return tags
+}
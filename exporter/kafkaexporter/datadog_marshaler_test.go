@@ -0,0 +1,73 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestDatadogMetricsMarshaler(t *testing.T) {
+	ts := pcommon.NewTimestampFromTime(time.Unix(1690891200, 0))
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("host.name", "web-1")
+	rm.Resource().Attributes().PutStr("env", "prod")
+	metrics := rm.ScopeMetrics().AppendEmpty().Metrics()
+
+	gauge := metrics.AppendEmpty()
+	gauge.SetName("system.cpu.load")
+	dp := gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetTimestamp(ts)
+	dp.SetDoubleValue(0.5)
+	dp.Attributes().PutStr("env", "staging")
+	dp.Attributes().PutInt("cpu", 0)
+	gauge.Gauge().DataPoints().AppendEmpty().SetDoubleValue(math.NaN())
+
+	count := metrics.AppendEmpty()
+	count.SetName("http.requests")
+	count.SetEmptySum().SetIsMonotonic(true)
+	count.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	dp = count.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(pcommon.NewTimestampFromTime(ts.AsTime().Add(-10 * time.Second)))
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(42)
+
+	cumulative := metrics.AppendEmpty()
+	cumulative.SetName("process.uptime")
+	cumulative.SetEmptySum().SetIsMonotonic(true)
+	cumulative.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	dp = cumulative.Sum().DataPoints().AppendEmpty()
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(3600)
+
+	metrics.AppendEmpty().SetEmptyHistogram().DataPoints().AppendEmpty().SetCount(1)
+
+	messages, err := metricsMarshalers()["datadog_json"].Marshal(md, &Config{Topic: "topic"})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "topic", messages[0].Topic)
+	bts, err := messages[0].Value.Encode()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"series":[
+		{"metric":"system.cpu.load","type":"gauge","points":[[1690891200,0.5]],"host":"web-1","tags":["cpu:0","env:staging","host.name:web-1"]},
+		{"metric":"http.requests","type":"count","points":[[1690891200,42]],"host":"web-1","tags":["env:prod","host.name:web-1"],"interval":10},
+		{"metric":"process.uptime","type":"gauge","points":[[1690891200,3600]],"host":"web-1","tags":["env:prod","host.name:web-1"]}
+	]}`, string(bts))
+}
+
+func TestDatadogMetricsMarshaler_empty(t *testing.T) {
+	messages, err := datadogMetricsMarshaler{}.Marshal(pmetric.NewMetrics(), &Config{Topic: "topic"})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	bts, err := messages[0].Value.Encode()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"series":[]}`, string(bts))
+}
@@ -0,0 +1,158 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"sort"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// jsonEncodings are the encodings whose attributes are sorted by DeterministicOrder.
+var jsonEncodings = map[string]bool{
+	"otlp_json":          true,
+	"otlp_json_envelope": true,
+	"jaeger_json":        true,
+}
+
+// attributed is implemented by the data points, which are ranged over alike.
+type attributed interface {
+	Attributes() pcommon.Map
+	Exemplars() pmetric.ExemplarSlice
+}
+
+// sortedTraces returns a copy of td with all its attributes sorted by key, td is left unchanged.
+func sortedTraces(td ptrace.Traces) ptrace.Traces {
+	sorted := ptrace.NewTraces()
+	td.CopyTo(sorted)
+	rangeTracesAttributes(sorted, sortAttributes)
+	return sorted
+}
+
+// sortedMetrics returns a copy of md with all its attributes sorted by key, md is left unchanged.
+func sortedMetrics(md pmetric.Metrics) pmetric.Metrics {
+	sorted := pmetric.NewMetrics()
+	md.CopyTo(sorted)
+	rangeMetricsAttributes(sorted, sortAttributes)
+	return sorted
+}
+
+// sortedLogs returns a copy of ld with all its attributes sorted by key, ld is left unchanged.
+func sortedLogs(ld plog.Logs) plog.Logs {
+	sorted := plog.NewLogs()
+	ld.CopyTo(sorted)
+	rangeLogsAttributes(sorted, sortAttributes)
+	return sorted
+}
+
+// rangeTracesAttributes calls fn for the attributes of the resources, scopes, spans, span events and span links of td.
+func rangeTracesAttributes(td ptrace.Traces, fn func(pcommon.Map)) {
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		fn(rs.Resource().Attributes())
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			fn(ss.Scope().Attributes())
+			for k := 0; k < ss.Spans().Len(); k++ {
+				span := ss.Spans().At(k)
+				fn(span.Attributes())
+				for l := 0; l < span.Events().Len(); l++ {
+					fn(span.Events().At(l).Attributes())
+				}
+				for l := 0; l < span.Links().Len(); l++ {
+					fn(span.Links().At(l).Attributes())
+				}
+			}
+		}
+	}
+}
+
+// rangeMetricsAttributes calls fn for the attributes of the resources, scopes, data points and exemplars of md.
+func rangeMetricsAttributes(md pmetric.Metrics, fn func(pcommon.Map)) {
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		fn(rm.Resource().Attributes())
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			sm := rm.ScopeMetrics().At(j)
+			fn(sm.Scope().Attributes())
+			for k := 0; k < sm.Metrics().Len(); k++ {
+				m := sm.Metrics().At(k)
+				switch m.Type() {
+				case pmetric.MetricTypeGauge:
+					rangePoints(m.Gauge().DataPoints().Len(), m.Gauge().DataPoints().At, fn)
+				case pmetric.MetricTypeSum:
+					rangePoints(m.Sum().DataPoints().Len(), m.Sum().DataPoints().At, fn)
+				case pmetric.MetricTypeHistogram:
+					rangePoints(m.Histogram().DataPoints().Len(), m.Histogram().DataPoints().At, fn)
+				case pmetric.MetricTypeExponentialHistogram:
+					rangePoints(m.ExponentialHistogram().DataPoints().Len(), m.ExponentialHistogram().DataPoints().At, fn)
+				case pmetric.MetricTypeSummary:
+					for l := 0; l < m.Summary().DataPoints().Len(); l++ {
+						fn(m.Summary().DataPoints().At(l).Attributes())
+					}
+				}
+			}
+		}
+	}
+}
+
+func rangePoints[P attributed](n int, at func(int) P, fn func(pcommon.Map)) {
+	for i := 0; i < n; i++ {
+		dp := at(i)
+		fn(dp.Attributes())
+		for j := 0; j < dp.Exemplars().Len(); j++ {
+			fn(dp.Exemplars().At(j).FilteredAttributes())
+		}
+	}
+}
+
+// rangeLogsAttributes calls fn for the attributes of the resources, scopes and log records of ld.
+func rangeLogsAttributes(ld plog.Logs, fn func(pcommon.Map)) {
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		fn(rl.Resource().Attributes())
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			fn(sl.Scope().Attributes())
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				fn(sl.LogRecords().At(k).Attributes())
+			}
+		}
+	}
+}
+
+// sortAttributes sorts m by key, and the maps nested in its values too.
+func sortAttributes(m pcommon.Map) {
+	keys := make([]string, 0, m.Len())
+	m.Range(func(k string, v pcommon.Value) bool {
+		keys = append(keys, k)
+		sortValue(v)
+		return true
+	})
+	if sort.StringsAreSorted(keys) {
+		return
+	}
+	sort.Strings(keys)
+	sorted := pcommon.NewMap()
+	sorted.EnsureCapacity(len(keys))
+	for _, k := range keys {
+		v, _ := m.Get(k)
+		v.CopyTo(sorted.PutEmpty(k))
+	}
+	sorted.CopyTo(m)
+}
+
+func sortValue(v pcommon.Value) {
+	switch v.Type() {
+	case pcommon.ValueTypeMap:
+		sortAttributes(v.Map())
+	case pcommon.ValueTypeSlice:
+		for i := 0; i < v.Slice().Len(); i++ {
+			sortValue(v.Slice().At(i))
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+// putAttributes sets the same attributes in the given key order.
+func putAttributes(m pcommon.Map, keys ...string) {
+	for _, k := range keys {
+		if k == "nested" {
+			nested := m.PutEmptyMap(k)
+			nested.PutStr("z", "last")
+			nested.PutStr("a", "first")
+			continue
+		}
+		m.PutStr(k, k+"-value")
+	}
+}
+
+func TestSortAttributes(t *testing.T) {
+	m := pcommon.NewMap()
+	putAttributes(m, "service.name", "nested", "host.name")
+	slice := m.PutEmptySlice("list")
+	putAttributes(slice.AppendEmpty().SetEmptyMap(), "b", "a")
+
+	sortAttributes(m)
+	var keys []string
+	m.Range(func(k string, v pcommon.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	assert.Equal(t, []string{"host.name", "list", "nested", "service.name"}, keys)
+	nested, _ := m.Get("nested")
+	assert.Equal(t, `{"a":"first","z":"last"}`, nested.AsString())
+	list, _ := m.Get("list")
+	assert.Equal(t, `[{"a":"a-value","b":"b-value"}]`, list.AsString())
+}
+
+func TestSortedTraces_unchanged_input(t *testing.T) {
+	td := ptrace.NewTraces()
+	putAttributes(td.ResourceSpans().AppendEmpty().Resource().Attributes(), "b", "a")
+	sorted := sortedTraces(td)
+
+	var keys []string
+	td.ResourceSpans().At(0).Resource().Attributes().Range(func(k string, v pcommon.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	assert.Equal(t, []string{"b", "a"}, keys)
+	assert.Equal(t, td.ResourceSpans().At(0).Resource().Attributes().AsRaw(), sorted.ResourceSpans().At(0).Resource().Attributes().AsRaw())
+}
+
+func TestDeterministicOrder(t *testing.T) {
+	orders := [][]string{
+		{"service.name", "nested", "host.name", "cloud.region"},
+		{"cloud.region", "host.name", "service.name", "nested"},
+	}
+	newTraces := func(keys []string) ptrace.Traces {
+		td := ptrace.NewTraces()
+		rs := td.ResourceSpans().AppendEmpty()
+		putAttributes(rs.Resource().Attributes(), keys...)
+		span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetTraceID([16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+		span.SetSpanID([8]byte{1, 2, 3, 4, 5, 6, 7, 8})
+		putAttributes(span.Attributes(), keys...)
+		putAttributes(span.Events().AppendEmpty().Attributes(), keys...)
+		return td
+	}
+	newMetrics := func(keys []string) pmetric.Metrics {
+		md := pmetric.NewMetrics()
+		rm := md.ResourceMetrics().AppendEmpty()
+		putAttributes(rm.Resource().Attributes(), keys...)
+		dp := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptySum().DataPoints().AppendEmpty()
+		putAttributes(dp.Attributes(), keys...)
+		putAttributes(dp.Exemplars().AppendEmpty().FilteredAttributes(), keys...)
+		return md
+	}
+	newLogs := func(keys []string) plog.Logs {
+		ld := plog.NewLogs()
+		rl := ld.ResourceLogs().AppendEmpty()
+		putAttributes(rl.Resource().Attributes(), keys...)
+		putAttributes(rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Attributes(), keys...)
+		return ld
+	}
+
+	for _, encoding := range []string{"otlp_json", "otlp_json_envelope", "jaeger_json"} {
+		t.Run(encoding, func(t *testing.T) {
+			config := &Config{Topic: "topic", Encoding: encoding, DeterministicOrder: true, Producer: Producer{MaxMessageBytes: 1000 * 1000}}
+			producer := mocks.NewSyncProducer(t, nil)
+			var values [][]byte
+			producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recordValue(&values))
+			producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recordValue(&values))
+			producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recordValue(&values))
+			p := kafkaTracesProducer{producer: producer, marshaler: tracesMarshalers()[encoding], config: config, logger: zap.NewNop()}
+
+			td := newTraces(orders[0])
+			// The same input twice, then the same attributes set in another order.
+			require.NoError(t, p.tracesPusher(context.Background(), td))
+			require.NoError(t, p.tracesPusher(context.Background(), td))
+			require.NoError(t, p.tracesPusher(context.Background(), newTraces(orders[1])))
+			require.Len(t, values, 3)
+			assert.Equal(t, values[0], values[1])
+			assert.Equal(t, values[0], values[2])
+		})
+	}
+
+	for _, encoding := range []string{"otlp_json", "otlp_json_envelope"} {
+		t.Run("metrics "+encoding, func(t *testing.T) {
+			config := &Config{Topic: "topic", Encoding: encoding, DeterministicOrder: true, Producer: Producer{MaxMessageBytes: 1000 * 1000}}
+			producer := mocks.NewSyncProducer(t, nil)
+			var values [][]byte
+			producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recordValue(&values))
+			producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recordValue(&values))
+			p := kafkaMetricsProducer{producer: producer, marshaler: metricsMarshalers()[encoding], config: config, logger: zap.NewNop()}
+
+			require.NoError(t, p.metricsDataPusher(context.Background(), newMetrics(orders[0])))
+			require.NoError(t, p.metricsDataPusher(context.Background(), newMetrics(orders[1])))
+			require.Len(t, values, 2)
+			assert.Equal(t, values[0], values[1])
+		})
+
+		t.Run("logs "+encoding, func(t *testing.T) {
+			config := &Config{Topic: "topic", Encoding: encoding, DeterministicOrder: true, Producer: Producer{MaxMessageBytes: 1000 * 1000}}
+			producer := mocks.NewSyncProducer(t, nil)
+			var values [][]byte
+			producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recordValue(&values))
+			producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recordValue(&values))
+			p := kafkaLogsProducer{producer: producer, marshaler: logsMarshalers()[encoding], config: config, logger: zap.NewNop()}
+
+			require.NoError(t, p.logsDataPusher(context.Background(), newLogs(orders[0])))
+			require.NoError(t, p.logsDataPusher(context.Background(), newLogs(orders[1])))
+			require.Len(t, values, 2)
+			assert.Equal(t, values[0], values[1])
+		})
+	}
+}
+
+func recordValue(values *[][]byte) mocks.MessageChecker {
+	return func(msg *sarama.ProducerMessage) error {
+		value, err := msg.Value.Encode()
+		*values = append(*values, value)
+		return err
+	}
+}
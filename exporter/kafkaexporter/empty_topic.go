@@ -0,0 +1,123 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"context"
+	"text/template"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	emptyTopicDefault = "default"
+	emptyTopicDrop    = "drop"
+)
+
+// emptyTopicFilter drops the resources whose topic_template or topic_from_attribute topic is empty, when
+// empty_topic is drop, before they are produced.
+type emptyTopicFilter struct {
+	topic     func(pcommon.Resource) string
+	statsTags []tag.Mutator
+}
+
+// newEmptyTopicFilter returns nil if empty_topic is not drop or the topic is not dynamic. The dropped records
+// are not counted if statsTags is nil.
+func newEmptyTopicFilter(config *Config, tmpl *template.Template, statsTags []tag.Mutator) *emptyTopicFilter {
+	if config.EmptyTopic != emptyTopicDrop {
+		return nil
+	}
+	topic := dynamicTopic(config, tmpl)
+	if topic == nil {
+		return nil
+	}
+	return &emptyTopicFilter{topic: topic, statsTags: statsTags}
+}
+
+func (f *emptyTopicFilter) recordDropped(n int) {
+	if n > 0 && f.statsTags != nil {
+		_ = stats.RecordWithTags(context.Background(), f.statsTags, statEmptyTopicRecordsDropped.M(int64(n)))
+	}
+}
+
+// traces returns td without the resources that have no topic. td is left unchanged, and returned as is if
+// all its resources have one.
+func (f *emptyTopicFilter) traces(td ptrace.Traces) ptrace.Traces {
+	if !f.anyEmpty(td.ResourceSpans().Len(), func(i int) pcommon.Resource { return td.ResourceSpans().At(i).Resource() }) {
+		return td
+	}
+	routed := ptrace.NewTraces()
+	td.CopyTo(routed)
+	dropped := 0
+	routed.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		if f.topic(rs.Resource()) != "" {
+			return false
+		}
+		for i := 0; i < rs.ScopeSpans().Len(); i++ {
+			dropped += rs.ScopeSpans().At(i).Spans().Len()
+		}
+		return true
+	})
+	f.recordDropped(dropped)
+	return routed
+}
+
+// metrics is the traces of metrics.
+func (f *emptyTopicFilter) metrics(md pmetric.Metrics) pmetric.Metrics {
+	if !f.anyEmpty(md.ResourceMetrics().Len(), func(i int) pcommon.Resource { return md.ResourceMetrics().At(i).Resource() }) {
+		return md
+	}
+	routed := pmetric.NewMetrics()
+	md.CopyTo(routed)
+	dropped := 0
+	routed.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+		if f.topic(rm.Resource()) != "" {
+			return false
+		}
+		for i := 0; i < rm.ScopeMetrics().Len(); i++ {
+			metrics := rm.ScopeMetrics().At(i).Metrics()
+			for j := 0; j < metrics.Len(); j++ {
+				dropped += dataPointCount(metrics.At(j))
+			}
+		}
+		return true
+	})
+	f.recordDropped(dropped)
+	return routed
+}
+
+// logs is the traces of logs.
+func (f *emptyTopicFilter) logs(ld plog.Logs) plog.Logs {
+	if !f.anyEmpty(ld.ResourceLogs().Len(), func(i int) pcommon.Resource { return ld.ResourceLogs().At(i).Resource() }) {
+		return ld
+	}
+	routed := plog.NewLogs()
+	ld.CopyTo(routed)
+	dropped := 0
+	routed.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+		if f.topic(rl.Resource()) != "" {
+			return false
+		}
+		for i := 0; i < rl.ScopeLogs().Len(); i++ {
+			dropped += rl.ScopeLogs().At(i).LogRecords().Len()
+		}
+		return true
+	})
+	f.recordDropped(dropped)
+	return routed
+}
+
+func (f *emptyTopicFilter) anyEmpty(n int, resource func(i int) pcommon.Resource) bool {
+	for i := 0; i < n; i++ {
+		if f.topic(resource(i)) == "" {
+			return true
+		}
+	}
+	return false
+}
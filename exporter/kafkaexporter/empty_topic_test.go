@@ -0,0 +1,99 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+func TestEmptyTopicFilter_disabled(t *testing.T) {
+	assert.Nil(t, newEmptyTopicFilter(&Config{TopicFromAttribute: "tenant", EmptyTopic: emptyTopicDefault}, nil, nil))
+	assert.Nil(t, newEmptyTopicFilter(&Config{EmptyTopic: emptyTopicDrop}, nil, nil))
+}
+
+func TestEmptyTopicFilter(t *testing.T) {
+	f := newEmptyTopicFilter(&Config{TopicFromAttribute: "tenant", EmptyTopic: emptyTopicDrop}, nil, nil)
+	require.NotNil(t, f)
+
+	td := partitionKeyTraces()
+	td.ResourceSpans().At(1).Resource().Attributes().PutStr("tenant", "")
+	routed := f.traces(td)
+	assert.Equal(t, 2, routed.SpanCount())
+	assert.Equal(t, 4, td.SpanCount())
+	assert.Equal(t, 3, f.metrics(partitionKeyMetrics()).DataPointCount())
+	assert.Equal(t, 3, f.logs(partitionKeyLogs()).LogRecordCount())
+
+	// The resources with a topic are returned as is.
+	md := partitionKeyMetrics()
+	md.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+		_, ok := rm.Resource().Attributes().Get("tenant")
+		return !ok
+	})
+	assert.Equal(t, md, f.metrics(md))
+}
+
+func TestTracesPusher_empty_topic_drop(t *testing.T) {
+	views := exporterMetricViews()
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	var topics []string
+	producer := mocks.NewSyncProducer(t, nil)
+	for i := 0; i < 2; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(message *sarama.ProducerMessage) error {
+			topics = append(topics, message.Topic)
+			return nil
+		})
+	}
+	config := &Config{
+		Topic:              "otlp_spans",
+		TopicFromAttribute: "tenant",
+		EmptyTopic:         emptyTopicDrop,
+		Producer:           Producer{MaxMessageBytes: 1000 * 1000},
+	}
+	p := kafkaTracesProducer{
+		producer:   producer,
+		marshaler:  newPdataTracesMarshaler(&ptrace.ProtoMarshaler{}, defaultEncoding),
+		config:     config,
+		emptyTopic: newEmptyTopicFilter(config, nil, []tag.Mutator{tag.Upsert(tagInstanceName, component.NewID("kafka").String())}),
+		logger:     zap.NewNop(),
+	}
+
+	td := partitionKeyTraces()
+	td.ResourceSpans().At(2).Resource().Attributes().PutStr("tenant", "")
+	require.NoError(t, p.tracesPusher(context.Background(), td))
+	assert.ElementsMatch(t, []string{"acme", "globex"}, topics)
+	require.NoError(t, producer.Close())
+
+	rows, err := view.RetrieveData(statEmptyTopicRecordsDropped.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, float64(2), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestEstimateLogs_empty_topic_drop(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.TopicFromAttribute = "tenant"
+	cfg.EmptyTopic = emptyTopicDrop
+
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("record")
+	messages, bytes, err := EstimateLogs(*cfg, ld)
+	require.NoError(t, err)
+	assert.Equal(t, 0, messages)
+	assert.Equal(t, 0, bytes)
+}
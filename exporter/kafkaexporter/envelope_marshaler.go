@@ -0,0 +1,122 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"encoding/json"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// envelope is the JSON message produced by the envelope encodings. The payload holds
+// the telemetry of a single resource and is base64-encoded by encoding/json.
+type envelope struct {
+	Resource map[string]any `json:"resource"`
+	Payload  []byte         `json:"payload"`
+}
+
+func newEnvelopeMessage(resource pcommon.Resource, payload []byte, config *Config) (*sarama.ProducerMessage, error) {
+	bts, err := json.Marshal(envelope{
+		Resource: resource.Attributes().AsRaw(),
+		Payload:  payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sarama.ProducerMessage{
+		Topic: config.Topic,
+		Value: sarama.ByteEncoder(bts),
+	}, nil
+}
+
+// envelopeTracesMarshaler produces one envelope for every resource of the traces.
+type envelopeTracesMarshaler struct {
+	marshaler ptrace.Marshaler
+	encoding  string
+}
+
+func (e envelopeTracesMarshaler) Marshal(td ptrace.Traces, config *Config) ([]*sarama.ProducerMessage, error) {
+	messages := make([]*sarama.ProducerMessage, 0, td.ResourceSpans().Len())
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		single := ptrace.NewTraces()
+		rs.CopyTo(single.ResourceSpans().AppendEmpty())
+		payload, err := e.marshaler.MarshalTraces(single)
+		if err != nil {
+			return nil, err
+		}
+		message, err := newEnvelopeMessage(rs.Resource(), payload, config)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func (e envelopeTracesMarshaler) Encoding() string {
+	return e.encoding
+}
+
+// envelopeMetricsMarshaler produces one envelope for every resource of the metrics.
+type envelopeMetricsMarshaler struct {
+	marshaler pmetric.Marshaler
+	encoding  string
+}
+
+func (e envelopeMetricsMarshaler) Marshal(md pmetric.Metrics, config *Config) ([]*sarama.ProducerMessage, error) {
+	messages := make([]*sarama.ProducerMessage, 0, md.ResourceMetrics().Len())
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		single := pmetric.NewMetrics()
+		rm.CopyTo(single.ResourceMetrics().AppendEmpty())
+		payload, err := e.marshaler.MarshalMetrics(single)
+		if err != nil {
+			return nil, err
+		}
+		message, err := newEnvelopeMessage(rm.Resource(), payload, config)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func (e envelopeMetricsMarshaler) Encoding() string {
+	return e.encoding
+}
+
+// envelopeLogsMarshaler produces one envelope for every resource of the logs.
+type envelopeLogsMarshaler struct {
+	marshaler plog.Marshaler
+	encoding  string
+}
+
+func (e envelopeLogsMarshaler) Marshal(ld plog.Logs, config *Config) ([]*sarama.ProducerMessage, error) {
+	messages := make([]*sarama.ProducerMessage, 0, ld.ResourceLogs().Len())
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		single := plog.NewLogs()
+		rl.CopyTo(single.ResourceLogs().AppendEmpty())
+		payload, err := e.marshaler.MarshalLogs(single)
+		if err != nil {
+			return nil, err
+		}
+		message, err := newEnvelopeMessage(rl.Resource(), payload, config)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func (e envelopeLogsMarshaler) Encoding() string {
+	return e.encoding
+}
@@ -0,0 +1,91 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestEnvelopeTracesMarshaler(t *testing.T) {
+	td := ptrace.NewTraces()
+	for _, service := range []string{"foo", "bar"} {
+		rs := td.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("service.name", service)
+		rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span-" + service)
+	}
+
+	m := tracesMarshalers()["otlp_proto_envelope"]
+	messages, err := m.Marshal(td, &Config{Topic: "topic"})
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+
+	for i, service := range []string{"foo", "bar"} {
+		assert.Equal(t, "topic", messages[i].Topic)
+		bts, err := messages[i].Value.Encode()
+		require.NoError(t, err)
+
+		var raw struct {
+			Resource map[string]any `json:"resource"`
+			Payload  string         `json:"payload"`
+		}
+		require.NoError(t, json.Unmarshal(bts, &raw))
+		assert.Equal(t, map[string]any{"service.name": service}, raw.Resource)
+
+		payload, err := base64.StdEncoding.DecodeString(raw.Payload)
+		require.NoError(t, err)
+		decoded, err := (&ptrace.ProtoUnmarshaler{}).UnmarshalTraces(payload)
+		require.NoError(t, err)
+		expected := ptrace.NewTraces()
+		td.ResourceSpans().At(i).CopyTo(expected.ResourceSpans().AppendEmpty())
+		assert.Equal(t, expected, decoded)
+	}
+}
+
+func TestEnvelopeMetricsMarshaler(t *testing.T) {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "foo")
+	rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetName("metric")
+
+	messages, err := metricsMarshalers()["otlp_json_envelope"].Marshal(md, &Config{Topic: "topic"})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	bts, err := messages[0].Value.Encode()
+	require.NoError(t, err)
+
+	var env envelope
+	require.NoError(t, json.Unmarshal(bts, &env))
+	assert.Equal(t, map[string]any{"service.name": "foo"}, env.Resource)
+	decoded, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics(env.Payload)
+	require.NoError(t, err)
+	assert.Equal(t, md, decoded)
+}
+
+func TestEnvelopeLogsMarshaler(t *testing.T) {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "foo")
+	rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("log")
+
+	messages, err := logsMarshalers()["otlp_proto_envelope"].Marshal(ld, &Config{Topic: "topic"})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	bts, err := messages[0].Value.Encode()
+	require.NoError(t, err)
+
+	var env envelope
+	require.NoError(t, json.Unmarshal(bts, &env))
+	assert.Equal(t, map[string]any{"service.name": "foo"}, env.Resource)
+	decoded, err := (&plog.ProtoUnmarshaler{}).UnmarshalLogs(env.Payload)
+	require.NoError(t, err)
+	assert.Equal(t, ld, decoded)
+}
@@ -0,0 +1,186 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"context"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+// EstimateTraces returns the number of messages the traces exporter configured by cfg produces for td, and
+// their total size in bytes, headers included. No broker is contacted. The estimate runs the marshaling of the
+// exporter, drops the records without a topic with empty_topic drop and adds the headers of the exporter, but
+// neither drops the records older than max_record_age nor adds the baggage of the context of the export, which
+// depend on the time and on the context. The state of the exporter is that of a new one: the sequence header of
+// the messages of a topic is numbered from 1, as if the topic had a single partition. td is left unchanged.
+func EstimateTraces(cfg Config, td ptrace.Traces) (messages int, bytes int, err error) {
+	marshaler := tracesMarshalers()[cfg.Encoding]
+	if marshaler == nil {
+		return 0, 0, errUnrecognizedEncoding
+	}
+	cfg.Topic = signalTopic(cfg.Topic, cfg.TracesTopic, defaultTracesTopic)
+	if err = setKafkaProtoVersion(&cfg); err != nil {
+		return 0, 0, err
+	}
+	td = prepareTraces(&cfg, marshaler, newAttributeRenamer(cfg.AttributeRenames), td)
+	topicTemplate, err := compileTopicTemplate(cfg.TopicTemplate)
+	if err != nil {
+		return 0, 0, err
+	}
+	if emptyTopic := newEmptyTopicFilter(&cfg, topicTemplate, nil); emptyTopic != nil {
+		if td = emptyTopic.traces(td); td.SpanCount() == 0 {
+			return 0, 0, nil
+		}
+	}
+	produced, err := marshalTracesByTopic(marshaler, td, &cfg, topicTemplate, newResourceHeaders(context.Background(), &cfg, zap.NewNop()))
+	if err != nil {
+		return 0, 0, err
+	}
+	return estimateMessages(&cfg, produced)
+}
+
+// EstimateMetrics is the EstimateTraces of the metrics exporter. The repeats of coalesce_repeats are only those
+// within md, and the first_seen start timestamps of missing_start_timestamp those of the data points of md.
+func EstimateMetrics(cfg Config, md pmetric.Metrics) (messages int, bytes int, err error) {
+	marshaler := metricsMarshalers()[cfg.Encoding]
+	if marshaler == nil {
+		return 0, 0, errUnrecognizedEncoding
+	}
+	cfg.Topic = signalTopic(cfg.Topic, cfg.MetricsTopic, defaultMetricsTopic)
+	if err = setKafkaProtoVersion(&cfg); err != nil {
+		return 0, 0, err
+	}
+	var update *coalescerUpdate
+	if coalescer := newRepeatCoalescer(&cfg); coalescer != nil {
+		if md, update = coalescer.metrics(md); md.DataPointCount() == 0 {
+			return 0, 0, nil
+		}
+	}
+	md = prepareMetrics(&cfg, marshaler, newAttributeRenamer(cfg.AttributeRenames), newStartTimestamps(), md)
+	topicTemplate, err := compileTopicTemplate(cfg.TopicTemplate)
+	if err != nil {
+		return 0, 0, err
+	}
+	if emptyTopic := newEmptyTopicFilter(&cfg, topicTemplate, nil); emptyTopic != nil {
+		if md = emptyTopic.metrics(md); md.DataPointCount() == 0 {
+			return 0, 0, nil
+		}
+	}
+	produced, err := marshalMetricsByTopic(marshaler, md, &cfg, topicTemplate, newResourceHeaders(context.Background(), &cfg, zap.NewNop()))
+	if err != nil {
+		return 0, 0, err
+	}
+	addHeaders(produced, update.headers())
+	return estimateMessages(&cfg, produced)
+}
+
+// EstimateLogs is the EstimateTraces of the logs exporter.
+func EstimateLogs(cfg Config, ld plog.Logs) (messages int, bytes int, err error) {
+	marshaler := logsMarshalers()[cfg.Encoding]
+	if marshaler == nil {
+		return 0, 0, errUnrecognizedEncoding
+	}
+	cfg.Topic = signalTopic(cfg.Topic, cfg.LogsTopic, defaultLogsTopic)
+	if err = setKafkaProtoVersion(&cfg); err != nil {
+		return 0, 0, err
+	}
+	ld = prepareLogs(&cfg, marshaler, newAttributeRenamer(cfg.AttributeRenames), ld)
+	topicTemplate, err := compileTopicTemplate(cfg.TopicTemplate)
+	if err != nil {
+		return 0, 0, err
+	}
+	if emptyTopic := newEmptyTopicFilter(&cfg, topicTemplate, nil); emptyTopic != nil {
+		if ld = emptyTopic.logs(ld); ld.LogRecordCount() == 0 {
+			return 0, 0, nil
+		}
+	}
+	produced, err := marshalLogsByTopic(marshaler, ld, &cfg, topicTemplate, newResourceHeaders(context.Background(), &cfg, zap.NewNop()))
+	if err != nil {
+		return 0, 0, err
+	}
+	return estimateMessages(&cfg, produced)
+}
+
+// estimateMessages prepares messages like sendMessages, and returns their count and total size, with the sequence
+// header the producer adds if sequence_header is set. It fails like sendMessages if one of them is bigger than the
+// maximum size of its topic, unless no_split is set.
+func estimateMessages(config *Config, messages []*sarama.ProducerMessage) (int, int, error) {
+	messages, err := prepareMessages(config, messages)
+	if err != nil {
+		return 0, 0, err
+	}
+	bytes := 0
+	sequences := map[string]uint64{}
+	for _, message := range messages {
+		if !config.NoSplit && message.ByteSize(config.Producer.protoVersion) > config.maxMessageBytes(message.Topic) {
+			return 0, 0, errSingleKafkaProducerMessageSizeOverMaxMsgByte
+		}
+		if config.SequenceHeader {
+			sequences[message.Topic]++
+			appendSequenceHeader(message, sequences[message.Topic])
+		}
+		bytes += message.ByteSize(config.Producer.protoVersion)
+	}
+	return len(messages), bytes, nil
+}
+
+// prepareTraces returns td as it is marshaled by the traces exporter, with its attributes renamed and, for
+// the JSON encodings, sorted if the order is deterministic.
+func prepareTraces(config *Config, marshaler TracesMarshaler, renamer *attributeRenamer, td ptrace.Traces) ptrace.Traces {
+	if renamer != nil {
+		td = renamer.traces(td)
+	}
+	if config.DeterministicOrder && jsonEncodings[marshaler.Encoding()] {
+		td = sortedTraces(td)
+	}
+	return td
+}
+
+// prepareMetrics is the prepareTraces of the metrics exporter, which also applies missing_start_timestamp with the
+// first timestamps of the series of starts, if not nil, for the encodings it is used by.
+func prepareMetrics(config *Config, marshaler MetricsMarshaler, renamer *attributeRenamer, starts *startTimestamps, md pmetric.Metrics) pmetric.Metrics {
+	if renamer != nil {
+		md = renamer.metrics(md)
+	}
+	if starts != nil && startTimestampEncodings[marshaler.Encoding()] {
+		md = starts.apply(md, config.MissingStartTimestamp, config.FirstSeenExpiry)
+	}
+	if config.DeterministicOrder && jsonEncodings[marshaler.Encoding()] {
+		md = sortedMetrics(md)
+	}
+	return md
+}
+
+// prepareLogs is the prepareTraces of the logs exporter.
+func prepareLogs(config *Config, marshaler LogsMarshaler, renamer *attributeRenamer, ld plog.Logs) plog.Logs {
+	if renamer != nil {
+		ld = renamer.logs(ld)
+	}
+	if config.DeterministicOrder && jsonEncodings[marshaler.Encoding()] {
+		ld = sortedLogs(ld)
+	}
+	return ld
+}
+
+// prepareMessages compresses the payloads and trims the headers of the messages before they are produced,
+// and appends their index message if configured.
+func prepareMessages(config *Config, messages []*sarama.ProducerMessage) ([]*sarama.ProducerMessage, error) {
+	if err := compressPayloads(config.Producer.PayloadCompression, messages); err != nil {
+		return nil, err
+	}
+	trimHeaders(messages, config.MaxHeadersPerMessage)
+	index, err := indexMessage(config.Index, messages)
+	if err != nil {
+		return nil, err
+	}
+	if index != nil {
+		messages = append(messages, index)
+	}
+	return messages, nil
+}
@@ -0,0 +1,263 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
+)
+
+// inspectedSize returns an inspector counting the messages it receives and their total size.
+func inspectedSize(messages *int, bytes *int) MessageInspector {
+	return func(message *sarama.ProducerMessage) {
+		*messages++
+		*bytes += message.ByteSize(2)
+	}
+}
+
+// expectSends returns a producer expecting n messages.
+func expectSends(t *testing.T, n int) *mocks.SyncProducer {
+	producer := mocks.NewSyncProducer(t, sarama.NewConfig())
+	for i := 0; i < n; i++ {
+		producer.ExpectSendMessageAndSucceed()
+	}
+	return producer
+}
+
+func TestEstimateTraces(t *testing.T) {
+	for encoding := range tracesMarshalers() {
+		t.Run(encoding, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.Encoding = encoding
+			td := genJaegerTracesData(3)
+			messages, bytes, err := EstimateTraces(*cfg, td)
+			require.NoError(t, err)
+			assert.Positive(t, messages)
+			assert.Positive(t, bytes)
+			assert.Equal(t, 3, td.SpanCount())
+
+			var sent, sentBytes int
+			p := kafkaTracesProducer{
+				producer:  expectSends(t, messages),
+				marshaler: tracesMarshalers()[encoding],
+				config:    cfg,
+				logger:    zap.NewNop(),
+				inspector: inspectedSize(&sent, &sentBytes),
+			}
+			require.NoError(t, setKafkaProtoVersion(p.config))
+			require.NoError(t, p.tracesPusher(context.Background(), td))
+			require.NoError(t, p.Close(context.Background()))
+			assert.Equal(t, sent, messages)
+			assert.Equal(t, sentBytes, bytes)
+		})
+	}
+}
+
+func TestEstimateMetrics(t *testing.T) {
+	for encoding := range metricsMarshalers() {
+		t.Run(encoding, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.Encoding = encoding
+			md := testdata.GenerateMetricsTwoMetrics()
+			messages, bytes, err := EstimateMetrics(*cfg, md)
+			require.NoError(t, err)
+			assert.Positive(t, messages)
+			assert.Positive(t, bytes)
+
+			var sent, sentBytes int
+			p := kafkaMetricsProducer{
+				producer:  expectSends(t, messages),
+				marshaler: metricsMarshalers()[encoding],
+				config:    cfg,
+				logger:    zap.NewNop(),
+				inspector: inspectedSize(&sent, &sentBytes),
+			}
+			require.NoError(t, setKafkaProtoVersion(p.config))
+			require.NoError(t, p.metricsDataPusher(context.Background(), md))
+			require.NoError(t, p.Close(context.Background()))
+			assert.Equal(t, sent, messages)
+			assert.Equal(t, sentBytes, bytes)
+		})
+	}
+}
+
+func TestEstimateLogs(t *testing.T) {
+	for encoding := range logsMarshalers() {
+		t.Run(encoding, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.Encoding = encoding
+			ld := testdata.GenerateLogsTwoLogRecordsSameResource()
+			messages, bytes, err := EstimateLogs(*cfg, ld)
+			require.NoError(t, err)
+			assert.Positive(t, messages)
+			assert.Positive(t, bytes)
+
+			var sent, sentBytes int
+			p := kafkaLogsProducer{
+				producer:  expectSends(t, messages),
+				marshaler: logsMarshalers()[encoding],
+				config:    cfg,
+				logger:    zap.NewNop(),
+				inspector: inspectedSize(&sent, &sentBytes),
+			}
+			require.NoError(t, setKafkaProtoVersion(p.config))
+			require.NoError(t, p.logsDataPusher(context.Background(), ld))
+			require.NoError(t, p.Close(context.Background()))
+			assert.Equal(t, sent, messages)
+			assert.Equal(t, sentBytes, bytes)
+		})
+	}
+}
+
+func TestEstimate_granularity(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	md := testdata.GenerateMetricsManyMetricsSameResource(10)
+	perRequest, _, err := EstimateMetrics(*cfg, md)
+	require.NoError(t, err)
+	assert.Equal(t, 1, perRequest)
+
+	cfg.MetricsGranularity = metricsGranularityPerDataPoint
+	perDataPoint, _, err := EstimateMetrics(*cfg, md)
+	require.NoError(t, err)
+	assert.Equal(t, md.DataPointCount(), perDataPoint)
+}
+
+func TestEstimate_errors(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Encoding = "jaeger_proto"
+	_, _, err := EstimateMetrics(*cfg, testdata.GenerateMetricsOneMetric())
+	assert.ErrorIs(t, err, errUnrecognizedEncoding)
+	_, _, err = EstimateLogs(*cfg, testdata.GenerateLogsOneLogRecord())
+	assert.ErrorIs(t, err, errUnrecognizedEncoding)
+
+	cfg.Producer.MaxMessageBytes = 10
+	_, _, err = EstimateTraces(*cfg, genJaegerTracesData(1))
+	assert.ErrorIs(t, err, errSingleKafkaProducerMessageSizeOverMaxMsgByte)
+}
+
+// expectSizedSends returns a producer expecting n messages, numbered like the exporter with sequence_header on a
+// single partition, and counting their total size once partitioned.
+func expectSizedSends(t *testing.T, n int, bytes *int) *mocks.SyncProducer {
+	c := sarama.NewConfig()
+	c.Producer.Partitioner = newSequencePartitioner(true, sarama.NewManualPartitioner)
+	producer := mocks.NewSyncProducer(t, c)
+	for i := 0; i < n; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			*bytes += msg.ByteSize(2)
+			return nil
+		})
+	}
+	return producer
+}
+
+func TestEstimate_headers(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.HeadersFromAttributes = []string{"service.name"}
+	cfg.Baggage = Baggage{Attribute: "baggage"}
+	cfg.MaxHeaderValueBytes = 8
+	cfg.SequenceHeader = true
+	cfg.CoalesceRepeats = true
+	cfg.PartitionKey = "resource_attribute:service.name"
+	withResources := func(resources func(i int) pcommon.Resource) {
+		for i, service := range []string{"checkout-service", "cart"} {
+			resource := resources(i)
+			resource.Attributes().PutStr("service.name", service)
+			resource.Attributes().PutStr("baggage", "owner=team-"+service)
+		}
+	}
+
+	t.Run("traces", func(t *testing.T) {
+		td := ptrace.NewTraces()
+		withResources(func(int) pcommon.Resource {
+			rs := td.ResourceSpans().AppendEmpty()
+			rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+			return rs.Resource()
+		})
+		messages, bytes, err := EstimateTraces(*cfg, td)
+		require.NoError(t, err)
+		assert.Equal(t, 2, messages)
+
+		var sentBytes int
+		p := kafkaTracesProducer{
+			producer:  expectSizedSends(t, messages, &sentBytes),
+			marshaler: tracesMarshalers()[cfg.Encoding],
+			config:    cfg,
+			logger:    zap.NewNop(),
+		}
+		require.NoError(t, setKafkaProtoVersion(p.config))
+		require.NoError(t, p.tracesPusher(context.Background(), td))
+		require.NoError(t, p.Close(context.Background()))
+		assert.Equal(t, sentBytes, bytes)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		md := pmetric.NewMetrics()
+		withResources(func(int) pcommon.Resource {
+			rm := md.ResourceMetrics().AppendEmpty()
+			sum := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+			sum.SetName("requests")
+			sum.SetEmptySum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+			// The repeat of the second data point is dropped, and the third one gets the coalesced-repeats header.
+			for _, value := range []int64{1, 1, 2} {
+				dp := sum.Sum().DataPoints().AppendEmpty()
+				dp.SetStartTimestamp(1)
+				dp.SetIntValue(value)
+			}
+			return rm.Resource()
+		})
+		messages, bytes, err := EstimateMetrics(*cfg, md)
+		require.NoError(t, err)
+		assert.Equal(t, 2, messages)
+
+		var sentBytes int
+		p := kafkaMetricsProducer{
+			producer:        expectSizedSends(t, messages, &sentBytes),
+			marshaler:       metricsMarshalers()[cfg.Encoding],
+			config:          cfg,
+			coalescer:       newRepeatCoalescer(cfg),
+			startTimestamps: newStartTimestamps(),
+			logger:          zap.NewNop(),
+		}
+		require.NoError(t, setKafkaProtoVersion(p.config))
+		require.NoError(t, p.metricsDataPusher(context.Background(), md))
+		require.NoError(t, p.Close(context.Background()))
+		assert.Equal(t, sentBytes, bytes)
+	})
+
+	t.Run("logs", func(t *testing.T) {
+		ld := plog.NewLogs()
+		withResources(func(int) pcommon.Resource {
+			rl := ld.ResourceLogs().AppendEmpty()
+			rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("log")
+			return rl.Resource()
+		})
+		messages, bytes, err := EstimateLogs(*cfg, ld)
+		require.NoError(t, err)
+		assert.Equal(t, 2, messages)
+
+		var sentBytes int
+		p := kafkaLogsProducer{
+			producer:  expectSizedSends(t, messages, &sentBytes),
+			marshaler: logsMarshalers()[cfg.Encoding],
+			config:    cfg,
+			logger:    zap.NewNop(),
+		}
+		require.NoError(t, setKafkaProtoVersion(p.config))
+		require.NoError(t, p.logsDataPusher(context.Background(), ld))
+		require.NoError(t, p.Close(context.Background()))
+		assert.Equal(t, sentBytes, bytes)
+	})
+}
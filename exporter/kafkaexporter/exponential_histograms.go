@@ -0,0 +1,118 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"math"
+
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+const (
+	exponentialHistogramsKeep     = "keep"
+	exponentialHistogramsExplicit = "explicit"
+)
+
+// explicitHistograms returns md with its exponential histograms converted to explicit bucket histograms if
+// policy is explicit. md is left unchanged, and returned as is if it has no exponential histogram.
+func explicitHistograms(md pmetric.Metrics, policy string) pmetric.Metrics {
+	if policy != exponentialHistogramsExplicit || !hasExponentialHistograms(md) {
+		return md
+	}
+	converted := pmetric.NewMetrics()
+	md.CopyTo(converted)
+	for i := 0; i < converted.ResourceMetrics().Len(); i++ {
+		rm := converted.ResourceMetrics().At(i)
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			metrics := rm.ScopeMetrics().At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				if m := metrics.At(k); m.Type() == pmetric.MetricTypeExponentialHistogram {
+					toExplicitHistogram(m)
+				}
+			}
+		}
+	}
+	return converted
+}
+
+func hasExponentialHistograms(md pmetric.Metrics) bool {
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			metrics := rm.ScopeMetrics().At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				if metrics.At(k).Type() == pmetric.MetricTypeExponentialHistogram {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// toExplicitHistogram replaces the exponential histogram of m by the explicit bucket histogram of the same data points.
+func toExplicitHistogram(m pmetric.Metric) {
+	exponential := pmetric.NewExponentialHistogram()
+	m.ExponentialHistogram().MoveTo(exponential)
+	histogram := m.SetEmptyHistogram()
+	histogram.SetAggregationTemporality(exponential.AggregationTemporality())
+	points := exponential.DataPoints()
+	histogram.DataPoints().EnsureCapacity(points.Len())
+	for i := 0; i < points.Len(); i++ {
+		toExplicitDataPoint(points.At(i), histogram.DataPoints().AppendEmpty())
+	}
+}
+
+// toExplicitDataPoint sets dest to the explicit bucket data point of src. The buckets are, in order: an empty
+// bucket from negative infinity, the negative buckets, the zero bucket up to the smallest positive bound, the
+// positive buckets, and an empty bucket up to positive infinity. This is the conversion of the Tanzu
+// Observability exporter.
+func toExplicitDataPoint(src pmetric.ExponentialHistogramDataPoint, dest pmetric.HistogramDataPoint) {
+	src.Attributes().CopyTo(dest.Attributes())
+	dest.SetStartTimestamp(src.StartTimestamp())
+	dest.SetTimestamp(src.Timestamp())
+	dest.SetCount(src.Count())
+	if src.HasSum() {
+		dest.SetSum(src.Sum())
+	}
+	if src.HasMin() {
+		dest.SetMin(src.Min())
+	}
+	if src.HasMax() {
+		dest.SetMax(src.Max())
+	}
+	dest.SetFlags(src.Flags())
+	src.Exemplars().CopyTo(dest.Exemplars())
+
+	// base is the factor by which the bounds of the buckets grow, see the definition of the scale in
+	// the OTLP metrics proto.
+	base := math.Pow(2, math.Pow(2, -float64(src.Scale())))
+	negative := src.Negative().BucketCounts().AsRaw()
+	positive := src.Positive().BucketCounts().AsRaw()
+	counts := make([]uint64, 0, len(negative)+len(positive)+3)
+	bounds := make([]float64, 0, len(negative)+len(positive)+2)
+
+	// The negative buckets go from the biggest magnitude to the smallest one.
+	counts = append(counts, 0)
+	bound := -math.Pow(base, float64(src.Negative().Offset())+float64(len(negative)))
+	bounds = append(bounds, bound)
+	for i := len(negative) - 1; i >= 0; i-- {
+		counts = append(counts, negative[i])
+		bound /= base
+		bounds = append(bounds, bound)
+	}
+
+	counts = append(counts, src.ZeroCount())
+	bound = math.Pow(base, float64(src.Positive().Offset()))
+	bounds = append(bounds, bound)
+	for _, count := range positive {
+		counts = append(counts, count)
+		bound *= base
+		bounds = append(bounds, bound)
+	}
+	counts = append(counts, 0)
+
+	dest.BucketCounts().FromRaw(counts)
+	dest.ExplicitBounds().FromRaw(bounds)
+}
@@ -0,0 +1,79 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func exponentialHistogram() pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName("latency")
+	m.SetUnit("ms")
+	eh := m.SetEmptyExponentialHistogram()
+	eh.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	dp := eh.DataPoints().AppendEmpty()
+	dp.Attributes().PutStr("route", "/")
+	dp.SetStartTimestamp(100)
+	dp.SetTimestamp(200)
+	dp.SetCount(10)
+	dp.SetSum(21)
+	dp.SetMin(-1.5)
+	dp.SetMax(7)
+	dp.SetScale(0)
+	dp.SetZeroCount(2)
+	dp.Negative().BucketCounts().FromRaw([]uint64{1})
+	dp.Positive().SetOffset(1)
+	dp.Positive().BucketCounts().FromRaw([]uint64{3, 4})
+	gauge := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().AppendEmpty()
+	gauge.SetName("temperature")
+	gauge.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(20)
+	return md
+}
+
+func TestPdataMetricsMarshaler_exponential_histograms_explicit(t *testing.T) {
+	p := newPdataMetricsMarshaler(&pmetric.ProtoMarshaler{}, defaultEncoding)
+	md := exponentialHistogram()
+	messages, err := p.Marshal(md, &Config{Topic: "topic", ExponentialHistograms: exponentialHistogramsExplicit})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+
+	metrics := unmarshalMessageMetrics(t, messages[0]).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 2, metrics.Len())
+	m := metrics.At(0)
+	require.Equal(t, pmetric.MetricTypeHistogram, m.Type())
+	assert.Equal(t, "latency", m.Name())
+	assert.Equal(t, "ms", m.Unit())
+	assert.Equal(t, pmetric.AggregationTemporalityDelta, m.Histogram().AggregationTemporality())
+	require.Equal(t, 1, m.Histogram().DataPoints().Len())
+	dp := m.Histogram().DataPoints().At(0)
+	assert.Equal(t, map[string]any{"route": "/"}, dp.Attributes().AsRaw())
+	assert.Equal(t, pcommon.Timestamp(100), dp.StartTimestamp())
+	assert.Equal(t, pcommon.Timestamp(200), dp.Timestamp())
+	assert.Equal(t, uint64(10), dp.Count())
+	assert.Equal(t, 21.0, dp.Sum())
+	assert.Equal(t, -1.5, dp.Min())
+	assert.Equal(t, 7.0, dp.Max())
+	// The negative bucket holds (-2, -1], the zero bucket (-1, 2] and the positive buckets (2, 4] and (4, 8].
+	assert.Equal(t, []float64{-2, -1, 2, 4, 8}, dp.ExplicitBounds().AsRaw())
+	assert.Equal(t, []uint64{0, 1, 2, 3, 4, 0}, dp.BucketCounts().AsRaw())
+	assert.Equal(t, pmetric.MetricTypeGauge, metrics.At(1).Type())
+
+	assert.Equal(t, pmetric.MetricTypeExponentialHistogram, md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Type())
+}
+
+func TestPdataMetricsMarshaler_exponential_histograms_keep(t *testing.T) {
+	p := newPdataMetricsMarshaler(&pmetric.ProtoMarshaler{}, defaultEncoding)
+	messages, err := p.Marshal(exponentialHistogram(), &Config{Topic: "topic", ExponentialHistograms: exponentialHistogramsKeep})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	m := unmarshalMessageMetrics(t, messages[0]).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+	assert.Equal(t, pmetric.MetricTypeExponentialHistogram, m.Type())
+}
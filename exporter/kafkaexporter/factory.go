@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/IBM/sarama"
+	"go.opencensus.io/stats/view"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/exporter"
@@ -31,11 +32,43 @@ const (
 	// default max.message.bytes for the producer
 	defaultProducerMaxMessageBytes = 1000000
 	// default required_acks for the producer
-	defaultProducerRequiredAcks = sarama.WaitForLocal
+	defaultProducerRequiredAcks = RequiredAcks(sarama.WaitForLocal)
 	// default from sarama.NewConfig()
 	defaultCompression = "none"
+	// default number of messages sampled to select the codec if compression is auto
+	defaultAutoCompressionSamples = 10
+	// default computes the shares of the partitions over the last 1000 messages of a topic
+	defaultHotPartitionWindow      = 1000
+	defaultStartupProbePayloadSize = 64
+	defaultStartupProbeTimeout     = 10 * time.Second
+	// default produces the message values as marshaled
+	defaultPayloadCompression = payloadCompressionNone
 	// default from sarama.NewConfig()
 	defaultFluxMaxMessages = 0
+	// default produces one message per request
+	defaultMetricsGranularity = metricsGranularityPerRequest
+	// default produces the cumulative data points without start timestamp as they are
+	defaultMissingStartTimestamp = startTimestampKeep
+	// default forgets the first timestamp of the series without a data point for an hour
+	defaultFirstSeenExpiry = time.Hour
+	// default produces the repeats of a series at least every 5 minutes
+	defaultCoalesceRepeatsMaxInterval = 5 * time.Minute
+	// default forgets the last value of the series without a data point for an hour
+	defaultCoalesceRepeatsExpiry = time.Hour
+	// default produces the exponential histograms as they are
+	defaultExponentialHistograms = exponentialHistogramsKeep
+	// default produces the log messages without key
+	defaultLogsKey = logsKeyNone
+	// default registers the Avro schemas under "<topic>-value"
+	defaultSubjectNameStrategy = subjectNameStrategyTopicName
+	// default produces the resources without a dynamic topic to the topic
+	defaultEmptyTopic = emptyTopicDefault
+	// default produces the messages of the otlp encodings without key
+	defaultPartitionKey = partitionKeyNone
+	// default fails the requests with items exceeding max_message_bytes
+	defaultOnUnsplittable = unsplittableError
+	// default source of the events of the cloudEvents encodings
+	defaultCloudEventsSource = "otelcol/kafkaexporter"
 )
 
 // FactoryOption applies changes to kafkaExporterFactory.
@@ -68,8 +101,23 @@ func WithLogsMarshalers(logsMarshalers ...LogsMarshaler) FactoryOption {
 	}
 }
 
+// MessageInspector receives every message produced by the exporters, once marshaled and right before
+// it is sent, e.g. to debug the topics, keys, headers and sizes of the messages. It is called
+// concurrently by the exporters, and must not modify the message.
+type MessageInspector func(message *sarama.ProducerMessage)
+
+// WithMessageInspector sets the MessageInspector of the exporters created by the factory.
+func WithMessageInspector(inspector MessageInspector) FactoryOption {
+	return func(factory *kafkaExporterFactory) {
+		factory.inspector = inspector
+	}
+}
+
 // NewFactory creates Kafka exporter factory.
 func NewFactory(options ...FactoryOption) exporter.Factory {
+	_ = view.Register(MetricViews()...)
+	_ = view.Register(exporterMetricViews()...)
+
 	f := &kafkaExporterFactory{
 		tracesMarshalers:  tracesMarshalers(),
 		metricsMarshalers: metricsMarshalers(),
@@ -94,8 +142,28 @@ func createDefaultConfig() component.Config {
 		QueueSettings:   exporterhelper.NewDefaultQueueSettings(),
 		Brokers:         []string{defaultBroker},
 		// using an empty topic to track when it has not been set by user, default is based on traces or metrics.
-		Topic:    "",
-		Encoding: defaultEncoding,
+		Topic:                      "",
+		Encoding:                   defaultEncoding,
+		EmptyTopic:                 defaultEmptyTopic,
+		SubjectNameStrategy:        defaultSubjectNameStrategy,
+		CloudEventsSource:          defaultCloudEventsSource,
+		MetricsGranularity:         defaultMetricsGranularity,
+		MissingStartTimestamp:      defaultMissingStartTimestamp,
+		FirstSeenExpiry:            defaultFirstSeenExpiry,
+		CoalesceRepeatsMaxInterval: defaultCoalesceRepeatsMaxInterval,
+		CoalesceRepeatsExpiry:      defaultCoalesceRepeatsExpiry,
+		ExponentialHistograms:      defaultExponentialHistograms,
+		LogsKey:                    defaultLogsKey,
+		PartitionKey:               defaultPartitionKey,
+		OnUnsplittable: OnUnsplittable{
+			Traces:  defaultOnUnsplittable,
+			Metrics: defaultOnUnsplittable,
+			Logs:    defaultOnUnsplittable,
+		},
+		StartupProbe: StartupProbe{
+			PayloadSize: defaultStartupProbePayloadSize,
+			Timeout:     defaultStartupProbeTimeout,
+		},
 		Metadata: Metadata{
 			Full: defaultMetadataFull,
 			Retry: MetadataRetry{
@@ -104,9 +172,16 @@ func createDefaultConfig() component.Config {
 			},
 		},
 		Producer: Producer{
-			MaxMessageBytes:  defaultProducerMaxMessageBytes,
-			RequiredAcks:     defaultProducerRequiredAcks,
-			Compression:      defaultCompression,
+			MaxMessageBytes: defaultProducerMaxMessageBytes,
+			RequiredAcks:    defaultProducerRequiredAcks,
+			Compression:     defaultCompression,
+			AutoCompression: AutoCompression{
+				Samples: defaultAutoCompressionSamples,
+			},
+			PayloadCompression: defaultPayloadCompression,
+			HotPartition: HotPartition{
+				Window: defaultHotPartitionWindow,
+			},
 			FlushMaxMessages: defaultFluxMaxMessages,
 		},
 	}
@@ -116,6 +191,7 @@ type kafkaExporterFactory struct {
 	tracesMarshalers  map[string]TracesMarshaler
 	metricsMarshalers map[string]MetricsMarshaler
 	logsMarshalers    map[string]LogsMarshaler
+	inspector         MessageInspector
 }
 
 func (f *kafkaExporterFactory) createTracesExporter(
@@ -124,9 +200,7 @@ func (f *kafkaExporterFactory) createTracesExporter(
 	cfg component.Config,
 ) (exporter.Traces, error) {
 	oCfg := *(cfg.(*Config)) // Clone the config
-	if oCfg.Topic == "" {
-		oCfg.Topic = defaultTracesTopic
-	}
+	oCfg.Topic = signalTopic(oCfg.Topic, oCfg.TracesTopic, defaultTracesTopic)
 	if oCfg.Encoding == "otlp_json" {
 		set.Logger.Info("otlp_json is considered experimental and should not be used in a production environment")
 	}
@@ -134,6 +208,7 @@ func (f *kafkaExporterFactory) createTracesExporter(
 	if err != nil {
 		return nil, err
 	}
+	exp.inspector = f.inspector
 	return exporterhelper.NewTracesExporter(
 		ctx,
 		set,
@@ -145,6 +220,7 @@ func (f *kafkaExporterFactory) createTracesExporter(
 		exporterhelper.WithTimeout(exporterhelper.TimeoutSettings{Timeout: 0}),
 		exporterhelper.WithRetry(oCfg.RetrySettings),
 		exporterhelper.WithQueue(oCfg.QueueSettings),
+		exporterhelper.WithStart(exp.probe.start),
 		exporterhelper.WithShutdown(exp.Close))
 }
 
@@ -154,9 +230,7 @@ func (f *kafkaExporterFactory) createMetricsExporter(
 	cfg component.Config,
 ) (exporter.Metrics, error) {
 	oCfg := *(cfg.(*Config)) // Clone the config
-	if oCfg.Topic == "" {
-		oCfg.Topic = defaultMetricsTopic
-	}
+	oCfg.Topic = signalTopic(oCfg.Topic, oCfg.MetricsTopic, defaultMetricsTopic)
 	if oCfg.Encoding == "otlp_json" {
 		set.Logger.Info("otlp_json is considered experimental and should not be used in a production environment")
 	}
@@ -164,6 +238,7 @@ func (f *kafkaExporterFactory) createMetricsExporter(
 	if err != nil {
 		return nil, err
 	}
+	exp.inspector = f.inspector
 	return exporterhelper.NewMetricsExporter(
 		ctx,
 		set,
@@ -175,6 +250,7 @@ func (f *kafkaExporterFactory) createMetricsExporter(
 		exporterhelper.WithTimeout(exporterhelper.TimeoutSettings{Timeout: 0}),
 		exporterhelper.WithRetry(oCfg.RetrySettings),
 		exporterhelper.WithQueue(oCfg.QueueSettings),
+		exporterhelper.WithStart(exp.probe.start),
 		exporterhelper.WithShutdown(exp.Close))
 }
 
@@ -184,9 +260,7 @@ func (f *kafkaExporterFactory) createLogsExporter(
 	cfg component.Config,
 ) (exporter.Logs, error) {
 	oCfg := *(cfg.(*Config)) // Clone the config
-	if oCfg.Topic == "" {
-		oCfg.Topic = defaultLogsTopic
-	}
+	oCfg.Topic = signalTopic(oCfg.Topic, oCfg.LogsTopic, defaultLogsTopic)
 	if oCfg.Encoding == "otlp_json" {
 		set.Logger.Info("otlp_json is considered experimental and should not be used in a production environment")
 	}
@@ -194,6 +268,7 @@ func (f *kafkaExporterFactory) createLogsExporter(
 	if err != nil {
 		return nil, err
 	}
+	exp.inspector = f.inspector
 	return exporterhelper.NewLogsExporter(
 		ctx,
 		set,
@@ -205,5 +280,6 @@ func (f *kafkaExporterFactory) createLogsExporter(
 		exporterhelper.WithTimeout(exporterhelper.TimeoutSettings{Timeout: 0}),
 		exporterhelper.WithRetry(oCfg.RetrySettings),
 		exporterhelper.WithQueue(oCfg.QueueSettings),
+		exporterhelper.WithStart(exp.probe.start),
 		exporterhelper.WithShutdown(exp.Close))
 }
@@ -11,6 +11,7 @@ import (
 
 	"github.com/IBM/sarama"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/exporter/exportertest"
 	"go.opentelemetry.io/collector/pdata/plog"
@@ -78,6 +79,19 @@ func TestCreateDefaultConfig(t *testing.T) {
 	assert.Equal(t, "", cfg.Topic)
 }
 
+func TestWithMessageInspector(t *testing.T) {
+	var inspected []*sarama.ProducerMessage
+	f := &kafkaExporterFactory{}
+	WithMessageInspector(func(message *sarama.ProducerMessage) {
+		inspected = append(inspected, message)
+	})(f)
+
+	require.NotNil(t, f.inspector)
+	message := &sarama.ProducerMessage{Topic: "topic"}
+	f.inspector(message)
+	assert.Equal(t, []*sarama.ProducerMessage{message}, inspected)
+}
+
 func TestCreateMetricExporter(t *testing.T) {
 	t.Parallel()
 
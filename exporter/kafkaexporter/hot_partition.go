@@ -0,0 +1,86 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// spilledFromPartitionHeader is the header of the messages spilled from a hot partition, holding the partition
+// their key hashes to.
+const spilledFromPartitionHeader = "spilled-from-partition"
+
+// newSpilloverPartitioner returns the constructor of the partitioners of the topics, or nil if config is disabled.
+func newSpilloverPartitioner(config HotPartition) sarama.PartitionerConstructor {
+	if config.MaxShare <= 0 {
+		return nil
+	}
+	return func(topic string) sarama.Partitioner {
+		return &spilloverPartitioner{
+			hash:     sarama.NewHashPartitioner(topic),
+			maxShare: config.MaxShare,
+			recent:   make([]int32, 0, config.Window),
+			counts:   map[int32]int{},
+		}
+	}
+}
+
+// spilloverPartitioner partitions the messages by the hash of their key, like the default partitioner, but
+// produces the keyed messages to the next partition while their partition receives more than maxShare of the
+// recent messages of the topic. Spilled messages get the spilled-from-partition header.
+type spilloverPartitioner struct {
+	hash     sarama.Partitioner
+	maxShare float64
+
+	mu sync.Mutex
+	// recent are the partitions of the last messages, used as a ring buffer once full.
+	recent []int32
+	next   int
+	counts map[int32]int
+}
+
+func (p *spilloverPartitioner) Partition(message *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	partition, err := p.hash.Partition(message, numPartitions)
+	if err != nil {
+		return -1, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if message.Key != nil && numPartitions > 1 && p.isHot(partition) {
+		message.Headers = append(message.Headers, sarama.RecordHeader{
+			Key:   []byte(spilledFromPartitionHeader),
+			Value: []byte(strconv.Itoa(int(partition))),
+		})
+		partition = (partition + 1) % numPartitions
+	}
+	p.record(partition)
+	return partition, nil
+}
+
+func (p *spilloverPartitioner) RequiresConsistency() bool {
+	return p.hash.RequiresConsistency()
+}
+
+// isHot tells whether partition received more than maxShare of the recent messages. No partition is hot
+// until the window is full.
+func (p *spilloverPartitioner) isHot(partition int32) bool {
+	if len(p.recent) < cap(p.recent) {
+		return false
+	}
+	return float64(p.counts[partition]) > p.maxShare*float64(len(p.recent))
+}
+
+func (p *spilloverPartitioner) record(partition int32) {
+	if len(p.recent) < cap(p.recent) {
+		p.recent = append(p.recent, partition)
+	} else {
+		p.counts[p.recent[p.next]]--
+		p.recent[p.next] = partition
+		p.next = (p.next + 1) % len(p.recent)
+	}
+	p.counts[partition]++
+}
@@ -0,0 +1,60 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpilloverPartitioner_hot_key(t *testing.T) {
+	partitioner := newSpilloverPartitioner(HotPartition{MaxShare: 0.5, Window: 100})("topic")
+	hashed, err := sarama.NewHashPartitioner("topic").Partition(&sarama.ProducerMessage{Key: sarama.StringEncoder("hot")}, 8)
+	require.NoError(t, err)
+
+	counts := map[int32]int{}
+	spilled := 0
+	for i := 0; i < 1000; i++ {
+		key := "hot"
+		if i%4 == 0 {
+			key = "cold-" + strconv.Itoa(i)
+		}
+		message := &sarama.ProducerMessage{Key: sarama.StringEncoder(key)}
+		partition, err := partitioner.Partition(message, 8)
+		require.NoError(t, err)
+		counts[partition]++
+		if len(message.Headers) == 0 {
+			continue
+		}
+		// The cold keys of the hot partition are spilled alike.
+		spilled++
+		assert.Equal(t, (hashed+1)%8, partition)
+		assert.Equal(t, []sarama.RecordHeader{{
+			Key:   []byte(spilledFromPartitionHeader),
+			Value: []byte(strconv.Itoa(int(hashed))),
+		}}, message.Headers)
+	}
+	assert.Greater(t, spilled, 0)
+	// The hot partition stays around its maximum share once the window is full.
+	assert.Less(t, counts[hashed], 600)
+}
+
+func TestSpilloverPartitioner_unkeyed(t *testing.T) {
+	partitioner := newSpilloverPartitioner(HotPartition{MaxShare: 0.1, Window: 10})("topic")
+	for i := 0; i < 100; i++ {
+		message := &sarama.ProducerMessage{}
+		_, err := partitioner.Partition(message, 1)
+		require.NoError(t, err)
+		assert.Empty(t, message.Headers)
+	}
+	assert.True(t, partitioner.RequiresConsistency())
+}
+
+func TestSpilloverPartitioner_disabled(t *testing.T) {
+	assert.Nil(t, newSpilloverPartitioner(HotPartition{Window: 1000}))
+}
@@ -0,0 +1,50 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"encoding/binary"
+
+	"github.com/IBM/sarama"
+)
+
+// indexVersion is the first byte of the index messages, identifying their layout.
+const indexVersion = 1
+
+// indexMessage returns the message indexing the messages of an export, or nil if the index has no topic.
+//
+// The value of the index is the version byte, followed by the number of messages and, for every message,
+// the length of its key followed by its key, then the offset and the length of its value within the payload
+// of the export, which is the values of its messages one after the other. The numbers are unsigned varints,
+// and messages without key have a key of length 0.
+func indexMessage(config Index, messages []*sarama.ProducerMessage) (*sarama.ProducerMessage, error) {
+	if config.Topic == "" {
+		return nil, nil
+	}
+	value := []byte{indexVersion}
+	value = binary.AppendUvarint(value, uint64(len(messages)))
+	offset := 0
+	for _, message := range messages {
+		var key []byte
+		if message.Key != nil {
+			var err error
+			if key, err = message.Key.Encode(); err != nil {
+				return nil, err
+			}
+		}
+		value = binary.AppendUvarint(value, uint64(len(key)))
+		value = append(value, key...)
+		length := 0
+		if message.Value != nil {
+			length = message.Value.Length()
+		}
+		value = binary.AppendUvarint(value, uint64(offset))
+		value = binary.AppendUvarint(value, uint64(length))
+		offset += length
+	}
+	return &sarama.ProducerMessage{
+		Topic: config.Topic,
+		Value: sarama.ByteEncoder(value),
+	}, nil
+}
@@ -0,0 +1,105 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type indexEntry struct {
+	key    string
+	offset uint64
+	length uint64
+}
+
+// decodeIndex returns the entries of the value of an index message.
+func decodeIndex(t *testing.T, value []byte) []indexEntry {
+	require.Equal(t, byte(indexVersion), value[0])
+	value = value[1:]
+	next := func() uint64 {
+		n, size := binary.Uvarint(value)
+		require.Positive(t, size)
+		value = value[size:]
+		return n
+	}
+	entries := make([]indexEntry, next())
+	for i := range entries {
+		keyLength := next()
+		entries[i].key = string(value[:keyLength])
+		value = value[keyLength:]
+		entries[i].offset = next()
+		entries[i].length = next()
+	}
+	assert.Empty(t, value)
+	return entries
+}
+
+func TestIndexMessage(t *testing.T) {
+	messages := []*sarama.ProducerMessage{
+		{Topic: "spans", Key: sarama.StringEncoder("trace-1"), Value: sarama.ByteEncoder("abc")},
+		{Topic: "spans", Value: sarama.ByteEncoder("de")},
+		{Topic: "spans", Key: sarama.ByteEncoder("trace-2"), Value: sarama.ByteEncoder("fghij")},
+	}
+
+	index, err := indexMessage(Index{Topic: "spans-index"}, messages)
+	require.NoError(t, err)
+	require.NotNil(t, index)
+	assert.Equal(t, "spans-index", index.Topic)
+	assert.Nil(t, index.Key)
+	value, err := index.Value.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, []indexEntry{
+		{key: "trace-1", offset: 0, length: 3},
+		{key: "", offset: 3, length: 2},
+		{key: "trace-2", offset: 5, length: 5},
+	}, decodeIndex(t, value))
+
+	index, err = indexMessage(Index{}, messages)
+	require.NoError(t, err)
+	assert.Nil(t, index)
+}
+
+func TestTracesPusher_index(t *testing.T) {
+	c := sarama.NewConfig()
+	producer := expectSends(t, 3)
+	var sent []*sarama.ProducerMessage
+	p := kafkaTracesProducer{
+		producer:  producer,
+		marshaler: tracesMarshalers()["jaeger_proto"],
+		config: &Config{
+			Topic:    "spans",
+			Index:    Index{Topic: "spans-index"},
+			Producer: Producer{MaxMessageBytes: c.Producer.MaxMessageBytes},
+		},
+		logger: zap.NewNop(),
+		inspector: func(message *sarama.ProducerMessage) {
+			sent = append(sent, message)
+		},
+	}
+	td := genJaegerTracesData(2)
+	require.NoError(t, p.tracesPusher(context.Background(), td))
+	require.NoError(t, p.Close(context.Background()))
+
+	require.Len(t, sent, 3)
+	index := sent[2]
+	assert.Equal(t, "spans-index", index.Topic)
+	value, err := index.Value.Encode()
+	require.NoError(t, err)
+	entries := decodeIndex(t, value)
+	require.Len(t, entries, 2)
+	for i, entry := range entries {
+		key, err := sent[i].Key.Encode()
+		require.NoError(t, err)
+		assert.Equal(t, string(key), entry.key)
+		assert.Equal(t, uint64(sent[i].Value.Length()), entry.length)
+	}
+	assert.Equal(t, entries[0].length, entries[1].offset)
+}
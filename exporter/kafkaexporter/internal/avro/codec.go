@@ -0,0 +1,369 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package avro // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/avro"
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+var errShortBuffer = errors.New("avro: short buffer")
+
+// Codec encodes and decodes the values of a schema in the Avro binary encoding.
+type Codec struct {
+	schema string
+	root   *schema
+}
+
+// NewCodec returns the codec of the JSON schema.
+func NewCodec(schemaJSON string) (*Codec, error) {
+	var v any
+	if err := json.Unmarshal([]byte(schemaJSON), &v); err != nil {
+		return nil, fmt.Errorf("avro: invalid schema: %w", err)
+	}
+	root, err := parseSchema(v, map[string]*schema{}, "")
+	if err != nil {
+		return nil, fmt.Errorf("avro: invalid schema: %w", err)
+	}
+	// The schema is compacted, as it is embedded in the object container files and registered as is.
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, []byte(schemaJSON)); err != nil {
+		return nil, fmt.Errorf("avro: invalid schema: %w", err)
+	}
+	return &Codec{schema: compacted.String(), root: root}, nil
+}
+
+// MustNewCodec is NewCodec for the schemas known to be valid, it panics if the schema is invalid.
+func MustNewCodec(schemaJSON string) *Codec {
+	codec, err := NewCodec(schemaJSON)
+	if err != nil {
+		panic(err)
+	}
+	return codec
+}
+
+// Schema returns the compacted JSON schema of the codec.
+func (c *Codec) Schema() string {
+	return c.schema
+}
+
+// Name returns the full name of the record or enum of the schema, or its type name for the other schemas.
+func (c *Codec) Name() string {
+	return c.root.name
+}
+
+// BinaryFromNative appends the binary encoding of value to buf.
+func (c *Codec) BinaryFromNative(buf []byte, value any) ([]byte, error) {
+	buf, err := encode(buf, c.root, value)
+	if err != nil {
+		return nil, fmt.Errorf("avro: %w", err)
+	}
+	return buf, nil
+}
+
+// NativeFromBinary decodes the value at the start of buf, and returns it with the rest of buf.
+func (c *Codec) NativeFromBinary(buf []byte) (any, []byte, error) {
+	value, rest, err := decode(buf, c.root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("avro: %w", err)
+	}
+	return value, rest, nil
+}
+
+func encode(buf []byte, s *schema, value any) ([]byte, error) {
+	switch s.kind {
+	case kindNull:
+		if value != nil {
+			return nil, fmt.Errorf("null: unexpected value %T", value)
+		}
+		return buf, nil
+	case kindBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("boolean: unexpected value %T", value)
+		}
+		if b {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case kindInt, kindLong:
+		n, ok := toInt64(value)
+		if !ok || (s.kind == kindInt && (n < math.MinInt32 || n > math.MaxInt32)) {
+			return nil, fmt.Errorf("%s: unexpected value %T %v", s.name, value, value)
+		}
+		return binary.AppendVarint(buf, n), nil
+	case kindFloat:
+		f, ok := toFloat64(value)
+		if !ok {
+			return nil, fmt.Errorf("float: unexpected value %T", value)
+		}
+		return binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(f))), nil
+	case kindDouble:
+		f, ok := toFloat64(value)
+		if !ok {
+			return nil, fmt.Errorf("double: unexpected value %T", value)
+		}
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(f)), nil
+	case kindBytes, kindString:
+		switch v := value.(type) {
+		case []byte:
+			return append(binary.AppendVarint(buf, int64(len(v))), v...), nil
+		case string:
+			return append(binary.AppendVarint(buf, int64(len(v))), v...), nil
+		}
+		return nil, fmt.Errorf("%s: unexpected value %T", s.name, value)
+	case kindRecord:
+		record, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("record %s: unexpected value %T", s.name, value)
+		}
+		var err error
+		for _, f := range s.fields {
+			if buf, err = encode(buf, f.schema, record[f.name]); err != nil {
+				return nil, fmt.Errorf("record %s, field %s: %w", s.name, f.name, err)
+			}
+		}
+		return buf, nil
+	case kindEnum:
+		symbol, _ := value.(string)
+		for i, candidate := range s.symbols {
+			if candidate == symbol {
+				return binary.AppendVarint(buf, int64(i)), nil
+			}
+		}
+		return nil, fmt.Errorf("enum %s: unknown symbol %v", s.name, value)
+	case kindArray:
+		items, ok := value.([]any)
+		if !ok && value != nil {
+			return nil, fmt.Errorf("array: unexpected value %T", value)
+		}
+		if len(items) > 0 {
+			buf = binary.AppendVarint(buf, int64(len(items)))
+			var err error
+			for i, item := range items {
+				if buf, err = encode(buf, s.items, item); err != nil {
+					return nil, fmt.Errorf("array item %d: %w", i, err)
+				}
+			}
+		}
+		return append(buf, 0), nil
+	case kindMap:
+		entries, ok := value.(map[string]any)
+		if !ok && value != nil {
+			return nil, fmt.Errorf("map: unexpected value %T", value)
+		}
+		if len(entries) > 0 {
+			// The keys are sorted so that the same map is always encoded to the same bytes.
+			keys := make([]string, 0, len(entries))
+			for key := range entries {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			buf = binary.AppendVarint(buf, int64(len(keys)))
+			var err error
+			for _, key := range keys {
+				buf = append(binary.AppendVarint(buf, int64(len(key))), key...)
+				if buf, err = encode(buf, s.items, entries[key]); err != nil {
+					return nil, fmt.Errorf("map value %s: %w", key, err)
+				}
+			}
+		}
+		return append(buf, 0), nil
+	case kindUnion:
+		return encodeUnion(buf, s, value)
+	}
+	return nil, fmt.Errorf("unsupported schema %s", s.name)
+}
+
+func encodeUnion(buf []byte, s *schema, value any) ([]byte, error) {
+	if value == nil {
+		for i, branch := range s.branches {
+			if branch.kind == kindNull {
+				return binary.AppendVarint(buf, int64(i)), nil
+			}
+		}
+		return nil, errors.New("union: null is not a branch")
+	}
+	wrapped, ok := value.(map[string]any)
+	if !ok || len(wrapped) != 1 {
+		return nil, fmt.Errorf("union: unexpected value %T, expecting a map holding the value under its branch name", value)
+	}
+	for name, v := range wrapped {
+		for i, branch := range s.branches {
+			if branch.name == name {
+				buf, err := encode(binary.AppendVarint(buf, int64(i)), branch, v)
+				if err != nil {
+					return nil, fmt.Errorf("union branch %s: %w", name, err)
+				}
+				return buf, nil
+			}
+		}
+		return nil, fmt.Errorf("union: unknown branch %s", name)
+	}
+	return buf, nil
+}
+
+func decode(buf []byte, s *schema) (any, []byte, error) {
+	switch s.kind {
+	case kindNull:
+		return nil, buf, nil
+	case kindBoolean:
+		if len(buf) < 1 {
+			return nil, nil, errShortBuffer
+		}
+		return buf[0] != 0, buf[1:], nil
+	case kindInt:
+		n, rest, err := decodeLong(buf)
+		return int32(n), rest, err
+	case kindLong:
+		return decodeLong(buf)
+	case kindFloat:
+		if len(buf) < 4 {
+			return nil, nil, errShortBuffer
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(buf)), buf[4:], nil
+	case kindDouble:
+		if len(buf) < 8 {
+			return nil, nil, errShortBuffer
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(buf)), buf[8:], nil
+	case kindBytes:
+		b, rest, err := decodeBytes(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		return append([]byte{}, b...), rest, nil
+	case kindString:
+		b, rest, err := decodeBytes(buf)
+		return string(b), rest, err
+	case kindRecord:
+		record := make(map[string]any, len(s.fields))
+		for _, f := range s.fields {
+			v, rest, err := decode(buf, f.schema)
+			if err != nil {
+				return nil, nil, fmt.Errorf("record %s, field %s: %w", s.name, f.name, err)
+			}
+			record[f.name], buf = v, rest
+		}
+		return record, buf, nil
+	case kindEnum:
+		i, rest, err := decodeLong(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i < 0 || int(i) >= len(s.symbols) {
+			return nil, nil, fmt.Errorf("enum %s: invalid index %d", s.name, i)
+		}
+		return s.symbols[i], rest, nil
+	case kindArray, kindMap:
+		return decodeBlocks(buf, s)
+	case kindUnion:
+		i, rest, err := decodeLong(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i < 0 || int(i) >= len(s.branches) {
+			return nil, nil, fmt.Errorf("union: invalid branch %d", i)
+		}
+		branch := s.branches[i]
+		v, rest, err := decode(rest, branch)
+		if err != nil || branch.kind == kindNull {
+			return nil, rest, err
+		}
+		return map[string]any{branch.name: v}, rest, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported schema %s", s.name)
+}
+
+// decodeBlocks decodes the blocks of the items of an array, or of the entries of a map.
+func decodeBlocks(buf []byte, s *schema) (any, []byte, error) {
+	items := []any{}
+	entries := map[string]any{}
+	for {
+		count, rest, err := decodeLong(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		buf = rest
+		if count == 0 {
+			break
+		}
+		if count < 0 {
+			// A negative count is followed by the size of the block in bytes.
+			count = -count
+			if _, buf, err = decodeLong(buf); err != nil {
+				return nil, nil, err
+			}
+		}
+		for ; count > 0; count-- {
+			var key []byte
+			if s.kind == kindMap {
+				if key, buf, err = decodeBytes(buf); err != nil {
+					return nil, nil, err
+				}
+			}
+			var v any
+			if v, buf, err = decode(buf, s.items); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", s.name, err)
+			}
+			if s.kind == kindMap {
+				entries[string(key)] = v
+			} else {
+				items = append(items, v)
+			}
+		}
+	}
+	if s.kind == kindMap {
+		return entries, buf, nil
+	}
+	return items, buf, nil
+}
+
+func decodeLong(buf []byte) (int64, []byte, error) {
+	n, size := binary.Varint(buf)
+	if size <= 0 {
+		return 0, nil, errShortBuffer
+	}
+	return n, buf[size:], nil
+}
+
+func decodeBytes(buf []byte) ([]byte, []byte, error) {
+	n, rest, err := decodeLong(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n < 0 || int64(len(rest)) < n {
+		return nil, nil, errShortBuffer
+	}
+	return rest[:n], rest[n:], nil
+}
+
+func toInt64(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint32:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+func toFloat64(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
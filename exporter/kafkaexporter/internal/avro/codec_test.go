@@ -0,0 +1,128 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package avro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+	"type": "record",
+	"name": "Node",
+	"namespace": "test",
+	"fields": [
+		{"name": "name", "type": "string"},
+		{"name": "count", "type": "int"},
+		{"name": "total", "type": "long"},
+		{"name": "ratio", "type": "float"},
+		{"name": "value", "type": "double"},
+		{"name": "ok", "type": "boolean"},
+		{"name": "raw", "type": "bytes"},
+		{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "GREEN"]}},
+		{"name": "labels", "type": {"type": "map", "values": "string"}},
+		{"name": "children", "type": {"type": "array", "items": "Node"}},
+		{"name": "parent", "type": ["null", "string", "Node"]}
+	]
+}`
+
+func TestCodec_roundTrip(t *testing.T) {
+	codec, err := NewCodec(testSchema)
+	require.NoError(t, err)
+	child := map[string]any{
+		"name":     "child",
+		"count":    int32(-1),
+		"total":    int64(0),
+		"ratio":    float32(0.5),
+		"value":    float64(-2.25),
+		"ok":       false,
+		"raw":      []byte{},
+		"color":    "GREEN",
+		"labels":   map[string]any{},
+		"children": []any{},
+		"parent":   map[string]any{"string": "root"},
+	}
+	root := map[string]any{
+		"name":     "root",
+		"count":    int32(3),
+		"total":    int64(1) << 40,
+		"ratio":    float32(1.5),
+		"value":    float64(3.125),
+		"ok":       true,
+		"raw":      []byte{0, 1, 2},
+		"color":    "RED",
+		"labels":   map[string]any{"b": "2", "a": "1"},
+		"children": []any{child, child},
+		"parent":   nil,
+	}
+
+	buf, err := codec.BinaryFromNative(nil, root)
+	require.NoError(t, err)
+	decoded, rest, err := codec.NativeFromBinary(buf)
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+	assert.Equal(t, root, decoded)
+}
+
+func TestCodec_Name(t *testing.T) {
+	assert.Equal(t, "example.Record", MustNewCodec(`{"type": "record", "name": "Record", "namespace": "example", "fields": []}`).Name())
+	assert.Equal(t, "string", MustNewCodec(`"string"`).Name())
+}
+
+func TestCodec_encoding(t *testing.T) {
+	codec, err := NewCodec(`["null", "long", "string"]`)
+	require.NoError(t, err)
+	tests := []struct {
+		value any
+		want  []byte
+	}{
+		{value: nil, want: []byte{0}},
+		{value: map[string]any{"long": int64(-64)}, want: []byte{2, 0x7f}},
+		{value: map[string]any{"long": 64}, want: []byte{2, 0x80, 0x01}},
+		{value: map[string]any{"string": "foo"}, want: []byte{4, 6, 'f', 'o', 'o'}},
+	}
+	for _, test := range tests {
+		buf, err := codec.BinaryFromNative(nil, test.value)
+		require.NoError(t, err)
+		assert.Equal(t, test.want, buf)
+	}
+}
+
+func TestCodec_errors(t *testing.T) {
+	_, err := NewCodec(`{"type": "record", "name": "R", "fields": [{"name": "f", "type": "Unknown"}]}`)
+	assert.ErrorContains(t, err, `unknown type "Unknown"`)
+	_, err = NewCodec(`{`)
+	assert.Error(t, err)
+
+	codec := MustNewCodec(testSchema)
+	_, err = codec.BinaryFromNative(nil, map[string]any{"name": 1})
+	assert.ErrorContains(t, err, "record test.Node, field name")
+	_, err = codec.BinaryFromNative(nil, "root")
+	assert.ErrorContains(t, err, "unexpected value string")
+
+	union := MustNewCodec(`["null", "string"]`)
+	_, err = union.BinaryFromNative(nil, "foo")
+	assert.ErrorContains(t, err, "expecting a map holding the value under its branch name")
+	_, err = union.BinaryFromNative(nil, map[string]any{"long": 1})
+	assert.ErrorContains(t, err, "unknown branch long")
+	_, _, err = union.NativeFromBinary([]byte{2, 10})
+	assert.ErrorIs(t, err, errShortBuffer)
+}
+
+func TestOCF_roundTrip(t *testing.T) {
+	codec := MustNewCodec(`{"type": "record", "name": "R", "fields": [{"name": "f", "type": "string"}]}`)
+	values := []any{map[string]any{"f": "a"}, map[string]any{"f": "b"}}
+	buf, err := codec.OCFFromNative(values)
+	require.NoError(t, err)
+
+	decodedCodec, decoded, err := NativeFromOCF(buf)
+	require.NoError(t, err)
+	assert.Equal(t, codec.Schema(), decodedCodec.Schema())
+	assert.Equal(t, values, decoded)
+
+	_, _, err = NativeFromOCF([]byte("foo"))
+	assert.ErrorContains(t, err, "not an object container file")
+}
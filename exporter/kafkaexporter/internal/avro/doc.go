@@ -0,0 +1,13 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package avro implements the parts of Apache Avro used by the Avro encodings of the Kafka exporter: the binary
+// encoding of the values of a schema, the object container files embedding their schema, and the registration
+// and fetching of the schemas in a Confluent compatible schema registry.
+//
+// The values are represented like in github.com/linkedin/goavro: records and maps as map[string]any, arrays as
+// []any, enums as their symbol, and unions as nil for their null branch or as a map holding the value under the
+// name of its branch, e.g. map[string]any{"string": "value"}.
+//
+// The specification is at https://avro.apache.org/docs/1.11.1/specification/.
+package avro // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/avro"
@@ -0,0 +1,97 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package avro // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/avro"
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var ocfMagic = []byte{'O', 'b', 'j', 1}
+
+// ocfHeader is the schema of the metadata of the object container files.
+var ocfHeader = &schema{kind: kindMap, name: "map", items: &schema{kind: kindBytes, name: "bytes"}}
+
+const syncSize = 16
+
+// OCFFromNative returns the object container file holding values in a single block, without compression.
+// The file embeds the schema, so that it can be decoded without schema registry.
+func (c *Codec) OCFFromNative(values []any) ([]byte, error) {
+	buf := append([]byte{}, ocfMagic...)
+	buf, err := encode(buf, ocfHeader, map[string]any{
+		"avro.schema": []byte(c.schema),
+		"avro.codec":  []byte("null"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("avro: %w", err)
+	}
+	sync := make([]byte, syncSize)
+	if _, err = rand.Read(sync); err != nil {
+		return nil, fmt.Errorf("avro: %w", err)
+	}
+	buf = append(buf, sync...)
+	if len(values) == 0 {
+		return buf, nil
+	}
+	var block []byte
+	for _, value := range values {
+		if block, err = c.BinaryFromNative(block, value); err != nil {
+			return nil, err
+		}
+	}
+	buf = binary.AppendVarint(buf, int64(len(values)))
+	buf = binary.AppendVarint(buf, int64(len(block)))
+	buf = append(buf, block...)
+	return append(buf, sync...), nil
+}
+
+// NativeFromOCF returns the codec of the schema embedded in the object container file buf, and its values.
+// Only the files without compression are supported.
+func NativeFromOCF(buf []byte) (*Codec, []any, error) {
+	if !bytes.HasPrefix(buf, ocfMagic) {
+		return nil, nil, errors.New("avro: not an object container file")
+	}
+	header, buf, err := decode(buf[len(ocfMagic):], ocfHeader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("avro: %w", err)
+	}
+	metadata := header.(map[string]any)
+	if codec, ok := metadata["avro.codec"].([]byte); ok && string(codec) != "null" {
+		return nil, nil, fmt.Errorf("avro: unsupported codec %s", codec)
+	}
+	schemaJSON, _ := metadata["avro.schema"].([]byte)
+	c, err := NewCodec(string(schemaJSON))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(buf) < syncSize {
+		return nil, nil, errShortBuffer
+	}
+	sync, buf := buf[:syncSize], buf[syncSize:]
+	var values []any
+	for len(buf) > 0 {
+		count, rest, err := decodeLong(buf)
+		if err != nil {
+			return nil, nil, fmt.Errorf("avro: %w", err)
+		}
+		if _, buf, err = decodeLong(rest); err != nil {
+			return nil, nil, fmt.Errorf("avro: %w", err)
+		}
+		for ; count > 0; count-- {
+			var value any
+			if value, buf, err = c.NativeFromBinary(buf); err != nil {
+				return nil, nil, err
+			}
+			values = append(values, value)
+		}
+		if !bytes.HasPrefix(buf, sync) {
+			return nil, nil, errors.New("avro: invalid sync marker")
+		}
+		buf = buf[syncSize:]
+	}
+	return c, values, nil
+}
@@ -0,0 +1,130 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package avro // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/avro"
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// wireFormatMagic is the first byte of the values in the Confluent wire format.
+	wireFormatMagic = 0
+	// wireFormatHeaderSize is the size of the magic byte followed by the big-endian schema ID.
+	wireFormatHeaderSize = 5
+)
+
+// SchemaRegistry registers schemas in a Confluent compatible schema registry, and caches their IDs. It also
+// fetches the schemas of IDs.
+type SchemaRegistry struct {
+	client *http.Client
+
+	mu  sync.Mutex
+	ids map[registration]int
+}
+
+type registration struct {
+	url     string
+	subject string
+	schema  string
+}
+
+// NewSchemaRegistry returns a SchemaRegistry sending its requests with client.
+func NewSchemaRegistry(client *http.Client) *SchemaRegistry {
+	return &SchemaRegistry{client: client, ids: map[registration]int{}}
+}
+
+// ID returns the ID of schema under subject in the registry at registryURL. The schema is registered the
+// first time, which returns the ID of the schema if the registry already knows it.
+func (r *SchemaRegistry) ID(registryURL, subject, schema string) (int, error) {
+	key := registration{url: registryURL, subject: subject, schema: schema}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id, ok := r.ids[key]; ok {
+		return id, nil
+	}
+	id, err := r.register(key)
+	if err != nil {
+		return 0, err
+	}
+	r.ids[key] = id
+	return id, nil
+}
+
+func (r *SchemaRegistry) register(key registration) (int, error) {
+	body, err := json.Marshal(map[string]string{"schema": key.schema})
+	if err != nil {
+		return 0, err
+	}
+	endpoint := strings.TrimSuffix(key.url, "/") + "/subjects/" + url.PathEscape(key.subject) + "/versions"
+	resp, err := r.client.Post(endpoint, "application/vnd.schemaregistry.v1+json", bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("schema registry: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("schema registry: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("schema registry: registering subject %s returned %s: %s", key.subject, resp.Status, respBody)
+	}
+	var registered struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(respBody, &registered); err != nil {
+		return 0, fmt.Errorf("schema registry: %w", err)
+	}
+	return registered.ID, nil
+}
+
+// Schema returns the schema of id in the registry at registryURL. The schemas are not cached.
+func (r *SchemaRegistry) Schema(registryURL string, id int) (string, error) {
+	endpoint := strings.TrimSuffix(registryURL, "/") + "/schemas/ids/" + strconv.Itoa(id)
+	resp, err := r.client.Get(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("schema registry: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("schema registry: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("schema registry: fetching schema %d returned %s: %s", id, resp.Status, respBody)
+	}
+	var fetched struct {
+		Schema string `json:"schema"`
+	}
+	if err := json.Unmarshal(respBody, &fetched); err != nil {
+		return "", fmt.Errorf("schema registry: %w", err)
+	}
+	return fetched.Schema, nil
+}
+
+// WireFormat returns payload prefixed by the magic byte and the schema ID, as expected by the
+// Confluent deserializers.
+func WireFormat(id int, payload []byte) []byte {
+	buf := make([]byte, 0, wireFormatHeaderSize+len(payload))
+	buf = append(buf, wireFormatMagic)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(id))
+	return append(buf, payload...)
+}
+
+// FromWireFormat returns the schema ID and the payload of buf in the Confluent wire format, and false if buf
+// does not start with the magic byte and a schema ID.
+func FromWireFormat(buf []byte) (int, []byte, bool) {
+	if len(buf) < wireFormatHeaderSize || buf[0] != wireFormatMagic {
+		return 0, nil, false
+	}
+	return int(binary.BigEndian.Uint32(buf[1:wireFormatHeaderSize])), buf[wireFormatHeaderSize:], true
+}
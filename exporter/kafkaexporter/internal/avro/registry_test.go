@@ -0,0 +1,84 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package avro
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaRegistry_ID(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/subjects/spans-value/versions", r.URL.Path)
+		assert.Equal(t, "application/vnd.schemaregistry.v1+json", r.Header.Get("Content-Type"))
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, `"string"`, body["schema"])
+		_, _ = w.Write([]byte(`{"id": 42}`))
+	}))
+	defer server.Close()
+
+	registry := NewSchemaRegistry(server.Client())
+	for i := 0; i < 2; i++ {
+		id, err := registry.ID(server.URL+"/", "spans-value", `"string"`)
+		require.NoError(t, err)
+		assert.Equal(t, 42, id)
+	}
+	assert.Equal(t, int32(1), requests.Load(), "the ID should be cached")
+}
+
+func TestSchemaRegistry_error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"error_code": 42201, "message": "Invalid schema"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewSchemaRegistry(server.Client()).ID(server.URL, "spans-value", `"string"`)
+	assert.ErrorContains(t, err, "registering subject spans-value returned 422 Unprocessable Entity")
+}
+
+func TestSchemaRegistry_Schema(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		if r.URL.Path != "/schemas/ids/42" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code": 40403, "message": "Schema not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"schema": "\"string\""}`))
+	}))
+	defer server.Close()
+
+	registry := NewSchemaRegistry(server.Client())
+	schema, err := registry.Schema(server.URL+"/", 42)
+	require.NoError(t, err)
+	assert.Equal(t, `"string"`, schema)
+
+	_, err = registry.Schema(server.URL, 7)
+	assert.ErrorContains(t, err, "fetching schema 7 returned 404 Not Found")
+}
+
+func TestWireFormat(t *testing.T) {
+	assert.Equal(t, []byte{0, 0, 0, 1, 2, 'f'}, WireFormat(258, []byte("f")))
+
+	id, payload, ok := FromWireFormat([]byte{0, 0, 0, 1, 2, 'f'})
+	assert.True(t, ok)
+	assert.Equal(t, 258, id)
+	assert.Equal(t, []byte("f"), payload)
+
+	_, _, ok = FromWireFormat([]byte{0, 0, 0, 1})
+	assert.False(t, ok)
+	_, _, ok = FromWireFormat([]byte("Obj\x01"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,172 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package avro // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/avro"
+
+import (
+	"fmt"
+	"strings"
+)
+
+type kind int
+
+const (
+	kindNull kind = iota
+	kindBoolean
+	kindInt
+	kindLong
+	kindFloat
+	kindDouble
+	kindBytes
+	kindString
+	kindRecord
+	kindEnum
+	kindArray
+	kindMap
+	kindUnion
+)
+
+var primitives = map[string]kind{
+	"null":    kindNull,
+	"boolean": kindBoolean,
+	"int":     kindInt,
+	"long":    kindLong,
+	"float":   kindFloat,
+	"double":  kindDouble,
+	"bytes":   kindBytes,
+	"string":  kindString,
+}
+
+// schema is a parsed Avro schema. The references to a named type share the schema of its definition, so
+// that recursive types are supported.
+type schema struct {
+	kind kind
+	// name is the type name of the schema, the full name of records and enums.
+	name string
+	// fields are the fields of a record.
+	fields []field
+	// symbols are the symbols of an enum.
+	symbols []string
+	// items is the schema of the items of an array, or of the values of a map.
+	items *schema
+	// branches are the schemas of a union.
+	branches []*schema
+}
+
+type field struct {
+	name   string
+	schema *schema
+}
+
+// parseSchema parses the JSON value v of a schema, whose named types are registered in names.
+func parseSchema(v any, names map[string]*schema, namespace string) (*schema, error) {
+	switch t := v.(type) {
+	case string:
+		if k, ok := primitives[t]; ok {
+			return &schema{kind: k, name: t}, nil
+		}
+		if s, ok := names[fullName(t, namespace)]; ok {
+			return s, nil
+		}
+		if s, ok := names[t]; ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unknown type %q", t)
+	case []any:
+		s := &schema{kind: kindUnion}
+		for _, branch := range t {
+			b, err := parseSchema(branch, names, namespace)
+			if err != nil {
+				return nil, err
+			}
+			if b.kind == kindUnion {
+				return nil, fmt.Errorf("unions cannot hold unions")
+			}
+			s.branches = append(s.branches, b)
+		}
+		return s, nil
+	case map[string]any:
+		return parseComplex(t, names, namespace)
+	default:
+		return nil, fmt.Errorf("invalid schema %v", v)
+	}
+}
+
+func parseComplex(t map[string]any, names map[string]*schema, namespace string) (*schema, error) {
+	typ, ok := t["type"].(string)
+	if !ok {
+		return parseSchema(t["type"], names, namespace)
+	}
+	switch typ {
+	case "record", "error":
+		s, ns, err := defineNamed(t, kindRecord, names, namespace)
+		if err != nil {
+			return nil, err
+		}
+		fields, _ := t["fields"].([]any)
+		for _, f := range fields {
+			fieldMap, _ := f.(map[string]any)
+			name, _ := fieldMap["name"].(string)
+			if name == "" {
+				return nil, fmt.Errorf("record %s has a field without name", s.name)
+			}
+			fieldSchema, err := parseSchema(fieldMap["type"], names, ns)
+			if err != nil {
+				return nil, fmt.Errorf("record %s, field %s: %w", s.name, name, err)
+			}
+			s.fields = append(s.fields, field{name: name, schema: fieldSchema})
+		}
+		return s, nil
+	case "enum":
+		s, _, err := defineNamed(t, kindEnum, names, namespace)
+		if err != nil {
+			return nil, err
+		}
+		symbols, _ := t["symbols"].([]any)
+		for _, symbol := range symbols {
+			name, _ := symbol.(string)
+			s.symbols = append(s.symbols, name)
+		}
+		return s, nil
+	case "array", "map":
+		k, key := kindArray, "items"
+		if typ == "map" {
+			k, key = kindMap, "values"
+		}
+		items, err := parseSchema(t[key], names, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", typ, err)
+		}
+		return &schema{kind: k, name: typ, items: items}, nil
+	default:
+		return parseSchema(typ, names, namespace)
+	}
+}
+
+// defineNamed registers the named type defined by t, and returns it with its namespace.
+func defineNamed(t map[string]any, k kind, names map[string]*schema, namespace string) (*schema, string, error) {
+	name, _ := t["name"].(string)
+	if name == "" {
+		return nil, "", fmt.Errorf("named type without name")
+	}
+	if ns, ok := t["namespace"].(string); ok {
+		namespace = ns
+	}
+	full := fullName(name, namespace)
+	if _, ok := names[full]; ok {
+		return nil, "", fmt.Errorf("type %s is defined twice", full)
+	}
+	s := &schema{kind: k, name: full}
+	names[full] = s
+	if i := strings.LastIndex(full, "."); i >= 0 {
+		namespace = full[:i]
+	}
+	return s, namespace, nil
+}
+
+func fullName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
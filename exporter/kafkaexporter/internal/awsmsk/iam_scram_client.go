@@ -11,9 +11,15 @@ import (
 	"time"
 
 	"github.com/IBM/sarama"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
 	sign "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"go.uber.org/multierr"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/kafkaauth"
 )
 
 const (
@@ -42,12 +48,15 @@ type IAMSASLClient struct {
 	MSKHostname string
 	Region      string
 	UserAgent   string
+	// AssumeRoleARN is the role assumed with the credentials of the client, if set.
+	AssumeRoleARN string
 
-	signer *sign.StreamSigner
+	signer      *sign.StreamSigner
+	credentials *credentials.Credentials
+	// newAssumeRoler returns the STS client assuming the role, it is replaced in tests.
+	newAssumeRoler func(sess *session.Session) stscreds.AssumeRoler
 
-	state     int32
-	accessKey string
-	secretKey string
+	state int32
 }
 
 type payload struct {
@@ -61,6 +70,7 @@ type payload struct {
 	Expires       string `json:"x-amz-expires"`
 	SignedHeaders string `json:"x-amz-signedheaders"`
 	Signature     string `json:"x-amz-signature"`
+	SecurityToken string `json:"x-amz-security-token,omitempty"`
 }
 
 type response struct {
@@ -70,14 +80,44 @@ type response struct {
 
 var _ sarama.SCRAMClient = (*IAMSASLClient)(nil)
 
-func NewIAMSASLClient(mskhostname, region, useragent string) sarama.SCRAMClient {
+func NewIAMSASLClient(mskhostname, region, useragent, assumeRoleARN string) sarama.SCRAMClient {
 	return &IAMSASLClient{
-		MSKHostname: mskhostname,
-		Region:      region,
-		UserAgent:   useragent,
+		MSKHostname:    mskhostname,
+		Region:         region,
+		UserAgent:      useragent,
+		AssumeRoleARN:  assumeRoleARN,
+		newAssumeRoler: newSTSAssumeRoler,
 	}
 }
 
+// NewIAMSASLClientGenerator returns the generator of the clients of every new connection, signing with the
+// credentials of username and password, or of the default credential chain of the AWS SDK if username is empty,
+// and with those of the role assumeRoleARN if set. The credentials are loaded once and shared by the clients, so
+// that the role is only assumed again when its credentials expire.
+func NewIAMSASLClientGenerator(mskhostname, region, useragent, username, password, assumeRoleARN string) (func() sarama.SCRAMClient, error) {
+	return newIAMSASLClientGenerator(mskhostname, region, useragent, username, password, assumeRoleARN, newSTSAssumeRoler)
+}
+
+func newIAMSASLClientGenerator(mskhostname, region, useragent, username, password, assumeRoleARN string, newAssumeRoler func(sess *session.Session) stscreds.AssumeRoler) (func() sarama.SCRAMClient, error) {
+	creds, err := roleCredentials(region, username, password, assumeRoleARN, newAssumeRoler)
+	if err != nil {
+		return nil, err
+	}
+	return func() sarama.SCRAMClient {
+		return &IAMSASLClient{
+			MSKHostname:   mskhostname,
+			Region:        region,
+			UserAgent:     useragent,
+			AssumeRoleARN: assumeRoleARN,
+			credentials:   creds,
+		}
+	}, nil
+}
+
+func newSTSAssumeRoler(sess *session.Session) stscreds.AssumeRoler {
+	return sts.New(sess)
+}
+
 func (sc *IAMSASLClient) Begin(username, password, _ string) error {
 	if sc.MSKHostname == "" {
 		return errors.New("missing required MSK Broker hostname")
@@ -91,26 +131,66 @@ func (sc *IAMSASLClient) Begin(username, password, _ string) error {
 		return errors.New("missing value for MSK user agent")
 	}
 
-	sc.signer = sign.NewStreamSigner(
-		sc.Region,
-		service,
-		nil,
-		credentials.NewChainCredentials([]credentials.Provider{
-			&credentials.EnvProvider{},
-			&credentials.StaticProvider{
-				Value: credentials.Value{
-					AccessKeyID:     username,
-					SecretAccessKey: password,
-				},
-			},
-		}),
-	)
-	sc.accessKey = username
-	sc.secretKey = password
+	// The clients of NewIAMSASLClientGenerator share the credentials loaded with the generator.
+	creds := sc.credentials
+	if creds == nil {
+		var err error
+		if creds, err = roleCredentials(sc.Region, username, password, sc.AssumeRoleARN, sc.newAssumeRoler); err != nil {
+			return err
+		}
+	}
+	sc.setCredentials(creds)
 	sc.state = initMessage
 	return nil
 }
 
+// roleCredentials returns the baseCredentials of username and password, or the credentials of the role
+// assumed with them if assumeRoleARN is set.
+func roleCredentials(region, username, password, assumeRoleARN string, newAssumeRoler func(sess *session.Session) stscreds.AssumeRoler) (*credentials.Credentials, error) {
+	creds, err := baseCredentials(region, username, password)
+	if err != nil {
+		return nil, err
+	}
+	if assumeRoleARN == "" {
+		return creds, nil
+	}
+	sess, err := session.NewSession(aws.NewConfig().WithRegion(region).WithCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	return stscreds.NewCredentialsWithClient(newAssumeRoler(sess), assumeRoleARN), nil
+}
+
+// baseCredentials returns the static credentials of username and password, or the credentials of the default
+// chain of the AWS SDK if username is empty: environment, web identity token (e.g. IAM roles for service
+// accounts on EKS), shared credentials file, then container or instance role.
+func baseCredentials(region, username, password string) (*credentials.Credentials, error) {
+	if username == "" {
+		sess, err := session.NewSessionWithOptions(session.Options{
+			Config:            *aws.NewConfig().WithRegion(region),
+			SharedConfigState: session.SharedConfigEnable,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return sess.Config.Credentials, nil
+	}
+	return credentials.NewChainCredentials([]credentials.Provider{
+		&credentials.EnvProvider{},
+		&credentials.StaticProvider{
+			Value: credentials.Value{
+				AccessKeyID:     username,
+				SecretAccessKey: password,
+			},
+		},
+	}), nil
+}
+
+func (sc *IAMSASLClient) setCredentials(creds *credentials.Credentials) {
+	sc.credentials = creds
+	sc.signer = sign.NewStreamSigner(sc.Region, service, nil, creds)
+}
+
 func (sc *IAMSASLClient) Step(challenge string) (string, error) {
 	var resp string
 
@@ -121,6 +201,7 @@ func (sc *IAMSASLClient) Step(challenge string) (string, error) {
 			return "", fmt.Errorf("challenge must be empty for initial request: %w", ErrBadChallenge)
 		}
 		payload, err := sc.getAuthPayload()
+		kafkaauth.RecordTokenRefresh(Mechanism, err)
 		if err != nil {
 			sc.state = failed
 			return "", err
@@ -157,6 +238,11 @@ func (sc *IAMSASLClient) Done() bool { return sc.state == complete }
 func (sc *IAMSASLClient) getAuthPayload() ([]byte, error) {
 	ts := time.Now().UTC()
 
+	creds, err := sc.credentials.Get()
+	if err != nil {
+		return nil, err
+	}
+
 	headers := []byte("host:" + sc.MSKHostname)
 
 	sig, err := sc.signer.GetSignature(headers, nil, ts)
@@ -173,10 +259,11 @@ func (sc *IAMSASLClient) getAuthPayload() ([]byte, error) {
 		UserAgent:     sc.UserAgent,
 		Action:        "kafka-cluster:Connect",
 		Algorithm:     "AWS4-HMAC-SHA256",
-		Credentials:   fmt.Sprintf(scopeFormat, sc.accessKey, date[:8], sc.Region),
+		Credentials:   fmt.Sprintf(scopeFormat, creds.AccessKeyID, date[:8], sc.Region),
 		Date:          date,
 		SignedHeaders: "host",
 		Expires:       "300", // Seconds => 5 Minutes
 		Signature:     string(sig),
+		SecurityToken: creds.SessionToken,
 	})
 }
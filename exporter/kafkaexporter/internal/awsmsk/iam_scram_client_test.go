@@ -5,11 +5,21 @@ package awsmsk
 
 import (
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/kafkaauth"
 )
 
 func TestAuthentication(t *testing.T) {
@@ -23,7 +33,7 @@ func TestAuthentication(t *testing.T) {
 		region     = "us-east-1"
 	)
 
-	mskAuth := NewIAMSASLClient(BrokerName, region, UserAgent).(*IAMSASLClient)
+	mskAuth := NewIAMSASLClient(BrokerName, region, UserAgent, "").(*IAMSASLClient)
 	require.NotNil(t, mskAuth, "Must have a valid client")
 
 	assert.NoError(t, mskAuth.Begin(AccessKey, SecretKey, ""))
@@ -114,3 +124,133 @@ func TestValidatingServerResponse(t *testing.T) {
 	assert.ErrorIs(t, err, ErrInvalidStateReached, "Must be an invalid step when not set up correctly")
 
 }
+
+// mockTokenSource is a credentials provider failing with err.
+type mockTokenSource struct {
+	err error
+}
+
+func (m *mockTokenSource) Retrieve() (credentials.Value, error) {
+	if m.err != nil {
+		return credentials.Value{}, m.err
+	}
+	return credentials.Value{AccessKeyID: "testing", SecretAccessKey: "hunter2"}, nil
+}
+
+func (m *mockTokenSource) IsExpired() bool {
+	// Always expired, so that every authentication refreshes the credentials.
+	return true
+}
+
+func tokenRefreshCount(t *testing.T, name string) float64 {
+	rows, err := view.RetrieveData(name)
+	require.NoError(t, err)
+	if len(rows) == 0 {
+		return 0
+	}
+	require.Len(t, rows, 1)
+	assert.Equal(t, Mechanism, rows[0].Tags[0].Value)
+	return rows[0].Data.(*view.SumData).Value
+}
+
+func TestTokenRefreshMetrics(t *testing.T) {
+	views := kafkaauth.MetricViews()
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	source := &mockTokenSource{}
+	authenticate := func() error {
+		mskAuth := NewIAMSASLClient("localhost:9092", "us-east-1", "kafka-exporter", "").(*IAMSASLClient)
+		require.NoError(t, mskAuth.Begin("testing", "hunter2", ""))
+		mskAuth.setCredentials(credentials.NewCredentials(source))
+		_, err := mskAuth.Step("")
+		return err
+	}
+
+	assert.NoError(t, authenticate())
+	assert.NoError(t, authenticate())
+	assert.Equal(t, float64(2), tokenRefreshCount(t, "kafka_auth_token_refresh_success"))
+	assert.Equal(t, float64(0), tokenRefreshCount(t, "kafka_auth_token_refresh_failure"))
+
+	source.err = errors.New("expired session")
+	assert.Error(t, authenticate())
+	assert.Equal(t, float64(2), tokenRefreshCount(t, "kafka_auth_token_refresh_success"))
+	assert.Equal(t, float64(1), tokenRefreshCount(t, "kafka_auth_token_refresh_failure"))
+}
+
+func TestAuthentication_defaultCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-access-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret-key")
+	t.Setenv("AWS_SESSION_TOKEN", "env-session-token")
+
+	mskAuth := NewIAMSASLClient("localhost:9098", "us-east-1", "kafka-exporter", "").(*IAMSASLClient)
+	require.NoError(t, mskAuth.Begin("", "", ""))
+	payload, err := mskAuth.Step("")
+	require.NoError(t, err)
+
+	var request map[string]string
+	require.NoError(t, json.NewDecoder(strings.NewReader(payload)).Decode(&request))
+	assert.True(t, strings.HasPrefix(request["x-amz-credential"], "env-access-key/"))
+	assert.Equal(t, "env-session-token", request["x-amz-security-token"])
+}
+
+type mockAssumeRoler struct {
+	input *sts.AssumeRoleInput
+	calls int
+}
+
+func (m *mockAssumeRoler) AssumeRole(input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
+	m.input = input
+	m.calls++
+	return &sts.AssumeRoleOutput{Credentials: &sts.Credentials{
+		AccessKeyId:     aws.String("role-access-key"),
+		SecretAccessKey: aws.String("role-secret-key"),
+		SessionToken:    aws.String("role-session-token"),
+		Expiration:      aws.Time(time.Now().Add(time.Hour)),
+	}}, nil
+}
+
+func TestAuthentication_assumeRole(t *testing.T) {
+	const roleARN = "arn:aws:iam::123456789012:role/msk-producer"
+	assumeRoler := &mockAssumeRoler{}
+	mskAuth := NewIAMSASLClient("localhost:9098", "us-east-1", "kafka-exporter", roleARN).(*IAMSASLClient)
+	mskAuth.newAssumeRoler = func(sess *session.Session) stscreds.AssumeRoler {
+		assert.Equal(t, "us-east-1", aws.StringValue(sess.Config.Region))
+		return assumeRoler
+	}
+	require.NoError(t, mskAuth.Begin("testing", "hunter2", ""))
+	payload, err := mskAuth.Step("")
+	require.NoError(t, err)
+
+	require.NotNil(t, assumeRoler.input)
+	assert.Equal(t, roleARN, aws.StringValue(assumeRoler.input.RoleArn))
+	var request map[string]string
+	require.NoError(t, json.NewDecoder(strings.NewReader(payload)).Decode(&request))
+	assert.True(t, strings.HasPrefix(request["x-amz-credential"], "role-access-key/"))
+	assert.Equal(t, "role-session-token", request["x-amz-security-token"])
+}
+
+func TestIAMSASLClientGenerator_assumeRole(t *testing.T) {
+	const roleARN = "arn:aws:iam::123456789012:role/msk-producer"
+	assumeRoler := &mockAssumeRoler{}
+	sessions := 0
+	generator, err := newIAMSASLClientGenerator("localhost:9098", "us-east-1", "kafka-exporter", "testing", "hunter2", roleARN,
+		func(sess *session.Session) stscreds.AssumeRoler {
+			sessions++
+			return assumeRoler
+		})
+	require.NoError(t, err)
+
+	// The connections share the credentials of the role, which is only assumed once.
+	for i := 0; i < 3; i++ {
+		mskAuth := generator().(*IAMSASLClient)
+		require.NoError(t, mskAuth.Begin("testing", "hunter2", ""))
+		payload, err := mskAuth.Step("")
+		require.NoError(t, err)
+		var request map[string]string
+		require.NoError(t, json.NewDecoder(strings.NewReader(payload)).Decode(&request))
+		assert.True(t, strings.HasPrefix(request["x-amz-credential"], "role-access-key/"))
+	}
+	assert.Equal(t, 1, sessions)
+	assert.Equal(t, 1, assumeRoler.calls)
+}
@@ -0,0 +1,92 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package awsmsk // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/awsmsk"
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	sign "github.com/aws/aws-sdk-go/aws/signer/v4"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/kafkaauth"
+)
+
+const (
+	// OAuthBearerMechanism is the name of the AWS MSK IAM mechanism over OAUTHBEARER in the configuration.
+	OAuthBearerMechanism = "AWS_MSK_IAM_OAUTHBEARER"
+
+	// tokenExpiry is how long the signed tokens are valid, the maximum accepted by MSK.
+	tokenExpiry = 15 * time.Minute
+	// tokenExpiryBuffer is how long before its expiry a token is replaced, so that it does not expire while a
+	// connection is being authenticated.
+	tokenExpiryBuffer = time.Minute
+)
+
+// TokenProvider provides the OAUTHBEARER tokens of MSK IAM authentication: the base64url encoding of the
+// kafka-cluster:Connect URL of the region, presigned with AWS Signature Version 4. A token is reused by the
+// connections until it is about to expire, and is signed again for the next ones.
+type TokenProvider struct {
+	region      string
+	userAgent   string
+	credentials *credentials.Credentials
+	now         func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+var _ sarama.AccessTokenProvider = (*TokenProvider)(nil)
+
+// NewTokenProvider returns a token provider signing with the credentials of username and password, or of the
+// default credential chain of the AWS SDK if username is empty, and with those of the role assumeRoleARN if set.
+func NewTokenProvider(region, username, password, assumeRoleARN, userAgent string) (*TokenProvider, error) {
+	creds, err := roleCredentials(region, username, password, assumeRoleARN, newSTSAssumeRoler)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenProvider{
+		region:      region,
+		userAgent:   userAgent,
+		credentials: creds,
+		now:         time.Now,
+	}, nil
+}
+
+// Token returns the current token, or signs a new one if it expires within a minute.
+func (p *TokenProvider) Token() (*sarama.AccessToken, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if p.token != "" && now.Before(p.expiry) {
+		return &sarama.AccessToken{Token: p.token}, nil
+	}
+	token, err := p.sign(now)
+	kafkaauth.RecordTokenRefresh(OAuthBearerMechanism, err)
+	if err != nil {
+		return nil, err
+	}
+	p.token = token
+	p.expiry = now.Add(tokenExpiry - tokenExpiryBuffer)
+	return &sarama.AccessToken{Token: p.token}, nil
+}
+
+func (p *TokenProvider) sign(now time.Time) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://kafka.%s.amazonaws.com/?Action=kafka-cluster%%3AConnect", p.region), nil)
+	if err != nil {
+		return "", err
+	}
+	if _, err = sign.NewSigner(p.credentials).Presign(req, nil, service, p.region, tokenExpiry, now); err != nil {
+		return "", fmt.Errorf("failed to sign the MSK IAM token with the AWS credentials: %w", err)
+	}
+	query := req.URL.Query()
+	query.Set("User-Agent", p.userAgent)
+	req.URL.RawQuery = query.Encode()
+	return base64.RawURLEncoding.EncodeToString([]byte(req.URL.String())), nil
+}
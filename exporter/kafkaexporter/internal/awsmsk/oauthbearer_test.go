@@ -0,0 +1,76 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package awsmsk
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenProvider(t *testing.T) {
+	p, err := NewTokenProvider("us-east-1", "testing", "hunter2", "", "kafka-exporter")
+	require.NoError(t, err)
+	now := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	token, err := p.Token()
+	require.NoError(t, err)
+	decoded, err := base64.RawURLEncoding.DecodeString(token.Token)
+	require.NoError(t, err)
+	signed, err := url.Parse(string(decoded))
+	require.NoError(t, err)
+	assert.Equal(t, "kafka.us-east-1.amazonaws.com", signed.Host)
+	query := signed.Query()
+	assert.Equal(t, "kafka-cluster:Connect", query.Get("Action"))
+	assert.Equal(t, "testing/20230801/us-east-1/kafka-cluster/aws4_request", query.Get("X-Amz-Credential"))
+	assert.Equal(t, "20230801T120000Z", query.Get("X-Amz-Date"))
+	assert.Equal(t, "900", query.Get("X-Amz-Expires"))
+	assert.Equal(t, "kafka-exporter", query.Get("User-Agent"))
+	assert.NotEmpty(t, query.Get("X-Amz-Signature"))
+
+	// The token is reused until it is about to expire.
+	now = now.Add(tokenExpiry - tokenExpiryBuffer - time.Second)
+	reused, err := p.Token()
+	require.NoError(t, err)
+	assert.Equal(t, token.Token, reused.Token)
+
+	now = now.Add(time.Second)
+	refreshed, err := p.Token()
+	require.NoError(t, err)
+	assert.NotEqual(t, token.Token, refreshed.Token)
+}
+
+func TestTokenProvider_sessionToken(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-access-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret-key")
+	t.Setenv("AWS_SESSION_TOKEN", "env-session-token")
+
+	p, err := NewTokenProvider("us-east-1", "", "", "", "kafka-exporter")
+	require.NoError(t, err)
+	token, err := p.Token()
+	require.NoError(t, err)
+	decoded, err := base64.RawURLEncoding.DecodeString(token.Token)
+	require.NoError(t, err)
+	signed, err := url.Parse(string(decoded))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(signed.Query().Get("X-Amz-Credential"), "env-access-key/"))
+	assert.Equal(t, "env-session-token", signed.Query().Get("X-Amz-Security-Token"))
+}
+
+func TestTokenProvider_credentialsError(t *testing.T) {
+	p, err := NewTokenProvider("us-east-1", "testing", "hunter2", "", "kafka-exporter")
+	require.NoError(t, err)
+	p.credentials = credentials.NewCredentials(&mockTokenSource{err: errors.New("expired session")})
+
+	_, err = p.Token()
+	assert.EqualError(t, err, "failed to sign the MSK IAM token with the AWS credentials: expired session")
+}
@@ -0,0 +1,6 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package kafkaauth holds the SASL authentication parts shared by the Kafka exporter and receiver:
+// the OAUTHBEARER token provider and the metrics of the token refreshes.
+package kafkaauth // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/kafkaauth"
@@ -0,0 +1,54 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaauth // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/kafkaauth"
+
+import (
+	"context"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	tagMechanism, _ = tag.NewKey("mechanism")
+
+	statTokenRefreshSuccess = stats.Int64("kafka_auth_token_refresh_success", "Number of authentication tokens successfully refreshed", stats.UnitDimensionless)
+	statTokenRefreshFailure = stats.Int64("kafka_auth_token_refresh_failure", "Number of authentication tokens that could not be refreshed", stats.UnitDimensionless)
+)
+
+// MetricViews returns the views of the authentication token refreshes.
+func MetricViews() []*view.View {
+	tagKeys := []tag.Key{tagMechanism}
+
+	countTokenRefreshSuccess := &view.View{
+		Name:        statTokenRefreshSuccess.Name(),
+		Measure:     statTokenRefreshSuccess,
+		Description: statTokenRefreshSuccess.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+
+	countTokenRefreshFailure := &view.View{
+		Name:        statTokenRefreshFailure.Name(),
+		Measure:     statTokenRefreshFailure,
+		Description: statTokenRefreshFailure.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+
+	return []*view.View{
+		countTokenRefreshSuccess,
+		countTokenRefreshFailure,
+	}
+}
+
+// RecordTokenRefresh counts a refresh of the token of mechanism, as failed if err is not nil.
+func RecordTokenRefresh(mechanism string, err error) {
+	measure := statTokenRefreshSuccess
+	if err != nil {
+		measure = statTokenRefreshFailure
+	}
+	_ = stats.RecordWithTags(context.Background(), []tag.Mutator{tag.Upsert(tagMechanism, mechanism)}, measure.M(1))
+}
@@ -0,0 +1,160 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaauth // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/kafkaauth"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+const (
+	// OAuthBearerMechanism is the name of the OAUTHBEARER mechanism in the configuration.
+	OAuthBearerMechanism = "OAUTHBEARER"
+
+	// defaultExpiryBuffer is how long before its expiry a token is refreshed by default, so that it
+	// does not expire while a connection is being authenticated.
+	defaultExpiryBuffer = 30 * time.Second
+	// refreshRetryInterval is how long the background refresh waits after a failed refresh.
+	refreshRetryInterval = 5 * time.Second
+
+	tokenRequestTimeout = 10 * time.Second
+)
+
+var errMissingAccessToken = errors.New("token endpoint response has no access_token")
+
+// ClientCredentialsTokenProvider provides OAUTHBEARER tokens obtained with the OAuth 2.0 client
+// credentials grant. A token is reused by the connections until it is about to expire.
+type ClientCredentialsTokenProvider struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	expiryBuffer time.Duration
+	client       *http.Client
+	now          func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+var _ sarama.AccessTokenProvider = (*ClientCredentialsTokenProvider)(nil)
+
+// NewClientCredentialsTokenProvider returns a token provider requesting tokens from tokenURL. The tokens are
+// refreshed expiryBuffer before they expire, or 30s before if expiryBuffer is 0.
+func NewClientCredentialsTokenProvider(tokenURL, clientID, clientSecret string, scopes []string, expiryBuffer time.Duration) *ClientCredentialsTokenProvider {
+	if expiryBuffer <= 0 {
+		expiryBuffer = defaultExpiryBuffer
+	}
+	return &ClientCredentialsTokenProvider{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+		expiryBuffer: expiryBuffer,
+		client:       &http.Client{Timeout: tokenRequestTimeout},
+		now:          time.Now,
+	}
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Token returns the current token, or requests a new one if it expires within the expiry buffer.
+func (p *ClientCredentialsTokenProvider) Token() (*sarama.AccessToken, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && p.now().Before(p.expiry) {
+		return &sarama.AccessToken{Token: p.token}, nil
+	}
+	resp, err := p.requestToken()
+	RecordTokenRefresh(OAuthBearerMechanism, err)
+	if err != nil {
+		return nil, err
+	}
+
+	p.token = resp.AccessToken
+	// Tokens without expiry are requested again for every connection.
+	p.expiry = time.Time{}
+	if resp.ExpiresIn > 0 {
+		p.expiry = p.now().Add(time.Duration(resp.ExpiresIn)*time.Second - p.expiryBuffer)
+	}
+	return &sarama.AccessToken{Token: p.token}, nil
+}
+
+// Refresh refreshes the token in the background until ctx is done, as soon as it comes within the expiry
+// buffer, so that the connections are not delayed by the token endpoint. The failed refreshes are retried
+// every 5s. Refresh returns if the tokens have no expiry, they are then requested for every connection.
+func (p *ClientCredentialsTokenProvider) Refresh(ctx context.Context) {
+	for {
+		wait := refreshRetryInterval
+		if _, err := p.Token(); err == nil {
+			p.mu.Lock()
+			expiry := p.expiry
+			p.mu.Unlock()
+			if expiry.IsZero() {
+				return
+			}
+			// The tokens expiring within the expiry buffer are not refreshed more than every 5s.
+			if wait = expiry.Sub(p.now()); wait <= 0 {
+				wait = refreshRetryInterval
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (p *ClientCredentialsTokenProvider) requestToken() (*tokenResponse, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(p.scopes) > 0 {
+		form.Set("scope", strings.Join(p.scopes, " "))
+	}
+	req, err := http.NewRequest(http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+
+	httpResp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token: %w", err)
+	}
+	defer httpResp.Body.Close()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned status %d: %s", httpResp.StatusCode, body)
+	}
+
+	resp := &tokenResponse{}
+	if err = json.Unmarshal(body, resp); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return nil, errMissingAccessToken
+	}
+	return resp, nil
+}
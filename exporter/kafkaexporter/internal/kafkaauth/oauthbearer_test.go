@@ -0,0 +1,186 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+)
+
+func newTokenServer(t *testing.T, status *int) (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "kafka produce", r.PostForm.Get("scope"))
+		clientID, clientSecret, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "client", clientID)
+		assert.Equal(t, "secret", clientSecret)
+		if *status != http.StatusOK {
+			w.WriteHeader(*status)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600}`, requests)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func refreshCount(t *testing.T, name string) float64 {
+	rows, err := view.RetrieveData(name)
+	require.NoError(t, err)
+	if len(rows) == 0 {
+		return 0
+	}
+	require.Len(t, rows, 1)
+	assert.Equal(t, OAuthBearerMechanism, rows[0].Tags[0].Value)
+	return rows[0].Data.(*view.SumData).Value
+}
+
+func TestClientCredentialsTokenProvider(t *testing.T) {
+	views := MetricViews()
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	status := http.StatusOK
+	server, requests := newTokenServer(t, &status)
+	now := time.Now()
+	p := NewClientCredentialsTokenProvider(server.URL, "client", "secret", []string{"kafka", "produce"}, 0)
+	p.now = func() time.Time { return now }
+
+	token, err := p.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.Token)
+
+	// The token is reused until it is about to expire.
+	now = now.Add(time.Hour - defaultExpiryBuffer - time.Second)
+	token, err = p.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token.Token)
+	assert.Equal(t, 1, *requests)
+
+	now = now.Add(time.Second)
+	token, err = p.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token.Token)
+	assert.Equal(t, float64(2), refreshCount(t, "kafka_auth_token_refresh_success"))
+	assert.Equal(t, float64(0), refreshCount(t, "kafka_auth_token_refresh_failure"))
+
+	status = http.StatusUnauthorized
+	now = now.Add(time.Hour)
+	_, err = p.Token()
+	assert.ErrorContains(t, err, "token endpoint returned status 401")
+	assert.Equal(t, float64(2), refreshCount(t, "kafka_auth_token_refresh_success"))
+	assert.Equal(t, float64(1), refreshCount(t, "kafka_auth_token_refresh_failure"))
+}
+
+func TestClientCredentialsTokenProvider_concurrent(t *testing.T) {
+	status := http.StatusOK
+	server, requests := newTokenServer(t, &status)
+	p := NewClientCredentialsTokenProvider(server.URL, "client", "secret", []string{"kafka", "produce"}, 0)
+
+	// The connections requesting a token concurrently share the one requested first.
+	var wg sync.WaitGroup
+	tokens := make([]string, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			token, err := p.Token()
+			assert.NoError(t, err)
+			tokens[i] = token.Token
+		}(i)
+	}
+	wg.Wait()
+	for _, token := range tokens {
+		assert.Equal(t, "token-1", token)
+	}
+	assert.Equal(t, 1, *requests)
+}
+
+func TestClientCredentialsTokenProvider_missingToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"token_type":"bearer"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClientCredentialsTokenProvider(server.URL, "client", "secret", nil, 0).Token()
+	assert.ErrorIs(t, err, errMissingAccessToken)
+}
+
+func TestClientCredentialsTokenProvider_Refresh(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":1}`, requests)
+	}))
+	defer server.Close()
+	requestCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+
+	// The tokens expire in 1s and are refreshed 900ms before.
+	p := NewClientCredentialsTokenProvider(server.URL, "client", "secret", nil, 900*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Refresh(ctx)
+	}()
+
+	// The token nearing its expiry is refreshed in the background, without a connection asking for it.
+	require.Eventually(t, func() bool { return requestCount() >= 3 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Refresh did not return once its context was done")
+	}
+
+	// The refreshed token is returned by the next connection without requesting it.
+	p.mu.Lock()
+	p.expiry = time.Now().Add(time.Hour)
+	p.mu.Unlock()
+	count := requestCount()
+	token, err := p.Token()
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("token-%d", count), token.Token)
+	assert.Equal(t, count, requestCount())
+}
+
+func TestClientCredentialsTokenProvider_Refresh_noExpiry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"bearer"}`))
+	}))
+	defer server.Close()
+
+	// The tokens without expiry are not refreshed in the background.
+	p := NewClientCredentialsTokenProvider(server.URL, "client", "secret", nil, 0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Refresh(context.Background())
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Refresh did not return for a token without expiry")
+	}
+}
@@ -1,8 +1,6 @@
 package splitObjs
 
 import (
-	"fmt"
-	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,14 +12,14 @@ import (
 func cutTracesByMaxSpanCount(splitSize int, td ptrace.Traces, maxSpanCount int) (dest []ptrace.Traces) {
 	// 因为一旦切割粒度不小于当前拥有的总数量，则切割完毕后，切前的对象和前后的对象一样
 	// 因此这样就会产生问题，这里直接将切割粒度/2
-	if kafkaexporter.tracesSpansNum(td) <= splitSize {
+	if td.SpanCount() <= splitSize {
 		return cutTracesByMaxSpanCount(splitSize/2, td, maxSpanCount)
 	}
 	// 进行分割
 	split := SplitTraces(splitSize, td)
 
 	// 判断切下的那边是否需要再切，此时切割粒度/2
-	if kafkaexporter.tracesSpansNum(split) > maxSpanCount {
+	if split.SpanCount() > maxSpanCount {
 		left := cutTracesByMaxSpanCount(splitSize/2, split, maxSpanCount)
 		dest = append(dest, left...)
 	} else {
@@ -29,7 +27,7 @@ func cutTracesByMaxSpanCount(splitSize int, td ptrace.Traces, maxSpanCount int)
 	}
 
 	// 判断切剩的那边是否还需要再切，此时切割粒度保持
-	if kafkaexporter.tracesSpansNum(td) > maxSpanCount {
+	if td.SpanCount() > maxSpanCount {
 		right := cutTracesByMaxSpanCount(splitSize, td, maxSpanCount)
 		dest = append(dest, right...)
 	} else {
@@ -42,7 +40,6 @@ func TestSplitTraces_maxSpanCount(t *testing.T) {
 	totalSpanCount := 20
 	cutSpansCount := 0
 	td := testdata.GenerateTraces(totalSpanCount)
-	fmt.Println("num: ", kafkaexporter.tracesSpansNum(td))
 	split := cutTracesByMaxSpanCount(5, td, 2)
 	for _, s := range split {
 		cutSpansCount += s.ResourceSpans().At(0).ScopeSpans().At(0).Spans().Len()
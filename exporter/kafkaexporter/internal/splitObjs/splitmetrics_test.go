@@ -319,3 +319,43 @@ func TestSplitMetricsMultipleILM(t *testing.T) {
 	assert.Equal(t, "test-metric-int-0-0", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name())
 	assert.Equal(t, "test-metric-int-0-4", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(4).Name())
 }
+
+func cutMetricsByMaxDataPointCount(splitSize int, md pmetric.Metrics, maxDataPointCount int) (dest []pmetric.Metrics) {
+	if md.DataPointCount() <= splitSize {
+		return cutMetricsByMaxDataPointCount(splitSize/2, md, maxDataPointCount)
+	}
+	split := SplitMetrics(splitSize, md)
+
+	if split.DataPointCount() > maxDataPointCount {
+		left := cutMetricsByMaxDataPointCount(splitSize/2, split, maxDataPointCount)
+		dest = append(dest, left...)
+	} else {
+		dest = append(dest, split)
+	}
+
+	if md.DataPointCount() > maxDataPointCount {
+		right := cutMetricsByMaxDataPointCount(splitSize, md, maxDataPointCount)
+		dest = append(dest, right...)
+	} else {
+		dest = append(dest, md)
+	}
+	return dest
+}
+
+func TestSplitMetrics_maxDataPointCount(t *testing.T) {
+	totalDataPointCount := 2000
+	md := pmetric.NewMetrics()
+	dps := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyHistogram().DataPoints()
+	for i := 0; i < totalDataPointCount; i++ {
+		dps.AppendEmpty().SetCount(uint64(i))
+	}
+
+	// A single metric is split across its data points.
+	cutDataPointCount := 0
+	split := cutMetricsByMaxDataPointCount(500, md, 300)
+	for _, s := range split {
+		assert.LessOrEqual(t, s.DataPointCount(), 300)
+		cutDataPointCount += s.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Histogram().DataPoints().Len()
+	}
+	assert.Equal(t, totalDataPointCount, cutDataPointCount)
+}
@@ -9,11 +9,19 @@ import (
 	"github.com/gogo/protobuf/jsonpb"
 	jaegerproto "github.com/jaegertracing/jaeger/model"
 	"go.opentelemetry.io/collector/pdata/ptrace"
+	conventions "go.opentelemetry.io/collector/semconv/v1.6.1"
 	"go.uber.org/multierr"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/translator/jaeger"
 )
 
+const (
+	statusCodeHeader    = "otel-status-code"
+	statusMessageHeader = "otel-status-message"
+	// statusCodeUnset is the otel-status-code of the spans without status, the translator only tags OK and ERROR.
+	statusCodeUnset = "UNSET"
+)
+
 type jaegerMarshaler struct {
 	marshaler jaegerSpanMarshaler
 }
@@ -43,7 +51,10 @@ func (j jaegerMarshaler) Marshal(traces ptrace.Traces, config *Config) ([]*saram
 				Value: sarama.ByteEncoder(bts),
 				Key:   sarama.ByteEncoder(key),
 			}
-			if message.ByteSize(config.Producer.protoVersion) > config.Producer.MaxMessageBytes {
+			if config.SpanStatusHeaders {
+				message.Headers = spanStatusHeaders(span)
+			}
+			if !config.NoSplit && message.ByteSize(config.Producer.protoVersion) > config.Producer.MaxMessageBytes {
 				return nil, errSingleKafkaProducerMessageSizeOverMaxMsgByte
 			}
 			messages = append(messages, message)
@@ -52,6 +63,26 @@ func (j jaegerMarshaler) Marshal(traces ptrace.Traces, config *Config) ([]*saram
 	return messages, errs
 }
 
+// spanStatusHeaders returns the otel-status-code header of span, and its otel-status-message header
+// if the status has a message. The status is read back from the tags set by the Jaeger translator.
+func spanStatusHeaders(span *jaegerproto.Span) []sarama.RecordHeader {
+	code := statusCodeUnset
+	var statusMessage string
+	for _, kv := range span.Tags {
+		switch kv.Key {
+		case conventions.OtelStatusCode:
+			code = kv.VStr
+		case conventions.OtelStatusDescription:
+			statusMessage = kv.VStr
+		}
+	}
+	headers := []sarama.RecordHeader{{Key: []byte(statusCodeHeader), Value: []byte(code)}}
+	if statusMessage != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(statusMessageHeader), Value: []byte(statusMessage)})
+	}
+	return headers
+}
+
 func (j jaegerMarshaler) Encoding() string {
 	return j.marshaler.encoding()
 }
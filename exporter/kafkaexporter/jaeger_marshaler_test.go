@@ -5,6 +5,7 @@ package kafkaexporter
 
 import (
 	"bytes"
+	"encoding/json"
 	"strconv"
 	"testing"
 
@@ -102,6 +103,54 @@ func TestJaegerMarshaler(t *testing.T) {
 	}
 }
 
+func TestJaegerJSONMarshaler_events_and_links(t *testing.T) {
+	td := genJaegerTracesData(1)
+	span := td.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	event := span.Events().AppendEmpty()
+	event.SetName("exception")
+	event.SetTimestamp(pcommon.Timestamp(15))
+	event.Attributes().PutStr("exception.message", "boom")
+	link := span.Links().AppendEmpty()
+	link.SetTraceID([16]byte{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1})
+	link.SetSpanID([8]byte{8, 7, 6, 5, 4, 3, 2, 1})
+
+	m := jaegerMarshaler{
+		marshaler: jaegerJSONSpanMarshaler{
+			pbMarshaler: &jsonpb.Marshaler{},
+		},
+	}
+	messages, err := m.Marshal(td, &Config{Topic: "topic", Producer: Producer{MaxMessageBytes: 1000 * 1000}})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+
+	bts, err := messages[0].Value.Encode()
+	require.NoError(t, err)
+	var out struct {
+		References []struct {
+			TraceID string `json:"traceId"`
+			SpanID  string `json:"spanId"`
+			RefType string `json:"refType"`
+		} `json:"references"`
+		Logs []struct {
+			Fields []struct {
+				Key  string `json:"key"`
+				VStr string `json:"vStr"`
+			} `json:"fields"`
+		} `json:"logs"`
+	}
+	require.NoError(t, json.Unmarshal(bts, &out))
+
+	require.Len(t, out.References, 1)
+	assert.Equal(t, "FOLLOWS_FROM", out.References[0].RefType)
+	require.Len(t, out.Logs, 1)
+	fields := map[string]string{}
+	for _, f := range out.Logs[0].Fields {
+		fields[f.Key] = f.VStr
+	}
+	assert.Equal(t, "exception", fields["event"])
+	assert.Equal(t, "boom", fields["exception.message"])
+}
+
 func genJaegerTracesData(spanNum int) ptrace.Traces {
 	td := ptrace.NewTraces()
 	for i := 0; i < spanNum; i++ {
@@ -114,3 +163,26 @@ func genJaegerTracesData(spanNum int) ptrace.Traces {
 	}
 	return td
 }
+
+func TestJaegerMarshaler_span_status_headers(t *testing.T) {
+	td := genJaegerTracesData(2)
+	spans := td.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	spans.At(0).Status().SetCode(ptrace.StatusCodeError)
+	spans.At(0).Status().SetMessage("connection refused")
+
+	m := jaegerMarshaler{marshaler: jaegerProtoSpanMarshaler{}}
+	messages, err := m.Marshal(td, &Config{Topic: "topic", SpanStatusHeaders: true, Producer: Producer{MaxMessageBytes: 1000 * 1000}})
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("otel-status-code"), Value: []byte("ERROR")},
+		{Key: []byte("otel-status-message"), Value: []byte("connection refused")},
+	}, messages[0].Headers)
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("otel-status-code"), Value: []byte("UNSET")},
+	}, messages[1].Headers)
+
+	messages, err = m.Marshal(td, &Config{Topic: "topic", Producer: Producer{MaxMessageBytes: 1000 * 1000}})
+	require.NoError(t, err)
+	assert.Empty(t, messages[0].Headers)
+}
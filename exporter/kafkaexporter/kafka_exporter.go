@@ -7,26 +7,41 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"text/template"
+	"time"
 
 	"github.com/IBM/sarama"
+	"go.opencensus.io/tag"
 	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/exporter"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/awsmsk"
 )
 
 var errUnrecognizedEncoding = fmt.Errorf("unrecognized encoding")
+var errBrokerQuorumNotReached = fmt.Errorf("broker quorum not reached")
 var errSingleKafkaProducerMessageSizeOverMaxMsgByte = fmt.Errorf("one kafka produer message big then max_message_bytes settings")
 
 // kafkaTracesProducer uses sarama to produce trace messages to Kafka.
 type kafkaTracesProducer struct {
-	producer  sarama.SyncProducer
-	topic     string
-	marshaler TracesMarshaler
-	config    *Config
-	logger    *zap.Logger
+	producer      sarama.SyncProducer
+	topic         string
+	topicTemplate *template.Template
+	marshaler     TracesMarshaler
+	config        *Config
+	limiter       *produceRateLimiter
+	recordAge     *recordAgeFilter
+	emptyTopic    *emptyTopicFilter
+	renamer       *attributeRenamer
+	timer         *produceTimer
+	probe         *startupProbe
+	logger        *zap.Logger
+	inspector     MessageInspector
 }
 
 type kafkaErrors struct {
@@ -38,55 +53,27 @@ func (ke kafkaErrors) Error() string {
 	return fmt.Sprintf("Failed to deliver %d messages due to %s", ke.count, ke.err)
 }
 
-func (e *kafkaTracesProducer) tracesPusher(_ context.Context, td ptrace.Traces) error {
-	messagesSlice, err := e.marshaler.Marshal(td, e.config)
-	if err != nil {
-		return consumererror.NewPermanent(err)
-	}
-
-	startIndex := 0
-	messagesSize := 0
-	for i, messages := range messagesSlice {
-		currentMessageSize := messages.ByteSize(e.config.Producer.protoVersion)
-		if currentMessageSize > e.config.Producer.MaxMessageBytes {
-			return errSingleKafkaProducerMessageSizeOverMaxMsgByte
-		}
-		messagesSize += currentMessageSize
-		if messagesSize <= e.config.Producer.MaxMessageBytes {
-			continue
-		}
-
-		// if only one message big then MaxMessageBytes
-		if i-startIndex == 0 {
-			return errSingleKafkaProducerMessageSizeOverMaxMsgByte
+func (e *kafkaTracesProducer) tracesPusher(ctx context.Context, td ptrace.Traces) error {
+	if e.recordAge != nil {
+		td = e.recordAge.traces(td)
+		if td.SpanCount() == 0 {
+			return nil
 		}
-
-		err = e.pushMsg(messagesSlice, startIndex, i)
-		if err != nil {
-			return err
-		}
-		startIndex = i
-		messagesSize = messages.ByteSize(e.config.Producer.protoVersion)
 	}
-	// push the rest message
-	return e.pushMsg(messagesSlice, startIndex, len(messagesSlice))
-}
-
-func (e *kafkaTracesProducer) pushMsg(messagesSlice []*sarama.ProducerMessage, startIndex, endIndex int) error {
-	if startIndex >= endIndex {
-		return nil
+	td = prepareTraces(e.config, e.marshaler, e.renamer, td)
+	if e.emptyTopic != nil {
+		td = e.emptyTopic.traces(td)
+		if td.SpanCount() == 0 {
+			return nil
+		}
 	}
-	err := e.producer.SendMessages(messagesSlice[startIndex:endIndex])
+	start := time.Now()
+	messages, err := marshalTracesByTopic(e.marshaler, td, e.config, e.topicTemplate, newResourceHeaders(ctx, e.config, e.logger))
+	e.timer.marshaled(ctx, start)
 	if err != nil {
-		var prodErr sarama.ProducerErrors
-		if errors.As(err, &prodErr) {
-			if len(prodErr) > 0 {
-				return kafkaErrors{len(prodErr), prodErr[0].Err.Error()}
-			}
-		}
-		return err
+		return consumererror.NewPermanent(err)
 	}
-	return nil
+	return sendMessages(ctx, e.producer, e.limiter, e.timer, e.config, messages, e.inspector, e.logger)
 }
 
 func (e *kafkaTracesProducer) Close(context.Context) error {
@@ -95,36 +82,58 @@ func (e *kafkaTracesProducer) Close(context.Context) error {
 
 // kafkaMetricsProducer uses sarama to produce metrics messages to kafka
 type kafkaMetricsProducer struct {
-	producer  sarama.SyncProducer
-	topic     string
-	marshaler MetricsMarshaler
-	config    *Config
-	logger    *zap.Logger
+	producer      sarama.SyncProducer
+	topic         string
+	topicTemplate *template.Template
+	marshaler     MetricsMarshaler
+	config        *Config
+	limiter       *produceRateLimiter
+	recordAge     *recordAgeFilter
+	emptyTopic    *emptyTopicFilter
+	coalescer     *repeatCoalescer
+	// startTimestamps holds the first timestamps of the series of missing_start_timestamp first_seen.
+	startTimestamps *startTimestamps
+	renamer         *attributeRenamer
+	timer           *produceTimer
+	probe           *startupProbe
+	logger          *zap.Logger
+	inspector       MessageInspector
 }
 
-func (e *kafkaMetricsProducer) metricsDataPusher(_ context.Context, md pmetric.Metrics) error {
-	messages, err := e.marshaler.Marshal(md, e.config)
-	if err != nil {
-		return consumererror.NewPermanent(err)
+func (e *kafkaMetricsProducer) metricsDataPusher(ctx context.Context, md pmetric.Metrics) error {
+	if e.recordAge != nil {
+		md = e.recordAge.metrics(md)
+		if md.DataPointCount() == 0 {
+			return nil
+		}
 	}
-
-	messagesByte := 0
-	for _, message := range messages {
-		messagesByte += message.ByteSize(e.config.Producer.protoVersion)
-		if messagesByte > e.config.Producer.MaxMessageBytes {
-			return errSingleKafkaProducerMessageSizeOverMaxMsgByte
+	var update *coalescerUpdate
+	if e.coalescer != nil {
+		md, update = e.coalescer.metrics(md)
+		if md.DataPointCount() == 0 {
+			e.coalescer.commit(update)
+			return nil
 		}
 	}
-	err = e.producer.SendMessages(messages)
-	if err != nil {
-		var prodErr sarama.ProducerErrors
-		if errors.As(err, &prodErr) {
-			if len(prodErr) > 0 {
-				return kafkaErrors{len(prodErr), prodErr[0].Err.Error()}
-			}
+	md = prepareMetrics(e.config, e.marshaler, e.renamer, e.startTimestamps, md)
+	if e.emptyTopic != nil {
+		md = e.emptyTopic.metrics(md)
+		if md.DataPointCount() == 0 {
+			e.coalescer.commit(update)
+			return nil
 		}
+	}
+	start := time.Now()
+	messages, err := marshalMetricsByTopic(e.marshaler, md, e.config, e.topicTemplate, newResourceHeaders(ctx, e.config, e.logger))
+	e.timer.marshaled(ctx, start)
+	if err != nil {
+		return consumererror.NewPermanent(err)
+	}
+	addHeaders(messages, update.headers())
+	if err = sendMessages(ctx, e.producer, e.limiter, e.timer, e.config, messages, e.inspector, e.logger); err != nil {
 		return err
 	}
+	e.coalescer.commit(update)
 	return nil
 }
 
@@ -134,28 +143,96 @@ func (e *kafkaMetricsProducer) Close(context.Context) error {
 
 // kafkaLogsProducer uses sarama to produce logs messages to kafka
 type kafkaLogsProducer struct {
-	producer  sarama.SyncProducer
-	topic     string
-	marshaler LogsMarshaler
-	config    *Config
-	logger    *zap.Logger
+	producer      sarama.SyncProducer
+	topic         string
+	topicTemplate *template.Template
+	marshaler     LogsMarshaler
+	config        *Config
+	limiter       *produceRateLimiter
+	recordAge     *recordAgeFilter
+	emptyTopic    *emptyTopicFilter
+	renamer       *attributeRenamer
+	timer         *produceTimer
+	probe         *startupProbe
+	logger        *zap.Logger
+	inspector     MessageInspector
 }
 
-func (e *kafkaLogsProducer) logsDataPusher(_ context.Context, ld plog.Logs) error {
-	messages, err := e.marshaler.Marshal(ld, e.config)
+func (e *kafkaLogsProducer) logsDataPusher(ctx context.Context, ld plog.Logs) error {
+	if e.recordAge != nil {
+		ld = e.recordAge.logs(ld)
+		if ld.LogRecordCount() == 0 {
+			return nil
+		}
+	}
+	ld = prepareLogs(e.config, e.marshaler, e.renamer, ld)
+	if e.emptyTopic != nil {
+		ld = e.emptyTopic.logs(ld)
+		if ld.LogRecordCount() == 0 {
+			return nil
+		}
+	}
+	start := time.Now()
+	messages, err := marshalLogsByTopic(e.marshaler, ld, e.config, e.topicTemplate, newResourceHeaders(ctx, e.config, e.logger))
+	e.timer.marshaled(ctx, start)
 	if err != nil {
 		return consumererror.NewPermanent(err)
 	}
+	return sendMessages(ctx, e.producer, e.limiter, e.timer, e.config, messages, e.inspector, e.logger)
+}
+
+func (e *kafkaLogsProducer) Close(context.Context) error {
+	return e.producer.Close()
+}
 
-	messagesByte := 0
-	for _, message := range messages {
-		messagesByte += message.ByteSize(e.config.Producer.protoVersion)
-		if messagesByte > e.config.Producer.MaxMessageBytes {
-			return errSingleKafkaProducerMessageSizeOverMaxMsgByte
+// sendMessages produces the messages in batches of at most max_message_bytes. Messages bigger
+// than the maximum size of their topic fail the whole request, or are only logged with no_split.
+// The messages are passed to inspect, if not nil, before they are sent.
+func sendMessages(ctx context.Context, producer sarama.SyncProducer, limiter *produceRateLimiter, timer *produceTimer, config *Config, messages []*sarama.ProducerMessage, inspect MessageInspector, logger *zap.Logger) error {
+	messages, err := prepareMessages(config, messages)
+	if err != nil {
+		return consumererror.NewPermanent(err)
+	}
+	if inspect != nil {
+		for _, message := range messages {
+			inspect(message)
 		}
 	}
+	var spent time.Duration
+	startIndex := 0
+	batchSize := 0
+	for i, message := range messages {
+		messageSize := message.ByteSize(config.Producer.protoVersion)
+		if maxBytes := config.maxMessageBytes(message.Topic); messageSize > maxBytes {
+			if !config.NoSplit {
+				return errSingleKafkaProducerMessageSizeOverMaxMsgByte
+			}
+			logger.Warn("Producing a Kafka message bigger than max_message_bytes",
+				zap.String("topic", message.Topic),
+				zap.Int("size", messageSize),
+				zap.Int("max_message_bytes", maxBytes))
+		}
+		if i > startIndex && batchSize+messageSize > config.Producer.MaxMessageBytes {
+			if err := pushMessages(ctx, producer, limiter, timer, &spent, messages[startIndex:i]); err != nil {
+				return err
+			}
+			startIndex = i
+			batchSize = 0
+		}
+		batchSize += messageSize
+	}
+	// push the rest message
+	return pushMessages(ctx, producer, limiter, timer, &spent, messages[startIndex:])
+}
 
-	err = e.producer.SendMessages(messages)
+func pushMessages(ctx context.Context, producer sarama.SyncProducer, limiter *produceRateLimiter, timer *produceTimer, spent *time.Duration, messages []*sarama.ProducerMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	if err := limiter.wait(ctx, messages); err != nil {
+		return err
+	}
+	err := timer.send(ctx, producer, messages, spent)
 	if err != nil {
 		var prodErr sarama.ProducerErrors
 		if errors.As(err, &prodErr) {
@@ -168,23 +245,34 @@ func (e *kafkaLogsProducer) logsDataPusher(_ context.Context, ld plog.Logs) erro
 	return nil
 }
 
-func (e *kafkaLogsProducer) Close(context.Context) error {
-	return e.producer.Close()
-}
-
-func newSaramaProducer(config Config) (sarama.SyncProducer, error) {
+func newSaramaProducer(config Config, set exporter.CreateSettings) (sarama.SyncProducer, error) {
 	c := sarama.NewConfig()
-	// These setting are required by the sarama.SyncProducer implementation.
+	// These setting are required by the sarama.SyncProducer implementation, and by asyncProducer.
 	c.Producer.Return.Successes = true
 	c.Producer.Return.Errors = true
-	c.Producer.RequiredAcks = config.Producer.RequiredAcks
+	requiredAcks, err := saramaRequiredAcks(config.Producer.RequiredAcks)
+	if err != nil {
+		return nil, err
+	}
+	c.Producer.RequiredAcks = requiredAcks
 	// Because sarama does not accept a Context for every message, set the Timeout here.
 	c.Producer.Timeout = config.Timeout
 	c.Metadata.Full = config.Metadata.Full
 	c.Metadata.Retry.Max = config.Metadata.Retry.Max
 	c.Metadata.Retry.Backoff = config.Metadata.Retry.Backoff
 	c.Producer.MaxMessageBytes = config.Producer.MaxMessageBytes
+	if config.OnUnsplittable.DeadLetterMaxMessageBytes > c.Producer.MaxMessageBytes {
+		c.Producer.MaxMessageBytes = config.OnUnsplittable.DeadLetterMaxMessageBytes
+	}
+	if config.NoSplit {
+		// The size of the messages is left to the broker.
+		c.Producer.MaxMessageBytes = int(sarama.MaxRequestSize) - 1
+	}
 	c.Producer.Flush.MaxMessages = config.Producer.FlushMaxMessages
+	if partitioner := newSpilloverPartitioner(config.Producer.HotPartition); partitioner != nil {
+		c.Producer.Partitioner = partitioner
+	}
+	c.Producer.Partitioner = newSequencePartitioner(config.SequenceHeader, c.Producer.Partitioner)
 
 	if config.ProtocolVersion != "" {
 		version, err := sarama.ParseKafkaVersion(config.ProtocolVersion)
@@ -197,6 +285,19 @@ func newSaramaProducer(config Config) (sarama.SyncProducer, error) {
 	if err := ConfigureAuthentication(config.Authentication, c); err != nil {
 		return nil, err
 	}
+	// A missing keytab fails the start instead of the first produce.
+	if kerberos := config.Authentication.Kerberos; kerberos != nil && kerberos.UseKeyTab {
+		if _, err := os.Stat(kerberos.KeyTabPath); err != nil {
+			return nil, fmt.Errorf("auth.kerberos.keytab_file cannot be read: %w", err)
+		}
+	}
+	// The MSK IAM tokens are signed before connecting, as sarama only reports the brokers as unreachable when
+	// the AWS credentials cannot be obtained.
+	if provider, ok := c.Net.SASL.TokenProvider.(*awsmsk.TokenProvider); ok {
+		if _, err := provider.Token(); err != nil {
+			return nil, fmt.Errorf("failed to obtain the AWS_MSK_IAM_OAUTHBEARER token: %w", err)
+		}
+	}
 
 	compression, err := saramaProducerCompressionCodec(config.Producer.Compression)
 	if err != nil {
@@ -204,11 +305,70 @@ func newSaramaProducer(config Config) (sarama.SyncProducer, error) {
 	}
 	c.Producer.Compression = compression
 
-	producer, err := sarama.NewSyncProducer(config.Brokers, c)
+	if config.BrokerQuorum > 0 {
+		if err = checkBrokerQuorum(config.Brokers, config.BrokerQuorum, c, set.Logger, newQuorumBroker); err != nil {
+			return nil, err
+		}
+	}
+
+	newProducer := func(c *sarama.Config) (sarama.SyncProducer, error) {
+		if !config.Producer.Async {
+			return sarama.NewSyncProducer(config.Brokers, c)
+		}
+		producer, err := sarama.NewAsyncProducer(config.Brokers, c)
+		if err != nil {
+			return nil, err
+		}
+		return newAsyncProducer(producer, set.Logger, set.ID), nil
+	}
+	var producer sarama.SyncProducer
+	if config.Producer.Compression == compressionAuto {
+		producer, err = newAutoCompressionProducer(config.Producer.AutoCompression.Samples, func(codec sarama.CompressionCodec) (sarama.SyncProducer, error) {
+			pc := *c
+			pc.Producer.Compression = codec
+			return newProducer(&pc)
+		}, set.Logger)
+	} else {
+		producer, err = newProducer(c)
+	}
 	if err != nil {
 		return nil, err
 	}
-	return producer, nil
+	return withTokenRefresh(c, producer), nil
+}
+
+// quorumBroker is the part of *sarama.Broker used by checkBrokerQuorum.
+type quorumBroker interface {
+	Open(conf *sarama.Config) error
+	Connected() (bool, error)
+	Close() error
+}
+
+func newQuorumBroker(addr string) quorumBroker {
+	return sarama.NewBroker(addr)
+}
+
+// checkBrokerQuorum connects to every broker and fails if less than quorum of them are reachable.
+// The brokers that cannot be opened or reached are logged.
+func checkBrokerQuorum(brokers []string, quorum int, c *sarama.Config, logger *zap.Logger, newBroker func(addr string) quorumBroker) error {
+	reachable := 0
+	for _, addr := range brokers {
+		broker := newBroker(addr)
+		if err := broker.Open(c); err != nil {
+			logger.Warn("Kafka broker is not reachable", zap.String("broker", addr), zap.Error(err))
+			continue
+		}
+		if _, err := broker.Connected(); err != nil {
+			logger.Warn("Kafka broker is not reachable", zap.String("broker", addr), zap.Error(err))
+			continue
+		}
+		reachable++
+		_ = broker.Close()
+	}
+	if reachable < quorum {
+		return fmt.Errorf("%w: %d of %d brokers reachable, %d required", errBrokerQuorumNotReached, reachable, len(brokers), quorum)
+	}
+	return nil
 }
 
 func newMetricsExporter(config Config, set exporter.CreateSettings, marshalers map[string]MetricsMarshaler) (*kafkaMetricsProducer, error) {
@@ -216,7 +376,11 @@ func newMetricsExporter(config Config, set exporter.CreateSettings, marshalers m
 	if marshaler == nil {
 		return nil, errUnrecognizedEncoding
 	}
-	producer, err := newSaramaProducer(config)
+	topicTemplate, err := compileTopicTemplate(config.TopicTemplate)
+	if err != nil {
+		return nil, err
+	}
+	producer, err := newSaramaProducer(config, set)
 	if err != nil {
 		return nil, err
 	}
@@ -227,11 +391,20 @@ func newMetricsExporter(config Config, set exporter.CreateSettings, marshalers m
 	}
 
 	return &kafkaMetricsProducer{
-		producer:  producer,
-		topic:     config.Topic,
-		marshaler: marshaler,
-		config:    &config,
-		logger:    set.Logger,
+		producer:        producer,
+		topic:           config.Topic,
+		marshaler:       marshaler,
+		config:          &config,
+		topicTemplate:   topicTemplate,
+		limiter:         newProduceRateLimiter(config.Producer),
+		recordAge:       newRecordAgeFilter(config.MaxRecordAge, set.ID),
+		emptyTopic:      newEmptyTopicFilter(&config, topicTemplate, []tag.Mutator{tag.Upsert(tagInstanceName, set.ID.String())}),
+		coalescer:       newRepeatCoalescer(&config),
+		startTimestamps: newStartTimestamps(),
+		renamer:         newAttributeRenamer(config.AttributeRenames),
+		timer:           newProduceTimer(config.Producer.ProduceDeadline, set.ID),
+		probe:           newStartupProbe(config, producer, set.Logger),
+		logger:          set.Logger,
 	}, nil
 
 }
@@ -242,7 +415,11 @@ func newTracesExporter(config Config, set exporter.CreateSettings, marshalers ma
 	if marshaler == nil {
 		return nil, errUnrecognizedEncoding
 	}
-	producer, err := newSaramaProducer(config)
+	topicTemplate, err := compileTopicTemplate(config.TopicTemplate)
+	if err != nil {
+		return nil, err
+	}
+	producer, err := newSaramaProducer(config, set)
 	if err != nil {
 		return nil, err
 	}
@@ -253,11 +430,18 @@ func newTracesExporter(config Config, set exporter.CreateSettings, marshalers ma
 	}
 
 	return &kafkaTracesProducer{
-		producer:  producer,
-		topic:     config.Topic,
-		marshaler: marshaler,
-		config:    &config,
-		logger:    set.Logger,
+		producer:      producer,
+		topic:         config.Topic,
+		marshaler:     marshaler,
+		config:        &config,
+		topicTemplate: topicTemplate,
+		limiter:       newProduceRateLimiter(config.Producer),
+		recordAge:     newRecordAgeFilter(config.MaxRecordAge, set.ID),
+		emptyTopic:    newEmptyTopicFilter(&config, topicTemplate, []tag.Mutator{tag.Upsert(tagInstanceName, set.ID.String())}),
+		renamer:       newAttributeRenamer(config.AttributeRenames),
+		timer:         newProduceTimer(config.Producer.ProduceDeadline, set.ID),
+		probe:         newStartupProbe(config, producer, set.Logger),
+		logger:        set.Logger,
 	}, nil
 }
 
@@ -266,7 +450,11 @@ func newLogsExporter(config Config, set exporter.CreateSettings, marshalers map[
 	if marshaler == nil {
 		return nil, errUnrecognizedEncoding
 	}
-	producer, err := newSaramaProducer(config)
+	topicTemplate, err := compileTopicTemplate(config.TopicTemplate)
+	if err != nil {
+		return nil, err
+	}
+	producer, err := newSaramaProducer(config, set)
 	if err != nil {
 		return nil, err
 	}
@@ -277,11 +465,18 @@ func newLogsExporter(config Config, set exporter.CreateSettings, marshalers map[
 	}
 
 	return &kafkaLogsProducer{
-		producer:  producer,
-		topic:     config.Topic,
-		marshaler: marshaler,
-		config:    &config,
-		logger:    set.Logger,
+		producer:      producer,
+		topic:         config.Topic,
+		marshaler:     marshaler,
+		config:        &config,
+		topicTemplate: topicTemplate,
+		limiter:       newProduceRateLimiter(config.Producer),
+		recordAge:     newRecordAgeFilter(config.MaxRecordAge, set.ID),
+		emptyTopic:    newEmptyTopicFilter(&config, topicTemplate, []tag.Mutator{tag.Upsert(tagInstanceName, set.ID.String())}),
+		renamer:       newAttributeRenamer(config.AttributeRenames),
+		timer:         newProduceTimer(config.Producer.ProduceDeadline, set.ID),
+		probe:         newStartupProbe(config, producer, set.Logger),
+		logger:        set.Logger,
 	}, nil
 
 }
@@ -9,7 +9,11 @@ import (
 	"fmt"
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/translator/jaeger"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/IBM/sarama/mocks"
@@ -21,6 +25,8 @@ import (
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
 )
@@ -54,7 +60,19 @@ func TestNewMetricsExporter_err_encoding(t *testing.T) {
 }
 
 func TestNewMetricsExporter_err_traces_encoding(t *testing.T) {
-	c := Config{Encoding: "jaeger_proto"}
+	for _, encoding := range []string{"jaeger_proto", "zipkin_proto", "zipkin_json"} {
+		c := Config{Encoding: encoding}
+		mexp, err := newMetricsExporter(c, exportertest.NewNopCreateSettings(), metricsMarshalers())
+		assert.EqualError(t, err, errUnrecognizedEncoding.Error())
+		assert.Nil(t, mexp)
+	}
+}
+
+func TestNewExporter_err_raw_encoding(t *testing.T) {
+	c := Config{Encoding: "raw"}
+	texp, err := newTracesExporter(c, exportertest.NewNopCreateSettings(), tracesMarshalers())
+	assert.EqualError(t, err, errUnrecognizedEncoding.Error())
+	assert.Nil(t, texp)
 	mexp, err := newMetricsExporter(c, exportertest.NewNopCreateSettings(), metricsMarshalers())
 	assert.EqualError(t, err, errUnrecognizedEncoding.Error())
 	assert.Nil(t, mexp)
@@ -75,10 +93,12 @@ func TestNewLogsExporter_err_encoding(t *testing.T) {
 }
 
 func TestNewLogsExporter_err_traces_encoding(t *testing.T) {
-	c := Config{Encoding: "jaeger_proto"}
-	mexp, err := newLogsExporter(c, exportertest.NewNopCreateSettings(), logsMarshalers())
-	assert.EqualError(t, err, errUnrecognizedEncoding.Error())
-	assert.Nil(t, mexp)
+	for _, encoding := range []string{"jaeger_proto", "zipkin_proto", "zipkin_json"} {
+		c := Config{Encoding: encoding}
+		mexp, err := newLogsExporter(c, exportertest.NewNopCreateSettings(), logsMarshalers())
+		assert.EqualError(t, err, errUnrecognizedEncoding.Error())
+		assert.Nil(t, mexp)
+	}
 }
 
 func TestNewExporter_err_auth_type(t *testing.T) {
@@ -123,8 +143,121 @@ func TestNewExporter_err_compression(t *testing.T) {
 	}
 	texp, err := newTracesExporter(c, exportertest.NewNopCreateSettings(), tracesMarshalers())
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "producer.compression should be one of 'none', 'gzip', 'snappy', 'lz4', or 'zstd'. configured value idk")
+	assert.Contains(t, err.Error(), "producer.compression should be one of 'none', 'gzip', 'snappy', 'lz4', 'zstd', or 'auto'. configured value idk")
+	assert.Nil(t, texp)
+}
+
+func TestNewExporter_err_required_acks(t *testing.T) {
+	c := Config{
+		Encoding: defaultEncoding,
+		Producer: Producer{
+			RequiredAcks: 2,
+			Compression:  "none",
+		},
+	}
+	texp, err := newTracesExporter(c, exportertest.NewNopCreateSettings(), tracesMarshalers())
+	assert.EqualError(t, err, "producer.required_acks should be one of 'none', 'leader', 'local', 'all', 0, 1 or -1. configured value 2")
+	assert.Nil(t, texp)
+}
+
+func TestNewExporter_err_sasl_mechanism(t *testing.T) {
+	c := Config{
+		Encoding: defaultEncoding,
+		Authentication: Authentication{
+			SASL: &SASLConfig{Username: "jdoe", Password: "pass", Mechanism: "SCRAM-SHA-1"},
+		},
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+	texp, err := newTracesExporter(c, exportertest.NewNopCreateSettings(), tracesMarshalers())
+	assert.ErrorContains(t, err, `invalid SASL Mechanism "SCRAM-SHA-1"`)
 	assert.Nil(t, texp)
+	mexp, err := newMetricsExporter(c, exportertest.NewNopCreateSettings(), metricsMarshalers())
+	assert.ErrorContains(t, err, `invalid SASL Mechanism "SCRAM-SHA-1"`)
+	assert.Nil(t, mexp)
+	lexp, err := newLogsExporter(c, exportertest.NewNopCreateSettings(), logsMarshalers())
+	assert.ErrorContains(t, err, `invalid SASL Mechanism "SCRAM-SHA-1"`)
+	assert.Nil(t, lexp)
+}
+
+func TestNewExporter_err_kerberos_keytab(t *testing.T) {
+	c := Config{
+		Encoding: defaultEncoding,
+		Authentication: Authentication{
+			Kerberos: &KerberosConfig{ServiceName: "kafka", UseKeyTab: true, KeyTabPath: filepath.Join(t.TempDir(), "kafka.keytab")},
+		},
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+	require.NoError(t, c.Validate())
+	texp, err := newTracesExporter(c, exportertest.NewNopCreateSettings(), tracesMarshalers())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.ErrorContains(t, err, "auth.kerberos.keytab_file cannot be read")
+	assert.Nil(t, texp)
+}
+
+func TestNewExporter_broker_quorum(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	zcore, logObserver := observer.New(zapcore.WarnLevel)
+	set := exportertest.NewNopCreateSettings()
+	set.Logger = zap.New(zcore)
+	c := *createDefaultConfig().(*Config)
+	c.Brokers = []string{broker.Addr(), "localhost:1"}
+	c.BrokerQuorum = 1
+	c.Metadata.Full = false
+	texp, err := newTracesExporter(c, set, tracesMarshalers())
+	require.NoError(t, err)
+	require.NotNil(t, texp)
+	t.Cleanup(func() {
+		require.NoError(t, texp.Close(context.Background()))
+	})
+	logs := logObserver.FilterMessage("Kafka broker is not reachable").All()
+	require.Len(t, logs, 1)
+	assert.Equal(t, "localhost:1", logs[0].ContextMap()["broker"])
+
+	c.BrokerQuorum = 2
+	_, err = newTracesExporter(c, set, tracesMarshalers())
+	assert.ErrorIs(t, err, errBrokerQuorumNotReached)
+}
+
+type fakeQuorumBroker struct {
+	openErr error
+}
+
+func (b fakeQuorumBroker) Open(*sarama.Config) error {
+	return b.openErr
+}
+
+func (b fakeQuorumBroker) Connected() (bool, error) {
+	return true, nil
+}
+
+func (b fakeQuorumBroker) Close() error {
+	return nil
+}
+
+func TestCheckBrokerQuorum_openError(t *testing.T) {
+	newBroker := func(addr string) quorumBroker {
+		if addr == "broken:9092" {
+			return fakeQuorumBroker{openErr: sarama.ErrAlreadyConnected}
+		}
+		return fakeQuorumBroker{}
+	}
+	brokers := []string{"broker1:9092", "broken:9092", "broker2:9092"}
+
+	zcore, logObserver := observer.New(zapcore.WarnLevel)
+	require.NoError(t, checkBrokerQuorum(brokers, 2, sarama.NewConfig(), zap.New(zcore), newBroker))
+	logs := logObserver.FilterMessage("Kafka broker is not reachable").All()
+	require.Len(t, logs, 1)
+	assert.Equal(t, "broken:9092", logs[0].ContextMap()["broker"])
+
+	err := checkBrokerQuorum(brokers, 3, sarama.NewConfig(), zap.NewNop(), newBroker)
+	assert.ErrorIs(t, err, errBrokerQuorumNotReached)
+	assert.EqualError(t, err, "broker quorum not reached: 2 of 3 brokers reachable, 3 required")
 }
 
 func TestTracesPusher(t *testing.T) {
@@ -144,6 +277,24 @@ func TestTracesPusher(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestTracesPusher_requiredAcksNone(t *testing.T) {
+	c := sarama.NewConfig()
+	c.Producer.Return.Successes = true
+	c.Producer.RequiredAcks = sarama.RequiredAcks(requiredAcksNames["none"])
+	producer := mocks.NewSyncProducer(t, c)
+	producer.ExpectSendMessageAndSucceed()
+
+	p := kafkaTracesProducer{
+		producer:  producer,
+		marshaler: newPdataTracesMarshaler(&ptrace.ProtoMarshaler{}, defaultEncoding),
+		config:    &Config{Producer: Producer{protoVersion: 2, MaxMessageBytes: 1000 * 1000}},
+	}
+	t.Cleanup(func() {
+		require.NoError(t, p.Close(context.Background()))
+	})
+	require.NoError(t, p.tracesPusher(context.Background(), testdata.GenerateTracesTwoSpansSameResource()))
+}
+
 func TestTracesPusher_err(t *testing.T) {
 	c := sarama.NewConfig()
 	producer := mocks.NewSyncProducer(t, c)
@@ -372,6 +523,40 @@ func TestMetricsDataPusher(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestMetricsDataPusher_inspector(t *testing.T) {
+	c := sarama.NewConfig()
+	producer := mocks.NewSyncProducer(t, c)
+	producer.ExpectSendMessageAndSucceed()
+
+	var inspected []*sarama.ProducerMessage
+	p := kafkaMetricsProducer{
+		producer:  producer,
+		marshaler: newPdataMetricsMarshaler(&pmetric.ProtoMarshaler{}, defaultEncoding),
+		config: &Config{
+			Topic:    "metrics",
+			Baggage:  Baggage{FromContext: true},
+			Producer: Producer{protoVersion: 2, MaxMessageBytes: 1000 * 1000},
+		},
+		inspector: func(message *sarama.ProducerMessage) {
+			inspected = append(inspected, message)
+		},
+	}
+	t.Cleanup(func() {
+		require.NoError(t, p.Close(context.Background()))
+	})
+	md := testdata.GenerateMetricsTwoMetrics()
+	require.NoError(t, p.metricsDataPusher(contextWithBaggage(t, "tenant=acme"), md))
+
+	require.Len(t, inspected, 1)
+	assert.Equal(t, "metrics", inspected[0].Topic)
+	assert.Nil(t, inspected[0].Key)
+	assert.Equal(t, []sarama.RecordHeader{{Key: []byte("tenant"), Value: []byte("acme")}}, inspected[0].Headers)
+	expected, err := (&pmetric.ProtoMarshaler{}).MarshalMetrics(md)
+	require.NoError(t, err)
+	assert.Equal(t, sarama.ByteEncoder(expected), inspected[0].Value)
+	assert.Equal(t, len(expected), inspected[0].Value.Length())
+}
+
 func TestMetricsDataPusher_err(t *testing.T) {
 	c := sarama.NewConfig()
 	producer := mocks.NewSyncProducer(t, c)
@@ -440,6 +625,41 @@ func TestLogsDataPusher(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestLogsDataPusher_rate_limit(t *testing.T) {
+	c := sarama.NewConfig()
+	producer := mocks.NewSyncProducer(t, c)
+	for i := 0; i < 30; i++ {
+		producer.ExpectSendMessageAndSucceed()
+	}
+
+	config := &Config{Producer: Producer{
+		protoVersion:    2,
+		MaxMessageBytes: 1000 * 1000,
+		RateLimit:       RateLimit{MessagesPerSecond: 20},
+	}}
+	p := kafkaLogsProducer{
+		producer:  producer,
+		marshaler: newPdataLogsMarshaler(&plog.ProtoMarshaler{}, defaultEncoding),
+		config:    config,
+		limiter:   newProduceRateLimiter(config.Producer),
+	}
+	t.Cleanup(func() {
+		require.NoError(t, p.Close(context.Background()))
+	})
+
+	// The first 20 messages fit in the burst, the other 10 take half a second.
+	start := time.Now()
+	for i := 0; i < 30; i++ {
+		require.NoError(t, p.logsDataPusher(context.Background(), testdata.GenerateLogsOneLogRecord()))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 450*time.Millisecond)
+
+	// The limiter is exhausted, waiting for more tokens is cancelled with the context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, p.logsDataPusher(ctx, testdata.GenerateLogsOneLogRecord()), context.Canceled)
+}
+
 func TestLogsDataPusher_err(t *testing.T) {
 	c := sarama.NewConfig()
 	producer := mocks.NewSyncProducer(t, c)
@@ -528,3 +748,99 @@ func (e logsErrorMarshaler) Marshal(_ plog.Logs, _ *Config) ([]*sarama.ProducerM
 func (e logsErrorMarshaler) Encoding() string {
 	panic("implement me")
 }
+
+func TestLogsDataPusher_on_unsplittable_deadletter(t *testing.T) {
+	c := sarama.NewConfig()
+	producer := mocks.NewSyncProducer(t, c)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, "logs", msg.Topic)
+		return nil
+	})
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, "dlq", msg.Topic)
+		return nil
+	})
+
+	config := &Config{
+		Topic: "logs",
+		OnUnsplittable: OnUnsplittable{
+			Logs:                      unsplittableDeadLetter,
+			DeadLetterTopic:           "dlq",
+			DeadLetterMaxMessageBytes: 10000,
+		},
+		Producer: Producer{protoVersion: 2, MaxMessageBytes: 1000},
+	}
+	p := kafkaLogsProducer{
+		producer:  producer,
+		marshaler: newPdataLogsMarshaler(&plog.ProtoMarshaler{}, defaultEncoding),
+		config:    config,
+	}
+	t.Cleanup(func() {
+		require.NoError(t, p.Close(context.Background()))
+	})
+
+	// The oversized log record is produced alone to the dead letter topic.
+	ld := testdata.GenerateLogsOneLogRecord()
+	ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().AppendEmpty().Body().SetStr(strings.Repeat("a", 2000))
+	require.NoError(t, p.logsDataPusher(context.Background(), ld))
+}
+
+func TestTracesPusher_no_split(t *testing.T) {
+	c := sarama.NewConfig()
+	producer := mocks.NewSyncProducer(t, c)
+	producer.ExpectSendMessageAndSucceed()
+
+	zcore, logObserver := observer.New(zapcore.WarnLevel)
+	p := kafkaTracesProducer{
+		producer:  producer,
+		marshaler: newPdataTracesMarshaler(&ptrace.ProtoMarshaler{}, defaultEncoding),
+		logger:    zap.New(zcore),
+		config:    &Config{Topic: "spans", NoSplit: true, Producer: Producer{protoVersion: 2, MaxMessageBytes: 1000}},
+	}
+	t.Cleanup(func() {
+		require.NoError(t, p.Close(context.Background()))
+	})
+
+	// The request is bigger than max_message_bytes, but is produced as one message.
+	td := testdata.GenerateTracesManySpansSameResource(100)
+	require.NoError(t, p.tracesPusher(context.Background(), td))
+	warnings := logObserver.FilterMessage("Producing a Kafka message bigger than max_message_bytes").All()
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "spans", warnings[0].ContextMap()["topic"])
+	assert.Greater(t, warnings[0].ContextMap()["size"], int64(1000))
+}
+
+func TestLogsDataPusher_split(t *testing.T) {
+	c := sarama.NewConfig()
+	producer := mocks.NewSyncProducer(t, c)
+	logRecords := 0
+	countLogRecords := func(msg *sarama.ProducerMessage) error {
+		bts, err := msg.Value.Encode()
+		require.NoError(t, err)
+		ld, err := (&plog.ProtoUnmarshaler{}).UnmarshalLogs(bts)
+		require.NoError(t, err)
+		logRecords += ld.LogRecordCount()
+		assert.LessOrEqual(t, msg.ByteSize(2), 1000)
+		return nil
+	}
+	p := kafkaLogsProducer{
+		producer:  producer,
+		marshaler: newPdataLogsMarshaler(&plog.ProtoMarshaler{}, defaultEncoding),
+		logger:    zap.NewNop(),
+		config:    &Config{Topic: "logs", Producer: Producer{protoVersion: 2, MaxMessageBytes: 1000}},
+	}
+	t.Cleanup(func() {
+		require.NoError(t, p.Close(context.Background()))
+	})
+
+	ld := testdata.GenerateLogsManyLogRecordsSameResource(50)
+	messages, err := p.marshaler.Marshal(ld, p.config)
+	require.NoError(t, err)
+	require.Greater(t, len(messages), 1)
+	for range messages {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(countLogRecords)
+	}
+
+	require.NoError(t, p.logsDataPusher(context.Background(), ld))
+	assert.Equal(t, 50, logRecords)
+}
@@ -0,0 +1,74 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// attributeKey returns the message key of the value of the attribute k: the raw bytes of a bytes value,
+// truncated to the max_length of k, or the trimmed string representation of the other values.
+// The keys combining several attributes use trimmedKeyValue, bytes values being base64 encoded there.
+func attributeKey(k string, v pcommon.Value, trimmings []AttributeTrimming) sarama.Encoder {
+	if v.Type() != pcommon.ValueTypeBytes {
+		return sarama.StringEncoder(trimmedKeyValue(k, v, trimmings))
+	}
+	raw := v.Bytes().AsRaw()
+	for _, trimming := range trimmings {
+		if trimming.Attribute == k {
+			if trimming.MaxLength > 0 && len(raw) > trimming.MaxLength {
+				raw = raw[:trimming.MaxLength]
+			}
+			break
+		}
+	}
+	return sarama.ByteEncoder(raw)
+}
+
+// trimmedKeyValue returns the string representation of the value of the attribute k,
+// trimmed by the first of trimmings configured for k.
+func trimmedKeyValue(k string, v pcommon.Value, trimmings []AttributeTrimming) string {
+	for _, trimming := range trimmings {
+		if trimming.Attribute != k {
+			continue
+		}
+		switch v.Type() {
+		case pcommon.ValueTypeStr:
+			if trimming.MaxLength > 0 {
+				return truncateString(v.Str(), trimming.MaxLength)
+			}
+		case pcommon.ValueTypeInt:
+			if trimming.RoundTo > 0 {
+				return strconv.FormatFloat(roundDown(float64(v.Int()), trimming.RoundTo), 'f', -1, 64)
+			}
+		case pcommon.ValueTypeDouble:
+			if trimming.RoundTo > 0 {
+				return strconv.FormatFloat(roundDown(v.Double(), trimming.RoundTo), 'f', -1, 64)
+			}
+		}
+		break
+	}
+	return v.AsString()
+}
+
+// truncateString keeps the first maxLength characters of s.
+func truncateString(s string, maxLength int) string {
+	runes := 0
+	for i := range s {
+		if runes == maxLength {
+			return s[:i]
+		}
+		runes++
+	}
+	return s
+}
+
+// roundDown rounds f down to a multiple of step.
+func roundDown(f float64, step float64) float64 {
+	return math.Floor(f/step) * step
+}
@@ -0,0 +1,63 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+func TestTrimmedKeyValue(t *testing.T) {
+	trimmings := []AttributeTrimming{
+		{Attribute: "str", MaxLength: 3},
+		{Attribute: "num", RoundTo: 0.5},
+		{Attribute: "untrimmed"},
+	}
+	tests := []struct {
+		name     string
+		key      string
+		value    pcommon.Value
+		expected string
+	}{
+		{name: "truncated string", key: "str", value: pcommon.NewValueStr("abcdef"), expected: "abc"},
+		{name: "short string", key: "str", value: pcommon.NewValueStr("ab"), expected: "ab"},
+		{name: "multi-byte string", key: "str", value: pcommon.NewValueStr("日本語です"), expected: "日本語"},
+		{name: "rounded int", key: "num", value: pcommon.NewValueInt(7), expected: "7"},
+		{name: "rounded double", key: "num", value: pcommon.NewValueDouble(1.7), expected: "1.5"},
+		{name: "negative double", key: "num", value: pcommon.NewValueDouble(-0.2), expected: "-0.5"},
+		{name: "string not rounded", key: "num", value: pcommon.NewValueStr("1.7"), expected: "1.7"},
+		{name: "no trimming configured", key: "untrimmed", value: pcommon.NewValueStr("abcdef"), expected: "abcdef"},
+		{name: "other attribute", key: "other", value: pcommon.NewValueStr("abcdef"), expected: "abcdef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, trimmedKeyValue(tt.key, tt.value, trimmings))
+		})
+	}
+}
+
+func TestAttributeKey(t *testing.T) {
+	trimmings := []AttributeTrimming{{Attribute: "id", MaxLength: 2}}
+	bytesValue := pcommon.NewValueBytes()
+	bytesValue.Bytes().FromRaw([]byte{0xde, 0xad, 0xbe, 0xef})
+
+	key, err := attributeKey("raw", bytesValue, trimmings).Encode()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, key, "bytes values are used as raw bytes, not base64")
+
+	key, err = attributeKey("id", bytesValue, trimmings).Encode()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xde, 0xad}, key)
+
+	key, err = attributeKey("id", pcommon.NewValueStr("abcdef"), trimmings).Encode()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ab"), key)
+
+	key, err = attributeKey("raw", pcommon.NewValueBool(true), trimmings).Encode()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("true"), key)
+}
@@ -0,0 +1,37 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	conventions "go.opentelemetry.io/collector/semconv/v1.6.1"
+)
+
+// hostKey identifies the host of resource by its host.name, followed by its host.id if set.
+// It returns nil if resource has neither attribute.
+func hostKey(resource pcommon.Resource, trimmings []AttributeTrimming) sarama.Encoder {
+	name, hasName := resource.Attributes().Get(conventions.AttributeHostName)
+	id, hasID := resource.Attributes().Get(conventions.AttributeHostID)
+	if !hasName && !hasID {
+		return nil
+	}
+	var key string
+	if hasName {
+		key = trimmedKeyValue(conventions.AttributeHostName, name, trimmings)
+	}
+	if hasID {
+		key += "/" + trimmedKeyValue(conventions.AttributeHostID, id, trimmings)
+	}
+	return sarama.StringEncoder(key)
+}
+
+// splitLogsByHost groups the resource logs of ld by host, in the order the hosts first appear.
+// ld is returned as is if all its resource logs come from the same host.
+func splitLogsByHost(ld plog.Logs, trimmings []AttributeTrimming) []keyedLogs {
+	return splitLogsByResource(ld, func(resource pcommon.Resource) sarama.Encoder {
+		return hostKey(resource, trimmings)
+	})
+}
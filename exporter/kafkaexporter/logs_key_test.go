@@ -0,0 +1,88 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func appendHostLog(ld plog.Logs, hostName, hostID, body string) {
+	rl := ld.ResourceLogs().AppendEmpty()
+	if hostName != "" {
+		rl.Resource().Attributes().PutStr("host.name", hostName)
+	}
+	if hostID != "" {
+		rl.Resource().Attributes().PutStr("host.id", hostID)
+	}
+	rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr(body)
+}
+
+func TestHostKey(t *testing.T) {
+	ld := plog.NewLogs()
+	appendHostLog(ld, "web-1", "", "a")
+	appendHostLog(ld, "web-1", "", "b")
+	appendHostLog(ld, "web-2", "", "c")
+	appendHostLog(ld, "web-1", "i-0123", "d")
+	appendHostLog(ld, "", "", "e")
+	resource := func(i int) plog.ResourceLogs { return ld.ResourceLogs().At(i) }
+
+	assert.Equal(t, hostKey(resource(0).Resource(), nil), hostKey(resource(1).Resource(), nil))
+	assert.NotEqual(t, hostKey(resource(0).Resource(), nil), hostKey(resource(2).Resource(), nil))
+	assert.Equal(t, sarama.StringEncoder("web-1/i-0123"), hostKey(resource(3).Resource(), nil))
+	assert.Nil(t, hostKey(resource(4).Resource(), nil))
+
+	trimmings := []AttributeTrimming{{Attribute: "host.name", MaxLength: 3}}
+	assert.Equal(t, hostKey(resource(0).Resource(), trimmings), hostKey(resource(2).Resource(), trimmings))
+}
+
+func TestSplitLogsByHost(t *testing.T) {
+	ld := plog.NewLogs()
+	appendHostLog(ld, "web-1", "", "a")
+	appendHostLog(ld, "web-1", "", "b")
+	hosts := splitLogsByHost(ld, nil)
+	require.Len(t, hosts, 1)
+	assert.Equal(t, ld, hosts[0].logs)
+	assert.Equal(t, sarama.StringEncoder("web-1"), hosts[0].key)
+
+	appendHostLog(ld, "web-2", "", "c")
+	appendHostLog(ld, "web-1", "", "d")
+	hosts = splitLogsByHost(ld, nil)
+	require.Len(t, hosts, 2)
+	assert.Equal(t, sarama.StringEncoder("web-1"), hosts[0].key)
+	assert.Equal(t, 3, hosts[0].logs.LogRecordCount())
+	assert.Equal(t, "d", hosts[0].logs.ResourceLogs().At(2).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+	assert.Equal(t, sarama.StringEncoder("web-2"), hosts[1].key)
+	assert.Equal(t, 1, hosts[1].logs.LogRecordCount())
+}
+
+func TestMarshalLogs_logsKeyHost(t *testing.T) {
+	ld := plog.NewLogs()
+	appendHostLog(ld, "web-1", "", "a")
+	appendHostLog(ld, "web-2", "", "b")
+	appendHostLog(ld, "web-1", "", "c")
+	config := &Config{
+		Topic:   "logs",
+		LogsKey: logsKeyHost,
+		Producer: Producer{
+			MaxMessageBytes: 1000000,
+		},
+	}
+
+	messages, err := newPdataLogsMarshaler(&plog.ProtoMarshaler{}, defaultEncoding).Marshal(ld, config)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, sarama.StringEncoder("web-1"), messages[0].Key)
+	assert.Equal(t, sarama.StringEncoder("web-2"), messages[1].Key)
+
+	config.LogsKey = logsKeyNone
+	messages, err = newPdataLogsMarshaler(&plog.ProtoMarshaler{}, defaultEncoding).Marshal(ld, config)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Nil(t, messages[0].Key)
+}
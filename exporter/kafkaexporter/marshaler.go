@@ -43,11 +43,23 @@ func tracesMarshalers() map[string]TracesMarshaler {
 	otlpJSON := newPdataTracesMarshaler(&ptrace.JSONMarshaler{}, "otlp_json")
 	jaegerProto := jaegerMarshaler{marshaler: jaegerProtoSpanMarshaler{}}
 	jaegerJSON := jaegerMarshaler{marshaler: newJaegerJSONMarshaler()}
+	zipkinProto := newZipkinProtoMarshaler()
+	zipkinJSON := newZipkinJSONMarshaler()
+	envelopePb := envelopeTracesMarshaler{marshaler: &ptrace.ProtoMarshaler{}, encoding: "otlp_proto_envelope"}
+	envelopeJSON := envelopeTracesMarshaler{marshaler: &ptrace.JSONMarshaler{}, encoding: "otlp_json_envelope"}
+	avroTraces := newAvroTracesMarshaler(newSchemaRegistry())
+	cloudEvents := cloudEventsTracesMarshaler{}
 	return map[string]TracesMarshaler{
-		otlpPb.Encoding():      otlpPb,
-		otlpJSON.Encoding():    otlpJSON,
-		jaegerProto.Encoding(): jaegerProto,
-		jaegerJSON.Encoding():  jaegerJSON,
+		otlpPb.Encoding():       otlpPb,
+		otlpJSON.Encoding():     otlpJSON,
+		jaegerProto.Encoding():  jaegerProto,
+		jaegerJSON.Encoding():   jaegerJSON,
+		zipkinProto.Encoding():  zipkinProto,
+		zipkinJSON.Encoding():   zipkinJSON,
+		envelopePb.Encoding():   envelopePb,
+		envelopeJSON.Encoding(): envelopeJSON,
+		avroTraces.Encoding():   avroTraces,
+		cloudEvents.Encoding():  cloudEvents,
 	}
 }
 
@@ -55,9 +67,19 @@ func tracesMarshalers() map[string]TracesMarshaler {
 func metricsMarshalers() map[string]MetricsMarshaler {
 	otlpPb := newPdataMetricsMarshaler(&pmetric.ProtoMarshaler{}, defaultEncoding)
 	otlpJSON := newPdataMetricsMarshaler(&pmetric.JSONMarshaler{}, "otlp_json")
+	envelopePb := envelopeMetricsMarshaler{marshaler: &pmetric.ProtoMarshaler{}, encoding: "otlp_proto_envelope"}
+	envelopeJSON := envelopeMetricsMarshaler{marshaler: &pmetric.JSONMarshaler{}, encoding: "otlp_json_envelope"}
+	datadogJSON := datadogMetricsMarshaler{}
+	avroMetrics := newAvroMetricsMarshaler(newSchemaRegistry())
+	cloudEvents := newCloudEventsMetricsMarshaler()
 	return map[string]MetricsMarshaler{
-		otlpPb.Encoding():   otlpPb,
-		otlpJSON.Encoding(): otlpJSON,
+		otlpPb.Encoding():       otlpPb,
+		otlpJSON.Encoding():     otlpJSON,
+		envelopePb.Encoding():   envelopePb,
+		envelopeJSON.Encoding(): envelopeJSON,
+		datadogJSON.Encoding():  datadogJSON,
+		avroMetrics.Encoding():  avroMetrics,
+		cloudEvents.Encoding():  cloudEvents,
 	}
 }
 
@@ -66,9 +88,17 @@ func logsMarshalers() map[string]LogsMarshaler {
 	otlpPb := newPdataLogsMarshaler(&plog.ProtoMarshaler{}, defaultEncoding)
 	otlpJSON := newPdataLogsMarshaler(&plog.JSONMarshaler{}, "otlp_json")
 	raw := newRawMarshaler()
+	envelopePb := envelopeLogsMarshaler{marshaler: &plog.ProtoMarshaler{}, encoding: "otlp_proto_envelope"}
+	envelopeJSON := envelopeLogsMarshaler{marshaler: &plog.JSONMarshaler{}, encoding: "otlp_json_envelope"}
+	avroLogs := newAvroLogsMarshaler(newSchemaRegistry())
+	cloudEvents := cloudEventsLogsMarshaler{}
 	return map[string]LogsMarshaler{
-		otlpPb.Encoding():   otlpPb,
-		otlpJSON.Encoding(): otlpJSON,
-		raw.Encoding():      raw,
+		otlpPb.Encoding():       otlpPb,
+		otlpJSON.Encoding():     otlpJSON,
+		raw.Encoding():          raw,
+		envelopePb.Encoding():   envelopePb,
+		envelopeJSON.Encoding(): envelopeJSON,
+		avroLogs.Encoding():     avroLogs,
+		cloudEvents.Encoding():  cloudEvents,
 	}
 }
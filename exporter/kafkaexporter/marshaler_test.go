@@ -22,6 +22,12 @@ func TestDefaultTracesMarshalers(t *testing.T) {
 		"otlp_json",
 		"jaeger_proto",
 		"jaeger_json",
+		"zipkin_proto",
+		"zipkin_json",
+		"otlp_proto_envelope",
+		"otlp_json_envelope",
+		"avro_traces",
+		"cloudEvents_traces",
 	}
 	marshalers := tracesMarshalers()
 	assert.Equal(t, len(expectedEncodings), len(marshalers))
@@ -38,6 +44,11 @@ func TestDefaultMetricsMarshalers(t *testing.T) {
 	expectedEncodings := []string{
 		"otlp_proto",
 		"otlp_json",
+		"otlp_proto_envelope",
+		"otlp_json_envelope",
+		"datadog_json",
+		"avro_metrics",
+		"cloudEvents_metrics",
 	}
 	marshalers := metricsMarshalers()
 	assert.Equal(t, len(expectedEncodings), len(marshalers))
@@ -55,6 +66,10 @@ func TestDefaultLogsMarshalers(t *testing.T) {
 		"otlp_proto",
 		"otlp_json",
 		"raw",
+		"otlp_proto_envelope",
+		"otlp_json_envelope",
+		"avro_logs",
+		"cloudEvents_logs",
 	}
 	marshalers := logsMarshalers()
 	assert.Equal(t, len(expectedEncodings), len(marshalers))
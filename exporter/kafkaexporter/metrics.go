@@ -0,0 +1,72 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/kafkaauth"
+)
+
+var (
+	tagInstanceName, _ = tag.NewKey("name")
+
+	statStaleRecordsDropped      = stats.Int64("kafka_exporter_stale_records_dropped", "Number of spans, data points and log records dropped because they are older than max_record_age", stats.UnitDimensionless)
+	statEmptyTopicRecordsDropped = stats.Int64("kafka_exporter_empty_topic_records_dropped", "Number of spans, data points and log records dropped because their topic is empty", stats.UnitDimensionless)
+	statMarshalDuration          = stats.Int64("kafka_exporter_marshal_duration", "Time spent marshaling the data of a push", stats.UnitMilliseconds)
+	statProduceDuration          = stats.Int64("kafka_exporter_produce_duration", "Time spent sending the messages of a push to the brokers", stats.UnitMilliseconds)
+	statAsyncProduceFailed       = stats.Int64("kafka_exporter_async_produce_failed", "Number of messages the async producer failed to deliver", stats.UnitDimensionless)
+)
+
+// durationBounds are the bucket bounds, in milliseconds, of the duration distributions.
+var durationBounds = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// MetricViews return metric views for the authentication of the Kafka clients,
+// they are shared by the Kafka exporter and receiver.
+func MetricViews() []*view.View {
+	return kafkaauth.MetricViews()
+}
+
+// exporterMetricViews return the metric views of the Kafka exporter only.
+func exporterMetricViews() []*view.View {
+	return []*view.View{
+		{
+			Name:        statStaleRecordsDropped.Name(),
+			Measure:     statStaleRecordsDropped,
+			Description: statStaleRecordsDropped.Description(),
+			TagKeys:     []tag.Key{tagInstanceName},
+			Aggregation: view.Sum(),
+		},
+		{
+			Name:        statEmptyTopicRecordsDropped.Name(),
+			Measure:     statEmptyTopicRecordsDropped,
+			Description: statEmptyTopicRecordsDropped.Description(),
+			TagKeys:     []tag.Key{tagInstanceName},
+			Aggregation: view.Sum(),
+		},
+		{
+			Name:        statMarshalDuration.Name(),
+			Measure:     statMarshalDuration,
+			Description: statMarshalDuration.Description(),
+			TagKeys:     []tag.Key{tagInstanceName},
+			Aggregation: view.Distribution(durationBounds...),
+		},
+		{
+			Name:        statProduceDuration.Name(),
+			Measure:     statProduceDuration,
+			Description: statProduceDuration.Description(),
+			TagKeys:     []tag.Key{tagInstanceName},
+			Aggregation: view.Distribution(durationBounds...),
+		},
+		{
+			Name:        statAsyncProduceFailed.Name(),
+			Measure:     statAsyncProduceFailed,
+			Description: statAsyncProduceFailed.Description(),
+			TagKeys:     []tag.Key{tagInstanceName},
+			Aggregation: view.Sum(),
+		},
+	}
+}
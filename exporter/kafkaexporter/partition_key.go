@@ -0,0 +1,157 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"strings"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	partitionKeyNone                    = "none"
+	partitionKeyTraceID                 = "trace_id"
+	partitionKeyResourceAttributePrefix = "resource_attribute:"
+)
+
+// partitionKeyAttribute returns the resource attribute named by a resource_attribute partition key,
+// or "" for the other partition keys.
+func partitionKeyAttribute(partitionKey string) string {
+	if !strings.HasPrefix(partitionKey, partitionKeyResourceAttributePrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(partitionKey, partitionKeyResourceAttributePrefix)
+}
+
+// resourcePartitionKey returns the key of the messages of a resource if partition_key is a resource
+// attribute, or nil otherwise.
+func resourcePartitionKey(config *Config) func(pcommon.Resource) sarama.Encoder {
+	attribute := partitionKeyAttribute(config.PartitionKey)
+	if attribute == "" {
+		return nil
+	}
+	return func(resource pcommon.Resource) sarama.Encoder {
+		return resourceAttributeKey(resource, attribute, config.KeyAttributeTrimming)
+	}
+}
+
+// resourceAttributeKey returns the value of the attribute of resource, trimmed, or nil if resource does not
+// have the attribute.
+func resourceAttributeKey(resource pcommon.Resource, attribute string, trimmings []AttributeTrimming) sarama.Encoder {
+	value, ok := resource.Attributes().Get(attribute)
+	if !ok {
+		return nil
+	}
+	return attributeKey(attribute, value, trimmings)
+}
+
+// keyedTraces are the spans of a request sharing the same message key.
+type keyedTraces struct {
+	key    sarama.Encoder
+	traces ptrace.Traces
+}
+
+// keyedMetrics are the data points of a request sharing the same message key.
+type keyedMetrics struct {
+	key     sarama.Encoder
+	metrics pmetric.Metrics
+}
+
+// keyedLogs are the log records of a request sharing the same message key.
+type keyedLogs struct {
+	key  sarama.Encoder
+	logs plog.Logs
+}
+
+// splitTracesByResource groups the resource spans of td by the key of their resource, in the order the keys
+// first appear. td is returned as is if all its resources have the same key.
+func splitTracesByResource(td ptrace.Traces, key func(pcommon.Resource) sarama.Encoder) []keyedTraces {
+	rss := td.ResourceSpans()
+	keys, indexes := resourceKeys(rss.Len(), func(i int) sarama.Encoder { return key(rss.At(i).Resource()) })
+	if len(keys) <= 1 {
+		return []keyedTraces{{key: firstKey(keys), traces: td}}
+	}
+	groups := make([]keyedTraces, len(keys))
+	for i := range groups {
+		groups[i] = keyedTraces{key: keys[i], traces: ptrace.NewTraces()}
+	}
+	for i := 0; i < rss.Len(); i++ {
+		rss.At(i).CopyTo(groups[indexes[i]].traces.ResourceSpans().AppendEmpty())
+	}
+	return groups
+}
+
+// splitMetricsByResource is the splitTracesByResource of metrics.
+func splitMetricsByResource(md pmetric.Metrics, key func(pcommon.Resource) sarama.Encoder) []keyedMetrics {
+	rms := md.ResourceMetrics()
+	keys, indexes := resourceKeys(rms.Len(), func(i int) sarama.Encoder { return key(rms.At(i).Resource()) })
+	if len(keys) <= 1 {
+		return []keyedMetrics{{key: firstKey(keys), metrics: md}}
+	}
+	groups := make([]keyedMetrics, len(keys))
+	for i := range groups {
+		groups[i] = keyedMetrics{key: keys[i], metrics: pmetric.NewMetrics()}
+	}
+	for i := 0; i < rms.Len(); i++ {
+		rms.At(i).CopyTo(groups[indexes[i]].metrics.ResourceMetrics().AppendEmpty())
+	}
+	return groups
+}
+
+// splitLogsByResource is the splitTracesByResource of logs.
+func splitLogsByResource(ld plog.Logs, key func(pcommon.Resource) sarama.Encoder) []keyedLogs {
+	rls := ld.ResourceLogs()
+	keys, indexes := resourceKeys(rls.Len(), func(i int) sarama.Encoder { return key(rls.At(i).Resource()) })
+	if len(keys) <= 1 {
+		return []keyedLogs{{key: firstKey(keys), logs: ld}}
+	}
+	groups := make([]keyedLogs, len(keys))
+	for i := range groups {
+		groups[i] = keyedLogs{key: keys[i], logs: plog.NewLogs()}
+	}
+	for i := 0; i < rls.Len(); i++ {
+		rls.At(i).CopyTo(groups[indexes[i]].logs.ResourceLogs().AppendEmpty())
+	}
+	return groups
+}
+
+// resourceKeys returns the distinct keys of n resources in the order they first appear, and the index
+// of the key of every resource. The keys are compared by their encoding, nil being distinct from the empty key.
+func resourceKeys(n int, key func(i int) sarama.Encoder) ([]sarama.Encoder, []int) {
+	var keys []sarama.Encoder
+	indexes := make([]int, n)
+	positions := map[string]int{}
+	nilPosition := -1
+	for i := 0; i < n; i++ {
+		k := key(i)
+		if k == nil {
+			if nilPosition < 0 {
+				nilPosition = len(keys)
+				keys = append(keys, nil)
+			}
+			indexes[i] = nilPosition
+			continue
+		}
+		encoded, _ := k.Encode()
+		position, ok := positions[string(encoded)]
+		if !ok {
+			position = len(keys)
+			positions[string(encoded)] = position
+			keys = append(keys, k)
+		}
+		indexes[i] = position
+	}
+	return keys, indexes
+}
+
+func firstKey(keys []sarama.Encoder) sarama.Encoder {
+	if len(keys) == 0 {
+		return nil
+	}
+	return keys[0]
+}
@@ -0,0 +1,154 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// partitionKeyTenants are the tenant resource attributes of the test data, "" for no attribute.
+var partitionKeyTenants = []string{"acme", "globex", "acme", ""}
+
+func partitionKeyTraces() ptrace.Traces {
+	td := ptrace.NewTraces()
+	for i, tenant := range partitionKeyTenants {
+		rs := td.ResourceSpans().AppendEmpty()
+		if tenant != "" {
+			rs.Resource().Attributes().PutStr("tenant", tenant)
+		}
+		span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetTraceID([16]byte{byte(i%2 + 1)})
+	}
+	return td
+}
+
+func partitionKeyMetrics() pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	for _, tenant := range partitionKeyTenants {
+		rm := md.ResourceMetrics().AppendEmpty()
+		if tenant != "" {
+			rm.Resource().Attributes().PutStr("tenant", tenant)
+		}
+		rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1)
+	}
+	return md
+}
+
+func partitionKeyLogs() plog.Logs {
+	ld := plog.NewLogs()
+	for _, tenant := range partitionKeyTenants {
+		rl := ld.ResourceLogs().AppendEmpty()
+		if tenant != "" {
+			rl.Resource().Attributes().PutStr("tenant", tenant)
+		}
+		rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr(tenant)
+	}
+	return ld
+}
+
+// messageKeys returns the encoded keys of messages, nil for the messages without key.
+func messageKeys(t *testing.T, messages []*sarama.ProducerMessage) [][]byte {
+	keys := make([][]byte, len(messages))
+	for i, message := range messages {
+		if message.Key == nil {
+			continue
+		}
+		key, err := message.Key.Encode()
+		require.NoError(t, err)
+		keys[i] = key
+	}
+	return keys
+}
+
+func TestPartitionKey(t *testing.T) {
+	tests := []struct {
+		partitionKey string
+		traces       [][]byte
+		metrics      [][]byte
+		logs         [][]byte
+	}{
+		{
+			partitionKey: "none",
+			traces:       [][]byte{nil},
+			metrics:      [][]byte{nil},
+			logs:         [][]byte{nil},
+		},
+		{
+			partitionKey: "trace_id",
+			traces: [][]byte{
+				[]byte("01000000000000000000000000000000"),
+				[]byte("02000000000000000000000000000000"),
+			},
+			metrics: [][]byte{nil},
+			logs:    [][]byte{nil},
+		},
+		{
+			partitionKey: "resource_attribute:tenant",
+			traces:       [][]byte{[]byte("acme"), []byte("globex"), nil},
+			metrics:      [][]byte{[]byte("acme"), []byte("globex"), nil},
+			logs:         [][]byte{[]byte("acme"), []byte("globex"), nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.partitionKey, func(t *testing.T) {
+			config := &Config{Topic: "topic", PartitionKey: tt.partitionKey, Producer: Producer{MaxMessageBytes: 1000 * 1000}}
+			for _, encoding := range []string{"otlp_proto", "otlp_json"} {
+				messages, err := tracesMarshalers()[encoding].Marshal(partitionKeyTraces(), config)
+				require.NoError(t, err)
+				assert.Equal(t, tt.traces, messageKeys(t, messages))
+
+				messages, err = metricsMarshalers()[encoding].Marshal(partitionKeyMetrics(), config)
+				require.NoError(t, err)
+				assert.Equal(t, tt.metrics, messageKeys(t, messages))
+
+				messages, err = logsMarshalers()[encoding].Marshal(partitionKeyLogs(), config)
+				require.NoError(t, err)
+				assert.Equal(t, tt.logs, messageKeys(t, messages))
+			}
+		})
+	}
+}
+
+func TestSplitLogsByResource(t *testing.T) {
+	key := resourcePartitionKey(&Config{PartitionKey: "resource_attribute:tenant"})
+	require.NotNil(t, key)
+	assert.Nil(t, resourcePartitionKey(&Config{PartitionKey: "trace_id"}))
+
+	groups := splitLogsByResource(partitionKeyLogs(), key)
+	require.Len(t, groups, 3)
+	assert.Equal(t, sarama.StringEncoder("acme"), groups[0].key)
+	assert.Equal(t, 2, groups[0].logs.LogRecordCount())
+	assert.Equal(t, sarama.StringEncoder("globex"), groups[1].key)
+	assert.Nil(t, groups[2].key)
+
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().Resource().Attributes().PutStr("tenant", "acme")
+	groups = splitLogsByResource(ld, key)
+	require.Len(t, groups, 1)
+	assert.Equal(t, ld, groups[0].logs)
+	assert.Equal(t, sarama.StringEncoder("acme"), groups[0].key)
+}
+
+func TestSplitLogsByResource_bytesAttribute(t *testing.T) {
+	key := resourcePartitionKey(&Config{PartitionKey: "resource_attribute:tenant"})
+	ld := plog.NewLogs()
+	for _, tenant := range [][]byte{{0xff, 0x00}, {0x01}, {0xff, 0x00}} {
+		ld.ResourceLogs().AppendEmpty().Resource().Attributes().PutEmptyBytes("tenant").FromRaw(tenant)
+	}
+	ld.ResourceLogs().AppendEmpty().Resource().Attributes().PutInt("tenant", 42)
+
+	groups := splitLogsByResource(ld, key)
+	require.Len(t, groups, 3)
+	assert.Equal(t, sarama.ByteEncoder{0xff, 0x00}, groups[0].key)
+	assert.Equal(t, 2, groups[0].logs.ResourceLogs().Len())
+	assert.Equal(t, sarama.ByteEncoder{0x01}, groups[1].key)
+	assert.Equal(t, sarama.StringEncoder("42"), groups[2].key)
+}
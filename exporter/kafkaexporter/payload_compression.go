@@ -0,0 +1,43 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"github.com/IBM/sarama"
+	snappy "github.com/eapache/go-xerial-snappy"
+)
+
+const (
+	payloadCompressionNone         = "none"
+	payloadCompressionXerialSnappy = "xerial_snappy"
+
+	// contentEncodingHeader tells the consumers how the message value is compressed.
+	contentEncodingHeader = "content-encoding"
+	// xerialSnappyContentEncoding is the content-encoding of the values compressed with the xerial snappy framing.
+	xerialSnappyContentEncoding = "x-snappy-framed"
+)
+
+// compressPayloads compresses the values of messages at the application layer, on top of the compression
+// of the record batches by the producer, and sets their content-encoding header.
+func compressPayloads(compression string, messages []*sarama.ProducerMessage) error {
+	if compression != payloadCompressionXerialSnappy {
+		return nil
+	}
+	for _, message := range messages {
+		if message.Value == nil {
+			continue
+		}
+		value, err := message.Value.Encode()
+		if err != nil {
+			return err
+		}
+		message.Value = sarama.ByteEncoder(snappy.EncodeStream(nil, value))
+		// The content-encoding comes first, it is the last header to be trimmed.
+		message.Headers = append([]sarama.RecordHeader{{
+			Key:   []byte(contentEncodingHeader),
+			Value: []byte(xerialSnappyContentEncoding),
+		}}, message.Headers...)
+	}
+	return nil
+}
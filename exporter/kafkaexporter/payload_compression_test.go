@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	snappy "github.com/eapache/go-xerial-snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
+)
+
+// xerialHeader is the magic header starting the xerial snappy framing.
+var xerialHeader = []byte{130, 83, 78, 65, 80, 80, 89, 0}
+
+func TestCompressPayloads(t *testing.T) {
+	value := bytes.Repeat([]byte("telemetry"), 100)
+	messages := []*sarama.ProducerMessage{
+		{Topic: "topic", Value: sarama.ByteEncoder(value)},
+		{Topic: "topic"},
+	}
+	require.NoError(t, compressPayloads(payloadCompressionXerialSnappy, messages))
+
+	compressed, err := messages[0].Value.Encode()
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(compressed, xerialHeader))
+	assert.Less(t, len(compressed), len(value))
+	decompressed, err := snappy.Decode(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, value, decompressed)
+	assert.Equal(t, []sarama.RecordHeader{{Key: []byte("content-encoding"), Value: []byte("x-snappy-framed")}}, messages[0].Headers)
+
+	assert.Nil(t, messages[1].Value)
+	assert.Empty(t, messages[1].Headers)
+}
+
+func TestCompressPayloads_none(t *testing.T) {
+	messages := []*sarama.ProducerMessage{{Topic: "topic", Value: sarama.StringEncoder("value")}}
+	require.NoError(t, compressPayloads(payloadCompressionNone, messages))
+	assert.Equal(t, sarama.StringEncoder("value"), messages[0].Value)
+	assert.Empty(t, messages[0].Headers)
+}
+
+func TestLogsDataPusher_xerial_snappy(t *testing.T) {
+	c := sarama.NewConfig()
+	producer := mocks.NewSyncProducer(t, c)
+	ld := testdata.GenerateLogsOneLogRecord()
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		compressed, err := msg.Value.Encode()
+		require.NoError(t, err)
+		decompressed, err := snappy.Decode(compressed)
+		require.NoError(t, err)
+		got, err := (&plog.ProtoUnmarshaler{}).UnmarshalLogs(decompressed)
+		require.NoError(t, err)
+		assert.Equal(t, ld, got)
+		return nil
+	})
+
+	p := kafkaLogsProducer{
+		producer:  producer,
+		marshaler: newPdataLogsMarshaler(&plog.ProtoMarshaler{}, defaultEncoding),
+		config: &Config{Producer: Producer{
+			protoVersion:       2,
+			MaxMessageBytes:    1000 * 1000,
+			PayloadCompression: payloadCompressionXerialSnappy,
+		}},
+	}
+	t.Cleanup(func() {
+		require.NoError(t, p.Close(context.Background()))
+	})
+	require.NoError(t, p.logsDataPusher(context.Background(), ld))
+}
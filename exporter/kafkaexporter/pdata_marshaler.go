@@ -4,8 +4,12 @@
 package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/IBM/sarama"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/splitObjs"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
@@ -17,16 +21,93 @@ type pdataLogsMarshaler struct {
 }
 
 func (p pdataLogsMarshaler) Marshal(ld plog.Logs, config *Config) ([]*sarama.ProducerMessage, error) {
+	var groups []keyedLogs
+	if config.LogsKey == logsKeyHost {
+		groups = splitLogsByHost(ld, config.KeyAttributeTrimming)
+	} else if key := resourcePartitionKey(config); key != nil {
+		groups = splitLogsByResource(ld, key)
+	} else {
+		return p.marshalWithKey(ld, config, nil)
+	}
+	var messages []*sarama.ProducerMessage
+	for _, group := range groups {
+		groupMessages, err := p.marshalWithKey(group.logs, config, group.key)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, groupMessages...)
+	}
+	return messages, nil
+}
+
+// marshalWithKey marshals ld into messages with the given key, split to fit max_message_bytes.
+func (p pdataLogsMarshaler) marshalWithKey(ld plog.Logs, config *Config, key sarama.Encoder) ([]*sarama.ProducerMessage, error) {
 	bts, err := p.marshaler.MarshalLogs(ld)
 	if err != nil {
 		return nil, err
 	}
-	return []*sarama.ProducerMessage{
-		{
+	maxBytesSizeWithoutCommonData := maxDataBytes(config)
+	if maxBytesSizeWithoutCommonData <= 0 || len(bts) <= maxBytesSizeWithoutCommonData {
+		message := &sarama.ProducerMessage{
 			Topic: config.Topic,
+			Key:   key,
 			Value: sarama.ByteEncoder(bts),
-		},
-	}, nil
+		}
+		message.Headers = logsHeaders(config, ld)
+		return []*sarama.ProducerMessage{message}, nil
+	}
+
+	// The log records are moved out of their source while it is cut, so work on a copy.
+	src := plog.NewLogs()
+	ld.CopyTo(src)
+	deadLetters, err := p.handleUnsplittable(src, maxBytesSizeWithoutCommonData, config.OnUnsplittable.Logs)
+	if err != nil {
+		return nil, err
+	}
+	// The rest is cut by log record, and fails if a single log record is still too big for a message.
+	logsSlice, err := p.cutLogs(src, maxBytesSizeWithoutCommonData)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*sarama.ProducerMessage, 0, len(logsSlice)+len(deadLetters))
+	for _, logs := range logsSlice {
+		if messages, err = p.appendMessage(messages, config, config.Topic, key, logs); err != nil {
+			return nil, err
+		}
+	}
+	for _, logs := range deadLetters {
+		if messages, err = p.appendMessage(messages, config, config.OnUnsplittable.DeadLetterTopic, key, logs); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+func (p pdataLogsMarshaler) appendMessage(messages []*sarama.ProducerMessage, config *Config, topic string, key sarama.Encoder, ld plog.Logs) ([]*sarama.ProducerMessage, error) {
+	bts, err := p.marshaler.MarshalLogs(ld)
+	if err != nil {
+		return nil, err
+	}
+	message := &sarama.ProducerMessage{
+		Topic: topic,
+		Key:   key,
+		Value: sarama.ByteEncoder(bts),
+	}
+	message.Headers = logsHeaders(config, ld)
+	return append(messages, message), nil
+}
+
+// logsHeaders returns the trace context and syslog severity headers of a message holding ld.
+func logsHeaders(config *Config, ld plog.Logs) []sarama.RecordHeader {
+	var headers []sarama.RecordHeader
+	if config.TraceContextHeaders {
+		headers = logsTraceContextHeaders(ld)
+	}
+	if config.SyslogSeverity.Enabled {
+		headers = append(headers, logsSyslogSeverityHeaders(ld, config.SyslogSeverity.Mapping)...)
+	}
+	return headers
 }
 
 func (p pdataLogsMarshaler) Encoding() string {
@@ -34,6 +115,10 @@ func (p pdataLogsMarshaler) Encoding() string {
 }
 
 func (p pdataLogsMarshaler) cutLogs(ld plog.Logs, maxBytesSizeWithoutCommonData int) ([]plog.Logs, error) {
+	// nothing left to cut, for example when on_unsplittable dropped every log record
+	if ld.ResourceLogs().Len() == 0 {
+		return nil, nil
+	}
 	if maxBytesSizeWithoutCommonData <= 0 {
 		return []plog.Logs{ld}, nil
 	}
@@ -50,7 +135,6 @@ func (p pdataLogsMarshaler) cutLogs(ld plog.Logs, maxBytesSizeWithoutCommonData
 
 	// 2. cut ld  90*3/100
 	// 2.1 cutSize = (max_bytes_size / total_ld_size) * totalLogRecordsNum = (max_bytes_size * totalLogRecordsNum) / total_ld_size
-	//cutSize := int(float64(maxProducerMsgBytesSize) / float64(len(bytes)) * float64(logRecordsNum(ld)))
 	cutSize := (maxBytesSizeWithoutCommonData * logRecordsNum(ld)) / len(bytes)
 	if cutSize == 0 {
 		if len(bytes) > maxBytesSizeWithoutCommonData {
@@ -130,22 +214,120 @@ type pdataMetricsMarshaler struct {
 }
 
 func (p pdataMetricsMarshaler) Marshal(ld pmetric.Metrics, config *Config) ([]*sarama.ProducerMessage, error) {
+	ld = explicitHistograms(ld, config.ExponentialHistograms)
+	if config.MetricsGranularity == metricsGranularityPerDataPoint {
+		return p.marshalPerDataPoint(ld, config)
+	}
+	key := resourcePartitionKey(config)
+	if key == nil {
+		return p.marshalWithKey(ld, config, nil)
+	}
+	var messages []*sarama.ProducerMessage
+	for _, group := range splitMetricsByResource(ld, key) {
+		groupMessages, err := p.marshalWithKey(group.metrics, config, group.key)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, groupMessages...)
+	}
+	return messages, nil
+}
+
+// marshalWithKey marshals ld into messages with the given key, split to fit max_message_bytes.
+func (p pdataMetricsMarshaler) marshalWithKey(ld pmetric.Metrics, config *Config, key sarama.Encoder) ([]*sarama.ProducerMessage, error) {
 	bts, err := p.marshaler.MarshalMetrics(ld)
 	if err != nil {
 		return nil, err
 	}
-	return []*sarama.ProducerMessage{
-		{
-			Topic: config.Topic,
-			Value: sarama.ByteEncoder(bts),
-		},
-	}, nil
+	maxBytesSizeWithoutCommonData := maxDataBytes(config)
+	if maxBytesSizeWithoutCommonData <= 0 || len(bts) <= maxBytesSizeWithoutCommonData {
+		return []*sarama.ProducerMessage{
+			{
+				Topic: config.Topic,
+				Key:   key,
+				Value: sarama.ByteEncoder(bts),
+			},
+		}, nil
+	}
+
+	// The metrics are moved out of their source while it is cut, so work on a copy.
+	src := pmetric.NewMetrics()
+	ld.CopyTo(src)
+	deadLetters, err := p.handleUnsplittable(src, maxBytesSizeWithoutCommonData, config.OnUnsplittable.Metrics)
+	if err != nil {
+		return nil, err
+	}
+	// The rest is cut by metric, and the metrics too big for a message on their own by data point.
+	var metricsSlice []pmetric.Metrics
+	if src.ResourceMetrics().Len() > 0 {
+		if metricsSlice, err = p.cutMetrics(src, maxBytesSizeWithoutCommonData); err != nil {
+			return nil, err
+		}
+	}
+
+	messages := make([]*sarama.ProducerMessage, 0, len(metricsSlice)+len(deadLetters))
+	for _, metrics := range metricsSlice {
+		if messages, err = p.appendMessage(messages, config.Topic, metrics, key); err != nil {
+			return nil, err
+		}
+	}
+	for _, metrics := range deadLetters {
+		if messages, err = p.appendMessage(messages, config.OnUnsplittable.DeadLetterTopic, metrics, key); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+func (p pdataMetricsMarshaler) appendMessage(messages []*sarama.ProducerMessage, topic string, md pmetric.Metrics, key sarama.Encoder) ([]*sarama.ProducerMessage, error) {
+	bts, err := p.marshaler.MarshalMetrics(md)
+	if err != nil {
+		return nil, err
+	}
+	return append(messages, &sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.ByteEncoder(bts),
+		Key:   key,
+	}), nil
 }
 
 func (p pdataMetricsMarshaler) Encoding() string {
 	return p.encoding
 }
 
+// marshalPerDataPoint produces one message for every data point of md, keyed by the series of the data point.
+func (p pdataMetricsMarshaler) marshalPerDataPoint(md pmetric.Metrics, config *Config) ([]*sarama.ProducerMessage, error) {
+	// on_unsplittable removes the data points that do not fit from its source, so work on a copy.
+	src := pmetric.NewMetrics()
+	md.CopyTo(src)
+
+	var deadLetters []pmetric.Metrics
+	maxBytesSizeWithoutCommonData := maxDataBytes(config)
+	if maxBytesSizeWithoutCommonData > 0 && metricsBytes(src, p) > maxBytesSizeWithoutCommonData {
+		var err error
+		if deadLetters, err = p.handleUnsplittable(src, maxBytesSizeWithoutCommonData, config.OnUnsplittable.Metrics); err != nil {
+			return nil, err
+		}
+	}
+
+	messages := make([]*sarama.ProducerMessage, 0, src.DataPointCount()+len(deadLetters))
+	for _, dp := range dataPointMetrics(src) {
+		var err error
+		key := sarama.StringEncoder(dataPointSeriesKey(dp, config.KeyAttributeTrimming))
+		if messages, err = p.appendMessage(messages, config.Topic, dp, key); err != nil {
+			return nil, err
+		}
+	}
+	for _, dp := range deadLetters {
+		var err error
+		key := sarama.StringEncoder(dataPointSeriesKey(dp, config.KeyAttributeTrimming))
+		if messages, err = p.appendMessage(messages, config.OnUnsplittable.DeadLetterTopic, dp, key); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
 func (p pdataMetricsMarshaler) cutMetrics(md pmetric.Metrics, maxBytesSizeWithoutCommonData int) ([]pmetric.Metrics, error) {
 	if maxBytesSizeWithoutCommonData <= 0 {
 		return []pmetric.Metrics{md}, nil
@@ -163,13 +345,9 @@ func (p pdataMetricsMarshaler) cutMetrics(md pmetric.Metrics, maxBytesSizeWithou
 
 	// 2. cut md  90*3/100
 	// 2.1 cutSize = (max_bytes_size / total_md_size) * totalMetricNum = (max_bytes_size * totalMetricNum) / total_md_size
-	//cutSize := int(float64(maxProducerMsgBytesSize) / float64(len(bytes)) * float64(metricsNum(md)))
 	cutSize := (maxBytesSizeWithoutCommonData * metricsNum(md)) / len(bytes)
 	if cutSize == 0 {
-		if len(bytes) > maxBytesSizeWithoutCommonData {
-			return nil, errSingleKafkaProducerMessageSizeOverMaxMsgByte
-		}
-		return []pmetric.Metrics{md}, nil
+		return p.cutDataPoints(md, maxBytesSizeWithoutCommonData)
 	}
 
 	// 2.2 cut metrics to metricsSlice
@@ -184,11 +362,13 @@ func (p pdataMetricsMarshaler) cutMetricsByMaxByte(splitSize int, md pmetric.Met
 	split := splitObjs.SplitMetrics(splitSize, md)
 
 	if metricsBytes(split, p) > maxByte {
-		// check metricsNum == 1
+		var left []pmetric.Metrics
+		// a single metric is cut by data point
 		if metricsNum(split) == 1 {
-			return nil, errSingleKafkaProducerMessageSizeOverMaxMsgByte
+			left, err = p.cutDataPoints(split, maxByte)
+		} else {
+			left, err = p.cutMetricsByMaxByte(splitSize/2, split, maxByte)
 		}
-		left, err := p.cutMetricsByMaxByte(splitSize/2, split, maxByte)
 		if err != nil {
 			return nil, err
 		}
@@ -198,10 +378,58 @@ func (p pdataMetricsMarshaler) cutMetricsByMaxByte(splitSize int, md pmetric.Met
 	}
 
 	if metricsBytes(md, p) > maxByte {
+		var right []pmetric.Metrics
 		if metricsNum(md) == 1 {
+			right, err = p.cutDataPoints(md, maxByte)
+		} else {
+			right, err = p.cutMetricsByMaxByte(splitSize, md, maxByte)
+		}
+		if err != nil {
+			return nil, err
+		}
+		dest = append(dest, right...)
+	} else {
+		dest = append(dest, md)
+	}
+	return dest, nil
+
+}
+
+// cutDataPoints cuts md by data point, for the metrics too big to fit in a message on their own, such as
+// histograms with thousands of data points.
+func (p pdataMetricsMarshaler) cutDataPoints(md pmetric.Metrics, maxByte int) ([]pmetric.Metrics, error) {
+	cutSize := (maxByte * md.DataPointCount()) / metricsBytes(md, p)
+	if cutSize == 0 {
+		return nil, errSingleKafkaProducerMessageSizeOverMaxMsgByte
+	}
+	return p.cutDataPointsByMaxByte(cutSize, md, maxByte)
+}
+
+func (p pdataMetricsMarshaler) cutDataPointsByMaxByte(splitSize int, md pmetric.Metrics, maxByte int) (dest []pmetric.Metrics, err error) {
+	if md.DataPointCount() <= splitSize {
+		return p.cutDataPointsByMaxByte(splitSize/2, md, maxByte)
+	}
+
+	split := splitObjs.SplitMetrics(splitSize, md)
+
+	if metricsBytes(split, p) > maxByte {
+		if split.DataPointCount() == 1 {
 			return nil, errSingleKafkaProducerMessageSizeOverMaxMsgByte
 		}
-		right, err := p.cutMetricsByMaxByte(splitSize, md, maxByte)
+		left, err := p.cutDataPointsByMaxByte(splitSize/2, split, maxByte)
+		if err != nil {
+			return nil, err
+		}
+		dest = append(dest, left...)
+	} else {
+		dest = append(dest, split)
+	}
+
+	if metricsBytes(md, p) > maxByte {
+		if md.DataPointCount() == 1 {
+			return nil, errSingleKafkaProducerMessageSizeOverMaxMsgByte
+		}
+		right, err := p.cutDataPointsByMaxByte(splitSize, md, maxByte)
 		if err != nil {
 			return nil, err
 		}
@@ -210,7 +438,157 @@ func (p pdataMetricsMarshaler) cutMetricsByMaxByte(splitSize int, md pmetric.Met
 		dest = append(dest, md)
 	}
 	return dest, nil
+}
+
+// dataPointMetrics returns a metrics for every data point of md, holding the data point with its resource, scope
+// and metric.
+func dataPointMetrics(md pmetric.Metrics) []pmetric.Metrics {
+	dest := make([]pmetric.Metrics, 0, md.DataPointCount())
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			sm := rm.ScopeMetrics().At(j)
+			for k := 0; k < sm.Metrics().Len(); k++ {
+				m := sm.Metrics().At(k)
+				switch m.Type() {
+				case pmetric.MetricTypeGauge:
+					dps := m.Gauge().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						dpMetrics, dpMetric := newDataPointMetrics(rm, sm, m)
+						dps.At(l).CopyTo(dpMetric.SetEmptyGauge().DataPoints().AppendEmpty())
+						dest = append(dest, dpMetrics)
+					}
+				case pmetric.MetricTypeSum:
+					dps := m.Sum().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						dpMetrics, dpMetric := newDataPointMetrics(rm, sm, m)
+						sum := dpMetric.SetEmptySum()
+						sum.SetAggregationTemporality(m.Sum().AggregationTemporality())
+						sum.SetIsMonotonic(m.Sum().IsMonotonic())
+						dps.At(l).CopyTo(sum.DataPoints().AppendEmpty())
+						dest = append(dest, dpMetrics)
+					}
+				case pmetric.MetricTypeHistogram:
+					dps := m.Histogram().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						dpMetrics, dpMetric := newDataPointMetrics(rm, sm, m)
+						histogram := dpMetric.SetEmptyHistogram()
+						histogram.SetAggregationTemporality(m.Histogram().AggregationTemporality())
+						dps.At(l).CopyTo(histogram.DataPoints().AppendEmpty())
+						dest = append(dest, dpMetrics)
+					}
+				case pmetric.MetricTypeExponentialHistogram:
+					dps := m.ExponentialHistogram().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						dpMetrics, dpMetric := newDataPointMetrics(rm, sm, m)
+						histogram := dpMetric.SetEmptyExponentialHistogram()
+						histogram.SetAggregationTemporality(m.ExponentialHistogram().AggregationTemporality())
+						dps.At(l).CopyTo(histogram.DataPoints().AppendEmpty())
+						dest = append(dest, dpMetrics)
+					}
+				case pmetric.MetricTypeSummary:
+					dps := m.Summary().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						dpMetrics, dpMetric := newDataPointMetrics(rm, sm, m)
+						dps.At(l).CopyTo(dpMetric.SetEmptySummary().DataPoints().AppendEmpty())
+						dest = append(dest, dpMetrics)
+					}
+				}
+			}
+		}
+	}
+	return dest
+}
 
+// newDataPointMetrics returns a metrics with the resource of rm, the scope of sm, and a metric without data points
+// named after m.
+func newDataPointMetrics(rm pmetric.ResourceMetrics, sm pmetric.ScopeMetrics, m pmetric.Metric) (pmetric.Metrics, pmetric.Metric) {
+	md := pmetric.NewMetrics()
+	destRm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().CopyTo(destRm.Resource())
+	destRm.SetSchemaUrl(rm.SchemaUrl())
+	destSm := destRm.ScopeMetrics().AppendEmpty()
+	sm.Scope().CopyTo(destSm.Scope())
+	destSm.SetSchemaUrl(sm.SchemaUrl())
+	destM := destSm.Metrics().AppendEmpty()
+	destM.SetName(m.Name())
+	destM.SetDescription(m.Description())
+	destM.SetUnit(m.Unit())
+	return md, destM
+}
+
+// dataPointSeriesKey identifies the series of the first data point of md by its resource
+// attributes, scope name, metric name and data point attributes. The attribute values are
+// trimmed according to trimmings.
+func dataPointSeriesKey(md pmetric.Metrics, trimmings []AttributeTrimming) string {
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			sm := rm.ScopeMetrics().At(j)
+			for k := 0; k < sm.Metrics().Len(); k++ {
+				m := sm.Metrics().At(k)
+				attrs, ok := firstDataPointAttributes(m)
+				if !ok {
+					continue
+				}
+				var b strings.Builder
+				writeSortedAttributes(&b, rm.Resource().Attributes(), trimmings)
+				b.WriteString(sm.Scope().Name())
+				b.WriteByte('/')
+				b.WriteString(m.Name())
+				writeSortedAttributes(&b, attrs, trimmings)
+				return b.String()
+			}
+		}
+	}
+	return ""
+}
+
+func firstDataPointAttributes(m pmetric.Metric) (pcommon.Map, bool) {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		if m.Gauge().DataPoints().Len() > 0 {
+			return m.Gauge().DataPoints().At(0).Attributes(), true
+		}
+	case pmetric.MetricTypeSum:
+		if m.Sum().DataPoints().Len() > 0 {
+			return m.Sum().DataPoints().At(0).Attributes(), true
+		}
+	case pmetric.MetricTypeHistogram:
+		if m.Histogram().DataPoints().Len() > 0 {
+			return m.Histogram().DataPoints().At(0).Attributes(), true
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		if m.ExponentialHistogram().DataPoints().Len() > 0 {
+			return m.ExponentialHistogram().DataPoints().At(0).Attributes(), true
+		}
+	case pmetric.MetricTypeSummary:
+		if m.Summary().DataPoints().Len() > 0 {
+			return m.Summary().DataPoints().At(0).Attributes(), true
+		}
+	}
+	return pcommon.Map{}, false
+}
+
+// writeSortedAttributes writes attrs as {k1=v1,k2=v2} ordered by key.
+func writeSortedAttributes(b *strings.Builder, attrs pcommon.Map, trimmings []AttributeTrimming) {
+	keys := make([]string, 0, attrs.Len())
+	attrs.Range(func(k string, _ pcommon.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	sort.Strings(keys)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		v, _ := attrs.Get(k)
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(trimmedKeyValue(k, v, trimmings))
+	}
+	b.WriteByte('}')
 }
 
 func metricsNum(md pmetric.Metrics) (num int) {
@@ -243,31 +621,82 @@ type pdataTracesMarshaler struct {
 }
 
 func (p pdataTracesMarshaler) Marshal(td ptrace.Traces, config *Config) ([]*sarama.ProducerMessage, error) {
-	maxBytesSizeWithoutCommonData := config.Producer.MaxMessageBytes - getBlankProducerMessageSize(config)
+	var groups []keyedTraces
+	if config.PartitionTracesByID || config.PartitionKey == partitionKeyTraceID {
+		groups = splitTracesByID(td)
+	} else if key := resourcePartitionKey(config); key != nil {
+		groups = splitTracesByResource(td, key)
+	} else {
+		return p.marshalWithKey(td, config, nil)
+	}
+	var messages []*sarama.ProducerMessage
+	for _, group := range groups {
+		groupMessages, err := p.marshalWithKey(group.traces, config, group.key)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, groupMessages...)
+	}
+	return messages, nil
+}
 
-	tracesSlice, err := p.cutTraces(td, maxBytesSizeWithoutCommonData)
+// marshalWithKey marshals td into messages with the given key, split to fit max_message_bytes.
+func (p pdataTracesMarshaler) marshalWithKey(td ptrace.Traces, config *Config, key sarama.Encoder) ([]*sarama.ProducerMessage, error) {
+	bts, err := p.marshaler.MarshalTraces(td)
 	if err != nil {
 		return nil, err
 	}
+	maxBytesSizeWithoutCommonData := maxDataBytes(config)
+	if maxBytesSizeWithoutCommonData <= 0 || len(bts) <= maxBytesSizeWithoutCommonData {
+		return []*sarama.ProducerMessage{
+			{
+				Topic: config.Topic,
+				Key:   key,
+				Value: sarama.ByteEncoder(bts),
+			},
+		}, nil
+	}
+
+	// The spans are moved out of their source while it is cut, so work on a copy.
+	src := ptrace.NewTraces()
+	td.CopyTo(src)
+	deadLetters, err := p.handleUnsplittable(src, maxBytesSizeWithoutCommonData, config.OnUnsplittable.Traces)
+	if err != nil {
+		return nil, err
+	}
+	var tracesSlice []ptrace.Traces
+	if src.ResourceSpans().Len() > 0 {
+		if tracesSlice, err = p.cutTraces(src, maxBytesSizeWithoutCommonData); err != nil {
+			return nil, err
+		}
+	}
 
-	var messagesSlice []*sarama.ProducerMessage
-
+	messagesSlice := make([]*sarama.ProducerMessage, 0, len(tracesSlice)+len(deadLetters))
 	for _, traces := range tracesSlice {
-		tracesData, err := p.marshaler.MarshalTraces(traces)
-		if err != nil {
+		if messagesSlice, err = p.appendMessage(messagesSlice, config.Topic, key, traces); err != nil {
 			return nil, err
 		}
-
-		message := &sarama.ProducerMessage{
-			Topic: config.Topic,
-			Value: sarama.ByteEncoder(tracesData),
+	}
+	for _, traces := range deadLetters {
+		if messagesSlice, err = p.appendMessage(messagesSlice, config.OnUnsplittable.DeadLetterTopic, key, traces); err != nil {
+			return nil, err
 		}
-		messagesSlice = append(messagesSlice, message)
 	}
-
 	return messagesSlice, nil
 }
 
+func (p pdataTracesMarshaler) appendMessage(messages []*sarama.ProducerMessage, topic string, key sarama.Encoder, td ptrace.Traces) ([]*sarama.ProducerMessage, error) {
+	bts, err := p.marshaler.MarshalTraces(td)
+	if err != nil {
+		return nil, err
+	}
+	return append(messages, &sarama.ProducerMessage{
+		Topic: topic,
+		Key:   key,
+		Value: sarama.ByteEncoder(bts),
+	}), nil
+}
+
 func (p pdataTracesMarshaler) Encoding() string {
 	return p.encoding
 }
@@ -296,7 +725,6 @@ func (p pdataTracesMarshaler) cutTraces(td ptrace.Traces, maxBytesSizeWithoutCom
 
 	// 2. cut td  90*3/100
 	// 2.1 cutSize = (max_bytes_size / total_td_size) * totalSpanNum = (max_bytes_size * totalSpanNum) / total_td_size
-	//cutSize := int(float64(maxProducerMsgBytesSize) / float64(len(bytes)) * float64(tracesSpansNum(td)))
 	cutSize := (maxBytesSizeWithoutCommonData * tracesSpansNum(td)) / len(bytes)
 	if cutSize == 0 {
 		if len(bytes) > maxBytesSizeWithoutCommonData {
@@ -367,3 +795,11 @@ func getBlankProducerMessageSize(config *Config) int {
 	msg := sarama.ProducerMessage{}
 	return msg.ByteSize(config.Producer.protoVersion)
 }
+
+// maxDataBytes returns the maximum size of the marshaled data of a message, or 0 if the data is not split.
+func maxDataBytes(config *Config) int {
+	if config.NoSplit {
+		return 0
+	}
+	return config.Producer.MaxMessageBytes - getBlankProducerMessageSize(config)
+}
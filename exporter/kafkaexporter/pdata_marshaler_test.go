@@ -2,8 +2,11 @@ package kafkaexporter
 
 import (
 	"fmt"
+	"github.com/IBM/sarama"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/splitObjs"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/testdata"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
@@ -99,6 +102,16 @@ func TestSplitLogs_noMaxMessageSize_success(t *testing.T) {
 	assert.NotNil(t, split)
 }
 
+func TestSplitLogs_emptyLogs(t *testing.T) {
+	p := pdataLogsMarshaler{
+		marshaler: &plog.ProtoMarshaler{},
+		encoding:  defaultEncoding,
+	}
+	split, err := p.cutLogs(plog.NewLogs(), 100)
+	assert.NoError(t, err)
+	assert.Empty(t, split)
+}
+
 func TestSplitMetrics_maxMetricsByteSize_success(t *testing.T) {
 	maxMessageBytes := 1000
 	p := pdataMetricsMarshaler{
@@ -136,6 +149,80 @@ func TestSplitMetrics_maxMetricsByteSize_success(t *testing.T) {
 	}
 }
 
+func TestSplitMetrics_maxMetricsByteSize_manyDataPoints(t *testing.T) {
+	p := pdataMetricsMarshaler{
+		marshaler: &pmetric.ProtoMarshaler{},
+		encoding:  defaultEncoding,
+	}
+	config := &Config{Topic: "topic", Producer: Producer{MaxMessageBytes: 10000}}
+	md := pmetric.NewMetrics()
+	histogram := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	histogram.SetName("latency")
+	dps := histogram.SetEmptyHistogram().DataPoints()
+	for i := 0; i < 5000; i++ {
+		dp := dps.AppendEmpty()
+		dp.SetCount(uint64(i))
+		dp.BucketCounts().FromRaw([]uint64{1, 2, 3})
+		dp.ExplicitBounds().FromRaw([]float64{10, 100})
+	}
+	require.Greater(t, metricsBytes(md, p), config.Producer.MaxMessageBytes)
+
+	// A single metric with too many data points is split across messages instead of failing.
+	messages, err := p.Marshal(md, config)
+	require.NoError(t, err)
+	assert.Greater(t, len(messages), 1)
+	dataPoints := 0
+	for _, message := range messages {
+		assert.LessOrEqual(t, message.ByteSize(config.Producer.protoVersion), config.Producer.MaxMessageBytes)
+		bts, err := message.Value.Encode()
+		require.NoError(t, err)
+		split, err := (&pmetric.ProtoUnmarshaler{}).UnmarshalMetrics(bts)
+		require.NoError(t, err)
+		assert.Equal(t, "latency", split.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name())
+		dataPoints += split.DataPointCount()
+	}
+	assert.Equal(t, 5000, dataPoints)
+}
+
+func TestSplitMetrics_maxMetricsByteSize_cutBigMetricByDataPoint(t *testing.T) {
+	p := pdataMetricsMarshaler{
+		marshaler: &pmetric.ProtoMarshaler{},
+		encoding:  defaultEncoding,
+	}
+	config := &Config{Topic: "topic", Producer: Producer{MaxMessageBytes: 10000}}
+	md := testdata.GenerateMetrics(10)
+	histogram := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().AppendEmpty()
+	histogram.SetName("latency")
+	dps := histogram.SetEmptyHistogram().DataPoints()
+	for i := 0; i < 5000; i++ {
+		dp := dps.AppendEmpty()
+		dp.SetCount(uint64(i))
+		dp.BucketCounts().FromRaw([]uint64{1, 2, 3})
+		dp.ExplicitBounds().FromRaw([]float64{10, 100})
+	}
+	dataPoints := md.DataPointCount()
+
+	maxBytesSizeWithoutCommonData := config.Producer.MaxMessageBytes - getBlankProducerMessageSize(config)
+	split, err := p.cutMetrics(md, maxBytesSizeWithoutCommonData)
+	require.NoError(t, err)
+
+	// The small metrics are kept whole, and only the histogram is cut by data point.
+	otherMetrics, cutDataPoints := 0, 0
+	for _, metrics := range split {
+		assert.LessOrEqual(t, metricsBytes(metrics, p), maxBytesSizeWithoutCommonData)
+		cutDataPoints += metrics.DataPointCount()
+		ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+		for i := 0; i < ms.Len(); i++ {
+			if ms.At(i).Name() != "latency" {
+				otherMetrics++
+			}
+		}
+	}
+	assert.Greater(t, len(split), 2)
+	assert.Equal(t, 10, otherMetrics)
+	assert.Equal(t, dataPoints, cutDataPoints)
+}
+
 func TestSplitMetrics_maxMetricsByteSize_bigSingleMetircError(t *testing.T) {
 	maxMessageBytes := 100
 	p := pdataMetricsMarshaler{
@@ -279,3 +366,147 @@ func TestSplitTraces_noMaxMessageSize_success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, split)
 }
+
+func TestMarshalMetrics_perDataPoint(t *testing.T) {
+	p := pdataMetricsMarshaler{
+		marshaler: &pmetric.ProtoMarshaler{},
+		encoding:  defaultEncoding,
+	}
+	config := &Config{Topic: "topic", MetricsGranularity: metricsGranularityPerDataPoint}
+
+	md := testdata.GenerateMetricsAllTypes()
+	messages, err := p.Marshal(md, config)
+	assert.NoError(t, err)
+	assert.Len(t, messages, md.DataPointCount())
+
+	unmarshaler := &pmetric.ProtoUnmarshaler{}
+	keys := map[string]bool{}
+	for _, msg := range messages {
+		assert.Equal(t, "topic", msg.Topic)
+		bts, err := msg.Value.Encode()
+		assert.NoError(t, err)
+		dp, err := unmarshaler.UnmarshalMetrics(bts)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, dp.DataPointCount())
+
+		key, err := msg.Key.Encode()
+		assert.NoError(t, err)
+		assert.NotEmpty(t, key)
+		keys[string(key)] = true
+	}
+	// Every data point of the generated metrics belongs to a different series.
+	assert.Len(t, keys, md.DataPointCount())
+	// The input is not modified.
+	assert.Equal(t, testdata.GenerateMetricsAllTypes(), md)
+}
+
+func TestDataPointMetrics(t *testing.T) {
+	md := testdata.GenerateMetricsAllTypes()
+	dataPoints := dataPointMetrics(md)
+	require.Len(t, dataPoints, md.DataPointCount())
+
+	// Every data point comes with its resource, scope and metric, as if split one at a time.
+	src := testdata.GenerateMetricsAllTypes()
+	for i, dp := range dataPoints {
+		want := src
+		if i < len(dataPoints)-1 {
+			want = splitObjs.SplitMetrics(1, src)
+		}
+		assert.Equal(t, want, dp)
+	}
+	// The input is not modified.
+	assert.Equal(t, testdata.GenerateMetricsAllTypes(), md)
+}
+
+func TestMarshalMetrics_perDataPoint_keyAttributeTrimming(t *testing.T) {
+	p := pdataMetricsMarshaler{
+		marshaler: &pmetric.ProtoMarshaler{},
+		encoding:  defaultEncoding,
+	}
+	config := &Config{
+		Topic:              "topic",
+		MetricsGranularity: metricsGranularityPerDataPoint,
+		KeyAttributeTrimming: []AttributeTrimming{
+			{Attribute: "url", MaxLength: 10},
+			{Attribute: "latency", RoundTo: 100},
+		},
+	}
+
+	md := pmetric.NewMetrics()
+	dps := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints()
+	for _, dp := range []struct {
+		url     string
+		latency int64
+	}{
+		{url: "/api/users/1", latency: 120},
+		{url: "/api/users/2", latency: 180},
+	} {
+		attrs := dps.AppendEmpty().Attributes()
+		attrs.PutStr("url", dp.url)
+		attrs.PutInt("latency", dp.latency)
+	}
+
+	messages, err := p.Marshal(md, config)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	for _, msg := range messages {
+		key, err := msg.Key.Encode()
+		require.NoError(t, err)
+		assert.Equal(t, "{}/{latency=100,url=/api/users}", string(key))
+	}
+}
+
+func TestMarshal_otlpJSON_maxMessageBytes(t *testing.T) {
+	config := &Config{Topic: "topic", Producer: Producer{MaxMessageBytes: 1000}}
+	unmarshalValue := func(t *testing.T, message *sarama.ProducerMessage) []byte {
+		assert.LessOrEqual(t, message.ByteSize(config.Producer.protoVersion), config.Producer.MaxMessageBytes)
+		value, err := message.Value.Encode()
+		require.NoError(t, err)
+		return value
+	}
+
+	t.Run("traces", func(t *testing.T) {
+		marshaler := tracesMarshalers()["otlp_json"]
+		assert.Equal(t, "otlp_json", marshaler.Encoding())
+		messages, err := marshaler.Marshal(testdata.GenerateTraces(20), config)
+		require.NoError(t, err)
+		assert.Greater(t, len(messages), 1)
+		spans := 0
+		for _, message := range messages {
+			td, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(unmarshalValue(t, message))
+			require.NoError(t, err)
+			spans += td.SpanCount()
+		}
+		assert.Equal(t, 20, spans)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		marshaler := metricsMarshalers()["otlp_json"]
+		assert.Equal(t, "otlp_json", marshaler.Encoding())
+		messages, err := marshaler.Marshal(testdata.GenerateMetrics(20), config)
+		require.NoError(t, err)
+		assert.Greater(t, len(messages), 1)
+		dataPoints := 0
+		for _, message := range messages {
+			md, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics(unmarshalValue(t, message))
+			require.NoError(t, err)
+			dataPoints += md.DataPointCount()
+		}
+		assert.Equal(t, testdata.GenerateMetrics(20).DataPointCount(), dataPoints)
+	})
+
+	t.Run("logs", func(t *testing.T) {
+		marshaler := logsMarshalers()["otlp_json"]
+		assert.Equal(t, "otlp_json", marshaler.Encoding())
+		messages, err := marshaler.Marshal(testdata.GenerateLogs(20), config)
+		require.NoError(t, err)
+		assert.Greater(t, len(messages), 1)
+		logRecords := 0
+		for _, message := range messages {
+			ld, err := (&plog.JSONUnmarshaler{}).UnmarshalLogs(unmarshalValue(t, message))
+			require.NoError(t, err)
+			logRecords += ld.LogRecordCount()
+		}
+		assert.Equal(t, 20, logRecords)
+	})
+}
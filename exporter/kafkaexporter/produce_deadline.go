@@ -0,0 +1,114 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/component"
+)
+
+// errProduceDeadlineExceeded is returned when the messages of a push are not produced within producer.produce_deadline.
+// It is not permanent, so the push is retried, once the sends abandoned by the earlier pushes have ended.
+var errProduceDeadlineExceeded = errors.New("kafka produce deadline exceeded")
+
+// produceTimer records the time spent marshaling and producing the data of every push, and bounds the time
+// spent producing it by the produce deadline.
+type produceTimer struct {
+	deadline  time.Duration
+	statsTags []tag.Mutator
+
+	mu sync.Mutex
+	// abandoned is closed once the sends that exceeded the deadline have ended, nil if none did.
+	abandoned chan struct{}
+}
+
+func newProduceTimer(deadline time.Duration, id component.ID) *produceTimer {
+	return &produceTimer{
+		deadline:  deadline,
+		statsTags: []tag.Mutator{tag.Upsert(tagInstanceName, id.String())},
+	}
+}
+
+// marshaled records the time spent marshaling since start.
+func (t *produceTimer) marshaled(ctx context.Context, start time.Time) {
+	if t == nil {
+		return
+	}
+	_ = stats.RecordWithTags(ctx, t.statsTags, statMarshalDuration.M(time.Since(start).Milliseconds()))
+}
+
+// send sends messages with producer. spent is the time already spent sending the earlier messages of the push,
+// and is increased by the time spent sending messages. If the produce deadline is set and spent exceeds it,
+// send returns errProduceDeadlineExceeded without waiting for producer, whose messages may still be produced.
+// The sends abandoned this way are waited for, within the deadline, before sending again, so that the retries
+// of a push do not pile up sends to the same slow brokers.
+func (t *produceTimer) send(ctx context.Context, producer sarama.SyncProducer, messages []*sarama.ProducerMessage, spent *time.Duration) error {
+	if t == nil {
+		return producer.SendMessages(messages)
+	}
+	start := time.Now()
+	defer func() {
+		elapsed := time.Since(start)
+		*spent += elapsed
+		_ = stats.RecordWithTags(ctx, t.statsTags, statProduceDuration.M(elapsed.Milliseconds()))
+	}()
+	if t.deadline <= 0 {
+		return producer.SendMessages(messages)
+	}
+	remaining := t.deadline - *spent
+	if remaining <= 0 {
+		return errProduceDeadlineExceeded
+	}
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+	if abandoned := t.abandonedSends(); abandoned != nil {
+		select {
+		case <-abandoned:
+		case <-timer.C:
+			return errProduceDeadlineExceeded
+		}
+	}
+	done := make(chan error, 1)
+	ended := make(chan struct{})
+	go func() {
+		done <- producer.SendMessages(messages)
+		close(ended)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		t.abandon(ended)
+		return errProduceDeadlineExceeded
+	}
+}
+
+func (t *produceTimer) abandonedSends() chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.abandoned
+}
+
+// abandon adds the send that closes ended to the abandoned sends.
+func (t *produceTimer) abandon(ended chan struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	previous := t.abandoned
+	abandoned := make(chan struct{})
+	go func() {
+		if previous != nil {
+			<-previous
+		}
+		<-ended
+		close(abandoned)
+	}()
+	t.abandoned = abandoned
+}
@@ -0,0 +1,99 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+func TestLogsDataPusher_produce_deadline(t *testing.T) {
+	views := exporterMetricViews()
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	release := make(chan struct{})
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(*sarama.ProducerMessage) error {
+		<-release
+		return nil
+	})
+	producer.ExpectSendMessageAndSucceed()
+	config := &Config{Topic: "topic", Producer: Producer{MaxMessageBytes: 1000 * 1000, ProduceDeadline: 10 * time.Millisecond}}
+	p := kafkaLogsProducer{
+		producer:  producer,
+		marshaler: newPdataLogsMarshaler(&plog.ProtoMarshaler{}, defaultEncoding),
+		config:    config,
+		timer:     newProduceTimer(config.Producer.ProduceDeadline, component.NewID("kafka")),
+		logger:    zap.NewNop(),
+	}
+
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("record")
+	err := p.logsDataPusher(context.Background(), ld)
+	assert.ErrorIs(t, err, errProduceDeadlineExceeded)
+	assert.False(t, consumererror.IsPermanent(err))
+
+	// The next push is not bounded by the time spent by the previous one.
+	close(release)
+	<-p.timer.abandonedSends()
+	require.NoError(t, p.logsDataPusher(context.Background(), ld))
+
+	for _, stat := range []string{statMarshalDuration.Name(), statProduceDuration.Name()} {
+		rows, err := view.RetrieveData(stat)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, int64(2), rows[0].Data.(*view.DistributionData).Count)
+	}
+}
+
+func TestProduceTimer_spent(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	timer := newProduceTimer(time.Minute, component.NewID("kafka"))
+
+	// The deadline covers all the batches of a push, the exhausted ones fail without sending.
+	spent := time.Minute
+	assert.ErrorIs(t, timer.send(context.Background(), producer, []*sarama.ProducerMessage{{Topic: "topic"}}, &spent), errProduceDeadlineExceeded)
+
+	producer.ExpectSendMessageAndSucceed()
+	spent = 0
+	require.NoError(t, timer.send(context.Background(), producer, []*sarama.ProducerMessage{{Topic: "topic"}}, &spent))
+	assert.Greater(t, spent, time.Duration(0))
+}
+
+func TestProduceTimer_waits_for_abandoned_send(t *testing.T) {
+	release := make(chan struct{})
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(*sarama.ProducerMessage) error {
+		<-release
+		return nil
+	})
+	producer.ExpectSendMessageAndSucceed()
+	timer := newProduceTimer(10*time.Millisecond, component.NewID("kafka"))
+	messages := []*sarama.ProducerMessage{{Topic: "topic"}}
+
+	var spent time.Duration
+	assert.ErrorIs(t, timer.send(context.Background(), producer, messages, &spent), errProduceDeadlineExceeded)
+
+	// The retry waits for the abandoned send instead of sending again.
+	spent = 0
+	assert.ErrorIs(t, timer.send(context.Background(), producer, messages, &spent), errProduceDeadlineExceeded)
+
+	close(release)
+	<-timer.abandonedSends()
+	spent = 0
+	require.NoError(t, timer.send(context.Background(), producer, messages, &spent))
+	require.NoError(t, producer.Close())
+}
@@ -0,0 +1,67 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"context"
+	"math"
+
+	"github.com/IBM/sarama"
+	"golang.org/x/time/rate"
+)
+
+// produceRateLimiter throttles the produced messages with a token bucket for the number of
+// messages and one for the number of bytes.
+type produceRateLimiter struct {
+	messages     *rate.Limiter
+	bytes        *rate.Limiter
+	protoVersion int
+}
+
+// newProduceRateLimiter returns nil if no rate limit is configured.
+func newProduceRateLimiter(config Producer) *produceRateLimiter {
+	rl := config.RateLimit
+	if rl.MessagesPerSecond <= 0 && rl.BytesPerSecond <= 0 {
+		return nil
+	}
+	l := &produceRateLimiter{protoVersion: config.protoVersion}
+	if rl.MessagesPerSecond > 0 {
+		l.messages = rate.NewLimiter(rate.Limit(rl.MessagesPerSecond), int(math.Max(1, math.Ceil(rl.MessagesPerSecond))))
+	}
+	if rl.BytesPerSecond > 0 {
+		// The burst has to fit the biggest message that can be produced.
+		burst := rl.BytesPerSecond
+		if config.MaxMessageBytes > burst {
+			burst = config.MaxMessageBytes
+		}
+		l.bytes = rate.NewLimiter(rate.Limit(rl.BytesPerSecond), burst)
+	}
+	return l
+}
+
+// wait blocks until the messages can be produced without exceeding the rate limit,
+// or until ctx is done.
+func (l *produceRateLimiter) wait(ctx context.Context, messages []*sarama.ProducerMessage) error {
+	if l == nil {
+		return nil
+	}
+	for _, message := range messages {
+		if l.messages != nil {
+			if err := l.messages.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		if l.bytes != nil {
+			// Messages bigger than the burst, produced to the dead letter topic, consume the whole burst.
+			n := message.ByteSize(l.protoVersion)
+			if n > l.bytes.Burst() {
+				n = l.bytes.Burst()
+			}
+			if err := l.bytes.WaitN(ctx, n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -14,6 +14,8 @@ import (
 
 var errUnsupported = errors.New("unsupported serialization")
 
+// rawMarshaler produces every log record as its own message holding only its body: string bodies as UTF-8,
+// bytes bodies as is, and the other bodies as JSON. The log records with an empty body are skipped.
 type rawMarshaler struct {
 }
 
@@ -37,10 +39,21 @@ func (r rawMarshaler) Marshal(logs plog.Logs, config *Config) ([]*sarama.Produce
 					continue
 				}
 
-				messages = append(messages, &sarama.ProducerMessage{
+				message := &sarama.ProducerMessage{
 					Topic: config.Topic,
 					Value: sarama.ByteEncoder(b),
-				})
+				}
+				if config.TraceContextHeaders {
+					message.Headers = traceContextHeaders(lr.TraceID(), lr.SpanID())
+				}
+				if config.SyslogSeverity.Enabled {
+					message.Headers = append(message.Headers, syslogSeverityHeaders(lr.SeverityNumber(), config.SyslogSeverity.Mapping)...)
+				}
+				if !config.NoSplit && config.Producer.MaxMessageBytes > 0 &&
+					message.ByteSize(config.Producer.protoVersion) > config.Producer.MaxMessageBytes {
+					return nil, errSingleKafkaProducerMessageSizeOverMaxMsgByte
+				}
+				messages = append(messages, message)
 			}
 		}
 	}
@@ -51,7 +64,7 @@ func (r rawMarshaler) Marshal(logs plog.Logs, config *Config) ([]*sarama.Produce
 func (r rawMarshaler) logBodyAsBytes(value pcommon.Value) ([]byte, error) {
 	switch value.Type() {
 	case pcommon.ValueTypeStr:
-		return r.interfaceAsBytes(value.Str())
+		return []byte(value.Str()), nil
 	case pcommon.ValueTypeBytes:
 		return value.Bytes().AsRaw(), nil
 	case pcommon.ValueTypeBool:
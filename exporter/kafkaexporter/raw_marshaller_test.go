@@ -4,6 +4,7 @@
 package kafkaexporter
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/IBM/sarama"
@@ -32,7 +33,7 @@ func Test_RawMarshaler(t *testing.T) {
 				return lr
 			},
 			errorExpected: false,
-			marshaled:     []byte("\"foo\""),
+			marshaled:     []byte("foo"),
 		},
 		{
 			name: "[]byte",
@@ -138,3 +139,21 @@ func Test_RawMarshaler(t *testing.T) {
 		})
 	}
 }
+
+func Test_RawMarshaler_maxMessageBytes(t *testing.T) {
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	records.AppendEmpty().Body().SetStr("small")
+	records.AppendEmpty().Body().SetStr(strings.Repeat("a", 200))
+	config := &Config{Topic: "foo", Producer: Producer{protoVersion: 2, MaxMessageBytes: 100}}
+
+	messages, err := newRawMarshaler().Marshal(logs, config)
+	assert.Equal(t, errSingleKafkaProducerMessageSizeOverMaxMsgByte, err)
+	assert.Nil(t, messages)
+
+	config.Producer.MaxMessageBytes = 1000
+	messages, err = newRawMarshaler().Marshal(logs, config)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, sarama.ByteEncoder("small"), messages[0].Value)
+}
@@ -0,0 +1,236 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"context"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// recordAgeFilter drops the spans, data points and log records older than maxAge before they are
+// produced. The records without a timestamp are kept.
+type recordAgeFilter struct {
+	maxAge    time.Duration
+	now       func() time.Time
+	statsTags []tag.Mutator
+}
+
+// newRecordAgeFilter returns nil if maxAge is 0.
+func newRecordAgeFilter(maxAge time.Duration, id component.ID) *recordAgeFilter {
+	if maxAge <= 0 {
+		return nil
+	}
+	return &recordAgeFilter{
+		maxAge:    maxAge,
+		now:       time.Now,
+		statsTags: []tag.Mutator{tag.Upsert(tagInstanceName, id.String())},
+	}
+}
+
+func (f *recordAgeFilter) cutoff() pcommon.Timestamp {
+	return pcommon.NewTimestampFromTime(f.now().Add(-f.maxAge))
+}
+
+func (f *recordAgeFilter) recordDropped(n int) {
+	if n > 0 {
+		_ = stats.RecordWithTags(context.Background(), f.statsTags, statStaleRecordsDropped.M(int64(n)))
+	}
+}
+
+// isStale reports whether ts is set and before cutoff.
+func isStale(ts pcommon.Timestamp, cutoff pcommon.Timestamp) bool {
+	return ts != 0 && ts < cutoff
+}
+
+// traces returns td without its stale spans, judged by their end timestamp or, if they have none, by
+// their start timestamp. td is left unchanged, and returned as is if none of its spans is stale.
+func (f *recordAgeFilter) traces(td ptrace.Traces) ptrace.Traces {
+	cutoff := f.cutoff()
+	stale := func(span ptrace.Span) bool {
+		ts := span.EndTimestamp()
+		if ts == 0 {
+			ts = span.StartTimestamp()
+		}
+		return isStale(ts, cutoff)
+	}
+	if !anySpan(td, stale) {
+		return td
+	}
+	fresh := ptrace.NewTraces()
+	td.CopyTo(fresh)
+	dropped := 0
+	fresh.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			ss.Spans().RemoveIf(func(span ptrace.Span) bool {
+				if stale(span) {
+					dropped++
+					return true
+				}
+				return false
+			})
+			return ss.Spans().Len() == 0
+		})
+		return rs.ScopeSpans().Len() == 0
+	})
+	f.recordDropped(dropped)
+	return fresh
+}
+
+func anySpan(td ptrace.Traces, match func(ptrace.Span) bool) bool {
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			spans := rs.ScopeSpans().At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				if match(spans.At(k)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// logs returns ld without its stale log records, judged by their timestamp or, if they have none, by
+// their observed timestamp. ld is left unchanged, and returned as is if none of its records is stale.
+func (f *recordAgeFilter) logs(ld plog.Logs) plog.Logs {
+	cutoff := f.cutoff()
+	stale := func(record plog.LogRecord) bool {
+		ts := record.Timestamp()
+		if ts == 0 {
+			ts = record.ObservedTimestamp()
+		}
+		return isStale(ts, cutoff)
+	}
+	if !anyLogRecord(ld, stale) {
+		return ld
+	}
+	fresh := plog.NewLogs()
+	ld.CopyTo(fresh)
+	dropped := 0
+	fresh.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+		rl.ScopeLogs().RemoveIf(func(sl plog.ScopeLogs) bool {
+			sl.LogRecords().RemoveIf(func(record plog.LogRecord) bool {
+				if stale(record) {
+					dropped++
+					return true
+				}
+				return false
+			})
+			return sl.LogRecords().Len() == 0
+		})
+		return rl.ScopeLogs().Len() == 0
+	})
+	f.recordDropped(dropped)
+	return fresh
+}
+
+func anyLogRecord(ld plog.Logs, match func(plog.LogRecord) bool) bool {
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			records := rl.ScopeLogs().At(j).LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				if match(records.At(k)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// timedPoint is implemented by all the data points.
+type timedPoint interface {
+	Timestamp() pcommon.Timestamp
+}
+
+// metrics returns md without its stale data points, and without the metrics left without data points.
+// md is left unchanged, and returned as is if none of its data points is stale.
+func (f *recordAgeFilter) metrics(md pmetric.Metrics) pmetric.Metrics {
+	cutoff := f.cutoff()
+	if countStalePoints(md, cutoff, false) == 0 {
+		return md
+	}
+	fresh := pmetric.NewMetrics()
+	md.CopyTo(fresh)
+	f.recordDropped(countStalePoints(fresh, cutoff, true))
+	fresh.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+		rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+			sm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+				return dataPointCount(m) == 0
+			})
+			return sm.Metrics().Len() == 0
+		})
+		return rm.ScopeMetrics().Len() == 0
+	})
+	return fresh
+}
+
+// countStalePoints returns the number of data points of md older than cutoff, and removes them if remove is true.
+func countStalePoints(md pmetric.Metrics, cutoff pcommon.Timestamp, remove bool) int {
+	count := 0
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			metrics := rm.ScopeMetrics().At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				m := metrics.At(k)
+				switch m.Type() {
+				case pmetric.MetricTypeGauge:
+					count += stalePoints(cutoff, remove, m.Gauge().DataPoints().Len(), m.Gauge().DataPoints().At, m.Gauge().DataPoints().RemoveIf)
+				case pmetric.MetricTypeSum:
+					count += stalePoints(cutoff, remove, m.Sum().DataPoints().Len(), m.Sum().DataPoints().At, m.Sum().DataPoints().RemoveIf)
+				case pmetric.MetricTypeHistogram:
+					count += stalePoints(cutoff, remove, m.Histogram().DataPoints().Len(), m.Histogram().DataPoints().At, m.Histogram().DataPoints().RemoveIf)
+				case pmetric.MetricTypeExponentialHistogram:
+					count += stalePoints(cutoff, remove, m.ExponentialHistogram().DataPoints().Len(), m.ExponentialHistogram().DataPoints().At, m.ExponentialHistogram().DataPoints().RemoveIf)
+				case pmetric.MetricTypeSummary:
+					count += stalePoints(cutoff, remove, m.Summary().DataPoints().Len(), m.Summary().DataPoints().At, m.Summary().DataPoints().RemoveIf)
+				}
+			}
+		}
+	}
+	return count
+}
+
+func stalePoints[P timedPoint](cutoff pcommon.Timestamp, remove bool, n int, at func(int) P, removeIf func(func(P) bool)) int {
+	count := 0
+	for i := 0; i < n; i++ {
+		if isStale(at(i).Timestamp(), cutoff) {
+			count++
+		}
+	}
+	if remove && count > 0 {
+		removeIf(func(dp P) bool {
+			return isStale(dp.Timestamp(), cutoff)
+		})
+	}
+	return count
+}
+
+func dataPointCount(m pmetric.Metric) int {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		return m.Gauge().DataPoints().Len()
+	case pmetric.MetricTypeSum:
+		return m.Sum().DataPoints().Len()
+	case pmetric.MetricTypeHistogram:
+		return m.Histogram().DataPoints().Len()
+	case pmetric.MetricTypeExponentialHistogram:
+		return m.ExponentialHistogram().DataPoints().Len()
+	case pmetric.MetricTypeSummary:
+		return m.Summary().DataPoints().Len()
+	}
+	// Metrics without type have no data point to drop.
+	return 1
+}
@@ -0,0 +1,134 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+var fakeNow = time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestRecordAgeFilter(maxAge time.Duration) *recordAgeFilter {
+	f := newRecordAgeFilter(maxAge, component.NewID("kafka"))
+	f.now = func() time.Time { return fakeNow }
+	return f
+}
+
+func ago(d time.Duration) pcommon.Timestamp {
+	return pcommon.NewTimestampFromTime(fakeNow.Add(-d))
+}
+
+func TestRecordAgeFilter_traces(t *testing.T) {
+	f := newTestRecordAgeFilter(time.Minute)
+	td := ptrace.NewTraces()
+	spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	spans.AppendEmpty().SetName("fresh")
+	spans.At(0).SetEndTimestamp(ago(time.Second))
+	spans.AppendEmpty().SetName("stale")
+	spans.At(1).SetEndTimestamp(ago(time.Hour))
+	spans.AppendEmpty().SetName("unfinished")
+	spans.At(2).SetStartTimestamp(ago(time.Hour))
+	spans.AppendEmpty().SetName("untimed")
+	// The whole resource is dropped with its only span.
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetEndTimestamp(ago(2 * time.Minute))
+
+	fresh := f.traces(td)
+	assert.Equal(t, 5, td.SpanCount())
+	require.Equal(t, 1, fresh.ResourceSpans().Len())
+	freshSpans := fresh.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	require.Equal(t, 2, freshSpans.Len())
+	assert.Equal(t, "fresh", freshSpans.At(0).Name())
+	assert.Equal(t, "untimed", freshSpans.At(1).Name())
+
+	assert.Equal(t, fresh, f.traces(fresh))
+}
+
+func TestRecordAgeFilter_metrics(t *testing.T) {
+	f := newTestRecordAgeFilter(time.Minute)
+	md := pmetric.NewMetrics()
+	metrics := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	gauge := metrics.AppendEmpty()
+	gauge.SetName("gauge")
+	gauge.SetEmptyGauge().DataPoints().AppendEmpty().SetTimestamp(ago(time.Second))
+	gauge.Gauge().DataPoints().AppendEmpty().SetTimestamp(ago(time.Hour))
+	histogram := metrics.AppendEmpty()
+	histogram.SetName("histogram")
+	histogram.SetEmptyHistogram().DataPoints().AppendEmpty().SetTimestamp(ago(time.Hour))
+
+	fresh := f.metrics(md)
+	assert.Equal(t, 3, md.DataPointCount())
+	assert.Equal(t, 1, fresh.DataPointCount())
+	freshMetrics := fresh.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 1, freshMetrics.Len())
+	assert.Equal(t, "gauge", freshMetrics.At(0).Name())
+	assert.Equal(t, ago(time.Second), freshMetrics.At(0).Gauge().DataPoints().At(0).Timestamp())
+}
+
+func TestRecordAgeFilter_logs(t *testing.T) {
+	f := newTestRecordAgeFilter(time.Minute)
+	ld := plog.NewLogs()
+	records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	records.AppendEmpty().Body().SetStr("fresh")
+	records.At(0).SetTimestamp(ago(time.Second))
+	records.At(0).SetObservedTimestamp(ago(time.Hour))
+	records.AppendEmpty().Body().SetStr("stale")
+	records.At(1).SetTimestamp(ago(time.Hour))
+	records.AppendEmpty().Body().SetStr("observed stale")
+	records.At(2).SetObservedTimestamp(ago(time.Hour))
+
+	fresh := f.logs(ld)
+	assert.Equal(t, 3, ld.LogRecordCount())
+	require.Equal(t, 1, fresh.LogRecordCount())
+	assert.Equal(t, "fresh", fresh.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+}
+
+func TestRecordAgeFilter_disabled(t *testing.T) {
+	assert.Nil(t, newRecordAgeFilter(0, component.NewID("kafka")))
+}
+
+func TestLogsDataPusher_max_record_age(t *testing.T) {
+	views := exporterMetricViews()
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageAndSucceed()
+	p := kafkaLogsProducer{
+		producer:  producer,
+		marshaler: newPdataLogsMarshaler(&plog.ProtoMarshaler{}, defaultEncoding),
+		config:    &Config{Topic: "topic", Producer: Producer{MaxMessageBytes: 1000 * 1000}},
+		recordAge: newTestRecordAgeFilter(time.Minute),
+		logger:    zap.NewNop(),
+	}
+
+	ld := plog.NewLogs()
+	records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	records.AppendEmpty().SetTimestamp(ago(time.Second))
+	records.AppendEmpty().SetTimestamp(ago(time.Hour))
+	require.NoError(t, p.logsDataPusher(context.Background(), ld))
+
+	// Nothing is produced once all the records are stale.
+	ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().RemoveIf(func(record plog.LogRecord) bool {
+		return record.Timestamp() == ago(time.Second)
+	})
+	require.NoError(t, p.logsDataPusher(context.Background(), ld))
+
+	rows, err := view.RetrieveData(statStaleRecordsDropped.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, float64(2), rows[0].Data.(*view.SumData).Value)
+}
@@ -0,0 +1,39 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXDGSCRAMClient_mechanisms(t *testing.T) {
+	tests := []struct {
+		mechanism string
+		size      int
+	}{
+		{mechanism: "SCRAM-SHA-256", size: sha256.Size},
+		{mechanism: "SCRAM-SHA-512", size: sha512.Size},
+	}
+	for _, test := range tests {
+		t.Run(test.mechanism, func(t *testing.T) {
+			config := &sarama.Config{}
+			require.NoError(t, ConfigureAuthentication(Authentication{SASL: &SASLConfig{Username: "jdoe", Password: "pass", Mechanism: test.mechanism}}, config))
+			client, ok := config.Net.SASL.SCRAMClientGeneratorFunc().(*XDGSCRAMClient)
+			require.True(t, ok)
+			assert.Equal(t, test.size, client.HashGeneratorFcn().Size())
+
+			require.NoError(t, client.Begin("jdoe", "pass", ""))
+			first, err := client.Step("")
+			require.NoError(t, err)
+			assert.Contains(t, first, "n=jdoe,r=")
+			assert.False(t, client.Done())
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/IBM/sarama"
+)
+
+// sequenceHeader is the header holding the sequence number of a message in its partition.
+const sequenceHeader = "sequence"
+
+// newSequencePartitioner returns the constructor of the partitioners of the topics stamping the sequence
+// header on the messages partitioned by the partitioners of constructor, or constructor if disabled.
+func newSequencePartitioner(enabled bool, constructor sarama.PartitionerConstructor) sarama.PartitionerConstructor {
+	if !enabled {
+		return constructor
+	}
+	return func(topic string) sarama.Partitioner {
+		return &sequencePartitioner{partitioner: constructor(topic)}
+	}
+}
+
+// sequencePartitioner sets the sequence header of the messages to the number of messages partitioned to their
+// partition so far, starting at 1, so that consumers can detect gaps. sarama partitions the messages once, so
+// the messages retried by the producer keep their number. The numbers restart with the exporter.
+type sequencePartitioner struct {
+	partitioner sarama.Partitioner
+
+	// counters holds the *atomic.Uint64 of every partition.
+	counters sync.Map
+}
+
+func (p *sequencePartitioner) Partition(message *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	partition, err := p.partitioner.Partition(message, numPartitions)
+	if err != nil {
+		return -1, err
+	}
+	counter, _ := p.counters.LoadOrStore(partition, &atomic.Uint64{})
+	appendSequenceHeader(message, counter.(*atomic.Uint64).Add(1))
+	return partition, nil
+}
+
+// appendSequenceHeader sets the sequence header of message to sequence.
+func appendSequenceHeader(message *sarama.ProducerMessage, sequence uint64) {
+	message.Headers = append(message.Headers, sarama.RecordHeader{
+		Key:   []byte(sequenceHeader),
+		Value: []byte(strconv.FormatUint(sequence, 10)),
+	})
+}
+
+func (p *sequencePartitioner) RequiresConsistency() bool {
+	return p.partitioner.RequiresConsistency()
+}
@@ -0,0 +1,48 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequencePartitioner(t *testing.T) {
+	partitioner := newSequencePartitioner(true, sarama.NewHashPartitioner)("topic")
+	last := map[int32]uint64{}
+	for i := 0; i < 100; i++ {
+		message := &sarama.ProducerMessage{Key: sarama.StringEncoder("key-" + strconv.Itoa(i%7))}
+		partition, err := partitioner.Partition(message, 4)
+		require.NoError(t, err)
+		require.Len(t, message.Headers, 1)
+		assert.Equal(t, sequenceHeader, string(message.Headers[0].Key))
+		sequence, err := strconv.ParseUint(string(message.Headers[0].Value), 10, 64)
+		require.NoError(t, err)
+		// The sequence of every partition increases by one with every message.
+		assert.Equal(t, last[partition]+1, sequence)
+		last[partition] = sequence
+	}
+	assert.Greater(t, len(last), 1)
+	assert.True(t, partitioner.RequiresConsistency())
+}
+
+func TestSequencePartitioner_spillover(t *testing.T) {
+	partitioner := newSequencePartitioner(true, newSpilloverPartitioner(HotPartition{MaxShare: 0.1, Window: 10}))("topic")
+	message := &sarama.ProducerMessage{}
+	_, err := partitioner.Partition(message, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []sarama.RecordHeader{{Key: []byte(sequenceHeader), Value: []byte("1")}}, message.Headers)
+}
+
+func TestSequencePartitioner_disabled(t *testing.T) {
+	partitioner := newSequencePartitioner(false, sarama.NewHashPartitioner)("topic")
+	message := &sarama.ProducerMessage{}
+	_, err := partitioner.Partition(message, 4)
+	require.NoError(t, err)
+	assert.Empty(t, message.Headers)
+}
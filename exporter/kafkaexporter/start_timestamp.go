@@ -0,0 +1,197 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+const (
+	startTimestampKeep      = "keep"
+	startTimestampFirstSeen = "first_seen"
+	startTimestampDrop      = "drop"
+)
+
+// startTimestampEncodings are the encodings of the metrics exporter MissingStartTimestamp applies to.
+var startTimestampEncodings = map[string]bool{
+	defaultEncoding:       true,
+	"otlp_json":           true,
+	"avro_metrics":        true,
+	"cloudEvents_metrics": true,
+}
+
+// cumulativePoint is implemented by the data points that have a start timestamp.
+type cumulativePoint interface {
+	Attributes() pcommon.Map
+	StartTimestamp() pcommon.Timestamp
+	SetStartTimestamp(pcommon.Timestamp)
+	Timestamp() pcommon.Timestamp
+}
+
+// startTimestamps applies the MissingStartTimestamp policy to the cumulative data points
+// without a start timestamp. It remembers the timestamp of the first data point of every
+// series, which is used as the start timestamp of the series by the first_seen policy.
+// The series without a data point for FirstSeenExpiry are forgotten. Every metrics exporter has its own.
+type startTimestamps struct {
+	mu           sync.Mutex
+	firstSeen    map[string]firstSeenSeries
+	lastEviction time.Time
+	now          func() time.Time
+}
+
+// firstSeenSeries is the first timestamp of a series, and when its last data point was exported.
+type firstSeenSeries struct {
+	start    pcommon.Timestamp
+	lastSeen time.Time
+}
+
+func newStartTimestamps() *startTimestamps {
+	return &startTimestamps{firstSeen: map[string]firstSeenSeries{}, now: time.Now}
+}
+
+// apply returns md with the policy applied. md is left unchanged, and returned as is if none of its
+// cumulative data points is missing its start timestamp.
+func (s *startTimestamps) apply(md pmetric.Metrics, policy string, expiry time.Duration) pmetric.Metrics {
+	if policy != startTimestampFirstSeen && policy != startTimestampDrop {
+		return md
+	}
+	if !missingStartTimestamp(md) {
+		return md
+	}
+	fixed := pmetric.NewMetrics()
+	md.CopyTo(fixed)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if policy == startTimestampFirstSeen {
+		s.evict(now, expiry)
+	}
+	for i := 0; i < fixed.ResourceMetrics().Len(); i++ {
+		rm := fixed.ResourceMetrics().At(i)
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			sm := rm.ScopeMetrics().At(j)
+			for k := 0; k < sm.Metrics().Len(); k++ {
+				m := sm.Metrics().At(k)
+				prefix := seriesPrefix(rm, sm, m)
+				switch m.Type() {
+				case pmetric.MetricTypeSum:
+					if m.Sum().AggregationTemporality() == pmetric.AggregationTemporalityCumulative {
+						fixPoints(s, now, prefix, policy, m.Sum().DataPoints().RemoveIf)
+					}
+				case pmetric.MetricTypeHistogram:
+					if m.Histogram().AggregationTemporality() == pmetric.AggregationTemporalityCumulative {
+						fixPoints(s, now, prefix, policy, m.Histogram().DataPoints().RemoveIf)
+					}
+				case pmetric.MetricTypeExponentialHistogram:
+					if m.ExponentialHistogram().AggregationTemporality() == pmetric.AggregationTemporalityCumulative {
+						fixPoints(s, now, prefix, policy, m.ExponentialHistogram().DataPoints().RemoveIf)
+					}
+				case pmetric.MetricTypeSummary:
+					fixPoints(s, now, prefix, policy, m.Summary().DataPoints().RemoveIf)
+				}
+			}
+		}
+	}
+	return fixed
+}
+
+// evict forgets the series without a data point for expiry, at most once per expiry so that the
+// series are not all walked for every request. It is called with s.mu held.
+func (s *startTimestamps) evict(now time.Time, expiry time.Duration) {
+	if expiry <= 0 || now.Sub(s.lastEviction) < expiry {
+		return
+	}
+	s.lastEviction = now
+	for key, series := range s.firstSeen {
+		if now.Sub(series.lastSeen) >= expiry {
+			delete(s.firstSeen, key)
+		}
+	}
+}
+
+// fixPoints drops or sets the start timestamp of the data points without one. It is called with s.mu held.
+func fixPoints[P cumulativePoint](s *startTimestamps, now time.Time, prefix string, policy string, removeIf func(func(P) bool)) {
+	removeIf(func(dp P) bool {
+		if dp.StartTimestamp() != 0 {
+			return false
+		}
+		if policy == startTimestampDrop {
+			return true
+		}
+		var b strings.Builder
+		b.WriteString(prefix)
+		writeSortedAttributes(&b, dp.Attributes(), nil)
+		key := b.String()
+		series, ok := s.firstSeen[key]
+		if !ok || dp.Timestamp() < series.start {
+			series.start = dp.Timestamp()
+		}
+		series.lastSeen = now
+		s.firstSeen[key] = series
+		dp.SetStartTimestamp(series.start)
+		return false
+	})
+}
+
+// seriesPrefix identifies the series of the data points of m, without their attributes.
+func seriesPrefix(rm pmetric.ResourceMetrics, sm pmetric.ScopeMetrics, m pmetric.Metric) string {
+	var b strings.Builder
+	writeSortedAttributes(&b, rm.Resource().Attributes(), nil)
+	b.WriteString(sm.Scope().Name())
+	b.WriteByte('/')
+	b.WriteString(m.Name())
+	return b.String()
+}
+
+// missingStartTimestamp reports whether a cumulative data point of md has no start timestamp.
+func missingStartTimestamp(md pmetric.Metrics) bool {
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			sm := rm.ScopeMetrics().At(j)
+			for k := 0; k < sm.Metrics().Len(); k++ {
+				m := sm.Metrics().At(k)
+				switch m.Type() {
+				case pmetric.MetricTypeSum:
+					if m.Sum().AggregationTemporality() == pmetric.AggregationTemporalityCumulative {
+						for l := 0; l < m.Sum().DataPoints().Len(); l++ {
+							if m.Sum().DataPoints().At(l).StartTimestamp() == 0 {
+								return true
+							}
+						}
+					}
+				case pmetric.MetricTypeHistogram:
+					if m.Histogram().AggregationTemporality() == pmetric.AggregationTemporalityCumulative {
+						for l := 0; l < m.Histogram().DataPoints().Len(); l++ {
+							if m.Histogram().DataPoints().At(l).StartTimestamp() == 0 {
+								return true
+							}
+						}
+					}
+				case pmetric.MetricTypeExponentialHistogram:
+					if m.ExponentialHistogram().AggregationTemporality() == pmetric.AggregationTemporalityCumulative {
+						for l := 0; l < m.ExponentialHistogram().DataPoints().Len(); l++ {
+							if m.ExponentialHistogram().DataPoints().At(l).StartTimestamp() == 0 {
+								return true
+							}
+						}
+					}
+				case pmetric.MetricTypeSummary:
+					for l := 0; l < m.Summary().DataPoints().Len(); l++ {
+						if m.Summary().DataPoints().At(l).StartTimestamp() == 0 {
+							return true
+						}
+					}
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+)
+
+func cumulativeSum(start, ts pcommon.Timestamp, series ...string) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	sum := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	sum.SetName("requests")
+	sum.SetEmptySum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	for _, s := range series {
+		dp := sum.Sum().DataPoints().AppendEmpty()
+		dp.Attributes().PutStr("series", s)
+		dp.SetStartTimestamp(start)
+		dp.SetTimestamp(ts)
+		dp.SetIntValue(1)
+	}
+	gauge := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().AppendEmpty()
+	gauge.SetName("temperature")
+	gauge.SetEmptyGauge().DataPoints().AppendEmpty().SetTimestamp(ts)
+	return md
+}
+
+// startTimestampsMarshaler applies missing_start_timestamp with the first timestamps of startTimestamps before
+// marshaling, like the metrics exporter.
+type startTimestampsMarshaler struct {
+	MetricsMarshaler
+	startTimestamps *startTimestamps
+}
+
+func newStartTimestampsMarshaler(marshaler MetricsMarshaler) startTimestampsMarshaler {
+	return startTimestampsMarshaler{MetricsMarshaler: marshaler, startTimestamps: newStartTimestamps()}
+}
+
+func (m startTimestampsMarshaler) Marshal(md pmetric.Metrics, config *Config) ([]*sarama.ProducerMessage, error) {
+	return m.MetricsMarshaler.Marshal(prepareMetrics(config, m.MetricsMarshaler, nil, m.startTimestamps, md), config)
+}
+
+func unmarshalMessageMetrics(t *testing.T, message *sarama.ProducerMessage) pmetric.Metrics {
+	bts, err := message.Value.Encode()
+	require.NoError(t, err)
+	md, err := (&pmetric.ProtoUnmarshaler{}).UnmarshalMetrics(bts)
+	require.NoError(t, err)
+	return md
+}
+
+func TestPdataMetricsMarshaler_missing_start_timestamp_first_seen(t *testing.T) {
+	p := newStartTimestampsMarshaler(newPdataMetricsMarshaler(&pmetric.ProtoMarshaler{}, defaultEncoding))
+	config := &Config{Topic: "topic", MissingStartTimestamp: startTimestampFirstSeen}
+
+	first := cumulativeSum(0, 100, "a")
+	messages, err := p.Marshal(first, config)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	sum := unmarshalMessageMetrics(t, messages[0]).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum()
+	assert.Equal(t, pcommon.Timestamp(100), sum.DataPoints().At(0).StartTimestamp())
+	assert.Equal(t, pcommon.Timestamp(0), first.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0).StartTimestamp())
+
+	messages, err = p.Marshal(cumulativeSum(0, 200, "a", "b"), config)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	metrics := unmarshalMessageMetrics(t, messages[0]).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	assert.Equal(t, pcommon.Timestamp(100), metrics.At(0).Sum().DataPoints().At(0).StartTimestamp())
+	assert.Equal(t, pcommon.Timestamp(200), metrics.At(0).Sum().DataPoints().At(1).StartTimestamp())
+	assert.Equal(t, pcommon.Timestamp(0), metrics.At(1).Gauge().DataPoints().At(0).StartTimestamp())
+
+	messages, err = p.Marshal(cumulativeSum(150, 300, "a"), config)
+	require.NoError(t, err)
+	sum = unmarshalMessageMetrics(t, messages[0]).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum()
+	assert.Equal(t, pcommon.Timestamp(150), sum.DataPoints().At(0).StartTimestamp())
+}
+
+func TestPdataMetricsMarshaler_missing_start_timestamp_drop(t *testing.T) {
+	p := newStartTimestampsMarshaler(newPdataMetricsMarshaler(&pmetric.ProtoMarshaler{}, defaultEncoding))
+	config := &Config{Topic: "topic", MissingStartTimestamp: startTimestampDrop}
+
+	md := cumulativeSum(0, 100, "a", "b")
+	md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(1).SetStartTimestamp(50)
+	messages, err := p.Marshal(md, config)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	got := unmarshalMessageMetrics(t, messages[0])
+	assert.Equal(t, 2, got.DataPointCount())
+	dp := got.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	series, _ := dp.Attributes().Get("series")
+	assert.Equal(t, "b", series.Str())
+	assert.Equal(t, 3, md.DataPointCount())
+}
+
+func TestPdataMetricsMarshaler_missing_start_timestamp_keep(t *testing.T) {
+	p := newStartTimestampsMarshaler(newPdataMetricsMarshaler(&pmetric.ProtoMarshaler{}, defaultEncoding))
+	messages, err := p.Marshal(cumulativeSum(0, 100, "a"), &Config{Topic: "topic", MissingStartTimestamp: startTimestampKeep})
+	require.NoError(t, err)
+	sum := unmarshalMessageMetrics(t, messages[0]).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum()
+	assert.Equal(t, pcommon.Timestamp(0), sum.DataPoints().At(0).StartTimestamp())
+}
+
+func TestPdataMetricsMarshaler_missing_start_timestamp_first_seen_expiry(t *testing.T) {
+	p := newStartTimestampsMarshaler(newPdataMetricsMarshaler(&pmetric.ProtoMarshaler{}, defaultEncoding))
+	now := time.Unix(1000, 0)
+	p.startTimestamps.now = func() time.Time { return now }
+	config := &Config{Topic: "topic", MissingStartTimestamp: startTimestampFirstSeen, FirstSeenExpiry: time.Hour}
+	startOf := func(md pmetric.Metrics, series string) pcommon.Timestamp {
+		messages, err := p.Marshal(md, config)
+		require.NoError(t, err)
+		dps := unmarshalMessageMetrics(t, messages[0]).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			if v, _ := dps.At(i).Attributes().Get("series"); v.Str() == series {
+				return dps.At(i).StartTimestamp()
+			}
+		}
+		t.Fatalf("series %s not found", series)
+		return 0
+	}
+
+	assert.Equal(t, pcommon.Timestamp(100), startOf(cumulativeSum(0, 100, "a", "b"), "a"))
+	assert.Len(t, p.startTimestamps.firstSeen, 2)
+
+	now = now.Add(30 * time.Minute)
+	assert.Equal(t, pcommon.Timestamp(100), startOf(cumulativeSum(0, 200, "a"), "a"))
+
+	// b has no data point for an hour and is forgotten, a is kept.
+	now = now.Add(45 * time.Minute)
+	assert.Equal(t, pcommon.Timestamp(100), startOf(cumulativeSum(0, 300, "a"), "a"))
+	assert.Len(t, p.startTimestamps.firstSeen, 1)
+	assert.Equal(t, pcommon.Timestamp(400), startOf(cumulativeSum(0, 400, "b"), "b"))
+
+	// 0 keeps the series.
+	config.FirstSeenExpiry = 0
+	now = now.Add(24 * time.Hour)
+	assert.Equal(t, pcommon.Timestamp(100), startOf(cumulativeSum(0, 500, "a"), "a"))
+	assert.Len(t, p.startTimestamps.firstSeen, 2)
+}
+
+func TestMetricsDataPusher_missing_start_timestamp_per_exporter(t *testing.T) {
+	// The exporters of a factory share its marshalers, but not the first timestamps of their series.
+	marshaler := metricsMarshalers()[defaultEncoding]
+	config := &Config{Topic: "topic", MissingStartTimestamp: startTimestampFirstSeen, Producer: Producer{protoVersion: 2, MaxMessageBytes: 1000 * 1000}}
+	newExporter := func(expectedStarts ...pcommon.Timestamp) *kafkaMetricsProducer {
+		producer := mocks.NewSyncProducer(t, sarama.NewConfig())
+		for _, start := range expectedStarts {
+			start := start
+			producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+				sum := unmarshalMessageMetrics(t, msg).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum()
+				assert.Equal(t, start, sum.DataPoints().At(0).StartTimestamp())
+				return nil
+			})
+		}
+		p := &kafkaMetricsProducer{
+			producer:        producer,
+			marshaler:       marshaler,
+			config:          config,
+			startTimestamps: newStartTimestamps(),
+			logger:          zap.NewNop(),
+		}
+		t.Cleanup(func() {
+			require.NoError(t, p.Close(context.Background()))
+		})
+		return p
+	}
+
+	first := newExporter(100, 100)
+	second := newExporter(200)
+	require.NoError(t, first.metricsDataPusher(context.Background(), cumulativeSum(0, 100, "a")))
+	require.NoError(t, second.metricsDataPusher(context.Background(), cumulativeSum(0, 200, "a")))
+	require.NoError(t, first.metricsDataPusher(context.Background(), cumulativeSum(0, 300, "a")))
+}
@@ -0,0 +1,149 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+)
+
+// startupProbeHeader is the header of the canary messages produced by the startup probe.
+const startupProbeHeader = "otel-startup-probe"
+
+var errStartupProbeTimeout = errors.New("startup probe timed out")
+
+// startupProbe produces a canary message when the exporter starts, with the producer of the exported data, so
+// that the permissions, quotas and topic are checked before the first export. The canary is optionally consumed
+// back to check that it was written.
+type startupProbe struct {
+	config   StartupProbe
+	producer sarama.SyncProducer
+	// newConsumer returns the consumer of the canary, it is only used if consume_back is set.
+	newConsumer func() (sarama.Consumer, error)
+	logger      *zap.Logger
+}
+
+// newStartupProbe returns nil if the startup probe has no topic.
+func newStartupProbe(config Config, producer sarama.SyncProducer, logger *zap.Logger) *startupProbe {
+	if config.StartupProbe.Topic == "" {
+		return nil
+	}
+	return &startupProbe{
+		config:   config.StartupProbe,
+		producer: producer,
+		newConsumer: func() (sarama.Consumer, error) {
+			c := sarama.NewConfig()
+			if config.ProtocolVersion != "" {
+				version, err := sarama.ParseKafkaVersion(config.ProtocolVersion)
+				if err != nil {
+					return nil, err
+				}
+				c.Version = version
+			}
+			if err := ConfigureAuthentication(config.Authentication, c); err != nil {
+				return nil, err
+			}
+			return sarama.NewConsumer(config.Brokers, c)
+		},
+		logger: logger,
+	}
+}
+
+// start runs the probe. Its failure fails the start of the exporter if the probe is strict, and is only
+// logged otherwise.
+func (p *startupProbe) start(ctx context.Context, _ component.Host) error {
+	if p == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+	err := p.run(ctx)
+	if err == nil {
+		p.logger.Info("Kafka startup probe succeeded", zap.String("topic", p.config.Topic))
+		return nil
+	}
+	if p.config.Strict {
+		return fmt.Errorf("kafka startup probe to topic %s failed: %w", p.config.Topic, err)
+	}
+	p.logger.Warn("Kafka startup probe failed", zap.String("topic", p.config.Topic), zap.Error(err))
+	return nil
+}
+
+func (p *startupProbe) run(ctx context.Context) error {
+	value := canaryValue(p.config.PayloadSize)
+	message := &sarama.ProducerMessage{
+		Topic:   p.config.Topic,
+		Value:   sarama.ByteEncoder(value),
+		Headers: []sarama.RecordHeader{{Key: []byte(startupProbeHeader), Value: []byte("true")}},
+	}
+	type produced struct {
+		partition int32
+		offset    int64
+		err       error
+	}
+	done := make(chan produced, 1)
+	go func() {
+		partition, offset, err := p.producer.SendMessage(message)
+		done <- produced{partition: partition, offset: offset, err: err}
+	}()
+	var result produced
+	select {
+	case result = <-done:
+		if result.err != nil {
+			return result.err
+		}
+	case <-ctx.Done():
+		return errStartupProbeTimeout
+	}
+	if !p.config.ConsumeBack {
+		return nil
+	}
+	return p.consume(ctx, result.partition, result.offset, value)
+}
+
+// consume reads the canary back from its partition and offset.
+func (p *startupProbe) consume(ctx context.Context, partition int32, offset int64, value []byte) error {
+	consumer, err := p.newConsumer()
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+	pc, err := consumer.ConsumePartition(p.config.Topic, partition, offset)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+	for {
+		select {
+		case message := <-pc.Messages():
+			if message.Offset == offset {
+				if !bytes.Equal(message.Value, value) {
+					return fmt.Errorf("startup probe consumed another message at offset %d of partition %d", offset, partition)
+				}
+				return nil
+			}
+		case err := <-pc.Errors():
+			return err
+		case <-ctx.Done():
+			return errStartupProbeTimeout
+		}
+	}
+}
+
+// canaryValue returns a payload of size bytes, starting with the current time so that every canary differs.
+func canaryValue(size int) []byte {
+	value := []byte("otel-startup-probe-" + strconv.FormatInt(time.Now().UnixNano(), 10))
+	if len(value) >= size {
+		return value[:size]
+	}
+	return append(value, bytes.Repeat([]byte{'.'}, size-len(value))...)
+}
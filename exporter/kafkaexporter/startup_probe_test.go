@@ -0,0 +1,101 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestStartupProbe(config StartupProbe, producer sarama.SyncProducer, logger *zap.Logger) *startupProbe {
+	config.Topic = "canary"
+	config.PayloadSize = 32
+	config.Timeout = time.Second
+	return newStartupProbe(Config{StartupProbe: config}, producer, logger)
+}
+
+func TestStartupProbe_consume_back(t *testing.T) {
+	var produced []byte
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, "canary", msg.Topic)
+		assert.Equal(t, []sarama.RecordHeader{{Key: []byte("otel-startup-probe"), Value: []byte("true")}}, msg.Headers)
+		var err error
+		produced, err = msg.Value.Encode()
+		return err
+	})
+
+	probe := newTestStartupProbe(StartupProbe{ConsumeBack: true, Strict: true}, producer, zap.NewNop())
+	consumer := mocks.NewConsumer(t, nil)
+	pc := consumer.ExpectConsumePartition("canary", 0, 1)
+	probe.newConsumer = func() (sarama.Consumer, error) {
+		// The canary is only known once produced.
+		pc.YieldMessage(&sarama.ConsumerMessage{Value: produced})
+		return consumer, nil
+	}
+	require.NoError(t, probe.start(context.Background(), componenttest.NewNopHost()))
+	assert.Len(t, produced, 32)
+}
+
+func TestStartupProbe_strict(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageAndFail(sarama.ErrTopicAuthorizationFailed)
+
+	probe := newTestStartupProbe(StartupProbe{Strict: true}, producer, zap.NewNop())
+	err := probe.start(context.Background(), componenttest.NewNopHost())
+	assert.ErrorIs(t, err, sarama.ErrTopicAuthorizationFailed)
+	assert.ErrorContains(t, err, "kafka startup probe to topic canary failed")
+}
+
+func TestStartupProbe_not_strict(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageAndFail(sarama.ErrTopicAuthorizationFailed)
+	zcore, logObserver := observer.New(zapcore.WarnLevel)
+
+	probe := newTestStartupProbe(StartupProbe{}, producer, zap.New(zcore))
+	require.NoError(t, probe.start(context.Background(), componenttest.NewNopHost()))
+	assert.Equal(t, 1, logObserver.FilterMessage("Kafka startup probe failed").Len())
+}
+
+func TestStartupProbe_timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(*sarama.ProducerMessage) error {
+		<-release
+		return nil
+	})
+
+	probe := newTestStartupProbe(StartupProbe{Strict: true}, producer, zap.NewNop())
+	probe.config.Timeout = 10 * time.Millisecond
+	assert.ErrorIs(t, probe.start(context.Background(), componenttest.NewNopHost()), errStartupProbeTimeout)
+}
+
+func TestStartupProbe_consumer_error(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageAndSucceed()
+
+	probe := newTestStartupProbe(StartupProbe{ConsumeBack: true, Strict: true}, producer, zap.NewNop())
+	probe.newConsumer = func() (sarama.Consumer, error) {
+		return nil, errors.New("no brokers")
+	}
+	assert.EqualError(t, probe.start(context.Background(), componenttest.NewNopHost()), "kafka startup probe to topic canary failed: no brokers")
+}
+
+func TestStartupProbe_disabled(t *testing.T) {
+	probe := newStartupProbe(Config{}, nil, zap.NewNop())
+	assert.Nil(t, probe)
+	assert.NoError(t, probe.start(context.Background(), componenttest.NewNopHost()))
+}
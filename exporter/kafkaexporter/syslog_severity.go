@@ -0,0 +1,71 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"strconv"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+const syslogSeverityHeader = "syslog-severity"
+
+// severityRanges are the names of the OTLP severity ranges, each holding four severity numbers from TRACE (1-4)
+// to FATAL (21-24).
+var severityRanges = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+// defaultSyslogSeverities are the RFC 5424 severities of the OTLP severity ranges.
+var defaultSyslogSeverities = map[string]int{
+	"trace": 7, // debug
+	"debug": 7, // debug
+	"info":  6, // informational
+	"warn":  4, // warning
+	"error": 3, // error
+	"fatal": 2, // critical
+}
+
+// syslogSeverity returns the syslog severity of number, overridden by mapping. It returns false if the
+// severity number is unspecified.
+func syslogSeverity(number plog.SeverityNumber, mapping map[string]int) (int, bool) {
+	if number < plog.SeverityNumberTrace || number > plog.SeverityNumberFatal4 {
+		return 0, false
+	}
+	severityRange := severityRanges[(number-plog.SeverityNumberTrace)/4]
+	if severity, ok := mapping[severityRange]; ok {
+		return severity, true
+	}
+	return defaultSyslogSeverities[severityRange], true
+}
+
+// syslogSeverityHeaders returns the syslog-severity header of a message holding a log record of severity number.
+func syslogSeverityHeaders(number plog.SeverityNumber, mapping map[string]int) []sarama.RecordHeader {
+	severity, ok := syslogSeverity(number, mapping)
+	if !ok {
+		return nil
+	}
+	return []sarama.RecordHeader{{Key: []byte(syslogSeverityHeader), Value: []byte(strconv.Itoa(severity))}}
+}
+
+// logsSyslogSeverityHeaders returns the syslog-severity header of a message holding ld, set to the severity of
+// its most severe log record, which is the lowest syslog severity. The header is not set if no log record
+// has a severity number.
+func logsSyslogSeverityHeaders(ld plog.Logs, mapping map[string]int) []sarama.RecordHeader {
+	mostSevere := plog.SeverityNumberUnspecified
+	lowest := 0
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			records := rl.ScopeLogs().At(j).LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				number := records.At(k).SeverityNumber()
+				severity, ok := syslogSeverity(number, mapping)
+				if ok && (mostSevere == plog.SeverityNumberUnspecified || severity < lowest) {
+					mostSevere, lowest = number, severity
+				}
+			}
+		}
+	}
+	return syslogSeverityHeaders(mostSevere, mapping)
+}
@@ -0,0 +1,88 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func TestSyslogSeverity(t *testing.T) {
+	tests := []struct {
+		number   plog.SeverityNumber
+		mapping  map[string]int
+		severity int
+		ok       bool
+	}{
+		{number: plog.SeverityNumberUnspecified},
+		{number: plog.SeverityNumberTrace, severity: 7, ok: true},
+		{number: plog.SeverityNumberDebug4, severity: 7, ok: true},
+		{number: plog.SeverityNumberInfo, severity: 6, ok: true},
+		{number: plog.SeverityNumberInfo2, mapping: map[string]int{"info": 5}, severity: 5, ok: true},
+		{number: plog.SeverityNumberWarn3, severity: 4, ok: true},
+		{number: plog.SeverityNumberError, severity: 3, ok: true},
+		{number: plog.SeverityNumberFatal4, severity: 2, ok: true},
+		{number: plog.SeverityNumberFatal, mapping: map[string]int{"info": 5, "fatal": 0}, severity: 0, ok: true},
+		{number: plog.SeverityNumberFatal4 + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.number.String(), func(t *testing.T) {
+			severity, ok := syslogSeverity(tt.number, tt.mapping)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.severity, severity)
+		})
+	}
+}
+
+func TestLogsMarshaler_syslog_severity(t *testing.T) {
+	ld := plog.NewLogs()
+	records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for _, number := range []plog.SeverityNumber{plog.SeverityNumberInfo, plog.SeverityNumberWarn, plog.SeverityNumberUnspecified} {
+		record := records.AppendEmpty()
+		record.Body().SetStr(number.String())
+		record.SetSeverityNumber(number)
+	}
+	header := func(value string) []sarama.RecordHeader {
+		return []sarama.RecordHeader{{Key: []byte("syslog-severity"), Value: []byte(value)}}
+	}
+
+	config := &Config{Topic: "topic", SyslogSeverity: SyslogSeverity{Enabled: true}, Producer: Producer{MaxMessageBytes: 1000 * 1000}}
+	for _, encoding := range []string{"otlp_proto", "otlp_json"} {
+		t.Run(encoding, func(t *testing.T) {
+			messages, err := logsMarshalers()[encoding].Marshal(ld, config)
+			require.NoError(t, err)
+			require.Len(t, messages, 1)
+			assert.Equal(t, header("4"), messages[0].Headers)
+		})
+	}
+
+	t.Run("raw", func(t *testing.T) {
+		messages, err := logsMarshalers()["raw"].Marshal(ld, config)
+		require.NoError(t, err)
+		require.Len(t, messages, 3)
+		assert.Equal(t, header("6"), messages[0].Headers)
+		assert.Equal(t, header("4"), messages[1].Headers)
+		assert.Empty(t, messages[2].Headers)
+	})
+
+	t.Run("mapping", func(t *testing.T) {
+		config := *config
+		config.SyslogSeverity.Mapping = map[string]int{"info": 1}
+		messages, err := logsMarshalers()["otlp_proto"].Marshal(ld, &config)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.Equal(t, header("1"), messages[0].Headers)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		messages, err := logsMarshalers()["otlp_proto"].Marshal(ld, &Config{Topic: "topic", Producer: Producer{MaxMessageBytes: 1000 * 1000}})
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.Empty(t, messages[0].Headers)
+	})
+}
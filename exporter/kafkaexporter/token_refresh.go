@@ -0,0 +1,45 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"context"
+
+	"github.com/IBM/sarama"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter/internal/kafkaauth"
+)
+
+// tokenRefreshingProducer stops the background refresh of the OAUTHBEARER token of its producer when it is closed.
+type tokenRefreshingProducer struct {
+	sarama.SyncProducer
+	stop func()
+}
+
+// withTokenRefresh refreshes the OAUTHBEARER token of c in the background until producer is closed,
+// so that the connections opened by the long-lived producers never wait for an expired token.
+func withTokenRefresh(c *sarama.Config, producer sarama.SyncProducer) sarama.SyncProducer {
+	provider, ok := c.Net.SASL.TokenProvider.(*kafkaauth.ClientCredentialsTokenProvider)
+	if !ok {
+		return producer
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		provider.Refresh(ctx)
+	}()
+	return &tokenRefreshingProducer{
+		SyncProducer: producer,
+		stop: func() {
+			cancel()
+			<-done
+		},
+	}
+}
+
+func (p *tokenRefreshingProducer) Close() error {
+	p.stop()
+	return p.SyncProducer.Close()
+}
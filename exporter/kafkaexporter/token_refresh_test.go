@@ -0,0 +1,50 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTokenRefresh(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":1}`, requests.Add(1))
+	}))
+	defer server.Close()
+
+	c := sarama.NewConfig()
+	require.NoError(t, ConfigureAuthentication(Authentication{SASL: &SASLConfig{
+		Username:    "client",
+		Password:    "secret",
+		Mechanism:   "OAUTHBEARER",
+		OAuthBearer: OAuthBearerConfig{TokenURL: server.URL, TokenExpiryBuffer: 900 * time.Millisecond},
+	}}, c))
+	producer := withTokenRefresh(c, mocks.NewSyncProducer(t, c))
+	require.IsType(t, &tokenRefreshingProducer{}, producer)
+
+	// The token is refreshed before it expires, while the producer is open.
+	require.Eventually(t, func() bool { return requests.Load() >= 2 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, producer.Close())
+	closed := requests.Load()
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, closed, requests.Load())
+}
+
+func TestWithTokenRefresh_noOAuthBearer(t *testing.T) {
+	c := sarama.NewConfig()
+	producer := mocks.NewSyncProducer(t, c)
+	assert.Same(t, producer, withTokenRefresh(c, producer))
+	require.NoError(t, producer.Close())
+}
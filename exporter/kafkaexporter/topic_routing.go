@@ -0,0 +1,220 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// maxTopicLength is the maximum length of the Kafka topic names.
+const maxTopicLength = 249
+
+// sanitizeTopic returns topic with the characters Kafka does not accept in topic names replaced by '_', and
+// truncated to maxTopicLength. The names "." and ".." are not accepted either, and become "_" and "__".
+func sanitizeTopic(topic string) string {
+	sanitized := []byte(topic)
+	for i, c := range sanitized {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-') {
+			sanitized[i] = '_'
+		}
+	}
+	if len(sanitized) > maxTopicLength {
+		sanitized = sanitized[:maxTopicLength]
+	}
+	if s := string(sanitized); s == "." || s == ".." {
+		return strings.Repeat("_", len(s))
+	}
+	return string(sanitized)
+}
+
+// dynamicTopic returns the topic of a resource given by topic_template, compiled to tmpl, or by
+// topic_from_attribute, or nil if neither is set. With topic_from_attribute, the topic of a resource is the
+// sanitized string representation of its attribute. The topic is "" if the resource does not have the attribute
+// or its value is empty, or if the template has no result for it.
+func dynamicTopic(config *Config, tmpl *template.Template) func(pcommon.Resource) string {
+	if tmpl != nil {
+		return func(resource pcommon.Resource) string {
+			return templateTopicName(tmpl, resource)
+		}
+	}
+	if config.TopicFromAttribute == "" {
+		return nil
+	}
+	return func(resource pcommon.Resource) string {
+		value, ok := resource.Attributes().Get(config.TopicFromAttribute)
+		if !ok || value.AsString() == "" {
+			return ""
+		}
+		return sanitizeTopic(value.AsString())
+	}
+}
+
+// resourceTopic returns the topic of the messages of a resource if topic_template, compiled to tmpl, or
+// topic_from_attribute is set, or nil otherwise. The topic of a resource is its dynamicTopic, or topic if it has
+// none.
+func resourceTopic(config *Config, tmpl *template.Template) func(pcommon.Resource) sarama.Encoder {
+	topic := dynamicTopic(config, tmpl)
+	if topic == nil {
+		return nil
+	}
+	return func(resource pcommon.Resource) sarama.Encoder {
+		if resourceTopic := topic(resource); resourceTopic != "" {
+			return sarama.StringEncoder(resourceTopic)
+		}
+		return sarama.StringEncoder(config.Topic)
+	}
+}
+
+// withTopic returns a copy of config producing to the topic of a group of resources.
+func withTopic(config *Config, topic sarama.Encoder) *Config {
+	topicConfig := *config
+	topicConfig.Topic = string(topic.(sarama.StringEncoder))
+	return &topicConfig
+}
+
+// marshalTracesByTopic marshals td with marshaler, the resources being grouped by their topic before, so that
+// the data of different topics never shares a message. tmpl is the compiled topic_template, if set. The resources
+// of a topic are then grouped by their headers if headers is not nil, and the messages of a group get the headers
+// of its resources. The spans with an error status are also produced to error_spans_topic if set.
+func marshalTracesByTopic(marshaler TracesMarshaler, td ptrace.Traces, config *Config, tmpl *template.Template, headers func(pcommon.Resource) []sarama.RecordHeader) ([]*sarama.ProducerMessage, error) {
+	var messages []*sarama.ProducerMessage
+	if topic := resourceTopic(config, tmpl); topic == nil {
+		var err error
+		if messages, err = marshalTracesWithHeaders(marshaler, td, config, headers); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, group := range splitTracesByResource(td, topic) {
+			groupMessages, err := marshalTracesWithHeaders(marshaler, group.traces, withTopic(config, group.key), headers)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, groupMessages...)
+		}
+	}
+	if config.ErrorSpansTopic == "" {
+		return messages, nil
+	}
+	errorTraces := errorSpans(td)
+	if errorTraces.SpanCount() == 0 {
+		return messages, nil
+	}
+	errorMessages, err := marshalTracesWithHeaders(marshaler, errorTraces, withTopic(config, sarama.StringEncoder(config.ErrorSpansTopic)), headers)
+	if err != nil {
+		return nil, err
+	}
+	return append(messages, errorMessages...), nil
+}
+
+// marshalTracesWithHeaders marshals td with marshaler, the resources being grouped by their headers before if
+// headers is not nil, and adds the headers of the resources of every group to its messages.
+func marshalTracesWithHeaders(marshaler TracesMarshaler, td ptrace.Traces, config *Config, headers func(pcommon.Resource) []sarama.RecordHeader) ([]*sarama.ProducerMessage, error) {
+	if headers == nil {
+		return marshaler.Marshal(td, config)
+	}
+	var messages []*sarama.ProducerMessage
+	for _, group := range splitTracesByResource(td, resourceHeadersKey(headers)) {
+		groupMessages, err := marshaler.Marshal(group.traces, config)
+		if err != nil {
+			return nil, err
+		}
+		groupHeaders, _ := group.key.(headersKey)
+		addHeaders(groupMessages, groupHeaders)
+		messages = append(messages, groupMessages...)
+	}
+	return messages, nil
+}
+
+// errorSpans returns a copy of td holding only its spans with an error status.
+func errorSpans(td ptrace.Traces) ptrace.Traces {
+	errorTraces := ptrace.NewTraces()
+	td.CopyTo(errorTraces)
+	errorTraces.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			ss.Spans().RemoveIf(func(span ptrace.Span) bool {
+				return span.Status().Code() != ptrace.StatusCodeError
+			})
+			return ss.Spans().Len() == 0
+		})
+		return rs.ScopeSpans().Len() == 0
+	})
+	return errorTraces
+}
+
+// marshalMetricsByTopic is the marshalTracesByTopic of metrics.
+func marshalMetricsByTopic(marshaler MetricsMarshaler, md pmetric.Metrics, config *Config, tmpl *template.Template, headers func(pcommon.Resource) []sarama.RecordHeader) ([]*sarama.ProducerMessage, error) {
+	topic := resourceTopic(config, tmpl)
+	if topic == nil {
+		return marshalMetricsWithHeaders(marshaler, md, config, headers)
+	}
+	var messages []*sarama.ProducerMessage
+	for _, group := range splitMetricsByResource(md, topic) {
+		groupMessages, err := marshalMetricsWithHeaders(marshaler, group.metrics, withTopic(config, group.key), headers)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, groupMessages...)
+	}
+	return messages, nil
+}
+
+// marshalMetricsWithHeaders is the marshalTracesWithHeaders of metrics.
+func marshalMetricsWithHeaders(marshaler MetricsMarshaler, md pmetric.Metrics, config *Config, headers func(pcommon.Resource) []sarama.RecordHeader) ([]*sarama.ProducerMessage, error) {
+	if headers == nil {
+		return marshaler.Marshal(md, config)
+	}
+	var messages []*sarama.ProducerMessage
+	for _, group := range splitMetricsByResource(md, resourceHeadersKey(headers)) {
+		groupMessages, err := marshaler.Marshal(group.metrics, config)
+		if err != nil {
+			return nil, err
+		}
+		groupHeaders, _ := group.key.(headersKey)
+		addHeaders(groupMessages, groupHeaders)
+		messages = append(messages, groupMessages...)
+	}
+	return messages, nil
+}
+
+// marshalLogsByTopic is the marshalTracesByTopic of logs.
+func marshalLogsByTopic(marshaler LogsMarshaler, ld plog.Logs, config *Config, tmpl *template.Template, headers func(pcommon.Resource) []sarama.RecordHeader) ([]*sarama.ProducerMessage, error) {
+	topic := resourceTopic(config, tmpl)
+	if topic == nil {
+		return marshalLogsWithHeaders(marshaler, ld, config, headers)
+	}
+	var messages []*sarama.ProducerMessage
+	for _, group := range splitLogsByResource(ld, topic) {
+		groupMessages, err := marshalLogsWithHeaders(marshaler, group.logs, withTopic(config, group.key), headers)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, groupMessages...)
+	}
+	return messages, nil
+}
+
+// marshalLogsWithHeaders is the marshalTracesWithHeaders of logs.
+func marshalLogsWithHeaders(marshaler LogsMarshaler, ld plog.Logs, config *Config, headers func(pcommon.Resource) []sarama.RecordHeader) ([]*sarama.ProducerMessage, error) {
+	if headers == nil {
+		return marshaler.Marshal(ld, config)
+	}
+	var messages []*sarama.ProducerMessage
+	for _, group := range splitLogsByResource(ld, resourceHeadersKey(headers)) {
+		groupMessages, err := marshaler.Marshal(group.logs, config)
+		if err != nil {
+			return nil, err
+		}
+		groupHeaders, _ := group.key.(headersKey)
+		addHeaders(groupMessages, groupHeaders)
+		messages = append(messages, groupMessages...)
+	}
+	return messages, nil
+}
@@ -0,0 +1,185 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+func TestSanitizeTopic(t *testing.T) {
+	tests := []struct {
+		topic    string
+		expected string
+	}{
+		{topic: "traces-acme", expected: "traces-acme"},
+		{topic: "Tenant_1.eu-west", expected: "Tenant_1.eu-west"},
+		{topic: "acme corp/eu", expected: "acme_corp_eu"},
+		{topic: "café", expected: "caf__"},
+		{topic: ".", expected: "_"},
+		{topic: "..", expected: "__"},
+		{topic: "...", expected: "..."},
+		{topic: strings.Repeat("a", 300), expected: strings.Repeat("a", maxTopicLength)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.topic, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sanitizeTopic(tt.topic))
+			// Sanitizing is deterministic, and a sanitized topic is left as is.
+			assert.Equal(t, tt.expected, sanitizeTopic(sanitizeTopic(tt.topic)))
+		})
+	}
+}
+
+func messageTopics(messages []*sarama.ProducerMessage) []string {
+	topics := make([]string, len(messages))
+	for i, message := range messages {
+		topics[i] = message.Topic
+	}
+	return topics
+}
+
+func TestMarshalByTopic(t *testing.T) {
+	config := &Config{Topic: "default", TopicFromAttribute: "tenant", Producer: Producer{MaxMessageBytes: 1000 * 1000}}
+	expected := []string{"acme", "globex", "default"}
+
+	messages, err := marshalTracesByTopic(tracesMarshalers()["otlp_proto"], partitionKeyTraces(), config, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, messageTopics(messages))
+
+	messages, err = marshalMetricsByTopic(metricsMarshalers()["otlp_json"], partitionKeyMetrics(), config, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, messageTopics(messages))
+
+	messages, err = marshalLogsByTopic(logsMarshalers()["otlp_proto"], partitionKeyLogs(), config, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, messageTopics(messages))
+	assert.Equal(t, "default", config.Topic, "the configuration is left unchanged")
+
+	config.TopicFromAttribute = ""
+	messages, err = marshalTracesByTopic(tracesMarshalers()["otlp_proto"], partitionKeyTraces(), config, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default"}, messageTopics(messages))
+}
+
+func TestMarshalTracesByTopic_errorSpans(t *testing.T) {
+	td := ptrace.NewTraces()
+	spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	for _, code := range []ptrace.StatusCode{ptrace.StatusCodeOk, ptrace.StatusCodeError, ptrace.StatusCodeUnset, ptrace.StatusCodeError} {
+		span := spans.AppendEmpty()
+		span.SetName(code.String())
+		span.Status().SetCode(code)
+	}
+	config := &Config{Topic: "spans", ErrorSpansTopic: "errors", Producer: Producer{MaxMessageBytes: 1000 * 1000}}
+
+	messages, err := marshalTracesByTopic(tracesMarshalers()["otlp_proto"], td, config, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"spans", "errors"}, messageTopics(messages))
+	names := func(message *sarama.ProducerMessage) []string {
+		value, err := message.Value.Encode()
+		require.NoError(t, err)
+		decoded, err := (&ptrace.ProtoUnmarshaler{}).UnmarshalTraces(value)
+		require.NoError(t, err)
+		var names []string
+		decodedSpans := decoded.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+		for i := 0; i < decodedSpans.Len(); i++ {
+			names = append(names, decodedSpans.At(i).Name())
+		}
+		return names
+	}
+	assert.Equal(t, []string{"Ok", "Error", "Unset", "Error"}, names(messages[0]))
+	assert.Equal(t, []string{"Error", "Error"}, names(messages[1]))
+	assert.Equal(t, 4, td.SpanCount(), "the traces are left unchanged")
+
+	// The traces without error spans are only produced to their topic.
+	spans.RemoveIf(func(span ptrace.Span) bool { return span.Status().Code() == ptrace.StatusCodeError })
+	messages, err = marshalTracesByTopic(tracesMarshalers()["otlp_proto"], td, config, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"spans"}, messageTopics(messages))
+}
+
+func TestMarshalByTopic_split(t *testing.T) {
+	// Every topic is split to fit max_message_bytes on its own, so that no message mixes topics.
+	ld := plog.NewLogs()
+	for i := 0; i < 40; i++ {
+		rl := ld.ResourceLogs().AppendEmpty()
+		tenant := []string{"acme corp", "globex"}[i%2]
+		rl.Resource().Attributes().PutStr("tenant", tenant)
+		rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr(tenant + strings.Repeat("x", 100))
+	}
+	config := &Config{Topic: "default", TopicFromAttribute: "tenant", Producer: Producer{MaxMessageBytes: 1000}}
+	messages, err := marshalLogsByTopic(logsMarshalers()["otlp_proto"], ld, config, nil, nil)
+	require.NoError(t, err)
+	require.Greater(t, len(messages), 2)
+
+	unmarshaler := &plog.ProtoUnmarshaler{}
+	records := 0
+	for _, message := range messages {
+		value, err := message.Value.Encode()
+		require.NoError(t, err)
+		logs, err := unmarshaler.UnmarshalLogs(value)
+		require.NoError(t, err)
+		for i := 0; i < logs.ResourceLogs().Len(); i++ {
+			tenant, _ := logs.ResourceLogs().At(i).Resource().Attributes().Get("tenant")
+			assert.Equal(t, sanitizeTopic(tenant.Str()), message.Topic)
+		}
+		records += logs.LogRecordCount()
+	}
+	assert.Equal(t, ld.LogRecordCount(), records)
+}
+
+func TestTracesPusher_topicFromAttribute(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, sarama.NewConfig())
+	var topics []string
+	for i := 0; i < 2; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			topics = append(topics, msg.Topic)
+			return nil
+		})
+	}
+	p := kafkaTracesProducer{
+		producer:  producer,
+		marshaler: newPdataTracesMarshaler(&ptrace.ProtoMarshaler{}, defaultEncoding),
+		config:    &Config{Topic: "otlp_spans", TopicFromAttribute: "service.name", Producer: Producer{protoVersion: 2, MaxMessageBytes: 1000 * 1000}},
+		logger:    zap.NewNop(),
+	}
+	t.Cleanup(func() {
+		require.NoError(t, p.Close(context.Background()))
+	})
+
+	td := ptrace.NewTraces()
+	for _, service := range []string{"checkout", "cart/v2"} {
+		rs := td.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("service.name", service)
+		rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	}
+	require.NoError(t, p.tracesPusher(context.Background(), td))
+	assert.Equal(t, []string{"checkout", "cart_v2"}, topics)
+}
+
+func TestEstimateTraces_topicFromAttribute(t *testing.T) {
+	td := ptrace.NewTraces()
+	for _, tenant := range []string{"acme", "globex"} {
+		rs := td.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("tenant", tenant)
+		rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	}
+	cfg := *createDefaultConfig().(*Config)
+	messages, _, err := EstimateTraces(cfg, td)
+	require.NoError(t, err)
+	assert.Equal(t, 1, messages)
+
+	cfg.TopicFromAttribute = "tenant"
+	messages, _, err = EstimateTraces(cfg, td)
+	require.NoError(t, err)
+	assert.Equal(t, 2, messages)
+}
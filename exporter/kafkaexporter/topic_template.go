@@ -0,0 +1,81 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// topicTemplateData is the data the topic template is executed with for a resource.
+type topicTemplateData struct {
+	Attributes topicTemplateAttributes
+}
+
+// topicTemplateAttributes exposes the attributes of a resource to the topic template, and records whether the
+// template used an attribute the resource does not have.
+type topicTemplateAttributes struct {
+	attributes pcommon.Map
+	missing    *bool
+}
+
+// GetStr returns the string representation of the value of the attribute key, or "" if the resource does not
+// have it or its value is empty.
+func (a topicTemplateAttributes) GetStr(key string) string {
+	value, ok := a.attributes.Get(key)
+	if !ok || value.AsString() == "" {
+		*a.missing = true
+		return ""
+	}
+	return value.AsString()
+}
+
+// compileTopicTemplate returns the compiled template of text, or nil if text is empty. The template is executed
+// once with a resource without attributes, so that the fields and methods it cannot use fail it.
+func compileTopicTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("topic_template").Parse(text)
+	if err == nil {
+		_, _, err = executeTopicTemplate(tmpl, pcommon.NewResource())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("topic_template has to be a valid Go template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// executeTopicTemplate returns the result of tmpl for resource, and whether it used a missing attribute.
+func executeTopicTemplate(tmpl *template.Template, resource pcommon.Resource) (string, bool, error) {
+	missing := false
+	var b strings.Builder
+	err := tmpl.Execute(&b, topicTemplateData{Attributes: topicTemplateAttributes{attributes: resource.Attributes(), missing: &missing}})
+	return b.String(), missing, err
+}
+
+// templateTopicName returns the sanitized result of the compiled topic_template tmpl for resource, or "" if the
+// template uses an attribute the resource does not have, fails, or has an empty result.
+func templateTopicName(tmpl *template.Template, resource pcommon.Resource) string {
+	resourceTopic, missing, err := executeTopicTemplate(tmpl, resource)
+	if missing || err != nil || resourceTopic == "" {
+		return ""
+	}
+	return sanitizeTopic(resourceTopic)
+}
+
+// templateTopic returns the topic of the messages of a resource with the compiled topic_template tmpl: its
+// templateTopicName, or topic if it has none.
+func templateTopic(tmpl *template.Template, topic string) func(pcommon.Resource) sarama.Encoder {
+	return func(resource pcommon.Resource) sarama.Encoder {
+		if resourceTopic := templateTopicName(tmpl, resource); resourceTopic != "" {
+			return sarama.StringEncoder(resourceTopic)
+		}
+		return sarama.StringEncoder(topic)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"text/template"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const testTopicTemplate = `traces-{{.Attributes.GetStr "deployment.environment"}}-{{.Attributes.GetStr "service.namespace"}}`
+
+func compileTestTopicTemplate(t *testing.T) *template.Template {
+	tmpl, err := compileTopicTemplate(testTopicTemplate)
+	require.NoError(t, err)
+	return tmpl
+}
+
+func TestTemplateTopic(t *testing.T) {
+	topic := templateTopic(compileTestTopicTemplate(t), "otlp_spans")
+	resource := func(attributes map[string]any) pcommon.Resource {
+		r := pcommon.NewResource()
+		require.NoError(t, r.Attributes().FromRaw(attributes))
+		return r
+	}
+	tests := []struct {
+		name       string
+		attributes map[string]any
+		expected   string
+	}{
+		{name: "attributes", attributes: map[string]any{"deployment.environment": "prod", "service.namespace": "shop"}, expected: "traces-prod-shop"},
+		{name: "sanitized", attributes: map[string]any{"deployment.environment": "prod eu", "service.namespace": "shop/v2"}, expected: "traces-prod_eu-shop_v2"},
+		{name: "not a string", attributes: map[string]any{"deployment.environment": 42, "service.namespace": true}, expected: "traces-42-true"},
+		{name: "missing attribute", attributes: map[string]any{"deployment.environment": "prod"}, expected: "otlp_spans"},
+		{name: "empty attribute", attributes: map[string]any{"deployment.environment": "prod", "service.namespace": ""}, expected: "otlp_spans"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, string(topic(resource(tt.attributes)).(sarama.StringEncoder)))
+		})
+	}
+}
+
+func TestTemplateTopic_concurrent(t *testing.T) {
+	topic := templateTopic(compileTestTopicTemplate(t), "otlp_spans")
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resource := pcommon.NewResource()
+			resource.Attributes().PutInt("deployment.environment", int64(i))
+			expected := "otlp_spans"
+			if i%2 == 0 {
+				resource.Attributes().PutStr("service.namespace", "shop")
+				expected = "traces-" + strconv.Itoa(i) + "-shop"
+			}
+			for j := 0; j < 100; j++ {
+				assert.Equal(t, expected, string(topic(resource).(sarama.StringEncoder)))
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestCompileTopicTemplate(t *testing.T) {
+	tmpl, err := compileTopicTemplate("")
+	require.NoError(t, err)
+	assert.Nil(t, tmpl)
+
+	tmpl, err = compileTopicTemplate(testTopicTemplate)
+	require.NoError(t, err)
+	assert.NotNil(t, tmpl)
+
+	_, err = compileTopicTemplate(`traces-{{.Attributes.GetStr "env"`)
+	assert.ErrorContains(t, err, "topic_template has to be a valid Go template")
+	// The fields and methods the template cannot use are reported before any resource is exported.
+	_, err = compileTopicTemplate(`traces-{{.Resource.Name}}`)
+	assert.ErrorContains(t, err, "topic_template has to be a valid Go template")
+}
+
+func TestMarshalTracesByTopic_template(t *testing.T) {
+	td := ptrace.NewTraces()
+	for _, environment := range []string{"prod", "staging", ""} {
+		rs := td.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("service.namespace", "shop")
+		if environment != "" {
+			rs.Resource().Attributes().PutStr("deployment.environment", environment)
+		}
+		rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	}
+	config := &Config{Topic: "otlp_spans", TopicTemplate: testTopicTemplate, Producer: Producer{MaxMessageBytes: 1000 * 1000}}
+	messages, err := marshalTracesByTopic(tracesMarshalers()["otlp_proto"], td, config, compileTestTopicTemplate(t), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"traces-prod-shop", "traces-staging-shop", "otlp_spans"}, messageTopics(messages))
+}
+
+func TestNewExporter_err_topic_template(t *testing.T) {
+	c := Config{Encoding: defaultEncoding, TopicTemplate: `traces-{{.Attributes.GetStr}}`}
+	texp, err := newTracesExporter(c, exportertest.NewNopCreateSettings(), tracesMarshalers())
+	assert.ErrorContains(t, err, "topic_template has to be a valid Go template")
+	assert.Nil(t, texp)
+	mexp, err := newMetricsExporter(c, exportertest.NewNopCreateSettings(), metricsMarshalers())
+	assert.ErrorContains(t, err, "topic_template has to be a valid Go template")
+	assert.Nil(t, mexp)
+	lexp, err := newLogsExporter(c, exportertest.NewNopCreateSettings(), logsMarshalers())
+	assert.ErrorContains(t, err, "topic_template has to be a valid Go template")
+	assert.Nil(t, lexp)
+}
+
+func TestValidate_topic_template(t *testing.T) {
+	config := &Config{
+		TopicTemplate:      testTopicTemplate,
+		TopicFromAttribute: "tenant",
+		Producer: Producer{
+			Compression: "none",
+		},
+	}
+	assert.EqualError(t, config.Validate(), "topic_template and topic_from_attribute cannot both be set")
+
+	config.TopicFromAttribute = ""
+	assert.NoError(t, config.Validate())
+
+	config.TopicTemplate = `{{.Attributes.Get "tenant"}}`
+	assert.ErrorContains(t, config.Validate(), "topic_template has to be a valid Go template")
+}
@@ -0,0 +1,57 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+const (
+	traceIDHeader = "trace-id"
+	spanIDHeader  = "span-id"
+)
+
+// logsTraceContextHeaders returns the trace context headers of a message holding ld. The trace-id header is
+// only set if all the log records have the same trace id, and the span-id header if they have the same span id too.
+func logsTraceContextHeaders(ld plog.Logs) []sarama.RecordHeader {
+	var traceID pcommon.TraceID
+	var spanID pcommon.SpanID
+	first, sameSpan := true, true
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			records := rl.ScopeLogs().At(j).LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				record := records.At(k)
+				if first {
+					traceID, spanID, first = record.TraceID(), record.SpanID(), false
+					continue
+				}
+				if record.TraceID() != traceID {
+					return nil
+				}
+				sameSpan = sameSpan && record.SpanID() == spanID
+			}
+		}
+	}
+	if !sameSpan {
+		spanID = pcommon.NewSpanIDEmpty()
+	}
+	return traceContextHeaders(traceID, spanID)
+}
+
+// traceContextHeaders returns the trace-id header, and the span-id header, holding the hex-encoded ids
+// that are not empty. The span id is ignored without a trace id.
+func traceContextHeaders(traceID pcommon.TraceID, spanID pcommon.SpanID) []sarama.RecordHeader {
+	if traceID.IsEmpty() {
+		return nil
+	}
+	headers := []sarama.RecordHeader{{Key: []byte(traceIDHeader), Value: []byte(traceID.String())}}
+	if !spanID.IsEmpty() {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(spanIDHeader), Value: []byte(spanID.String())})
+	}
+	return headers
+}
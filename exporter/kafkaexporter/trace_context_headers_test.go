@@ -0,0 +1,63 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+var (
+	testTraceID = pcommon.TraceID([16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	testSpanID  = pcommon.SpanID([8]byte{1, 2, 3, 4, 5, 6, 7, 8})
+)
+
+func TestLogsMarshaler_trace_context_headers(t *testing.T) {
+	ld := plog.NewLogs()
+	record := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	record.Body().SetStr("record")
+	record.SetTraceID(testTraceID)
+	record.SetSpanID(testSpanID)
+
+	config := &Config{Topic: "topic", TraceContextHeaders: true, Producer: Producer{MaxMessageBytes: 1000 * 1000}}
+	for _, encoding := range []string{"otlp_proto", "otlp_json", "raw"} {
+		t.Run(encoding, func(t *testing.T) {
+			messages, err := logsMarshalers()[encoding].Marshal(ld, config)
+			require.NoError(t, err)
+			require.Len(t, messages, 1)
+			assert.Equal(t, []sarama.RecordHeader{
+				{Key: []byte("trace-id"), Value: []byte("0102030405060708090a0b0c0d0e0f10")},
+				{Key: []byte("span-id"), Value: []byte("0102030405060708")},
+			}, messages[0].Headers)
+		})
+	}
+
+	config.TraceContextHeaders = false
+	messages, err := logsMarshalers()["otlp_proto"].Marshal(ld, config)
+	require.NoError(t, err)
+	assert.Empty(t, messages[0].Headers)
+}
+
+func TestLogsTraceContextHeaders(t *testing.T) {
+	ld := plog.NewLogs()
+	records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	records.AppendEmpty().SetTraceID(testTraceID)
+	records.At(0).SetSpanID(testSpanID)
+	records.AppendEmpty().SetTraceID(testTraceID)
+
+	// The records of a trace with different spans only share the trace id.
+	assert.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("trace-id"), Value: []byte("0102030405060708090a0b0c0d0e0f10")},
+	}, logsTraceContextHeaders(ld))
+
+	records.AppendEmpty()
+	assert.Nil(t, logsTraceContextHeaders(ld))
+	assert.Nil(t, logsTraceContextHeaders(plog.NewLogs()))
+	assert.Nil(t, traceContextHeaders(pcommon.NewTraceIDEmpty(), testSpanID))
+}
@@ -0,0 +1,68 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// splitTracesByID groups the spans of td by trace ID, in the order the traces first appear, keeping their
+// resource and scope. The spans of a trace are grouped even if they belong to different resource spans.
+// td is returned as is if all its spans belong to the same trace.
+func splitTracesByID(td ptrace.Traces) []keyedTraces {
+	var traces []keyedTraces
+	indexes := map[pcommon.TraceID]int{}
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		sss := rss.At(i).ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			spans := sss.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				traceID := spans.At(k).TraceID()
+				if _, ok := indexes[traceID]; !ok {
+					indexes[traceID] = len(traces)
+					traces = append(traces, keyedTraces{key: sarama.ByteEncoder(traceID.String()), traces: ptrace.NewTraces()})
+				}
+			}
+		}
+	}
+	switch len(traces) {
+	case 0:
+		return []keyedTraces{{traces: td}}
+	case 1:
+		traces[0].traces = td
+		return traces
+	}
+	for i := 0; i < rss.Len(); i++ {
+		rs := rss.At(i)
+		// The resource and scope spans of every trace within the current resource and scope spans.
+		resources := map[pcommon.TraceID]ptrace.ResourceSpans{}
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			scopes := map[pcommon.TraceID]ptrace.ScopeSpans{}
+			for k := 0; k < ss.Spans().Len(); k++ {
+				span := ss.Spans().At(k)
+				traceID := span.TraceID()
+				scope, ok := scopes[traceID]
+				if !ok {
+					resource, ok := resources[traceID]
+					if !ok {
+						resource = traces[indexes[traceID]].traces.ResourceSpans().AppendEmpty()
+						rs.Resource().CopyTo(resource.Resource())
+						resource.SetSchemaUrl(rs.SchemaUrl())
+						resources[traceID] = resource
+					}
+					scope = resource.ScopeSpans().AppendEmpty()
+					ss.Scope().CopyTo(scope.Scope())
+					scope.SetSchemaUrl(ss.SchemaUrl())
+					scopes[traceID] = scope
+				}
+				span.CopyTo(scope.Spans().AppendEmpty())
+			}
+		}
+	}
+	return traces
+}
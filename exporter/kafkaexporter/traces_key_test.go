@@ -0,0 +1,119 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+var (
+	traceIDA = pcommon.TraceID([16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	traceIDB = pcommon.TraceID([16]byte{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1})
+)
+
+// appendTraceResource appends a resource named service holding one span per trace ID.
+func appendTraceResource(td ptrace.Traces, service string, traceIDs ...pcommon.TraceID) {
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", service)
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("scope")
+	for _, traceID := range traceIDs {
+		span := ss.Spans().AppendEmpty()
+		span.SetTraceID(traceID)
+		span.SetName(service)
+	}
+}
+
+func TestSplitTracesByID(t *testing.T) {
+	td := ptrace.NewTraces()
+	appendTraceResource(td, "a", traceIDA, traceIDA)
+	traces := splitTracesByID(td)
+	require.Len(t, traces, 1)
+	assert.Equal(t, td, traces[0].traces)
+	assert.Equal(t, sarama.ByteEncoder("0102030405060708090a0b0c0d0e0f10"), traces[0].key)
+
+	appendTraceResource(td, "b", traceIDB, traceIDA)
+	traces = splitTracesByID(td)
+	require.Len(t, traces, 2)
+
+	assert.Equal(t, sarama.ByteEncoder(traceIDA.String()), traces[0].key)
+	assert.Equal(t, 3, traces[0].traces.SpanCount())
+	require.Equal(t, 2, traces[0].traces.ResourceSpans().Len())
+	second := traces[0].traces.ResourceSpans().At(1)
+	service, _ := second.Resource().Attributes().Get("service.name")
+	assert.Equal(t, "b", service.Str())
+	assert.Equal(t, "scope", second.ScopeSpans().At(0).Scope().Name())
+
+	assert.Equal(t, sarama.ByteEncoder(traceIDB.String()), traces[1].key)
+	assert.Equal(t, 1, traces[1].traces.SpanCount())
+	assert.Equal(t, 4, td.SpanCount())
+}
+
+func TestMarshalTraces_partitionTracesByID(t *testing.T) {
+	td := ptrace.NewTraces()
+	appendTraceResource(td, "a", traceIDA, traceIDB)
+	appendTraceResource(td, "b", traceIDA)
+	config := &Config{
+		Topic:               "spans",
+		PartitionTracesByID: true,
+		Producer: Producer{
+			MaxMessageBytes: 1000000,
+		},
+	}
+
+	messages, err := newPdataTracesMarshaler(&ptrace.ProtoMarshaler{}, defaultEncoding).Marshal(td, config)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, sarama.ByteEncoder(traceIDA.String()), messages[0].Key)
+	assert.Equal(t, sarama.ByteEncoder(traceIDB.String()), messages[1].Key)
+	value, err := messages[0].Value.Encode()
+	require.NoError(t, err)
+	unmarshaled, err := (&ptrace.ProtoUnmarshaler{}).UnmarshalTraces(value)
+	require.NoError(t, err)
+	assert.Equal(t, 2, unmarshaled.SpanCount())
+
+	config.PartitionTracesByID = false
+	messages, err = newPdataTracesMarshaler(&ptrace.ProtoMarshaler{}, defaultEncoding).Marshal(td, config)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Nil(t, messages[0].Key)
+}
+
+func TestMarshalTraces_partitionTracesByID_maxMessageBytes(t *testing.T) {
+	td := ptrace.NewTraces()
+	appendTraceResource(td, "a", traceIDB)
+	for i := 0; i < 10; i++ {
+		appendTraceResource(td, strings.Repeat("a", 100), traceIDA)
+	}
+	config := &Config{
+		Topic:               "spans",
+		PartitionTracesByID: true,
+		Producer: Producer{
+			MaxMessageBytes: 500,
+		},
+	}
+
+	messages, err := newPdataTracesMarshaler(&ptrace.ProtoMarshaler{}, defaultEncoding).Marshal(td, config)
+	require.NoError(t, err)
+	require.Greater(t, len(messages), 2)
+	assert.Equal(t, sarama.ByteEncoder(traceIDB.String()), messages[0].Key)
+	spans := 0
+	for _, message := range messages[1:] {
+		assert.Equal(t, sarama.ByteEncoder(traceIDA.String()), message.Key)
+		assert.LessOrEqual(t, message.ByteSize(config.Producer.protoVersion), config.Producer.MaxMessageBytes)
+		value, err := message.Value.Encode()
+		require.NoError(t, err)
+		unmarshaled, err := (&ptrace.ProtoUnmarshaler{}).UnmarshalTraces(value)
+		require.NoError(t, err)
+		spans += unmarshaled.SpanCount()
+	}
+	assert.Equal(t, 10, spans)
+}
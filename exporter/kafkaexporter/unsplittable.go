@@ -0,0 +1,271 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"unicode/utf8"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	unsplittableError      = "error"
+	unsplittableDrop       = "drop"
+	unsplittableTruncate   = "truncate"
+	unsplittableDeadLetter = "deadletter"
+)
+
+// unsplittableHandler applies an OnUnsplittable policy to the items that exceed maxBytes once
+// marshaled on their own, together with their resource and scope.
+type unsplittableHandler[T any] struct {
+	policy      string
+	maxBytes    int
+	size        func(T) int
+	deadLetters []T
+	err         error
+}
+
+// keep reports whether the item marshaled alone as single is kept. With the truncate policy,
+// steps shrink the item in single until it fits, and update copies it back to the original item.
+func (h *unsplittableHandler[T]) keep(single T, update func(), steps ...func() bool) bool {
+	if h.err != nil || h.size(single) <= h.maxBytes {
+		return true
+	}
+	switch h.policy {
+	case unsplittableDrop:
+		return false
+	case unsplittableTruncate:
+		for _, step := range steps {
+			for h.size(single) > h.maxBytes {
+				if !step() {
+					break
+				}
+			}
+		}
+		if h.size(single) > h.maxBytes {
+			return false
+		}
+		update()
+		return true
+	case unsplittableDeadLetter:
+		h.deadLetters = append(h.deadLetters, single)
+		return false
+	default:
+		h.err = errSingleKafkaProducerMessageSizeOverMaxMsgByte
+		return true
+	}
+}
+
+// clearAttributes removes all the attributes, it reports false if there were none.
+func clearAttributes(attrs pcommon.Map) func() bool {
+	return func() bool {
+		if attrs.Len() == 0 {
+			return false
+		}
+		attrs.Clear()
+		return true
+	}
+}
+
+func clearExemplars(exemplars pmetric.ExemplarSlice) func() bool {
+	return func() bool {
+		if exemplars.Len() == 0 {
+			return false
+		}
+		exemplars.RemoveIf(func(pmetric.Exemplar) bool { return true })
+		return true
+	}
+}
+
+// halveBody halves the string or bytes body of a log record, it reports false once the body is empty.
+func halveBody(body pcommon.Value) func() bool {
+	return func() bool {
+		switch body.Type() {
+		case pcommon.ValueTypeStr:
+			s := body.Str()
+			if s == "" {
+				return false
+			}
+			n := len(s) / 2
+			for n > 0 && !utf8.RuneStart(s[n]) {
+				n--
+			}
+			body.SetStr(s[:n])
+			return true
+		case pcommon.ValueTypeBytes:
+			b := body.Bytes().AsRaw()
+			if len(b) == 0 {
+				return false
+			}
+			body.SetEmptyBytes().FromRaw(b[:len(b)/2])
+			return true
+		}
+		return false
+	}
+}
+
+// handleUnsplittable applies policy to the log records of ld exceeding maxBytes. Dropped and
+// dead-lettered records are removed from ld, the dead-lettered ones are returned.
+// The log record bodies are truncated first, then their attributes are removed.
+func (p pdataLogsMarshaler) handleUnsplittable(ld plog.Logs, maxBytes int, policy string) ([]plog.Logs, error) {
+	h := &unsplittableHandler[plog.Logs]{
+		policy:   policy,
+		maxBytes: maxBytes,
+		size:     func(single plog.Logs) int { return logRecordsBytes(single, p) },
+	}
+	ld.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+		rl.ScopeLogs().RemoveIf(func(sl plog.ScopeLogs) bool {
+			sl.LogRecords().RemoveIf(func(lr plog.LogRecord) bool {
+				single := plog.NewLogs()
+				srl := single.ResourceLogs().AppendEmpty()
+				rl.Resource().CopyTo(srl.Resource())
+				srl.SetSchemaUrl(rl.SchemaUrl())
+				ssl := srl.ScopeLogs().AppendEmpty()
+				sl.Scope().CopyTo(ssl.Scope())
+				ssl.SetSchemaUrl(sl.SchemaUrl())
+				record := ssl.LogRecords().AppendEmpty()
+				lr.CopyTo(record)
+				return !h.keep(single, func() { record.CopyTo(lr) },
+					halveBody(record.Body()),
+					clearAttributes(record.Attributes()))
+			})
+			return sl.LogRecords().Len() == 0
+		})
+		return rl.ScopeLogs().Len() == 0
+	})
+	return h.deadLetters, h.err
+}
+
+// handleUnsplittable applies policy to the spans of td exceeding maxBytes. Dropped and
+// dead-lettered spans are removed from td, the dead-lettered ones are returned.
+// The span events are removed first, then the links, then the attributes.
+func (p pdataTracesMarshaler) handleUnsplittable(td ptrace.Traces, maxBytes int, policy string) ([]ptrace.Traces, error) {
+	h := &unsplittableHandler[ptrace.Traces]{
+		policy:   policy,
+		maxBytes: maxBytes,
+		size:     func(single ptrace.Traces) int { return tracesSpansBytes(single, p) },
+	}
+	td.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			ss.Spans().RemoveIf(func(span ptrace.Span) bool {
+				single := ptrace.NewTraces()
+				srs := single.ResourceSpans().AppendEmpty()
+				rs.Resource().CopyTo(srs.Resource())
+				srs.SetSchemaUrl(rs.SchemaUrl())
+				sss := srs.ScopeSpans().AppendEmpty()
+				ss.Scope().CopyTo(sss.Scope())
+				sss.SetSchemaUrl(ss.SchemaUrl())
+				s := sss.Spans().AppendEmpty()
+				span.CopyTo(s)
+				return !h.keep(single, func() { s.CopyTo(span) },
+					func() bool {
+						if s.Events().Len() == 0 {
+							return false
+						}
+						s.Events().RemoveIf(func(ptrace.SpanEvent) bool { return true })
+						return true
+					},
+					func() bool {
+						if s.Links().Len() == 0 {
+							return false
+						}
+						s.Links().RemoveIf(func(ptrace.SpanLink) bool { return true })
+						return true
+					},
+					clearAttributes(s.Attributes()))
+			})
+			return ss.Spans().Len() == 0
+		})
+		return rs.ScopeSpans().Len() == 0
+	})
+	return h.deadLetters, h.err
+}
+
+// handleUnsplittable applies policy to the data points of md exceeding maxBytes. Dropped and
+// dead-lettered data points are removed from md, the dead-lettered ones are returned.
+// The exemplars of the data points are removed first, then their attributes.
+func (p pdataMetricsMarshaler) handleUnsplittable(md pmetric.Metrics, maxBytes int, policy string) ([]pmetric.Metrics, error) {
+	h := &unsplittableHandler[pmetric.Metrics]{
+		policy:   policy,
+		maxBytes: maxBytes,
+		size:     func(single pmetric.Metrics) int { return metricsBytes(single, p) },
+	}
+	md.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+		rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+			sm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+				// single returns a copy of the metric without data points, alone in its resource and scope.
+				single := func() (pmetric.Metrics, pmetric.Metric) {
+					md := pmetric.NewMetrics()
+					srm := md.ResourceMetrics().AppendEmpty()
+					rm.Resource().CopyTo(srm.Resource())
+					srm.SetSchemaUrl(rm.SchemaUrl())
+					ssm := srm.ScopeMetrics().AppendEmpty()
+					sm.Scope().CopyTo(ssm.Scope())
+					ssm.SetSchemaUrl(sm.SchemaUrl())
+					dest := ssm.Metrics().AppendEmpty()
+					dest.SetName(m.Name())
+					dest.SetDescription(m.Description())
+					dest.SetUnit(m.Unit())
+					return md, dest
+				}
+				switch m.Type() {
+				case pmetric.MetricTypeGauge:
+					m.Gauge().DataPoints().RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+						md, dest := single()
+						sdp := dest.SetEmptyGauge().DataPoints().AppendEmpty()
+						dp.CopyTo(sdp)
+						return !h.keep(md, func() { sdp.CopyTo(dp) }, clearExemplars(sdp.Exemplars()), clearAttributes(sdp.Attributes()))
+					})
+					return m.Gauge().DataPoints().Len() == 0
+				case pmetric.MetricTypeSum:
+					m.Sum().DataPoints().RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+						md, dest := single()
+						sum := dest.SetEmptySum()
+						sum.SetAggregationTemporality(m.Sum().AggregationTemporality())
+						sum.SetIsMonotonic(m.Sum().IsMonotonic())
+						sdp := sum.DataPoints().AppendEmpty()
+						dp.CopyTo(sdp)
+						return !h.keep(md, func() { sdp.CopyTo(dp) }, clearExemplars(sdp.Exemplars()), clearAttributes(sdp.Attributes()))
+					})
+					return m.Sum().DataPoints().Len() == 0
+				case pmetric.MetricTypeHistogram:
+					m.Histogram().DataPoints().RemoveIf(func(dp pmetric.HistogramDataPoint) bool {
+						md, dest := single()
+						histogram := dest.SetEmptyHistogram()
+						histogram.SetAggregationTemporality(m.Histogram().AggregationTemporality())
+						sdp := histogram.DataPoints().AppendEmpty()
+						dp.CopyTo(sdp)
+						return !h.keep(md, func() { sdp.CopyTo(dp) }, clearExemplars(sdp.Exemplars()), clearAttributes(sdp.Attributes()))
+					})
+					return m.Histogram().DataPoints().Len() == 0
+				case pmetric.MetricTypeExponentialHistogram:
+					m.ExponentialHistogram().DataPoints().RemoveIf(func(dp pmetric.ExponentialHistogramDataPoint) bool {
+						md, dest := single()
+						histogram := dest.SetEmptyExponentialHistogram()
+						histogram.SetAggregationTemporality(m.ExponentialHistogram().AggregationTemporality())
+						sdp := histogram.DataPoints().AppendEmpty()
+						dp.CopyTo(sdp)
+						return !h.keep(md, func() { sdp.CopyTo(dp) }, clearExemplars(sdp.Exemplars()), clearAttributes(sdp.Attributes()))
+					})
+					return m.ExponentialHistogram().DataPoints().Len() == 0
+				case pmetric.MetricTypeSummary:
+					m.Summary().DataPoints().RemoveIf(func(dp pmetric.SummaryDataPoint) bool {
+						md, dest := single()
+						sdp := dest.SetEmptySummary().DataPoints().AppendEmpty()
+						dp.CopyTo(sdp)
+						return !h.keep(md, func() { sdp.CopyTo(dp) }, clearAttributes(sdp.Attributes()))
+					})
+					return m.Summary().DataPoints().Len() == 0
+				}
+				return false
+			})
+			return sm.Metrics().Len() == 0
+		})
+		return rm.ScopeMetrics().Len() == 0
+	})
+	return h.deadLetters, h.err
+}
@@ -0,0 +1,218 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const unsplittableMaxMessageBytes = 1000
+
+func unsplittableConfig(policy string) *Config {
+	return &Config{
+		Topic: "topic",
+		OnUnsplittable: OnUnsplittable{
+			Traces:                    policy,
+			Metrics:                   policy,
+			Logs:                      policy,
+			DeadLetterTopic:           "dlq",
+			DeadLetterMaxMessageBytes: 10 * unsplittableMaxMessageBytes,
+		},
+		Producer: Producer{MaxMessageBytes: unsplittableMaxMessageBytes},
+	}
+}
+
+// messagesByTopic decodes the messages, checking they fit in the maximum size of their topic.
+func messagesByTopic[T any](t *testing.T, config *Config, messages []*sarama.ProducerMessage, unmarshal func([]byte) (T, error)) map[string][]T {
+	decoded := map[string][]T{}
+	for _, msg := range messages {
+		assert.LessOrEqual(t, msg.ByteSize(config.Producer.protoVersion), config.maxMessageBytes(msg.Topic))
+		bts, err := msg.Value.Encode()
+		require.NoError(t, err)
+		value, err := unmarshal(bts)
+		require.NoError(t, err)
+		decoded[msg.Topic] = append(decoded[msg.Topic], value)
+	}
+	return decoded
+}
+
+func TestMarshalLogs_onUnsplittable(t *testing.T) {
+	oversized := strings.Repeat("a", 2*unsplittableMaxMessageBytes)
+	newLogs := func() plog.Logs {
+		ld := plog.NewLogs()
+		records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+		records.AppendEmpty().Body().SetStr("small")
+		records.AppendEmpty().Body().SetStr(oversized)
+		return ld
+	}
+	bodies := func(logs []plog.Logs) []string {
+		var bodies []string
+		for _, ld := range logs {
+			records := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+			for i := 0; i < records.Len(); i++ {
+				bodies = append(bodies, records.At(i).Body().Str())
+			}
+		}
+		return bodies
+	}
+	p := pdataLogsMarshaler{marshaler: &plog.ProtoMarshaler{}, encoding: defaultEncoding}
+	unmarshaler := &plog.ProtoUnmarshaler{}
+
+	_, err := p.Marshal(newLogs(), unsplittableConfig(unsplittableError))
+	assert.ErrorIs(t, err, errSingleKafkaProducerMessageSizeOverMaxMsgByte)
+
+	config := unsplittableConfig(unsplittableDrop)
+	messages, err := p.Marshal(newLogs(), config)
+	require.NoError(t, err)
+	decoded := messagesByTopic(t, config, messages, unmarshaler.UnmarshalLogs)
+	assert.Equal(t, []string{"small"}, bodies(decoded["topic"]))
+	assert.Empty(t, decoded["dlq"])
+
+	config = unsplittableConfig(unsplittableTruncate)
+	messages, err = p.Marshal(newLogs(), config)
+	require.NoError(t, err)
+	decoded = messagesByTopic(t, config, messages, unmarshaler.UnmarshalLogs)
+	got := bodies(decoded["topic"])
+	require.Len(t, got, 2)
+	assert.Equal(t, "small", got[0])
+	assert.NotEmpty(t, got[1])
+	assert.True(t, strings.HasPrefix(oversized, got[1]))
+	assert.Less(t, len(got[1]), len(oversized))
+
+	config = unsplittableConfig(unsplittableDeadLetter)
+	messages, err = p.Marshal(newLogs(), config)
+	require.NoError(t, err)
+	decoded = messagesByTopic(t, config, messages, unmarshaler.UnmarshalLogs)
+	assert.Equal(t, []string{"small"}, bodies(decoded["topic"]))
+	assert.Equal(t, []string{oversized}, bodies(decoded["dlq"]))
+}
+
+func TestMarshalTraces_onUnsplittable(t *testing.T) {
+	newTraces := func() ptrace.Traces {
+		td := ptrace.NewTraces()
+		spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+		spans.AppendEmpty().SetName("small")
+		oversized := spans.AppendEmpty()
+		oversized.SetName("oversized")
+		for i := 0; i < 50; i++ {
+			oversized.Events().AppendEmpty().SetName(strings.Repeat("e", 50))
+		}
+		return td
+	}
+	spans := func(traces []ptrace.Traces) map[string]ptrace.Span {
+		spans := map[string]ptrace.Span{}
+		for _, td := range traces {
+			s := td.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+			for i := 0; i < s.Len(); i++ {
+				spans[s.At(i).Name()] = s.At(i)
+			}
+		}
+		return spans
+	}
+	p := pdataTracesMarshaler{marshaler: &ptrace.ProtoMarshaler{}, encoding: defaultEncoding}
+	unmarshaler := &ptrace.ProtoUnmarshaler{}
+
+	_, err := p.Marshal(newTraces(), unsplittableConfig(unsplittableError))
+	assert.ErrorIs(t, err, errSingleKafkaProducerMessageSizeOverMaxMsgByte)
+
+	config := unsplittableConfig(unsplittableDrop)
+	messages, err := p.Marshal(newTraces(), config)
+	require.NoError(t, err)
+	decoded := messagesByTopic(t, config, messages, unmarshaler.UnmarshalTraces)
+	assert.Len(t, spans(decoded["topic"]), 1)
+	assert.Contains(t, spans(decoded["topic"]), "small")
+	assert.Empty(t, decoded["dlq"])
+
+	config = unsplittableConfig(unsplittableTruncate)
+	messages, err = p.Marshal(newTraces(), config)
+	require.NoError(t, err)
+	decoded = messagesByTopic(t, config, messages, unmarshaler.UnmarshalTraces)
+	got := spans(decoded["topic"])
+	require.Len(t, got, 2)
+	assert.Equal(t, 0, got["oversized"].Events().Len())
+
+	config = unsplittableConfig(unsplittableDeadLetter)
+	messages, err = p.Marshal(newTraces(), config)
+	require.NoError(t, err)
+	decoded = messagesByTopic(t, config, messages, unmarshaler.UnmarshalTraces)
+	assert.Len(t, spans(decoded["topic"]), 1)
+	dlq := spans(decoded["dlq"])
+	require.Len(t, dlq, 1)
+	assert.Equal(t, 50, dlq["oversized"].Events().Len())
+}
+
+func TestMarshalMetrics_onUnsplittable(t *testing.T) {
+	newMetrics := func() pmetric.Metrics {
+		md := pmetric.NewMetrics()
+		dps := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints()
+		dps.AppendEmpty().Attributes().PutStr("name", "small")
+		oversized := dps.AppendEmpty()
+		oversized.Attributes().PutStr("name", "oversized")
+		oversized.Attributes().PutStr("payload", strings.Repeat("a", 2*unsplittableMaxMessageBytes))
+		return md
+	}
+	dataPoints := func(metrics []pmetric.Metrics) map[string]pmetric.NumberDataPoint {
+		dataPoints := map[string]pmetric.NumberDataPoint{}
+		for _, md := range metrics {
+			dps := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints()
+			for i := 0; i < dps.Len(); i++ {
+				name := ""
+				if v, ok := dps.At(i).Attributes().Get("name"); ok {
+					name = v.Str()
+				}
+				dataPoints[name] = dps.At(i)
+			}
+		}
+		return dataPoints
+	}
+	p := pdataMetricsMarshaler{marshaler: &pmetric.ProtoMarshaler{}, encoding: defaultEncoding}
+	unmarshaler := &pmetric.ProtoUnmarshaler{}
+
+	for _, granularity := range []string{metricsGranularityPerRequest, metricsGranularityPerDataPoint} {
+		t.Run(granularity, func(t *testing.T) {
+			withGranularity := func(config *Config) *Config {
+				config.MetricsGranularity = granularity
+				return config
+			}
+
+			_, err := p.Marshal(newMetrics(), withGranularity(unsplittableConfig(unsplittableError)))
+			assert.ErrorIs(t, err, errSingleKafkaProducerMessageSizeOverMaxMsgByte)
+
+			config := withGranularity(unsplittableConfig(unsplittableDrop))
+			messages, err := p.Marshal(newMetrics(), config)
+			require.NoError(t, err)
+			decoded := messagesByTopic(t, config, messages, unmarshaler.UnmarshalMetrics)
+			assert.Len(t, dataPoints(decoded["topic"]), 1)
+			assert.Contains(t, dataPoints(decoded["topic"]), "small")
+			assert.Empty(t, decoded["dlq"])
+
+			// Truncation removes the attributes of the oversized data point.
+			config = withGranularity(unsplittableConfig(unsplittableTruncate))
+			messages, err = p.Marshal(newMetrics(), config)
+			require.NoError(t, err)
+			decoded = messagesByTopic(t, config, messages, unmarshaler.UnmarshalMetrics)
+			got := dataPoints(decoded["topic"])
+			require.Len(t, got, 2)
+			assert.Contains(t, got, "small")
+			assert.Equal(t, 0, got[""].Attributes().Len())
+
+			config = withGranularity(unsplittableConfig(unsplittableDeadLetter))
+			messages, err = p.Marshal(newMetrics(), config)
+			require.NoError(t, err)
+			decoded = messagesByTopic(t, config, messages, unmarshaler.UnmarshalMetrics)
+			assert.Len(t, dataPoints(decoded["topic"]), 1)
+			dlq := dataPoints(decoded["dlq"])
+			require.Len(t, dlq, 1)
+			assert.Equal(t, 2, dlq["oversized"].Attributes().Len())
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter // import "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+
+import (
+	"github.com/IBM/sarama"
+	zipkinmodel "github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/proto/zipkin_proto3"
+	zipkinreporter "github.com/openzipkin/zipkin-go/reporter"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/multierr"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/translator/zipkin/zipkinv2"
+)
+
+// zipkinMarshaler produces every span as its own message, keyed by its trace ID. The value of a message is a
+// Zipkin v2 list of spans holding the single span, as consumed by the Zipkin Kafka collector.
+type zipkinMarshaler struct {
+	serializer zipkinreporter.SpanSerializer
+	encoding   string
+}
+
+var _ TracesMarshaler = (*zipkinMarshaler)(nil)
+
+func newZipkinProtoMarshaler() zipkinMarshaler {
+	return zipkinMarshaler{serializer: zipkin_proto3.SpanSerializer{}, encoding: "zipkin_proto"}
+}
+
+func newZipkinJSONMarshaler() zipkinMarshaler {
+	return zipkinMarshaler{serializer: zipkinreporter.JSONSerializer{}, encoding: "zipkin_json"}
+}
+
+func (z zipkinMarshaler) Marshal(traces ptrace.Traces, config *Config) ([]*sarama.ProducerMessage, error) {
+	spans, err := zipkinv2.FromTranslator{}.FromTraces(traces)
+	if err != nil {
+		return nil, err
+	}
+	var messages []*sarama.ProducerMessage
+
+	var errs error
+	for _, span := range spans {
+		bts, err := z.serializer.Serialize([]*zipkinmodel.SpanModel{span})
+		// continue to process spans that can be serialized
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		message := &sarama.ProducerMessage{
+			Topic: config.Topic,
+			Value: sarama.ByteEncoder(bts),
+			Key:   sarama.ByteEncoder(span.TraceID.String()),
+		}
+		if !config.NoSplit && message.ByteSize(config.Producer.protoVersion) > config.Producer.MaxMessageBytes {
+			return nil, errSingleKafkaProducerMessageSizeOverMaxMsgByte
+		}
+		messages = append(messages, message)
+	}
+	return messages, errs
+}
+
+func (z zipkinMarshaler) Encoding() string {
+	return z.encoding
+}
@@ -0,0 +1,87 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkaexporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/translator/zipkin/zipkinv2"
+)
+
+func TestZipkinMarshaler(t *testing.T) {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "foo")
+	spans := rs.ScopeSpans().AppendEmpty().Spans()
+	for i := byte(1); i <= 2; i++ {
+		span := spans.AppendEmpty()
+		span.SetName("bar")
+		span.SetStartTimestamp(pcommon.Timestamp(10))
+		span.SetEndTimestamp(pcommon.Timestamp(20))
+		span.SetTraceID([16]byte{i, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+		span.SetSpanID([8]byte{i, 2, 3, 4, 5, 6, 7, 8})
+	}
+
+	tests := []struct {
+		marshaler   zipkinMarshaler
+		encoding    string
+		unmarshaler ptrace.Unmarshaler
+	}{
+		{
+			marshaler:   newZipkinProtoMarshaler(),
+			encoding:    "zipkin_proto",
+			unmarshaler: zipkinv2.NewProtobufTracesUnmarshaler(false, false),
+		},
+		{
+			marshaler:   newZipkinJSONMarshaler(),
+			encoding:    "zipkin_json",
+			unmarshaler: zipkinv2.NewJSONTracesUnmarshaler(false),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.encoding, func(t *testing.T) {
+			assert.Equal(t, test.encoding, test.marshaler.Encoding())
+			messages, err := test.marshaler.Marshal(td, &Config{Topic: "topic", Producer: Producer{protoVersion: 2, MaxMessageBytes: 1000 * 1000}})
+			require.NoError(t, err)
+			require.Len(t, messages, 2)
+			for i, message := range messages {
+				assert.Equal(t, "topic", message.Topic)
+				key, err := message.Key.Encode()
+				require.NoError(t, err)
+				traceID := spans.At(i).TraceID()
+				assert.Equal(t, traceID.String(), string(key))
+
+				bts, err := message.Value.Encode()
+				require.NoError(t, err)
+				got, err := test.unmarshaler.UnmarshalTraces(bts)
+				require.NoError(t, err)
+				require.Equal(t, 1, got.SpanCount())
+				span := got.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+				assert.Equal(t, traceID, span.TraceID())
+				assert.Equal(t, spans.At(i).SpanID(), span.SpanID())
+				assert.Equal(t, "bar", span.Name())
+				serviceName, ok := got.ResourceSpans().At(0).Resource().Attributes().Get("service.name")
+				require.True(t, ok)
+				assert.Equal(t, "foo", serviceName.Str())
+			}
+		})
+	}
+}
+
+func TestZipkinMarshaler_maxMessageBytes(t *testing.T) {
+	td := ptrace.NewTraces()
+	span := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID([16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	span.SetSpanID([8]byte{1, 2, 3, 4, 5, 6, 7, 8})
+	for _, m := range []zipkinMarshaler{newZipkinProtoMarshaler(), newZipkinJSONMarshaler()} {
+		messages, err := m.Marshal(td, &Config{Topic: "topic", Producer: Producer{protoVersion: 2, MaxMessageBytes: 1}})
+		assert.Equal(t, errSingleKafkaProducerMessageSizeOverMaxMsgByte, err)
+		assert.Nil(t, messages)
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+	"go.uber.org/zap"
+)
+
+// assignmentConsumer consumes a static list of partitions without joining a consumer group.
+// The offsets of the consumed messages are tracked per partition and persisted with the
+// configured storage extension.
+type assignmentConsumer struct {
+	consumer      sarama.Consumer
+	partitions    map[string][]int32
+	initialOffset int64
+	retryBackoff  time.Duration
+	storageID     *component.ID
+	autoCommit    AutoCommit
+	logger        *zap.Logger
+
+	handler sarama.ConsumerGroupHandler
+	session *assignmentSession
+	wg      sync.WaitGroup
+}
+
+// newConsumer creates the consumer group used by the receiver or, if a static partition
+// assignment is configured, the consumer of the assigned partitions.
+func newConsumer(config Config, c *sarama.Config, logger *zap.Logger) (sarama.ConsumerGroup, *assignmentConsumer, error) {
+	if config.Assignment == nil {
+		client, err := sarama.NewConsumerGroup(config.Brokers, consumerGroupID(config), c)
+		return client, nil, err
+	}
+	consumer, err := sarama.NewConsumer(config.Brokers, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, &assignmentConsumer{
+		consumer:      consumer,
+		partitions:    config.Assignment.Partitions,
+		initialOffset: c.Consumer.Offsets.Initial,
+		retryBackoff:  c.Consumer.Retry.Backoff,
+		storageID:     config.Assignment.StorageID,
+		autoCommit:    config.AutoCommit,
+		logger:        logger,
+	}, nil
+}
+
+// withSignalDefaults returns config with the signal appended to the default group and client IDs, e.g.
+// otel-collector-traces, so that the receivers of different signals do not join the same consumer group
+// and fight over the partitions of each other's topics. The configured IDs are kept as they are.
+func withSignalDefaults(config Config, signal component.DataType) Config {
+	if config.GroupID == "" && config.Assignment == nil {
+		config.GroupID = defaultGroupID + "-" + string(signal)
+	}
+	if config.ClientID == "" {
+		config.ClientID = defaultClientID + "-" + string(signal)
+	}
+	return config
+}
+
+// consumerGroupID returns the configured group ID or the default one if it is not set.
+func consumerGroupID(config Config) string {
+	if config.GroupID == "" {
+		return defaultGroupID
+	}
+	return config.GroupID
+}
+
+// start loads the stored offsets and starts consuming every assigned partition with handler.
+func (a *assignmentConsumer) start(ctx context.Context, host component.Host, id component.ID, handler sarama.ConsumerGroupHandler) error {
+	client, err := getStorageClient(ctx, host, a.storageID, id)
+	if err != nil {
+		return err
+	}
+	a.session = &assignmentSession{
+		ctx:     ctx,
+		client:  client,
+		claims:  a.partitions,
+		offsets: make(map[string]map[int32]int64, len(a.partitions)),
+		logger:  a.logger,
+	}
+	for topic, partitions := range a.partitions {
+		a.session.offsets[topic] = make(map[int32]int64, len(partitions))
+		for _, partition := range partitions {
+			offset, err := a.session.load(ctx, topic, partition)
+			if err != nil {
+				return err
+			}
+			if offset < 0 {
+				offset = a.initialOffset
+			}
+			a.session.offsets[topic][partition] = offset
+		}
+	}
+
+	a.handler = handler
+	if err = handler.Setup(a.session); err != nil {
+		return err
+	}
+	for topic, partitions := range a.partitions {
+		for _, partition := range partitions {
+			a.wg.Add(1)
+			go a.consumePartition(ctx, handler, topic, partition)
+		}
+	}
+	if a.autoCommit.Enable {
+		a.wg.Add(1)
+		go a.commitLoop(ctx)
+	}
+	return nil
+}
+
+func (a *assignmentConsumer) consumePartition(ctx context.Context, handler sarama.ConsumerGroupHandler, topic string, partition int32) {
+	defer a.wg.Done()
+	for {
+		offset := a.session.offset(topic, partition)
+		pc, err := a.consumer.ConsumePartition(topic, partition, offset)
+		if errors.Is(err, sarama.ErrOffsetOutOfRange) {
+			a.logger.Warn("Stored offset is out of range, resetting to the initial offset",
+				zap.String("topic", topic), zap.Int32("partition", partition), zap.Int64("offset", offset))
+			a.session.ResetOffset(topic, partition, a.initialOffset, "")
+			continue
+		}
+		if err == nil {
+			err = handler.ConsumeClaim(a.session, &assignmentClaim{
+				PartitionConsumer: pc,
+				topic:             topic,
+				partition:         partition,
+				initialOffset:     offset,
+			})
+			_ = pc.Close()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			a.logger.Error("Error from partition consumer",
+				zap.String("topic", topic), zap.Int32("partition", partition), zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(a.retryBackoff):
+		}
+	}
+}
+
+func (a *assignmentConsumer) commitLoop(ctx context.Context) {
+	defer a.wg.Done()
+	ticker := time.NewTicker(a.autoCommit.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.session.Commit()
+		}
+	}
+}
+
+// shutdown waits for the partition consumers to stop, then commits the offsets and releases
+// the storage client. The context passed to start has to be cancelled before.
+func (a *assignmentConsumer) shutdown(ctx context.Context) error {
+	var errs error
+	if a.session != nil {
+		a.wg.Wait()
+		errs = errors.Join(errs, a.handler.Cleanup(a.session))
+		a.session.Commit()
+		errs = errors.Join(errs, a.session.client.Close(ctx))
+	}
+	return errors.Join(errs, a.consumer.Close())
+}
+
+// assignmentSession implements sarama.ConsumerGroupSession for statically assigned partitions,
+// so the consumer group handlers can be reused. Marked offsets are persisted on Commit.
+type assignmentSession struct {
+	ctx    context.Context
+	client storage.Client
+	claims map[string][]int32
+	logger *zap.Logger
+
+	mu      sync.Mutex
+	offsets map[string]map[int32]int64
+}
+
+var _ sarama.ConsumerGroupSession = (*assignmentSession)(nil)
+
+func (s *assignmentSession) Claims() map[string][]int32 {
+	return s.claims
+}
+
+func (s *assignmentSession) MemberID() string {
+	return ""
+}
+
+func (s *assignmentSession) GenerationID() int32 {
+	return 0
+}
+
+func (s *assignmentSession) MarkOffset(topic string, partition int32, offset int64, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if offset > s.offsets[topic][partition] {
+		s.offsets[topic][partition] = offset
+	}
+}
+
+func (s *assignmentSession) Commit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ops []storage.Operation
+	for topic, partitions := range s.offsets {
+		for partition, offset := range partitions {
+			// Initial offsets are only placeholders until the first message is marked.
+			if offset < 0 {
+				continue
+			}
+			ops = append(ops, storage.SetOperation(offsetStorageKey(topic, partition), []byte(strconv.FormatInt(offset, 10))))
+		}
+	}
+	if len(ops) == 0 {
+		return
+	}
+	if err := s.client.Batch(context.Background(), ops...); err != nil {
+		s.logger.Error("Failed to persist offsets", zap.Error(err))
+	}
+}
+
+func (s *assignmentSession) ResetOffset(topic string, partition int32, offset int64, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offsets[topic][partition] = offset
+}
+
+func (s *assignmentSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.MarkOffset(msg.Topic, msg.Partition, msg.Offset+1, metadata)
+}
+
+func (s *assignmentSession) Context() context.Context {
+	return s.ctx
+}
+
+// offset returns the next offset to consume from the partition.
+func (s *assignmentSession) offset(topic string, partition int32) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.offsets[topic][partition]
+}
+
+// load returns the offset persisted for the partition, or -1 if there is none.
+func (s *assignmentSession) load(ctx context.Context, topic string, partition int32) (int64, error) {
+	value, err := s.client.Get(ctx, offsetStorageKey(topic, partition))
+	if err != nil || value == nil {
+		return -1, err
+	}
+	offset, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return -1, fmt.Errorf("invalid offset stored for topic %q partition %d: %w", topic, partition, err)
+	}
+	return offset, nil
+}
+
+func offsetStorageKey(topic string, partition int32) string {
+	return fmt.Sprintf("%s/%d", topic, partition)
+}
+
+// assignmentClaim implements sarama.ConsumerGroupClaim for a statically assigned partition.
+type assignmentClaim struct {
+	sarama.PartitionConsumer
+	topic         string
+	partition     int32
+	initialOffset int64
+}
+
+var _ sarama.ConsumerGroupClaim = (*assignmentClaim)(nil)
+
+func (c *assignmentClaim) Topic() string {
+	return c.topic
+}
+
+func (c *assignmentClaim) Partition() int32 {
+	return c.partition
+}
+
+func (c *assignmentClaim) InitialOffset() int64 {
+	return c.initialOffset
+}
+
+func getStorageClient(ctx context.Context, host component.Host, storageID *component.ID, componentID component.ID) (storage.Client, error) {
+	if storageID == nil {
+		return storage.NewNopClient(), nil
+	}
+
+	extension, ok := host.GetExtensions()[*storageID]
+	if !ok {
+		return nil, fmt.Errorf("storage extension '%s' not found", storageID)
+	}
+
+	storageExtension, ok := extension.(storage.Extension)
+	if !ok {
+		return nil, fmt.Errorf("non-storage extension '%s' found", storageID)
+	}
+
+	return storageExtension.GetClient(ctx, component.KindReceiver, componentID, "")
+}
@@ -0,0 +1,146 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+)
+
+func TestAssignmentConsumer(t *testing.T) {
+	storageID := component.NewID("file_storage")
+	client := &testStorageClient{data: map[string][]byte{"logs/0": []byte("5")}}
+	host := &testStorageHost{
+		Host:       componenttest.NewNopHost(),
+		extensions: map[component.ID]component.Component{storageID: &testStorageExtension{client: client}},
+	}
+
+	consumer := mocks.NewConsumer(t, nil)
+	consumer.ExpectConsumePartition("logs", 0, 5).
+		YieldMessage(&sarama.ConsumerMessage{Value: []byte("first")}).
+		YieldMessage(&sarama.ConsumerMessage{Value: []byte("second")})
+	consumer.ExpectConsumePartition("logs", 1, sarama.OffsetNewest)
+
+	obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+	require.NoError(t, err)
+	sink := &consumertest.LogsSink{}
+	handler := &logsConsumerGroupHandler{
+		unmarshaler:  newRawLogsUnmarshaler(),
+		logger:       zap.NewNop(),
+		ready:        make(chan bool),
+		nextConsumer: sink,
+		obsrecv:      obsrecv,
+	}
+	a := &assignmentConsumer{
+		consumer:      consumer,
+		partitions:    map[string][]int32{"logs": {0, 1}},
+		initialOffset: sarama.OffsetNewest,
+		retryBackoff:  time.Millisecond,
+		storageID:     &storageID,
+		logger:        zap.NewNop(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, a.start(ctx, host, component.NewID("kafka"), handler))
+	_, ok := <-handler.ready
+	assert.False(t, ok)
+	assert.Eventually(t, func() bool {
+		return sink.LogRecordCount() == 2
+	}, 10*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, a.shutdown(context.Background()))
+	assert.Equal(t, map[string][]byte{"logs/0": []byte("7")}, client.data)
+	assert.True(t, client.closed)
+}
+
+func TestAssignmentConsumer_storage_not_found(t *testing.T) {
+	storageID := component.NewID("file_storage")
+	a := &assignmentConsumer{
+		consumer:   mocks.NewConsumer(t, nil),
+		partitions: map[string][]int32{"logs": {0}},
+		storageID:  &storageID,
+		logger:     zap.NewNop(),
+	}
+	err := a.start(context.Background(), componenttest.NewNopHost(), component.NewID("kafka"), &logsConsumerGroupHandler{})
+	assert.EqualError(t, err, "storage extension 'file_storage' not found")
+}
+
+type testStorageHost struct {
+	component.Host
+	extensions map[component.ID]component.Component
+}
+
+func (h *testStorageHost) GetExtensions() map[component.ID]component.Component {
+	return h.extensions
+}
+
+type testStorageExtension struct {
+	component.StartFunc
+	component.ShutdownFunc
+	client *testStorageClient
+}
+
+func (e *testStorageExtension) GetClient(context.Context, component.Kind, component.ID, string) (storage.Client, error) {
+	return e.client, nil
+}
+
+type testStorageClient struct {
+	mu     sync.Mutex
+	data   map[string][]byte
+	closed bool
+}
+
+func (c *testStorageClient) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data[key], nil
+}
+
+func (c *testStorageClient) Set(_ context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = value
+	return nil
+}
+
+func (c *testStorageClient) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+	return nil
+}
+
+func (c *testStorageClient) Batch(ctx context.Context, ops ...storage.Operation) error {
+	for _, op := range ops {
+		switch op.Type {
+		case storage.Get:
+			op.Value, _ = c.Get(ctx, op.Key)
+		case storage.Set:
+			_ = c.Set(ctx, op.Key, op.Value)
+		case storage.Delete:
+			_ = c.Delete(ctx, op.Key)
+		}
+	}
+	return nil
+}
+
+func (c *testStorageClient) Close(context.Context) error {
+	c.closed = true
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
+
+import (
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+)
+
+const avroEncoding = "avro"
+
+// avroTracesUnmarshaler unmarshals the messages of the avro_traces encoding of the Kafka exporter. The receivers
+// replace the default one with one fetching the schemas from their schema_registry_url.
+type avroTracesUnmarshaler struct {
+	unmarshaler *kafkaexporter.AvroUnmarshaler
+}
+
+func newAvroTracesUnmarshaler(schemaRegistryURL string) TracesUnmarshaler {
+	return avroTracesUnmarshaler{unmarshaler: kafkaexporter.NewAvroUnmarshaler(schemaRegistryURL)}
+}
+
+func (a avroTracesUnmarshaler) Unmarshal(buf []byte) (ptrace.Traces, error) {
+	return a.unmarshaler.UnmarshalTraces(buf)
+}
+
+func (a avroTracesUnmarshaler) Encoding() string {
+	return avroEncoding
+}
+
+// avroMetricsUnmarshaler unmarshals the messages of the avro_metrics encoding of the Kafka exporter.
+type avroMetricsUnmarshaler struct {
+	unmarshaler *kafkaexporter.AvroUnmarshaler
+}
+
+func newAvroMetricsUnmarshaler(schemaRegistryURL string) MetricsUnmarshaler {
+	return avroMetricsUnmarshaler{unmarshaler: kafkaexporter.NewAvroUnmarshaler(schemaRegistryURL)}
+}
+
+func (a avroMetricsUnmarshaler) Unmarshal(buf []byte) (pmetric.Metrics, error) {
+	return a.unmarshaler.UnmarshalMetrics(buf)
+}
+
+func (a avroMetricsUnmarshaler) Encoding() string {
+	return avroEncoding
+}
+
+// avroLogsUnmarshaler unmarshals the messages of the avro_logs encoding of the Kafka exporter.
+type avroLogsUnmarshaler struct {
+	unmarshaler *kafkaexporter.AvroUnmarshaler
+}
+
+func newAvroLogsUnmarshaler(schemaRegistryURL string) LogsUnmarshaler {
+	return avroLogsUnmarshaler{unmarshaler: kafkaexporter.NewAvroUnmarshaler(schemaRegistryURL)}
+}
+
+func (a avroLogsUnmarshaler) Unmarshal(buf []byte) (plog.Logs, error) {
+	return a.unmarshaler.UnmarshalLogs(buf)
+}
+
+func (a avroLogsUnmarshaler) Encoding() string {
+	return avroEncoding
+}
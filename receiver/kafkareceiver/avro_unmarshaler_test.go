@@ -0,0 +1,115 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+)
+
+// testAvroLogsSchema is a schema of the avro_logs encoding with the severity text of the log records only.
+const testAvroLogsSchema = `{"type": "record", "name": "LogsData", "namespace": "opentelemetry.proto.logs.v1", "fields": [
+	{"name": "resource_logs", "type": {"type": "array", "items": {"type": "record", "name": "ResourceLogs", "fields": [
+		{"name": "scope_logs", "type": {"type": "array", "items": {"type": "record", "name": "ScopeLogs", "fields": [
+			{"name": "log_records", "type": {"type": "array", "items": {"type": "record", "name": "LogRecord", "fields": [
+				{"name": "severity_text", "type": "string"}]}}}]}}}]}}}]}`
+
+// testAvroLogsValue is a value of testAvroLogsSchema with schema ID 1 in the Confluent wire format: a log record
+// with the INFO severity text.
+var testAvroLogsValue = []byte{0, 0, 0, 0, 1, 2, 2, 2, 8, 'I', 'N', 'F', 'O', 0, 0, 0}
+
+// newTestSchemaRegistry returns a schema registry serving testAvroLogsSchema with ID 1 until it is made unavailable.
+func newTestSchemaRegistry(t *testing.T) (*httptest.Server, *atomic.Bool) {
+	var unavailable atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case unavailable.Load():
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case r.URL.Path == "/schemas/ids/1":
+			_ = json.NewEncoder(w).Encode(map[string]string{"schema": testAvroLogsSchema})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &unavailable
+}
+
+func TestAvroLogsUnmarshaler(t *testing.T) {
+	server, unavailable := newTestSchemaRegistry(t)
+	u := newAvroLogsUnmarshaler(server.URL)
+	assert.Equal(t, "avro", u.Encoding())
+
+	logs, err := u.Unmarshal(testAvroLogsValue)
+	require.NoError(t, err)
+	require.Equal(t, 1, logs.LogRecordCount())
+	assert.Equal(t, "INFO", logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).SeverityText())
+
+	// The messages of the fetched schema are still unmarshaled while the registry is unavailable.
+	unavailable.Store(true)
+	logs, err = u.Unmarshal(testAvroLogsValue)
+	require.NoError(t, err)
+	assert.Equal(t, 1, logs.LogRecordCount())
+	_, err = u.Unmarshal([]byte{0, 0, 0, 0, 2, 0})
+	assert.ErrorContains(t, err, "fetching schema 2 returned 503 Service Unavailable")
+
+	_, err = newAvroLogsUnmarshaler("").Unmarshal(testAvroLogsValue)
+	assert.Error(t, err)
+	_, err = newAvroTracesUnmarshaler(server.URL).Unmarshal([]byte(`{"resourceSpans": []}`))
+	assert.Error(t, err)
+	_, err = newAvroMetricsUnmarshaler(server.URL).Unmarshal(nil)
+	assert.Error(t, err)
+}
+
+func TestAvroLogsUnmarshaler_unmarshal_failed(t *testing.T) {
+	views := MetricViews()
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	server, _ := newTestSchemaRegistry(t)
+	obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+	require.NoError(t, err)
+	sink := &consumertest.LogsSink{}
+	c := logsConsumerGroupHandler{
+		id:            component.NewID("kafka"),
+		unmarshaler:   newAvroLogsUnmarshaler(server.URL),
+		logger:        zap.NewNop(),
+		ready:         make(chan bool),
+		nextConsumer:  sink,
+		obsrecv:       obsrecv,
+		onDecodeError: onDecodeErrorSkip,
+		decodeErrors:  newDecodeErrorLogger(zap.NewNop()),
+	}
+
+	session := &markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: context.Background()}}
+	groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage, 2)}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Topic: "logs", Offset: 1, Value: []byte("!@#")}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Topic: "logs", Offset: 2, Value: testAvroLogsValue}
+	close(groupClaim.messageChan)
+
+	require.NoError(t, c.ConsumeClaim(session, groupClaim))
+	require.Len(t, sink.AllLogs(), 1)
+	assert.Equal(t, 1, sink.LogRecordCount())
+
+	rows, err := view.RetrieveData(statMessageUnmarshalFailed.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Contains(t, rows[0].Tags, tag.Tag{Key: tagEncoding, Value: "avro"})
+	assert.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+}
@@ -0,0 +1,225 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// memoryLimiterRefusal is the error message of the memory_limiter processor refusing data.
+const memoryLimiterRefusal = "data refused due to high memory usage"
+
+// partitionPauser stops and restarts the fetching of partitions, it is implemented by both
+// sarama.ConsumerGroup and sarama.Consumer.
+type partitionPauser interface {
+	Pause(partitions map[string][]int32)
+	Resume(partitions map[string][]int32)
+}
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// backpressure pauses the fetching of the claimed partitions while the next consumer refuses their
+// messages, or while the memory in use exceeds the limit, so that no more messages are fetched and
+// unmarshaled than the pipeline can take. The refused messages are redelivered until they are accepted.
+type backpressure struct {
+	pauser        partitionPauser
+	memoryLimit   uint64
+	checkInterval time.Duration
+	logger        *zap.Logger
+	statsTags     []tag.Mutator
+
+	mu sync.Mutex
+	// claims are the claimed partitions, mapped to whether the next consumer refuses their messages.
+	claims    map[topicPartition]bool
+	overLimit bool
+}
+
+// newBackpressure returns nil unless the backpressure is enabled.
+func newBackpressure(config Config, group sarama.ConsumerGroup, assignment *assignmentConsumer, id component.ID, logger *zap.Logger) *backpressure {
+	if !config.Backpressure.Enable {
+		return nil
+	}
+	var pauser partitionPauser = group
+	if assignment != nil {
+		pauser = assignment.consumer
+	}
+	return &backpressure{
+		pauser:        pauser,
+		memoryLimit:   config.Backpressure.MemoryLimitMiB << 20,
+		checkInterval: config.Backpressure.CheckInterval,
+		logger:        logger,
+		statsTags:     []tag.Mutator{tag.Upsert(tagInstanceName, id.String())},
+		claims:        map[topicPartition]bool{},
+	}
+}
+
+// start checks the memory in use every check interval until ctx is done.
+func (b *backpressure) start(ctx context.Context) {
+	if b == nil || b.memoryLimit == 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(b.checkInterval)
+		defer ticker.Stop()
+		var ms runtime.MemStats
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runtime.ReadMemStats(&ms)
+				b.setOverLimit(ms.Alloc > b.memoryLimit)
+			}
+		}
+	}()
+}
+
+// claim registers the partition of claim, and pauses it right away if the memory is over the limit.
+func (b *backpressure) claim(claim sarama.ConsumerGroupClaim) {
+	if b == nil {
+		return
+	}
+	tp := topicPartition{topic: claim.Topic(), partition: claim.Partition()}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.claims[tp] = false
+	if b.overLimit {
+		b.pauser.Pause(partitionsOf(tp))
+	}
+	b.recordPaused()
+}
+
+// release unregisters the partition of claim once its consumption is over.
+func (b *backpressure) release(claim sarama.ConsumerGroupClaim) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.claims, topicPartition{topic: claim.Topic(), partition: claim.Partition()})
+	b.recordPaused()
+}
+
+// deliver calls consume until the next consumer stops refusing the message, with the partition of claim
+// paused in the meantime. It returns errDeliveryInterrupted if ctx is done while waiting.
+// A nil backpressure calls consume only once.
+func (b *backpressure) deliver(ctx context.Context, claim sarama.ConsumerGroupClaim, consume func() error) error {
+	err := consume()
+	if b == nil {
+		return err
+	}
+	tp := topicPartition{topic: claim.Topic(), partition: claim.Partition()}
+	for isResourceExhausted(err) {
+		b.setRefused(tp, true, err)
+		timer := time.NewTimer(b.checkInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errDeliveryInterrupted
+		case <-timer.C:
+		}
+		err = consume()
+	}
+	b.setRefused(tp, false, nil)
+	return err
+}
+
+func (b *backpressure) setRefused(tp topicPartition, refused bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if wasRefused, ok := b.claims[tp]; !ok || wasRefused == refused {
+		return
+	}
+	b.claims[tp] = refused
+	if refused {
+		b.logger.Warn("Pausing the partition, the next consumer refuses its messages",
+			zap.String("topic", tp.topic),
+			zap.Int32("partition", tp.partition),
+			zap.Error(err))
+	} else {
+		b.logger.Info("Resuming the partition, the next consumer accepts its messages again",
+			zap.String("topic", tp.topic),
+			zap.Int32("partition", tp.partition))
+	}
+	// The partitions are all paused while the memory is over the limit.
+	if !b.overLimit {
+		if refused {
+			b.pauser.Pause(partitionsOf(tp))
+		} else {
+			b.pauser.Resume(partitionsOf(tp))
+		}
+	}
+	b.recordPaused()
+}
+
+func (b *backpressure) setOverLimit(overLimit bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.overLimit == overLimit {
+		return
+	}
+	b.overLimit = overLimit
+	if overLimit {
+		b.logger.Warn("Pausing all the partitions, the memory in use is over the limit", zap.Uint64("limit_mib", b.memoryLimit>>20))
+	} else {
+		b.logger.Info("Resuming the partitions, the memory in use is back under the limit", zap.Uint64("limit_mib", b.memoryLimit>>20))
+	}
+	// The refused partitions stay paused.
+	partitions := map[string][]int32{}
+	for tp, refused := range b.claims {
+		if !refused {
+			partitions[tp.topic] = append(partitions[tp.topic], tp.partition)
+		}
+	}
+	if len(partitions) > 0 {
+		if overLimit {
+			b.pauser.Pause(partitions)
+		} else {
+			b.pauser.Resume(partitions)
+		}
+	}
+	b.recordPaused()
+}
+
+// recordPaused records the number of paused partitions, b.mu must be held.
+func (b *backpressure) recordPaused() {
+	paused := 0
+	for _, refused := range b.claims {
+		if refused || b.overLimit {
+			paused++
+		}
+	}
+	_ = stats.RecordWithTags(context.Background(), b.statsTags, statPausedPartitions.M(int64(paused)))
+}
+
+func partitionsOf(tp topicPartition) map[string][]int32 {
+	return map[string][]int32{tp.topic: {tp.partition}}
+}
+
+// isResourceExhausted reports whether err is the refusal of data by an overloaded pipeline: a resource
+// exhausted status, or the error of the memory_limiter processor.
+func isResourceExhausted(err error) bool {
+	if err == nil {
+		return false
+	}
+	if s, ok := status.FromError(err); ok && s.Code() == codes.ResourceExhausted {
+		return true
+	}
+	return strings.Contains(err.Error(), memoryLimiterRefusal)
+}
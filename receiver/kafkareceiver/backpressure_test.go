@@ -0,0 +1,195 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testPauser struct {
+	mu     sync.Mutex
+	paused map[topicPartition]bool
+}
+
+func (p *testPauser) Pause(partitions map[string][]int32) {
+	p.set(partitions, true)
+}
+
+func (p *testPauser) Resume(partitions map[string][]int32) {
+	p.set(partitions, false)
+}
+
+func (p *testPauser) set(partitions map[string][]int32, paused bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for topic, ps := range partitions {
+		for _, partition := range ps {
+			p.paused[topicPartition{topic: topic, partition: partition}] = paused
+		}
+	}
+}
+
+func (p *testPauser) isPaused(topic string, partition int32) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused[topicPartition{topic: topic, partition: partition}]
+}
+
+func newTestBackpressure(pauser *testPauser) *backpressure {
+	return &backpressure{
+		pauser:        pauser,
+		checkInterval: time.Millisecond,
+		logger:        zap.NewNop(),
+		claims:        map[topicPartition]bool{},
+	}
+}
+
+func TestBackpressure_deliver(t *testing.T) {
+	pauser := &testPauser{paused: map[topicPartition]bool{}}
+	b := newTestBackpressure(pauser)
+	claim := &testConsumerGroupClaim{}
+	b.claim(claim)
+	defer b.release(claim)
+
+	calls := 0
+	err := b.deliver(context.Background(), claim, func() error {
+		calls++
+		if calls == 1 {
+			assert.False(t, pauser.isPaused(testTopic, testPartition))
+		} else {
+			assert.True(t, pauser.isPaused(testTopic, testPartition))
+		}
+		if calls < 3 {
+			return status.Error(codes.ResourceExhausted, "queue is full")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.False(t, pauser.isPaused(testTopic, testPartition))
+
+	// The other errors are left to on_error.
+	err = b.deliver(context.Background(), claim, func() error { return errors.New("invalid data") })
+	assert.EqualError(t, err, "invalid data")
+	assert.False(t, pauser.isPaused(testTopic, testPartition))
+}
+
+func TestBackpressure_deliver_interrupted(t *testing.T) {
+	pauser := &testPauser{paused: map[topicPartition]bool{}}
+	b := newTestBackpressure(pauser)
+	claim := &testConsumerGroupClaim{}
+	b.claim(claim)
+	defer b.release(claim)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := b.deliver(ctx, claim, func() error {
+		cancel()
+		return errors.New(memoryLimiterRefusal)
+	})
+	assert.ErrorIs(t, err, errDeliveryInterrupted)
+}
+
+func TestBackpressure_memory_limit(t *testing.T) {
+	pauser := &testPauser{paused: map[topicPartition]bool{}}
+	b := newTestBackpressure(pauser)
+	b.claim(&testConsumerGroupClaim{})
+	b.setRefused(topicPartition{topic: testTopic, partition: testPartition}, true, errors.New(memoryLimiterRefusal))
+	b.claim(&partitionClaim{partition: testPartition + 1})
+
+	b.setOverLimit(true)
+	assert.True(t, pauser.isPaused(testTopic, testPartition))
+	assert.True(t, pauser.isPaused(testTopic, testPartition+1))
+
+	// The partitions claimed while over the limit start paused.
+	b.claim(&partitionClaim{partition: testPartition + 2})
+	assert.True(t, pauser.isPaused(testTopic, testPartition+2))
+
+	b.setOverLimit(false)
+	assert.True(t, pauser.isPaused(testTopic, testPartition))
+	assert.False(t, pauser.isPaused(testTopic, testPartition+1))
+	assert.False(t, pauser.isPaused(testTopic, testPartition+2))
+}
+
+func TestBackpressure_disabled(t *testing.T) {
+	assert.Nil(t, newBackpressure(Config{}, nil, nil, component.NewID("kafka"), zap.NewNop()))
+
+	var b *backpressure
+	b.start(context.Background())
+	b.claim(&testConsumerGroupClaim{})
+	err := b.deliver(context.Background(), &testConsumerGroupClaim{}, func() error { return errors.New(memoryLimiterRefusal) })
+	assert.EqualError(t, err, memoryLimiterRefusal)
+}
+
+func TestIsResourceExhausted(t *testing.T) {
+	assert.False(t, isResourceExhausted(nil))
+	assert.False(t, isResourceExhausted(errors.New("invalid data")))
+	assert.False(t, isResourceExhausted(status.Error(codes.Unavailable, "unavailable")))
+	assert.True(t, isResourceExhausted(status.Error(codes.ResourceExhausted, "queue is full")))
+	assert.True(t, isResourceExhausted(fmt.Errorf("exporter: %w", status.Error(codes.ResourceExhausted, "queue is full"))))
+	assert.True(t, isResourceExhausted(errors.New(memoryLimiterRefusal)))
+}
+
+func TestLogsConsumerGroupHandler_backpressure(t *testing.T) {
+	obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+	require.NoError(t, err)
+	pauser := &testPauser{paused: map[topicPartition]bool{}}
+	session := &markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: context.Background()}}
+
+	refusals := 2
+	next, err := consumer.NewLogs(func(context.Context, plog.Logs) error {
+		// The message is not marked while it is refused, even though it is marked before delivery by default.
+		assert.Empty(t, session.markedOffsets())
+		if refusals > 0 {
+			refusals--
+			return errors.New(memoryLimiterRefusal)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	c := logsConsumerGroupHandler{
+		unmarshaler:       newRawLogsUnmarshaler(),
+		logger:            zap.NewNop(),
+		ready:             make(chan bool),
+		nextConsumer:      next,
+		obsrecv:           obsrecv,
+		autocommitEnabled: true,
+		onError:           onErrorDrop,
+		backpressure:      newTestBackpressure(pauser),
+	}
+
+	groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage, 1)}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Topic: testTopic, Offset: 7, Value: []byte("refused")}
+	close(groupClaim.messageChan)
+	require.NoError(t, c.ConsumeClaim(session, groupClaim))
+	assert.Equal(t, 0, refusals)
+	assert.Equal(t, []int64{7}, session.markedOffsets())
+	assert.False(t, pauser.isPaused(testTopic, testPartition))
+}
+
+// partitionClaim is a claim of another partition of testTopic.
+type partitionClaim struct {
+	testConsumerGroupClaim
+	partition int32
+}
+
+func (c *partitionClaim) Partition() int32 {
+	return c.partition
+}
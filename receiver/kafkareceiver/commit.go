@@ -0,0 +1,81 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
+
+import (
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+const (
+	commitAfterDelivery = "after_delivery"
+	commitAfterReceive  = "after_receive"
+)
+
+// withCommit returns config with the AutoCommit and MessageMarking settings derived from Commit, if set.
+func withCommit(config Config) Config {
+	if config.Commit == nil {
+		return config
+	}
+	config.MessageMarking.After = config.Commit.Mode == commitAfterDelivery
+	config.AutoCommit = AutoCommit{
+		Enable:   !config.Commit.Sync,
+		Interval: config.Commit.Interval,
+	}
+	return config
+}
+
+// syncCommitInterval returns the minimum time between two synchronous commits of the handlers.
+func syncCommitInterval(config Config) time.Duration {
+	if config.Commit == nil || !config.Commit.Sync {
+		return 0
+	}
+	return config.Commit.Interval
+}
+
+// offsetCommitter synchronously commits the offsets marked in a session, at most once per interval.
+// A nil committer leaves the commits to the auto-commit of sarama.
+type offsetCommitter struct {
+	session  sarama.ConsumerGroupSession
+	interval time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// newOffsetCommitter returns nil if autocommitEnabled.
+func newOffsetCommitter(autocommitEnabled bool, interval time.Duration, session sarama.ConsumerGroupSession) *offsetCommitter {
+	if autocommitEnabled {
+		return nil
+	}
+	return &offsetCommitter{
+		session:  session,
+		interval: interval,
+		last:     time.Now(),
+	}
+}
+
+// commit commits the marked offsets unless the last commit is more recent than the interval.
+func (c *offsetCommitter) commit() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.last) < c.interval {
+		return
+	}
+	c.session.Commit()
+	c.last = time.Now()
+}
+
+// flush commits the marked offsets regardless of the interval.
+func (c *offsetCommitter) flush() {
+	if c == nil {
+		return
+	}
+	c.session.Commit()
+}
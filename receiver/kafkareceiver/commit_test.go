@@ -0,0 +1,149 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+)
+
+// committingSession records the last offset marked at the time of every commit, a consumer
+// restarting after a crash would resume after it.
+type committingSession struct {
+	markingSession
+	committed int64
+}
+
+func (s *committingSession) Commit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.marked) > 0 {
+		s.committed = s.marked[len(s.marked)-1]
+	}
+}
+
+func (s *committingSession) committedOffset() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.committed
+}
+
+func newCommittingLogsHandler(t *testing.T, commit Commit, consume consumer.ConsumeLogsFunc) *logsConsumerGroupHandler {
+	config := withCommit(Config{Commit: &commit})
+	obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+	require.NoError(t, err)
+	next, err := consumer.NewLogs(consume)
+	require.NoError(t, err)
+	return &logsConsumerGroupHandler{
+		unmarshaler:       newRawLogsUnmarshaler(),
+		logger:            zap.NewNop(),
+		ready:             make(chan bool),
+		nextConsumer:      next,
+		obsrecv:           obsrecv,
+		autocommitEnabled: config.AutoCommit.Enable,
+		commitInterval:    syncCommitInterval(config),
+		messageMarking:    config.MessageMarking,
+	}
+}
+
+func TestWithCommit(t *testing.T) {
+	config := withCommit(Config{
+		AutoCommit:     AutoCommit{Enable: true, Interval: time.Second},
+		MessageMarking: MessageMarking{OnError: true},
+		Commit:         &Commit{Mode: commitAfterDelivery, Interval: 5 * time.Second, Sync: true},
+	})
+	assert.Equal(t, AutoCommit{Enable: false, Interval: 5 * time.Second}, config.AutoCommit)
+	assert.Equal(t, MessageMarking{After: true, OnError: true}, config.MessageMarking)
+	assert.Equal(t, 5*time.Second, syncCommitInterval(config))
+
+	config = withCommit(Config{Commit: &Commit{Mode: commitAfterReceive, Interval: time.Second}})
+	assert.Equal(t, AutoCommit{Enable: true, Interval: time.Second}, config.AutoCommit)
+	assert.False(t, config.MessageMarking.After)
+	assert.Equal(t, time.Duration(0), syncCommitInterval(config))
+
+	config = withCommit(Config{AutoCommit: AutoCommit{Enable: true, Interval: time.Second}})
+	assert.Equal(t, AutoCommit{Enable: true, Interval: time.Second}, config.AutoCommit)
+}
+
+// The session ends while the second message is delivered, e.g. on a crash or a rebalance.
+func TestLogsConsumerGroupHandler_commit_crash_during_delivery(t *testing.T) {
+	tests := []struct {
+		mode string
+		// committedDuringDelivery is the offset a restarted consumer would resume after.
+		committedDuringDelivery int64
+	}{
+		{
+			mode:                    commitAfterReceive,
+			committedDuringDelivery: 2,
+		},
+		{
+			mode:                    commitAfterDelivery,
+			committedDuringDelivery: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			session := &committingSession{markingSession: markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: ctx}}}
+			var committedDuringDelivery int64
+			calls := 0
+			c := newCommittingLogsHandler(t, Commit{Mode: tt.mode, Sync: true}, func(context.Context, plog.Logs) error {
+				calls++
+				if calls == 1 {
+					return nil
+				}
+				committedDuringDelivery = session.committedOffset()
+				cancel()
+				return errors.New("session ended")
+			})
+
+			groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage, 2)}
+			groupClaim.messageChan <- &sarama.ConsumerMessage{Offset: 1, Value: []byte("log")}
+			groupClaim.messageChan <- &sarama.ConsumerMessage{Offset: 2, Value: []byte("log")}
+			close(groupClaim.messageChan)
+			_ = c.ConsumeClaim(session, groupClaim)
+
+			assert.Equal(t, tt.committedDuringDelivery, committedDuringDelivery)
+			// The message interrupted by the end of the session is not committed on the way out.
+			assert.Equal(t, tt.committedDuringDelivery, session.committedOffset())
+		})
+	}
+}
+
+// The process stops after the first message is delivered, before its offset is committed.
+func TestLogsConsumerGroupHandler_commit_crash_after_delivery(t *testing.T) {
+	session := &committingSession{markingSession: markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: context.Background()}}}
+	var committedAfterDelivery int64
+	calls := 0
+	c := newCommittingLogsHandler(t, Commit{Mode: commitAfterDelivery, Interval: time.Hour, Sync: true}, func(context.Context, plog.Logs) error {
+		calls++
+		if calls == 2 {
+			committedAfterDelivery = session.committedOffset()
+		}
+		return nil
+	})
+
+	groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage, 2)}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Offset: 1, Value: []byte("log")}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Offset: 2, Value: []byte("log")}
+	close(groupClaim.messageChan)
+	require.NoError(t, c.ConsumeClaim(session, groupClaim))
+
+	// The first message was delivered but not committed yet, it would be consumed again.
+	assert.Equal(t, int64(0), committedAfterDelivery)
+	assert.Equal(t, []int64{1, 2}, session.markedOffsets())
+	assert.Equal(t, int64(2), session.committedOffset())
+}
@@ -0,0 +1,139 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// messageMarker marks the offsets of the processed messages, it is implemented by the session.
+type messageMarker interface {
+	MarkMessage(msg *sarama.ConsumerMessage, metadata string)
+}
+
+// pendingMessage is a message of the claim whose processing may not have completed yet.
+type pendingMessage struct {
+	message *sarama.ConsumerMessage
+	done    bool
+}
+
+// claimProcessor processes the messages of a claim on up to concurrency goroutines. With a concurrency
+// of 1 the messages are processed synchronously and marked directly in the session. Otherwise, the
+// marked offset only advances past a message once all the earlier messages of the claim are marked:
+// the marks of the later messages are held until then.
+type claimProcessor struct {
+	session sarama.ConsumerGroupSession
+	// workers limits the number of goroutines, it is nil if the messages are processed synchronously.
+	workers chan struct{}
+	wg      sync.WaitGroup
+
+	mu sync.Mutex
+	// pending are the messages not marked yet, in the order they were claimed.
+	pending []*pendingMessage
+	stopped bool
+	err     error
+}
+
+func newClaimProcessor(concurrency int, session sarama.ConsumerGroupSession) *claimProcessor {
+	p := &claimProcessor{session: session}
+	if concurrency > 1 {
+		p.workers = make(chan struct{}, concurrency)
+	}
+	return p
+}
+
+// process runs handle for message, on a new goroutine if the concurrency allows it. handle marks the
+// message with the given marker once processed. process returns false once the processing of the claim
+// has to stop because handle failed.
+func (p *claimProcessor) process(message *sarama.ConsumerMessage, handle func(marker messageMarker) error) bool {
+	if p.workers == nil {
+		if err := handle(p.session); err != nil {
+			p.stop(err)
+		}
+		return !p.isStopped()
+	}
+	if p.isStopped() {
+		return false
+	}
+	p.track(message)
+	p.workers <- struct{}{}
+	p.wg.Add(1)
+	go func() {
+		defer func() {
+			<-p.workers
+			p.wg.Done()
+		}()
+		if err := handle(p); err != nil {
+			p.stop(err)
+		}
+	}()
+	return !p.isStopped()
+}
+
+// skip marks message, which is not processed.
+func (p *claimProcessor) skip(message *sarama.ConsumerMessage) {
+	if p.workers == nil {
+		p.session.MarkMessage(message, "")
+		return
+	}
+	p.track(message)
+	p.MarkMessage(message, "")
+}
+
+// MarkMessage records that message is processed, and marks the last message of the claim
+// all the earlier messages of which are processed too.
+func (p *claimProcessor) MarkMessage(message *sarama.ConsumerMessage, metadata string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, pending := range p.pending {
+		if pending.message.Offset == message.Offset {
+			pending.done = true
+			break
+		}
+	}
+	var last *sarama.ConsumerMessage
+	for len(p.pending) > 0 && p.pending[0].done {
+		last = p.pending[0].message
+		p.pending = p.pending[1:]
+	}
+	if last != nil {
+		p.session.MarkMessage(last, metadata)
+	}
+}
+
+// wait waits for the messages being processed, and returns the error the processing stopped with, if any.
+func (p *claimProcessor) wait() error {
+	p.wg.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if errors.Is(p.err, errDeliveryInterrupted) {
+		return nil
+	}
+	return p.err
+}
+
+func (p *claimProcessor) track(message *sarama.ConsumerMessage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending = append(p.pending, &pendingMessage{message: message})
+}
+
+// stop records the error of the first failed message.
+func (p *claimProcessor) stop(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.stopped {
+		p.stopped = true
+		p.err = err
+	}
+}
+
+func (p *claimProcessor) isStopped() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopped
+}
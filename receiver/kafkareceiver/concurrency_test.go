@@ -0,0 +1,147 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+)
+
+func TestClaimProcessor_low_watermark(t *testing.T) {
+	session := &markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: context.Background()}}
+	p := newClaimProcessor(3, session)
+
+	release := make(chan struct{})
+	var marked sync.WaitGroup
+	messages := []*sarama.ConsumerMessage{{Offset: 1}, {Offset: 2}, {Offset: 3}}
+	marked.Add(2)
+	for _, message := range messages {
+		message := message
+		require.True(t, p.process(message, func(marker messageMarker) error {
+			if message.Offset == 1 {
+				<-release
+			} else {
+				defer marked.Done()
+			}
+			marker.MarkMessage(message, "")
+			return nil
+		}))
+	}
+
+	// The later messages are processed, but not marked while the first one is in progress.
+	marked.Wait()
+	assert.Empty(t, session.markedOffsets())
+
+	close(release)
+	require.NoError(t, p.wait())
+	assert.Equal(t, []int64{3}, session.markedOffsets())
+}
+
+func TestClaimProcessor_skip(t *testing.T) {
+	session := &markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: context.Background()}}
+	p := newClaimProcessor(2, session)
+
+	release := make(chan struct{})
+	require.True(t, p.process(&sarama.ConsumerMessage{Offset: 1}, func(marker messageMarker) error {
+		<-release
+		marker.MarkMessage(&sarama.ConsumerMessage{Offset: 1}, "")
+		return nil
+	}))
+	p.skip(&sarama.ConsumerMessage{Offset: 2})
+	assert.Empty(t, session.markedOffsets())
+
+	close(release)
+	require.NoError(t, p.wait())
+	assert.Equal(t, []int64{2}, session.markedOffsets())
+}
+
+func TestClaimProcessor_error(t *testing.T) {
+	session := &markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: context.Background()}}
+	p := newClaimProcessor(2, session)
+
+	failed := make(chan struct{})
+	require.True(t, p.process(&sarama.ConsumerMessage{Offset: 1}, func(messageMarker) error {
+		defer close(failed)
+		return errors.New("downstream unavailable")
+	}))
+	<-failed
+	assert.Eventually(t, func() bool {
+		return !p.process(&sarama.ConsumerMessage{Offset: 2}, func(messageMarker) error { return nil })
+	}, time.Second, time.Millisecond)
+	assert.EqualError(t, p.wait(), "downstream unavailable")
+	assert.Empty(t, session.markedOffsets())
+}
+
+func TestClaimProcessor_synchronous(t *testing.T) {
+	session := &markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: context.Background()}}
+	p := newClaimProcessor(1, session)
+
+	message := &sarama.ConsumerMessage{Offset: 1}
+	require.True(t, p.process(message, func(marker messageMarker) error {
+		assert.Same(t, session, marker)
+		marker.MarkMessage(message, "")
+		return nil
+	}))
+	assert.Equal(t, []int64{1}, session.markedOffsets())
+	assert.False(t, p.process(&sarama.ConsumerMessage{Offset: 2}, func(messageMarker) error { return errDeliveryInterrupted }))
+	assert.NoError(t, p.wait())
+}
+
+func TestLogsConsumerGroupHandler_processing_concurrency(t *testing.T) {
+	obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+	require.NoError(t, err)
+
+	// The first message is only delivered once all the others are.
+	release := make(chan struct{})
+	var delivered sync.WaitGroup
+	delivered.Add(3)
+	next, err := consumer.NewLogs(func(_ context.Context, ld plog.Logs) error {
+		if ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Bytes().AsRaw()[0] == '1' {
+			<-release
+			return nil
+		}
+		delivered.Done()
+		return nil
+	})
+	require.NoError(t, err)
+	c := logsConsumerGroupHandler{
+		unmarshaler:           newRawLogsUnmarshaler(),
+		logger:                zap.NewNop(),
+		ready:                 make(chan bool),
+		nextConsumer:          next,
+		obsrecv:               obsrecv,
+		autocommitEnabled:     true,
+		messageMarking:        MessageMarking{After: true},
+		processingConcurrency: 4,
+	}
+
+	session := &markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: context.Background()}}
+	groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage, 4)}
+	for i, value := range []string{"1", "2", "3", "4"} {
+		groupClaim.messageChan <- &sarama.ConsumerMessage{Offset: int64(i + 1), Value: []byte(value)}
+	}
+	close(groupClaim.messageChan)
+	done := make(chan error)
+	go func() {
+		done <- c.ConsumeClaim(session, groupClaim)
+	}()
+
+	delivered.Wait()
+	assert.Empty(t, session.markedOffsets())
+	close(release)
+	require.NoError(t, <-done)
+	assert.Equal(t, []int64{4}, session.markedOffsets())
+}
@@ -4,8 +4,13 @@
 package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
 	"time"
 
+	"github.com/IBM/sarama"
 	"go.opentelemetry.io/collector/component"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
@@ -31,23 +36,148 @@ type MessageMarking struct {
 	OnError bool `mapstructure:"on_error"`
 }
 
+// Commit defines when the offsets of the consumed messages are committed. If set, it replaces
+// the AutoCommit settings and MessageMarking.After.
+type Commit struct {
+	// Mode is either `after_delivery`, the offset of a message is marked once the next consumer
+	// accepted it, or `after_receive`, the offset is marked before the message is delivered.
+	// `after_delivery` may consume messages twice after a crash, `after_receive` may lose them.
+	Mode string `mapstructure:"mode"`
+	// Interval is how frequently the marked offsets are committed.
+	Interval time.Duration `mapstructure:"interval"`
+	// Sync commits the marked offsets from the consuming goroutine, at most once per Interval, instead
+	// of in the background. The consumption of the partition is blocked during the commits.
+	Sync bool `mapstructure:"sync"`
+}
+
+type MessageMetadata struct {
+	// If true, the topic, partition and offset of the consumed message are
+	// added to the attributes of the received telemetry (default disabled).
+	Enable bool `mapstructure:"enable"`
+	// Placement of the attributes, either `resource` for the resource attributes, or `record` for the
+	// attributes of the spans, metric data points and log records (default "resource").
+	Placement string `mapstructure:"placement"`
+}
+
+// HeaderExtraction defines the message headers added to the resource attributes of the received telemetry.
+type HeaderExtraction struct {
+	// Headers is the list of header keys to extract.
+	Headers []string `mapstructure:"headers"`
+	// Prefix is prepended to the header key to build the attribute key (default none).
+	Prefix string `mapstructure:"prefix"`
+	// HeadersToAttributes is a regular expression, the headers whose key matches it are extracted too.
+	// Disabled if empty (default).
+	HeadersToAttributes string `mapstructure:"headers_to_attributes"`
+	// MaxHeaders is the maximum number of headers of a message extracted with HeadersToAttributes (default 16).
+	MaxHeaders int `mapstructure:"max_headers"`
+	// MaxHeaderBytes is the maximum total size of the keys and values of the headers of a message extracted
+	// with HeadersToAttributes (default 4096).
+	MaxHeaderBytes int `mapstructure:"max_header_bytes"`
+}
+
+// KeyExtraction defines how the message key is added to the resource attributes of the received telemetry.
+type KeyExtraction struct {
+	// Attribute is the key of the resource attribute holding the message key. Disabled if empty (default).
+	Attribute string `mapstructure:"attribute"`
+	// Encoding of the message key in the attribute, either `string` or `hex` (default "string").
+	// Keys that are not valid UTF-8 are always hex-encoded.
+	Encoding string `mapstructure:"encoding"`
+}
+
+// ConfluentWireFormat defines how the Confluent wire-format framing of the otlp_proto messages is handled.
+type ConfluentWireFormat struct {
+	// Mode is `disabled`, `enabled` to strip the framing of every message, or `auto` to strip it
+	// from the messages that have it only (default "disabled").
+	Mode string `mapstructure:"mode"`
+	// AllowedSchemaIDs are the schema IDs accepted in the framing. All are accepted if empty (default).
+	AllowedSchemaIDs []uint32 `mapstructure:"allowed_schema_ids"`
+}
+
+// Backpressure defines when the fetching of the claimed partitions is paused.
+type Backpressure struct {
+	// Enable pauses a partition while the next consumer refuses its messages with resource exhausted
+	// errors, such as the ones of the memory_limiter processor. The refused messages are redelivered
+	// every CheckInterval until they are accepted, instead of applying OnError (default false).
+	Enable bool `mapstructure:"enable"`
+	// MemoryLimitMiB pauses all the claimed partitions while the memory allocated by the collector
+	// exceeds it. Disabled if 0 (default).
+	MemoryLimitMiB uint64 `mapstructure:"memory_limit_mib"`
+	// CheckInterval is the time between the redeliveries of a refused message, and between the checks
+	// of the memory in use (default 1s).
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+}
+
+// ErrorBackOff defines the exponential backoff between the deliveries of a message when OnError is `retry`.
+type ErrorBackOff struct {
+	// InitialInterval is the time to wait after the first failure before retrying (default 1s).
+	InitialInterval time.Duration `mapstructure:"initial_interval"`
+	// MaxInterval is the upper bound of the time to wait between two retries (default 30s).
+	MaxInterval time.Duration `mapstructure:"max_interval"`
+	// MaxElapsedTime is the maximum time spent retrying a message before it is dropped.
+	// 0 retries until the message is delivered (default 5m).
+	MaxElapsedTime time.Duration `mapstructure:"max_elapsed_time"`
+}
+
+// Fetch defines the size of the fetch requests sent to the brokers.
+type Fetch struct {
+	// MinBytes is the minimum number of message bytes the broker waits for before answering
+	// a fetch request, up to MaxWait (default 1).
+	MinBytes int32 `mapstructure:"min_bytes"`
+	// DefaultBytes is the number of message bytes fetched per partition in each request.
+	// It should be larger than most messages to avoid extra round trips (default 1048576).
+	DefaultBytes int32 `mapstructure:"default_bytes"`
+	// MaxBytes is the maximum number of message bytes fetched per partition in each request,
+	// larger messages are not consumed. 0 means no limit (default 0).
+	MaxBytes int32 `mapstructure:"max_bytes"`
+}
+
+// Assignment defines a static assignment of partitions consumed without joining a consumer group.
+type Assignment struct {
+	// Partitions maps every topic to the list of its partitions to consume from.
+	Partitions map[string][]int32 `mapstructure:"partitions"`
+
+	// StorageID is the storage extension used to persist the offsets of the consumed
+	// messages. If not set, the offsets are only kept in memory.
+	StorageID *component.ID `mapstructure:"storage"`
+}
+
 // Config defines configuration for Kafka receiver.
 type Config struct {
 	// The list of kafka brokers (default localhost:9092)
 	Brokers []string `mapstructure:"brokers"`
 	// Kafka protocol version
 	ProtocolVersion string `mapstructure:"protocol_version"`
-	// The name of the kafka topic to consume from (default "otlp_spans")
+	// The name of the kafka topic to consume from (default "otlp_spans").
+	// Ignored if Topics or TopicRegex is set.
 	Topic string `mapstructure:"topic"`
+	// The names of the kafka topics to consume from
+	Topics []string `mapstructure:"topics"`
+	// TopicRegex subscribes to every topic of the cluster matching the regular expression
+	TopicRegex string `mapstructure:"topic_regex"`
+	// How frequently the topics of the cluster are matched against TopicRegex (default 1m)
+	TopicRefreshInterval time.Duration `mapstructure:"topic_refresh_interval"`
 	// Encoding of the messages (default "otlp_proto")
 	Encoding string `mapstructure:"encoding"`
-	// The consumer group that receiver will be consuming messages from (default "otel-collector")
+	// SchemaRegistryURL is the URL of the Confluent compatible schema registry the schemas of the avro messages
+	// in the Confluent wire format are fetched from by their ID. If empty, only the avro object container files
+	// embedding their schema are unmarshaled.
+	SchemaRegistryURL string `mapstructure:"schema_registry_url"`
+	// ConfluentWireFormat strips the Confluent wire-format framing of the otlp_proto messages
+	ConfluentWireFormat ConfluentWireFormat `mapstructure:"confluent_wire_format"`
+	// The consumer group that receiver will be consuming messages from (default "otel-collector-<signal>")
 	GroupID string `mapstructure:"group_id"`
-	// The consumer client ID that receiver will use (default "otel-collector")
+	// Assignment pins the receiver to the given partitions instead of joining a consumer group.
+	// It cannot be used together with GroupID.
+	Assignment *Assignment `mapstructure:"assignment"`
+	// The consumer client ID that receiver will use (default "otel-collector-<signal>")
 	ClientID string `mapstructure:"client_id"`
 	// The initial offset to use if no offset was previously committed.
-	// Must be `latest` or `earliest` (default "latest").
+	// Must be `latest`, `earliest` or `timestamp` (default "latest").
 	InitialOffset string `mapstructure:"initial_offset"`
+	// InitialOffsetTimestamp is the time to start consuming from if InitialOffset is `timestamp`.
+	InitialOffsetTimestamp time.Time `mapstructure:"initial_offset_timestamp"`
+	// ForceSeek seeks to InitialOffsetTimestamp even if offsets were previously committed.
+	ForceSeek bool `mapstructure:"force_seek"`
 
 	// Metadata is the namespace for metadata management properties used by the
 	// Client, and shared by the Producer/Consumer.
@@ -55,21 +185,284 @@ type Config struct {
 
 	Authentication kafkaexporter.Authentication `mapstructure:"auth"`
 
+	// Fetch controls the size of the fetch requests
+	Fetch Fetch `mapstructure:"fetch"`
+	// MaxWait is the maximum time the broker waits for Fetch.MinBytes before answering (default 500ms)
+	MaxWait time.Duration `mapstructure:"max_wait"`
+	// SessionTimeout is the time after which a consumer missing heartbeats is removed from the group (default 10s)
+	SessionTimeout time.Duration `mapstructure:"session_timeout"`
+	// HeartbeatInterval is the time between the heartbeats sent to the group coordinator,
+	// it has to be lower than SessionTimeout (default 3s)
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"`
+	// MaxProcessingTime is the time a message is expected to be processed in before the
+	// fetching of the partition is paused (default 100ms)
+	MaxProcessingTime time.Duration `mapstructure:"max_processing_time"`
+	// GroupRebalanceStrategy is the strategy the partitions are assigned to the members of the
+	// consumer group with: `range`, `roundrobin` or `sticky` (default "range")
+	GroupRebalanceStrategy string `mapstructure:"group_rebalance_strategy"`
+	// GroupInstanceID makes the receiver a static member of the consumer group, so that its partitions
+	// are not reassigned when it restarts within SessionTimeout. It requires Kafka 2.3.0 or later.
+	GroupInstanceID string `mapstructure:"group_instance_id"`
+
 	// Controls the auto-commit functionality
 	AutoCommit AutoCommit `mapstructure:"autocommit"`
 
 	// Controls the way the messages are marked as consumed
 	MessageMarking MessageMarking `mapstructure:"message_marking"`
+
+	// Commit controls when the offsets are committed. It replaces AutoCommit and MessageMarking.After if set.
+	Commit *Commit `mapstructure:"commit"`
+
+	// Controls whether the Kafka message metadata is attached to the received telemetry
+	MessageMetadata MessageMetadata `mapstructure:"message_metadata"`
+
+	// Controls which Kafka message headers are attached to the received telemetry
+	HeaderExtraction HeaderExtraction `mapstructure:"header_extraction"`
+
+	// Controls whether the Kafka message key is attached to the received telemetry
+	KeyExtraction KeyExtraction `mapstructure:"key_extraction"`
+
+	// ObservedTimestamp is the observed timestamp of the received log records: `receive_time`, or
+	// `message_timestamp` for the timestamp of the Kafka message (default "receive_time").
+	ObservedTimestamp string `mapstructure:"observed_timestamp"`
+
+	// Backpressure pauses the fetching of the claimed partitions while the pipeline is overloaded
+	Backpressure Backpressure `mapstructure:"backpressure"`
+
+	// MaxMessageAge drops the messages whose timestamp is older than the given age without
+	// unmarshaling them. Their offsets are still marked as consumed (default 0, disabled).
+	MaxMessageAge time.Duration `mapstructure:"max_message_age"`
+
+	// LagRefreshInterval is how often the lag of every claimed partition is recorded, 0 disables it (default 10s)
+	LagRefreshInterval time.Duration `mapstructure:"lag_refresh_interval"`
+
+	// OnError is what happens to a message the next consumer fails to process with a
+	// non-permanent error, either `drop` or `retry` (default "drop"). `retry` blocks the
+	// consumption of the partition and redelivers the message until it succeeds.
+	OnError string `mapstructure:"on_error"`
+	// ErrorBackOff configures the time between the deliveries of a message when OnError is `retry`.
+	ErrorBackOff ErrorBackOff `mapstructure:"error_backoff"`
+
+	// ProcessingConcurrency is the number of messages of every partition processed concurrently (default 1).
+	// The order of the messages of a partition is only kept with 1. The offsets are committed up to the
+	// first message still being processed.
+	ProcessingConcurrency int `mapstructure:"processing_concurrency"`
+
+	// DeadLetterTopic is the topic the messages that cannot be unmarshaled are produced to,
+	// with their original key and headers. Disabled if empty (default).
+	DeadLetterTopic string `mapstructure:"dead_letter_topic"`
+	// OnDecodeError is what happens to the messages that cannot be unmarshaled: `skip` marks them and goes on,
+	// `halt` stops the consumption of the partition without marking them, and `dead_letter` produces them to
+	// DeadLetterTopic. If empty, they are dead-lettered if DeadLetterTopic is set, and handled like the messages
+	// the next consumer fails otherwise.
+	OnDecodeError string `mapstructure:"on_decode_error"`
 }
 
 const (
-	offsetLatest   string = "latest"
-	offsetEarliest string = "earliest"
+	offsetLatest    string = "latest"
+	offsetEarliest  string = "earliest"
+	offsetTimestamp string = "timestamp"
+)
+
+const (
+	keyEncodingString = "string"
+	keyEncodingHex    = "hex"
+)
+
+const (
+	messageMetadataPlacementResource = "resource"
+	messageMetadataPlacementRecord   = "record"
+)
+
+const (
+	observedTimestampReceiveTime = "receive_time"
+	observedTimestampMessage     = "message_timestamp"
 )
 
 var _ component.Config = (*Config)(nil)
 
 // Validate checks the receiver configuration is valid
 func (cfg *Config) Validate() error {
+	if cfg.InitialOffset == offsetTimestamp {
+		if cfg.InitialOffsetTimestamp.IsZero() {
+			return errors.New("initial_offset_timestamp is required when initial_offset is timestamp")
+		}
+		if cfg.Assignment != nil {
+			return errors.New("initial_offset timestamp cannot be used together with assignment")
+		}
+	} else if cfg.ForceSeek {
+		return errors.New("force_seek requires initial_offset timestamp")
+	}
+	if cfg.TopicRegex != "" {
+		if _, err := regexp.Compile(cfg.TopicRegex); err != nil {
+			return fmt.Errorf("topic_regex is not a valid regular expression: %w", err)
+		}
+		if cfg.TopicRefreshInterval <= 0 {
+			return fmt.Errorf("topic_refresh_interval has to be positive. configured value %v", cfg.TopicRefreshInterval)
+		}
+		if cfg.Assignment != nil {
+			return errors.New("topic_regex cannot be used together with assignment")
+		}
+	}
+	if cfg.Commit != nil {
+		switch cfg.Commit.Mode {
+		case commitAfterDelivery, commitAfterReceive:
+		default:
+			return fmt.Errorf("commit.mode should be one of 'after_delivery' or 'after_receive'. configured value %v", cfg.Commit.Mode)
+		}
+		if cfg.Commit.Interval < 0 || (!cfg.Commit.Sync && cfg.Commit.Interval == 0) {
+			return fmt.Errorf("commit.interval has to be positive, or zero if commit.sync is true. configured value %v", cfg.Commit.Interval)
+		}
+	}
+	if cfg.SchemaRegistryURL != "" {
+		if u, err := url.Parse(cfg.SchemaRegistryURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("schema_registry_url has to be an http or https URL. configured value %v", cfg.SchemaRegistryURL)
+		}
+	}
+	switch cfg.ConfluentWireFormat.Mode {
+	case "", confluentWireFormatDisabled:
+	case confluentWireFormatEnabled, confluentWireFormatAuto:
+		if cfg.Encoding != "" && cfg.Encoding != defaultEncoding {
+			return fmt.Errorf("confluent_wire_format requires the otlp_proto encoding. configured value %v", cfg.Encoding)
+		}
+	default:
+		return fmt.Errorf("confluent_wire_format.mode should be one of 'disabled', 'enabled' or 'auto'. configured value %v", cfg.ConfluentWireFormat.Mode)
+	}
+	if cfg.HeaderExtraction.HeadersToAttributes != "" {
+		if _, err := regexp.Compile(cfg.HeaderExtraction.HeadersToAttributes); err != nil {
+			return fmt.Errorf("header_extraction.headers_to_attributes is not a valid regular expression: %w", err)
+		}
+		if cfg.HeaderExtraction.MaxHeaders <= 0 {
+			return fmt.Errorf("header_extraction.max_headers has to be positive. configured value %v", cfg.HeaderExtraction.MaxHeaders)
+		}
+		if cfg.HeaderExtraction.MaxHeaderBytes <= 0 {
+			return fmt.Errorf("header_extraction.max_header_bytes has to be positive. configured value %v", cfg.HeaderExtraction.MaxHeaderBytes)
+		}
+	}
+	switch cfg.KeyExtraction.Encoding {
+	case "", keyEncodingString, keyEncodingHex:
+	default:
+		return fmt.Errorf("key_extraction.encoding should be one of 'string' or 'hex'. configured value %v", cfg.KeyExtraction.Encoding)
+	}
+	switch cfg.MessageMetadata.Placement {
+	case "", messageMetadataPlacementResource, messageMetadataPlacementRecord:
+	default:
+		return fmt.Errorf("message_metadata.placement should be one of 'resource' or 'record'. configured value %v", cfg.MessageMetadata.Placement)
+	}
+	switch cfg.ObservedTimestamp {
+	case "", observedTimestampReceiveTime, observedTimestampMessage:
+	default:
+		return fmt.Errorf("observed_timestamp should be one of 'receive_time' or 'message_timestamp'. configured value %v", cfg.ObservedTimestamp)
+	}
+	if err := validateConsumerTuning(cfg); err != nil {
+		return err
+	}
+	if cfg.ProcessingConcurrency < 0 {
+		return fmt.Errorf("processing_concurrency must not be negative. configured value %v", cfg.ProcessingConcurrency)
+	}
+	if cfg.Backpressure.Enable && cfg.Backpressure.CheckInterval <= 0 {
+		return fmt.Errorf("backpressure.check_interval has to be positive. configured value %v", cfg.Backpressure.CheckInterval)
+	}
+	if cfg.MaxMessageAge < 0 {
+		return fmt.Errorf("max_message_age must not be negative. configured value %v", cfg.MaxMessageAge)
+	}
+	if cfg.LagRefreshInterval < 0 {
+		return fmt.Errorf("lag_refresh_interval must not be negative. configured value %v", cfg.LagRefreshInterval)
+	}
+	if cfg.DeadLetterTopic != "" {
+		for _, topic := range configuredTopics(*cfg) {
+			if topic == cfg.DeadLetterTopic {
+				return fmt.Errorf("dead_letter_topic must not be consumed by the receiver. configured value %v", cfg.DeadLetterTopic)
+			}
+		}
+		if cfg.TopicRegex != "" && regexp.MustCompile(cfg.TopicRegex).MatchString(cfg.DeadLetterTopic) {
+			return fmt.Errorf("dead_letter_topic must not match topic_regex. configured value %v", cfg.DeadLetterTopic)
+		}
+	}
+	switch cfg.OnDecodeError {
+	case "":
+	case onDecodeErrorSkip, onDecodeErrorHalt:
+		if cfg.DeadLetterTopic != "" {
+			return fmt.Errorf("dead_letter_topic cannot be used together with on_decode_error %v", cfg.OnDecodeError)
+		}
+	case onDecodeErrorDeadLetter:
+		if cfg.DeadLetterTopic == "" {
+			return errors.New("dead_letter_topic must be set when on_decode_error is dead_letter")
+		}
+	default:
+		return fmt.Errorf("on_decode_error should be one of 'skip', 'halt' or 'dead_letter'. configured value %v", cfg.OnDecodeError)
+	}
+	switch cfg.OnError {
+	case "", onErrorDrop:
+	case onErrorRetry:
+		if cfg.ErrorBackOff.InitialInterval <= 0 {
+			return fmt.Errorf("error_backoff.initial_interval has to be positive. configured value %v", cfg.ErrorBackOff.InitialInterval)
+		}
+		if cfg.ErrorBackOff.MaxInterval < cfg.ErrorBackOff.InitialInterval {
+			return fmt.Errorf("error_backoff.max_interval must not be less than initial_interval. configured value %v", cfg.ErrorBackOff.MaxInterval)
+		}
+		if cfg.ErrorBackOff.MaxElapsedTime < 0 {
+			return fmt.Errorf("error_backoff.max_elapsed_time must not be negative. configured value %v", cfg.ErrorBackOff.MaxElapsedTime)
+		}
+	default:
+		return fmt.Errorf("on_error should be one of 'drop' or 'retry'. configured value %v", cfg.OnError)
+	}
+	if cfg.Assignment != nil {
+		if cfg.GroupID != "" {
+			return errors.New("group_id cannot be used together with assignment")
+		}
+		if len(cfg.Assignment.Partitions) == 0 {
+			return errors.New("assignment.partitions must not be empty")
+		}
+		for topic, partitions := range cfg.Assignment.Partitions {
+			if len(partitions) == 0 {
+				return fmt.Errorf("assignment.partitions of topic %q must not be empty", topic)
+			}
+		}
+	}
+	return cfg.Authentication.Validate()
+}
+
+func validateConsumerTuning(cfg *Config) error {
+	if cfg.Fetch.MinBytes < 0 || cfg.Fetch.DefaultBytes < 0 || cfg.Fetch.MaxBytes < 0 {
+		return fmt.Errorf("fetch must not be negative. configured value %+v", cfg.Fetch)
+	}
+	if cfg.Fetch.MaxBytes > 0 {
+		if cfg.Fetch.MinBytes > cfg.Fetch.MaxBytes {
+			return fmt.Errorf("fetch.min_bytes must not be greater than fetch.max_bytes. configured value %v", cfg.Fetch.MinBytes)
+		}
+		if cfg.Fetch.DefaultBytes > cfg.Fetch.MaxBytes {
+			return fmt.Errorf("fetch.default_bytes must not be greater than fetch.max_bytes. configured value %v", cfg.Fetch.DefaultBytes)
+		}
+	}
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{name: "max_wait", value: cfg.MaxWait},
+		{name: "session_timeout", value: cfg.SessionTimeout},
+		{name: "heartbeat_interval", value: cfg.HeartbeatInterval},
+		{name: "max_processing_time", value: cfg.MaxProcessingTime},
+	} {
+		if d.value < 0 {
+			return fmt.Errorf("%s must not be negative. configured value %v", d.name, d.value)
+		}
+	}
+	if cfg.HeartbeatInterval > 0 && cfg.SessionTimeout > 0 && cfg.HeartbeatInterval >= cfg.SessionTimeout {
+		return fmt.Errorf("heartbeat_interval has to be less than session_timeout. configured value %v", cfg.HeartbeatInterval)
+	}
+	if _, ok := balanceStrategies[cfg.GroupRebalanceStrategy]; !ok && cfg.GroupRebalanceStrategy != "" {
+		return fmt.Errorf("group_rebalance_strategy should be one of 'range', 'roundrobin' or 'sticky'. configured value %v", cfg.GroupRebalanceStrategy)
+	}
+	if cfg.GroupInstanceID != "" {
+		if cfg.Assignment != nil {
+			return errors.New("group_instance_id cannot be used together with assignment")
+		}
+		// The protocol version defaults to the one of sarama, which predates static membership.
+		version, err := sarama.ParseKafkaVersion(cfg.ProtocolVersion)
+		if err != nil || !version.IsAtLeast(sarama.V2_3_0_0) {
+			return fmt.Errorf("group_instance_id requires protocol_version 2.3.0 or later. configured value %v", cfg.ProtocolVersion)
+		}
+	}
 	return nil
 }
@@ -23,6 +23,7 @@ func TestLoadConfig(t *testing.T) {
 
 	cm, err := confmaptest.LoadConf(filepath.Join("testdata", "config.yaml"))
 	require.NoError(t, err)
+	storageID := component.NewID("file_storage")
 
 	tests := []struct {
 		id          component.ID
@@ -32,12 +33,13 @@ func TestLoadConfig(t *testing.T) {
 		{
 			id: component.NewIDWithName(metadata.Type, ""),
 			expected: &Config{
-				Topic:         "spans",
-				Encoding:      "otlp_proto",
-				Brokers:       []string{"foo:123", "bar:456"},
-				ClientID:      "otel-collector",
-				GroupID:       "otel-collector",
-				InitialOffset: "latest",
+				Topic:                "spans",
+				TopicRefreshInterval: time.Minute,
+				Encoding:             "otlp_proto",
+				Brokers:              []string{"foo:123", "bar:456"},
+				ClientID:             "otel-collector",
+				GroupID:              "otel-collector",
+				InitialOffset:        "latest",
 				Authentication: kafkaexporter.Authentication{
 					TLS: &configtls.TLSClientSetting{
 						TLSSetting: configtls.TLSSetting{
@@ -54,22 +56,57 @@ func TestLoadConfig(t *testing.T) {
 						Backoff: time.Second * 5,
 					},
 				},
+				Fetch: Fetch{
+					MinBytes:     1,
+					DefaultBytes: 1048576,
+				},
+				MaxWait:                500 * time.Millisecond,
+				SessionTimeout:         10 * time.Second,
+				HeartbeatInterval:      3 * time.Second,
+				MaxProcessingTime:      100 * time.Millisecond,
+				GroupRebalanceStrategy: "range",
 				AutoCommit: AutoCommit{
 					Enable:   true,
 					Interval: 1 * time.Second,
 				},
+				MessageMetadata: MessageMetadata{
+					Placement: "resource",
+				},
+				HeaderExtraction: HeaderExtraction{
+					MaxHeaders:     16,
+					MaxHeaderBytes: 4096,
+				},
+				KeyExtraction: KeyExtraction{
+					Encoding: "string",
+				},
+				ConfluentWireFormat: ConfluentWireFormat{
+					Mode: "disabled",
+				},
+				ObservedTimestamp: "receive_time",
+				OnError:           "drop",
+				ErrorBackOff: ErrorBackOff{
+					InitialInterval: time.Second,
+					MaxInterval:     30 * time.Second,
+					MaxElapsedTime:  5 * time.Minute,
+				},
+				ProcessingConcurrency: 1,
+				Backpressure: Backpressure{
+					CheckInterval: time.Second,
+				},
+				LagRefreshInterval: 10 * time.Second,
 			},
 		},
 		{
 
 			id: component.NewIDWithName(metadata.Type, "logs"),
 			expected: &Config{
-				Topic:         "logs",
-				Encoding:      "direct",
-				Brokers:       []string{"coffee:123", "foobar:456"},
-				ClientID:      "otel-collector",
-				GroupID:       "otel-collector",
-				InitialOffset: "earliest",
+				Topic:                "logs",
+				TopicRefreshInterval: time.Minute,
+				Encoding:             "direct",
+				Brokers:              []string{"coffee:123", "foobar:456"},
+				ClientID:             "otel-collector",
+				GroupID:              "otel-collector",
+				InitialOffset:        "earliest",
 				Authentication: kafkaexporter.Authentication{
 					TLS: &configtls.TLSClientSetting{
 						TLSSetting: configtls.TLSSetting{
@@ -86,10 +123,161 @@ func TestLoadConfig(t *testing.T) {
 						Backoff: time.Second * 5,
 					},
 				},
+				Fetch: Fetch{
+					MinBytes:     1,
+					DefaultBytes: 8388608,
+					MaxBytes:     16777216,
+				},
+				MaxWait:                500 * time.Millisecond,
+				SessionTimeout:         45 * time.Second,
+				HeartbeatInterval:      15 * time.Second,
+				MaxProcessingTime:      100 * time.Millisecond,
+				GroupRebalanceStrategy: "range",
 				AutoCommit: AutoCommit{
 					Enable:   true,
 					Interval: 1 * time.Second,
 				},
+				MessageMetadata: MessageMetadata{
+					Placement: "resource",
+				},
+				HeaderExtraction: HeaderExtraction{
+					MaxHeaders:     16,
+					MaxHeaderBytes: 4096,
+				},
+				KeyExtraction: KeyExtraction{
+					Encoding: "string",
+				},
+				ConfluentWireFormat: ConfluentWireFormat{
+					Mode: "disabled",
+				},
+				ObservedTimestamp: "receive_time",
+				OnError:           "drop",
+				ErrorBackOff: ErrorBackOff{
+					InitialInterval: time.Second,
+					MaxInterval:     30 * time.Second,
+					MaxElapsedTime:  5 * time.Minute,
+				},
+				ProcessingConcurrency: 1,
+				Backpressure: Backpressure{
+					CheckInterval: time.Second,
+				},
+				LagRefreshInterval: 10 * time.Second,
+			},
+		},
+		{
+			id: component.NewIDWithName(metadata.Type, "assignment"),
+			expected: &Config{
+				Topic:                "otlp_spans",
+				TopicRefreshInterval: time.Minute,
+				Encoding:             "otlp_proto",
+				Brokers:              []string{"foo:123"},
+				InitialOffset:        "latest",
+				Assignment: &Assignment{
+					Partitions: map[string][]int32{"spans": {0, 1, 2, 3}},
+					StorageID:  &storageID,
+				},
+				Metadata: kafkaexporter.Metadata{
+					Full: true,
+					Retry: kafkaexporter.MetadataRetry{
+						Max:     3,
+						Backoff: time.Millisecond * 250,
+					},
+				},
+				Fetch: Fetch{
+					MinBytes:     1,
+					DefaultBytes: 1048576,
+				},
+				MaxWait:                500 * time.Millisecond,
+				SessionTimeout:         10 * time.Second,
+				HeartbeatInterval:      3 * time.Second,
+				MaxProcessingTime:      100 * time.Millisecond,
+				GroupRebalanceStrategy: "range",
+				AutoCommit: AutoCommit{
+					Enable:   true,
+					Interval: 1 * time.Second,
+				},
+				MessageMetadata: MessageMetadata{
+					Placement: "resource",
+				},
+				HeaderExtraction: HeaderExtraction{
+					MaxHeaders:     16,
+					MaxHeaderBytes: 4096,
+				},
+				KeyExtraction: KeyExtraction{
+					Encoding: "string",
+				},
+				ConfluentWireFormat: ConfluentWireFormat{
+					Mode: "disabled",
+				},
+				ObservedTimestamp: "receive_time",
+				OnError:           "drop",
+				ErrorBackOff: ErrorBackOff{
+					InitialInterval: time.Second,
+					MaxInterval:     30 * time.Second,
+					MaxElapsedTime:  5 * time.Minute,
+				},
+				ProcessingConcurrency: 1,
+				Backpressure: Backpressure{
+					CheckInterval: time.Second,
+				},
+				LagRefreshInterval: 10 * time.Second,
+			},
+		},
+		{
+			id: component.NewIDWithName(metadata.Type, "timestamp"),
+			expected: &Config{
+				Topic:                  "otlp_spans",
+				TopicRefreshInterval:   time.Minute,
+				Encoding:               "otlp_proto",
+				Brokers:                []string{"foo:123"},
+				InitialOffset:          "timestamp",
+				InitialOffsetTimestamp: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+				ForceSeek:              true,
+				Metadata: kafkaexporter.Metadata{
+					Full: true,
+					Retry: kafkaexporter.MetadataRetry{
+						Max:     3,
+						Backoff: time.Millisecond * 250,
+					},
+				},
+				Fetch: Fetch{
+					MinBytes:     1,
+					DefaultBytes: 1048576,
+				},
+				MaxWait:                500 * time.Millisecond,
+				SessionTimeout:         10 * time.Second,
+				HeartbeatInterval:      3 * time.Second,
+				MaxProcessingTime:      100 * time.Millisecond,
+				GroupRebalanceStrategy: "range",
+				AutoCommit: AutoCommit{
+					Enable:   true,
+					Interval: 1 * time.Second,
+				},
+				MessageMetadata: MessageMetadata{
+					Placement: "resource",
+				},
+				HeaderExtraction: HeaderExtraction{
+					MaxHeaders:     16,
+					MaxHeaderBytes: 4096,
+				},
+				KeyExtraction: KeyExtraction{
+					Encoding: "string",
+				},
+				ConfluentWireFormat: ConfluentWireFormat{
+					Mode: "disabled",
+				},
+				ObservedTimestamp: "receive_time",
+				OnError:           "drop",
+				ErrorBackOff: ErrorBackOff{
+					InitialInterval: time.Second,
+					MaxInterval:     30 * time.Second,
+					MaxElapsedTime:  5 * time.Minute,
+				},
+				ProcessingConcurrency: 1,
+				Backpressure: Backpressure{
+					CheckInterval: time.Second,
+				},
+				LagRefreshInterval: 10 * time.Second,
 			},
 		},
 	}
@@ -108,3 +296,374 @@ func TestLoadConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfig_signal_defaults(t *testing.T) {
+	cm, err := confmaptest.LoadConf(filepath.Join("testdata", "config.yaml"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		id       component.ID
+		signal   component.DataType
+		groupID  string
+		clientID string
+	}{
+		{
+			// The existing single-group users keep their group by setting it explicitly.
+			id:       component.NewIDWithName(metadata.Type, ""),
+			signal:   component.DataTypeMetrics,
+			groupID:  "otel-collector",
+			clientID: "otel-collector",
+		},
+		{
+			id:       component.NewIDWithName(metadata.Type, "timestamp"),
+			signal:   component.DataTypeTraces,
+			groupID:  "otel-collector-traces",
+			clientID: "otel-collector-traces",
+		},
+		{
+			id:       component.NewIDWithName(metadata.Type, "timestamp"),
+			signal:   component.DataTypeLogs,
+			groupID:  "otel-collector-logs",
+			clientID: "otel-collector-logs",
+		},
+		{
+			id:       component.NewIDWithName(metadata.Type, "assignment"),
+			signal:   component.DataTypeTraces,
+			clientID: "otel-collector-traces",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id.String()+"/"+string(tt.signal), func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			sub, err := cm.Sub(tt.id.String())
+			require.NoError(t, err)
+			require.NoError(t, component.UnmarshalConfig(sub, cfg))
+			require.NoError(t, component.ValidateConfig(cfg))
+
+			config := withSignalDefaults(*cfg, tt.signal)
+			assert.Equal(t, tt.groupID, config.GroupID)
+			assert.Equal(t, tt.clientID, config.ClientID)
+		})
+	}
+}
+
+func TestValidate_assignment(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *Config
+		expectedErr string
+	}{
+		{
+			name: "group_id and assignment",
+			config: &Config{
+				GroupID:    "otel-collector",
+				Assignment: &Assignment{Partitions: map[string][]int32{"spans": {0}}},
+			},
+			expectedErr: "group_id cannot be used together with assignment",
+		},
+		{
+			name: "no partitions",
+			config: &Config{
+				Assignment: &Assignment{},
+			},
+			expectedErr: "assignment.partitions must not be empty",
+		},
+		{
+			name: "no partitions for topic",
+			config: &Config{
+				Assignment: &Assignment{Partitions: map[string][]int32{"spans": {}}},
+			},
+			expectedErr: `assignment.partitions of topic "spans" must not be empty`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.config.Validate(), tt.expectedErr)
+		})
+	}
+}
+
+func TestValidate_max_message_age(t *testing.T) {
+	config := &Config{MaxMessageAge: -time.Minute}
+	assert.EqualError(t, config.Validate(), "max_message_age must not be negative. configured value -1m0s")
+}
+
+func TestValidate_lag_refresh_interval(t *testing.T) {
+	config := &Config{LagRefreshInterval: -time.Second}
+	assert.EqualError(t, config.Validate(), "lag_refresh_interval must not be negative. configured value -1s")
+}
+
+func TestValidate_initial_offset_timestamp(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *Config
+		expectedErr string
+	}{
+		{
+			name:        "missing timestamp",
+			config:      &Config{InitialOffset: offsetTimestamp},
+			expectedErr: "initial_offset_timestamp is required when initial_offset is timestamp",
+		},
+		{
+			name: "assignment",
+			config: &Config{
+				InitialOffset:          offsetTimestamp,
+				InitialOffsetTimestamp: time.Now(),
+				Assignment:             &Assignment{Partitions: map[string][]int32{"spans": {0}}},
+			},
+			expectedErr: "initial_offset timestamp cannot be used together with assignment",
+		},
+		{
+			name:        "force_seek without timestamp",
+			config:      &Config{InitialOffset: offsetEarliest, ForceSeek: true},
+			expectedErr: "force_seek requires initial_offset timestamp",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.config.Validate(), tt.expectedErr)
+		})
+	}
+}
+
+func TestValidate_topic_regex(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *Config
+		expectedErr string
+	}{
+		{
+			name:        "invalid regex",
+			config:      &Config{TopicRegex: "otlp_spans_(", TopicRefreshInterval: time.Minute},
+			expectedErr: "topic_regex is not a valid regular expression: error parsing regexp: missing closing ): `otlp_spans_(`",
+		},
+		{
+			name:        "no refresh interval",
+			config:      &Config{TopicRegex: "^otlp_spans_.*"},
+			expectedErr: "topic_refresh_interval has to be positive. configured value 0s",
+		},
+		{
+			name: "assignment",
+			config: &Config{
+				TopicRegex:           "^otlp_spans_.*",
+				TopicRefreshInterval: time.Minute,
+				Assignment:           &Assignment{Partitions: map[string][]int32{"spans": {0}}},
+			},
+			expectedErr: "topic_regex cannot be used together with assignment",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.config.Validate(), tt.expectedErr)
+		})
+	}
+}
+
+func TestValidate_on_error(t *testing.T) {
+	backOff := ErrorBackOff{InitialInterval: time.Second, MaxInterval: time.Minute}
+	assert.NoError(t, (&Config{OnError: onErrorRetry, ErrorBackOff: backOff}).Validate())
+
+	tests := []struct {
+		name        string
+		config      *Config
+		expectedErr string
+	}{
+		{
+			name:        "unknown mode",
+			config:      &Config{OnError: "ignore"},
+			expectedErr: "on_error should be one of 'drop' or 'retry'. configured value ignore",
+		},
+		{
+			name:        "no initial interval",
+			config:      &Config{OnError: onErrorRetry},
+			expectedErr: "error_backoff.initial_interval has to be positive. configured value 0s",
+		},
+		{
+			name:        "max interval less than initial interval",
+			config:      &Config{OnError: onErrorRetry, ErrorBackOff: ErrorBackOff{InitialInterval: time.Minute, MaxInterval: time.Second}},
+			expectedErr: "error_backoff.max_interval must not be less than initial_interval. configured value 1s",
+		},
+		{
+			name:        "negative max elapsed time",
+			config:      &Config{OnError: onErrorRetry, ErrorBackOff: ErrorBackOff{InitialInterval: time.Second, MaxInterval: time.Second, MaxElapsedTime: -time.Second}},
+			expectedErr: "error_backoff.max_elapsed_time must not be negative. configured value -1s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.config.Validate(), tt.expectedErr)
+		})
+	}
+}
+
+func TestValidate_dead_letter_topic(t *testing.T) {
+	assert.NoError(t, (&Config{Topic: "spans", DeadLetterTopic: "spans_dlq"}).Validate())
+	assert.EqualError(t, (&Config{Topic: "spans", DeadLetterTopic: "spans"}).Validate(),
+		"dead_letter_topic must not be consumed by the receiver. configured value spans")
+	assert.EqualError(t, (&Config{Topics: []string{"logs", "spans"}, DeadLetterTopic: "spans"}).Validate(),
+		"dead_letter_topic must not be consumed by the receiver. configured value spans")
+	assert.EqualError(t, (&Config{TopicRegex: "^spans.*", TopicRefreshInterval: time.Minute, DeadLetterTopic: "spans_dlq"}).Validate(),
+		"dead_letter_topic must not match topic_regex. configured value spans_dlq")
+}
+
+func TestValidate_authentication(t *testing.T) {
+	config := &Config{Authentication: kafkaexporter.Authentication{SASL: &kafkaexporter.SASLConfig{
+		Username:  "client",
+		Password:  "secret",
+		Mechanism: "OAUTHBEARER",
+	}}}
+	assert.EqualError(t, config.Validate(), "auth.sasl.oauthbearer.token_url is required")
+
+	config.Authentication.SASL.OAuthBearer.TokenURL = "https://idp.example.com/token"
+	assert.NoError(t, config.Validate())
+
+	config.Authentication.SASL = &kafkaexporter.SASLConfig{Mechanism: "AWS_MSK_IAM"}
+	assert.EqualError(t, config.Validate(), "auth.sasl.aws_msk.region is required")
+
+	config.Authentication.SASL.AWSMSK.Region = "us-east-1"
+	assert.NoError(t, config.Validate())
+}
+
+func TestValidate_consumer_tuning(t *testing.T) {
+	assert.NoError(t, (&Config{Fetch: Fetch{MinBytes: 1, DefaultBytes: 8 << 20, MaxBytes: 16 << 20}}).Validate())
+	assert.NoError(t, (&Config{GroupRebalanceStrategy: "sticky", GroupInstanceID: "collector-0", ProtocolVersion: "2.8.0"}).Validate())
+
+	tests := []struct {
+		name        string
+		config      *Config
+		expectedErr string
+	}{
+		{
+			name:        "negative fetch",
+			config:      &Config{Fetch: Fetch{DefaultBytes: -1}},
+			expectedErr: "fetch must not be negative. configured value {MinBytes:0 DefaultBytes:-1 MaxBytes:0}",
+		},
+		{
+			name:        "min bytes greater than max bytes",
+			config:      &Config{Fetch: Fetch{MinBytes: 2048, MaxBytes: 1024}},
+			expectedErr: "fetch.min_bytes must not be greater than fetch.max_bytes. configured value 2048",
+		},
+		{
+			name:        "default bytes greater than max bytes",
+			config:      &Config{Fetch: Fetch{DefaultBytes: 2048, MaxBytes: 1024}},
+			expectedErr: "fetch.default_bytes must not be greater than fetch.max_bytes. configured value 2048",
+		},
+		{
+			name:        "negative max wait",
+			config:      &Config{MaxWait: -time.Second},
+			expectedErr: "max_wait must not be negative. configured value -1s",
+		},
+		{
+			name:        "unknown rebalance strategy",
+			config:      &Config{GroupRebalanceStrategy: "cooperative-sticky"},
+			expectedErr: "group_rebalance_strategy should be one of 'range', 'roundrobin' or 'sticky'. configured value cooperative-sticky",
+		},
+		{
+			name:        "group instance id with the default protocol version",
+			config:      &Config{GroupInstanceID: "collector-0"},
+			expectedErr: "group_instance_id requires protocol_version 2.3.0 or later. configured value ",
+		},
+		{
+			name:        "group instance id with an old protocol version",
+			config:      &Config{GroupInstanceID: "collector-0", ProtocolVersion: "2.2.0"},
+			expectedErr: "group_instance_id requires protocol_version 2.3.0 or later. configured value 2.2.0",
+		},
+		{
+			name:        "group instance id with assignment",
+			config:      &Config{GroupInstanceID: "collector-0", ProtocolVersion: "2.3.0", Assignment: &Assignment{}},
+			expectedErr: "group_instance_id cannot be used together with assignment",
+		},
+		{
+			name:        "heartbeat interval not less than session timeout",
+			config:      &Config{SessionTimeout: 10 * time.Second, HeartbeatInterval: 10 * time.Second},
+			expectedErr: "heartbeat_interval has to be less than session_timeout. configured value 10s",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.config.Validate(), tt.expectedErr)
+		})
+	}
+}
+
+func TestValidate_key_extraction(t *testing.T) {
+	assert.NoError(t, (&Config{KeyExtraction: KeyExtraction{Attribute: "kafka.key", Encoding: "hex"}}).Validate())
+	assert.EqualError(t, (&Config{KeyExtraction: KeyExtraction{Attribute: "kafka.key", Encoding: "base64"}}).Validate(),
+		"key_extraction.encoding should be one of 'string' or 'hex'. configured value base64")
+}
+
+func TestValidate_observed_timestamp(t *testing.T) {
+	assert.NoError(t, (&Config{ObservedTimestamp: "message_timestamp"}).Validate())
+	assert.EqualError(t, (&Config{ObservedTimestamp: "log_append_time"}).Validate(),
+		"observed_timestamp should be one of 'receive_time' or 'message_timestamp'. configured value log_append_time")
+}
+
+func TestValidate_commit(t *testing.T) {
+	assert.NoError(t, (&Config{Commit: &Commit{Mode: "after_delivery", Sync: true}}).Validate())
+	assert.NoError(t, (&Config{Commit: &Commit{Mode: "after_receive", Interval: time.Second}}).Validate())
+	assert.EqualError(t, (&Config{Commit: &Commit{Mode: "never", Interval: time.Second}}).Validate(),
+		"commit.mode should be one of 'after_delivery' or 'after_receive'. configured value never")
+	assert.EqualError(t, (&Config{Commit: &Commit{Mode: "after_delivery"}}).Validate(),
+		"commit.interval has to be positive, or zero if commit.sync is true. configured value 0s")
+}
+
+func TestValidate_backpressure(t *testing.T) {
+	assert.NoError(t, (&Config{Backpressure: Backpressure{Enable: true, MemoryLimitMiB: 512, CheckInterval: time.Second}}).Validate())
+	assert.EqualError(t, (&Config{Backpressure: Backpressure{Enable: true}}).Validate(),
+		"backpressure.check_interval has to be positive. configured value 0s")
+}
+
+func TestValidate_processing_concurrency(t *testing.T) {
+	assert.NoError(t, (&Config{ProcessingConcurrency: 8}).Validate())
+	assert.EqualError(t, (&Config{ProcessingConcurrency: -1}).Validate(),
+		"processing_concurrency must not be negative. configured value -1")
+}
+
+func TestValidate_confluent_wire_format(t *testing.T) {
+	assert.NoError(t, (&Config{ConfluentWireFormat: ConfluentWireFormat{Mode: "auto"}}).Validate())
+	assert.NoError(t, (&Config{Encoding: "otlp_json", ConfluentWireFormat: ConfluentWireFormat{Mode: "disabled"}}).Validate())
+	assert.EqualError(t, (&Config{ConfluentWireFormat: ConfluentWireFormat{Mode: "strict"}}).Validate(),
+		"confluent_wire_format.mode should be one of 'disabled', 'enabled' or 'auto'. configured value strict")
+	assert.EqualError(t, (&Config{Encoding: "otlp_json", ConfluentWireFormat: ConfluentWireFormat{Mode: "enabled"}}).Validate(),
+		"confluent_wire_format requires the otlp_proto encoding. configured value otlp_json")
+}
+
+func TestValidate_on_decode_error(t *testing.T) {
+	assert.NoError(t, (&Config{Topic: "spans", OnDecodeError: "skip"}).Validate())
+	assert.NoError(t, (&Config{Topic: "spans", OnDecodeError: "halt"}).Validate())
+	assert.NoError(t, (&Config{Topic: "spans", OnDecodeError: "dead_letter", DeadLetterTopic: "spans_dlq"}).Validate())
+	assert.EqualError(t, (&Config{Topic: "spans", OnDecodeError: "dead_letter"}).Validate(),
+		"dead_letter_topic must be set when on_decode_error is dead_letter")
+	assert.EqualError(t, (&Config{Topic: "spans", OnDecodeError: "skip", DeadLetterTopic: "spans_dlq"}).Validate(),
+		"dead_letter_topic cannot be used together with on_decode_error skip")
+	assert.EqualError(t, (&Config{Topic: "spans", OnDecodeError: "ignore"}).Validate(),
+		"on_decode_error should be one of 'skip', 'halt' or 'dead_letter'. configured value ignore")
+}
+
+func TestValidate_headers_to_attributes(t *testing.T) {
+	config := &Config{HeaderExtraction: HeaderExtraction{HeadersToAttributes: "^x-", MaxHeaders: 16, MaxHeaderBytes: 4096}}
+	assert.NoError(t, config.Validate())
+
+	config.HeaderExtraction.MaxHeaders = 0
+	assert.EqualError(t, config.Validate(), "header_extraction.max_headers has to be positive. configured value 0")
+
+	config.HeaderExtraction.MaxHeaders = 16
+	config.HeaderExtraction.MaxHeaderBytes = -1
+	assert.EqualError(t, config.Validate(), "header_extraction.max_header_bytes has to be positive. configured value -1")
+
+	config.HeaderExtraction.HeadersToAttributes = "x-("
+	assert.ErrorContains(t, config.Validate(), "header_extraction.headers_to_attributes is not a valid regular expression")
+}
+
+func TestValidate_schema_registry_url(t *testing.T) {
+	assert.NoError(t, (&Config{Encoding: "avro", SchemaRegistryURL: "http://registry:8081"}).Validate())
+	assert.EqualError(t, (&Config{Encoding: "avro", SchemaRegistryURL: "registry:8081"}).Validate(),
+		"schema_registry_url has to be an http or https URL. configured value registry:8081")
+}
+
+func TestValidate_message_metadata_placement(t *testing.T) {
+	assert.NoError(t, (&Config{MessageMetadata: MessageMetadata{Enable: true, Placement: "record"}}).Validate())
+	assert.EqualError(t, (&Config{MessageMetadata: MessageMetadata{Enable: true, Placement: "scope"}}).Validate(),
+		"message_metadata.placement should be one of 'resource' or 'record'. configured value scope")
+}
@@ -0,0 +1,131 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	confluentWireFormatDisabled = "disabled"
+	confluentWireFormatEnabled  = "enabled"
+	confluentWireFormatAuto     = "auto"
+
+	// confluentMagicByte starts the messages in the Confluent wire format. A protobuf message cannot
+	// start with it, since it would be the tag of the invalid field number 0.
+	confluentMagicByte = 0
+	// confluentHeaderSize is the size of the magic byte followed by the big-endian schema ID.
+	confluentHeaderSize = 5
+)
+
+var (
+	errNotConfluentFramed    = errors.New("message is not in the Confluent wire format")
+	errInvalidMessageIndexes = errors.New("invalid message indexes in the Confluent wire format")
+)
+
+// confluentFraming strips the Confluent wire-format framing of protobuf messages: the magic byte,
+// the schema ID, and the message indexes of the protobuf schema.
+type confluentFraming struct {
+	auto      bool
+	allowed   map[uint32]bool
+	statsTags []tag.Mutator
+}
+
+func newConfluentFraming(config ConfluentWireFormat, id component.ID) confluentFraming {
+	f := confluentFraming{
+		auto:      config.Mode == confluentWireFormatAuto,
+		statsTags: []tag.Mutator{tag.Upsert(tagInstanceName, id.String())},
+	}
+	if len(config.AllowedSchemaIDs) > 0 {
+		f.allowed = make(map[uint32]bool, len(config.AllowedSchemaIDs))
+		for _, schemaID := range config.AllowedSchemaIDs {
+			f.allowed[schemaID] = true
+		}
+	}
+	return f
+}
+
+// strip returns the protobuf payload of buf. In auto mode, the messages without framing are returned as is.
+func (f confluentFraming) strip(buf []byte) ([]byte, error) {
+	if len(buf) < confluentHeaderSize || buf[0] != confluentMagicByte {
+		if !f.auto {
+			return nil, errNotConfluentFramed
+		}
+		_ = stats.RecordWithTags(context.Background(), f.statsTags, statConfluentUnframed.M(1))
+		return buf, nil
+	}
+	schemaID := binary.BigEndian.Uint32(buf[1:confluentHeaderSize])
+	if f.allowed != nil && !f.allowed[schemaID] {
+		return nil, fmt.Errorf("schema ID %d is not in confluent_wire_format.allowed_schema_ids", schemaID)
+	}
+	payload := buf[confluentHeaderSize:]
+	// The message indexes are a zigzag varint count followed by the indexes, a count of 0 stands for [0].
+	count, n := binary.Varint(payload)
+	if n <= 0 || count < 0 {
+		return nil, errInvalidMessageIndexes
+	}
+	payload = payload[n:]
+	for i := int64(0); i < count; i++ {
+		if _, n = binary.Varint(payload); n <= 0 {
+			return nil, errInvalidMessageIndexes
+		}
+		payload = payload[n:]
+	}
+	_ = stats.RecordWithTags(context.Background(), f.statsTags, statConfluentFramed.M(1))
+	return payload, nil
+}
+
+type confluentTracesUnmarshaler struct {
+	TracesUnmarshaler
+	framing confluentFraming
+}
+
+func (u confluentTracesUnmarshaler) Unmarshal(buf []byte) (ptrace.Traces, error) {
+	payload, err := u.framing.strip(buf)
+	if err != nil {
+		return ptrace.NewTraces(), err
+	}
+	return u.TracesUnmarshaler.Unmarshal(payload)
+}
+
+type confluentMetricsUnmarshaler struct {
+	MetricsUnmarshaler
+	framing confluentFraming
+}
+
+func (u confluentMetricsUnmarshaler) Unmarshal(buf []byte) (pmetric.Metrics, error) {
+	payload, err := u.framing.strip(buf)
+	if err != nil {
+		return pmetric.NewMetrics(), err
+	}
+	return u.MetricsUnmarshaler.Unmarshal(payload)
+}
+
+type confluentLogsUnmarshaler struct {
+	LogsUnmarshaler
+	framing confluentFraming
+}
+
+func (u confluentLogsUnmarshaler) Unmarshal(buf []byte) (plog.Logs, error) {
+	payload, err := u.framing.strip(buf)
+	if err != nil {
+		return plog.NewLogs(), err
+	}
+	return u.LogsUnmarshaler.Unmarshal(payload)
+}
+
+// confluentWireFormatEnabledFor reports whether the framing of the messages has to be stripped.
+func confluentWireFormatEnabledFor(config Config) bool {
+	return config.ConfluentWireFormat.Mode == confluentWireFormatEnabled || config.ConfluentWireFormat.Mode == confluentWireFormatAuto
+}
@@ -0,0 +1,109 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func confluentFramed(schemaID byte, indexes []byte, payload []byte) []byte {
+	buf := []byte{confluentMagicByte, 0, 0, 0, schemaID}
+	buf = append(buf, indexes...)
+	return append(buf, payload...)
+}
+
+func TestConfluentFraming_strip(t *testing.T) {
+	payload := []byte{0x0a, 0x02, 0x08, 0x01}
+	tests := []struct {
+		name    string
+		config  ConfluentWireFormat
+		buf     []byte
+		want    []byte
+		wantErr string
+	}{
+		{
+			name:   "first message of the schema",
+			config: ConfluentWireFormat{Mode: confluentWireFormatEnabled},
+			buf:    confluentFramed(1, []byte{0x00}, payload),
+			want:   payload,
+		},
+		{
+			name:   "nested message",
+			config: ConfluentWireFormat{Mode: confluentWireFormatEnabled},
+			// Zigzag varints: 2 indexes, [1, 0].
+			buf:  confluentFramed(1, []byte{0x04, 0x02, 0x00}, payload),
+			want: payload,
+		},
+		{
+			name:    "not framed",
+			config:  ConfluentWireFormat{Mode: confluentWireFormatEnabled},
+			buf:     payload,
+			wantErr: errNotConfluentFramed.Error(),
+		},
+		{
+			name:   "not framed in auto mode",
+			config: ConfluentWireFormat{Mode: confluentWireFormatAuto},
+			buf:    payload,
+			want:   payload,
+		},
+		{
+			name:   "framed in auto mode",
+			config: ConfluentWireFormat{Mode: confluentWireFormatAuto},
+			buf:    confluentFramed(1, []byte{0x00}, payload),
+			want:   payload,
+		},
+		{
+			name:   "allowed schema ID",
+			config: ConfluentWireFormat{Mode: confluentWireFormatEnabled, AllowedSchemaIDs: []uint32{1, 2}},
+			buf:    confluentFramed(2, []byte{0x00}, payload),
+			want:   payload,
+		},
+		{
+			name:    "schema ID not allowed",
+			config:  ConfluentWireFormat{Mode: confluentWireFormatAuto, AllowedSchemaIDs: []uint32{1, 2}},
+			buf:     confluentFramed(3, []byte{0x00}, payload),
+			wantErr: "schema ID 3 is not in confluent_wire_format.allowed_schema_ids",
+		},
+		{
+			name:    "truncated message indexes",
+			config:  ConfluentWireFormat{Mode: confluentWireFormatEnabled},
+			buf:     confluentFramed(1, []byte{0x04, 0x02}, nil),
+			wantErr: errInvalidMessageIndexes.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newConfluentFraming(tt.config, component.NewID("kafka")).strip(tt.buf)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfluentTracesUnmarshaler(t *testing.T) {
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	payload, err := (&ptrace.ProtoMarshaler{}).MarshalTraces(td)
+	require.NoError(t, err)
+
+	u := confluentTracesUnmarshaler{
+		TracesUnmarshaler: newPdataTracesUnmarshaler(&ptrace.ProtoUnmarshaler{}, defaultEncoding),
+		framing:           newConfluentFraming(ConfluentWireFormat{Mode: confluentWireFormatAuto}, component.NewID("kafka")),
+	}
+	for _, buf := range [][]byte{payload, confluentFramed(1, []byte{0x00}, payload)} {
+		got, err := u.Unmarshal(buf)
+		require.NoError(t, err)
+		assert.Equal(t, td, got)
+	}
+	assert.Equal(t, defaultEncoding, u.Encoding())
+}
@@ -0,0 +1,120 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
+
+import (
+	"context"
+	"sync"
+
+	"github.com/IBM/sarama"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+)
+
+// deadLetterErrorHeader is the header of the dead-lettered messages describing why they could not be unmarshaled.
+const deadLetterErrorHeader = "dead_letter_error"
+
+// deadLetterQueue re-produces the messages that cannot be unmarshaled to the dead letter topic.
+// Messages are produced asynchronously, and dropped if the producer cannot keep up, so that
+// the consumption is never blocked.
+type deadLetterQueue struct {
+	topic    string
+	producer sarama.AsyncProducer
+	id       component.ID
+	logger   *zap.Logger
+	wg       sync.WaitGroup
+}
+
+// newDeadLetterQueue returns nil if no dead letter topic is configured. The producer shares
+// the client and authentication settings of the consumer.
+func newDeadLetterQueue(config Config, c *sarama.Config, id component.ID, logger *zap.Logger) (*deadLetterQueue, error) {
+	if config.DeadLetterTopic == "" {
+		return nil, nil
+	}
+	producerConfig := *c
+	producerConfig.Producer.Return.Successes = true
+	producerConfig.Producer.Return.Errors = true
+	producer, err := sarama.NewAsyncProducer(config.Brokers, &producerConfig)
+	if err != nil {
+		return nil, err
+	}
+	return startDeadLetterQueue(config.DeadLetterTopic, producer, id, logger), nil
+}
+
+func startDeadLetterQueue(topic string, producer sarama.AsyncProducer, id component.ID, logger *zap.Logger) *deadLetterQueue {
+	q := &deadLetterQueue{
+		topic:    topic,
+		producer: producer,
+		id:       id,
+		logger:   logger,
+	}
+	q.wg.Add(2)
+	go func() {
+		defer q.wg.Done()
+		for msg := range producer.Successes() {
+			q.record(msg.Metadata.(string), statMessageDeadLettered)
+		}
+	}()
+	go func() {
+		defer q.wg.Done()
+		for err := range producer.Errors() {
+			sourceTopic := err.Msg.Metadata.(string)
+			q.logger.Warn("Failed to produce message to the dead letter topic",
+				zap.String("topic", sourceTopic),
+				zap.String("dead_letter_topic", q.topic),
+				zap.Error(err.Err))
+			q.record(sourceTopic, statMessageDeadLetterFailed)
+		}
+	}()
+	return q
+}
+
+// send re-produces message to the dead letter topic, with its original key and headers,
+// and the unmarshaling error in the deadLetterErrorHeader header.
+func (q *deadLetterQueue) send(message *sarama.ConsumerMessage, cause error) {
+	headers := make([]sarama.RecordHeader, 0, len(message.Headers)+1)
+	for _, header := range message.Headers {
+		if header != nil {
+			headers = append(headers, *header)
+		}
+	}
+	headers = append(headers, sarama.RecordHeader{Key: []byte(deadLetterErrorHeader), Value: []byte(cause.Error())})
+	msg := &sarama.ProducerMessage{
+		Topic:    q.topic,
+		Value:    sarama.ByteEncoder(message.Value),
+		Headers:  headers,
+		Metadata: message.Topic,
+	}
+	if message.Key != nil {
+		msg.Key = sarama.ByteEncoder(message.Key)
+	}
+
+	select {
+	case q.producer.Input() <- msg:
+	default:
+		q.logger.Warn("Dead letter producer is full, dropping message",
+			zap.String("topic", message.Topic),
+			zap.Int32("partition", message.Partition),
+			zap.Int64("offset", message.Offset))
+		q.record(message.Topic, statMessageDeadLetterFailed)
+	}
+}
+
+func (q *deadLetterQueue) record(sourceTopic string, measure *stats.Int64Measure) {
+	_ = stats.RecordWithTags(
+		context.Background(),
+		[]tag.Mutator{tag.Upsert(tagInstanceName, q.id.String()), tag.Upsert(tagTopic, sourceTopic)},
+		measure.M(1))
+}
+
+// close flushes the pending messages and closes the producer. It is a no-op on a nil queue.
+func (q *deadLetterQueue) close() {
+	if q == nil {
+		return
+	}
+	q.producer.AsyncClose()
+	q.wg.Wait()
+}
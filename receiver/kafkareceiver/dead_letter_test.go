@@ -0,0 +1,123 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+)
+
+func newMockDeadLetterProducer(t *testing.T) *mocks.AsyncProducer {
+	c := sarama.NewConfig()
+	c.Producer.Return.Successes = true
+	return mocks.NewAsyncProducer(t, c)
+}
+
+func TestDeadLetterQueue_send(t *testing.T) {
+	producer := newMockDeadLetterProducer(t)
+	producer.ExpectInputWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, "dlq", msg.Topic)
+		key, err := msg.Key.Encode()
+		require.NoError(t, err)
+		assert.Equal(t, []byte("key"), key)
+		value, err := msg.Value.Encode()
+		require.NoError(t, err)
+		assert.Equal(t, []byte("garbage"), value)
+		assert.Equal(t, []sarama.RecordHeader{
+			{Key: []byte("tenant"), Value: []byte("a")},
+			{Key: []byte(deadLetterErrorHeader), Value: []byte("invalid payload")},
+		}, msg.Headers)
+		return nil
+	})
+	producer.ExpectInputAndFail(errors.New("broker unavailable"))
+
+	q := startDeadLetterQueue("dlq", producer, component.NewID("kafka"), zap.NewNop())
+	message := &sarama.ConsumerMessage{
+		Topic:   "spans",
+		Key:     []byte("key"),
+		Value:   []byte("garbage"),
+		Headers: []*sarama.RecordHeader{{Key: []byte("tenant"), Value: []byte("a")}},
+	}
+	q.send(message, errors.New("invalid payload"))
+	q.send(message, errors.New("invalid payload"))
+	q.close()
+}
+
+func TestDeadLetterQueue_nil(t *testing.T) {
+	q, err := newDeadLetterQueue(Config{}, sarama.NewConfig(), component.NewID("kafka"), zap.NewNop())
+	require.NoError(t, err)
+	assert.Nil(t, q)
+	q.close()
+}
+
+func TestLogsConsumerGroupHandler_dead_letter(t *testing.T) {
+	obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+	require.NoError(t, err)
+	producer := newMockDeadLetterProducer(t)
+	producer.ExpectInputAndSucceed()
+	sink := &consumertest.LogsSink{}
+	c := logsConsumerGroupHandler{
+		unmarshaler:  newPdataLogsUnmarshaler(&plog.ProtoUnmarshaler{}, defaultEncoding),
+		logger:       zap.NewNop(),
+		ready:        make(chan bool),
+		nextConsumer: sink,
+		obsrecv:      obsrecv,
+		deadLetters:  startDeadLetterQueue("dlq", producer, component.NewID("kafka"), zap.NewNop()),
+	}
+
+	session := &markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: context.Background()}}
+	groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage, 2)}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Offset: 1, Value: []byte("!@#")}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Offset: 2, Value: []byte{}}
+	close(groupClaim.messageChan)
+
+	// The undecodable message is dead-lettered and the consumption goes on.
+	assert.NoError(t, c.ConsumeClaim(session, groupClaim))
+	c.deadLetters.close()
+	assert.Equal(t, []int64{1, 2}, session.markedOffsets())
+	assert.Len(t, sink.AllLogs(), 1)
+}
+
+func TestLogsConsumerGroupHandler_otlp_json_malformed(t *testing.T) {
+	views := MetricViews()
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+	require.NoError(t, err)
+	producer := newMockDeadLetterProducer(t)
+	producer.ExpectInputAndSucceed()
+	c := logsConsumerGroupHandler{
+		unmarshaler:  defaultLogsUnmarshalers()["otlp_json"],
+		logger:       zap.NewNop(),
+		ready:        make(chan bool),
+		nextConsumer: &consumertest.LogsSink{},
+		obsrecv:      obsrecv,
+		deadLetters:  startDeadLetterQueue("dlq", producer, component.NewID("kafka"), zap.NewNop()),
+	}
+
+	groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage, 1)}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Topic: "logs", Value: []byte(`{"resourceLogs":[`)}
+	close(groupClaim.messageChan)
+	assert.NoError(t, c.ConsumeClaim(testConsumerGroupSession{ctx: context.Background()}, groupClaim))
+	c.deadLetters.close()
+
+	rows, err := view.RetrieveData(statMessageUnmarshalFailed.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+}
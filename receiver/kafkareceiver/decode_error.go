@@ -0,0 +1,107 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+const (
+	onDecodeErrorSkip       = "skip"
+	onDecodeErrorHalt       = "halt"
+	onDecodeErrorDeadLetter = "dead_letter"
+
+	// decodeErrorLogInterval is the minimum time between two logs of the messages that cannot be unmarshaled.
+	decodeErrorLogInterval = 10 * time.Second
+	// decodeErrorPayloadPrefix is the number of bytes of the payload logged with the unmarshaling errors.
+	decodeErrorPayloadPrefix = 64
+)
+
+// decodeErrorLogger logs the messages that cannot be unmarshaled at most once every interval, so that a topic
+// full of corrupted messages does not flood the logs. The errors logged in between are only counted.
+type decodeErrorLogger struct {
+	logger     *zap.Logger
+	interval   time.Duration
+	now        func() time.Time
+	mu         sync.Mutex
+	last       time.Time
+	suppressed int
+}
+
+func newDecodeErrorLogger(logger *zap.Logger) *decodeErrorLogger {
+	return &decodeErrorLogger{
+		logger:   logger,
+		interval: decodeErrorLogInterval,
+		now:      time.Now,
+	}
+}
+
+// log logs err with the position of message and the beginning of its payload, unless an error was logged
+// less than interval ago.
+func (l *decodeErrorLogger) log(message *sarama.ConsumerMessage, err error) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	now := l.now()
+	if !l.last.IsZero() && now.Sub(l.last) < l.interval {
+		l.suppressed++
+		l.mu.Unlock()
+		return
+	}
+	suppressed := l.suppressed
+	l.last = now
+	l.suppressed = 0
+	l.mu.Unlock()
+
+	payload := message.Value
+	if len(payload) > decodeErrorPayloadPrefix {
+		payload = payload[:decodeErrorPayloadPrefix]
+	}
+	l.logger.Error("failed to unmarshal message",
+		zap.Error(err),
+		zap.String("topic", message.Topic),
+		zap.Int32("partition", message.Partition),
+		zap.Int64("offset", message.Offset),
+		zap.Int("payload_size", len(message.Value)),
+		zap.String("payload_prefix", hex.EncodeToString(payload)),
+		zap.Int("suppressed", suppressed))
+}
+
+// handleDecodeError applies the on_decode_error policy to message, which could not be unmarshaled with err.
+// marked tells whether the message was already marked before being unmarshaled. It returns the error, if any,
+// ending the consumption of the partition.
+func handleDecodeError(onDecodeError string, deadLetters *deadLetterQueue, messageMarking MessageMarking, marked bool, marker messageMarker, message *sarama.ConsumerMessage, err error) error {
+	switch {
+	case onDecodeError == onDecodeErrorHalt:
+		// The message is left unmarked, so that it is consumed again once the cause is fixed.
+		return err
+	case deadLetters != nil:
+		deadLetters.send(message, err)
+		if !marked {
+			marker.MarkMessage(message, "")
+		}
+		return nil
+	case onDecodeError == onDecodeErrorSkip:
+		if !marked {
+			marker.MarkMessage(message, "")
+		}
+		return nil
+	}
+	if !messageMarking.After || messageMarking.OnError {
+		marker.MarkMessage(message, "")
+	}
+	return err
+}
+
+// marksBeforeDelivery tells whether the messages are marked before being unmarshaled. Retried messages and
+// messages that may halt the consumption are only marked once handled, so they are not lost on a rebalance.
+func marksBeforeDelivery(messageMarking MessageMarking, retrier *deliveryRetrier, backpressure *backpressure, onDecodeError string) bool {
+	return !messageMarking.After && retrier == nil && backpressure == nil && onDecodeError != onDecodeErrorHalt
+}
@@ -0,0 +1,145 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDecodeErrorLogger(t *testing.T) {
+	zcore, logObserver := observer.New(zapcore.ErrorLevel)
+	l := newDecodeErrorLogger(zap.New(zcore))
+	now := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	message := &sarama.ConsumerMessage{Topic: "logs", Partition: 3, Offset: 42, Value: []byte(strings.Repeat("\xff", 100))}
+	l.log(message, assert.AnError)
+	l.log(message, assert.AnError)
+	l.log(message, assert.AnError)
+	now = now.Add(decodeErrorLogInterval)
+	l.log(message, assert.AnError)
+
+	logs := logObserver.All()
+	require.Len(t, logs, 2)
+	fields := logs[0].ContextMap()
+	assert.Equal(t, "logs", fields["topic"])
+	assert.Equal(t, int32(3), fields["partition"])
+	assert.Equal(t, int64(42), fields["offset"])
+	assert.Equal(t, int64(100), fields["payload_size"])
+	assert.Equal(t, strings.Repeat("ff", decodeErrorPayloadPrefix), fields["payload_prefix"])
+	assert.Equal(t, int64(0), fields["suppressed"])
+	assert.Equal(t, int64(2), logs[1].ContextMap()["suppressed"])
+
+	var nilLogger *decodeErrorLogger
+	nilLogger.log(message, assert.AnError)
+}
+
+func TestLogsConsumerGroupHandler_on_decode_error(t *testing.T) {
+	tests := []struct {
+		name           string
+		onDecodeError  string
+		messageMarking MessageMarking
+		deadLetter     bool
+		wantErr        bool
+		wantMarked     []int64
+		wantDelivered  int
+	}{
+		{
+			name:          "skip",
+			onDecodeError: onDecodeErrorSkip,
+			wantMarked:    []int64{1, 2},
+			wantDelivered: 1,
+		},
+		{
+			name:           "skip marking after",
+			onDecodeError:  onDecodeErrorSkip,
+			messageMarking: MessageMarking{After: true},
+			wantMarked:     []int64{1, 2},
+			wantDelivered:  1,
+		},
+		{
+			name:          "halt",
+			onDecodeError: onDecodeErrorHalt,
+			wantErr:       true,
+		},
+		{
+			name:          "dead_letter",
+			onDecodeError: onDecodeErrorDeadLetter,
+			deadLetter:    true,
+			wantMarked:    []int64{1, 2},
+			wantDelivered: 1,
+		},
+		{
+			name:       "unset",
+			wantErr:    true,
+			wantMarked: []int64{1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			views := MetricViews()
+			require.NoError(t, view.Register(views...))
+			defer view.Unregister(views...)
+
+			obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+			require.NoError(t, err)
+			sink := &consumertest.LogsSink{}
+			c := logsConsumerGroupHandler{
+				id:             component.NewID("kafka"),
+				unmarshaler:    newPdataLogsUnmarshaler(&plog.ProtoUnmarshaler{}, defaultEncoding),
+				logger:         zap.NewNop(),
+				ready:          make(chan bool),
+				nextConsumer:   sink,
+				obsrecv:        obsrecv,
+				messageMarking: tt.messageMarking,
+				onDecodeError:  tt.onDecodeError,
+				decodeErrors:   newDecodeErrorLogger(zap.NewNop()),
+			}
+			if tt.deadLetter {
+				producer := newMockDeadLetterProducer(t)
+				producer.ExpectInputAndSucceed()
+				c.deadLetters = startDeadLetterQueue("dlq", producer, component.NewID("kafka"), zap.NewNop())
+			}
+
+			session := &markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: context.Background()}}
+			groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage, 2)}
+			groupClaim.messageChan <- &sarama.ConsumerMessage{Topic: "logs", Offset: 1, Value: []byte("!@#")}
+			groupClaim.messageChan <- &sarama.ConsumerMessage{Topic: "logs", Offset: 2, Value: []byte{}}
+			close(groupClaim.messageChan)
+
+			err = c.ConsumeClaim(session, groupClaim)
+			c.deadLetters.close()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantMarked, session.markedOffsets())
+			assert.Len(t, sink.AllLogs(), tt.wantDelivered)
+
+			rows, err := view.RetrieveData(statMessageUnmarshalFailed.Name())
+			require.NoError(t, err)
+			require.Len(t, rows, 1)
+			assert.Contains(t, rows[0].Tags, tag.Tag{Key: tagEncoding, Value: defaultEncoding})
+			assert.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+		})
+	}
+}
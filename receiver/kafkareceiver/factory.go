@@ -7,6 +7,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/IBM/sarama"
 	"go.opencensus.io/stats/view"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
@@ -17,13 +18,40 @@ import (
 )
 
 const (
-	defaultTopic         = "otlp_spans"
-	defaultEncoding      = "otlp_proto"
-	defaultBroker        = "localhost:9092"
+	defaultTopic    = "otlp_spans"
+	defaultEncoding = "otlp_proto"
+	defaultBroker   = "localhost:9092"
+	// the signal is appended to the default client and group ids, e.g. otel-collector-traces
 	defaultClientID      = "otel-collector"
 	defaultGroupID       = defaultClientID
 	defaultInitialOffset = offsetLatest
 
+	defaultTopicRefreshInterval = time.Minute
+
+	defaultKeyExtractionEncoding = keyEncodingString
+
+	defaultMessageMetadataPlacement = messageMetadataPlacementResource
+
+	// limits of the headers of a message extracted with headers_to_attributes
+	defaultHeaderExtractionMaxHeaders     = 16
+	defaultHeaderExtractionMaxHeaderBytes = 4096
+
+	defaultConfluentWireFormatMode = confluentWireFormatDisabled
+
+	defaultObservedTimestamp = observedTimestampReceiveTime
+
+	// default processes the messages of every partition in order
+	defaultProcessingConcurrency = 1
+
+	defaultOnError                     = onErrorDrop
+	defaultErrorBackOffInitialInterval = time.Second
+	defaultErrorBackOffMaxInterval     = 30 * time.Second
+	defaultErrorBackOffMaxElapsedTime  = 5 * time.Minute
+
+	defaultBackpressureCheckInterval = time.Second
+
+	defaultLagRefreshInterval = 10 * time.Second
+
 	// default from sarama.NewConfig()
 	defaultMetadataRetryMax = 3
 	// default from sarama.NewConfig()
@@ -35,6 +63,23 @@ const (
 	defaultAutoCommitEnable = true
 	// default from sarama.NewConfig()
 	defaultAutoCommitInterval = 1 * time.Second
+
+	// default from sarama.NewConfig()
+	defaultFetchMinBytes = 1
+	// default from sarama.NewConfig()
+	defaultFetchDefaultBytes = 1024 * 1024
+	// default from sarama.NewConfig()
+	defaultFetchMaxBytes = 0
+	// default from sarama.NewConfig()
+	defaultMaxWait = 500 * time.Millisecond
+	// default from sarama.NewConfig()
+	defaultSessionTimeout = 10 * time.Second
+	// default from sarama.NewConfig()
+	defaultHeartbeatInterval = 3 * time.Second
+	// default from sarama.NewConfig()
+	defaultMaxProcessingTime = 100 * time.Millisecond
+	// default from sarama.NewConfig()
+	defaultGroupRebalanceStrategy = sarama.RangeBalanceStrategyName
 )
 
 // FactoryOption applies changes to kafkaExporterFactory.
@@ -90,11 +135,14 @@ func NewFactory(options ...FactoryOption) receiver.Factory {
 
 func createDefaultConfig() component.Config {
 	return &Config{
-		Topic:         defaultTopic,
-		Encoding:      defaultEncoding,
-		Brokers:       []string{defaultBroker},
-		ClientID:      defaultClientID,
-		GroupID:       defaultGroupID,
+		Topic:                defaultTopic,
+		TopicRefreshInterval: defaultTopicRefreshInterval,
+		Encoding:             defaultEncoding,
+		Brokers:              []string{defaultBroker},
+		// using empty group and client ids to track when they have not been set by user, and default
+		// them per signal. The group id cannot be used with a static assignment.
+		GroupID:       "",
+		ClientID:      "",
 		InitialOffset: defaultInitialOffset,
 		Metadata: kafkaexporter.Metadata{
 			Full: defaultMetadataFull,
@@ -103,6 +151,16 @@ func createDefaultConfig() component.Config {
 				Backoff: defaultMetadataRetryBackoff,
 			},
 		},
+		Fetch: Fetch{
+			MinBytes:     defaultFetchMinBytes,
+			DefaultBytes: defaultFetchDefaultBytes,
+			MaxBytes:     defaultFetchMaxBytes,
+		},
+		MaxWait:                defaultMaxWait,
+		SessionTimeout:         defaultSessionTimeout,
+		HeartbeatInterval:      defaultHeartbeatInterval,
+		MaxProcessingTime:      defaultMaxProcessingTime,
+		GroupRebalanceStrategy: defaultGroupRebalanceStrategy,
 		AutoCommit: AutoCommit{
 			Enable:   defaultAutoCommitEnable,
 			Interval: defaultAutoCommitInterval,
@@ -111,6 +169,31 @@ func createDefaultConfig() component.Config {
 			After:   false,
 			OnError: false,
 		},
+		MessageMetadata: MessageMetadata{
+			Placement: defaultMessageMetadataPlacement,
+		},
+		HeaderExtraction: HeaderExtraction{
+			MaxHeaders:     defaultHeaderExtractionMaxHeaders,
+			MaxHeaderBytes: defaultHeaderExtractionMaxHeaderBytes,
+		},
+		KeyExtraction: KeyExtraction{
+			Encoding: defaultKeyExtractionEncoding,
+		},
+		ConfluentWireFormat: ConfluentWireFormat{
+			Mode: defaultConfluentWireFormatMode,
+		},
+		ObservedTimestamp: defaultObservedTimestamp,
+		OnError:           defaultOnError,
+		ErrorBackOff: ErrorBackOff{
+			InitialInterval: defaultErrorBackOffInitialInterval,
+			MaxInterval:     defaultErrorBackOffMaxInterval,
+			MaxElapsedTime:  defaultErrorBackOffMaxElapsedTime,
+		},
+		ProcessingConcurrency: defaultProcessingConcurrency,
+		Backpressure: Backpressure{
+			CheckInterval: defaultBackpressureCheckInterval,
+		},
+		LagRefreshInterval: defaultLagRefreshInterval,
 	}
 }
 
@@ -22,8 +22,8 @@ func TestCreateDefaultConfig(t *testing.T) {
 	assert.NoError(t, componenttest.CheckConfigStruct(cfg))
 	assert.Equal(t, []string{defaultBroker}, cfg.Brokers)
 	assert.Equal(t, defaultTopic, cfg.Topic)
-	assert.Equal(t, defaultGroupID, cfg.GroupID)
-	assert.Equal(t, defaultClientID, cfg.ClientID)
+	assert.Equal(t, "", cfg.GroupID)
+	assert.Equal(t, "", cfg.ClientID)
 	assert.Equal(t, defaultInitialOffset, cfg.InitialOffset)
 }
 
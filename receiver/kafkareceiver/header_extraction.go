@@ -0,0 +1,86 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
+
+import (
+	"encoding/base64"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// headerExtractor adds the message headers selected by the header extraction to the resource attributes.
+type headerExtractor struct {
+	headers        []string
+	pattern        *regexp.Regexp
+	prefix         string
+	maxHeaders     int
+	maxHeaderBytes int
+}
+
+// newHeaderExtractor returns nil if neither headers nor headers_to_attributes are configured.
+// The configuration is expected to be validated.
+func newHeaderExtractor(extraction HeaderExtraction) *headerExtractor {
+	if len(extraction.Headers) == 0 && extraction.HeadersToAttributes == "" {
+		return nil
+	}
+	e := &headerExtractor{
+		headers:        extraction.Headers,
+		prefix:         extraction.Prefix,
+		maxHeaders:     extraction.MaxHeaders,
+		maxHeaderBytes: extraction.MaxHeaderBytes,
+	}
+	if extraction.HeadersToAttributes != "" {
+		e.pattern = regexp.MustCompile(extraction.HeadersToAttributes)
+	}
+	return e
+}
+
+// put adds the headers of message to attrs. The listed headers replace the attributes of the same key, while the
+// headers matching the pattern never replace an attribute, be it one of the telemetry or one of a listed header.
+// If a header is set multiple times, its first value is used.
+func (e *headerExtractor) put(attrs pcommon.Map, message *sarama.ConsumerMessage) {
+	if e == nil {
+		return
+	}
+	for _, key := range e.headers {
+		for _, header := range message.Headers {
+			if header == nil || string(header.Key) != key {
+				continue
+			}
+			attrs.PutStr(e.prefix+key, headerValue(header.Value))
+			break
+		}
+	}
+	if e.pattern == nil {
+		return
+	}
+	count, size := 0, 0
+	for _, header := range message.Headers {
+		if header == nil || !e.pattern.Match(header.Key) {
+			continue
+		}
+		key := e.prefix + string(header.Key)
+		if _, ok := attrs.Get(key); ok {
+			continue
+		}
+		// The headers over the limits are dropped, the following smaller ones may still fit.
+		if count == e.maxHeaders || size+len(header.Key)+len(header.Value) > e.maxHeaderBytes {
+			continue
+		}
+		count++
+		size += len(header.Key) + len(header.Value)
+		attrs.PutStr(key, headerValue(header.Value))
+	}
+}
+
+// headerValue returns value, base64-encoded if it is not valid UTF-8.
+func headerValue(value []byte) string {
+	if !utf8.Valid(value) {
+		return base64.StdEncoding.EncodeToString(value)
+	}
+	return string(value)
+}
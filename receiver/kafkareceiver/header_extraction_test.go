@@ -0,0 +1,67 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+func TestHeaderExtractor_headers_to_attributes(t *testing.T) {
+	e := newHeaderExtractor(HeaderExtraction{
+		Headers:             []string{"tenant"},
+		Prefix:              "kafka.header.",
+		HeadersToAttributes: "^x-",
+		MaxHeaders:          3,
+		MaxHeaderBytes:      32,
+	})
+	attrs := pcommon.NewMap()
+	attrs.PutStr("kafka.header.x-service", "from-telemetry")
+	e.put(attrs, &sarama.ConsumerMessage{Headers: []*sarama.RecordHeader{
+		{Key: []byte("tenant"), Value: []byte("acme")},
+		{Key: []byte("x-service"), Value: []byte("from-header")},
+		{Key: []byte("x-route"), Value: []byte("first")},
+		{Key: []byte("x-route"), Value: []byte("second")},
+		{Key: []byte("other"), Value: []byte("ignored")},
+		{Key: []byte("x-signature"), Value: []byte{0xff, 0xfe}},
+		// Over max_header_bytes: x-route, x-signature and x-large would take 12+13+24 bytes.
+		{Key: []byte("x-large"), Value: []byte("too large to fit.")},
+		{Key: []byte("x-id"), Value: []byte("1")},
+		// Over max_headers.
+		{Key: []byte("x-extra"), Value: []byte("1")},
+	}})
+	assert.Equal(t, map[string]any{
+		"kafka.header.tenant":      "acme",
+		"kafka.header.x-service":   "from-telemetry",
+		"kafka.header.x-route":     "first",
+		"kafka.header.x-signature": "//4=",
+		"kafka.header.x-id":        "1",
+	}, attrs.AsRaw())
+}
+
+func TestHeaderExtractor_listed_headers_win(t *testing.T) {
+	e := newHeaderExtractor(HeaderExtraction{
+		Headers:             []string{"x-tenant"},
+		HeadersToAttributes: ".*",
+		MaxHeaders:          16,
+		MaxHeaderBytes:      4096,
+	})
+	attrs := pcommon.NewMap()
+	attrs.PutStr("x-tenant", "from-telemetry")
+	e.put(attrs, &sarama.ConsumerMessage{Headers: []*sarama.RecordHeader{
+		{Key: []byte("x-tenant"), Value: []byte("from-header")},
+	}})
+	assert.Equal(t, map[string]any{"x-tenant": "from-header"}, attrs.AsRaw())
+}
+
+func TestHeaderExtractor_disabled(t *testing.T) {
+	e := newHeaderExtractor(HeaderExtraction{Prefix: "kafka.header."})
+	assert.Nil(t, e)
+	attrs := pcommon.NewMap()
+	e.put(attrs, &sarama.ConsumerMessage{Headers: []*sarama.RecordHeader{{Key: []byte("tenant"), Value: []byte("acme")}}})
+	assert.Equal(t, 0, attrs.Len())
+}
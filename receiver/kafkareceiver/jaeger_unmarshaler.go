@@ -5,6 +5,8 @@ package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collect
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 
 	"github.com/gogo/protobuf/jsonpb"
 	jaegerproto "github.com/jaegertracing/jaeger/model"
@@ -36,7 +38,11 @@ type jaegerJSONSpanUnmarshaler struct {
 
 var _ TracesUnmarshaler = (*jaegerJSONSpanUnmarshaler)(nil)
 
+// Unmarshal unmarshals a span object, or an array of span objects into a single batch.
 func (j jaegerJSONSpanUnmarshaler) Unmarshal(data []byte) (ptrace.Traces, error) {
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '[' {
+		return unmarshalJaegerJSONSpans(trimmed)
+	}
 	span := &jaegerproto.Span{}
 	err := jsonpb.Unmarshal(bytes.NewReader(data), span)
 	if err != nil {
@@ -49,6 +55,27 @@ func (j jaegerJSONSpanUnmarshaler) Encoding() string {
 	return "jaeger_json"
 }
 
+// unmarshalJaegerJSONSpans unmarshals an array of span objects. The spans without a process share the
+// process of the first span, and the spans of the same process share a resource.
+func unmarshalJaegerJSONSpans(data []byte) (ptrace.Traces, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return ptrace.NewTraces(), err
+	}
+	batch := jaegerproto.Batch{Spans: make([]*jaegerproto.Span, 0, len(elements))}
+	for i, element := range elements {
+		span := &jaegerproto.Span{}
+		if err := jsonpb.Unmarshal(bytes.NewReader(element), span); err != nil {
+			return ptrace.NewTraces(), fmt.Errorf("span %d: %w", i, err)
+		}
+		if batch.Process == nil {
+			batch.Process = span.Process
+		}
+		batch.Spans = append(batch.Spans, span)
+	}
+	return jaeger.ProtoToTraces([]*jaegerproto.Batch{&batch})
+}
+
 func jaegerSpanToTraces(span *jaegerproto.Span) (ptrace.Traces, error) {
 	batch := jaegerproto.Batch{
 		Spans:   []*jaegerproto.Span{span},
@@ -73,3 +73,46 @@ func TestUnmarshalJaegerJSON_error(t *testing.T) {
 	assert.Equal(t, ptrace.NewTraces(), got)
 	assert.Error(t, err)
 }
+
+func TestUnmarshalJaegerJSON_array(t *testing.T) {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "checkout")
+	rs.Resource().Attributes().PutStr("host.name", "host-1")
+	spans := rs.ScopeSpans().AppendEmpty().Spans()
+	for i, name := range []string{"foo", "bar", "baz"} {
+		span := spans.AppendEmpty()
+		span.SetName(name)
+		span.SetStartTimestamp(pcommon.Timestamp(10))
+		span.SetEndTimestamp(pcommon.Timestamp(20))
+		span.SetTraceID([16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+		span.SetSpanID([8]byte{1, 2, 3, 4, 5, 6, 7, byte(i)})
+		span.Attributes().PutStr("http.method", "GET")
+		span.Attributes().PutInt("http.status_code", int64(200+i))
+	}
+	batches, err := jaeger.ProtoFromTraces(td)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+
+	// Every span of the array carries its process, as the per-span messages do.
+	jsonMarshaler := &jsonpb.Marshaler{}
+	array := new(bytes.Buffer)
+	array.WriteString(" [")
+	for i, span := range batches[0].Spans {
+		span.Process = batches[0].Process
+		if i > 0 {
+			array.WriteString(",")
+		}
+		require.NoError(t, jsonMarshaler.Marshal(array, span))
+	}
+	array.WriteString("]")
+
+	got, err := jaegerJSONSpanUnmarshaler{}.Unmarshal(array.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, 3, got.SpanCount())
+	require.Equal(t, 1, got.ResourceSpans().Len())
+	assert.Equal(t, td, got)
+
+	_, err = jaegerJSONSpanUnmarshaler{}.Unmarshal([]byte(`[{"operationName":"foo"},"bar"]`))
+	assert.Error(t, err)
+}
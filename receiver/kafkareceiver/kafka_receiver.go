@@ -5,9 +5,14 @@ package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collect
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/IBM/sarama"
 	"go.opencensus.io/stats"
@@ -15,7 +20,12 @@ import (
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/receiver"
+	conventions "go.opentelemetry.io/collector/semconv/v1.18.0"
 	"go.uber.org/zap"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
@@ -31,43 +41,89 @@ var errInvalidInitialOffset = fmt.Errorf("invalid initial offset")
 // kafkaTracesConsumer uses sarama to consume and handle messages from kafka.
 type kafkaTracesConsumer struct {
 	consumerGroup     sarama.ConsumerGroup
+	assignment        *assignmentConsumer
 	nextConsumer      consumer.Traces
-	topics            []string
+	subscription      *topicSubscription
 	cancelConsumeLoop context.CancelFunc
 	unmarshaler       TracesUnmarshaler
 
 	settings receiver.CreateSettings
 
-	autocommitEnabled bool
-	messageMarking    MessageMarking
+	autocommitEnabled     bool
+	commitInterval        time.Duration
+	processingConcurrency int
+	messageMarking        MessageMarking
+	messageMetadata       MessageMetadata
+	maxMessageAge         time.Duration
+	lagRefreshInterval    time.Duration
+	seeker                *timestampSeeker
+	headers               *headerExtractor
+	keyExtraction         KeyExtraction
+	onError               string
+	errorBackOff          ErrorBackOff
+	onDecodeError         string
+	decodeErrors          *decodeErrorLogger
+	deadLetters           *deadLetterQueue
+	backpressure          *backpressure
 }
 
 // kafkaMetricsConsumer uses sarama to consume and handle messages from kafka.
 type kafkaMetricsConsumer struct {
 	consumerGroup     sarama.ConsumerGroup
+	assignment        *assignmentConsumer
 	nextConsumer      consumer.Metrics
-	topics            []string
+	subscription      *topicSubscription
 	cancelConsumeLoop context.CancelFunc
 	unmarshaler       MetricsUnmarshaler
 
 	settings receiver.CreateSettings
 
-	autocommitEnabled bool
-	messageMarking    MessageMarking
+	autocommitEnabled     bool
+	commitInterval        time.Duration
+	processingConcurrency int
+	messageMarking        MessageMarking
+	messageMetadata       MessageMetadata
+	maxMessageAge         time.Duration
+	lagRefreshInterval    time.Duration
+	seeker                *timestampSeeker
+	headers               *headerExtractor
+	keyExtraction         KeyExtraction
+	onError               string
+	errorBackOff          ErrorBackOff
+	onDecodeError         string
+	decodeErrors          *decodeErrorLogger
+	deadLetters           *deadLetterQueue
+	backpressure          *backpressure
 }
 
 // kafkaLogsConsumer uses sarama to consume and handle messages from kafka.
 type kafkaLogsConsumer struct {
 	consumerGroup     sarama.ConsumerGroup
+	assignment        *assignmentConsumer
 	nextConsumer      consumer.Logs
-	topics            []string
+	subscription      *topicSubscription
 	cancelConsumeLoop context.CancelFunc
 	unmarshaler       LogsUnmarshaler
 
 	settings receiver.CreateSettings
 
-	autocommitEnabled bool
-	messageMarking    MessageMarking
+	autocommitEnabled     bool
+	commitInterval        time.Duration
+	processingConcurrency int
+	messageMarking        MessageMarking
+	messageMetadata       MessageMetadata
+	observedTimestamp     string
+	maxMessageAge         time.Duration
+	lagRefreshInterval    time.Duration
+	seeker                *timestampSeeker
+	headers               *headerExtractor
+	keyExtraction         KeyExtraction
+	onError               string
+	errorBackOff          ErrorBackOff
+	onDecodeError         string
+	decodeErrors          *decodeErrorLogger
+	deadLetters           *deadLetterQueue
+	backpressure          *backpressure
 }
 
 var _ receiver.Traces = (*kafkaTracesConsumer)(nil)
@@ -79,6 +135,14 @@ func newTracesReceiver(config Config, set receiver.CreateSettings, unmarshalers
 	if unmarshaler == nil {
 		return nil, errUnrecognizedEncoding
 	}
+	if _, ok := unmarshaler.(avroTracesUnmarshaler); ok {
+		unmarshaler = newAvroTracesUnmarshaler(config.SchemaRegistryURL)
+	}
+	if confluentWireFormatEnabledFor(config) {
+		unmarshaler = confluentTracesUnmarshaler{TracesUnmarshaler: unmarshaler, framing: newConfluentFraming(config.ConfluentWireFormat, set.ID)}
+	}
+	config = withCommit(config)
+	config = withSignalDefaults(config, component.DataTypeTraces)
 
 	c := sarama.NewConfig()
 	c.ClientID = config.ClientID
@@ -87,6 +151,7 @@ func newTracesReceiver(config Config, set receiver.CreateSettings, unmarshalers
 	c.Metadata.Retry.Backoff = config.Metadata.Retry.Backoff
 	c.Consumer.Offsets.AutoCommit.Enable = config.AutoCommit.Enable
 	c.Consumer.Offsets.AutoCommit.Interval = config.AutoCommit.Interval
+	configureConsumerTuning(config, c)
 	if initialOffset, err := toSaramaInitialOffset(config.InitialOffset); err == nil {
 		c.Consumer.Offsets.Initial = initialOffset
 	} else {
@@ -102,18 +167,41 @@ func newTracesReceiver(config Config, set receiver.CreateSettings, unmarshalers
 	if err := kafkaexporter.ConfigureAuthentication(config.Authentication, c); err != nil {
 		return nil, err
 	}
-	client, err := sarama.NewConsumerGroup(config.Brokers, config.GroupID, c)
+	client, assignment, err := newConsumer(config, c, set.Logger)
+	if err != nil {
+		return nil, err
+	}
+	subscription, err := newTopicSubscription(config, c, set.Logger)
+	if err != nil {
+		return nil, err
+	}
+	deadLetters, err := newDeadLetterQueue(config, c, set.ID, set.Logger)
 	if err != nil {
 		return nil, err
 	}
 	return &kafkaTracesConsumer{
-		consumerGroup:     client,
-		topics:            []string{config.Topic},
-		nextConsumer:      nextConsumer,
-		unmarshaler:       unmarshaler,
-		settings:          set,
-		autocommitEnabled: config.AutoCommit.Enable,
-		messageMarking:    config.MessageMarking,
+		consumerGroup:         client,
+		assignment:            assignment,
+		subscription:          subscription,
+		nextConsumer:          nextConsumer,
+		unmarshaler:           unmarshaler,
+		settings:              set,
+		autocommitEnabled:     config.AutoCommit.Enable,
+		commitInterval:        syncCommitInterval(config),
+		processingConcurrency: config.ProcessingConcurrency,
+		messageMarking:        config.MessageMarking,
+		messageMetadata:       config.MessageMetadata,
+		maxMessageAge:         config.MaxMessageAge,
+		lagRefreshInterval:    config.LagRefreshInterval,
+		seeker:                newTimestampSeeker(config, c, set.Logger),
+		headers:               newHeaderExtractor(config.HeaderExtraction),
+		keyExtraction:         config.KeyExtraction,
+		onError:               config.OnError,
+		errorBackOff:          config.ErrorBackOff,
+		onDecodeError:         config.OnDecodeError,
+		decodeErrors:          newDecodeErrorLogger(set.Logger),
+		deadLetters:           deadLetters,
+		backpressure:          newBackpressure(config, client, assignment, set.ID, set.Logger),
 	}, nil
 }
 
@@ -129,20 +217,44 @@ func (c *kafkaTracesConsumer) Start(_ context.Context, host component.Host) erro
 		return err
 	}
 	consumerGroup := &tracesConsumerGroupHandler{
-		logger:            c.settings.Logger,
-		unmarshaler:       c.unmarshaler,
-		nextConsumer:      c.nextConsumer,
-		ready:             make(chan bool),
-		obsrecv:           obsrecv,
-		autocommitEnabled: c.autocommitEnabled,
-		messageMarking:    c.messageMarking,
+		logger:                c.settings.Logger,
+		unmarshaler:           c.unmarshaler,
+		nextConsumer:          c.nextConsumer,
+		ready:                 make(chan bool),
+		obsrecv:               obsrecv,
+		autocommitEnabled:     c.autocommitEnabled,
+		commitInterval:        c.commitInterval,
+		processingConcurrency: c.processingConcurrency,
+		messageMarking:        c.messageMarking,
+		messageMetadata:       c.messageMetadata,
+		maxMessageAge:         c.maxMessageAge,
+		lagRefreshInterval:    c.lagRefreshInterval,
+		seeker:                c.seeker,
+		headers:               c.headers,
+		keyExtraction:         c.keyExtraction,
+		onError:               c.onError,
+		errorBackOff:          c.errorBackOff,
+		onDecodeError:         c.onDecodeError,
+		decodeErrors:          c.decodeErrors,
+		deadLetters:           c.deadLetters,
+		backpressure:          c.backpressure,
+	}
+	c.backpressure.start(ctx)
+	if c.assignment != nil {
+		return c.assignment.start(ctx, host, c.settings.ID, consumerGroup)
+	}
+	if err = c.subscription.start(ctx); err != nil {
+		return err
 	}
 	go func() {
 		if err := c.consumeLoop(ctx, consumerGroup); err != nil {
 			host.ReportFatalError(err)
 		}
 	}()
-	<-consumerGroup.ready
+	// Without topics to subscribe to, no session is started until matching topics are created.
+	if len(c.subscription.current()) > 0 {
+		<-consumerGroup.ready
+	}
 	return nil
 }
 
@@ -151,8 +263,10 @@ func (c *kafkaTracesConsumer) consumeLoop(ctx context.Context, handler sarama.Co
 		// `Consume` should be called inside an infinite loop, when a
 		// server-side rebalance happens, the consumer session will need to be
 		// recreated to get the new claims
-		if err := c.consumerGroup.Consume(ctx, c.topics, handler); err != nil {
-			c.settings.Logger.Error("Error from consumer", zap.Error(err))
+		if sessionCtx, topics, ok := c.subscription.next(ctx); ok {
+			if err := c.consumerGroup.Consume(sessionCtx, topics, handler); err != nil {
+				c.settings.Logger.Error("Error from consumer", zap.Error(err))
+			}
 		}
 		// check if context was cancelled, signaling that the consumer should stop
 		if ctx.Err() != nil {
@@ -162,8 +276,14 @@ func (c *kafkaTracesConsumer) consumeLoop(ctx context.Context, handler sarama.Co
 	}
 }
 
-func (c *kafkaTracesConsumer) Shutdown(context.Context) error {
+func (c *kafkaTracesConsumer) Shutdown(ctx context.Context) error {
 	c.cancelConsumeLoop()
+	// Closed last, the dead letters of the last consumed messages are flushed.
+	defer c.deadLetters.close()
+	if c.assignment != nil {
+		return c.assignment.shutdown(ctx)
+	}
+	c.subscription.shutdown()
 	return c.consumerGroup.Close()
 }
 
@@ -172,6 +292,14 @@ func newMetricsReceiver(config Config, set receiver.CreateSettings, unmarshalers
 	if unmarshaler == nil {
 		return nil, errUnrecognizedEncoding
 	}
+	if _, ok := unmarshaler.(avroMetricsUnmarshaler); ok {
+		unmarshaler = newAvroMetricsUnmarshaler(config.SchemaRegistryURL)
+	}
+	if confluentWireFormatEnabledFor(config) {
+		unmarshaler = confluentMetricsUnmarshaler{MetricsUnmarshaler: unmarshaler, framing: newConfluentFraming(config.ConfluentWireFormat, set.ID)}
+	}
+	config = withCommit(config)
+	config = withSignalDefaults(config, component.DataTypeMetrics)
 
 	c := sarama.NewConfig()
 	c.ClientID = config.ClientID
@@ -180,6 +308,7 @@ func newMetricsReceiver(config Config, set receiver.CreateSettings, unmarshalers
 	c.Metadata.Retry.Backoff = config.Metadata.Retry.Backoff
 	c.Consumer.Offsets.AutoCommit.Enable = config.AutoCommit.Enable
 	c.Consumer.Offsets.AutoCommit.Interval = config.AutoCommit.Interval
+	configureConsumerTuning(config, c)
 	if initialOffset, err := toSaramaInitialOffset(config.InitialOffset); err == nil {
 		c.Consumer.Offsets.Initial = initialOffset
 	} else {
@@ -195,18 +324,41 @@ func newMetricsReceiver(config Config, set receiver.CreateSettings, unmarshalers
 	if err := kafkaexporter.ConfigureAuthentication(config.Authentication, c); err != nil {
 		return nil, err
 	}
-	client, err := sarama.NewConsumerGroup(config.Brokers, config.GroupID, c)
+	client, assignment, err := newConsumer(config, c, set.Logger)
+	if err != nil {
+		return nil, err
+	}
+	subscription, err := newTopicSubscription(config, c, set.Logger)
+	if err != nil {
+		return nil, err
+	}
+	deadLetters, err := newDeadLetterQueue(config, c, set.ID, set.Logger)
 	if err != nil {
 		return nil, err
 	}
 	return &kafkaMetricsConsumer{
-		consumerGroup:     client,
-		topics:            []string{config.Topic},
-		nextConsumer:      nextConsumer,
-		unmarshaler:       unmarshaler,
-		settings:          set,
-		autocommitEnabled: config.AutoCommit.Enable,
-		messageMarking:    config.MessageMarking,
+		consumerGroup:         client,
+		assignment:            assignment,
+		subscription:          subscription,
+		nextConsumer:          nextConsumer,
+		unmarshaler:           unmarshaler,
+		settings:              set,
+		autocommitEnabled:     config.AutoCommit.Enable,
+		commitInterval:        syncCommitInterval(config),
+		processingConcurrency: config.ProcessingConcurrency,
+		messageMarking:        config.MessageMarking,
+		messageMetadata:       config.MessageMetadata,
+		maxMessageAge:         config.MaxMessageAge,
+		lagRefreshInterval:    config.LagRefreshInterval,
+		seeker:                newTimestampSeeker(config, c, set.Logger),
+		headers:               newHeaderExtractor(config.HeaderExtraction),
+		keyExtraction:         config.KeyExtraction,
+		onError:               config.OnError,
+		errorBackOff:          config.ErrorBackOff,
+		onDecodeError:         config.OnDecodeError,
+		decodeErrors:          newDecodeErrorLogger(set.Logger),
+		deadLetters:           deadLetters,
+		backpressure:          newBackpressure(config, client, assignment, set.ID, set.Logger),
 	}, nil
 }
 
@@ -222,20 +374,44 @@ func (c *kafkaMetricsConsumer) Start(_ context.Context, host component.Host) err
 		return err
 	}
 	metricsConsumerGroup := &metricsConsumerGroupHandler{
-		logger:            c.settings.Logger,
-		unmarshaler:       c.unmarshaler,
-		nextConsumer:      c.nextConsumer,
-		ready:             make(chan bool),
-		obsrecv:           obsrecv,
-		autocommitEnabled: c.autocommitEnabled,
-		messageMarking:    c.messageMarking,
+		logger:                c.settings.Logger,
+		unmarshaler:           c.unmarshaler,
+		nextConsumer:          c.nextConsumer,
+		ready:                 make(chan bool),
+		obsrecv:               obsrecv,
+		autocommitEnabled:     c.autocommitEnabled,
+		commitInterval:        c.commitInterval,
+		processingConcurrency: c.processingConcurrency,
+		messageMarking:        c.messageMarking,
+		messageMetadata:       c.messageMetadata,
+		maxMessageAge:         c.maxMessageAge,
+		lagRefreshInterval:    c.lagRefreshInterval,
+		seeker:                c.seeker,
+		headers:               c.headers,
+		keyExtraction:         c.keyExtraction,
+		onError:               c.onError,
+		errorBackOff:          c.errorBackOff,
+		onDecodeError:         c.onDecodeError,
+		decodeErrors:          c.decodeErrors,
+		deadLetters:           c.deadLetters,
+		backpressure:          c.backpressure,
+	}
+	c.backpressure.start(ctx)
+	if c.assignment != nil {
+		return c.assignment.start(ctx, host, c.settings.ID, metricsConsumerGroup)
+	}
+	if err = c.subscription.start(ctx); err != nil {
+		return err
 	}
 	go func() {
 		if err := c.consumeLoop(ctx, metricsConsumerGroup); err != nil {
 			host.ReportFatalError(err)
 		}
 	}()
-	<-metricsConsumerGroup.ready
+	// Without topics to subscribe to, no session is started until matching topics are created.
+	if len(c.subscription.current()) > 0 {
+		<-metricsConsumerGroup.ready
+	}
 	return nil
 }
 
@@ -244,8 +420,10 @@ func (c *kafkaMetricsConsumer) consumeLoop(ctx context.Context, handler sarama.C
 		// `Consume` should be called inside an infinite loop, when a
 		// server-side rebalance happens, the consumer session will need to be
 		// recreated to get the new claims
-		if err := c.consumerGroup.Consume(ctx, c.topics, handler); err != nil {
-			c.settings.Logger.Error("Error from consumer", zap.Error(err))
+		if sessionCtx, topics, ok := c.subscription.next(ctx); ok {
+			if err := c.consumerGroup.Consume(sessionCtx, topics, handler); err != nil {
+				c.settings.Logger.Error("Error from consumer", zap.Error(err))
+			}
 		}
 		// check if context was cancelled, signaling that the consumer should stop
 		if ctx.Err() != nil {
@@ -255,12 +433,20 @@ func (c *kafkaMetricsConsumer) consumeLoop(ctx context.Context, handler sarama.C
 	}
 }
 
-func (c *kafkaMetricsConsumer) Shutdown(context.Context) error {
+func (c *kafkaMetricsConsumer) Shutdown(ctx context.Context) error {
 	c.cancelConsumeLoop()
+	// Closed last, the dead letters of the last consumed messages are flushed.
+	defer c.deadLetters.close()
+	if c.assignment != nil {
+		return c.assignment.shutdown(ctx)
+	}
+	c.subscription.shutdown()
 	return c.consumerGroup.Close()
 }
 
 func newLogsReceiver(config Config, set receiver.CreateSettings, unmarshalers map[string]LogsUnmarshaler, nextConsumer consumer.Logs) (*kafkaLogsConsumer, error) {
+	config = withCommit(config)
+	config = withSignalDefaults(config, component.DataTypeLogs)
 	c := sarama.NewConfig()
 	c.ClientID = config.ClientID
 	c.Metadata.Full = config.Metadata.Full
@@ -268,6 +454,7 @@ func newLogsReceiver(config Config, set receiver.CreateSettings, unmarshalers ma
 	c.Metadata.Retry.Backoff = config.Metadata.Retry.Backoff
 	c.Consumer.Offsets.AutoCommit.Enable = config.AutoCommit.Enable
 	c.Consumer.Offsets.AutoCommit.Interval = config.AutoCommit.Interval
+	configureConsumerTuning(config, c)
 	if initialOffset, err := toSaramaInitialOffset(config.InitialOffset); err == nil {
 		c.Consumer.Offsets.Initial = initialOffset
 	} else {
@@ -277,6 +464,12 @@ func newLogsReceiver(config Config, set receiver.CreateSettings, unmarshalers ma
 	if err != nil {
 		return nil, err
 	}
+	if _, ok := unmarshaler.(avroLogsUnmarshaler); ok {
+		unmarshaler = newAvroLogsUnmarshaler(config.SchemaRegistryURL)
+	}
+	if confluentWireFormatEnabledFor(config) {
+		unmarshaler = confluentLogsUnmarshaler{LogsUnmarshaler: unmarshaler, framing: newConfluentFraming(config.ConfluentWireFormat, set.ID)}
+	}
 	if config.ProtocolVersion != "" {
 		var version sarama.KafkaVersion
 		version, err = sarama.ParseKafkaVersion(config.ProtocolVersion)
@@ -288,18 +481,42 @@ func newLogsReceiver(config Config, set receiver.CreateSettings, unmarshalers ma
 	if err = kafkaexporter.ConfigureAuthentication(config.Authentication, c); err != nil {
 		return nil, err
 	}
-	client, err := sarama.NewConsumerGroup(config.Brokers, config.GroupID, c)
+	client, assignment, err := newConsumer(config, c, set.Logger)
+	if err != nil {
+		return nil, err
+	}
+	subscription, err := newTopicSubscription(config, c, set.Logger)
+	if err != nil {
+		return nil, err
+	}
+	deadLetters, err := newDeadLetterQueue(config, c, set.ID, set.Logger)
 	if err != nil {
 		return nil, err
 	}
 	return &kafkaLogsConsumer{
-		consumerGroup:     client,
-		topics:            []string{config.Topic},
-		nextConsumer:      nextConsumer,
-		unmarshaler:       unmarshaler,
-		settings:          set,
-		autocommitEnabled: config.AutoCommit.Enable,
-		messageMarking:    config.MessageMarking,
+		consumerGroup:         client,
+		assignment:            assignment,
+		subscription:          subscription,
+		nextConsumer:          nextConsumer,
+		unmarshaler:           unmarshaler,
+		settings:              set,
+		autocommitEnabled:     config.AutoCommit.Enable,
+		commitInterval:        syncCommitInterval(config),
+		processingConcurrency: config.ProcessingConcurrency,
+		messageMarking:        config.MessageMarking,
+		messageMetadata:       config.MessageMetadata,
+		observedTimestamp:     config.ObservedTimestamp,
+		maxMessageAge:         config.MaxMessageAge,
+		lagRefreshInterval:    config.LagRefreshInterval,
+		seeker:                newTimestampSeeker(config, c, set.Logger),
+		headers:               newHeaderExtractor(config.HeaderExtraction),
+		keyExtraction:         config.KeyExtraction,
+		onError:               config.OnError,
+		errorBackOff:          config.ErrorBackOff,
+		onDecodeError:         config.OnDecodeError,
+		decodeErrors:          newDecodeErrorLogger(set.Logger),
+		deadLetters:           deadLetters,
+		backpressure:          newBackpressure(config, client, assignment, set.ID, set.Logger),
 	}, nil
 }
 
@@ -343,20 +560,45 @@ func (c *kafkaLogsConsumer) Start(_ context.Context, host component.Host) error
 	}
 
 	logsConsumerGroup := &logsConsumerGroupHandler{
-		logger:            c.settings.Logger,
-		unmarshaler:       c.unmarshaler,
-		nextConsumer:      c.nextConsumer,
-		ready:             make(chan bool),
-		obsrecv:           obsrecv,
-		autocommitEnabled: c.autocommitEnabled,
-		messageMarking:    c.messageMarking,
+		logger:                c.settings.Logger,
+		unmarshaler:           c.unmarshaler,
+		nextConsumer:          c.nextConsumer,
+		ready:                 make(chan bool),
+		obsrecv:               obsrecv,
+		autocommitEnabled:     c.autocommitEnabled,
+		commitInterval:        c.commitInterval,
+		processingConcurrency: c.processingConcurrency,
+		messageMarking:        c.messageMarking,
+		messageMetadata:       c.messageMetadata,
+		observedTimestamp:     c.observedTimestamp,
+		maxMessageAge:         c.maxMessageAge,
+		lagRefreshInterval:    c.lagRefreshInterval,
+		seeker:                c.seeker,
+		headers:               c.headers,
+		keyExtraction:         c.keyExtraction,
+		onError:               c.onError,
+		errorBackOff:          c.errorBackOff,
+		onDecodeError:         c.onDecodeError,
+		decodeErrors:          c.decodeErrors,
+		deadLetters:           c.deadLetters,
+		backpressure:          c.backpressure,
+	}
+	c.backpressure.start(ctx)
+	if c.assignment != nil {
+		return c.assignment.start(ctx, host, c.settings.ID, logsConsumerGroup)
+	}
+	if err = c.subscription.start(ctx); err != nil {
+		return err
 	}
 	go func() {
 		if err := c.consumeLoop(ctx, logsConsumerGroup); err != nil {
 			host.ReportFatalError(err)
 		}
 	}()
-	<-logsConsumerGroup.ready
+	// Without topics to subscribe to, no session is started until matching topics are created.
+	if len(c.subscription.current()) > 0 {
+		<-logsConsumerGroup.ready
+	}
 	return nil
 }
 
@@ -365,8 +607,10 @@ func (c *kafkaLogsConsumer) consumeLoop(ctx context.Context, handler sarama.Cons
 		// `Consume` should be called inside an infinite loop, when a
 		// server-side rebalance happens, the consumer session will need to be
 		// recreated to get the new claims
-		if err := c.consumerGroup.Consume(ctx, c.topics, handler); err != nil {
-			c.settings.Logger.Error("Error from consumer", zap.Error(err))
+		if sessionCtx, topics, ok := c.subscription.next(ctx); ok {
+			if err := c.consumerGroup.Consume(sessionCtx, topics, handler); err != nil {
+				c.settings.Logger.Error("Error from consumer", zap.Error(err))
+			}
 		}
 		// check if context was cancelled, signaling that the consumer should stop
 		if ctx.Err() != nil {
@@ -376,8 +620,14 @@ func (c *kafkaLogsConsumer) consumeLoop(ctx context.Context, handler sarama.Cons
 	}
 }
 
-func (c *kafkaLogsConsumer) Shutdown(context.Context) error {
+func (c *kafkaLogsConsumer) Shutdown(ctx context.Context) error {
 	c.cancelConsumeLoop()
+	// Closed last, the dead letters of the last consumed messages are flushed.
+	defer c.deadLetters.close()
+	if c.assignment != nil {
+		return c.assignment.shutdown(ctx)
+	}
+	c.subscription.shutdown()
 	return c.consumerGroup.Close()
 }
 
@@ -392,8 +642,22 @@ type tracesConsumerGroupHandler struct {
 
 	obsrecv *obsreport.Receiver
 
-	autocommitEnabled bool
-	messageMarking    MessageMarking
+	autocommitEnabled     bool
+	commitInterval        time.Duration
+	processingConcurrency int
+	messageMarking        MessageMarking
+	messageMetadata       MessageMetadata
+	maxMessageAge         time.Duration
+	lagRefreshInterval    time.Duration
+	seeker                *timestampSeeker
+	headers               *headerExtractor
+	keyExtraction         KeyExtraction
+	onError               string
+	errorBackOff          ErrorBackOff
+	onDecodeError         string
+	decodeErrors          *decodeErrorLogger
+	deadLetters           *deadLetterQueue
+	backpressure          *backpressure
 }
 
 type metricsConsumerGroupHandler struct {
@@ -407,8 +671,22 @@ type metricsConsumerGroupHandler struct {
 
 	obsrecv *obsreport.Receiver
 
-	autocommitEnabled bool
-	messageMarking    MessageMarking
+	autocommitEnabled     bool
+	commitInterval        time.Duration
+	processingConcurrency int
+	messageMarking        MessageMarking
+	messageMetadata       MessageMetadata
+	maxMessageAge         time.Duration
+	lagRefreshInterval    time.Duration
+	seeker                *timestampSeeker
+	headers               *headerExtractor
+	keyExtraction         KeyExtraction
+	onError               string
+	errorBackOff          ErrorBackOff
+	onDecodeError         string
+	decodeErrors          *decodeErrorLogger
+	deadLetters           *deadLetterQueue
+	backpressure          *backpressure
 }
 
 type logsConsumerGroupHandler struct {
@@ -422,8 +700,23 @@ type logsConsumerGroupHandler struct {
 
 	obsrecv *obsreport.Receiver
 
-	autocommitEnabled bool
-	messageMarking    MessageMarking
+	autocommitEnabled     bool
+	commitInterval        time.Duration
+	processingConcurrency int
+	messageMarking        MessageMarking
+	messageMetadata       MessageMetadata
+	observedTimestamp     string
+	maxMessageAge         time.Duration
+	lagRefreshInterval    time.Duration
+	seeker                *timestampSeeker
+	headers               *headerExtractor
+	keyExtraction         KeyExtraction
+	onError               string
+	errorBackOff          ErrorBackOff
+	onDecodeError         string
+	decodeErrors          *decodeErrorLogger
+	deadLetters           *deadLetterQueue
+	backpressure          *backpressure
 }
 
 var _ sarama.ConsumerGroupHandler = (*tracesConsumerGroupHandler)(nil)
@@ -436,6 +729,9 @@ func (c *tracesConsumerGroupHandler) Setup(session sarama.ConsumerGroupSession)
 	})
 	statsTags := []tag.Mutator{tag.Upsert(tagInstanceName, c.id.Name())}
 	_ = stats.RecordWithTags(session.Context(), statsTags, statPartitionStart.M(1))
+	if c.seeker != nil {
+		return c.seeker.seek(session)
+	}
 	return nil
 }
 
@@ -447,70 +743,123 @@ func (c *tracesConsumerGroupHandler) Cleanup(session sarama.ConsumerGroupSession
 
 func (c *tracesConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	c.logger.Info("Starting consumer group", zap.Int32("partition", claim.Partition()))
-	if !c.autocommitEnabled {
-		defer session.Commit()
-	}
+	lag := newPartitionLag(c.lagRefreshInterval, c.id, claim)
+	session = lag.track(session)
+	lag.start(session.Context())
+	defer lag.stop()
+	committer := newOffsetCommitter(c.autocommitEnabled, c.commitInterval, session)
+	defer committer.flush()
+	skipper := newExpiredMessageSkipper(c.maxMessageAge, c.id, claim, c.logger)
+	defer skipper.report()
+	retrier := newDeliveryRetrier(c.onError, c.errorBackOff, c.id, claim, c.logger)
+	c.backpressure.claim(claim)
+	defer c.backpressure.release(claim)
+	processor := newClaimProcessor(c.processingConcurrency, session)
 	for {
 		select {
 		case message, ok := <-claim.Messages():
 			if !ok {
-				return nil
+				return processor.wait()
 			}
 			c.logger.Debug("Kafka message claimed",
 				zap.String("value", string(message.Value)),
 				zap.Time("timestamp", message.Timestamp),
 				zap.String("topic", message.Topic))
-			if !c.messageMarking.After {
-				session.MarkMessage(message, "")
-			}
-
-			ctx := c.obsrecv.StartTracesOp(session.Context())
-			statsTags := []tag.Mutator{tag.Upsert(tagInstanceName, c.id.String())}
-			_ = stats.RecordWithTags(ctx, statsTags,
-				statMessageCount.M(1),
-				statMessageOffset.M(message.Offset),
-				statMessageOffsetLag.M(claim.HighWaterMarkOffset()-message.Offset-1))
-
-			traces, err := c.unmarshaler.Unmarshal(message.Value)
-			if err != nil {
-				c.logger.Error("failed to unmarshal message", zap.Error(err))
-				if c.messageMarking.After && c.messageMarking.OnError {
-					session.MarkMessage(message, "")
-				}
-				return err
-			}
-
-			spanCount := traces.SpanCount()
-			err = c.nextConsumer.ConsumeTraces(session.Context(), traces)
-			c.obsrecv.EndTracesOp(ctx, c.unmarshaler.Encoding(), spanCount, err)
-			if err != nil {
-				if c.messageMarking.After && c.messageMarking.OnError {
-					session.MarkMessage(message, "")
-				}
-				return err
-			}
-			if c.messageMarking.After {
-				session.MarkMessage(message, "")
+			if skipper.skip(session.Context(), message) {
+				processor.skip(message)
+				continue
 			}
-			if !c.autocommitEnabled {
-				session.Commit()
+			if !processor.process(message, func(marker messageMarker) error {
+				return c.handleMessage(session, claim, message, marker, retrier, committer)
+			}) {
+				return processor.wait()
 			}
 
 		// Should return when `session.Context()` is done.
 		// If not, will raise `ErrRebalanceInProgress` or `read tcp <ip>:<port>: i/o timeout` when kafka rebalance. see:
 		// https://github.com/IBM/sarama/issues/1192
 		case <-session.Context().Done():
+			_ = processor.wait()
 			return nil
 		}
 	}
 }
 
+// handleMessage unmarshals message and delivers it to the next consumer, then marks it with marker
+// according to the message marking settings. It returns errDeliveryInterrupted if the session ends
+// before the message is delivered.
+func (c *tracesConsumerGroupHandler) handleMessage(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, message *sarama.ConsumerMessage, marker messageMarker, retrier *deliveryRetrier, committer *offsetCommitter) error {
+	// Retried messages are only marked once handled, so they are not lost on a rebalance.
+	marked := marksBeforeDelivery(c.messageMarking, retrier, c.backpressure, c.onDecodeError)
+	if marked {
+		marker.MarkMessage(message, "")
+		committer.commit()
+	}
+
+	ctx := c.obsrecv.StartTracesOp(session.Context())
+	statsTags := []tag.Mutator{tag.Upsert(tagInstanceName, c.id.String()), tag.Upsert(tagTopic, message.Topic), tag.Upsert(tagPartition, strconv.Itoa(int(message.Partition)))}
+	_ = stats.RecordWithTags(ctx, statsTags,
+		statMessageCount.M(1),
+		statMessageBytes.M(int64(len(message.Key)+len(message.Value))),
+		statMessageOffset.M(message.Offset),
+		statMessageOffsetLag.M(claim.HighWaterMarkOffset()-message.Offset-1))
+
+	traces, err := c.unmarshaler.Unmarshal(message.Value)
+	if err != nil {
+		c.decodeErrors.log(message, err)
+		_ = stats.RecordWithTags(ctx, append(statsTags, tag.Upsert(tagEncoding, c.unmarshaler.Encoding())), statMessageUnmarshalFailed.M(1))
+		return handleDecodeError(c.onDecodeError, c.deadLetters, c.messageMarking, marked, marker, message, err)
+	}
+	if c.messageMetadata.Enable {
+		putTracesMessageMetadata(traces, message, c.messageMetadata.Placement)
+	}
+	if c.headers != nil {
+		for i := 0; i < traces.ResourceSpans().Len(); i++ {
+			c.headers.put(traces.ResourceSpans().At(i).Resource().Attributes(), message)
+		}
+	}
+	if c.keyExtraction.Attribute != "" && message.Key != nil {
+		for i := 0; i < traces.ResourceSpans().Len(); i++ {
+			putMessageKey(traces.ResourceSpans().At(i).Resource().Attributes(), message, c.keyExtraction)
+		}
+	}
+
+	spanCount := traces.SpanCount()
+	err = retrier.deliver(session.Context(), message, func() error {
+		return c.backpressure.deliver(session.Context(), claim, func() error {
+			return c.nextConsumer.ConsumeTraces(session.Context(), traces)
+		})
+	})
+	c.obsrecv.EndTracesOp(ctx, c.unmarshaler.Encoding(), spanCount, err)
+	if errors.Is(err, errDeliveryInterrupted) {
+		return err
+	}
+	if err != nil {
+		// A delivery cut short by the end of the session is left to the next owner of the partition.
+		if c.messageMarking.After && session.Context().Err() != nil {
+			return errDeliveryInterrupted
+		}
+		if !c.messageMarking.After || c.messageMarking.OnError {
+			marker.MarkMessage(message, "")
+		}
+		return err
+	}
+	if !marked {
+		marker.MarkMessage(message, "")
+	}
+	committer.commit()
+	return nil
+}
+
 func (c *metricsConsumerGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
 	c.readyCloser.Do(func() {
 		close(c.ready)
 	})
 	statsTags := []tag.Mutator{tag.Upsert(tagInstanceName, c.id.Name())}
 	_ = stats.RecordWithTags(session.Context(), statsTags, statPartitionStart.M(1))
+	if c.seeker != nil {
+		return c.seeker.seek(session)
+	}
 	return nil
 }
 
@@ -522,64 +871,114 @@ func (c *metricsConsumerGroupHandler) Cleanup(session sarama.ConsumerGroupSessio
 
 func (c *metricsConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	c.logger.Info("Starting consumer group", zap.Int32("partition", claim.Partition()))
-	if !c.autocommitEnabled {
-		defer session.Commit()
-	}
+	lag := newPartitionLag(c.lagRefreshInterval, c.id, claim)
+	session = lag.track(session)
+	lag.start(session.Context())
+	defer lag.stop()
+	committer := newOffsetCommitter(c.autocommitEnabled, c.commitInterval, session)
+	defer committer.flush()
+	skipper := newExpiredMessageSkipper(c.maxMessageAge, c.id, claim, c.logger)
+	defer skipper.report()
+	retrier := newDeliveryRetrier(c.onError, c.errorBackOff, c.id, claim, c.logger)
+	c.backpressure.claim(claim)
+	defer c.backpressure.release(claim)
+	processor := newClaimProcessor(c.processingConcurrency, session)
 	for {
 		select {
 		case message, ok := <-claim.Messages():
 			if !ok {
-				return nil
+				return processor.wait()
 			}
 			c.logger.Debug("Kafka message claimed",
 				zap.String("value", string(message.Value)),
 				zap.Time("timestamp", message.Timestamp),
 				zap.String("topic", message.Topic))
-			if !c.messageMarking.After {
-				session.MarkMessage(message, "")
+			if skipper.skip(session.Context(), message) {
+				processor.skip(message)
+				continue
 			}
-
-			ctx := c.obsrecv.StartMetricsOp(session.Context())
-			statsTags := []tag.Mutator{tag.Upsert(tagInstanceName, c.id.String())}
-			_ = stats.RecordWithTags(ctx, statsTags,
-				statMessageCount.M(1),
-				statMessageOffset.M(message.Offset),
-				statMessageOffsetLag.M(claim.HighWaterMarkOffset()-message.Offset-1))
-
-			metrics, err := c.unmarshaler.Unmarshal(message.Value)
-			if err != nil {
-				c.logger.Error("failed to unmarshal message", zap.Error(err))
-				if c.messageMarking.After && c.messageMarking.OnError {
-					session.MarkMessage(message, "")
-				}
-				return err
-			}
-
-			dataPointCount := metrics.DataPointCount()
-			err = c.nextConsumer.ConsumeMetrics(session.Context(), metrics)
-			c.obsrecv.EndMetricsOp(ctx, c.unmarshaler.Encoding(), dataPointCount, err)
-			if err != nil {
-				if c.messageMarking.After && c.messageMarking.OnError {
-					session.MarkMessage(message, "")
-				}
-				return err
-			}
-			if c.messageMarking.After {
-				session.MarkMessage(message, "")
-			}
-			if !c.autocommitEnabled {
-				session.Commit()
+			if !processor.process(message, func(marker messageMarker) error {
+				return c.handleMessage(session, claim, message, marker, retrier, committer)
+			}) {
+				return processor.wait()
 			}
 
 		// Should return when `session.Context()` is done.
 		// If not, will raise `ErrRebalanceInProgress` or `read tcp <ip>:<port>: i/o timeout` when kafka rebalance. see:
 		// https://github.com/IBM/sarama/issues/1192
 		case <-session.Context().Done():
+			_ = processor.wait()
 			return nil
 		}
 	}
 }
 
+// handleMessage unmarshals message and delivers it to the next consumer, then marks it with marker
+// according to the message marking settings. It returns errDeliveryInterrupted if the session ends
+// before the message is delivered.
+func (c *metricsConsumerGroupHandler) handleMessage(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, message *sarama.ConsumerMessage, marker messageMarker, retrier *deliveryRetrier, committer *offsetCommitter) error {
+	// Retried messages are only marked once handled, so they are not lost on a rebalance.
+	marked := marksBeforeDelivery(c.messageMarking, retrier, c.backpressure, c.onDecodeError)
+	if marked {
+		marker.MarkMessage(message, "")
+		committer.commit()
+	}
+
+	ctx := c.obsrecv.StartMetricsOp(session.Context())
+	statsTags := []tag.Mutator{tag.Upsert(tagInstanceName, c.id.String()), tag.Upsert(tagTopic, message.Topic), tag.Upsert(tagPartition, strconv.Itoa(int(message.Partition)))}
+	_ = stats.RecordWithTags(ctx, statsTags,
+		statMessageCount.M(1),
+		statMessageBytes.M(int64(len(message.Key)+len(message.Value))),
+		statMessageOffset.M(message.Offset),
+		statMessageOffsetLag.M(claim.HighWaterMarkOffset()-message.Offset-1))
+
+	metrics, err := c.unmarshaler.Unmarshal(message.Value)
+	if err != nil {
+		c.decodeErrors.log(message, err)
+		_ = stats.RecordWithTags(ctx, append(statsTags, tag.Upsert(tagEncoding, c.unmarshaler.Encoding())), statMessageUnmarshalFailed.M(1))
+		return handleDecodeError(c.onDecodeError, c.deadLetters, c.messageMarking, marked, marker, message, err)
+	}
+	if c.messageMetadata.Enable {
+		putMetricsMessageMetadata(metrics, message, c.messageMetadata.Placement)
+	}
+	if c.headers != nil {
+		for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+			c.headers.put(metrics.ResourceMetrics().At(i).Resource().Attributes(), message)
+		}
+	}
+	if c.keyExtraction.Attribute != "" && message.Key != nil {
+		for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+			putMessageKey(metrics.ResourceMetrics().At(i).Resource().Attributes(), message, c.keyExtraction)
+		}
+	}
+
+	dataPointCount := metrics.DataPointCount()
+	err = retrier.deliver(session.Context(), message, func() error {
+		return c.backpressure.deliver(session.Context(), claim, func() error {
+			return c.nextConsumer.ConsumeMetrics(session.Context(), metrics)
+		})
+	})
+	c.obsrecv.EndMetricsOp(ctx, c.unmarshaler.Encoding(), dataPointCount, err)
+	if errors.Is(err, errDeliveryInterrupted) {
+		return err
+	}
+	if err != nil {
+		// A delivery cut short by the end of the session is left to the next owner of the partition.
+		if c.messageMarking.After && session.Context().Err() != nil {
+			return errDeliveryInterrupted
+		}
+		if !c.messageMarking.After || c.messageMarking.OnError {
+			marker.MarkMessage(message, "")
+		}
+		return err
+	}
+	if !marked {
+		marker.MarkMessage(message, "")
+	}
+	committer.commit()
+	return nil
+}
+
 func (c *logsConsumerGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
 	c.readyCloser.Do(func() {
 		close(c.ready)
@@ -588,6 +987,9 @@ func (c *logsConsumerGroupHandler) Setup(session sarama.ConsumerGroupSession) er
 		session.Context(),
 		[]tag.Mutator{tag.Upsert(tagInstanceName, c.id.String())},
 		statPartitionStart.M(1))
+	if c.seeker != nil {
+		return c.seeker.seek(session)
+	}
 	return nil
 }
 
@@ -601,65 +1003,295 @@ func (c *logsConsumerGroupHandler) Cleanup(session sarama.ConsumerGroupSession)
 
 func (c *logsConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	c.logger.Info("Starting consumer group", zap.Int32("partition", claim.Partition()))
-	if !c.autocommitEnabled {
-		defer session.Commit()
-	}
+	lag := newPartitionLag(c.lagRefreshInterval, c.id, claim)
+	session = lag.track(session)
+	lag.start(session.Context())
+	defer lag.stop()
+	committer := newOffsetCommitter(c.autocommitEnabled, c.commitInterval, session)
+	defer committer.flush()
+	skipper := newExpiredMessageSkipper(c.maxMessageAge, c.id, claim, c.logger)
+	defer skipper.report()
+	retrier := newDeliveryRetrier(c.onError, c.errorBackOff, c.id, claim, c.logger)
+	c.backpressure.claim(claim)
+	defer c.backpressure.release(claim)
+	processor := newClaimProcessor(c.processingConcurrency, session)
 	for {
 		select {
 		case message, ok := <-claim.Messages():
 			if !ok {
-				return nil
+				return processor.wait()
 			}
 			c.logger.Debug("Kafka message claimed",
 				zap.String("value", string(message.Value)),
 				zap.Time("timestamp", message.Timestamp),
 				zap.String("topic", message.Topic))
-			if !c.messageMarking.After {
-				session.MarkMessage(message, "")
-			}
-
-			ctx := c.obsrecv.StartLogsOp(session.Context())
-			_ = stats.RecordWithTags(
-				ctx,
-				[]tag.Mutator{tag.Upsert(tagInstanceName, c.id.String())},
-				statMessageCount.M(1),
-				statMessageOffset.M(message.Offset),
-				statMessageOffsetLag.M(claim.HighWaterMarkOffset()-message.Offset-1))
-
-			logs, err := c.unmarshaler.Unmarshal(message.Value)
-			if err != nil {
-				c.logger.Error("failed to unmarshal message", zap.Error(err))
-				if c.messageMarking.After && c.messageMarking.OnError {
-					session.MarkMessage(message, "")
-				}
-				return err
-			}
-
-			err = c.nextConsumer.ConsumeLogs(session.Context(), logs)
-			// TODO
-			c.obsrecv.EndLogsOp(ctx, c.unmarshaler.Encoding(), logs.LogRecordCount(), err)
-			if err != nil {
-				if c.messageMarking.After && c.messageMarking.OnError {
-					session.MarkMessage(message, "")
-				}
-				return err
-			}
-			if c.messageMarking.After {
-				session.MarkMessage(message, "")
+			if skipper.skip(session.Context(), message) {
+				processor.skip(message)
+				continue
 			}
-			if !c.autocommitEnabled {
-				session.Commit()
+			if !processor.process(message, func(marker messageMarker) error {
+				return c.handleMessage(session, claim, message, marker, retrier, committer)
+			}) {
+				return processor.wait()
 			}
 
 		// Should return when `session.Context()` is done.
 		// If not, will raise `ErrRebalanceInProgress` or `read tcp <ip>:<port>: i/o timeout` when kafka rebalance. see:
 		// https://github.com/IBM/sarama/issues/1192
 		case <-session.Context().Done():
+			_ = processor.wait()
 			return nil
 		}
 	}
 }
 
+// handleMessage unmarshals message and delivers it to the next consumer, then marks it with marker
+// according to the message marking settings. It returns errDeliveryInterrupted if the session ends
+// before the message is delivered.
+func (c *logsConsumerGroupHandler) handleMessage(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, message *sarama.ConsumerMessage, marker messageMarker, retrier *deliveryRetrier, committer *offsetCommitter) error {
+	// Retried messages are only marked once handled, so they are not lost on a rebalance.
+	marked := marksBeforeDelivery(c.messageMarking, retrier, c.backpressure, c.onDecodeError)
+	if marked {
+		marker.MarkMessage(message, "")
+		committer.commit()
+	}
+
+	ctx := c.obsrecv.StartLogsOp(session.Context())
+	statsTags := []tag.Mutator{tag.Upsert(tagInstanceName, c.id.String()), tag.Upsert(tagTopic, message.Topic), tag.Upsert(tagPartition, strconv.Itoa(int(message.Partition)))}
+	_ = stats.RecordWithTags(ctx, statsTags,
+		statMessageCount.M(1),
+		statMessageBytes.M(int64(len(message.Key)+len(message.Value))),
+		statMessageOffset.M(message.Offset),
+		statMessageOffsetLag.M(claim.HighWaterMarkOffset()-message.Offset-1))
+
+	logs, err := c.unmarshaler.Unmarshal(message.Value)
+	if err != nil {
+		c.decodeErrors.log(message, err)
+		_ = stats.RecordWithTags(ctx, append(statsTags, tag.Upsert(tagEncoding, c.unmarshaler.Encoding())), statMessageUnmarshalFailed.M(1))
+		return handleDecodeError(c.onDecodeError, c.deadLetters, c.messageMarking, marked, marker, message, err)
+	}
+	if c.messageMetadata.Enable {
+		putLogsMessageMetadata(logs, message, c.messageMetadata.Placement)
+	}
+	if c.headers != nil {
+		for i := 0; i < logs.ResourceLogs().Len(); i++ {
+			c.headers.put(logs.ResourceLogs().At(i).Resource().Attributes(), message)
+		}
+	}
+	if c.keyExtraction.Attribute != "" && message.Key != nil {
+		for i := 0; i < logs.ResourceLogs().Len(); i++ {
+			putMessageKey(logs.ResourceLogs().At(i).Resource().Attributes(), message, c.keyExtraction)
+		}
+	}
+	if c.unmarshaler.Encoding() == "text" {
+		putTextMessageAttributes(logs, message)
+	}
+	if c.observedTimestamp == observedTimestampMessage {
+		setMessageTimestamp(logs, message, untimedEncodings[c.unmarshaler.Encoding()])
+	}
+
+	err = retrier.deliver(session.Context(), message, func() error {
+		return c.backpressure.deliver(session.Context(), claim, func() error {
+			return c.nextConsumer.ConsumeLogs(session.Context(), logs)
+		})
+	})
+	// TODO
+	c.obsrecv.EndLogsOp(ctx, c.unmarshaler.Encoding(), logs.LogRecordCount(), err)
+	if errors.Is(err, errDeliveryInterrupted) {
+		return err
+	}
+	if err != nil {
+		// A delivery cut short by the end of the session is left to the next owner of the partition.
+		if c.messageMarking.After && session.Context().Err() != nil {
+			return errDeliveryInterrupted
+		}
+		if !c.messageMarking.After || c.messageMarking.OnError {
+			marker.MarkMessage(message, "")
+		}
+		return err
+	}
+	if !marked {
+		marker.MarkMessage(message, "")
+	}
+	committer.commit()
+	return nil
+}
+
+// putMessageMetadata adds the topic, partition and offset of the message to attrs
+// following the messaging semantic conventions.
+func putMessageMetadata(attrs pcommon.Map, message *sarama.ConsumerMessage) {
+	attrs.PutStr(conventions.AttributeMessagingSourceName, message.Topic)
+	attrs.PutInt(conventions.AttributeMessagingKafkaSourcePartition, int64(message.Partition))
+	attrs.PutInt(conventions.AttributeMessagingKafkaMessageOffset, message.Offset)
+}
+
+// putTracesMessageMetadata adds the metadata of the message to the resources of traces, or to their spans with
+// the record placement.
+func putTracesMessageMetadata(traces ptrace.Traces, message *sarama.ConsumerMessage, placement string) {
+	for i := 0; i < traces.ResourceSpans().Len(); i++ {
+		rs := traces.ResourceSpans().At(i)
+		if placement != messageMetadataPlacementRecord {
+			putMessageMetadata(rs.Resource().Attributes(), message)
+			continue
+		}
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			spans := rs.ScopeSpans().At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				putMessageMetadata(spans.At(k).Attributes(), message)
+			}
+		}
+	}
+}
+
+// putMetricsMessageMetadata adds the metadata of the message to the resources of metrics, or to their data points
+// with the record placement.
+func putMetricsMessageMetadata(metrics pmetric.Metrics, message *sarama.ConsumerMessage, placement string) {
+	for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+		rm := metrics.ResourceMetrics().At(i)
+		if placement != messageMetadataPlacementRecord {
+			putMessageMetadata(rm.Resource().Attributes(), message)
+			continue
+		}
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			ms := rm.ScopeMetrics().At(j).Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				putDataPointsMessageMetadata(ms.At(k), message)
+			}
+		}
+	}
+}
+
+func putDataPointsMessageMetadata(metric pmetric.Metric, message *sarama.ConsumerMessage) {
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		for i := 0; i < metric.Gauge().DataPoints().Len(); i++ {
+			putMessageMetadata(metric.Gauge().DataPoints().At(i).Attributes(), message)
+		}
+	case pmetric.MetricTypeSum:
+		for i := 0; i < metric.Sum().DataPoints().Len(); i++ {
+			putMessageMetadata(metric.Sum().DataPoints().At(i).Attributes(), message)
+		}
+	case pmetric.MetricTypeHistogram:
+		for i := 0; i < metric.Histogram().DataPoints().Len(); i++ {
+			putMessageMetadata(metric.Histogram().DataPoints().At(i).Attributes(), message)
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for i := 0; i < metric.ExponentialHistogram().DataPoints().Len(); i++ {
+			putMessageMetadata(metric.ExponentialHistogram().DataPoints().At(i).Attributes(), message)
+		}
+	case pmetric.MetricTypeSummary:
+		for i := 0; i < metric.Summary().DataPoints().Len(); i++ {
+			putMessageMetadata(metric.Summary().DataPoints().At(i).Attributes(), message)
+		}
+	}
+}
+
+// putLogsMessageMetadata adds the metadata of the message to the resources of logs, or to their log records with
+// the record placement.
+func putLogsMessageMetadata(logs plog.Logs, message *sarama.ConsumerMessage, placement string) {
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		rl := logs.ResourceLogs().At(i)
+		if placement != messageMetadataPlacementRecord {
+			putMessageMetadata(rl.Resource().Attributes(), message)
+			continue
+		}
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			records := rl.ScopeLogs().At(j).LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				putMessageMetadata(records.At(k).Attributes(), message)
+			}
+		}
+	}
+}
+
+// putMessageKey adds the key of the message to attrs, hex-encoded if the key extraction encoding is hex
+// or if the key is not valid UTF-8.
+func putMessageKey(attrs pcommon.Map, message *sarama.ConsumerMessage, extraction KeyExtraction) {
+	value := string(message.Key)
+	if extraction.Encoding == keyEncodingHex || !utf8.Valid(message.Key) {
+		value = hex.EncodeToString(message.Key)
+	}
+	attrs.PutStr(extraction.Attribute, value)
+}
+
+// untimedEncodings are the encodings of the logs whose payloads have no timestamp.
+var untimedEncodings = map[string]bool{
+	"raw":  true,
+	"text": true,
+}
+
+// setMessageTimestamp sets the observed timestamp of the log records of logs to the timestamp of the
+// message, and their timestamp too if setTimestamp is true and they have none. The messages without
+// a timestamp, produced before Kafka 0.10 or with a negative one, fall back to the receive time.
+func setMessageTimestamp(logs plog.Logs, message *sarama.ConsumerMessage, setTimestamp bool) {
+	ts := pcommon.NewTimestampFromTime(time.Now())
+	if message.Timestamp.After(time.Unix(0, 0)) {
+		ts = pcommon.NewTimestampFromTime(message.Timestamp)
+	}
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		sls := logs.ResourceLogs().At(i).ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			records := sls.At(j).LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				record := records.At(k)
+				record.SetObservedTimestamp(ts)
+				if setTimestamp && record.Timestamp() == 0 {
+					record.SetTimestamp(ts)
+				}
+			}
+		}
+	}
+}
+
+// expiredMessageSkipper drops the messages of a claim whose timestamp is older than maxAge.
+// The number of skipped messages is logged once the first recent message is received, or
+// when the claim ends.
+type expiredMessageSkipper struct {
+	maxAge    time.Duration
+	logger    *zap.Logger
+	statsTags []tag.Mutator
+	topic     string
+	partition int32
+	skipped   int64
+}
+
+func newExpiredMessageSkipper(maxAge time.Duration, id component.ID, claim sarama.ConsumerGroupClaim, logger *zap.Logger) *expiredMessageSkipper {
+	return &expiredMessageSkipper{
+		maxAge: maxAge,
+		logger: logger,
+		statsTags: []tag.Mutator{
+			tag.Upsert(tagInstanceName, id.String()),
+			tag.Upsert(tagTopic, claim.Topic()),
+			tag.Upsert(tagPartition, strconv.Itoa(int(claim.Partition()))),
+		},
+		topic:     claim.Topic(),
+		partition: claim.Partition(),
+	}
+}
+
+// skip reports whether the message is expired. Messages without a timestamp are never expired.
+func (s *expiredMessageSkipper) skip(ctx context.Context, message *sarama.ConsumerMessage) bool {
+	if s.maxAge <= 0 || message.Timestamp.IsZero() || time.Since(message.Timestamp) <= s.maxAge {
+		s.report()
+		return false
+	}
+	s.skipped++
+	_ = stats.RecordWithTags(ctx, s.statsTags, statMessageSkipped.M(1))
+	return true
+}
+
+func (s *expiredMessageSkipper) report() {
+	if s.skipped == 0 {
+		return
+	}
+	s.logger.Info("Skipped messages older than max_message_age",
+		zap.String("topic", s.topic),
+		zap.Int32("partition", s.partition),
+		zap.Int64("skipped", s.skipped),
+		zap.Duration("max_message_age", s.maxAge))
+	s.skipped = 0
+}
+
 func toSaramaInitialOffset(initialOffset string) (int64, error) {
 	switch initialOffset {
 	case offsetEarliest:
@@ -668,7 +1300,49 @@ func toSaramaInitialOffset(initialOffset string) (int64, error) {
 		fallthrough
 	case "":
 		return sarama.OffsetNewest, nil
+	case offsetTimestamp:
+		// The offsets are resolved from the timestamp when the partitions are claimed,
+		// this is only used if the offset of the timestamp cannot be resolved.
+		return sarama.OffsetNewest, nil
 	default:
 		return 0, errInvalidInitialOffset
 	}
 }
+
+// configureConsumerTuning maps the fetch, session and group settings onto c. The settings left
+// to zero keep the defaults of sarama.
+func configureConsumerTuning(config Config, c *sarama.Config) {
+	if config.Fetch.MinBytes > 0 {
+		c.Consumer.Fetch.Min = config.Fetch.MinBytes
+	}
+	if config.Fetch.DefaultBytes > 0 {
+		c.Consumer.Fetch.Default = config.Fetch.DefaultBytes
+	}
+	if config.Fetch.MaxBytes > 0 {
+		c.Consumer.Fetch.Max = config.Fetch.MaxBytes
+	}
+	if config.MaxWait > 0 {
+		c.Consumer.MaxWaitTime = config.MaxWait
+	}
+	if config.SessionTimeout > 0 {
+		c.Consumer.Group.Session.Timeout = config.SessionTimeout
+	}
+	if config.HeartbeatInterval > 0 {
+		c.Consumer.Group.Heartbeat.Interval = config.HeartbeatInterval
+	}
+	if config.MaxProcessingTime > 0 {
+		c.Consumer.MaxProcessingTime = config.MaxProcessingTime
+	}
+	if newStrategy, ok := balanceStrategies[config.GroupRebalanceStrategy]; ok {
+		c.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{newStrategy()}
+	}
+	c.Consumer.Group.InstanceId = config.GroupInstanceID
+}
+
+// balanceStrategies are the group_rebalance_strategy values. sarama has no cooperative strategy,
+// sticky is the eager one: all the partitions are revoked at every rebalance.
+var balanceStrategies = map[string]func() sarama.BalanceStrategy{
+	sarama.RangeBalanceStrategyName:      sarama.NewBalanceStrategyRange,
+	sarama.RoundRobinBalanceStrategyName: sarama.NewBalanceStrategyRoundRobin,
+	sarama.StickyBalanceStrategyName:     sarama.NewBalanceStrategySticky,
+}
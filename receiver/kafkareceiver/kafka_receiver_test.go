@@ -19,6 +19,7 @@ import (
 	"go.opentelemetry.io/collector/config/configtls"
 	"go.opentelemetry.io/collector/consumer/consumertest"
 	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
@@ -89,6 +90,7 @@ func TestTracesReceiverStart(t *testing.T) {
 		nextConsumer:  consumertest.NewNop(),
 		settings:      receivertest.NewNopCreateSettings(),
 		consumerGroup: &testConsumerGroup{},
+		subscription:  &topicSubscription{topics: []string{defaultTopic}},
 	}
 
 	require.NoError(t, c.Start(context.Background(), componenttest.NewNopHost()))
@@ -100,6 +102,7 @@ func TestTracesReceiverStartConsume(t *testing.T) {
 		nextConsumer:  consumertest.NewNop(),
 		settings:      receivertest.NewNopCreateSettings(),
 		consumerGroup: &testConsumerGroup{},
+		subscription:  &topicSubscription{topics: []string{defaultTopic}},
 	}
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	c.cancelConsumeLoop = cancelFunc
@@ -121,6 +124,7 @@ func TestTracesReceiver_error(t *testing.T) {
 		nextConsumer:  consumertest.NewNop(),
 		settings:      settings,
 		consumerGroup: &testConsumerGroup{err: expectedErr},
+		subscription:  &topicSubscription{topics: []string{defaultTopic}},
 	}
 
 	require.NoError(t, c.Start(context.Background(), componenttest.NewNopHost()))
@@ -346,6 +350,7 @@ func TestMetricsReceiverStart(t *testing.T) {
 		nextConsumer:  consumertest.NewNop(),
 		settings:      receivertest.NewNopCreateSettings(),
 		consumerGroup: &testConsumerGroup{},
+		subscription:  &topicSubscription{topics: []string{defaultTopic}},
 	}
 
 	require.NoError(t, c.Start(context.Background(), componenttest.NewNopHost()))
@@ -357,6 +362,7 @@ func TestMetricsReceiverStartConsume(t *testing.T) {
 		nextConsumer:  consumertest.NewNop(),
 		settings:      receivertest.NewNopCreateSettings(),
 		consumerGroup: &testConsumerGroup{},
+		subscription:  &topicSubscription{topics: []string{defaultTopic}},
 	}
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	c.cancelConsumeLoop = cancelFunc
@@ -378,6 +384,7 @@ func TestMetricsReceiver_error(t *testing.T) {
 		nextConsumer:  consumertest.NewNop(),
 		settings:      settings,
 		consumerGroup: &testConsumerGroup{err: expectedErr},
+		subscription:  &topicSubscription{topics: []string{defaultTopic}},
 	}
 
 	require.NoError(t, c.Start(context.Background(), componenttest.NewNopHost()))
@@ -601,6 +608,7 @@ func TestLogsReceiverStart(t *testing.T) {
 		nextConsumer:  consumertest.NewNop(),
 		settings:      receivertest.NewNopCreateSettings(),
 		consumerGroup: &testConsumerGroup{},
+		subscription:  &topicSubscription{topics: []string{defaultTopic}},
 	}
 
 	require.NoError(t, c.Start(context.Background(), componenttest.NewNopHost()))
@@ -612,6 +620,7 @@ func TestLogsReceiverStartConsume(t *testing.T) {
 		nextConsumer:  consumertest.NewNop(),
 		settings:      receivertest.NewNopCreateSettings(),
 		consumerGroup: &testConsumerGroup{},
+		subscription:  &topicSubscription{topics: []string{defaultTopic}},
 	}
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	c.cancelConsumeLoop = cancelFunc
@@ -633,6 +642,7 @@ func TestLogsReceiver_error(t *testing.T) {
 		nextConsumer:  consumertest.NewNop(),
 		settings:      settings,
 		consumerGroup: &testConsumerGroup{err: expectedErr},
+		subscription:  &topicSubscription{topics: []string{defaultTopic}},
 	}
 
 	require.NoError(t, c.Start(context.Background(), componenttest.NewNopHost()))
@@ -867,18 +877,386 @@ func TestLogsConsumerGroupHandler_unmarshal_text(t *testing.T) {
 			encoded, err := encoder.Bytes([]byte(test.text))
 			require.NoError(t, err)
 			t1 := time.Now()
-			groupClaim.messageChan <- &sarama.ConsumerMessage{Value: encoded}
+			groupClaim.messageChan <- &sarama.ConsumerMessage{Topic: "logs", Value: encoded}
 			close(groupClaim.messageChan)
 			wg.Wait()
 			require.Equal(t, sink.LogRecordCount(), 1)
 			log := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
 			assert.Equal(t, log.Body().Str(), test.text)
+			topic, _ := log.Attributes().Get("messaging.source.name")
+			assert.Equal(t, "logs", topic.Str())
 			assert.LessOrEqual(t, t1, log.ObservedTimestamp().AsTime())
 			assert.LessOrEqual(t, log.ObservedTimestamp().AsTime(), time.Now())
 		})
 	}
 }
 
+func TestLogsConsumerGroupHandler_message_metadata(t *testing.T) {
+	metadata := map[string]any{
+		"messaging.source.name":            "otlp_logs",
+		"messaging.kafka.source.partition": int64(3),
+		"messaging.kafka.message.offset":   int64(42),
+	}
+	tests := []struct {
+		placement          string
+		wantResourceAttrs  map[string]any
+		wantLogRecordAttrs map[string]any
+	}{
+		{placement: "", wantResourceAttrs: metadata, wantLogRecordAttrs: map[string]any{}},
+		{placement: "resource", wantResourceAttrs: metadata, wantLogRecordAttrs: map[string]any{}},
+		{placement: "record", wantResourceAttrs: map[string]any{}, wantLogRecordAttrs: metadata},
+	}
+	for _, tt := range tests {
+		t.Run(tt.placement, func(t *testing.T) {
+			obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+			require.NoError(t, err)
+			sink := &consumertest.LogsSink{}
+			c := logsConsumerGroupHandler{
+				unmarshaler:     newRawLogsUnmarshaler(),
+				logger:          zap.NewNop(),
+				ready:           make(chan bool),
+				nextConsumer:    sink,
+				obsrecv:         obsrecv,
+				messageMetadata: MessageMetadata{Enable: true, Placement: tt.placement},
+			}
+
+			wg := sync.WaitGroup{}
+			wg.Add(1)
+			groupClaim := &testConsumerGroupClaim{
+				messageChan: make(chan *sarama.ConsumerMessage),
+			}
+			go func() {
+				err = c.ConsumeClaim(testConsumerGroupSession{ctx: context.Background()}, groupClaim)
+				assert.NoError(t, err)
+				wg.Done()
+			}()
+			groupClaim.messageChan <- &sarama.ConsumerMessage{
+				Topic:     "otlp_logs",
+				Partition: 3,
+				Offset:    42,
+				Value:     []byte("message"),
+			}
+			close(groupClaim.messageChan)
+			wg.Wait()
+
+			require.Equal(t, 1, sink.LogRecordCount())
+			rl := sink.AllLogs()[0].ResourceLogs().At(0)
+			assert.Equal(t, tt.wantResourceAttrs, rl.Resource().Attributes().AsRaw())
+			assert.Equal(t, tt.wantLogRecordAttrs, rl.ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw())
+		})
+	}
+}
+
+func TestPutTracesMessageMetadata(t *testing.T) {
+	message := &sarama.ConsumerMessage{Topic: "otlp_spans", Partition: 1, Offset: 7}
+	metadata := map[string]any{
+		"messaging.source.name":            "otlp_spans",
+		"messaging.kafka.source.partition": int64(1),
+		"messaging.kafka.message.offset":   int64(7),
+	}
+
+	traces := testdata.GenerateTracesTwoSpansSameResource()
+	traces.ResourceSpans().At(0).Resource().Attributes().Clear()
+	putTracesMessageMetadata(traces, message, messageMetadataPlacementResource)
+	assert.Equal(t, metadata, traces.ResourceSpans().At(0).Resource().Attributes().AsRaw())
+
+	traces = testdata.GenerateTracesTwoSpansSameResource()
+	traces.ResourceSpans().At(0).Resource().Attributes().Clear()
+	putTracesMessageMetadata(traces, message, messageMetadataPlacementRecord)
+	assert.Equal(t, map[string]any{}, traces.ResourceSpans().At(0).Resource().Attributes().AsRaw())
+	spans := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	require.Equal(t, 2, spans.Len())
+	for i := 0; i < spans.Len(); i++ {
+		for k, v := range metadata {
+			assert.Equal(t, v, spans.At(i).Attributes().AsRaw()[k])
+		}
+	}
+}
+
+func TestPutMetricsMessageMetadata(t *testing.T) {
+	message := &sarama.ConsumerMessage{Topic: "otlp_metrics", Partition: 1, Offset: 7}
+	metadata := map[string]any{
+		"messaging.source.name":            "otlp_metrics",
+		"messaging.kafka.source.partition": int64(1),
+		"messaging.kafka.message.offset":   int64(7),
+	}
+
+	metrics := testdata.GenerateMetricsAllTypesEmptyDataPoint()
+	metrics.ResourceMetrics().At(0).Resource().Attributes().Clear()
+	putMetricsMessageMetadata(metrics, message, messageMetadataPlacementResource)
+	assert.Equal(t, metadata, metrics.ResourceMetrics().At(0).Resource().Attributes().AsRaw())
+
+	metrics = testdata.GenerateMetricsAllTypesEmptyDataPoint()
+	metrics.ResourceMetrics().At(0).Resource().Attributes().Clear()
+	putMetricsMessageMetadata(metrics, message, messageMetadataPlacementRecord)
+	assert.Equal(t, map[string]any{}, metrics.ResourceMetrics().At(0).Resource().Attributes().AsRaw())
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	points := 0
+	for i := 0; i < ms.Len(); i++ {
+		forEachDataPointAttributes(ms.At(i), func(attrs pcommon.Map) {
+			points++
+			for k, v := range metadata {
+				assert.Equal(t, v, attrs.AsRaw()[k], ms.At(i).Name())
+			}
+		})
+	}
+	assert.Equal(t, metrics.DataPointCount(), points)
+}
+
+func forEachDataPointAttributes(metric pmetric.Metric, f func(pcommon.Map)) {
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		for i := 0; i < metric.Gauge().DataPoints().Len(); i++ {
+			f(metric.Gauge().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeSum:
+		for i := 0; i < metric.Sum().DataPoints().Len(); i++ {
+			f(metric.Sum().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeHistogram:
+		for i := 0; i < metric.Histogram().DataPoints().Len(); i++ {
+			f(metric.Histogram().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for i := 0; i < metric.ExponentialHistogram().DataPoints().Len(); i++ {
+			f(metric.ExponentialHistogram().DataPoints().At(i).Attributes())
+		}
+	case pmetric.MetricTypeSummary:
+		for i := 0; i < metric.Summary().DataPoints().Len(); i++ {
+			f(metric.Summary().DataPoints().At(i).Attributes())
+		}
+	}
+}
+
+func TestLogsConsumerGroupHandler_header_extraction(t *testing.T) {
+	obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+	require.NoError(t, err)
+	sink := &consumertest.LogsSink{}
+	c := logsConsumerGroupHandler{
+		unmarshaler:  newRawLogsUnmarshaler(),
+		logger:       zap.NewNop(),
+		ready:        make(chan bool),
+		nextConsumer: sink,
+		obsrecv:      obsrecv,
+		headers: newHeaderExtractor(HeaderExtraction{
+			Headers: []string{"tenant", "route", "signature", "missing"},
+			Prefix:  "kafka.header.",
+		}),
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	groupClaim := &testConsumerGroupClaim{
+		messageChan: make(chan *sarama.ConsumerMessage),
+	}
+	go func() {
+		err = c.ConsumeClaim(testConsumerGroupSession{ctx: context.Background()}, groupClaim)
+		assert.NoError(t, err)
+		wg.Done()
+	}()
+	groupClaim.messageChan <- &sarama.ConsumerMessage{
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("tenant"), Value: []byte("acme")},
+			{Key: []byte("route"), Value: []byte("first")},
+			{Key: []byte("route"), Value: []byte("second")},
+			{Key: []byte("signature"), Value: []byte{0xff, 0xfe, 0x00}},
+			{Key: []byte("ignored"), Value: []byte("value")},
+		},
+		Value: []byte("message"),
+	}
+	close(groupClaim.messageChan)
+	wg.Wait()
+
+	require.Equal(t, 1, sink.LogRecordCount())
+	attrs := sink.AllLogs()[0].ResourceLogs().At(0).Resource().Attributes()
+	assert.Equal(t, map[string]any{
+		"kafka.header.tenant":    "acme",
+		"kafka.header.route":     "first",
+		"kafka.header.signature": "//4A",
+	}, attrs.AsRaw())
+}
+
+func TestLogsConsumerGroupHandler_key_extraction(t *testing.T) {
+	tests := []struct {
+		name       string
+		extraction KeyExtraction
+		key        []byte
+		expected   map[string]any
+	}{
+		{
+			name:       "string",
+			extraction: KeyExtraction{Attribute: "kafka.key", Encoding: keyEncodingString},
+			key:        []byte("0af7651916cd43dd"),
+			expected:   map[string]any{"kafka.key": "0af7651916cd43dd"},
+		},
+		{
+			name:       "hex",
+			extraction: KeyExtraction{Attribute: "kafka.key", Encoding: keyEncodingHex},
+			key:        []byte{0x0a, 0xf7},
+			expected:   map[string]any{"kafka.key": "0af7"},
+		},
+		{
+			name:       "invalid utf-8 string",
+			extraction: KeyExtraction{Attribute: "kafka.key", Encoding: keyEncodingString},
+			key:        []byte{0xff, 0xfe},
+			expected:   map[string]any{"kafka.key": "fffe"},
+		},
+		{
+			name:       "nil key",
+			extraction: KeyExtraction{Attribute: "kafka.key", Encoding: keyEncodingString},
+			expected:   map[string]any{},
+		},
+		{
+			name:     "disabled",
+			key:      []byte("key"),
+			expected: map[string]any{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+			require.NoError(t, err)
+			sink := &consumertest.LogsSink{}
+			c := logsConsumerGroupHandler{
+				unmarshaler:   newRawLogsUnmarshaler(),
+				logger:        zap.NewNop(),
+				ready:         make(chan bool),
+				nextConsumer:  sink,
+				obsrecv:       obsrecv,
+				keyExtraction: tt.extraction,
+			}
+
+			groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage, 1)}
+			groupClaim.messageChan <- &sarama.ConsumerMessage{Key: tt.key, Value: []byte("message")}
+			close(groupClaim.messageChan)
+			require.NoError(t, c.ConsumeClaim(testConsumerGroupSession{ctx: context.Background()}, groupClaim))
+
+			require.Equal(t, 1, sink.LogRecordCount())
+			assert.Equal(t, tt.expected, sink.AllLogs()[0].ResourceLogs().At(0).Resource().Attributes().AsRaw())
+		})
+	}
+}
+
+func TestLogsConsumerGroupHandler_observed_timestamp(t *testing.T) {
+	messageTime := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name              string
+		unmarshaler       LogsUnmarshaler
+		observedTimestamp string
+		messageTime       time.Time
+		value             string
+		// A zero expected timestamp stands for the receive time.
+		expectedObserved  time.Time
+		expectedTimestamp time.Time
+	}{
+		{
+			name:              "raw payload",
+			unmarshaler:       newRawLogsUnmarshaler(),
+			observedTimestamp: observedTimestampMessage,
+			messageTime:       messageTime,
+			value:             "message",
+			expectedObserved:  messageTime,
+			expectedTimestamp: messageTime,
+		},
+		{
+			name:              "json payload",
+			unmarshaler:       newJSONLogsUnmarshaler(),
+			observedTimestamp: observedTimestampMessage,
+			messageTime:       messageTime,
+			value:             `{"message": "hello"}`,
+			expectedObserved:  messageTime,
+			expectedTimestamp: time.Unix(0, 0),
+		},
+		{
+			name:              "message without timestamp",
+			unmarshaler:       newRawLogsUnmarshaler(),
+			observedTimestamp: observedTimestampMessage,
+			value:             "message",
+		},
+		{
+			name:              "receive time",
+			unmarshaler:       newJSONLogsUnmarshaler(),
+			observedTimestamp: observedTimestampReceiveTime,
+			messageTime:       messageTime,
+			value:             `{"message": "hello"}`,
+			expectedTimestamp: time.Unix(0, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+			require.NoError(t, err)
+			sink := &consumertest.LogsSink{}
+			c := logsConsumerGroupHandler{
+				unmarshaler:       tt.unmarshaler,
+				logger:            zap.NewNop(),
+				ready:             make(chan bool),
+				nextConsumer:      sink,
+				obsrecv:           obsrecv,
+				observedTimestamp: tt.observedTimestamp,
+			}
+
+			groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage, 1)}
+			groupClaim.messageChan <- &sarama.ConsumerMessage{Timestamp: tt.messageTime, Value: []byte(tt.value)}
+			close(groupClaim.messageChan)
+			start := time.Now()
+			require.NoError(t, c.ConsumeClaim(testConsumerGroupSession{ctx: context.Background()}, groupClaim))
+
+			require.Equal(t, 1, sink.LogRecordCount())
+			record := sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+			if tt.expectedObserved.IsZero() {
+				assert.False(t, record.ObservedTimestamp().AsTime().Before(start))
+			} else {
+				assert.Equal(t, tt.expectedObserved, record.ObservedTimestamp().AsTime())
+			}
+			if tt.expectedTimestamp.IsZero() {
+				assert.False(t, record.Timestamp().AsTime().Before(start))
+			} else {
+				assert.Equal(t, tt.expectedTimestamp.UTC(), record.Timestamp().AsTime())
+			}
+		})
+	}
+}
+
+func TestLogsConsumerGroupHandler_max_message_age(t *testing.T) {
+	obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+	require.NoError(t, err)
+	zcore, logObserver := observer.New(zapcore.InfoLevel)
+	sink := &consumertest.LogsSink{}
+	c := logsConsumerGroupHandler{
+		unmarshaler:   newRawLogsUnmarshaler(),
+		logger:        zap.New(zcore),
+		ready:         make(chan bool),
+		nextConsumer:  sink,
+		obsrecv:       obsrecv,
+		maxMessageAge: time.Hour,
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	groupClaim := &testConsumerGroupClaim{
+		messageChan: make(chan *sarama.ConsumerMessage),
+	}
+	go func() {
+		err = c.ConsumeClaim(testConsumerGroupSession{ctx: context.Background()}, groupClaim)
+		assert.NoError(t, err)
+		wg.Done()
+	}()
+	// Expired messages are skipped before they are unmarshaled.
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Timestamp: time.Now().Add(-2 * time.Hour), Value: []byte("expired")}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Timestamp: time.Now().Add(-90 * time.Minute), Value: []byte("expired")}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Timestamp: time.Now(), Value: []byte("recent")}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Value: []byte("no timestamp")}
+	close(groupClaim.messageChan)
+	wg.Wait()
+
+	require.Equal(t, 2, sink.LogRecordCount())
+	assert.Equal(t, []byte("recent"), sink.AllLogs()[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Bytes().AsRaw())
+	logs := logObserver.FilterMessage("Skipped messages older than max_message_age").All()
+	require.Len(t, logs, 1)
+	assert.Equal(t, int64(2), logs[0].ContextMap()["skipped"])
+}
+
 func TestGetLogsUnmarshaler_encoding_text(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -961,12 +1339,57 @@ func TestToSaramaInitialOffset_default(t *testing.T) {
 	assert.Equal(t, sarama.OffsetNewest, saramaInitialOffset)
 }
 
+func TestToSaramaInitialOffset_timestamp(t *testing.T) {
+	saramaInitialOffset, err := toSaramaInitialOffset(offsetTimestamp)
+
+	require.NoError(t, err)
+	assert.Equal(t, sarama.OffsetNewest, saramaInitialOffset)
+}
+
 func TestToSaramaInitialOffset_invalid(t *testing.T) {
 	_, err := toSaramaInitialOffset("other")
 
 	assert.Equal(t, err, errInvalidInitialOffset)
 }
 
+func TestConfigureConsumerTuning(t *testing.T) {
+	c := sarama.NewConfig()
+	configureConsumerTuning(*createDefaultConfig().(*Config), c)
+	defaults := sarama.NewConfig()
+	assert.Equal(t, defaults.Consumer.Fetch, c.Consumer.Fetch)
+	assert.Equal(t, defaults.Consumer.MaxWaitTime, c.Consumer.MaxWaitTime)
+	assert.Equal(t, defaults.Consumer.Group.Session, c.Consumer.Group.Session)
+	assert.Equal(t, defaults.Consumer.Group.Heartbeat, c.Consumer.Group.Heartbeat)
+	assert.Equal(t, defaults.Consumer.MaxProcessingTime, c.Consumer.MaxProcessingTime)
+	require.Len(t, c.Consumer.Group.Rebalance.GroupStrategies, 1)
+	assert.Equal(t, defaults.Consumer.Group.Rebalance.GroupStrategies[0].Name(), c.Consumer.Group.Rebalance.GroupStrategies[0].Name())
+	assert.Empty(t, c.Consumer.Group.InstanceId)
+
+	config := Config{
+		Fetch:                  Fetch{MinBytes: 1024, DefaultBytes: 8 << 20, MaxBytes: 16 << 20},
+		MaxWait:                time.Second,
+		SessionTimeout:         45 * time.Second,
+		HeartbeatInterval:      15 * time.Second,
+		MaxProcessingTime:      time.Second,
+		GroupRebalanceStrategy: "sticky",
+		GroupInstanceID:        "collector-0",
+	}
+	c = sarama.NewConfig()
+	c.Version = sarama.V2_3_0_0
+	configureConsumerTuning(config, c)
+	assert.Equal(t, int32(1024), c.Consumer.Fetch.Min)
+	assert.Equal(t, int32(8<<20), c.Consumer.Fetch.Default)
+	assert.Equal(t, int32(16<<20), c.Consumer.Fetch.Max)
+	assert.Equal(t, time.Second, c.Consumer.MaxWaitTime)
+	assert.Equal(t, 45*time.Second, c.Consumer.Group.Session.Timeout)
+	assert.Equal(t, 15*time.Second, c.Consumer.Group.Heartbeat.Interval)
+	assert.Equal(t, time.Second, c.Consumer.MaxProcessingTime)
+	require.Len(t, c.Consumer.Group.Rebalance.GroupStrategies, 1)
+	assert.Equal(t, sarama.StickyBalanceStrategyName, c.Consumer.Group.Rebalance.GroupStrategies[0].Name())
+	assert.Equal(t, "collector-0", c.Consumer.Group.InstanceId)
+	assert.NoError(t, c.Validate())
+}
+
 type testConsumerGroupClaim struct {
 	messageChan chan *sarama.ConsumerMessage
 }
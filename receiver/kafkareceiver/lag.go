@@ -0,0 +1,123 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/component"
+)
+
+// partitionLag refreshes the lag of a claimed partition, its high watermark minus its committed offset,
+// every interval. The high watermark is the one of the last fetch response of the consumer of the claim,
+// and the committed offset the last one marked in the session, so the refresh opens no connection.
+type partitionLag struct {
+	claim     sarama.ConsumerGroupClaim
+	interval  time.Duration
+	statsTags []tag.Mutator
+	// next is the offset following the last marked message, or the initial offset of the claim.
+	next    atomic.Int64
+	stopped chan struct{}
+	done    chan struct{}
+}
+
+// newPartitionLag returns nil if interval is 0.
+func newPartitionLag(interval time.Duration, id component.ID, claim sarama.ConsumerGroupClaim) *partitionLag {
+	if interval <= 0 {
+		return nil
+	}
+	l := &partitionLag{
+		claim:    claim,
+		interval: interval,
+		statsTags: []tag.Mutator{
+			tag.Upsert(tagInstanceName, id.String()),
+			tag.Upsert(tagTopic, claim.Topic()),
+			tag.Upsert(tagPartition, strconv.Itoa(int(claim.Partition()))),
+		},
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	l.next.Store(claim.InitialOffset())
+	return l
+}
+
+// track returns session with the offsets of the messages it marks recorded as committed.
+func (l *partitionLag) track(session sarama.ConsumerGroupSession) sarama.ConsumerGroupSession {
+	if l == nil {
+		return session
+	}
+	return lagTrackingSession{ConsumerGroupSession: session, lag: l}
+}
+
+// start refreshes the lag until stop is called or ctx is done.
+func (l *partitionLag) start(ctx context.Context) {
+	if l == nil {
+		return
+	}
+	go func() {
+		defer close(l.done)
+		ticker := time.NewTicker(l.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				l.refresh(ctx)
+			case <-l.stopped:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// stop stops the refresh, and waits for it to return.
+func (l *partitionLag) stop() {
+	if l == nil {
+		return
+	}
+	close(l.stopped)
+	<-l.done
+}
+
+// refresh records the lag, unless the claim has neither a committed offset nor a marked message yet, or
+// no fetch response has set its high watermark.
+func (l *partitionLag) refresh(ctx context.Context) {
+	next := l.next.Load()
+	highWatermark := l.claim.HighWaterMarkOffset()
+	if next < 0 || highWatermark <= 0 {
+		return
+	}
+	lag := highWatermark - next
+	if lag < 0 {
+		lag = 0
+	}
+	_ = stats.RecordWithTags(ctx, l.statsTags, statPartitionLag.M(lag))
+}
+
+func (l *partitionLag) marked(offset int64) {
+	for {
+		next := l.next.Load()
+		if offset+1 <= next || l.next.CompareAndSwap(next, offset+1) {
+			return
+		}
+	}
+}
+
+// lagTrackingSession records the offsets of the marked messages of a claim.
+type lagTrackingSession struct {
+	sarama.ConsumerGroupSession
+	lag *partitionLag
+}
+
+func (s lagTrackingSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.ConsumerGroupSession.MarkMessage(msg, metadata)
+	s.lag.marked(msg.Offset)
+}
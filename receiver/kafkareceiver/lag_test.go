@@ -0,0 +1,90 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/component"
+)
+
+// watermarkClaim is a claim of testTopic with the given high watermark.
+type watermarkClaim struct {
+	testConsumerGroupClaim
+	highWatermark int64
+}
+
+func (c *watermarkClaim) HighWaterMarkOffset() int64 {
+	return c.highWatermark
+}
+
+func partitionLagValue(t *testing.T) float64 {
+	rows, err := view.RetrieveData(statPartitionLag.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, []tag.Tag{
+		{Key: tagInstanceName, Value: "kafka"},
+		{Key: tagPartition, Value: strconv.Itoa(testPartition)},
+		{Key: tagTopic, Value: testTopic},
+	}, rows[0].Tags)
+	return rows[0].Data.(*view.LastValueData).Value
+}
+
+func TestPartitionLag(t *testing.T) {
+	views := MetricViews()
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	claim := &watermarkClaim{highWatermark: 100}
+	lag := newPartitionLag(time.Minute, component.NewID("kafka"), claim)
+	session := lag.track(testConsumerGroupSession{ctx: context.Background()})
+
+	// Nothing is marked yet, the initial offset of the claim is the committed one.
+	lag.refresh(context.Background())
+	assert.Equal(t, float64(100-testInitialOffset), partitionLagValue(t))
+
+	session.MarkMessage(&sarama.ConsumerMessage{Offset: 41}, "")
+	lag.refresh(context.Background())
+	assert.Equal(t, float64(58), partitionLagValue(t))
+
+	// The marked offset never goes back.
+	session.MarkMessage(&sarama.ConsumerMessage{Offset: 20}, "")
+	claim.highWatermark = 42
+	lag.refresh(context.Background())
+	assert.Equal(t, float64(0), partitionLagValue(t))
+}
+
+func TestPartitionLag_refresh_loop(t *testing.T) {
+	views := MetricViews()
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	lag := newPartitionLag(time.Millisecond, component.NewID("kafka"), &watermarkClaim{highWatermark: 10})
+	lag.start(context.Background())
+	assert.Eventually(t, func() bool {
+		rows, err := view.RetrieveData(statPartitionLag.Name())
+		return err == nil && len(rows) == 1
+	}, 5*time.Second, time.Millisecond)
+	// stop returns once the refresh loop has.
+	lag.stop()
+	assert.Equal(t, float64(10-testInitialOffset), partitionLagValue(t))
+}
+
+func TestPartitionLag_disabled(t *testing.T) {
+	lag := newPartitionLag(0, component.NewID("kafka"), &testConsumerGroupClaim{})
+	assert.Nil(t, lag)
+
+	session := testConsumerGroupSession{ctx: context.Background()}
+	assert.Equal(t, session, lag.track(session))
+	lag.start(context.Background())
+	lag.stop()
+}
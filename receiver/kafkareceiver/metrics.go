@@ -7,14 +7,35 @@ import (
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
 )
 
 var (
 	tagInstanceName, _ = tag.NewKey("name")
+	tagTopic, _        = tag.NewKey("topic")
+	tagPartition, _    = tag.NewKey("partition")
+	tagEncoding, _     = tag.NewKey("encoding")
 
 	statMessageCount     = stats.Int64("kafka_receiver_messages", "Number of received messages", stats.UnitDimensionless)
+	statMessageBytes     = stats.Int64("kafka_receiver_bytes", "Size of the keys and values of the received messages", stats.UnitBytes)
 	statMessageOffset    = stats.Int64("kafka_receiver_current_offset", "Current message offset", stats.UnitDimensionless)
 	statMessageOffsetLag = stats.Int64("kafka_receiver_offset_lag", "Current offset lag", stats.UnitDimensionless)
+	statPartitionLag     = stats.Int64("kafka_receiver_partition_lag", "High watermark of a claimed partition minus its committed offset", stats.UnitDimensionless)
+	statMessageSkipped   = stats.Int64("kafka_receiver_messages_skipped", "Number of messages skipped because they are older than max_message_age", stats.UnitDimensionless)
+
+	statMessageUnmarshalFailed  = stats.Int64("kafka_receiver_unmarshal_failed", "Number of messages that could not be unmarshaled", stats.UnitDimensionless)
+	statNDJSONLinesSkipped      = stats.Int64("kafka_receiver_ndjson_lines_skipped", "Number of lines of otlp_ndjson messages that could not be unmarshaled", stats.UnitDimensionless)
+	statTextCharactersReplaced  = stats.Int64("kafka_receiver_text_characters_replaced", "Number of invalid byte sequences of text messages replaced by U+FFFD", stats.UnitDimensionless)
+	statConfluentFramed         = stats.Int64("kafka_receiver_confluent_framed_messages", "Number of messages in the Confluent wire format", stats.UnitDimensionless)
+	statConfluentUnframed       = stats.Int64("kafka_receiver_confluent_unframed_messages", "Number of messages without the Confluent wire format in auto mode", stats.UnitDimensionless)
+	statMessageDeadLettered     = stats.Int64("kafka_receiver_messages_dead_lettered", "Number of messages that could not be unmarshaled produced to the dead letter topic", stats.UnitDimensionless)
+	statMessageDeadLetterFailed = stats.Int64("kafka_receiver_messages_dead_letter_failed", "Number of messages that could not be unmarshaled nor produced to the dead letter topic", stats.UnitDimensionless)
+
+	statDeliveryRetries = stats.Int64("kafka_receiver_delivery_retries", "Number of times a message was redelivered to the next consumer", stats.UnitDimensionless)
+	statDeliveryPause   = stats.Int64("kafka_receiver_delivery_pause_duration", "Time the consumption of a partition was paused retrying a message", stats.UnitMilliseconds)
+
+	statPausedPartitions = stats.Int64("kafka_receiver_paused_partitions", "Number of claimed partitions paused by the backpressure", stats.UnitDimensionless)
 
 	statPartitionStart = stats.Int64("kafka_receiver_partition_start", "Number of started partitions", stats.UnitDimensionless)
 	statPartitionClose = stats.Int64("kafka_receiver_partition_close", "Number of finished partitions", stats.UnitDimensionless)
@@ -23,12 +44,22 @@ var (
 // MetricViews return metric views for Kafka receiver.
 func MetricViews() []*view.View {
 	tagKeys := []tag.Key{tagInstanceName}
+	messageTagKeys := []tag.Key{tagInstanceName, tagTopic}
+	partitionTagKeys := []tag.Key{tagInstanceName, tagTopic, tagPartition}
 
 	countMessages := &view.View{
 		Name:        statMessageCount.Name(),
 		Measure:     statMessageCount,
 		Description: statMessageCount.Description(),
-		TagKeys:     tagKeys,
+		TagKeys:     partitionTagKeys,
+		Aggregation: view.Sum(),
+	}
+
+	countMessageBytes := &view.View{
+		Name:        statMessageBytes.Name(),
+		Measure:     statMessageBytes,
+		Description: statMessageBytes.Description(),
+		TagKeys:     partitionTagKeys,
 		Aggregation: view.Sum(),
 	}
 
@@ -36,7 +67,7 @@ func MetricViews() []*view.View {
 		Name:        statMessageOffset.Name(),
 		Measure:     statMessageOffset,
 		Description: statMessageOffset.Description(),
-		TagKeys:     tagKeys,
+		TagKeys:     partitionTagKeys,
 		Aggregation: view.LastValue(),
 	}
 
@@ -44,6 +75,102 @@ func MetricViews() []*view.View {
 		Name:        statMessageOffsetLag.Name(),
 		Measure:     statMessageOffsetLag,
 		Description: statMessageOffsetLag.Description(),
+		TagKeys:     partitionTagKeys,
+		Aggregation: view.LastValue(),
+	}
+
+	lastValuePartitionLag := &view.View{
+		Name:        statPartitionLag.Name(),
+		Measure:     statPartitionLag,
+		Description: statPartitionLag.Description(),
+		TagKeys:     partitionTagKeys,
+		Aggregation: view.LastValue(),
+	}
+
+	countMessagesSkipped := &view.View{
+		Name:        statMessageSkipped.Name(),
+		Measure:     statMessageSkipped,
+		Description: statMessageSkipped.Description(),
+		TagKeys:     partitionTagKeys,
+		Aggregation: view.Sum(),
+	}
+
+	countMessagesUnmarshalFailed := &view.View{
+		Name:        statMessageUnmarshalFailed.Name(),
+		Measure:     statMessageUnmarshalFailed,
+		Description: statMessageUnmarshalFailed.Description(),
+		TagKeys:     []tag.Key{tagInstanceName, tagTopic, tagPartition, tagEncoding},
+		Aggregation: view.Sum(),
+	}
+
+	countNDJSONLinesSkipped := &view.View{
+		Name:        statNDJSONLinesSkipped.Name(),
+		Measure:     statNDJSONLinesSkipped,
+		Description: statNDJSONLinesSkipped.Description(),
+		TagKeys:     []tag.Key{tagEncoding},
+		Aggregation: view.Sum(),
+	}
+
+	countTextCharactersReplaced := &view.View{
+		Name:        statTextCharactersReplaced.Name(),
+		Measure:     statTextCharactersReplaced,
+		Description: statTextCharactersReplaced.Description(),
+		TagKeys:     []tag.Key{tagEncoding},
+		Aggregation: view.Sum(),
+	}
+
+	countConfluentFramed := &view.View{
+		Name:        statConfluentFramed.Name(),
+		Measure:     statConfluentFramed,
+		Description: statConfluentFramed.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+
+	countConfluentUnframed := &view.View{
+		Name:        statConfluentUnframed.Name(),
+		Measure:     statConfluentUnframed,
+		Description: statConfluentUnframed.Description(),
+		TagKeys:     tagKeys,
+		Aggregation: view.Sum(),
+	}
+
+	countMessagesDeadLettered := &view.View{
+		Name:        statMessageDeadLettered.Name(),
+		Measure:     statMessageDeadLettered,
+		Description: statMessageDeadLettered.Description(),
+		TagKeys:     messageTagKeys,
+		Aggregation: view.Sum(),
+	}
+
+	countMessagesDeadLetterFailed := &view.View{
+		Name:        statMessageDeadLetterFailed.Name(),
+		Measure:     statMessageDeadLetterFailed,
+		Description: statMessageDeadLetterFailed.Description(),
+		TagKeys:     messageTagKeys,
+		Aggregation: view.Sum(),
+	}
+
+	countDeliveryRetries := &view.View{
+		Name:        statDeliveryRetries.Name(),
+		Measure:     statDeliveryRetries,
+		Description: statDeliveryRetries.Description(),
+		TagKeys:     partitionTagKeys,
+		Aggregation: view.Sum(),
+	}
+
+	sumDeliveryPause := &view.View{
+		Name:        statDeliveryPause.Name(),
+		Measure:     statDeliveryPause,
+		Description: statDeliveryPause.Description(),
+		TagKeys:     partitionTagKeys,
+		Aggregation: view.Sum(),
+	}
+
+	lastValuePausedPartitions := &view.View{
+		Name:        statPausedPartitions.Name(),
+		Measure:     statPausedPartitions,
+		Description: statPausedPartitions.Description(),
 		TagKeys:     tagKeys,
 		Aggregation: view.LastValue(),
 	}
@@ -64,11 +191,26 @@ func MetricViews() []*view.View {
 		Aggregation: view.Sum(),
 	}
 
-	return []*view.View{
+	views := []*view.View{
 		countMessages,
+		countMessageBytes,
 		lastValueOffset,
 		lastValueOffsetLag,
+		lastValuePartitionLag,
+		countMessagesSkipped,
+		countMessagesUnmarshalFailed,
+		countNDJSONLinesSkipped,
+		countTextCharactersReplaced,
+		countConfluentFramed,
+		countConfluentUnframed,
+		countMessagesDeadLettered,
+		countMessagesDeadLetterFailed,
+		countDeliveryRetries,
+		sumDeliveryPause,
+		lastValuePausedPartitions,
 		countPartitionStart,
 		countPartitionClose,
 	}
+	// The authentication is configured by the exporter package, so are its metrics.
+	return append(views, kafkaexporter.MetricViews()...)
 }
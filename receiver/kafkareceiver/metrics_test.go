@@ -13,11 +13,27 @@ func TestMetrics(t *testing.T) {
 	metricViews := MetricViews()
 	viewNames := []string{
 		"kafka_receiver_messages",
+		"kafka_receiver_bytes",
 		"kafka_receiver_current_offset",
 		"kafka_receiver_offset_lag",
+		"kafka_receiver_partition_lag",
+		"kafka_receiver_messages_skipped",
+		"kafka_receiver_unmarshal_failed",
+		"kafka_receiver_ndjson_lines_skipped",
+		"kafka_receiver_text_characters_replaced",
+		"kafka_receiver_confluent_framed_messages",
+		"kafka_receiver_confluent_unframed_messages",
+		"kafka_receiver_messages_dead_lettered",
+		"kafka_receiver_messages_dead_letter_failed",
+		"kafka_receiver_delivery_retries",
+		"kafka_receiver_delivery_pause_duration",
+		"kafka_receiver_paused_partitions",
 		"kafka_receiver_partition_start",
 		"kafka_receiver_partition_close",
+		"kafka_auth_token_refresh_success",
+		"kafka_auth_token_refresh_failure",
 	}
+	assert.Len(t, metricViews, len(viewNames))
 	for i, viewName := range viewNames {
 		assert.Equal(t, viewName, metricViews[i].Name)
 	}
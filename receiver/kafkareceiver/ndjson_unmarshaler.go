@@ -0,0 +1,115 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const ndjsonEncoding = "otlp_ndjson"
+
+// unmarshalNDJSON calls unmarshal for every non-blank line of buf. The lines that cannot be unmarshaled
+// are skipped and counted, buf fails as a whole only if none of its lines can be unmarshaled.
+func unmarshalNDJSON(buf []byte, encoding string, unmarshal func(line []byte) error) error {
+	var lines, skipped int
+	var lastErr error
+	for _, line := range bytes.Split(buf, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		lines++
+		if err := unmarshal(line); err != nil {
+			skipped++
+			lastErr = err
+		}
+	}
+	if lines == 0 {
+		return fmt.Errorf("no %s document in the message", encoding)
+	}
+	if skipped == lines {
+		return lastErr
+	}
+	if skipped > 0 {
+		_ = stats.RecordWithTags(
+			context.Background(),
+			[]tag.Mutator{tag.Upsert(tagEncoding, encoding)},
+			statNDJSONLinesSkipped.M(int64(skipped)))
+	}
+	return nil
+}
+
+// ndjsonTracesUnmarshaler unmarshals newline-delimited otlp_json documents into a single batch.
+type ndjsonTracesUnmarshaler struct {
+	unmarshaler ptrace.JSONUnmarshaler
+}
+
+func (u ndjsonTracesUnmarshaler) Unmarshal(buf []byte) (ptrace.Traces, error) {
+	td := ptrace.NewTraces()
+	err := unmarshalNDJSON(buf, u.Encoding(), func(line []byte) error {
+		lineTraces, err := u.unmarshaler.UnmarshalTraces(line)
+		if err != nil {
+			return err
+		}
+		lineTraces.ResourceSpans().MoveAndAppendTo(td.ResourceSpans())
+		return nil
+	})
+	return td, err
+}
+
+func (u ndjsonTracesUnmarshaler) Encoding() string {
+	return ndjsonEncoding
+}
+
+// ndjsonMetricsUnmarshaler unmarshals newline-delimited otlp_json documents into a single batch.
+type ndjsonMetricsUnmarshaler struct {
+	unmarshaler pmetric.JSONUnmarshaler
+}
+
+func (u ndjsonMetricsUnmarshaler) Unmarshal(buf []byte) (pmetric.Metrics, error) {
+	md := pmetric.NewMetrics()
+	err := unmarshalNDJSON(buf, u.Encoding(), func(line []byte) error {
+		lineMetrics, err := u.unmarshaler.UnmarshalMetrics(line)
+		if err != nil {
+			return err
+		}
+		lineMetrics.ResourceMetrics().MoveAndAppendTo(md.ResourceMetrics())
+		return nil
+	})
+	return md, err
+}
+
+func (u ndjsonMetricsUnmarshaler) Encoding() string {
+	return ndjsonEncoding
+}
+
+// ndjsonLogsUnmarshaler unmarshals newline-delimited otlp_json documents into a single batch.
+type ndjsonLogsUnmarshaler struct {
+	unmarshaler plog.JSONUnmarshaler
+}
+
+func (u ndjsonLogsUnmarshaler) Unmarshal(buf []byte) (plog.Logs, error) {
+	ld := plog.NewLogs()
+	err := unmarshalNDJSON(buf, u.Encoding(), func(line []byte) error {
+		lineLogs, err := u.unmarshaler.UnmarshalLogs(line)
+		if err != nil {
+			return err
+		}
+		lineLogs.ResourceLogs().MoveAndAppendTo(ld.ResourceLogs())
+		return nil
+	})
+	return ld, err
+}
+
+func (u ndjsonLogsUnmarshaler) Encoding() string {
+	return ndjsonEncoding
+}
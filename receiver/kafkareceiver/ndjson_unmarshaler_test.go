@@ -0,0 +1,121 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+)
+
+func ndjsonLogs(t *testing.T, bodies ...string) []byte {
+	var buf bytes.Buffer
+	for _, body := range bodies {
+		ld := plog.NewLogs()
+		rl := ld.ResourceLogs().AppendEmpty()
+		rl.Resource().Attributes().PutStr("service.name", body)
+		rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr(body)
+		bts, err := (&plog.JSONMarshaler{}).MarshalLogs(ld)
+		require.NoError(t, err)
+		buf.Write(bts)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func TestNDJSONUnmarshalers(t *testing.T) {
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	traceLine, err := (&ptrace.JSONMarshaler{}).MarshalTraces(td)
+	require.NoError(t, err)
+	gotTraces, err := ndjsonTracesUnmarshaler{}.Unmarshal(bytes.Join([][]byte{traceLine, traceLine, nil}, []byte("\n")))
+	require.NoError(t, err)
+	assert.Equal(t, 2, gotTraces.ResourceSpans().Len())
+	assert.Equal(t, 2, gotTraces.SpanCount())
+
+	md := pmetric.NewMetrics()
+	md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(1)
+	metricLine, err := (&pmetric.JSONMarshaler{}).MarshalMetrics(md)
+	require.NoError(t, err)
+	gotMetrics, err := ndjsonMetricsUnmarshaler{}.Unmarshal(bytes.Join([][]byte{metricLine, metricLine, metricLine}, []byte("\r\n")))
+	require.NoError(t, err)
+	assert.Equal(t, 3, gotMetrics.DataPointCount())
+
+	gotLogs, err := ndjsonLogsUnmarshaler{}.Unmarshal(ndjsonLogs(t, "a", "b"))
+	require.NoError(t, err)
+	require.Equal(t, 2, gotLogs.ResourceLogs().Len())
+	assert.Equal(t, "a", gotLogs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+	assert.Equal(t, "b", gotLogs.ResourceLogs().At(1).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+}
+
+func TestNDJSONUnmarshalers_badLines(t *testing.T) {
+	views := MetricViews()
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	buf := append(ndjsonLogs(t, "a"), []byte("{\"resourceLogs\":[\n\n")...)
+	buf = append(buf, ndjsonLogs(t, "b")...)
+	ld, err := ndjsonLogsUnmarshaler{}.Unmarshal(buf)
+	require.NoError(t, err)
+	assert.Equal(t, 2, ld.LogRecordCount())
+
+	rows, err := view.RetrieveData(statNDJSONLinesSkipped.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, float64(1), rows[0].Data.(*view.SumData).Value)
+
+	_, err = ndjsonLogsUnmarshaler{}.Unmarshal([]byte("{\"resourceLogs\":[\nnot json\n"))
+	assert.Error(t, err)
+	_, err = ndjsonLogsUnmarshaler{}.Unmarshal([]byte("\n\n"))
+	assert.EqualError(t, err, "no otlp_ndjson document in the message")
+}
+
+func TestLogsConsumerGroupHandler_ndjson_message_metadata(t *testing.T) {
+	obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+	require.NoError(t, err)
+	sink := &consumertest.LogsSink{}
+	c := logsConsumerGroupHandler{
+		unmarshaler:     defaultLogsUnmarshalers()["otlp_ndjson"],
+		logger:          zap.NewNop(),
+		ready:           make(chan bool),
+		nextConsumer:    sink,
+		obsrecv:         obsrecv,
+		messageMetadata: MessageMetadata{Enable: true},
+	}
+
+	groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage, 1)}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{
+		Topic:     "otlp_logs",
+		Partition: 3,
+		Offset:    42,
+		Value:     ndjsonLogs(t, "a", "b", "c"),
+	}
+	close(groupClaim.messageChan)
+	require.NoError(t, c.ConsumeClaim(testConsumerGroupSession{ctx: context.Background()}, groupClaim))
+
+	require.Len(t, sink.AllLogs(), 1)
+	rls := sink.AllLogs()[0].ResourceLogs()
+	require.Equal(t, 3, rls.Len())
+	for i := 0; i < rls.Len(); i++ {
+		attrs := rls.At(i).Resource().Attributes()
+		partition, ok := attrs.Get("messaging.kafka.source.partition")
+		require.True(t, ok)
+		assert.Equal(t, int64(3), partition.Int())
+		offset, ok := attrs.Get("messaging.kafka.message.offset")
+		require.True(t, ok)
+		assert.Equal(t, int64(42), offset.Int())
+	}
+}
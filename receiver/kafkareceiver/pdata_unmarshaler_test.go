@@ -7,9 +7,12 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/testdata"
 )
 
 func TestNewPdataTracesUnmarshaler(t *testing.T) {
@@ -26,3 +29,37 @@ func TestNewPdataLogsUnmarshaler(t *testing.T) {
 	um := newPdataLogsUnmarshaler(&plog.ProtoUnmarshaler{}, "test")
 	assert.Equal(t, "test", um.Encoding())
 }
+
+// The exporter's otlp_json encoding is the output of the pdata JSON marshalers.
+func TestOTLPJSONUnmarshalers_roundTrip(t *testing.T) {
+	traces := testdata.GenerateTracesManySpansSameResource(5)
+	bts, err := (&ptrace.JSONMarshaler{}).MarshalTraces(traces)
+	require.NoError(t, err)
+	gotTraces, err := defaultTracesUnmarshalers()["otlp_json"].Unmarshal(bts)
+	require.NoError(t, err)
+	assert.Equal(t, traces, gotTraces)
+
+	metrics := testdata.GenerateMetricsManyMetricsSameResource(5)
+	bts, err = (&pmetric.JSONMarshaler{}).MarshalMetrics(metrics)
+	require.NoError(t, err)
+	gotMetrics, err := defaultMetricsUnmarshalers()["otlp_json"].Unmarshal(bts)
+	require.NoError(t, err)
+	assert.Equal(t, metrics, gotMetrics)
+
+	logs := testdata.GenerateLogsManyLogRecordsSameResource(5)
+	bts, err = (&plog.JSONMarshaler{}).MarshalLogs(logs)
+	require.NoError(t, err)
+	gotLogs, err := defaultLogsUnmarshalers()["otlp_json"].Unmarshal(bts)
+	require.NoError(t, err)
+	assert.Equal(t, logs, gotLogs)
+}
+
+func TestOTLPJSONUnmarshalers_malformed(t *testing.T) {
+	malformed := []byte(`{"resourceSpans":[`)
+	_, err := defaultTracesUnmarshalers()["otlp_json"].Unmarshal(malformed)
+	assert.Error(t, err)
+	_, err = defaultMetricsUnmarshalers()["otlp_json"].Unmarshal(malformed)
+	assert.Error(t, err)
+	_, err = defaultLogsUnmarshalers()["otlp_json"].Unmarshal(malformed)
+	assert.Error(t, err)
+}
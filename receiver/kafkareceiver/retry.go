@@ -0,0 +1,109 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/cenkalti/backoff/v4"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.uber.org/zap"
+)
+
+const (
+	onErrorDrop  = "drop"
+	onErrorRetry = "retry"
+)
+
+// errDeliveryInterrupted is returned when the session ends while a message is being retried.
+// The message is left unmarked so that it is consumed again by the next owner of the partition.
+var errDeliveryInterrupted = errors.New("delivery of the message interrupted by the end of the session")
+
+// deliveryRetrier redelivers a message to the next consumer while it fails with a non-permanent
+// error, blocking the consumption of the partition in the meantime. The messages waiting for
+// delivery are kept by Kafka, their offsets are not marked until they are delivered.
+type deliveryRetrier struct {
+	backOff   ErrorBackOff
+	logger    *zap.Logger
+	statsTags []tag.Mutator
+	topic     string
+	partition int32
+}
+
+// newDeliveryRetrier returns nil unless onError is `retry`.
+func newDeliveryRetrier(onError string, backOff ErrorBackOff, id component.ID, claim sarama.ConsumerGroupClaim, logger *zap.Logger) *deliveryRetrier {
+	if onError != onErrorRetry {
+		return nil
+	}
+	return &deliveryRetrier{
+		backOff: backOff,
+		logger:  logger,
+		statsTags: []tag.Mutator{
+			tag.Upsert(tagInstanceName, id.String()),
+			tag.Upsert(tagTopic, claim.Topic()),
+			tag.Upsert(tagPartition, strconv.Itoa(int(claim.Partition()))),
+		},
+		topic:     claim.Topic(),
+		partition: claim.Partition(),
+	}
+}
+
+// deliver calls consume until it succeeds, fails with a permanent error, or the max elapsed time
+// of the backoff is reached. It returns errDeliveryInterrupted if ctx is done while waiting.
+// A nil retrier calls consume only once.
+func (r *deliveryRetrier) deliver(ctx context.Context, message *sarama.ConsumerMessage, consume func() error) error {
+	err := consume()
+	if r == nil || err == nil || consumererror.IsPermanent(err) {
+		return err
+	}
+
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = r.backOff.InitialInterval
+	b.MaxInterval = r.backOff.MaxInterval
+	b.MaxElapsedTime = r.backOff.MaxElapsedTime
+	b.Reset()
+
+	start := time.Now()
+	defer func() {
+		_ = stats.RecordWithTags(ctx, r.statsTags, statDeliveryPause.M(time.Since(start).Milliseconds()))
+	}()
+	for {
+		wait := b.NextBackOff()
+		if wait == backoff.Stop {
+			r.logger.Error("Giving up delivering the message after retrying",
+				zap.String("topic", r.topic),
+				zap.Int32("partition", r.partition),
+				zap.Int64("offset", message.Offset),
+				zap.Duration("elapsed", time.Since(start)),
+				zap.Error(err))
+			return err
+		}
+		r.logger.Warn("Failed to deliver the message, retrying",
+			zap.String("topic", r.topic),
+			zap.Int32("partition", r.partition),
+			zap.Int64("offset", message.Offset),
+			zap.Duration("interval", wait),
+			zap.Error(err))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errDeliveryInterrupted
+		case <-timer.C:
+		}
+
+		_ = stats.RecordWithTags(ctx, r.statsTags, statDeliveryRetries.M(1))
+		if err = consume(); err == nil || consumererror.IsPermanent(err) {
+			return err
+		}
+	}
+}
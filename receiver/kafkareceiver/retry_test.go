@@ -0,0 +1,149 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+)
+
+// markingSession records the offsets of the marked messages.
+type markingSession struct {
+	testConsumerGroupSession
+	mu     sync.Mutex
+	marked []int64
+}
+
+func (s *markingSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked = append(s.marked, msg.Offset)
+}
+
+func (s *markingSession) markedOffsets() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.marked
+}
+
+// failingLogsConsumer fails the first failures calls with err.
+type failingLogsConsumer struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *failingLogsConsumer) consumeLogs(context.Context, plog.Logs) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.failures < 0 || f.calls <= f.failures {
+		return f.err
+	}
+	return nil
+}
+
+func (f *failingLogsConsumer) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func newRetryingLogsHandler(t *testing.T, failing *failingLogsConsumer, backOff ErrorBackOff) *logsConsumerGroupHandler {
+	obsrecv, err := obsreport.NewReceiver(obsreport.ReceiverSettings{ReceiverCreateSettings: receivertest.NewNopCreateSettings()})
+	require.NoError(t, err)
+	next, err := consumer.NewLogs(failing.consumeLogs)
+	require.NoError(t, err)
+	return &logsConsumerGroupHandler{
+		unmarshaler:  newRawLogsUnmarshaler(),
+		logger:       zap.NewNop(),
+		ready:        make(chan bool),
+		nextConsumer: next,
+		obsrecv:      obsrecv,
+		onError:      onErrorRetry,
+		errorBackOff: backOff,
+	}
+}
+
+func TestLogsConsumerGroupHandler_retry(t *testing.T) {
+	failing := &failingLogsConsumer{failures: 2, err: errors.New("downstream unavailable")}
+	c := newRetryingLogsHandler(t, failing, ErrorBackOff{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond})
+
+	session := &markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: context.Background()}}
+	groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage)}
+	done := make(chan error)
+	go func() {
+		done <- c.ConsumeClaim(session, groupClaim)
+	}()
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Offset: 1, Value: []byte("log")}
+	close(groupClaim.messageChan)
+	require.NoError(t, <-done)
+
+	assert.Equal(t, 3, failing.callCount())
+	assert.Equal(t, []int64{1}, session.markedOffsets())
+}
+
+func TestLogsConsumerGroupHandler_retry_permanent_error(t *testing.T) {
+	failing := &failingLogsConsumer{failures: -1, err: consumererror.NewPermanent(errors.New("invalid data"))}
+	c := newRetryingLogsHandler(t, failing, ErrorBackOff{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond})
+
+	session := &markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: context.Background()}}
+	groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage, 1)}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Offset: 1, Value: []byte("log")}
+	assert.Error(t, c.ConsumeClaim(session, groupClaim))
+
+	assert.Equal(t, 1, failing.callCount())
+	assert.Equal(t, []int64{1}, session.markedOffsets())
+}
+
+func TestLogsConsumerGroupHandler_retry_max_elapsed_time(t *testing.T) {
+	failing := &failingLogsConsumer{failures: -1, err: errors.New("downstream unavailable")}
+	c := newRetryingLogsHandler(t, failing, ErrorBackOff{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+		MaxElapsedTime:  20 * time.Millisecond,
+	})
+
+	session := &markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: context.Background()}}
+	groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage, 1)}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Offset: 1, Value: []byte("log")}
+	assert.EqualError(t, c.ConsumeClaim(session, groupClaim), "downstream unavailable")
+
+	assert.Greater(t, failing.callCount(), 1)
+	assert.Equal(t, []int64{1}, session.markedOffsets())
+}
+
+func TestLogsConsumerGroupHandler_retry_interrupted_by_rebalance(t *testing.T) {
+	failing := &failingLogsConsumer{failures: -1, err: errors.New("downstream unavailable")}
+	c := newRetryingLogsHandler(t, failing, ErrorBackOff{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &markingSession{testConsumerGroupSession: testConsumerGroupSession{ctx: ctx}}
+	groupClaim := &testConsumerGroupClaim{messageChan: make(chan *sarama.ConsumerMessage, 1)}
+	groupClaim.messageChan <- &sarama.ConsumerMessage{Offset: 1, Value: []byte("log")}
+	done := make(chan error)
+	go func() {
+		done <- c.ConsumeClaim(session, groupClaim)
+	}()
+	assert.Eventually(t, func() bool { return failing.callCount() > 1 }, 10*time.Second, time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	// The message is left unmarked so that it is consumed again after the rebalance.
+	assert.Empty(t, session.markedOffsets())
+}
@@ -0,0 +1,120 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
+
+import (
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// timestampSeeker moves the offsets of the claimed partitions to the first message produced
+// at or after timestamp. Every partition is seeked at most once during the lifetime of the
+// receiver, and only if the consumer group has not committed an offset for it yet, unless
+// force is set.
+type timestampSeeker struct {
+	brokers   []string
+	config    *sarama.Config
+	groupID   string
+	timestamp time.Time
+	force     bool
+	logger    *zap.Logger
+
+	mu     sync.Mutex
+	seeked map[string]map[int32]bool
+}
+
+// newTimestampSeeker returns nil if the receiver does not start consuming from a timestamp.
+func newTimestampSeeker(config Config, c *sarama.Config, logger *zap.Logger) *timestampSeeker {
+	if config.InitialOffset != offsetTimestamp {
+		return nil
+	}
+	return &timestampSeeker{
+		brokers:   config.Brokers,
+		config:    c,
+		groupID:   consumerGroupID(config),
+		timestamp: config.InitialOffsetTimestamp,
+		force:     config.ForceSeek,
+		logger:    logger,
+		seeked:    make(map[string]map[int32]bool),
+	}
+}
+
+// seek has to be called from the Setup of the consumer group handler, before the claims of
+// the session start consuming.
+func (s *timestampSeeker) seek(session sarama.ConsumerGroupSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	claims := make(map[string][]int32)
+	for topic, partitions := range session.Claims() {
+		for _, partition := range partitions {
+			if !s.seeked[topic][partition] {
+				claims[topic] = append(claims[topic], partition)
+			}
+		}
+	}
+	if len(claims) == 0 {
+		return nil
+	}
+
+	client, err := sarama.NewClient(s.brokers, s.config)
+	if err != nil {
+		return err
+	}
+	// Closing the admin closes the client as well.
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		_ = client.Close()
+		return err
+	}
+	defer admin.Close()
+
+	var committed *sarama.OffsetFetchResponse
+	if !s.force {
+		if committed, err = admin.ListConsumerGroupOffsets(s.groupID, claims); err != nil {
+			return err
+		}
+	}
+
+	for topic, partitions := range claims {
+		for _, partition := range partitions {
+			if committed != nil {
+				if block := committed.GetBlock(topic, partition); block != nil && block.Offset >= 0 {
+					s.logger.Info("Resuming from the committed offset",
+						zap.String("topic", topic), zap.Int32("partition", partition), zap.Int64("offset", block.Offset))
+					s.markSeeked(topic, partition)
+					continue
+				}
+			}
+			offset, err := client.GetOffset(topic, partition, s.timestamp.UnixMilli())
+			if err != nil {
+				return err
+			}
+			// No message was produced at or after the timestamp yet.
+			if offset < 0 {
+				if offset, err = client.GetOffset(topic, partition, sarama.OffsetNewest); err != nil {
+					return err
+				}
+			}
+			// ResetOffset only moves the offset backwards and MarkOffset only forwards.
+			session.ResetOffset(topic, partition, offset, "")
+			session.MarkOffset(topic, partition, offset, "")
+			s.logger.Info("Seeking to initial_offset_timestamp",
+				zap.String("topic", topic), zap.Int32("partition", partition),
+				zap.Time("timestamp", s.timestamp), zap.Int64("offset", offset))
+			s.markSeeked(topic, partition)
+		}
+	}
+	return nil
+}
+
+func (s *timestampSeeker) markSeeked(topic string, partition int32) {
+	if s.seeked[topic] == nil {
+		s.seeked[topic] = make(map[int32]bool)
+	}
+	s.seeked[topic][partition] = true
+}
@@ -0,0 +1,106 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTimestampSeeker(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()).
+			SetLeader("logs", 0, broker.BrokerID()).
+			SetLeader("logs", 1, broker.BrokerID()).
+			SetLeader("logs", 2, broker.BrokerID()),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, defaultGroupID, broker),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset(defaultGroupID, "logs", 0, -1, "", sarama.ErrNoError).
+			SetOffset(defaultGroupID, "logs", 1, 7, "", sarama.ErrNoError).
+			SetOffset(defaultGroupID, "logs", 2, -1, "", sarama.ErrNoError),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("logs", 0, timestamp.UnixMilli(), 42).
+			SetOffset("logs", 1, timestamp.UnixMilli(), 3).
+			SetOffset("logs", 2, timestamp.UnixMilli(), -1).
+			SetOffset("logs", 2, sarama.OffsetNewest, 100),
+	})
+
+	tests := []struct {
+		name     string
+		force    bool
+		expected map[int32]int64
+	}{
+		{
+			name:     "committed offsets take precedence",
+			expected: map[int32]int64{0: 42, 2: 100},
+		},
+		{
+			name:     "force seek",
+			force:    true,
+			expected: map[int32]int64{0: 42, 1: 3, 2: 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{
+				Brokers:                []string{broker.Addr()},
+				InitialOffset:          offsetTimestamp,
+				InitialOffsetTimestamp: timestamp,
+				ForceSeek:              tt.force,
+			}
+			seeker := newTimestampSeeker(config, sarama.NewConfig(), zap.NewNop())
+			require.NotNil(t, seeker)
+
+			session := &seekTestSession{
+				claims:  map[string][]int32{"logs": {0, 1, 2}},
+				offsets: map[int32]int64{},
+			}
+			require.NoError(t, seeker.seek(session))
+			assert.Equal(t, tt.expected, session.offsets)
+
+			// Partitions are only seeked once, later rebalances resume from the marked offsets.
+			session.offsets = map[int32]int64{}
+			require.NoError(t, seeker.seek(session))
+			assert.Empty(t, session.offsets)
+		})
+	}
+}
+
+func TestNewTimestampSeeker_disabled(t *testing.T) {
+	assert.Nil(t, newTimestampSeeker(Config{InitialOffset: offsetEarliest}, sarama.NewConfig(), zap.NewNop()))
+}
+
+type seekTestSession struct {
+	testConsumerGroupSession
+	claims  map[string][]int32
+	offsets map[int32]int64
+}
+
+func (s *seekTestSession) Claims() map[string][]int32 {
+	return s.claims
+}
+
+func (s *seekTestSession) ResetOffset(_ string, partition int32, offset int64, _ string) {
+	s.offsets[partition] = offset
+}
+
+func (s *seekTestSession) MarkOffset(_ string, partition int32, offset int64, _ string) {
+	s.offsets[partition] = offset
+}
+
+func (s *seekTestSession) Context() context.Context {
+	return context.Background()
+}
@@ -0,0 +1,194 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// topicSubscription tracks the topics the consumer group subscribes to. If a regex is configured,
+// the topics of the cluster are periodically matched against it, and the current session is ended
+// when the matched topics change so that the next session subscribes to them.
+type topicSubscription struct {
+	static          []string
+	regex           *regexp.Regexp
+	refreshInterval time.Duration
+	newClient       func() (sarama.Client, error)
+	logger          *zap.Logger
+
+	mu            sync.Mutex
+	topics        []string
+	changed       chan struct{}
+	cancelSession context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+func newTopicSubscription(config Config, c *sarama.Config, logger *zap.Logger) (*topicSubscription, error) {
+	s := &topicSubscription{
+		static:          configuredTopics(config),
+		refreshInterval: config.TopicRefreshInterval,
+		logger:          logger,
+		changed:         make(chan struct{}),
+	}
+	s.topics = s.static
+	if config.TopicRegex == "" {
+		return s, nil
+	}
+	regex, err := regexp.Compile(config.TopicRegex)
+	if err != nil {
+		return nil, err
+	}
+	s.regex = regex
+	s.newClient = func() (sarama.Client, error) {
+		return sarama.NewClient(config.Brokers, c)
+	}
+	return s, nil
+}
+
+// configuredTopics returns the topics listed in the configuration. The single topic is only
+// used if neither a list of topics nor a regex is configured.
+func configuredTopics(config Config) []string {
+	if len(config.Topics) > 0 {
+		return config.Topics
+	}
+	if config.TopicRegex != "" {
+		return nil
+	}
+	return []string{config.Topic}
+}
+
+// start matches the regex against the topics of the cluster, then keeps refreshing
+// the matched topics until ctx is done.
+func (s *topicSubscription) start(ctx context.Context) error {
+	if s.regex == nil {
+		return nil
+	}
+	client, err := s.newClient()
+	if err != nil {
+		return err
+	}
+	if err = s.refresh(client); err != nil {
+		_ = client.Close()
+		return err
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer client.Close()
+		ticker := time.NewTicker(s.refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.refresh(client); err != nil {
+					s.logger.Warn("Failed to refresh the topics matching topic_regex", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// shutdown waits for the refresh of the topics to stop. The context passed to start has to be cancelled before.
+func (s *topicSubscription) shutdown() {
+	s.wg.Wait()
+}
+
+func (s *topicSubscription) refresh(client sarama.Client) error {
+	if err := client.RefreshMetadata(); err != nil {
+		return err
+	}
+	clusterTopics, err := client.Topics()
+	if err != nil {
+		return err
+	}
+
+	matched := make(map[string]bool, len(s.static))
+	for _, topic := range s.static {
+		matched[topic] = true
+	}
+	for _, topic := range clusterTopics {
+		if s.regex.MatchString(topic) {
+			matched[topic] = true
+		}
+	}
+	topics := make([]string, 0, len(matched))
+	for topic := range matched {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if equalTopics(s.topics, topics) {
+		return nil
+	}
+	s.logger.Info("Subscribed topics changed", zap.Strings("topics", topics))
+	s.topics = topics
+	close(s.changed)
+	s.changed = make(chan struct{})
+	if s.cancelSession != nil {
+		s.cancelSession()
+	}
+	return nil
+}
+
+// next returns the topics to subscribe to and the context of the next session, which is
+// cancelled when the topics change. It blocks while there are no topics to subscribe to,
+// and returns false once ctx is done.
+func (s *topicSubscription) next(ctx context.Context) (context.Context, []string, bool) {
+	for {
+		if ctx.Err() != nil {
+			return ctx, nil, false
+		}
+		s.mu.Lock()
+		if len(s.topics) > 0 {
+			// The previous session is over, release its context.
+			if s.cancelSession != nil {
+				s.cancelSession()
+			}
+			sessionCtx, cancel := context.WithCancel(ctx)
+			s.cancelSession = cancel
+			topics := s.topics
+			s.mu.Unlock()
+			return sessionCtx, topics, true
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx, nil, false
+		case <-changed:
+		}
+	}
+}
+
+// current returns the topics currently subscribed to.
+func (s *topicSubscription) current() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.topics
+}
+
+func equalTopics(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
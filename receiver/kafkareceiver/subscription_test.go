@@ -0,0 +1,75 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package kafkareceiver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestConfiguredTopics(t *testing.T) {
+	assert.Equal(t, []string{"spans"}, configuredTopics(Config{Topic: "spans"}))
+	assert.Equal(t, []string{"a", "b"}, configuredTopics(Config{Topic: "spans", Topics: []string{"a", "b"}}))
+	assert.Nil(t, configuredTopics(Config{Topic: "spans", TopicRegex: "^otlp_spans_.*"}))
+	assert.Equal(t, []string{"a"}, configuredTopics(Config{Topic: "spans", Topics: []string{"a"}, TopicRegex: "^otlp_spans_.*"}))
+}
+
+func TestTopicSubscription_regex(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	metadataResponse := func(topics ...string) map[string]sarama.MockResponse {
+		response := sarama.NewMockMetadataResponse(t).SetBroker(broker.Addr(), broker.BrokerID())
+		for _, topic := range topics {
+			response = response.SetLeader(topic, 0, broker.BrokerID())
+		}
+		return map[string]sarama.MockResponse{"MetadataRequest": response}
+	}
+	broker.SetHandlerByMap(metadataResponse("otlp_spans_a", "otlp_spans_b", "otlp_logs"))
+
+	config := Config{
+		Brokers:              []string{broker.Addr()},
+		Topics:               []string{"spans"},
+		TopicRegex:           "^otlp_spans_.*",
+		TopicRefreshInterval: 10 * time.Millisecond,
+	}
+	s, err := newTopicSubscription(config, sarama.NewConfig(), zap.NewNop())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, s.start(ctx))
+	sessionCtx, topics, ok := s.next(ctx)
+	require.True(t, ok)
+	assert.Equal(t, []string{"otlp_spans_a", "otlp_spans_b", "spans"}, topics)
+
+	// A new matching topic ends the session, the next one subscribes to it.
+	broker.SetHandlerByMap(metadataResponse("otlp_spans_a", "otlp_spans_b", "otlp_spans_c", "otlp_logs"))
+	select {
+	case <-sessionCtx.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("session was not ended when the topics changed")
+	}
+	_, topics, ok = s.next(ctx)
+	require.True(t, ok)
+	assert.Equal(t, []string{"otlp_spans_a", "otlp_spans_b", "otlp_spans_c", "spans"}, topics)
+
+	cancel()
+	s.shutdown()
+	_, _, ok = s.next(ctx)
+	assert.False(t, ok)
+}
+
+func TestTopicSubscription_next_waits_for_topics(t *testing.T) {
+	s := &topicSubscription{changed: make(chan struct{}), logger: zap.NewNop()}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, topics, ok := s.next(ctx)
+	assert.False(t, ok)
+	assert.Empty(t, topics)
+}
@@ -3,17 +3,27 @@
 
 package kafkareceiver // import "github.com/open-telemetry/opentelemetry-collector-contrib/receiver/kafkareceiver"
 import (
+	"bytes"
+	"context"
 	"errors"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/IBM/sarama"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
+	conventions "go.opentelemetry.io/collector/semconv/v1.18.0"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
 )
 
 type textLogsUnmarshaler struct {
 	enc *textutils.Encoding
+	// encoding is the name of the encoding including the charset, e.g. text_iso-8859-1.
+	encoding string
 }
 
 func newTextLogsUnmarshaler() LogsUnmarshalerWithEnc {
@@ -25,14 +35,22 @@ func (r *textLogsUnmarshaler) Unmarshal(buf []byte) (plog.Logs, error) {
 		return plog.Logs{}, errors.New("encoding not set")
 	}
 	p := plog.NewLogs()
-	decoded, err := r.enc.Decode(buf)
-	if err != nil {
-		return p, err
+	// The invalid byte sequences are replaced by U+FFFD rather than failing the message. The decoders
+	// replace them already, the bytes they cannot transform at all are kept as invalid UTF-8 and replaced.
+	body := strings.ToValidUTF8(string(buf), string(utf8.RuneError))
+	if decoded, err := r.enc.Decode(buf); err == nil {
+		body = string(decoded)
+	}
+	if replaced := strings.Count(body, string(utf8.RuneError)) - bytes.Count(buf, []byte(string(utf8.RuneError))); replaced > 0 {
+		_ = stats.RecordWithTags(
+			context.Background(),
+			[]tag.Mutator{tag.Upsert(tagEncoding, r.encoding)},
+			statTextCharactersReplaced.M(int64(replaced)))
 	}
 
 	l := p.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
 	l.SetObservedTimestamp(pcommon.NewTimestampFromTime(time.Now()))
-	l.Body().SetStr(string(decoded))
+	l.Body().SetStr(body)
 	return p, nil
 }
 
@@ -48,7 +66,32 @@ func (r *textLogsUnmarshaler) WithEnc(encodingName string) (LogsUnmarshalerWithE
 	if err != nil {
 		return nil, err
 	}
+	encoding := r.Encoding()
+	if encodingName != "" {
+		encoding += "_" + encodingName
+	}
 	return &textLogsUnmarshaler{
-		enc: &enc,
+		enc:      &enc,
+		encoding: encoding,
 	}, nil
 }
+
+// putTextMessageAttributes adds the topic and the key, if any, of the message to the attributes of the
+// log records of logs, as the text messages have no attributes of their own. The keys that are not valid
+// UTF-8 are hex-encoded.
+func putTextMessageAttributes(logs plog.Logs, message *sarama.ConsumerMessage) {
+	keyExtraction := KeyExtraction{Attribute: conventions.AttributeMessagingKafkaMessageKey}
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		sls := logs.ResourceLogs().At(i).ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			records := sls.At(j).LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				attrs := records.At(k).Attributes()
+				attrs.PutStr(conventions.AttributeMessagingSourceName, message.Topic)
+				if message.Key != nil {
+					putMessageKey(attrs, message, keyExtraction)
+				}
+			}
+		}
+	}
+}
@@ -6,8 +6,13 @@ package kafkareceiver
 import (
 	"testing"
 
+	"github.com/IBM/sarama"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/collector/pdata/plog"
+	conventions "go.opentelemetry.io/collector/semconv/v1.18.0"
 )
 
 func TestNewTextUnmarshaler(t *testing.T) {
@@ -32,3 +37,50 @@ func TestTextUnmarshalerWithEnc(t *testing.T) {
 	require.NoError(t, err)
 	assert.EqualValues(t, um, um2)
 }
+
+func TestTextUnmarshaler_invalid_bytes(t *testing.T) {
+	views := MetricViews()
+	require.NoError(t, view.Register(views...))
+	defer view.Unregister(views...)
+
+	um, err := newTextLogsUnmarshaler().WithEnc("utf8")
+	require.NoError(t, err)
+	ld, err := um.Unmarshal([]byte("caf\xe9 \xff\xfe ok �"))
+	require.NoError(t, err)
+	require.Equal(t, 1, ld.LogRecordCount())
+	assert.Equal(t, "caf� �� ok �", ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+
+	// The ISO-8859-1 messages have no invalid byte.
+	um, err = newTextLogsUnmarshaler().WithEnc("iso-8859-1")
+	require.NoError(t, err)
+	ld, err = um.Unmarshal([]byte("caf\xe9"))
+	require.NoError(t, err)
+	assert.Equal(t, "café", ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+
+	rows, err := view.RetrieveData(statTextCharactersReplaced.Name())
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, []tag.Tag{{Key: tagEncoding, Value: "text_utf8"}}, rows[0].Tags)
+	assert.Equal(t, float64(3), rows[0].Data.(*view.SumData).Value)
+}
+
+func TestPutTextMessageAttributes(t *testing.T) {
+	ld := plog.NewLogs()
+	records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	records.AppendEmpty()
+	records.AppendEmpty()
+
+	putTextMessageAttributes(ld, &sarama.ConsumerMessage{Topic: "logs", Key: []byte{0xff, 0x01}})
+	for i := 0; i < records.Len(); i++ {
+		assert.Equal(t, map[string]any{
+			conventions.AttributeMessagingSourceName:      "logs",
+			conventions.AttributeMessagingKafkaMessageKey: "ff01",
+		}, records.At(i).Attributes().AsRaw())
+	}
+
+	ld = plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	putTextMessageAttributes(ld, &sarama.ConsumerMessage{Topic: "logs"})
+	assert.Equal(t, map[string]any{conventions.AttributeMessagingSourceName: "logs"},
+		ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw())
+}
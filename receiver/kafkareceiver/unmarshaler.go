@@ -49,37 +49,55 @@ type LogsUnmarshalerWithEnc interface {
 // defaultTracesUnmarshalers returns map of supported encodings with TracesUnmarshaler.
 func defaultTracesUnmarshalers() map[string]TracesUnmarshaler {
 	otlpPb := newPdataTracesUnmarshaler(&ptrace.ProtoUnmarshaler{}, defaultEncoding)
+	otlpJSON := newPdataTracesUnmarshaler(&ptrace.JSONUnmarshaler{}, "otlp_json")
+	otlpNDJSON := ndjsonTracesUnmarshaler{}
 	jaegerProto := jaegerProtoSpanUnmarshaler{}
 	jaegerJSON := jaegerJSONSpanUnmarshaler{}
 	zipkinProto := newPdataTracesUnmarshaler(zipkinv2.NewProtobufTracesUnmarshaler(false, false), "zipkin_proto")
 	zipkinJSON := newPdataTracesUnmarshaler(zipkinv2.NewJSONTracesUnmarshaler(false), "zipkin_json")
 	zipkinThrift := newPdataTracesUnmarshaler(zipkinv1.NewThriftTracesUnmarshaler(), "zipkin_thrift")
+	avro := newAvroTracesUnmarshaler("")
 	return map[string]TracesUnmarshaler{
 		otlpPb.Encoding():       otlpPb,
+		otlpJSON.Encoding():     otlpJSON,
+		otlpNDJSON.Encoding():   otlpNDJSON,
 		jaegerProto.Encoding():  jaegerProto,
 		jaegerJSON.Encoding():   jaegerJSON,
 		zipkinProto.Encoding():  zipkinProto,
 		zipkinJSON.Encoding():   zipkinJSON,
 		zipkinThrift.Encoding(): zipkinThrift,
+		avro.Encoding():         avro,
 	}
 }
 
 func defaultMetricsUnmarshalers() map[string]MetricsUnmarshaler {
 	otlpPb := newPdataMetricsUnmarshaler(&pmetric.ProtoUnmarshaler{}, defaultEncoding)
+	otlpJSON := newPdataMetricsUnmarshaler(&pmetric.JSONUnmarshaler{}, "otlp_json")
+	otlpNDJSON := ndjsonMetricsUnmarshaler{}
+	avro := newAvroMetricsUnmarshaler("")
 	return map[string]MetricsUnmarshaler{
-		otlpPb.Encoding(): otlpPb,
+		otlpPb.Encoding():     otlpPb,
+		otlpJSON.Encoding():   otlpJSON,
+		otlpNDJSON.Encoding(): otlpNDJSON,
+		avro.Encoding():       avro,
 	}
 }
 
 func defaultLogsUnmarshalers() map[string]LogsUnmarshaler {
 	otlpPb := newPdataLogsUnmarshaler(&plog.ProtoUnmarshaler{}, defaultEncoding)
+	otlpJSON := newPdataLogsUnmarshaler(&plog.JSONUnmarshaler{}, "otlp_json")
+	otlpNDJSON := ndjsonLogsUnmarshaler{}
 	raw := newRawLogsUnmarshaler()
 	text := newTextLogsUnmarshaler()
 	json := newJSONLogsUnmarshaler()
+	avro := newAvroLogsUnmarshaler("")
 	return map[string]LogsUnmarshaler{
-		otlpPb.Encoding(): otlpPb,
-		raw.Encoding():    raw,
-		text.Encoding():   text,
-		json.Encoding():   json,
+		otlpPb.Encoding():     otlpPb,
+		otlpJSON.Encoding():   otlpJSON,
+		otlpNDJSON.Encoding(): otlpNDJSON,
+		raw.Encoding():        raw,
+		text.Encoding():       text,
+		json.Encoding():       json,
+		avro.Encoding():       avro,
 	}
 }
@@ -13,11 +13,14 @@ import (
 func TestDefaultTracesUnMarshaler(t *testing.T) {
 	expectedEncodings := []string{
 		"otlp_proto",
+		"otlp_json",
+		"otlp_ndjson",
 		"jaeger_proto",
 		"jaeger_json",
 		"zipkin_proto",
 		"zipkin_json",
 		"zipkin_thrift",
+		"avro",
 	}
 	marshalers := defaultTracesUnmarshalers()
 	assert.Equal(t, len(expectedEncodings), len(marshalers))
@@ -33,6 +36,9 @@ func TestDefaultTracesUnMarshaler(t *testing.T) {
 func TestDefaultMetricsUnMarshaler(t *testing.T) {
 	expectedEncodings := []string{
 		"otlp_proto",
+		"otlp_json",
+		"otlp_ndjson",
+		"avro",
 	}
 	marshalers := defaultMetricsUnmarshalers()
 	assert.Equal(t, len(expectedEncodings), len(marshalers))
@@ -48,9 +54,12 @@ func TestDefaultMetricsUnMarshaler(t *testing.T) {
 func TestDefaultLogsUnMarshaler(t *testing.T) {
 	expectedEncodings := []string{
 		"otlp_proto",
+		"otlp_json",
+		"otlp_ndjson",
 		"raw",
 		"text",
 		"json",
+		"avro",
 	}
 	marshalers := defaultLogsUnmarshalers()
 	assert.Equal(t, len(expectedEncodings), len(marshalers))